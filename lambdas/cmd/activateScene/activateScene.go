@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	hDHandler "github.com/odhoman/home-devices/internal/handler"
+	hDService "github.com/odhoman/home-devices/internal/service"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+func HandleRequest(ctx context.Context, homeId string, id string, sceneService hDService.SceneService) (events.APIGatewayProxyResponse, error) {
+	return hDHandler.ActivateScene(ctx, homeId, id, sceneService)
+}
+
+func main() {
+
+	lambda.Start(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			log.Fatalf("unable to load SDK config for activateScene lambda function, %v", err)
+		}
+
+		return hDHandler.WithErrorNegotiation(hDHandler.ActivateSceneFromAPIGateway)(ctx, request, hDService.NewSceneServiceImplFromConfig(cfg))
+	})
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	hDConstants "github.com/odhoman/home-devices/internal/constants"
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockSceneService)
+
+	activation := &hDResponse.SceneActivationResponse{SceneID: "2f6c8d1a-4b3e-4f7a-8c9d-0e1f2a3b4c5d", HomeID: "home12122", ActivatedAt: 1735686000, Results: []hDResponse.SceneDeviceResultResponse{
+		{DeviceID: "light-1", Status: hDConstants.SceneDeviceStatusApplied, DesiredVersion: 4},
+		{DeviceID: "plug-1", Status: hDConstants.SceneDeviceStatusFailed, Error: "Device Not Found"},
+	}}
+
+	mockService.On("ActivateScene", mock.Anything, "home12122", "2f6c8d1a-4b3e-4f7a-8c9d-0e1f2a3b4c5d").Return(activation, nil)
+
+	response, err := HandleRequest(context.TODO(), "home12122", "2f6c8d1a-4b3e-4f7a-8c9d-0e1f2a3b4c5d", mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	expectedBody, _ := json.Marshal(activation)
+	assert.JSONEq(t, string(expectedBody), response.Body)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_EmptySceneId(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), "home12122", "", new(hDMock.MockSceneService))
+
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestHandleRequest_Errors(t *testing.T) {
+
+	tests := []struct {
+		err        error
+		statusCode int
+		message    string
+	}{
+		{hDError.ErrSceneNotFound.New(), 404, "Scene Not Found"},
+		{hDError.ErrActivatingScene.New(), 500, "Internal Server error activating the scene"},
+	}
+
+	for _, test := range tests {
+		t.Run(hDError.From(test.err).ErrorCode, func(t *testing.T) {
+			mockService := new(hDMock.MockSceneService)
+			mockService.On("ActivateScene", mock.Anything, "home12122", "2f6c8d1a-4b3e-4f7a-8c9d-0e1f2a3b4c5d").Return(nil, test.err)
+
+			response, _ := HandleRequest(context.TODO(), "home12122", "2f6c8d1a-4b3e-4f7a-8c9d-0e1f2a3b4c5d", mockService)
+
+			assert.Equal(t, test.statusCode, response.StatusCode)
+			assert.Contains(t, response.Body, test.message)
+		})
+	}
+}
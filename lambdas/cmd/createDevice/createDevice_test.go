@@ -6,7 +6,6 @@ import (
 	"testing"
 	"time"
 
-	hDConstants "github.com/odhoman/home-devices/internal/constants"
 	hDError "github.com/odhoman/home-devices/internal/error"
 	hDMock "github.com/odhoman/home-devices/internal/mock"
 	hDRequest "github.com/odhoman/home-devices/internal/request"
@@ -61,8 +60,30 @@ func TestHandleRequest_ValidationErrorRequiredFields(t *testing.T) {
 	assert.Equal(t, 400, response.StatusCode)
 
 	assert.Contains(t, response.Body, "Please enter a valid MAC address")
-	assert.Contains(t, response.Body, "Validation failed for field 'Type': required")
-	assert.Contains(t, response.Body, "Validation failed for field 'Name': required")
+	assert.Contains(t, response.Body, "type is required")
+	assert.Contains(t, response.Body, "name is required")
+}
+
+func TestHandleRequest_ValidationErrorUnknownType(t *testing.T) {
+
+	for _, deviceType := range []string{"Light", "lihgt"} {
+		t.Run(deviceType, func(t *testing.T) {
+			request := hDRequest.CreateDeviceRequest{
+				MAC:    "00:1B:44:11:3A:B7",
+				Name:   "Living Room Light",
+				Type:   deviceType,
+				HomeID: "home12122",
+			}
+
+			mockService := new(hDMock.MockHomeDeviceService)
+
+			response, _ := HandleRequest(context.TODO(), request, mockService)
+
+			assert.Equal(t, 400, response.StatusCode)
+			assert.Contains(t, response.Body, "Type must be one of light, lock, plug, sensor, thermostat")
+			mockService.AssertNotCalled(t, "CreateHomeDevice", mock.Anything, mock.Anything)
+		})
+	}
 }
 
 func TestHandleRequest_ValidationErrorFieldsLength(t *testing.T) {
@@ -80,7 +101,7 @@ func TestHandleRequest_ValidationErrorFieldsLength(t *testing.T) {
 
 	assert.Contains(t, response.Body, "MAC address must be between 12 and 17 characters")
 	assert.Contains(t, response.Body, "Name must be between 3 and 50 characters")
-	assert.Contains(t, response.Body, "Type must be between 3 and 20 characters")
+	assert.Contains(t, response.Body, "Type must be one of light, lock, plug, sensor, thermostat")
 	assert.Contains(t, response.Body, "Home ID must be between 5 and 30 characters")
 }
 
@@ -94,9 +115,7 @@ func TestHandleRequest_AlreadyExistError(t *testing.T) {
 	}
 
 	mockService := new(hDMock.MockHomeDeviceService)
-	mockService.On("CreateHomeDevice", mock.Anything, request).Return(nil, &hDError.HomeDeviceError{
-		ErrorCode: hDConstants.ErrDeviceAlreadyExistsCode,
-	})
+	mockService.On("CreateHomeDevice", mock.Anything, request).Return(nil, hDError.ErrDeviceAlreadyExists.New())
 
 	response, _ := HandleRequest(context.TODO(), request, mockService)
 
@@ -104,6 +123,43 @@ func TestHandleRequest_AlreadyExistError(t *testing.T) {
 	assert.Contains(t, response.Body, "Device Already Exist")
 }
 
+func TestHandleRequest_UnknownHomeError(t *testing.T) {
+
+	request := hDRequest.CreateDeviceRequest{
+		MAC:    "00:1B:44:11:3A:B7",
+		Name:   "Living Room Light",
+		Type:   "light",
+		HomeID: "home99999",
+	}
+
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockService.On("CreateHomeDevice", mock.Anything, request).Return(nil, hDError.ErrUnknownHome.New())
+
+	response, _ := HandleRequest(context.TODO(), request, mockService)
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Home Not Found for the homeId")
+}
+
+func TestHandleRequest_UnknownRoomError(t *testing.T) {
+
+	request := hDRequest.CreateDeviceRequest{
+		MAC:    "00:1B:44:11:3A:B7",
+		Name:   "Living Room Light",
+		Type:   "light",
+		HomeID: "home12122",
+		RoomID: "8d3c1b6e-2f4a-4c51-9a57-0d6f4f1f2b11",
+	}
+
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockService.On("CreateHomeDevice", mock.Anything, request).Return(nil, hDError.ErrUnknownRoom.New())
+
+	response, _ := HandleRequest(context.TODO(), request, mockService)
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Room Not Found for the roomId")
+}
+
 func TestHandleRequest_InternalServerError(t *testing.T) {
 
 	request := hDRequest.CreateDeviceRequest{
@@ -114,9 +170,7 @@ func TestHandleRequest_InternalServerError(t *testing.T) {
 	}
 
 	mockService := new(hDMock.MockHomeDeviceService)
-	mockService.On("CreateHomeDevice", mock.Anything, request).Return(nil, &hDError.HomeDeviceError{
-		ErrorCode: hDConstants.ErrDeviceNotCreatedErrorCode,
-	})
+	mockService.On("CreateHomeDevice", mock.Anything, request).Return(nil, hDError.ErrDeviceNotCreated.New())
 
 	response, _ := HandleRequest(context.TODO(), request, mockService)
 
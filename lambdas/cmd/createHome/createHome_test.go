@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newCreateHomeRequest() hDRequest.CreateHomeRequest {
+	return hDRequest.CreateHomeRequest{
+		Name:     "Beach House",
+		Timezone: "Europe/Madrid",
+		Address:  "Calle Mayor 1, Valencia",
+		Owner:    "user-1",
+	}
+}
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockHomeService)
+
+	homeRequest := newCreateHomeRequest()
+	home := &hDResponse.HomeResponse{
+		ID:       "home-1234567890",
+		Name:     homeRequest.Name,
+		Timezone: homeRequest.Timezone,
+		Address:  homeRequest.Address,
+		Owner:    homeRequest.Owner,
+		Version:  1,
+	}
+
+	mockService.On("CreateHome", mock.Anything, homeRequest).Return(home, nil)
+
+	response, err := HandleRequest(context.TODO(), homeRequest, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 201, response.StatusCode)
+
+	expectedBody, _ := json.Marshal(home)
+	assert.JSONEq(t, string(expectedBody), response.Body)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_ValidationError(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		request hDRequest.CreateHomeRequest
+		message string
+	}{
+		{"name required", hDRequest.CreateHomeRequest{Timezone: "UTC", Owner: "user-1"}, "name is required"},
+		{"invalid timezone", hDRequest.CreateHomeRequest{Name: "Beach House", Timezone: "Mars/Olympus", Owner: "user-1"}, "Please enter a valid IANA timezone, e.g. Europe/Madrid"},
+		{"short id", hDRequest.CreateHomeRequest{ID: "h1", Name: "Beach House", Timezone: "UTC", Owner: "user-1"}, "ID must be between 5 and 30 characters"},
+		{"owner required", hDRequest.CreateHomeRequest{Name: "Beach House", Timezone: "UTC"}, "owner is required"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockService := new(hDMock.MockHomeService)
+
+			response, _ := HandleRequest(context.TODO(), test.request, mockService)
+
+			assert.Equal(t, 400, response.StatusCode)
+			assert.Contains(t, response.Body, test.message)
+			mockService.AssertNotCalled(t, "CreateHome", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestHandleRequest_HomeAlreadyExists(t *testing.T) {
+	mockService := new(hDMock.MockHomeService)
+
+	homeRequest := newCreateHomeRequest()
+	homeRequest.ID = "home12122"
+
+	mockService.On("CreateHome", mock.Anything, homeRequest).Return(nil, hDError.ErrHomeAlreadyExists.New())
+
+	response, _ := HandleRequest(context.TODO(), homeRequest, mockService)
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Home Already Exist")
+}
+
+func TestHandleRequest_InternalServerError(t *testing.T) {
+	mockService := new(hDMock.MockHomeService)
+
+	mockService.On("CreateHome", mock.Anything, mock.Anything).Return(nil, hDError.ErrHomeNotCreated.New())
+
+	response, _ := HandleRequest(context.TODO(), newCreateHomeRequest(), mockService)
+
+	assert.Equal(t, 500, response.StatusCode)
+	assert.Contains(t, response.Body, "Internal Server error creating a new home")
+}
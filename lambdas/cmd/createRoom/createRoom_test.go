@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockRoomService)
+
+	roomRequest := hDRequest.CreateRoomRequest{Name: "Kitchen"}
+	room := &hDResponse.RoomResponse{ID: "8d3c1b6e-2f4a-4c51-9a57-0d6f4f1f2b11", HomeID: "home12122", Name: "Kitchen", Version: 1}
+
+	mockService.On("CreateRoom", mock.Anything, "home12122", roomRequest).Return(room, nil)
+
+	response, err := HandleRequest(context.TODO(), "home12122", roomRequest, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 201, response.StatusCode)
+
+	expectedBody, _ := json.Marshal(room)
+	assert.JSONEq(t, string(expectedBody), response.Body)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_ValidationError(t *testing.T) {
+	mockService := new(hDMock.MockRoomService)
+
+	response, _ := HandleRequest(context.TODO(), "home12122", hDRequest.CreateRoomRequest{Name: "K"}, mockService)
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Name must be between 3 and 50 characters")
+	mockService.AssertNotCalled(t, "CreateRoom", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleRequest_EmptyHomeId(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), "", hDRequest.CreateRoomRequest{Name: "Kitchen"}, new(hDMock.MockRoomService))
+
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestHandleRequest_Errors(t *testing.T) {
+
+	tests := []struct {
+		err        error
+		statusCode int
+		message    string
+	}{
+		{hDError.ErrHomeNotFound.New(), 404, "Home Not Found"},
+		{hDError.ErrRoomNotCreated.New(), 500, "Internal Server error creating a new room"},
+	}
+
+	for _, test := range tests {
+		t.Run(hDError.From(test.err).ErrorCode, func(t *testing.T) {
+			mockService := new(hDMock.MockRoomService)
+			mockService.On("CreateRoom", mock.Anything, "home12122", mock.Anything).Return(nil, test.err)
+
+			response, _ := HandleRequest(context.TODO(), "home12122", hDRequest.CreateRoomRequest{Name: "Kitchen"}, mockService)
+
+			assert.Equal(t, test.statusCode, response.StatusCode)
+			assert.Contains(t, response.Body, test.message)
+		})
+	}
+}
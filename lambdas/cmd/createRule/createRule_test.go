@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockRuleService)
+
+	ruleRequest := newRuleRequest()
+	rule := &hDResponse.RuleResponse{ID: "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", HomeID: "home12122", Name: "Hallway light at night", Enabled: true, CooldownSeconds: 300, Version: 1}
+
+	mockService.On("CreateRule", mock.Anything, "home12122", ruleRequest).Return(rule, nil)
+
+	response, err := HandleRequest(context.TODO(), "home12122", ruleRequest, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 201, response.StatusCode)
+
+	expectedBody, _ := json.Marshal(rule)
+	assert.JSONEq(t, string(expectedBody), response.Body)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_ValidationError(t *testing.T) {
+	mockService := new(hDMock.MockRuleService)
+
+	ruleRequest := newRuleRequest()
+	ruleRequest.Trigger.Operator = "between"
+	ruleRequest.TimeWindow = &hDRequest.RuleTimeWindow{From: "10pm", To: "06:00"}
+	ruleRequest.CooldownSeconds = 100000
+
+	response, _ := HandleRequest(context.TODO(), "home12122", ruleRequest, mockService)
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Operator must be one of gt, gte, lt, lte, eq, neq")
+	assert.Contains(t, response.Body, "From must be a time of the day, e.g. 22:00")
+	assert.Contains(t, response.Body, "Cooldown must be between 1 and 86400 seconds")
+	mockService.AssertNotCalled(t, "CreateRule", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleRequest_EmptyHomeId(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), "", newRuleRequest(), new(hDMock.MockRuleService))
+
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestHandleRequest_Errors(t *testing.T) {
+
+	tests := []struct {
+		errorCode    string
+		errorMessage string
+		statusCode   int
+		message      string
+	}{
+		{hDError.ErrInvalidRule.Code, "lock is not a command of the light devices", 400, "lock is not a command of the light devices"},
+		{hDError.ErrHomeNotFound.Code, "", 404, "Home Not Found"},
+		{hDError.ErrRuleNotCreated.Code, "", 500, "Internal Server error creating a new rule"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.errorCode, func(t *testing.T) {
+			mockService := new(hDMock.MockRuleService)
+			mockService.On("CreateRule", mock.Anything, "home12122", mock.Anything).Return(nil, &hDError.HomeDeviceError{ErrorCode: test.errorCode, ErrorMessage: test.errorMessage})
+
+			response, _ := HandleRequest(context.TODO(), "home12122", newRuleRequest(), mockService)
+
+			assert.Equal(t, test.statusCode, response.StatusCode)
+			assert.Contains(t, response.Body, test.message)
+		})
+	}
+}
+
+func newRuleRequest() hDRequest.RuleRequest {
+	value := 1.0
+	return hDRequest.RuleRequest{
+		Name:       "Hallway light at night",
+		Trigger:    hDRequest.RuleTrigger{DeviceID: "sensor-1", Metric: "motion", Operator: "eq", Value: &value},
+		TimeWindow: &hDRequest.RuleTimeWindow{From: "22:00", To: "06:00"},
+		Action:     hDRequest.RuleAction{DeviceID: "light-1", Command: "turnOn"},
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockSceneService)
+
+	sceneRequest := newSceneRequest()
+	scene := &hDResponse.SceneResponse{ID: "2f6c8d1a-4b3e-4f7a-8c9d-0e1f2a3b4c5d", HomeID: "home12122", Name: "Movie night", Devices: []hDResponse.SceneDeviceResponse{
+		{DeviceID: "light-1", State: map[string]interface{}{"on": true, "brightness": float64(20)}},
+	}, Version: 1}
+
+	mockService.On("CreateScene", mock.Anything, "home12122", sceneRequest).Return(scene, nil)
+
+	response, err := HandleRequest(context.TODO(), "home12122", sceneRequest, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 201, response.StatusCode)
+
+	expectedBody, _ := json.Marshal(scene)
+	assert.JSONEq(t, string(expectedBody), response.Body)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_ValidationError(t *testing.T) {
+	mockService := new(hDMock.MockSceneService)
+
+	sceneRequest := newSceneRequest()
+	sceneRequest.Devices = append(sceneRequest.Devices, hDRequest.SceneDevice{DeviceID: "plug-1"})
+
+	response, _ := HandleRequest(context.TODO(), "home12122", sceneRequest, mockService)
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Each device of the scene must have a state")
+
+	sceneRequest.Devices = nil
+
+	response, _ = HandleRequest(context.TODO(), "home12122", sceneRequest, mockService)
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "A scene must have between 1 and 50 devices")
+	mockService.AssertNotCalled(t, "CreateScene", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleRequest_EmptyHomeId(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), "", newSceneRequest(), new(hDMock.MockSceneService))
+
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestHandleRequest_Errors(t *testing.T) {
+
+	tests := []struct {
+		errorCode    string
+		errorMessage string
+		statusCode   int
+		message      string
+	}{
+		{hDError.ErrInvalidScene.Code, "the device light-1 is not a device of the home", 400, "the device light-1 is not a device of the home"},
+		{hDError.ErrHomeNotFound.Code, "", 404, "Home Not Found"},
+		{hDError.ErrSceneNotCreated.Code, "", 500, "Internal Server error creating a new scene"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.errorCode, func(t *testing.T) {
+			mockService := new(hDMock.MockSceneService)
+			mockService.On("CreateScene", mock.Anything, "home12122", mock.Anything).Return(nil, &hDError.HomeDeviceError{ErrorCode: test.errorCode, ErrorMessage: test.errorMessage})
+
+			response, _ := HandleRequest(context.TODO(), "home12122", newSceneRequest(), mockService)
+
+			assert.Equal(t, test.statusCode, response.StatusCode)
+			assert.Contains(t, response.Body, test.message)
+		})
+	}
+}
+
+func newSceneRequest() hDRequest.SceneRequest {
+	return hDRequest.SceneRequest{
+		Name: "Movie night",
+		Devices: []hDRequest.SceneDevice{
+			{DeviceID: "light-1", State: map[string]interface{}{"on": true, "brightness": float64(20)}},
+		},
+	}
+}
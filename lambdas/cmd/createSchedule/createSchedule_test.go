@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockScheduleService)
+
+	scheduleRequest := newScheduleRequest()
+	schedule := &hDResponse.ScheduleResponse{ID: "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", HomeID: "home12122", Name: "Lights off at midnight", Enabled: true, Cron: "0 0 * * *", Timezone: "Europe/Madrid", NextRunAt: 1735686000, Version: 1}
+
+	mockService.On("CreateSchedule", mock.Anything, "home12122", scheduleRequest).Return(schedule, nil)
+
+	response, err := HandleRequest(context.TODO(), "home12122", scheduleRequest, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 201, response.StatusCode)
+
+	expectedBody, _ := json.Marshal(schedule)
+	assert.JSONEq(t, string(expectedBody), response.Body)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_ValidationError(t *testing.T) {
+	mockService := new(hDMock.MockScheduleService)
+
+	scheduleRequest := newScheduleRequest()
+	scheduleRequest.RRule = "FREQ=DAILY"
+	scheduleRequest.Timezone = "Madrid"
+	scheduleRequest.Target.DeviceType = "light"
+
+	response, _ := HandleRequest(context.TODO(), "home12122", scheduleRequest, mockService)
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Only one of cron and rrule can be set")
+	assert.Contains(t, response.Body, "Please enter a valid IANA timezone, e.g. Europe/Madrid")
+	assert.Contains(t, response.Body, "Target must have either a deviceId or a deviceType, not both")
+	mockService.AssertNotCalled(t, "CreateSchedule", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleRequest_EmptyHomeId(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), "", newScheduleRequest(), new(hDMock.MockScheduleService))
+
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestHandleRequest_Errors(t *testing.T) {
+
+	tests := []struct {
+		errorCode    string
+		errorMessage string
+		statusCode   int
+		message      string
+	}{
+		{hDError.ErrInvalidSchedule.Code, "lock is not a command of the light devices", 400, "lock is not a command of the light devices"},
+		{hDError.ErrHomeNotFound.Code, "", 404, "Home Not Found"},
+		{hDError.ErrScheduleNotCreated.Code, "", 500, "Internal Server error creating a new schedule"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.errorCode, func(t *testing.T) {
+			mockService := new(hDMock.MockScheduleService)
+			mockService.On("CreateSchedule", mock.Anything, "home12122", mock.Anything).Return(nil, &hDError.HomeDeviceError{ErrorCode: test.errorCode, ErrorMessage: test.errorMessage})
+
+			response, _ := HandleRequest(context.TODO(), "home12122", newScheduleRequest(), mockService)
+
+			assert.Equal(t, test.statusCode, response.StatusCode)
+			assert.Contains(t, response.Body, test.message)
+		})
+	}
+}
+
+func TestHandleRequest_TargetValidationError(t *testing.T) {
+	mockService := new(hDMock.MockScheduleService)
+
+	scheduleRequest := newScheduleRequest()
+	scheduleRequest.Cron = ""
+	scheduleRequest.Target = hDRequest.ScheduleTarget{RoomID: "living-room"}
+
+	response, _ := HandleRequest(context.TODO(), "home12122", scheduleRequest, mockService)
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Either a cron expression or an RRULE is required")
+	assert.Contains(t, response.Body, "Target must have a deviceId or a deviceType")
+	assert.Contains(t, response.Body, "Room ID must be the id of a room")
+	mockService.AssertNotCalled(t, "CreateSchedule", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func newScheduleRequest() hDRequest.ScheduleRequest {
+	return hDRequest.ScheduleRequest{
+		Name:    "Lights off at midnight",
+		Cron:    "0 0 * * *",
+		Target:  hDRequest.ScheduleTarget{DeviceID: "light-1"},
+		Command: "turnOff",
+	}
+}
@@ -4,37 +4,16 @@ import (
 	"context"
 	"log"
 
-	hDConstants "github.com/odhoman/home-devices/internal/constants"
-	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDHandler "github.com/odhoman/home-devices/internal/handler"
 	hDService "github.com/odhoman/home-devices/internal/service"
-	hDValidation "github.com/odhoman/home-devices/internal/validation"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/config"
 )
 
-func HandleRequest(ctx context.Context, id string, deviceService hDService.HomeDeviceService) (events.APIGatewayProxyResponse, error) {
-
-	if err := hDValidation.CheckEmptyString("id", id); err != nil {
-		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
-	}
-
-	if err := deviceService.DeleteHomeDevice(ctx, id); err != nil {
-		return getErrorResponse(err.ErrorCode), nil
-	}
-
-	return hDResponse.ReturnOKWithMessageAPIGatewayProxyResponse(200, "Device deleted"), nil
-}
-
-func getErrorResponse(errorCode string) events.APIGatewayProxyResponse {
-	switch errorCode {
-	case hDConstants.ErrDeviceNotFoundCode:
-		return hDResponse.ReturnNotFoundErrorAPIGatewayProxyResponseSingleMessage("Device Not Found")
-	default:
-		return hDResponse.InternalServerErrorAPIGatewayProxyResponseSingleMessage("Internal Server error deleting a device")
-	}
-
+func HandleRequest(ctx context.Context, id string, ifMatch string, hard bool, deviceService hDService.HomeDeviceService) (events.APIGatewayProxyResponse, error) {
+	return hDHandler.DeleteDevice(ctx, id, ifMatch, hard, deviceService)
 }
 
 func main() {
@@ -45,6 +24,6 @@ func main() {
 			log.Fatalf("unable to load SDK config for deleteDevice lambda function, %v", err)
 		}
 
-		return HandleRequest(ctx, request.PathParameters["id"], hDService.NewHomeDeviceServiceImplFromConfig2(cfg))
+		return hDHandler.WithErrorNegotiation(hDHandler.DeleteDeviceFromAPIGateway)(ctx, request, hDService.NewHomeDeviceServiceImplFromConfig2(cfg))
 	})
 }
@@ -32,7 +32,7 @@ func TestDeleteHomeDevice_Success(t *testing.T) {
 
 	deviceCreated := CreateHomeDeviceForTesting(t, ctx, homeDeviceServiceImpl, request)
 
-	response, err := HandleRequest(context.Background(), deviceCreated.ID, homeDeviceServiceImpl)
+	response, err := HandleRequest(context.Background(), deviceCreated.ID, "", false, homeDeviceServiceImpl)
 
 	if err != nil {
 		t.Fatalf("Unexpected error running TestDeleteHomeDevice_Success. Error: %v", err.Error())
@@ -48,7 +48,7 @@ func TestDeleteHomeDevice_DeviceNotFound(t *testing.T) {
 	svc := mock.GetDynamoConnectionTestFromEnpoint()
 	homeDeviceServiceImpl := hDService.NewHomeDeviceServiceImpl2(dao.HomeDeviceDaoImpl{DynamoDbApi: svc})
 
-	response, err := HandleRequest(context.Background(), "fakeId", homeDeviceServiceImpl)
+	response, err := HandleRequest(context.Background(), "fakeId", "", false, homeDeviceServiceImpl)
 
 	if err != nil {
 		t.Fatalf("Unexpected error running TestDeleteHomeDevice. Error: %v", err.Error())
@@ -63,7 +63,7 @@ func CreateHomeDeviceForTesting(t *testing.T, ctx context.Context, service hDSer
 	deviceCreated, err := service.CreateHomeDevice(ctx, device)
 
 	if err != nil {
-		t.Fatalf("Unexpected error creating new Device for testing. Error: %v", err.ErrorCode)
+		t.Fatalf("Unexpected error creating new Device for testing. Error: %v", err)
 		return nil
 	}
 
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"testing"
 
-	hDConstants "github.com/odhoman/home-devices/internal/constants"
 	hDError "github.com/odhoman/home-devices/internal/error"
 	hDMock "github.com/odhoman/home-devices/internal/mock"
 	hDResponse "github.com/odhoman/home-devices/internal/response"
@@ -22,9 +21,9 @@ func TestHandleRequest_Success(t *testing.T) {
 
 	id := uuid.New().String()
 
-	mockService.On("DeleteHomeDevice", mock.Anything, id).Return(nil)
+	mockService.On("DeleteHomeDevice", mock.Anything, id, int64(0)).Return(&hDResponse.HomdeDeviceResponse{ID: id, Version: 2}, nil)
 
-	response, err := HandleRequest(context.TODO(), id, mockService)
+	response, err := HandleRequest(context.TODO(), id, "", false, mockService)
 
 	assert.NoError(t, err)
 	assert.Equal(t, 200, response.StatusCode)
@@ -37,7 +36,7 @@ func TestHandleRequest_Success(t *testing.T) {
 
 func TestHandleRequest_ValidationError(t *testing.T) {
 
-	response, _ := HandleRequest(context.TODO(), "", new(hDMock.MockHomeDeviceService))
+	response, _ := HandleRequest(context.TODO(), "", "", false, new(hDMock.MockHomeDeviceService))
 
 	assert.Equal(t, 400, response.StatusCode)
 
@@ -50,11 +49,9 @@ func TestHandleRequest_DeviceNotFound(t *testing.T) {
 	id := uuid.New().String()
 
 	mockService := new(hDMock.MockHomeDeviceService)
-	mockService.On("DeleteHomeDevice", mock.Anything, id).Return(&hDError.HomeDeviceError{
-		ErrorCode: hDConstants.ErrDeviceNotFoundCode,
-	})
+	mockService.On("DeleteHomeDevice", mock.Anything, id, int64(0)).Return(nil, hDError.ErrDeviceNotFound.New())
 
-	response, _ := HandleRequest(context.TODO(), id, mockService)
+	response, _ := HandleRequest(context.TODO(), id, "", false, mockService)
 
 	assert.Equal(t, 404, response.StatusCode)
 	assert.Contains(t, response.Body, "Device Not Found")
@@ -65,12 +62,72 @@ func TestHandleRequest_InternalServerError(t *testing.T) {
 	id := uuid.New().String()
 
 	mockService := new(hDMock.MockHomeDeviceService)
-	mockService.On("DeleteHomeDevice", mock.Anything, id).Return(&hDError.HomeDeviceError{
-		ErrorCode: hDConstants.ErrDeletingDeviceCode,
-	})
+	mockService.On("DeleteHomeDevice", mock.Anything, id, int64(0)).Return(nil, hDError.ErrDeletingDevice.New())
 
-	response, _ := HandleRequest(context.TODO(), id, mockService)
+	response, _ := HandleRequest(context.TODO(), id, "", false, mockService)
 
 	assert.Equal(t, 500, response.StatusCode)
 	assert.Contains(t, response.Body, "Internal Server error deleting a device")
 }
+
+func TestHandleRequest_IfMatch(t *testing.T) {
+	mockService := new(hDMock.MockHomeDeviceService)
+
+	id := uuid.New().String()
+
+	mockService.On("DeleteHomeDevice", mock.Anything, id, int64(3)).Return(&hDResponse.HomdeDeviceResponse{ID: id, Version: 4}, nil)
+
+	response, err := HandleRequest(context.TODO(), id, "\"3\"", false, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "\"4\"", response.Headers["ETag"])
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_InvalidIfMatch(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), uuid.New().String(), "\"abc\"", false, new(hDMock.MockHomeDeviceService))
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Invalid If-Match header")
+}
+
+func TestHandleRequest_WeakIfMatch(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), uuid.New().String(), "W/\"3\"", false, new(hDMock.MockHomeDeviceService))
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Invalid If-Match header")
+}
+
+func TestHandleRequest_VersionConflict(t *testing.T) {
+
+	id := uuid.New().String()
+
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockService.On("DeleteHomeDevice", mock.Anything, id, int64(2)).Return(nil, hDError.ErrVersionConflict.New())
+
+	response, _ := HandleRequest(context.TODO(), id, "\"2\"", false, mockService)
+
+	assert.Equal(t, 412, response.StatusCode)
+	assert.Contains(t, response.Body, "The device was modified by another request")
+}
+
+func TestHandleRequest_HardDelete(t *testing.T) {
+
+	id := uuid.New().String()
+
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockService.On("HardDeleteHomeDevice", mock.Anything, id, int64(0)).Return(nil)
+
+	response, err := HandleRequest(context.TODO(), id, "", true, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Empty(t, response.Headers["ETag"])
+
+	mockService.AssertExpectations(t)
+	mockService.AssertNotCalled(t, "DeleteHomeDevice", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	hDHandler "github.com/odhoman/home-devices/internal/handler"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDService "github.com/odhoman/home-devices/internal/service"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+func HandleRequest(ctx context.Context, id string, deleteRequest hDRequest.DeleteHomeRequest, ifMatch string, homeService hDService.HomeService) (events.APIGatewayProxyResponse, error) {
+	return hDHandler.DeleteHome(ctx, id, deleteRequest, ifMatch, homeService)
+}
+
+func main() {
+
+	lambda.Start(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			log.Fatalf("unable to load SDK config for deleteHome lambda function, %v", err)
+		}
+
+		return hDHandler.WithErrorNegotiation(hDHandler.DeleteHomeFromAPIGateway)(ctx, request, hDService.NewHomeServiceImplFromConfig(cfg))
+	})
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	hDConstants "github.com/odhoman/home-devices/internal/constants"
+	"github.com/odhoman/home-devices/internal/dao"
+	hDError "github.com/odhoman/home-devices/internal/error"
+	"github.com/odhoman/home-devices/internal/mock"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	mock.RunTestMain(m)
+}
+
+func TestDeleteHome_NeedsAStrategyAndCascades(t *testing.T) {
+
+	homeServiceImpl, homeDeviceServiceImpl := newServicesForTesting()
+	ctx := context.Background()
+
+	home := createHomeForTesting(t, homeServiceImpl)
+	device := createDeviceForTesting(t, homeDeviceServiceImpl, home.ID, "40:1A:2B:3C:4D:01")
+
+	response, _ := HandleRequest(ctx, home.ID, hDRequest.DeleteHomeRequest{}, "", homeServiceImpl)
+	assert.Equal(t, 409, response.StatusCode)
+
+	response, _ = HandleRequest(ctx, home.ID, hDRequest.DeleteHomeRequest{Strategy: hDConstants.DeleteHomeStrategyCascade}, `"1"`, homeServiceImpl)
+	assert.Equal(t, 200, response.StatusCode)
+
+	_, err := homeDeviceServiceImpl.GetHomeDevice(ctx, device.ID)
+	assert.ErrorIs(t, err, hDError.ErrDeviceNotFound)
+
+	_, err = homeServiceImpl.GetHome(ctx, home.ID)
+	assert.ErrorIs(t, err, hDError.ErrHomeNotFound)
+}
+
+func TestDeleteHome_Reassign(t *testing.T) {
+
+	homeServiceImpl, homeDeviceServiceImpl := newServicesForTesting()
+	ctx := context.Background()
+
+	home := createHomeForTesting(t, homeServiceImpl)
+	target := createHomeForTesting(t, homeServiceImpl)
+	device := createDeviceForTesting(t, homeDeviceServiceImpl, home.ID, "40:1A:2B:3C:4D:02")
+
+	response, _ := HandleRequest(ctx, home.ID, hDRequest.DeleteHomeRequest{Strategy: hDConstants.DeleteHomeStrategyReassign, TargetHomeID: target.ID}, "", homeServiceImpl)
+	assert.Equal(t, 200, response.StatusCode)
+
+	moved, err := homeDeviceServiceImpl.GetHomeDevice(ctx, device.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error getting the reassigned Device. Error: %v", err)
+	}
+	assert.Equal(t, target.ID, moved.HomeID)
+}
+
+func newServicesForTesting() (hDService.HomeService, hDService.HomeDeviceService) {
+	svc := mock.GetDynamoConnectionTestFromEnpoint()
+	homeDao := dao.HomeDaoImpl{DynamoDbApi: svc}
+	deviceService := hDService.NewHomeDeviceServiceImpl2(dao.HomeDeviceDaoImpl{DynamoDbApi: svc}, hDService.WithHomeDao(homeDao))
+	return hDService.NewHomeServiceImpl(homeDao, deviceService, nil, nil, nil, nil), deviceService
+}
+
+func createHomeForTesting(t *testing.T, service hDService.HomeService) *hDResponse.HomeResponse {
+
+	home, err := service.CreateHome(context.Background(), hDRequest.CreateHomeRequest{
+		Name:     "Beach House",
+		Timezone: "Europe/Madrid",
+		Owner:    "user-1",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error creating new Home for testing. Error: %v", err)
+	}
+
+	return home
+}
+
+func createDeviceForTesting(t *testing.T, service hDService.HomeDeviceService, homeId string, mac string) *hDResponse.HomdeDeviceResponse {
+
+	device, err := service.CreateHomeDevice(context.Background(), hDRequest.CreateDeviceRequest{
+		MAC:    mac,
+		Name:   "Living Room Light",
+		Type:   "light",
+		HomeID: homeId,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error creating new Device for testing. Error: %v", err)
+	}
+
+	return device
+}
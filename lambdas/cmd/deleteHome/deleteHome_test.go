@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	hDConstants "github.com/odhoman/home-devices/internal/constants"
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockHomeService)
+
+	deleteRequest := hDRequest.DeleteHomeRequest{Strategy: hDConstants.DeleteHomeStrategyReassign, TargetHomeID: "home33333"}
+	mockService.On("DeleteHome", mock.Anything, "home12122", deleteRequest, int64(4)).Return(nil)
+
+	response, err := HandleRequest(context.TODO(), "home12122", deleteRequest, `"4"`, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.JSONEq(t, `{"message": "Home deleted"}`, response.Body)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_ValidationError(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		request hDRequest.DeleteHomeRequest
+		message string
+	}{
+		{"unknown strategy", hDRequest.DeleteHomeRequest{Strategy: "orphan"}, "Strategy must be cascade or reassign"},
+		{"reassign without target", hDRequest.DeleteHomeRequest{Strategy: hDConstants.DeleteHomeStrategyReassign}, "Target home ID is required to reassign the devices"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockService := new(hDMock.MockHomeService)
+
+			response, _ := HandleRequest(context.TODO(), "home12122", test.request, "", mockService)
+
+			assert.Equal(t, 400, response.StatusCode)
+			assert.Contains(t, response.Body, test.message)
+			mockService.AssertNotCalled(t, "DeleteHome", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestHandleRequest_Errors(t *testing.T) {
+
+	tests := []struct {
+		err        error
+		statusCode int
+		message    string
+	}{
+		{hDError.ErrHomeNotFound.New(), 404, "Home Not Found"},
+		{hDError.ErrVersionConflict.WithMessage(hDError.HomeVersionConflictMessage), 412, "The home was modified by another request"},
+		{hDError.ErrHomeHasDevices.New(), 409, "The home has devices, please choose the cascade or reassign strategy"},
+		{hDError.ErrDeviceInTargetHome.New(), 409, "A device of the home already exists in the target home"},
+		{hDError.ErrUnknownTargetHome.New(), 400, "Target Home Not Found"},
+		{hDError.ErrInvalidTargetHome.New(), 400, "The target home must be another home"},
+		{hDError.ErrDeletingHome.New(), 500, "Internal Server error deleting a home"},
+	}
+
+	for _, test := range tests {
+		t.Run(hDError.From(test.err).ErrorCode, func(t *testing.T) {
+			mockService := new(hDMock.MockHomeService)
+			mockService.On("DeleteHome", mock.Anything, "home12122", hDRequest.DeleteHomeRequest{}, int64(0)).Return(test.err)
+
+			response, _ := HandleRequest(context.TODO(), "home12122", hDRequest.DeleteHomeRequest{}, "", mockService)
+
+			assert.Equal(t, test.statusCode, response.StatusCode)
+			assert.Contains(t, response.Body, test.message)
+		})
+	}
+}
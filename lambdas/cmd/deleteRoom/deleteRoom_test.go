@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockRoomService)
+
+	mockService.On("DeleteRoom", mock.Anything, "home12122", "8d3c1b6e-2f4a-4c51-9a57-0d6f4f1f2b11", int64(3)).Return(nil)
+
+	response, err := HandleRequest(context.TODO(), "home12122", "8d3c1b6e-2f4a-4c51-9a57-0d6f4f1f2b11", `"3"`, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.JSONEq(t, `{"message": "Room deleted"}`, response.Body)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_EmptyRoomId(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), "home12122", "", "", new(hDMock.MockRoomService))
+
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestHandleRequest_Errors(t *testing.T) {
+
+	tests := []struct {
+		err        error
+		statusCode int
+		message    string
+	}{
+		{hDError.ErrRoomNotFound.New(), 404, "Room Not Found"},
+		{hDError.ErrVersionConflict.WithMessage(hDError.RoomVersionConflictMessage), 412, "The room was modified by another request"},
+		{hDError.ErrRoomHasDevices.New(), 409, "The room has devices, please move them to another room first"},
+		{hDError.ErrDeletingRoom.New(), 500, "Internal Server error deleting a room"},
+	}
+
+	for _, test := range tests {
+		t.Run(hDError.From(test.err).ErrorCode, func(t *testing.T) {
+			mockService := new(hDMock.MockRoomService)
+			mockService.On("DeleteRoom", mock.Anything, "home12122", "8d3c1b6e-2f4a-4c51-9a57-0d6f4f1f2b11", int64(0)).Return(test.err)
+
+			response, _ := HandleRequest(context.TODO(), "home12122", "8d3c1b6e-2f4a-4c51-9a57-0d6f4f1f2b11", "", mockService)
+
+			assert.Equal(t, test.statusCode, response.StatusCode)
+			assert.Contains(t, response.Body, test.message)
+		})
+	}
+}
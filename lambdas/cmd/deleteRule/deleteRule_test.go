@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockRuleService)
+
+	mockService.On("DeleteRule", mock.Anything, "home12122", "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", int64(3)).Return(nil)
+
+	response, err := HandleRequest(context.TODO(), "home12122", "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", `"3"`, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.JSONEq(t, `{"message": "Rule deleted"}`, response.Body)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_EmptyRuleId(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), "home12122", "", "", new(hDMock.MockRuleService))
+
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestHandleRequest_Errors(t *testing.T) {
+
+	tests := []struct {
+		err        error
+		statusCode int
+		message    string
+	}{
+		{hDError.ErrRuleNotFound.New(), 404, "Rule Not Found"},
+		{hDError.ErrVersionConflict.WithMessage(hDError.RuleVersionConflictMessage), 412, "The rule was modified by another request"},
+		{hDError.ErrDeletingRule.New(), 500, "Internal Server error deleting a rule"},
+	}
+
+	for _, test := range tests {
+		t.Run(hDError.From(test.err).ErrorCode, func(t *testing.T) {
+			mockService := new(hDMock.MockRuleService)
+			mockService.On("DeleteRule", mock.Anything, "home12122", "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", int64(0)).Return(test.err)
+
+			response, _ := HandleRequest(context.TODO(), "home12122", "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", "", mockService)
+
+			assert.Equal(t, test.statusCode, response.StatusCode)
+			assert.Contains(t, response.Body, test.message)
+		})
+	}
+}
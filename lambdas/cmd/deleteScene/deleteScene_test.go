@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockSceneService)
+
+	mockService.On("DeleteScene", mock.Anything, "home12122", "2f6c8d1a-4b3e-4f7a-8c9d-0e1f2a3b4c5d", int64(3)).Return(nil)
+
+	response, err := HandleRequest(context.TODO(), "home12122", "2f6c8d1a-4b3e-4f7a-8c9d-0e1f2a3b4c5d", `"3"`, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.JSONEq(t, `{"message": "Scene deleted"}`, response.Body)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_EmptySceneId(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), "home12122", "", "", new(hDMock.MockSceneService))
+
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestHandleRequest_Errors(t *testing.T) {
+
+	tests := []struct {
+		err        error
+		statusCode int
+		message    string
+	}{
+		{hDError.ErrSceneNotFound.New(), 404, "Scene Not Found"},
+		{hDError.ErrVersionConflict.WithMessage(hDError.SceneVersionConflictMessage), 412, "The scene was modified by another request"},
+		{hDError.ErrDeletingScene.New(), 500, "Internal Server error deleting a scene"},
+	}
+
+	for _, test := range tests {
+		t.Run(hDError.From(test.err).ErrorCode, func(t *testing.T) {
+			mockService := new(hDMock.MockSceneService)
+			mockService.On("DeleteScene", mock.Anything, "home12122", "2f6c8d1a-4b3e-4f7a-8c9d-0e1f2a3b4c5d", int64(0)).Return(test.err)
+
+			response, _ := HandleRequest(context.TODO(), "home12122", "2f6c8d1a-4b3e-4f7a-8c9d-0e1f2a3b4c5d", "", mockService)
+
+			assert.Equal(t, test.statusCode, response.StatusCode)
+			assert.Contains(t, response.Body, test.message)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDQueue "github.com/odhoman/home-devices/internal/queue"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// Reasons sent to the dead-letter queue with the acks that will fail whatever
+// the number of retries.
+const (
+	ReasonInvalidMessage  = "INVALID_MESSAGE"
+	ReasonValidationError = "VALIDATION_ERROR"
+)
+
+// messageError is the reason why an ack could not be processed.
+type messageError struct {
+	Reason    string
+	Message   string
+	Permanent bool
+}
+
+// HandleRequest returns the acks that failed and can be retried, so SQS
+// deletes the rest of the batch.
+func HandleRequest(ctx context.Context, sqsEvent events.SQSEvent, deviceCommandService hDService.DeviceCommandService, deadLetterQueue hDQueue.DeadLetterQueue) events.SQSEventResponse {
+
+	response := events.SQSEventResponse{
+		BatchItemFailures: []events.SQSBatchItemFailure{},
+	}
+
+	for _, message := range sqsEvent.Records {
+
+		messageErr := processMessage(ctx, message, deviceCommandService)
+		if messageErr == nil {
+			continue
+		}
+
+		log.Printf("Error processing SQS message %v (%v): %v", message.MessageId, messageErr.Reason, messageErr.Message)
+
+		if messageErr.Permanent {
+			err := deadLetterQueue.Send(ctx, message, messageErr.Reason, messageErr.Message)
+			if err == nil {
+				continue
+			}
+			log.Printf("Error sending SQS message %v to the dead-letter queue: %v", message.MessageId, err)
+		}
+
+		response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{
+			ItemIdentifier: message.MessageId,
+		})
+	}
+
+	return response
+}
+
+// processMessage completes the command of the ack.
+func processMessage(ctx context.Context, message events.SQSMessage, deviceCommandService hDService.DeviceCommandService) *messageError {
+
+	var ack hDRequest.DeviceCommandAck
+	if err := json.Unmarshal([]byte(message.Body), &ack); err != nil {
+		return &messageError{Reason: ReasonInvalidMessage, Message: err.Error(), Permanent: true}
+	}
+
+	if validationErrors := hDValidation.ValidateDeviceRequestStruct(ack); len(validationErrors) > 0 {
+		return &messageError{Reason: ReasonValidationError, Message: strings.Join(validationErrors, "; "), Permanent: true}
+	}
+
+	command, err := deviceCommandService.AcknowledgeDeviceCommand(ctx, ack)
+	if err == nil {
+		log.Printf("Command %v of the device %v is %v", command.ID, command.DeviceID, command.Status)
+		return nil
+	}
+
+	if errors.Is(err, hDError.ErrDeviceCommandCompleted) {
+		log.Printf("Ignoring the duplicated ack of the command %v of the device %v", ack.CommandID, ack.DeviceID)
+		return nil
+	}
+
+	homeDeviceError := hDError.From(err)
+	return &messageError{Reason: homeDeviceError.ErrorCode, Message: fmt.Sprintf("acknowledging the command %v of the device %v: %v", ack.CommandID, ack.DeviceID, err), Permanent: !hDError.Lookup(homeDeviceError.ErrorCode).Retryable}
+}
+
+func main() {
+
+	lambda.Start(func(ctx context.Context, sqsEvent events.SQSEvent) (events.SQSEventResponse, error) {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			log.Fatalf("unable to load SDK config for deviceCommandAckListener lambda function, %v", err)
+		}
+
+		deadLetterQueue, err := hDQueue.NewSQSDeadLetterQueueFromConfig(cfg)
+		if err != nil {
+			log.Fatalf("unable to create the dead-letter queue for deviceCommandAckListener lambda function, %v", err)
+		}
+
+		return HandleRequest(ctx, sqsEvent, hDService.NewDeviceCommandServiceImplFromConfig(cfg, nil), deadLetterQueue), nil
+	})
+}
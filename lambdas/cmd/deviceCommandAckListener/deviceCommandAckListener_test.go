@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	hDConstants "github.com/odhoman/home-devices/internal/constants"
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const commandId = "5b0e7a0c-8c8f-4c0e-9f43-2f8f0f5f6b11"
+
+var ack = hDRequest.DeviceCommandAck{CommandID: commandId, DeviceID: "device123", Status: hDConstants.CommandStatusSucceeded}
+
+const ackBody = `{"commandId":"5b0e7a0c-8c8f-4c0e-9f43-2f8f0f5f6b11", "deviceId":"device123", "status":"succeeded"}`
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockDeviceCommandService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+	mockService.On("AcknowledgeDeviceCommand", mock.Anything, ack).Return(&hDResponse.DeviceCommandResponse{
+		ID:       commandId,
+		DeviceID: "device123",
+		Status:   hDConstants.CommandStatusSucceeded,
+	}, nil)
+
+	response := HandleRequest(context.TODO(), events.SQSEvent{Records: []events.SQSMessage{{MessageId: "message1", Body: ackBody}}}, mockService, mockDeadLetterQueue)
+
+	assert.Empty(t, response.BatchItemFailures)
+	mockService.AssertExpectations(t)
+	mockDeadLetterQueue.AssertNotCalled(t, "Send", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleRequest_PermanentErrors(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		body   string
+		reason string
+	}{
+		{"invalid json", `{ invalid json }`, ReasonInvalidMessage},
+		{"missing command id", `{"deviceId":"device123", "status":"succeeded"}`, ReasonValidationError},
+		{"unknown status", `{"commandId":"5b0e7a0c-8c8f-4c0e-9f43-2f8f0f5f6b11", "deviceId":"device123", "status":"done"}`, ReasonValidationError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(hDMock.MockDeviceCommandService)
+			mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+			message := events.SQSMessage{MessageId: "message1", Body: tt.body}
+			mockDeadLetterQueue.On("Send", mock.Anything, message, tt.reason, mock.Anything).Return(nil)
+
+			response := HandleRequest(context.TODO(), events.SQSEvent{Records: []events.SQSMessage{message}}, mockService, mockDeadLetterQueue)
+
+			assert.Empty(t, response.BatchItemFailures)
+			mockService.AssertNotCalled(t, "AcknowledgeDeviceCommand", mock.Anything, mock.Anything)
+			mockDeadLetterQueue.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleRequest_CommandNotFound(t *testing.T) {
+	mockService := new(hDMock.MockDeviceCommandService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+	mockService.On("AcknowledgeDeviceCommand", mock.Anything, ack).Return(nil, hDError.ErrDeviceCommandNotFound.New())
+
+	message := events.SQSMessage{MessageId: "message1", Body: ackBody}
+	mockDeadLetterQueue.On("Send", mock.Anything, message, hDError.ErrDeviceCommandNotFound.Code, mock.Anything).Return(nil)
+
+	response := HandleRequest(context.TODO(), events.SQSEvent{Records: []events.SQSMessage{message}}, mockService, mockDeadLetterQueue)
+
+	assert.Empty(t, response.BatchItemFailures)
+	mockDeadLetterQueue.AssertExpectations(t)
+}
+
+func TestHandleRequest_DuplicatedAck(t *testing.T) {
+	mockService := new(hDMock.MockDeviceCommandService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+	mockService.On("AcknowledgeDeviceCommand", mock.Anything, ack).Return(nil, hDError.ErrDeviceCommandCompleted.New())
+
+	response := HandleRequest(context.TODO(), events.SQSEvent{Records: []events.SQSMessage{{MessageId: "message1", Body: ackBody}}}, mockService, mockDeadLetterQueue)
+
+	assert.Empty(t, response.BatchItemFailures)
+	mockDeadLetterQueue.AssertNotCalled(t, "Send", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleRequest_UpdatingCommandError(t *testing.T) {
+	mockService := new(hDMock.MockDeviceCommandService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+	mockService.On("AcknowledgeDeviceCommand", mock.Anything, ack).Return(nil, hDError.ErrUpdatingDeviceCommand.New())
+
+	response := HandleRequest(context.TODO(), events.SQSEvent{Records: []events.SQSMessage{{MessageId: "message1", Body: ackBody}}}, mockService, mockDeadLetterQueue)
+
+	assert.Equal(t, []events.SQSBatchItemFailure{{ItemIdentifier: "message1"}}, response.BatchItemFailures)
+	mockDeadLetterQueue.AssertNotCalled(t, "Send", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleRequest_DeadLetterQueueError(t *testing.T) {
+	mockService := new(hDMock.MockDeviceCommandService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+	message := events.SQSMessage{MessageId: "message1", Body: `{ invalid json }`}
+	mockDeadLetterQueue.On("Send", mock.Anything, message, ReasonInvalidMessage, mock.Anything).Return(errors.New("queue unavailable"))
+
+	response := HandleRequest(context.TODO(), events.SQSEvent{Records: []events.SQSMessage{message}}, mockService, mockDeadLetterQueue)
+
+	assert.Equal(t, []events.SQSBatchItemFailure{{ItemIdentifier: "message1"}}, response.BatchItemFailures)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	hDDao "github.com/odhoman/home-devices/internal/dao"
+	hDStream "github.com/odhoman/home-devices/internal/stream"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// StreamReport counts what happened to the records of a batch.
+type StreamReport struct {
+	Received  int `json:"received"`
+	Projected int `json:"projected"`
+	Skipped   int `json:"skipped"`
+	Malformed int `json:"malformed"`
+	Failed    int `json:"failed"`
+}
+
+// HandleRequest sends each change of the devices table to every projection, in
+// the order of the stream.
+func HandleRequest(ctx context.Context, dynamoDBEvent events.DynamoDBEvent, projections []hDStream.Projection) events.DynamoDBEventResponse {
+
+	response, report := processRecords(ctx, dynamoDBEvent, projections)
+
+	if reportJson, err := json.Marshal(report); err == nil {
+		log.Printf("Device stream report: %s", reportJson)
+	}
+
+	return response
+}
+
+func processRecords(ctx context.Context, dynamoDBEvent events.DynamoDBEvent, projections []hDStream.Projection) (events.DynamoDBEventResponse, StreamReport) {
+
+	response := events.DynamoDBEventResponse{
+		BatchItemFailures: []events.DynamoDBBatchItemFailure{},
+	}
+	report := StreamReport{Received: len(dynamoDBEvent.Records)}
+
+	for i, record := range dynamoDBEvent.Records {
+
+		sequenceNumber := record.Change.SequenceNumber
+
+		change, err := hDStream.NewDeviceChange(record)
+		if err != nil {
+			report.Malformed++
+			log.Printf("Malformed %v record %v of the devices stream: %v", record.EventName, sequenceNumber, err)
+			continue
+		}
+
+		if change == nil {
+			report.Skipped++
+			continue
+		}
+
+		for _, projection := range projections {
+			if err := projection.Project(ctx, *change); err != nil {
+				log.Printf("Error projecting the %v of the device %v of the record %v into %v: %v", change.Operation, change.DeviceID, sequenceNumber, projection.Name(), err)
+				return failFrom(response, report, dynamoDBEvent.Records[i:])
+			}
+		}
+
+		report.Projected++
+	}
+
+	return response, report
+}
+
+// failFrom reports the first of the records as the failure of the batch.
+func failFrom(response events.DynamoDBEventResponse, report StreamReport, records []events.DynamoDBEventRecord) (events.DynamoDBEventResponse, StreamReport) {
+
+	report.Failed = len(records)
+	response.BatchItemFailures = append(response.BatchItemFailures, events.DynamoDBBatchItemFailure{
+		ItemIdentifier: records[0].Change.SequenceNumber,
+	})
+
+	return response, report
+}
+
+func main() {
+
+	lambda.Start(func(ctx context.Context, dynamoDBEvent events.DynamoDBEvent) (events.DynamoDBEventResponse, error) {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			log.Fatalf("unable to load SDK config for deviceStreamProcessor lambda function, %v", err)
+		}
+
+		projections := []hDStream.Projection{
+			hDStream.AuditProjection{},
+			hDStream.CounterProjection{DeviceCounterDao: hDDao.DeviceCounterDaoImpl{DynamoDbApi: dynamodb.NewFromConfig(cfg)}},
+		}
+
+		return HandleRequest(ctx, dynamoDBEvent, projections), nil
+	})
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	hDDao "github.com/odhoman/home-devices/internal/dao"
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDStream "github.com/odhoman/home-devices/internal/stream"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingProjection keeps the ids of the devices it projects, and fails
+// once for the devices in failFor.
+type recordingProjection struct {
+	projected []string
+	failFor   map[string]bool
+}
+
+func (rP *recordingProjection) Name() string {
+	return "recording"
+}
+
+func (rP *recordingProjection) Project(ctx context.Context, change hDStream.DeviceChange) error {
+
+	if rP.failFor[change.DeviceID] {
+		delete(rP.failFor, change.DeviceID)
+		return hDError.ErrUpdatingDeviceCounts.New()
+	}
+
+	rP.projected = append(rP.projected, change.DeviceID)
+	return nil
+}
+
+func deviceImage(id string, homeId string) map[string]events.DynamoDBAttributeValue {
+	return map[string]events.DynamoDBAttributeValue{
+		"id":     events.NewStringAttribute(id),
+		"mac":    events.NewStringAttribute("00:11:22:33:44:55"),
+		"name":   events.NewStringAttribute("Living Room Light"),
+		"type":   events.NewStringAttribute("light"),
+		"homeId": events.NewStringAttribute(homeId),
+	}
+}
+
+// buildDynamoDBEvent builds an INSERT record for each image, numbered from 1.
+func buildDynamoDBEvent(images ...map[string]events.DynamoDBAttributeValue) events.DynamoDBEvent {
+
+	dynamoDBEvent := events.DynamoDBEvent{}
+	for i, image := range images {
+		dynamoDBEvent.Records = append(dynamoDBEvent.Records, events.DynamoDBEventRecord{
+			EventID:   "event" + strconv.Itoa(i+1),
+			EventName: "INSERT",
+			Change: events.DynamoDBStreamRecord{
+				NewImage:       image,
+				SequenceNumber: strconv.Itoa(i + 1),
+			},
+		})
+	}
+
+	return dynamoDBEvent
+}
+
+func TestHandleRequest_Success(t *testing.T) {
+
+	counterDao := hDDao.NewInMemoryDeviceCounterDao()
+	projection := &recordingProjection{}
+
+	response := HandleRequest(context.TODO(), buildDynamoDBEvent(
+		deviceImage("device1", "home1"),
+		deviceImage("device2", "home1"),
+	), []hDStream.Projection{projection, hDStream.CounterProjection{DeviceCounterDao: counterDao}})
+
+	assert.Empty(t, response.BatchItemFailures)
+	assert.Equal(t, []string{"device1", "device2"}, projection.projected)
+	assert.Equal(t, map[string]int64{"TOTAL": 2, "light": 2}, counterDao.Counts("home1"))
+}
+
+func TestProcessRecords_SkipsMalformedAndNotDevices(t *testing.T) {
+
+	projection := &recordingProjection{}
+
+	response, report := processRecords(context.TODO(), buildDynamoDBEvent(
+		deviceImage("device1", "home1"),
+		// no id
+		map[string]events.DynamoDBAttributeValue{"name": events.NewStringAttribute("Living Room Light")},
+		// the mac + homeId guard of device1
+		map[string]events.DynamoDBAttributeValue{
+			"id":       events.NewStringAttribute("MAC#00:11:22:33:44:55#HOME#home1"),
+			"deviceId": events.NewStringAttribute("device1"),
+		},
+		deviceImage("device2", "home1"),
+	), []hDStream.Projection{projection})
+
+	assert.Empty(t, response.BatchItemFailures)
+	assert.Equal(t, StreamReport{Received: 4, Projected: 2, Skipped: 1, Malformed: 1}, report)
+	assert.Equal(t, []string{"device1", "device2"}, projection.projected)
+}
+
+func TestProcessRecords_FailureStopsTheBatch(t *testing.T) {
+
+	projection := &recordingProjection{failFor: map[string]bool{"device2": true}}
+	dynamoDBEvent := buildDynamoDBEvent(
+		deviceImage("device1", "home1"),
+		deviceImage("device2", "home1"),
+		deviceImage("device3", "home1"),
+	)
+
+	response, report := processRecords(context.TODO(), dynamoDBEvent, []hDStream.Projection{projection})
+
+	assert.Equal(t, []events.DynamoDBBatchItemFailure{{ItemIdentifier: "2"}}, response.BatchItemFailures)
+	assert.Equal(t, StreamReport{Received: 3, Projected: 1, Failed: 2}, report)
+	assert.Equal(t, []string{"device1"}, projection.projected)
+
+	// the stream is retried from the failed record
+	response, report = processRecords(context.TODO(), events.DynamoDBEvent{Records: dynamoDBEvent.Records[1:]}, []hDStream.Projection{projection})
+
+	assert.Empty(t, response.BatchItemFailures)
+	assert.Equal(t, StreamReport{Received: 2, Projected: 2}, report)
+	assert.Equal(t, []string{"device1", "device2", "device3"}, projection.projected)
+}
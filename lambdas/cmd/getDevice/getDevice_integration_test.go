@@ -65,7 +65,7 @@ func createHomeDeviceForTesting(t *testing.T, ctx context.Context, service hDSer
 	deviceCreated, err := service.CreateHomeDevice(ctx, device)
 
 	if err != nil {
-		t.Fatalf("Unexpected error creating new Device for testing. Error: %v", err.ErrorCode)
+		t.Fatalf("Unexpected error creating new Device for testing. Error: %v", err)
 		return nil
 	}
 
@@ -7,7 +7,6 @@ import (
 	"testing"
 	"time"
 
-	hDConstants "github.com/odhoman/home-devices/internal/constants"
 	hDError "github.com/odhoman/home-devices/internal/error"
 	hDMock "github.com/odhoman/home-devices/internal/mock"
 	hDResponse "github.com/odhoman/home-devices/internal/response"
@@ -30,6 +29,7 @@ func TestHandleRequest_Success(t *testing.T) {
 		HomeID:     "home12122",
 		CreatedAt:  time.Now().Unix(),
 		ModifiedAt: time.Now().Unix(),
+		Version:    4,
 	}
 
 	mockService.On("GetHomeDevice", mock.Anything, id).Return(device, nil)
@@ -38,6 +38,7 @@ func TestHandleRequest_Success(t *testing.T) {
 
 	assert.NoError(t, err)
 	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "\"4\"", response.Headers["ETag"])
 
 	expectedBody, _ := json.Marshal(device)
 	assert.JSONEq(t, string(expectedBody), response.Body)
@@ -60,9 +61,7 @@ func TestHandleRequest_DeviceNotFound(t *testing.T) {
 	id := uuid.New().String()
 
 	mockService := new(hDMock.MockHomeDeviceService)
-	mockService.On("GetHomeDevice", mock.Anything, id).Return(nil, &hDError.HomeDeviceError{
-		ErrorCode: hDConstants.ErrDeviceNotFoundCode,
-	})
+	mockService.On("GetHomeDevice", mock.Anything, id).Return(nil, hDError.ErrDeviceNotFound.New())
 
 	response, _ := HandleRequest(context.TODO(), id, mockService)
 
@@ -75,9 +74,7 @@ func TestHandleRequest_InternalServerError(t *testing.T) {
 	id := uuid.New().String()
 
 	mockService := new(hDMock.MockHomeDeviceService)
-	mockService.On("GetHomeDevice", mock.Anything, id).Return(nil, &hDError.HomeDeviceError{
-		ErrorCode: hDConstants.ErrGettingDeviceMessage,
-	})
+	mockService.On("GetHomeDevice", mock.Anything, id).Return(nil, hDError.ErrGettingDevice.New())
 
 	response, _ := HandleRequest(context.TODO(), id, mockService)
 
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	hDHandler "github.com/odhoman/home-devices/internal/handler"
+	hDService "github.com/odhoman/home-devices/internal/service"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+func HandleRequest(ctx context.Context, deviceId string, id string, deviceCommandService hDService.DeviceCommandService) (events.APIGatewayProxyResponse, error) {
+	return hDHandler.GetDeviceCommand(ctx, deviceId, id, deviceCommandService)
+}
+
+func main() {
+
+	lambda.Start(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			log.Fatalf("unable to load SDK config for getDeviceCommand lambda function, %v", err)
+		}
+
+		return hDHandler.WithErrorNegotiation(hDHandler.GetDeviceCommandFromAPIGateway)(ctx, request, hDService.NewDeviceCommandServiceImplFromConfig(cfg, nil))
+	})
+}
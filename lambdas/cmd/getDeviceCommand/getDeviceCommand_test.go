@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	hDConstants "github.com/odhoman/home-devices/internal/constants"
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockDeviceCommandService)
+
+	command := &hDResponse.DeviceCommandResponse{
+		ID:          "5b0e7a0c-8c8f-4c0e-9f43-2f8f0f5f6b11",
+		DeviceID:    "id",
+		Name:        "turnOn",
+		Status:      hDConstants.CommandStatusSucceeded,
+		CreatedAt:   1700000000,
+		ExpiresAt:   1700000300,
+		CompletedAt: 1700000002,
+	}
+
+	mockService.On("GetDeviceCommand", mock.Anything, "id", command.ID).Return(command, nil)
+
+	response, err := HandleRequest(context.TODO(), "id", command.ID, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	expectedBody, _ := json.Marshal(command)
+	assert.JSONEq(t, string(expectedBody), response.Body)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_EmptyIds(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), "", "cmd", new(hDMock.MockDeviceCommandService))
+	assert.Equal(t, 400, response.StatusCode)
+
+	response, _ = HandleRequest(context.TODO(), "id", "", new(hDMock.MockDeviceCommandService))
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestHandleRequest_Errors(t *testing.T) {
+
+	tests := []struct {
+		err        error
+		statusCode int
+		message    string
+	}{
+		{hDError.ErrDeviceCommandNotFound.New(), 404, "Device Command Not Found"},
+		{hDError.ErrGettingDeviceCommand.New(), 500, "Internal Server error getting the device command"},
+	}
+
+	for _, tt := range tests {
+		t.Run(hDError.From(tt.err).ErrorCode, func(t *testing.T) {
+			mockService := new(hDMock.MockDeviceCommandService)
+			mockService.On("GetDeviceCommand", mock.Anything, "id", "cmd").Return(nil, tt.err)
+
+			response, _ := HandleRequest(context.TODO(), "id", "cmd", mockService)
+
+			assert.Equal(t, tt.statusCode, response.StatusCode)
+			assert.Contains(t, response.Body, tt.message)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	hDAudit "github.com/odhoman/home-devices/internal/audit"
+	"github.com/odhoman/home-devices/internal/dao"
+	"github.com/odhoman/home-devices/internal/mock"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	mock.RunTestMain(m)
+}
+
+func TestGetDeviceHistory_RecordsEveryChange(t *testing.T) {
+
+	svc := mock.GetDynamoConnectionTestFromEnpoint()
+	homeDeviceServiceImpl := hDService.NewHomeDeviceServiceImpl2(dao.HomeDeviceDaoImpl{DynamoDbApi: svc, RecordHistory: true}, hDService.WithDeviceHistoryDao(dao.DeviceHistoryDaoImpl{DynamoDbApi: svc}))
+
+	ctx := hDAudit.WithSource(hDAudit.WithActor(context.Background(), "user-1"), hDAudit.SourceAPI)
+
+	device, err := homeDeviceServiceImpl.CreateHomeDevice(ctx, hDRequest.CreateDeviceRequest{
+		MAC:    "20:1A:2B:3C:4D:01",
+		Name:   "Living Room Light",
+		Type:   "light",
+		HomeID: "homeDeviceHistory",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error creating new Device for testing. Error: %v", err)
+	}
+
+	if _, err := homeDeviceServiceImpl.UpdateHomeDevice(ctx, hDRequest.UpdateDeviceRequest{Name: "Kitchen Light"}, device.ID, 0); err != nil {
+		t.Fatalf("Unexpected error updating the Device for testing. Error: %v", err)
+	}
+
+	if _, err := homeDeviceServiceImpl.DeleteHomeDevice(ctx, device.ID, 0); err != nil {
+		t.Fatalf("Unexpected error deleting the Device for testing. Error: %v", err)
+	}
+
+	firstPage := getDeviceHistoryForTesting(t, homeDeviceServiceImpl, hDRequest.DeviceHistoryRequest{ID: device.ID, Limit: 2})
+
+	assert.Len(t, firstPage.Changes, 2)
+	assert.NotEmpty(t, firstPage.NextCursor)
+	assert.Equal(t, hDAudit.OperationDelete, firstPage.Changes[0].Operation)
+	assert.Equal(t, hDAudit.OperationUpdate, firstPage.Changes[1].Operation)
+	assert.Equal(t, []hDResponse.DeviceFieldChange{{Field: "name", Before: "Living Room Light", After: "Kitchen Light"}}, firstPage.Changes[1].Changes)
+	assert.Equal(t, "user-1", firstPage.Changes[1].Actor)
+	assert.Equal(t, hDAudit.SourceAPI, firstPage.Changes[1].Source)
+
+	secondPage := getDeviceHistoryForTesting(t, homeDeviceServiceImpl, hDRequest.DeviceHistoryRequest{ID: device.ID, Limit: 2, Cursor: firstPage.NextCursor})
+
+	assert.Len(t, secondPage.Changes, 1)
+	assert.Equal(t, hDAudit.OperationCreate, secondPage.Changes[0].Operation)
+	assert.Empty(t, secondPage.NextCursor)
+}
+
+func getDeviceHistoryForTesting(t *testing.T, service hDService.HomeDeviceService, request hDRequest.DeviceHistoryRequest) *hDResponse.DeviceHistoryResponse {
+
+	response, err := HandleRequest(context.Background(), request, service)
+
+	if err != nil || response.StatusCode != 200 {
+		t.Fatalf("Unexpected error getting the Device history for testing. Response: %v", response.Body)
+		return nil
+	}
+
+	var history hDResponse.DeviceHistoryResponse
+	if err := json.Unmarshal([]byte(response.Body), &history); err != nil {
+		t.Fatalf("Unexpected error deserializing the Device history. Error: %v", err)
+	}
+
+	return &history
+}
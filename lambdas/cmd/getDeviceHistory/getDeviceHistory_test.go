@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	hDAudit "github.com/odhoman/home-devices/internal/audit"
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockHomeDeviceService)
+
+	request := hDRequest.DeviceHistoryRequest{
+		ID:    "id",
+		Limit: 2,
+	}
+
+	history := &hDResponse.DeviceHistoryResponse{
+		Changes: []hDResponse.DeviceChangeResponse{
+			{
+				DeviceID:  request.ID,
+				ChangeID:  "changeId",
+				Operation: hDAudit.OperationUpdate,
+				Actor:     "user-1",
+				Source:    hDAudit.SourceAPI,
+				ChangedAt: time.Now().Unix(),
+				Version:   2,
+				Changes:   []hDResponse.DeviceFieldChange{{Field: "name", Before: "Light", After: "Living Room Light"}},
+			},
+		},
+		NextCursor: "nextCursor",
+	}
+
+	mockService.On("GetDeviceHistory", mock.Anything, request.ID, request.Limit, request.Cursor).Return(history, nil)
+
+	response, err := HandleRequest(context.TODO(), request, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	expectedBody, _ := json.Marshal(history)
+	assert.JSONEq(t, string(expectedBody), response.Body)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_EmptyId(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), hDRequest.DeviceHistoryRequest{}, new(hDMock.MockHomeDeviceService))
+
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestHandleRequest_ValidationError(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), hDRequest.DeviceHistoryRequest{ID: "id", Limit: 500}, new(hDMock.MockHomeDeviceService))
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Limit must be between 1 and 100")
+}
+
+func TestHandleRequest_InvalidCursor(t *testing.T) {
+
+	request := hDRequest.DeviceHistoryRequest{
+		ID:     "id",
+		Cursor: "wrongCursor",
+	}
+
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockService.On("GetDeviceHistory", mock.Anything, request.ID, request.Limit, request.Cursor).Return(nil, hDError.ErrInvalidCursor.New())
+
+	response, _ := HandleRequest(context.TODO(), request, mockService)
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Invalid cursor")
+}
+
+func TestHandleRequest_InternalServerError(t *testing.T) {
+
+	request := hDRequest.DeviceHistoryRequest{
+		ID: "id",
+	}
+
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockService.On("GetDeviceHistory", mock.Anything, request.ID, request.Limit, request.Cursor).Return(nil, hDError.ErrListingDeviceHistory.New())
+
+	response, _ := HandleRequest(context.TODO(), request, mockService)
+
+	assert.Equal(t, 500, response.StatusCode)
+	assert.Contains(t, response.Body, "Internal Server error getting the device history")
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockDeviceStateService)
+
+	state := &hDResponse.DeviceStateResponse{
+		DeviceID: "id",
+		Desired:  hDResponse.DeviceStateDocumentResponse{State: map[string]interface{}{"power": "on"}, Version: 2, UpdatedAt: 1729000000},
+		Reported: hDResponse.DeviceStateDocumentResponse{State: map[string]interface{}{"power": "off"}, Version: 5, UpdatedAt: 1729000100},
+		Delta:    map[string]interface{}{"power": "on"},
+	}
+
+	mockService.On("GetDeviceState", mock.Anything, "id").Return(state, nil)
+
+	response, err := HandleRequest(context.TODO(), "id", mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	expectedBody, _ := json.Marshal(state)
+	assert.JSONEq(t, string(expectedBody), response.Body)
+}
+
+func TestHandleRequest_EmptyId(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), "", new(hDMock.MockDeviceStateService))
+
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestHandleRequest_DeviceNotFound(t *testing.T) {
+	mockService := new(hDMock.MockDeviceStateService)
+
+	mockService.On("GetDeviceState", mock.Anything, "id").Return(nil, hDError.ErrDeviceNotFound.New())
+
+	response, _ := HandleRequest(context.TODO(), "id", mockService)
+
+	assert.Equal(t, 404, response.StatusCode)
+}
+
+func TestHandleRequest_InternalServerError(t *testing.T) {
+	mockService := new(hDMock.MockDeviceStateService)
+
+	mockService.On("GetDeviceState", mock.Anything, "id").Return(nil, hDError.ErrGettingDeviceState.New())
+
+	response, _ := HandleRequest(context.TODO(), "id", mockService)
+
+	assert.Equal(t, 500, response.StatusCode)
+	assert.Contains(t, response.Body, "Internal Server error getting the device state")
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockHomeService)
+
+	home := &hDResponse.HomeResponse{
+		ID:       "home12122",
+		Name:     "Beach House",
+		Timezone: "Europe/Madrid",
+		Owner:    "user-1",
+		Version:  3,
+	}
+
+	mockService.On("GetHome", mock.Anything, "home12122").Return(home, nil)
+
+	response, err := HandleRequest(context.TODO(), "home12122", mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "\"3\"", response.Headers["ETag"])
+
+	expectedBody, _ := json.Marshal(home)
+	assert.JSONEq(t, string(expectedBody), response.Body)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_ValidationError(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), "", new(hDMock.MockHomeService))
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Field 'homeId' is empty. Please enter a value")
+}
+
+func TestHandleRequest_HomeNotFound(t *testing.T) {
+	mockService := new(hDMock.MockHomeService)
+	mockService.On("GetHome", mock.Anything, "home12122").Return(nil, hDError.ErrHomeNotFound.New())
+
+	response, _ := HandleRequest(context.TODO(), "home12122", mockService)
+
+	assert.Equal(t, 404, response.StatusCode)
+	assert.Contains(t, response.Body, "Home Not Found")
+}
+
+func TestHandleRequest_InternalServerError(t *testing.T) {
+	mockService := new(hDMock.MockHomeService)
+	mockService.On("GetHome", mock.Anything, "home12122").Return(nil, hDError.ErrGettingHome.New())
+
+	response, _ := HandleRequest(context.TODO(), "home12122", mockService)
+
+	assert.Equal(t, 500, response.StatusCode)
+	assert.Contains(t, response.Body, "Internal Server error getting the home")
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockRoomService)
+
+	room := &hDResponse.RoomResponse{ID: "8d3c1b6e-2f4a-4c51-9a57-0d6f4f1f2b11", HomeID: "home12122", Name: "Kitchen", Version: 2}
+
+	mockService.On("GetRoom", mock.Anything, "home12122", room.ID).Return(room, nil)
+
+	response, err := HandleRequest(context.TODO(), "home12122", room.ID, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "\"2\"", response.Headers["ETag"])
+
+	expectedBody, _ := json.Marshal(room)
+	assert.JSONEq(t, string(expectedBody), response.Body)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_EmptyRoomId(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), "home12122", "", new(hDMock.MockRoomService))
+
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestHandleRequest_Errors(t *testing.T) {
+
+	tests := []struct {
+		err        error
+		statusCode int
+		message    string
+	}{
+		{hDError.ErrRoomNotFound.New(), 404, "Room Not Found"},
+		{hDError.ErrGettingRoom.New(), 500, "Internal Server error getting the room"},
+	}
+
+	for _, test := range tests {
+		t.Run(hDError.From(test.err).ErrorCode, func(t *testing.T) {
+			mockService := new(hDMock.MockRoomService)
+			mockService.On("GetRoom", mock.Anything, "home12122", "8d3c1b6e-2f4a-4c51-9a57-0d6f4f1f2b11").Return(nil, test.err)
+
+			response, _ := HandleRequest(context.TODO(), "home12122", "8d3c1b6e-2f4a-4c51-9a57-0d6f4f1f2b11", mockService)
+
+			assert.Equal(t, test.statusCode, response.StatusCode)
+			assert.Contains(t, response.Body, test.message)
+		})
+	}
+}
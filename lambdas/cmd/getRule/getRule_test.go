@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockRuleService)
+
+	rule := &hDResponse.RuleResponse{ID: "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", HomeID: "home12122", Name: "Hallway light at night", Enabled: true, Version: 2}
+
+	mockService.On("GetRule", mock.Anything, "home12122", rule.ID).Return(rule, nil)
+
+	response, err := HandleRequest(context.TODO(), "home12122", rule.ID, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "\"2\"", response.Headers["ETag"])
+
+	expectedBody, _ := json.Marshal(rule)
+	assert.JSONEq(t, string(expectedBody), response.Body)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_EmptyRuleId(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), "home12122", "", new(hDMock.MockRuleService))
+
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestHandleRequest_Errors(t *testing.T) {
+
+	tests := []struct {
+		err        error
+		statusCode int
+		message    string
+	}{
+		{hDError.ErrRuleNotFound.New(), 404, "Rule Not Found"},
+		{hDError.ErrGettingRule.New(), 500, "Internal Server error getting the rule"},
+	}
+
+	for _, test := range tests {
+		t.Run(hDError.From(test.err).ErrorCode, func(t *testing.T) {
+			mockService := new(hDMock.MockRuleService)
+			mockService.On("GetRule", mock.Anything, "home12122", "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88").Return(nil, test.err)
+
+			response, _ := HandleRequest(context.TODO(), "home12122", "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", mockService)
+
+			assert.Equal(t, test.statusCode, response.StatusCode)
+			assert.Contains(t, response.Body, test.message)
+		})
+	}
+}
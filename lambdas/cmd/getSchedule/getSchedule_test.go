@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockScheduleService)
+
+	schedule := &hDResponse.ScheduleResponse{ID: "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", HomeID: "home12122", Name: "Lights off at midnight", Enabled: true, Cron: "0 0 * * *", Timezone: "Europe/Madrid", Version: 2}
+
+	mockService.On("GetSchedule", mock.Anything, "home12122", schedule.ID).Return(schedule, nil)
+
+	response, err := HandleRequest(context.TODO(), "home12122", schedule.ID, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "\"2\"", response.Headers["ETag"])
+
+	expectedBody, _ := json.Marshal(schedule)
+	assert.JSONEq(t, string(expectedBody), response.Body)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_EmptyScheduleId(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), "home12122", "", new(hDMock.MockScheduleService))
+
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestHandleRequest_Errors(t *testing.T) {
+
+	tests := []struct {
+		err        error
+		statusCode int
+		message    string
+	}{
+		{hDError.ErrScheduleNotFound.New(), 404, "Schedule Not Found"},
+		{hDError.ErrGettingSchedule.New(), 500, "Internal Server error getting the schedule"},
+	}
+
+	for _, test := range tests {
+		t.Run(hDError.From(test.err).ErrorCode, func(t *testing.T) {
+			mockService := new(hDMock.MockScheduleService)
+			mockService.On("GetSchedule", mock.Anything, "home12122", "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88").Return(nil, test.err)
+
+			response, _ := HandleRequest(context.TODO(), "home12122", "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", mockService)
+
+			assert.Equal(t, test.statusCode, response.StatusCode)
+			assert.Contains(t, response.Body, test.message)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+)
+
+// Operations of the device command messages.
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+	OpMove   = "move"
+)
+
+const (
+	ReasonUnknownOperation   = "UNKNOWN_OPERATION"
+	ReasonUnsupportedVersion = "UNSUPPORTED_VERSION"
+)
+
+// DeviceCommandMessage is the envelope of the SQS messages:
+// {"op": "create|update|delete|move", "version": 1, "payload": {...}}.
+type DeviceCommandMessage struct {
+	Op      string          `json:"op"`
+	Version int             `json:"version"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+type UpdateDeviceCommandPayload struct {
+	ID              string `json:"id" validate:"required"`
+	ExpectedVersion int64  `json:"expectedVersion" validate:"omitempty,min=1"`
+	hDRequest.UpdateDeviceRequest
+}
+
+type DeleteDeviceCommandPayload struct {
+	ID              string `json:"id" validate:"required"`
+	ExpectedVersion int64  `json:"expectedVersion" validate:"omitempty,min=1"`
+}
+
+type deviceCommandHandler func(ctx context.Context, payload json.RawMessage, deviceService hDService.HomeDeviceService) *messageError
+
+// deviceCommandHandlers routes the commands by version and op.
+var deviceCommandHandlers = map[int]map[string]deviceCommandHandler{
+	1: {
+		OpCreate: handleCreateDeviceCommand,
+		OpUpdate: handleUpdateDeviceCommand,
+		OpDelete: handleDeleteDeviceCommand,
+		OpMove:   handleMoveDeviceCommand,
+	},
+}
+
+func processDeviceCommand(ctx context.Context, command DeviceCommandMessage, deviceService hDService.HomeDeviceService) *messageError {
+
+	handlers, ok := deviceCommandHandlers[command.Version]
+	if !ok {
+		return &messageError{Reason: ReasonUnsupportedVersion, Message: fmt.Sprintf("version %v is not supported", command.Version), Permanent: true}
+	}
+
+	handler, ok := handlers[command.Op]
+	if !ok {
+		return &messageError{Reason: ReasonUnknownOperation, Message: fmt.Sprintf("op %q is not supported in version %v", command.Op, command.Version), Permanent: true}
+	}
+
+	return handler(ctx, command.Payload, deviceService)
+}
+
+func handleCreateDeviceCommand(ctx context.Context, payload json.RawMessage, deviceService hDService.HomeDeviceService) *messageError {
+
+	var createDeviceRequest hDRequest.CreateDeviceRequest
+	if err := decodeCommandPayload(payload, &createDeviceRequest); err != nil {
+		return err
+	}
+
+	if _, err := deviceService.CreateHomeDevice(ctx, createDeviceRequest); err != nil {
+		return getServiceMessageError(err, fmt.Sprintf("creating a device for mac %v - homeId %v", createDeviceRequest.MAC, createDeviceRequest.HomeID), false)
+	}
+
+	return nil
+}
+
+func handleUpdateDeviceCommand(ctx context.Context, payload json.RawMessage, deviceService hDService.HomeDeviceService) *messageError {
+
+	var updatePayload UpdateDeviceCommandPayload
+	if err := decodeCommandPayload(payload, &updatePayload); err != nil {
+		return err
+	}
+
+	if _, err := deviceService.UpdateHomeDevice(ctx, updatePayload.UpdateDeviceRequest, updatePayload.ID, updatePayload.ExpectedVersion); err != nil {
+		return getServiceMessageError(err, fmt.Sprintf("updating a device for id %v", updatePayload.ID), updatePayload.ExpectedVersion > 0)
+	}
+
+	return nil
+}
+
+func handleDeleteDeviceCommand(ctx context.Context, payload json.RawMessage, deviceService hDService.HomeDeviceService) *messageError {
+
+	var deletePayload DeleteDeviceCommandPayload
+	if err := decodeCommandPayload(payload, &deletePayload); err != nil {
+		return err
+	}
+
+	if _, err := deviceService.DeleteHomeDevice(ctx, deletePayload.ID, deletePayload.ExpectedVersion); err != nil {
+		return getServiceMessageError(err, fmt.Sprintf("deleting a device for id %v", deletePayload.ID), deletePayload.ExpectedVersion > 0)
+	}
+
+	return nil
+}
+
+func handleMoveDeviceCommand(ctx context.Context, payload json.RawMessage, deviceService hDService.HomeDeviceService) *messageError {
+
+	var updateDeviceSQSMessage UpdateDeviceSQSMessage
+	if err := decodeCommandPayload(payload, &updateDeviceSQSMessage); err != nil {
+		return err
+	}
+
+	deviceId := updateDeviceSQSMessage.ID
+	homeId := updateDeviceSQSMessage.HomeID
+
+	if _, err := deviceService.UpdateHomeDevice(ctx, hDRequest.UpdateDeviceRequest{
+		HomeID: homeId,
+		RoomID: updateDeviceSQSMessage.RoomID,
+	}, deviceId, 0); err != nil {
+		return getServiceMessageError(err, fmt.Sprintf("updating a device for id %v - homeId %v", deviceId, homeId), false)
+	}
+
+	return nil
+}
+
+// decodeCommandPayload decodes the payload and validates it with the rules of
+// the request it is decoded to.
+func decodeCommandPayload(payload json.RawMessage, target interface{}) *messageError {
+
+	if len(payload) == 0 {
+		return &messageError{Reason: ReasonInvalidMessage, Message: "payload is required", Permanent: true}
+	}
+
+	if err := json.Unmarshal(payload, target); err != nil {
+		return &messageError{Reason: ReasonInvalidMessage, Message: err.Error(), Permanent: true}
+	}
+
+	if validationErrors := hDValidation.ValidateDeviceRequestStruct(target); len(validationErrors) > 0 {
+		return &messageError{Reason: ReasonValidationError, Message: strings.Join(validationErrors, "; "), Permanent: true}
+	}
+
+	return nil
+}
+
+// getServiceMessageError tells the errors that will happen again on every retry
+// apart from the transient ones, as registered for their codes.
+func getServiceMessageError(err error, action string, versionChecked bool) *messageError {
+
+	homeDeviceError := hDError.From(err)
+
+	permanent := !hDError.Lookup(homeDeviceError.ErrorCode).Retryable
+	if errors.Is(err, hDError.ErrVersionConflict) {
+		permanent = versionChecked
+	}
+
+	return &messageError{
+		Reason:    homeDeviceError.ErrorCode,
+		Message:   fmt.Sprintf("%v: %v", action, err),
+		Permanent: permanent,
+	}
+}
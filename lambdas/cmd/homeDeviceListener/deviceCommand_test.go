@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	hDAudit "github.com/odhoman/home-devices/internal/audit"
+	hDDao "github.com/odhoman/home-devices/internal/dao"
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDEvent "github.com/odhoman/home-devices/internal/event"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_CreateCommand(t *testing.T) {
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+	createDeviceRequest := hDRequest.CreateDeviceRequest{
+		MAC:    "00:1A:2B:3C:4D:5E",
+		Name:   "Living Room Light",
+		Type:   "light",
+		HomeID: "home12345",
+	}
+
+	mockService.On("CreateHomeDevice", mock.Anything, createDeviceRequest).Return(&hDResponse.HomdeDeviceResponse{ID: "device123"}, nil)
+
+	response := HandleRequest(context.TODO(), buildCommandEvent(`{"op":"create","version":1,"payload":{"mac":"00:1A:2B:3C:4D:5E","name":"Living Room Light","type":"light","homeId":"home12345"}}`), mockService, mockDeadLetterQueue)
+
+	assert.Empty(t, response.BatchItemFailures)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_CreateCommandValidationError(t *testing.T) {
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+	mockDeadLetterQueue.On("Send", mock.Anything, mock.Anything, ReasonValidationError, mock.Anything).Return(nil)
+
+	response := HandleRequest(context.TODO(), buildCommandEvent(`{"op":"create","version":1,"payload":{"mac":"invalid","name":"Living Room Light","type":"light"}}`), mockService, mockDeadLetterQueue)
+
+	assert.Empty(t, response.BatchItemFailures)
+	mockService.AssertNotCalled(t, "CreateHomeDevice", mock.Anything, mock.Anything)
+	mockDeadLetterQueue.AssertExpectations(t)
+}
+
+func TestHandleRequest_CreateCommandAlreadyExists(t *testing.T) {
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+	mockService.On("CreateHomeDevice", mock.Anything, mock.Anything).Return(nil, hDError.ErrDeviceAlreadyExists.New())
+	mockDeadLetterQueue.On("Send", mock.Anything, mock.Anything, hDError.ErrDeviceAlreadyExists.Code, mock.Anything).Return(nil)
+
+	response := HandleRequest(context.TODO(), buildCommandEvent(`{"op":"create","version":1,"payload":{"mac":"00:1A:2B:3C:4D:5E","name":"Living Room Light","type":"light","homeId":"home12345"}}`), mockService, mockDeadLetterQueue)
+
+	assert.Empty(t, response.BatchItemFailures)
+	mockDeadLetterQueue.AssertExpectations(t)
+}
+
+func TestHandleRequest_UpdateCommand(t *testing.T) {
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+	mockService.On("UpdateHomeDevice", mock.Anything, hDRequest.UpdateDeviceRequest{Name: "Kitchen Light"}, "device123", int64(3)).Return(&hDResponse.HomdeDeviceResponse{ID: "device123", Version: 4}, nil)
+
+	response := HandleRequest(context.TODO(), buildCommandEvent(`{"op":"update","version":1,"payload":{"id":"device123","expectedVersion":3,"name":"Kitchen Light"}}`), mockService, mockDeadLetterQueue)
+
+	assert.Empty(t, response.BatchItemFailures)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_UpdateCommandVersionConflict(t *testing.T) {
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+	versionConflict := hDError.ErrVersionConflict.New()
+
+	mockService.On("UpdateHomeDevice", mock.Anything, mock.Anything, "device123", int64(3)).Return(nil, versionConflict)
+	mockService.On("UpdateHomeDevice", mock.Anything, mock.Anything, "device456", int64(0)).Return(nil, versionConflict)
+	mockDeadLetterQueue.On("Send", mock.Anything, mock.Anything, hDError.ErrVersionConflict.Code, mock.Anything).Return(nil)
+
+	sqsEvent := events.SQSEvent{
+		Records: []events.SQSMessage{
+			{MessageId: "message1", Body: `{"op":"update","version":1,"payload":{"id":"device123","expectedVersion":3,"name":"Kitchen Light"}}`},
+			{MessageId: "message2", Body: `{"op":"update","version":1,"payload":{"id":"device456","name":"Kitchen Light"}}`},
+		},
+	}
+
+	response := HandleRequest(context.TODO(), sqsEvent, mockService, mockDeadLetterQueue)
+
+	assert.Equal(t, []events.SQSBatchItemFailure{{ItemIdentifier: "message2"}}, response.BatchItemFailures)
+	mockDeadLetterQueue.AssertNumberOfCalls(t, "Send", 1)
+}
+
+func TestHandleRequest_UpdateCommandValidationError(t *testing.T) {
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+	mockDeadLetterQueue.On("Send", mock.Anything, mock.Anything, ReasonValidationError, mock.Anything).Return(nil)
+
+	response := HandleRequest(context.TODO(), buildCommandEvent(`{"op":"update","version":1,"payload":{"name":"Kitchen Light","expectedVersion":-1}}`), mockService, mockDeadLetterQueue)
+
+	assert.Empty(t, response.BatchItemFailures)
+	mockService.AssertNotCalled(t, "UpdateHomeDevice", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockDeadLetterQueue.AssertExpectations(t)
+}
+
+func TestHandleRequest_DeleteCommand(t *testing.T) {
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+	mockService.On("DeleteHomeDevice", mock.Anything, "device123", int64(0)).Return(&hDResponse.HomdeDeviceResponse{ID: "device123", Version: 2}, nil)
+
+	response := HandleRequest(context.TODO(), buildCommandEvent(`{"op":"delete","version":1,"payload":{"id":"device123"}}`), mockService, mockDeadLetterQueue)
+
+	assert.Empty(t, response.BatchItemFailures)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_DeleteCommandError(t *testing.T) {
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+	mockService.On("DeleteHomeDevice", mock.Anything, "device123", int64(0)).Return(nil, hDError.ErrDeletingDevice.New())
+
+	response := HandleRequest(context.TODO(), buildCommandEvent(`{"op":"delete","version":1,"payload":{"id":"device123"}}`), mockService, mockDeadLetterQueue)
+
+	assert.Equal(t, []events.SQSBatchItemFailure{{ItemIdentifier: "message1"}}, response.BatchItemFailures)
+	mockDeadLetterQueue.AssertNotCalled(t, "Send", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleRequest_MoveCommand(t *testing.T) {
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+	mockService.On("UpdateHomeDevice", mock.Anything, hDRequest.UpdateDeviceRequest{HomeID: "home12345"}, "device123", int64(0)).Return(&hDResponse.HomdeDeviceResponse{ID: "device123", Version: 2}, nil)
+
+	response := HandleRequest(context.TODO(), buildCommandEvent(`{"op":"move","version":1,"payload":{"id":"device123","homeId":"home12345"}}`), mockService, mockDeadLetterQueue)
+
+	assert.Empty(t, response.BatchItemFailures)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_MoveCommandPublishesEvent(t *testing.T) {
+	homeDao := hDDao.NewInMemoryHomeDao()
+	publisher := hDEvent.NewInMemoryEventPublisher()
+	deviceService := hDService.NewHomeDeviceServiceImpl2(hDDao.NewInMemoryHomeDeviceDao(), hDService.WithHomeDao(homeDao), hDService.WithEventPublisher(publisher))
+
+	home, _ := homeDao.SaveHome(context.TODO(), hDRequest.CreateHomeRequest{Name: "Home", Timezone: "UTC"})
+	otherHome, _ := homeDao.SaveHome(context.TODO(), hDRequest.CreateHomeRequest{Name: "Other Home", Timezone: "UTC"})
+	device, _ := deviceService.CreateHomeDevice(context.TODO(), hDRequest.CreateDeviceRequest{MAC: "00:1A:2B:3C:4D:5E", Name: "Living Room Light", Type: "light", HomeID: home.ID})
+
+	response := HandleRequest(context.TODO(), buildCommandEvent(`{"op":"move","version":1,"payload":{"id":"`+device.ID+`","homeId":"`+otherHome.ID+`"}}`), deviceService, new(hDMock.MockDeadLetterQueue))
+
+	assert.Empty(t, response.BatchItemFailures)
+
+	// the same events as a move through the API, with the source of the listener
+	published := publisher.Events()
+	assert.Len(t, published, 2)
+	assert.Equal(t, hDEvent.TypeDeviceMoved, published[1].Type)
+	assert.Equal(t, hDAudit.SourceSQSListener, published[1].Data.Source)
+	assert.Equal(t, home.ID, published[1].Data.Before.HomeID)
+	assert.Equal(t, otherHome.ID, published[1].Data.After.HomeID)
+}
+
+func TestHandleRequest_MoveCommandUnknownHome(t *testing.T) {
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+	mockService.On("UpdateHomeDevice", mock.Anything, hDRequest.UpdateDeviceRequest{HomeID: "home99999"}, "device123", int64(0)).Return(nil, hDError.ErrUnknownHome.New())
+	mockDeadLetterQueue.On("Send", mock.Anything, mock.Anything, hDError.ErrUnknownHome.Code, mock.Anything).Return(nil)
+
+	response := HandleRequest(context.TODO(), buildCommandEvent(`{"op":"move","version":1,"payload":{"id":"device123","homeId":"home99999"}}`), mockService, mockDeadLetterQueue)
+
+	assert.Empty(t, response.BatchItemFailures)
+	mockDeadLetterQueue.AssertExpectations(t)
+}
+
+func TestHandleRequest_MoveCommandWithRoom(t *testing.T) {
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+	roomId := "8d3c1b6e-2f4a-4c51-9a57-0d6f4f1f2b11"
+	mockService.On("UpdateHomeDevice", mock.Anything, hDRequest.UpdateDeviceRequest{HomeID: "home12345", RoomID: roomId}, "device123", int64(0)).Return(&hDResponse.HomdeDeviceResponse{ID: "device123", Version: 2}, nil)
+
+	response := HandleRequest(context.TODO(), buildCommandEvent(`{"op":"move","version":1,"payload":{"id":"device123","homeId":"home12345","roomId":"`+roomId+`"}}`), mockService, mockDeadLetterQueue)
+
+	assert.Empty(t, response.BatchItemFailures)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_MoveCommandUnknownRoom(t *testing.T) {
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+	mockService.On("UpdateHomeDevice", mock.Anything, mock.Anything, "device123", int64(0)).Return(nil, hDError.ErrUnknownRoom.New())
+	mockDeadLetterQueue.On("Send", mock.Anything, mock.Anything, hDError.ErrUnknownRoom.Code, mock.Anything).Return(nil)
+
+	response := HandleRequest(context.TODO(), buildCommandEvent(`{"op":"move","version":1,"payload":{"id":"device123","homeId":"home12345","roomId":"8d3c1b6e-2f4a-4c51-9a57-0d6f4f1f2b11"}}`), mockService, mockDeadLetterQueue)
+
+	assert.Empty(t, response.BatchItemFailures)
+	mockDeadLetterQueue.AssertExpectations(t)
+}
+
+func TestHandleRequest_CommandRejected(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		body   string
+		reason string
+	}{
+		{"unknown op", `{"op":"reboot","version":1,"payload":{"id":"device123"}}`, ReasonUnknownOperation},
+		{"missing op", `{"version":1,"payload":{"id":"device123"}}`, ReasonUnknownOperation},
+		{"unknown version", `{"op":"create","version":2,"payload":{"id":"device123"}}`, ReasonUnsupportedVersion},
+		{"missing version", `{"op":"create","payload":{"id":"device123"}}`, ReasonUnsupportedVersion},
+		{"missing payload", `{"op":"delete","version":1}`, ReasonInvalidMessage},
+		{"invalid payload", `{"op":"delete","version":1,"payload":"device123"}`, ReasonInvalidMessage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(hDMock.MockHomeDeviceService)
+			mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+			mockDeadLetterQueue.On("Send", mock.Anything, mock.Anything, tt.reason, mock.Anything).Return(nil)
+
+			response := HandleRequest(context.TODO(), buildCommandEvent(tt.body), mockService, mockDeadLetterQueue)
+
+			assert.Empty(t, response.BatchItemFailures)
+			assert.Empty(t, mockService.Calls)
+			mockDeadLetterQueue.AssertExpectations(t)
+		})
+	}
+}
+
+func buildCommandEvent(body string) events.SQSEvent {
+	return events.SQSEvent{
+		Records: []events.SQSMessage{
+			{MessageId: "message1", Body: body},
+		},
+	}
+}
+
+func TestHandleRequest_UnregisteredErrorIsRetried(t *testing.T) {
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+	mockService.On("DeleteHomeDevice", mock.Anything, "device123", int64(0)).Return(nil, &hDError.HomeDeviceError{ErrorCode: "ERROR_NOT_REGISTERED"})
+
+	response := HandleRequest(context.TODO(), buildCommandEvent(`{"op":"delete","version":1,"payload":{"id":"device123"}}`), mockService, mockDeadLetterQueue)
+
+	assert.Equal(t, []events.SQSBatchItemFailure{{ItemIdentifier: "message1"}}, response.BatchItemFailures)
+	mockDeadLetterQueue.AssertNotCalled(t, "Send", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -5,18 +5,31 @@ import (
 	"encoding/json"
 	"log"
 
-	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDAudit "github.com/odhoman/home-devices/internal/audit"
+	hDQueue "github.com/odhoman/home-devices/internal/queue"
 	hDService "github.com/odhoman/home-devices/internal/service"
-	hDValidation "github.com/odhoman/home-devices/internal/validation"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/config"
 )
 
+// listenerActor is recorded in the device history when the SQS message does
+// not say who sent it.
+const listenerActor = "homeDeviceListener"
+
+// Reasons sent to the dead-letter queue with the messages that will fail
+// whatever the number of retries.
+const (
+	ReasonInvalidMessage  = "INVALID_MESSAGE"
+	ReasonValidationError = "VALIDATION_ERROR"
+)
+
+// UpdateDeviceSQSMessage moves a device to another home.
 type UpdateDeviceSQSMessage struct {
 	ID     string `json:"id" validate:"required"`
 	HomeID string `json:"homeId" validate:"required,min=5,max=30"`
+	RoomID string `json:"roomId" validate:"omitempty,uuid"`
 }
 
 type Device struct {
@@ -28,45 +41,83 @@ type Device struct {
 	CreatedAt int64  `json:"createdAt"`
 }
 
-func HandleRequest(ctx context.Context, sqsEvent events.SQSEvent, deviceService hDService.HomeDeviceService) {
+// messageError is the reason why a message could not be processed.
+type messageError struct {
+	Reason    string
+	Message   string
+	Permanent bool
+}
+
+// HandleRequest returns the messages that failed and can be retried, so SQS
+// deletes the rest of the batch.
+func HandleRequest(ctx context.Context, sqsEvent events.SQSEvent, deviceService hDService.HomeDeviceService, deadLetterQueue hDQueue.DeadLetterQueue) events.SQSEventResponse {
+
+	response := events.SQSEventResponse{
+		BatchItemFailures: []events.SQSBatchItemFailure{},
+	}
 
 	for _, message := range sqsEvent.Records {
 
-		var updateDeviceSQSMessage UpdateDeviceSQSMessage
-		if err := buildUpdateDeviceSQSMessage(message.Body, &updateDeviceSQSMessage); err != nil {
-			log.Printf("Error parsing SQS message: %v", err)
+		messageErr := processMessage(ctx, message, deviceService)
+		if messageErr == nil {
 			continue
 		}
 
-		if valdationOutput := hDValidation.ValidateAndResponseBadRequestErrors(updateDeviceSQSMessage); len(valdationOutput) > 0 {
-			log.Printf("Validation Errors found in the request message : %v", valdationOutput)
-			continue
+		log.Printf("Error processing SQS message %v (%v): %v", message.MessageId, messageErr.Reason, messageErr.Message)
+
+		if messageErr.Permanent {
+			err := deadLetterQueue.Send(ctx, message, messageErr.Reason, messageErr.Message)
+			if err == nil {
+				continue
+			}
+			log.Printf("Error sending SQS message %v to the dead-letter queue: %v", message.MessageId, err)
 		}
 
-		deviceId := updateDeviceSQSMessage.ID
-		homeId := updateDeviceSQSMessage.HomeID
+		response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{
+			ItemIdentifier: message.MessageId,
+		})
+	}
 
-		if err := deviceService.UpdateHomeDevice(ctx, hDRequest.UpdateDeviceRequest{
-			HomeID: homeId,
-		}, deviceId); err != nil {
-			log.Printf("An error occurred updating a device for id %v - homeId %v: %v", deviceId, homeId, err.ErrorMessage)
-			continue
-		}
+	return response
+}
+
+// processMessage routes the message to the operation of its envelope.
+func processMessage(ctx context.Context, message events.SQSMessage, deviceService hDService.HomeDeviceService) *messageError {
+
+	var command DeviceCommandMessage
+	if err := json.Unmarshal([]byte(message.Body), &command); err != nil {
+		return &messageError{Reason: ReasonInvalidMessage, Message: err.Error(), Permanent: true}
+	}
+
+	auditCtx := hDAudit.WithSource(hDAudit.WithActor(ctx, getSender(message)), hDAudit.SourceSQSListener)
+
+	if command.Op == "" && command.Version == 0 && len(command.Payload) == 0 {
+		return handleMoveDeviceCommand(auditCtx, json.RawMessage(message.Body), deviceService)
 	}
+
+	return processDeviceCommand(auditCtx, command, deviceService)
 }
 
-func buildUpdateDeviceSQSMessage(message string, updateDeviceSQSMessage *UpdateDeviceSQSMessage) error {
-	return json.Unmarshal([]byte(message), &updateDeviceSQSMessage)
+func getSender(message events.SQSMessage) string {
+	if senderId := message.Attributes["SenderId"]; senderId != "" {
+		return senderId
+	}
+	return listenerActor
 }
 
 func main() {
 
-	lambda.Start(func(ctx context.Context, sqsEvent events.SQSEvent) {
+	lambda.Start(func(ctx context.Context, sqsEvent events.SQSEvent) (events.SQSEventResponse, error) {
 		cfg, err := config.LoadDefaultConfig(ctx)
 		if err != nil {
 			log.Fatalf("unable to load SDK config for homeDeviceListener lambda function, %v", err)
 		}
 
-		HandleRequest(ctx, sqsEvent, hDService.NewHomeDeviceServiceImplFromConfig2(cfg))
+		deadLetterQueue, err := hDQueue.NewSQSDeadLetterQueueFromConfig(cfg)
+		if err != nil {
+			log.Fatalf("unable to create the dead-letter queue for homeDeviceListener lambda function, %v", err)
+		}
+
+		return HandleRequest(ctx, sqsEvent, hDService.NewHomeDeviceServiceImplFromConfig2(cfg), deadLetterQueue), nil
 	})
 }
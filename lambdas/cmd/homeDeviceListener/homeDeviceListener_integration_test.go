@@ -7,12 +7,15 @@ import (
 	"fmt"
 
 	"github.com/aws/aws-lambda-go/events"
+
 	"github.com/odhoman/home-devices/internal/dao"
+	hDError "github.com/odhoman/home-devices/internal/error"
 	"github.com/odhoman/home-devices/internal/mock"
 	hDRequest "github.com/odhoman/home-devices/internal/request"
 	hDResponse "github.com/odhoman/home-devices/internal/response"
 	hDService "github.com/odhoman/home-devices/internal/service"
 	"github.com/stretchr/testify/assert"
+	testifyMock "github.com/stretchr/testify/mock"
 )
 
 func TestMain(m *testing.M) {
@@ -35,12 +38,15 @@ func TestHandleRequestUpdate_Success(t *testing.T) {
 	sqsEvent := events.SQSEvent{
 		Records: []events.SQSMessage{
 			{
-				Body: fmt.Sprintf(`{"id":"%s", "homeId":"homeListener"}`, id),
+				MessageId: "message1",
+				Body:      fmt.Sprintf(`{"id":"%s", "homeId":"homeListener"}`, id),
 			},
 		},
 	}
 
-	HandleRequest(ctx, sqsEvent, homeDeviceServiceImpl)
+	response := HandleRequest(ctx, sqsEvent, homeDeviceServiceImpl, new(mock.MockDeadLetterQueue))
+
+	assert.Empty(t, response.BatchItemFailures)
 
 	deviceReturned := GetHomeDeviceForTesting(t, ctx, homeDeviceServiceImpl, id)
 
@@ -49,12 +55,32 @@ func TestHandleRequestUpdate_Success(t *testing.T) {
 
 }
 
+func TestHandleRequestUpdate_DeviceNotFound(t *testing.T) {
+
+	ctx := context.Background()
+	svc := mock.GetDynamoConnectionTestFromEnpoint()
+	homeDeviceServiceImpl := hDService.NewHomeDeviceServiceImpl2(dao.HomeDeviceDaoImpl{DynamoDbApi: svc})
+
+	message := events.SQSMessage{
+		MessageId: "message1",
+		Body:      `{"id":"notFoundDevice", "homeId":"homeListener"}`,
+	}
+
+	deadLetterQueue := new(mock.MockDeadLetterQueue)
+	deadLetterQueue.On("Send", ctx, message, hDError.ErrDeviceNotFound.Code, testifyMock.Anything).Return(nil)
+
+	response := HandleRequest(ctx, events.SQSEvent{Records: []events.SQSMessage{message}}, homeDeviceServiceImpl, deadLetterQueue)
+
+	assert.Empty(t, response.BatchItemFailures)
+	deadLetterQueue.AssertExpectations(t)
+}
+
 func CreateHomeDeviceForTesting(t *testing.T, ctx context.Context, service hDService.HomeDeviceService, device hDRequest.CreateDeviceRequest) *hDResponse.HomdeDeviceResponse {
 
 	deviceCreated, err := service.CreateHomeDevice(ctx, device)
 
 	if err != nil {
-		t.Fatalf("Unexpected error creating new Device for testing. Error: %v", err.ErrorCode)
+		t.Fatalf("Unexpected error creating new Device for testing. Error: %v", err)
 		return nil
 	}
 
@@ -66,7 +92,7 @@ func GetHomeDeviceForTesting(t *testing.T, ctx context.Context, service hDServic
 	deviceReturned, err := service.GetHomeDevice(ctx, id)
 
 	if err != nil {
-		t.Fatalf("Unexpected error creating new Device for testing. Error: %v", err.ErrorCode)
+		t.Fatalf("Unexpected error creating new Device for testing. Error: %v", err)
 		return nil
 	}
 
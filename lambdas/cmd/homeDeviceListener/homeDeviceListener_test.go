@@ -2,86 +2,187 @@ package main
 
 import (
 	"context"
-
+	"errors"
 	"testing"
 
+	hDAudit "github.com/odhoman/home-devices/internal/audit"
 	hDError "github.com/odhoman/home-devices/internal/error"
 	hDMock "github.com/odhoman/home-devices/internal/mock"
 	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
 
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
 func TestHandleRequest_Success(t *testing.T) {
 	mockService := new(hDMock.MockHomeDeviceService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
 
-	mockService.On("UpdateHomeDevice", mock.Anything, hDRequest.UpdateDeviceRequest{HomeID: "home12345"}, "device123").Return(nil)
+	mockService.On("UpdateHomeDevice", mock.Anything, hDRequest.UpdateDeviceRequest{HomeID: "home12345"}, "device123", int64(0)).Return(&hDResponse.HomdeDeviceResponse{ID: "device123", Version: 2}, nil)
 
 	sqsEvent := events.SQSEvent{
 		Records: []events.SQSMessage{
 			{
-				Body: `{"id":"device123", "homeId":"home12345"}`,
+				MessageId: "message1",
+				Body:      `{"id":"device123", "homeId":"home12345"}`,
 			},
 		},
 	}
 
-	HandleRequest(context.TODO(), sqsEvent, mockService)
+	response := HandleRequest(context.TODO(), sqsEvent, mockService, mockDeadLetterQueue)
 
-	mockService.AssertCalled(t, "UpdateHomeDevice", mock.Anything, hDRequest.UpdateDeviceRequest{HomeID: "home12345"}, "device123")
+	assert.Empty(t, response.BatchItemFailures)
+	mockService.AssertCalled(t, "UpdateHomeDevice", mock.Anything, hDRequest.UpdateDeviceRequest{HomeID: "home12345"}, "device123", int64(0))
+	mockDeadLetterQueue.AssertNotCalled(t, "Send", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestHandleRequest_UnmarshalError(t *testing.T) {
 	mockService := new(hDMock.MockHomeDeviceService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+	message := events.SQSMessage{
+		MessageId: "message1",
+		Body:      `{ invalid json }`,
+	}
+
+	mockDeadLetterQueue.On("Send", mock.Anything, message, ReasonInvalidMessage, mock.Anything).Return(nil)
+
+	response := HandleRequest(context.TODO(), events.SQSEvent{Records: []events.SQSMessage{message}}, mockService, mockDeadLetterQueue)
+
+	assert.Empty(t, response.BatchItemFailures)
+	mockService.AssertNotCalled(t, "UpdateHomeDevice")
+	mockDeadLetterQueue.AssertExpectations(t)
+}
+
+func TestHandleRequest_ValidationError(t *testing.T) {
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+	message := events.SQSMessage{
+		MessageId: "message1",
+		Body:      `{"id":"", "homeId":""}`,
+	}
+
+	mockDeadLetterQueue.On("Send", mock.Anything, message, ReasonValidationError, mock.Anything).Return(nil)
+
+	response := HandleRequest(context.TODO(), events.SQSEvent{Records: []events.SQSMessage{message}}, mockService, mockDeadLetterQueue)
+
+	assert.Empty(t, response.BatchItemFailures)
+	mockService.AssertNotCalled(t, "UpdateHomeDevice")
+	mockDeadLetterQueue.AssertExpectations(t)
+}
+
+func TestHandleRequest_DeviceNotFound(t *testing.T) {
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+	mockService.On("UpdateHomeDevice", mock.Anything, hDRequest.UpdateDeviceRequest{HomeID: "home12345"}, "device123", int64(0)).Return(nil, hDError.ErrDeviceNotFound.New())
+
+	message := events.SQSMessage{
+		MessageId: "message1",
+		Body:      `{"id":"device123", "homeId":"home12345"}`,
+	}
+
+	mockDeadLetterQueue.On("Send", mock.Anything, message, hDError.ErrDeviceNotFound.Code, mock.Anything).Return(nil)
+
+	response := HandleRequest(context.TODO(), events.SQSEvent{Records: []events.SQSMessage{message}}, mockService, mockDeadLetterQueue)
+
+	assert.Empty(t, response.BatchItemFailures)
+	mockDeadLetterQueue.AssertExpectations(t)
+}
+
+func TestHandleRequest_UpdateDeviceError(t *testing.T) {
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+	updateError := &hDError.HomeDeviceError{
+		ErrorCode:    "InternalError",
+		ErrorMessage: "Unable to update device",
+	}
+
+	mockService.On("UpdateHomeDevice", mock.Anything, hDRequest.UpdateDeviceRequest{HomeID: "home12345"}, "device123", int64(0)).Return(nil, updateError)
 
 	sqsEvent := events.SQSEvent{
 		Records: []events.SQSMessage{
 			{
-				Body: `{ invalid json }`,
+				MessageId: "message1",
+				Body:      `{"id":"device123", "homeId":"home12345"}`,
 			},
 		},
 	}
 
-	HandleRequest(context.TODO(), sqsEvent, mockService)
+	response := HandleRequest(context.TODO(), sqsEvent, mockService, mockDeadLetterQueue)
 
-	mockService.AssertNotCalled(t, "UpdateHomeDevice")
+	assert.Equal(t, []events.SQSBatchItemFailure{{ItemIdentifier: "message1"}}, response.BatchItemFailures)
+	mockService.AssertCalled(t, "UpdateHomeDevice", mock.Anything, hDRequest.UpdateDeviceRequest{HomeID: "home12345"}, "device123", int64(0))
+	mockDeadLetterQueue.AssertNotCalled(t, "Send", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
-func TestHandleRequest_ValidationError(t *testing.T) {
+func TestHandleRequest_DeadLetterQueueError(t *testing.T) {
 	mockService := new(hDMock.MockHomeDeviceService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
+
+	mockDeadLetterQueue.On("Send", mock.Anything, mock.Anything, ReasonInvalidMessage, mock.Anything).Return(errors.New("throttled"))
 
 	sqsEvent := events.SQSEvent{
 		Records: []events.SQSMessage{
 			{
-				Body: `{"id":"", "homeId":""}`,
+				MessageId: "message1",
+				Body:      `{ invalid json }`,
 			},
 		},
 	}
 
-	HandleRequest(context.TODO(), sqsEvent, mockService)
+	response := HandleRequest(context.TODO(), sqsEvent, mockService, mockDeadLetterQueue)
 
-	mockService.AssertNotCalled(t, "UpdateHomeDevice")
+	assert.Equal(t, []events.SQSBatchItemFailure{{ItemIdentifier: "message1"}}, response.BatchItemFailures)
 }
 
-func TestHandleRequest_UpdateDeviceError(t *testing.T) {
+func TestHandleRequest_PartialBatchFailure(t *testing.T) {
 	mockService := new(hDMock.MockHomeDeviceService)
+	mockDeadLetterQueue := new(hDMock.MockDeadLetterQueue)
 
-	updateError := &hDError.HomeDeviceError{
-		ErrorCode:    "InternalError",
-		ErrorMessage: "Unable to update device",
+	mockService.On("UpdateHomeDevice", mock.Anything, hDRequest.UpdateDeviceRequest{HomeID: "home12345"}, "device1", int64(0)).Return(&hDResponse.HomdeDeviceResponse{ID: "device1", Version: 2}, nil)
+	mockService.On("UpdateHomeDevice", mock.Anything, hDRequest.UpdateDeviceRequest{HomeID: "home12345"}, "device2", int64(0)).Return(nil, hDError.ErrUpdatingDevice.New())
+	mockDeadLetterQueue.On("Send", mock.Anything, mock.Anything, ReasonValidationError, mock.Anything).Return(nil)
+
+	sqsEvent := events.SQSEvent{
+		Records: []events.SQSMessage{
+			{MessageId: "message1", Body: `{"id":"device1", "homeId":"home12345"}`},
+			{MessageId: "message2", Body: `{"id":"device2", "homeId":"home12345"}`},
+			{MessageId: "message3", Body: `{"id":"device3", "homeId":""}`},
+		},
 	}
 
-	mockService.On("UpdateHomeDevice", mock.Anything, hDRequest.UpdateDeviceRequest{HomeID: "home12345"}, "device123").Return(updateError)
+	response := HandleRequest(context.TODO(), sqsEvent, mockService, mockDeadLetterQueue)
+
+	assert.Equal(t, []events.SQSBatchItemFailure{{ItemIdentifier: "message2"}}, response.BatchItemFailures)
+	mockDeadLetterQueue.AssertNumberOfCalls(t, "Send", 1)
+}
+
+func TestHandleRequest_AuditContext(t *testing.T) {
+	mockService := new(hDMock.MockHomeDeviceService)
+
+	mockService.On("UpdateHomeDevice", mock.MatchedBy(func(ctx context.Context) bool {
+		return hDAudit.SourceFromContext(ctx) == hDAudit.SourceSQSListener && hDAudit.ActorFromContext(ctx) == "AIDAEXAMPLE"
+	}), hDRequest.UpdateDeviceRequest{HomeID: "home12345"}, "device123", int64(0)).Return(&hDResponse.HomdeDeviceResponse{ID: "device123", Version: 2}, nil)
 
 	sqsEvent := events.SQSEvent{
 		Records: []events.SQSMessage{
 			{
-				Body: `{"id":"device123", "homeId":"home12345"}`,
+				Body:       `{"id":"device123", "homeId":"home12345"}`,
+				Attributes: map[string]string{"SenderId": "AIDAEXAMPLE"},
 			},
 		},
 	}
 
-	HandleRequest(context.TODO(), sqsEvent, mockService)
+	HandleRequest(context.TODO(), sqsEvent, mockService, new(hDMock.MockDeadLetterQueue))
+
+	mockService.AssertExpectations(t)
+}
 
-	mockService.AssertCalled(t, "UpdateHomeDevice", mock.Anything, hDRequest.UpdateDeviceRequest{HomeID: "home12345"}, "device123")
+func TestGetSender_Default(t *testing.T) {
+	assert.Equal(t, listenerActor, getSender(events.SQSMessage{}))
 }
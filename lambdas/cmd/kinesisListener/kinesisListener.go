@@ -2,27 +2,272 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"log"
+	"strings"
+	"time"
+
+	hDCapability "github.com/odhoman/home-devices/internal/capability"
+	hDConstants "github.com/odhoman/home-devices/internal/constants"
+	hDDao "github.com/odhoman/home-devices/internal/dao"
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDQueue "github.com/odhoman/home-devices/internal/queue"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 )
 
-func HandleRequest(ctx context.Context, kinesisEvent events.KinesisEvent) (string, error) {
-	for _, record := range kinesisEvent.Records {
-		kinesisRecord := record.Kinesis
+// IngestionReport counts what happened to the records of a batch.
+type IngestionReport struct {
+	Received      int `json:"received"`
+	Stored        int `json:"stored"`
+	Heartbeats    int `json:"heartbeats"`
+	Malformed     int `json:"malformed"`
+	UnknownDevice int `json:"unknownDevice"`
+	Rejected      int `json:"rejected"`
+	Failed        int `json:"failed"`
+	RulesFired    int `json:"rulesFired"`
+	RulesFailed   int `json:"rulesFailed"`
+	CameOnline    int `json:"cameOnline"`
+}
+
+// TelemetryServices are the services the records go through: the devices are
+// looked up and marked as seen, their reported state is updated and their
+// rules are evaluated.
+type TelemetryServices struct {
+	Devices     hDService.HomeDeviceService
+	DeviceState hDService.DeviceStateService
+	Rules       hDService.RuleService
+}
+
+// HandleRequest stores the readings of the batch, updates the reported state of
+// the devices with them and evaluates the rules they trigger.
+func HandleRequest(ctx context.Context, kinesisEvent events.KinesisEvent, services TelemetryServices, telemetryDao hDDao.TelemetryDao) events.KinesisEventResponse {
 
-		// Los datos ya están en formato []byte, no necesitas decodificarlos de base64
-		data := kinesisRecord.Data
+	response, report := ingestRecords(ctx, kinesisEvent, services, telemetryDao)
 
-		// Loguear la Partition Key y los datos recibidos
-		log.Printf("Partition Key: %s", kinesisRecord.PartitionKey)
-		log.Printf("Data: %s", string(data))
+	if reportJson, err := json.Marshal(report); err == nil {
+		log.Printf("Telemetry ingestion report: %s", reportJson)
 	}
 
-	return "Processed Kinesis Event", nil
+	return response
+}
+
+func ingestRecords(ctx context.Context, kinesisEvent events.KinesisEvent, services TelemetryServices, telemetryDao hDDao.TelemetryDao) (events.KinesisEventResponse, IngestionReport) {
+
+	// the last time each device of the batch was seen, so each device is
+	// marked once per batch
+	lastSeen := map[string]time.Time{}
+
+	response, report := storeRecords(ctx, kinesisEvent, services, telemetryDao, lastSeen)
+	markDevicesSeen(ctx, services.Devices, lastSeen, &report)
+
+	return response, report
+}
+
+func storeRecords(ctx context.Context, kinesisEvent events.KinesisEvent, services TelemetryServices, telemetryDao hDDao.TelemetryDao, lastSeen map[string]time.Time) (events.KinesisEventResponse, IngestionReport) {
+
+	response := events.KinesisEventResponse{
+		BatchItemFailures: []events.KinesisBatchItemFailure{},
+	}
+	report := IngestionReport{Received: len(kinesisEvent.Records)}
+
+	// devices already looked up in this batch, nil when they do not exist
+	knownDevices := map[string]*hDResponse.HomdeDeviceResponse{}
+
+	for i, record := range kinesisEvent.Records {
+
+		sequenceNumber := record.Kinesis.SequenceNumber
+
+		reading, validationErrors := decodeTelemetryReading(record.Kinesis.Data)
+		if len(validationErrors) > 0 {
+			report.Malformed++
+			log.Printf("Malformed telemetry record %v (partition key %v): %v", sequenceNumber, record.Kinesis.PartitionKey, strings.Join(validationErrors, "; "))
+			continue
+		}
+
+		device, checked := knownDevices[reading.DeviceID]
+		if !checked {
+			var err error
+			device, err = services.Devices.GetHomeDevice(ctx, reading.DeviceID)
+			if err != nil && !errors.Is(err, hDError.ErrDeviceNotFound) {
+				log.Printf("Error getting the device %v of the telemetry record %v: %v", reading.DeviceID, sequenceNumber, err)
+				return failFrom(response, report, kinesisEvent.Records[i:])
+			}
+			knownDevices[reading.DeviceID] = device
+		}
+
+		if device == nil {
+			report.UnknownDevice++
+			log.Printf("Telemetry record %v is for the unknown device %v", sequenceNumber, reading.DeviceID)
+			continue
+		}
+
+		// any record tells that the device is online, even one rejected below
+		recordSeen(lastSeen, device, reading)
+
+		if reading.Type == hDConstants.TelemetryTypeHeartbeat {
+			report.Heartbeats++
+			continue
+		}
+
+		if validationErrors := validateTelemetryReading(device.Type, reading); len(validationErrors) > 0 {
+			report.Rejected++
+			log.Printf("Rejected telemetry record %v for the %v device %v: %v", sequenceNumber, device.Type, reading.DeviceID, strings.Join(validationErrors, "; "))
+			continue
+		}
+
+		if err := telemetryDao.SaveTelemetryReading(ctx, *reading); err != nil {
+			log.Printf("Error saving the telemetry record %v: %v", sequenceNumber, err)
+			return failFrom(response, report, kinesisEvent.Records[i:])
+		}
+
+		// the reported state has the last value of each metric
+		if _, err := services.DeviceState.UpdateDeviceState(ctx, reading.DeviceID, hDRequest.UpdateDeviceStateRequest{
+			Reported: map[string]interface{}{reading.Metric: reportedValue(device.Type, reading)},
+		}); err != nil {
+			log.Printf("Error updating the reported state of the device %v with the telemetry record %v: %v", reading.DeviceID, sequenceNumber, err)
+			return failFrom(response, report, kinesisEvent.Records[i:])
+		}
+
+		// the rules already fired by the record do not fire again when Kinesis
+		// retries it
+		executions, err := services.Rules.EvaluateRules(ctx, *reading)
+		if err != nil {
+			log.Printf("Error evaluating the rules of the device %v with the telemetry record %v: %v", reading.DeviceID, sequenceNumber, err)
+			return failFrom(response, report, kinesisEvent.Records[i:])
+		}
+		countRuleExecutions(&report, executions)
+
+		report.Stored++
+	}
+
+	return response, report
+}
+
+// recordSeen keeps the time of the record when it is the last one of the
+// device.
+func recordSeen(lastSeen map[string]time.Time, device *hDResponse.HomdeDeviceResponse, reading *hDRequest.TelemetryReadingRequest) {
+
+	now := time.Now()
+	seenAt := time.UnixMilli(reading.Timestamp)
+	if seenAt.After(now) {
+		seenAt = now
+	}
+
+	if now.Sub(seenAt) > hDCapability.OfflineAfter(device.Type) {
+		return
+	}
+
+	if seenAt.After(lastSeen[device.ID]) {
+		lastSeen[device.ID] = seenAt
+	}
+}
+
+// markDevicesSeen marks the devices of the batch as seen.
+func markDevicesSeen(ctx context.Context, deviceService hDService.HomeDeviceService, lastSeen map[string]time.Time, report *IngestionReport) {
+
+	for deviceId, seenAt := range lastSeen {
+
+		cameOnline, err := deviceService.MarkDeviceSeen(ctx, deviceId, seenAt)
+		if err != nil {
+			log.Printf("Error marking the device %v as seen: %v", deviceId, err)
+			continue
+		}
+
+		if cameOnline {
+			report.CameOnline++
+		}
+	}
+}
+
+// failFrom reports the first of the records as the failure of the batch.
+func failFrom(response events.KinesisEventResponse, report IngestionReport, records []events.KinesisEventRecord) (events.KinesisEventResponse, IngestionReport) {
+
+	report.Failed = len(records)
+	response.BatchItemFailures = append(response.BatchItemFailures, events.KinesisBatchItemFailure{
+		ItemIdentifier: records[0].Kinesis.SequenceNumber,
+	})
+
+	return response, report
+}
+
+func countRuleExecutions(report *IngestionReport, executions []hDResponse.RuleExecutionResponse) {
+
+	for _, execution := range executions {
+		if execution.Status == hDConstants.RuleExecutionStatusFailed {
+			report.RulesFailed++
+		} else {
+			report.RulesFired++
+		}
+	}
+}
+
+func decodeTelemetryReading(data []byte) (*hDRequest.TelemetryReadingRequest, []string) {
+
+	var reading hDRequest.TelemetryReadingRequest
+	if err := json.Unmarshal(data, &reading); err != nil {
+		return nil, []string{err.Error()}
+	}
+
+	if validationErrors := hDValidation.ValidateDeviceRequestStruct(reading); len(validationErrors) > 0 {
+		return nil, validationErrors
+	}
+
+	return &reading, nil
+}
+
+// validateTelemetryReading checks the reading against the schema of the device
+// type.
+func validateTelemetryReading(deviceType string, reading *hDRequest.TelemetryReadingRequest) []string {
+
+	schema, found := hDCapability.Get(deviceType)
+	if !found {
+		return nil
+	}
+
+	return schema.ValidateReading(reading.Metric, *reading.Value, reading.Unit)
+}
+
+// reportedValue is the value of the reading in the reported state.
+func reportedValue(deviceType string, reading *hDRequest.TelemetryReadingRequest) interface{} {
+
+	schema, found := hDCapability.Get(deviceType)
+	if !found {
+		return *reading.Value
+	}
+
+	return schema.ReadingValue(reading.Metric, *reading.Value)
 }
 
 func main() {
-	lambda.Start(HandleRequest)
+
+	lambda.Start(func(ctx context.Context, kinesisEvent events.KinesisEvent) (events.KinesisEventResponse, error) {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			log.Fatalf("unable to load SDK config for kinesisListener lambda function, %v", err)
+		}
+
+		telemetryDao := hDDao.TelemetryDaoImpl{DynamoDbApi: dynamodb.NewFromConfig(cfg)}
+
+		commandQueue, err := hDQueue.NewSQSCommandQueueFromConfig(cfg)
+		if err != nil {
+			log.Fatalf("unable to create the command queue for kinesisListener lambda function, %v", err)
+		}
+
+		services := TelemetryServices{
+			Devices:     hDService.NewHomeDeviceServiceImplFromConfig2(cfg),
+			DeviceState: hDService.NewDeviceStateServiceImplFromConfig(cfg),
+			Rules:       hDService.NewRuleServiceImplFromConfig(cfg, commandQueue),
+		}
+
+		return HandleRequest(ctx, kinesisEvent, services, telemetryDao), nil
+	})
 }
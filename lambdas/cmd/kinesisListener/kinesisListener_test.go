@@ -0,0 +1,349 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	hDConstants "github.com/odhoman/home-devices/internal/constants"
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mocks := newTelemetryMocks()
+	mockTelemetryDao := new(hDMock.MockTelemetryDao)
+
+	value := 21.5
+	mocks.devices.On("GetHomeDevice", mock.Anything, "device123").Return(&hDResponse.HomdeDeviceResponse{ID: "device123"}, nil)
+	mockTelemetryDao.On("SaveTelemetryReading", mock.Anything, hDRequest.TelemetryReadingRequest{
+		DeviceID:  "device123",
+		Metric:    "temperature",
+		Value:     &value,
+		Unit:      "celsius",
+		Timestamp: 1729000000000,
+	}).Return(nil)
+	mocks.deviceState.On("UpdateDeviceState", mock.Anything, "device123", hDRequest.UpdateDeviceStateRequest{
+		Reported: map[string]interface{}{"temperature": 21.5},
+	}).Return(&hDResponse.DeviceStateResponse{DeviceID: "device123"}, nil)
+	mocks.rules.On("EvaluateRules", mock.Anything, hDRequest.TelemetryReadingRequest{
+		DeviceID:  "device123",
+		Metric:    "temperature",
+		Value:     &value,
+		Unit:      "celsius",
+		Timestamp: 1729000000000,
+	}).Return([]hDResponse.RuleExecutionResponse{}, nil)
+
+	response := HandleRequest(context.TODO(), buildKinesisEvent(
+		`{"deviceId":"device123","metric":"temperature","value":21.5,"unit":"celsius","timestamp":1729000000000}`,
+	), mocks.services(), mockTelemetryDao)
+
+	assert.Empty(t, response.BatchItemFailures)
+	mockTelemetryDao.AssertExpectations(t)
+	mocks.assertExpectations(t)
+}
+
+func TestIngestRecords_SkipsMalformedAndUnknownDevices(t *testing.T) {
+	mocks := newTelemetryMocks()
+	mockTelemetryDao := new(hDMock.MockTelemetryDao)
+
+	mocks.devices.On("GetHomeDevice", mock.Anything, "device123").Return(&hDResponse.HomdeDeviceResponse{ID: "device123"}, nil)
+	mocks.devices.On("GetHomeDevice", mock.Anything, "unknown").Return(nil, hDError.ErrDeviceNotFound.New())
+	mocks.deviceState.On("UpdateDeviceState", mock.Anything, "device123", mock.Anything).Return(&hDResponse.DeviceStateResponse{DeviceID: "device123"}, nil)
+	mocks.rules.On("EvaluateRules", mock.Anything, mock.Anything).Return([]hDResponse.RuleExecutionResponse{}, nil)
+	mockTelemetryDao.On("SaveTelemetryReading", mock.Anything, mock.Anything).Return(nil)
+
+	response, report := ingestRecords(context.TODO(), buildKinesisEvent(
+		`{"deviceId":"device123","metric":"temperature","value":21.5,"timestamp":1729000000000}`,
+		`{ invalid json }`,
+		`{"deviceId":"device123","metric":"temperature","timestamp":1729000000000}`,
+		`{"deviceId":"unknown","metric":"temperature","value":21.5,"timestamp":1729000000000}`,
+		`{"deviceId":"unknown","metric":"humidity","value":40,"timestamp":1729000000000}`,
+		`{"deviceId":"device123","metric":"humidity","value":0,"timestamp":1729000000000}`,
+	), mocks.services(), mockTelemetryDao)
+
+	assert.Empty(t, response.BatchItemFailures)
+	assert.Equal(t, IngestionReport{Received: 6, Stored: 2, Malformed: 2, UnknownDevice: 2}, report)
+	// each device is looked up once per batch
+	mocks.devices.AssertNumberOfCalls(t, "GetHomeDevice", 2)
+	mockTelemetryDao.AssertNumberOfCalls(t, "SaveTelemetryReading", 2)
+}
+
+func TestIngestRecords_SaveError(t *testing.T) {
+	mocks := newTelemetryMocks()
+	mockTelemetryDao := new(hDMock.MockTelemetryDao)
+
+	mocks.devices.On("GetHomeDevice", mock.Anything, "device123").Return(&hDResponse.HomdeDeviceResponse{ID: "device123"}, nil)
+	mocks.deviceState.On("UpdateDeviceState", mock.Anything, "device123", mock.Anything).Return(&hDResponse.DeviceStateResponse{DeviceID: "device123"}, nil)
+	mocks.rules.On("EvaluateRules", mock.Anything, mock.Anything).Return([]hDResponse.RuleExecutionResponse{}, nil)
+	mockTelemetryDao.On("SaveTelemetryReading", mock.Anything, mock.MatchedBy(func(reading hDRequest.TelemetryReadingRequest) bool {
+		return reading.Metric == "temperature"
+	})).Return(nil)
+	mockTelemetryDao.On("SaveTelemetryReading", mock.Anything, mock.MatchedBy(func(reading hDRequest.TelemetryReadingRequest) bool {
+		return reading.Metric == "humidity"
+	})).Return(hDError.ErrSavingTelemetryReading.New())
+
+	response, report := ingestRecords(context.TODO(), buildKinesisEvent(
+		`{"deviceId":"device123","metric":"temperature","value":21.5,"timestamp":1729000000000}`,
+		`{"deviceId":"device123","metric":"humidity","value":40,"timestamp":1729000000000}`,
+		`{"deviceId":"device123","metric":"temperature","value":21.7,"timestamp":1729000001000}`,
+	), mocks.services(), mockTelemetryDao)
+
+	assert.Equal(t, []events.KinesisBatchItemFailure{{ItemIdentifier: "2"}}, response.BatchItemFailures)
+	assert.Equal(t, IngestionReport{Received: 3, Stored: 1, Failed: 2}, report)
+	mockTelemetryDao.AssertNumberOfCalls(t, "SaveTelemetryReading", 2)
+}
+
+func TestIngestRecords_GetDeviceError(t *testing.T) {
+	mocks := newTelemetryMocks()
+	mockTelemetryDao := new(hDMock.MockTelemetryDao)
+
+	mocks.devices.On("GetHomeDevice", mock.Anything, "device123").Return(nil, hDError.ErrGettingDevice.New())
+
+	response, report := ingestRecords(context.TODO(), buildKinesisEvent(
+		`{"deviceId":"device123","metric":"temperature","value":21.5,"timestamp":1729000000000}`,
+	), mocks.services(), mockTelemetryDao)
+
+	assert.Equal(t, []events.KinesisBatchItemFailure{{ItemIdentifier: "1"}}, response.BatchItemFailures)
+	assert.Equal(t, IngestionReport{Received: 1, Failed: 1}, report)
+	mockTelemetryDao.AssertNotCalled(t, "SaveTelemetryReading", mock.Anything, mock.Anything)
+}
+
+func TestIngestRecords_UpdateDeviceStateError(t *testing.T) {
+	mocks := newTelemetryMocks()
+	mockTelemetryDao := new(hDMock.MockTelemetryDao)
+
+	mocks.devices.On("GetHomeDevice", mock.Anything, "device123").Return(&hDResponse.HomdeDeviceResponse{ID: "device123"}, nil)
+	mocks.deviceState.On("UpdateDeviceState", mock.Anything, "device123", mock.Anything).Return(nil, hDError.ErrUpdatingDeviceState.New())
+	mockTelemetryDao.On("SaveTelemetryReading", mock.Anything, mock.Anything).Return(nil)
+
+	response, report := ingestRecords(context.TODO(), buildKinesisEvent(
+		`{"deviceId":"device123","metric":"temperature","value":21.5,"timestamp":1729000000000}`,
+	), mocks.services(), mockTelemetryDao)
+
+	assert.Equal(t, []events.KinesisBatchItemFailure{{ItemIdentifier: "1"}}, response.BatchItemFailures)
+	assert.Equal(t, IngestionReport{Received: 1, Failed: 1}, report)
+}
+
+func TestIngestRecords_RejectsReadingsTheDeviceTypeDoesNotHave(t *testing.T) {
+	mocks := newTelemetryMocks()
+	mockTelemetryDao := new(hDMock.MockTelemetryDao)
+
+	mocks.devices.On("GetHomeDevice", mock.Anything, "sensor123").Return(&hDResponse.HomdeDeviceResponse{ID: "sensor123", Type: "sensor"}, nil)
+	mocks.deviceState.On("UpdateDeviceState", mock.Anything, "sensor123", mock.Anything).Return(&hDResponse.DeviceStateResponse{DeviceID: "sensor123"}, nil)
+	mocks.rules.On("EvaluateRules", mock.Anything, mock.Anything).Return([]hDResponse.RuleExecutionResponse{}, nil)
+	mockTelemetryDao.On("SaveTelemetryReading", mock.Anything, mock.Anything).Return(nil)
+
+	response, report := ingestRecords(context.TODO(), buildKinesisEvent(
+		`{"deviceId":"sensor123","metric":"temperature","value":21.5,"unit":"celsius","timestamp":1729000000000}`,
+		`{"deviceId":"sensor123","metric":"temperature","value":70.7,"unit":"fahrenheit","timestamp":1729000000000}`,
+		`{"deviceId":"sensor123","metric":"humidity","value":140,"timestamp":1729000000000}`,
+		`{"deviceId":"sensor123","metric":"pressure","value":1013,"timestamp":1729000000000}`,
+	), mocks.services(), mockTelemetryDao)
+
+	assert.Empty(t, response.BatchItemFailures)
+	assert.Equal(t, IngestionReport{Received: 4, Stored: 1, Rejected: 3}, report)
+	mockTelemetryDao.AssertNumberOfCalls(t, "SaveTelemetryReading", 1)
+	mocks.deviceState.AssertNumberOfCalls(t, "UpdateDeviceState", 1)
+}
+
+func TestIngestRecords_ReportsBooleanReadingsAsBool(t *testing.T) {
+	mocks := newTelemetryMocks()
+	mockTelemetryDao := new(hDMock.MockTelemetryDao)
+
+	mocks.devices.On("GetHomeDevice", mock.Anything, "sensor123").Return(&hDResponse.HomdeDeviceResponse{ID: "sensor123", Type: "sensor"}, nil)
+	mocks.deviceState.On("UpdateDeviceState", mock.Anything, "sensor123", hDRequest.UpdateDeviceStateRequest{
+		Reported: map[string]interface{}{"motion": true},
+	}).Return(&hDResponse.DeviceStateResponse{DeviceID: "sensor123"}, nil)
+	mocks.rules.On("EvaluateRules", mock.Anything, mock.Anything).Return([]hDResponse.RuleExecutionResponse{}, nil)
+	mockTelemetryDao.On("SaveTelemetryReading", mock.Anything, mock.Anything).Return(nil)
+
+	response, report := ingestRecords(context.TODO(), buildKinesisEvent(
+		`{"deviceId":"sensor123","metric":"motion","value":1,"timestamp":1729000000000}`,
+	), mocks.services(), mockTelemetryDao)
+
+	assert.Empty(t, response.BatchItemFailures)
+	assert.Equal(t, IngestionReport{Received: 1, Stored: 1}, report)
+	mocks.assertExpectations(t)
+}
+
+func TestIngestRecords_CountsTheFiredRules(t *testing.T) {
+	mocks := newTelemetryMocks()
+	mockTelemetryDao := new(hDMock.MockTelemetryDao)
+
+	mocks.devices.On("GetHomeDevice", mock.Anything, "sensor123").Return(&hDResponse.HomdeDeviceResponse{ID: "sensor123", Type: "sensor"}, nil)
+	mocks.deviceState.On("UpdateDeviceState", mock.Anything, "sensor123", mock.Anything).Return(&hDResponse.DeviceStateResponse{DeviceID: "sensor123"}, nil)
+	mocks.rules.On("EvaluateRules", mock.Anything, mock.Anything).Return([]hDResponse.RuleExecutionResponse{
+		{RuleID: "rule1", Status: hDConstants.RuleExecutionStatusFired},
+		{RuleID: "rule2", Status: hDConstants.RuleExecutionStatusFailed},
+		{RuleID: "rule3", Status: hDConstants.RuleExecutionStatusFired},
+	}, nil)
+	mockTelemetryDao.On("SaveTelemetryReading", mock.Anything, mock.Anything).Return(nil)
+
+	response, report := ingestRecords(context.TODO(), buildKinesisEvent(
+		`{"deviceId":"sensor123","metric":"motion","value":1,"timestamp":1729000000000}`,
+	), mocks.services(), mockTelemetryDao)
+
+	assert.Empty(t, response.BatchItemFailures)
+	assert.Equal(t, IngestionReport{Received: 1, Stored: 1, RulesFired: 2, RulesFailed: 1}, report)
+}
+
+func TestIngestRecords_EvaluateRulesError(t *testing.T) {
+	mocks := newTelemetryMocks()
+	mockTelemetryDao := new(hDMock.MockTelemetryDao)
+
+	mocks.devices.On("GetHomeDevice", mock.Anything, "sensor123").Return(&hDResponse.HomdeDeviceResponse{ID: "sensor123", Type: "sensor"}, nil)
+	mocks.deviceState.On("UpdateDeviceState", mock.Anything, "sensor123", mock.Anything).Return(&hDResponse.DeviceStateResponse{DeviceID: "sensor123"}, nil)
+	mocks.rules.On("EvaluateRules", mock.Anything, mock.Anything).Return([]hDResponse.RuleExecutionResponse{}, hDError.ErrListingRules.New())
+	mockTelemetryDao.On("SaveTelemetryReading", mock.Anything, mock.Anything).Return(nil)
+
+	response, report := ingestRecords(context.TODO(), buildKinesisEvent(
+		`{"deviceId":"sensor123","metric":"motion","value":1,"timestamp":1729000000000}`,
+		`{"deviceId":"sensor123","metric":"motion","value":0,"timestamp":1729000001000}`,
+	), mocks.services(), mockTelemetryDao)
+
+	assert.Equal(t, []events.KinesisBatchItemFailure{{ItemIdentifier: "1"}}, response.BatchItemFailures)
+	assert.Equal(t, IngestionReport{Received: 2, Failed: 2}, report)
+}
+
+func TestDecodeTelemetryReading_ValidationErrors(t *testing.T) {
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"missing device id", `{"metric":"temperature","value":21.5,"timestamp":1729000000000}`},
+		{"missing metric", `{"deviceId":"device123","value":21.5,"timestamp":1729000000000}`},
+		{"missing value", `{"deviceId":"device123","metric":"temperature","timestamp":1729000000000}`},
+		{"missing timestamp", `{"deviceId":"device123","metric":"temperature","value":21.5}`},
+		{"negative timestamp", `{"deviceId":"device123","metric":"temperature","value":21.5,"timestamp":-1}`},
+		{"value is not a number", `{"deviceId":"device123","metric":"temperature","value":"21.5","timestamp":1729000000000}`},
+		{"unknown type", `{"type":"status","deviceId":"device123","timestamp":1729000000000}`},
+		{"heartbeat without timestamp", `{"type":"heartbeat","deviceId":"device123"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reading, validationErrors := decodeTelemetryReading([]byte(tt.data))
+
+			assert.Nil(t, reading)
+			assert.NotEmpty(t, validationErrors)
+		})
+	}
+}
+
+func TestDecodeTelemetryReading_Heartbeat(t *testing.T) {
+
+	reading, validationErrors := decodeTelemetryReading([]byte(`{"type":"heartbeat","deviceId":"device123","timestamp":1729000000000}`))
+
+	assert.Empty(t, validationErrors)
+	assert.Equal(t, hDConstants.TelemetryTypeHeartbeat, reading.Type)
+	assert.Nil(t, reading.Value)
+}
+
+func TestIngestRecords_MarksTheDevicesSeen(t *testing.T) {
+	mocks := newTelemetryMocks()
+	mockTelemetryDao := new(hDMock.MockTelemetryDao)
+	now := time.Now().Truncate(time.Millisecond)
+
+	mocks.devices.On("GetHomeDevice", mock.Anything, "light123").Return(&hDResponse.HomdeDeviceResponse{ID: "light123", Type: "light"}, nil)
+	mocks.devices.On("GetHomeDevice", mock.Anything, "sensor123").Return(&hDResponse.HomdeDeviceResponse{ID: "sensor123", Type: "sensor"}, nil)
+	mocks.deviceState.On("UpdateDeviceState", mock.Anything, "sensor123", mock.Anything).Return(&hDResponse.DeviceStateResponse{DeviceID: "sensor123"}, nil)
+	mocks.rules.On("EvaluateRules", mock.Anything, mock.Anything).Return([]hDResponse.RuleExecutionResponse{}, nil)
+	mockTelemetryDao.On("SaveTelemetryReading", mock.Anything, mock.Anything).Return(nil)
+	// each device is marked once, with its last record
+	mocks.devices.On("MarkDeviceSeen", mock.Anything, "light123", now).Return(true, nil).Once()
+	mocks.devices.On("MarkDeviceSeen", mock.Anything, "sensor123", now.Add(-time.Second)).Return(false, nil).Once()
+
+	response, report := ingestRecords(context.TODO(), buildKinesisEvent(
+		fmt.Sprintf(`{"type":"heartbeat","deviceId":"light123","timestamp":%d}`, now.Add(-time.Minute).UnixMilli()),
+		fmt.Sprintf(`{"type":"heartbeat","deviceId":"light123","timestamp":%d}`, now.UnixMilli()),
+		fmt.Sprintf(`{"deviceId":"sensor123","metric":"temperature","value":21.5,"timestamp":%d}`, now.Add(-time.Second).UnixMilli()),
+		// a rejected reading is seen too
+		fmt.Sprintf(`{"deviceId":"sensor123","metric":"pressure","value":1013,"timestamp":%d}`, now.Add(-2*time.Second).UnixMilli()),
+	), mocks.services(), mockTelemetryDao)
+
+	assert.Empty(t, response.BatchItemFailures)
+	assert.Equal(t, IngestionReport{Received: 4, Stored: 1, Heartbeats: 2, Rejected: 1, CameOnline: 1}, report)
+	mocks.assertExpectations(t)
+	// the heartbeats are not stored
+	mockTelemetryDao.AssertNumberOfCalls(t, "SaveTelemetryReading", 1)
+}
+
+func TestIngestRecords_OldRecordsDoNotMarkTheDevicesSeen(t *testing.T) {
+	mocks := newTelemetryMocks()
+	mockTelemetryDao := new(hDMock.MockTelemetryDao)
+
+	mocks.devices.On("GetHomeDevice", mock.Anything, "light123").Return(&hDResponse.HomdeDeviceResponse{ID: "light123", Type: "light"}, nil)
+
+	// the light is offline after 5 minutes
+	response, report := ingestRecords(context.TODO(), buildKinesisEvent(
+		fmt.Sprintf(`{"type":"heartbeat","deviceId":"light123","timestamp":%d}`, time.Now().Add(-6*time.Minute).UnixMilli()),
+	), mocks.services(), mockTelemetryDao)
+
+	assert.Empty(t, response.BatchItemFailures)
+	assert.Equal(t, IngestionReport{Received: 1, Heartbeats: 1}, report)
+	mocks.devices.AssertNotCalled(t, "MarkDeviceSeen", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestIngestRecords_MarkDeviceSeenErrorDoesNotFailTheBatch(t *testing.T) {
+	mocks := newTelemetryMocks()
+	mockTelemetryDao := new(hDMock.MockTelemetryDao)
+
+	mocks.devices.On("GetHomeDevice", mock.Anything, "light123").Return(&hDResponse.HomdeDeviceResponse{ID: "light123", Type: "light"}, nil)
+	mocks.devices.On("MarkDeviceSeen", mock.Anything, "light123", mock.Anything).Return(false, hDError.ErrUpdatingDevice.New())
+
+	response, report := ingestRecords(context.TODO(), buildKinesisEvent(
+		fmt.Sprintf(`{"type":"heartbeat","deviceId":"light123","timestamp":%d}`, time.Now().UnixMilli()),
+	), mocks.services(), mockTelemetryDao)
+
+	assert.Empty(t, response.BatchItemFailures)
+	assert.Equal(t, IngestionReport{Received: 1, Heartbeats: 1}, report)
+}
+
+func buildKinesisEvent(data ...string) events.KinesisEvent {
+
+	records := make([]events.KinesisEventRecord, 0, len(data))
+	for i, value := range data {
+		record := events.KinesisEventRecord{}
+		record.Kinesis.SequenceNumber = strconv.Itoa(i + 1)
+		record.Kinesis.PartitionKey = "partition"
+		record.Kinesis.Data = []byte(value)
+		records = append(records, record)
+	}
+
+	return events.KinesisEvent{Records: records}
+}
+
+// telemetryMocks mocks each of the TelemetryServices.
+type telemetryMocks struct {
+	devices     *hDMock.MockHomeDeviceService
+	deviceState *hDMock.MockDeviceStateService
+	rules       *hDMock.MockRuleService
+}
+
+func newTelemetryMocks() telemetryMocks {
+	return telemetryMocks{
+		devices:     new(hDMock.MockHomeDeviceService),
+		deviceState: new(hDMock.MockDeviceStateService),
+		rules:       new(hDMock.MockRuleService),
+	}
+}
+
+func (mocks telemetryMocks) services() TelemetryServices {
+	return TelemetryServices{Devices: mocks.devices, DeviceState: mocks.deviceState, Rules: mocks.rules}
+}
+
+func (mocks telemetryMocks) assertExpectations(t *testing.T) {
+	mocks.devices.AssertExpectations(t)
+	mocks.deviceState.AssertExpectations(t)
+	mocks.rules.AssertExpectations(t)
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"context"
+
+	hDHandler "github.com/odhoman/home-devices/internal/handler"
+	hDService "github.com/odhoman/home-devices/internal/service"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func HandleRequest(ctx context.Context, deviceTypeService hDService.DeviceTypeService) (events.APIGatewayProxyResponse, error) {
+	return hDHandler.ListDeviceTypes(ctx, deviceTypeService)
+}
+
+func main() {
+
+	lambda.Start(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return hDHandler.WithErrorNegotiation(hDHandler.ListDeviceTypesFromAPIGateway)(ctx, request, hDService.NewDeviceTypeServiceImpl())
+	})
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	hDCapability "github.com/odhoman/home-devices/internal/capability"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockDeviceTypeService)
+
+	light, _ := hDCapability.Get("light")
+	deviceTypes := &hDResponse.DeviceTypeListResponse{DeviceTypes: []hDCapability.DeviceType{light}}
+
+	mockService.On("ListDeviceTypes", mock.Anything).Return(deviceTypes)
+
+	response, err := HandleRequest(context.TODO(), mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	expectedBody, _ := json.Marshal(deviceTypes)
+	assert.JSONEq(t, string(expectedBody), response.Body)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_AllTheDeviceTypes(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), hDService.NewDeviceTypeServiceImpl())
+
+	var deviceTypes hDResponse.DeviceTypeListResponse
+	assert.NoError(t, json.Unmarshal([]byte(response.Body), &deviceTypes))
+
+	names := []string{}
+	for _, deviceType := range deviceTypes.DeviceTypes {
+		names = append(names, deviceType.Name)
+	}
+	assert.Equal(t, hDCapability.Names(), names)
+	assert.Contains(t, response.Body, `"unit":"percent"`)
+}
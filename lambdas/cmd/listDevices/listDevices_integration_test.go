@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+	"github.com/odhoman/home-devices/internal/mock"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	mock.RunTestMain(m)
+}
+
+func TestListDevices_Pagination(t *testing.T) {
+
+	ctx := context.Background()
+	svc := mock.GetDynamoConnectionTestFromEnpoint()
+	homeDeviceServiceImpl := hDService.NewHomeDeviceServiceImpl2(dao.HomeDeviceDaoImpl{DynamoDbApi: svc})
+
+	macs := []string{"10:1A:2B:3C:4D:01", "10:1A:2B:3C:4D:02", "10:1A:2B:3C:4D:03"}
+	for _, mac := range macs {
+		if _, err := homeDeviceServiceImpl.CreateHomeDevice(ctx, hDRequest.CreateDeviceRequest{
+			MAC:    mac,
+			Name:   "Living Room Light",
+			Type:   "light",
+			HomeID: "homeListDevices",
+		}); err != nil {
+			t.Fatalf("Unexpected error creating new Device for testing. Error: %v", err)
+		}
+	}
+
+	firstPage := listDevicesForTesting(t, ctx, homeDeviceServiceImpl, hDRequest.ListDevicesRequest{HomeID: "homeListDevices", Limit: 2})
+
+	assert.Len(t, firstPage.Devices, 2)
+	assert.NotEmpty(t, firstPage.NextCursor)
+
+	secondPage := listDevicesForTesting(t, ctx, homeDeviceServiceImpl, hDRequest.ListDevicesRequest{HomeID: "homeListDevices", Limit: 2, Cursor: firstPage.NextCursor})
+
+	assert.Len(t, secondPage.Devices, 1)
+	assert.Empty(t, secondPage.NextCursor)
+}
+
+func TestListDevices_InvalidCursor(t *testing.T) {
+
+	svc := mock.GetDynamoConnectionTestFromEnpoint()
+	homeDeviceServiceImpl := hDService.NewHomeDeviceServiceImpl2(dao.HomeDeviceDaoImpl{DynamoDbApi: svc})
+
+	response, err := HandleRequest(context.Background(), hDRequest.ListDevicesRequest{HomeID: "homeListDevices", Cursor: "wrongCursor"}, homeDeviceServiceImpl)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Invalid cursor")
+}
+
+func listDevicesForTesting(t *testing.T, ctx context.Context, service hDService.HomeDeviceService, request hDRequest.ListDevicesRequest) *hDResponse.HomeDeviceListResponse {
+
+	response, err := HandleRequest(ctx, request, service)
+
+	if err != nil || response.StatusCode != 200 {
+		t.Fatalf("Unexpected error listing Devices for testing. Response: %v", response.Body)
+		return nil
+	}
+
+	var devices hDResponse.HomeDeviceListResponse
+	if err := json.Unmarshal([]byte(response.Body), &devices); err != nil {
+		t.Fatalf("Unexpected error deserializing the list of Devices. Error: %v", err)
+	}
+
+	return &devices
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockHomeDeviceService)
+
+	request := hDRequest.ListDevicesRequest{
+		HomeID: "home12122",
+		Limit:  2,
+	}
+
+	devices := &hDResponse.HomeDeviceListResponse{
+		Devices: []hDResponse.HomdeDeviceResponse{
+			{
+				ID:         uuid.New().String(),
+				MAC:        "00:1A:2B:3C:4D:5E",
+				Name:       "Living Room Light",
+				Type:       "light",
+				HomeID:     request.HomeID,
+				CreatedAt:  time.Now().Unix(),
+				ModifiedAt: time.Now().Unix(),
+			},
+		},
+		NextCursor: "nextCursor",
+	}
+
+	mockService.On("ListHomeDevices", mock.Anything, request.HomeID, request.RoomID, request.Status, request.Limit, request.Cursor).Return(devices, nil)
+
+	response, err := HandleRequest(context.TODO(), request, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	expectedBody, _ := json.Marshal(devices)
+	assert.JSONEq(t, string(expectedBody), response.Body)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_ValidationError(t *testing.T) {
+
+	request := hDRequest.ListDevicesRequest{
+		HomeID: "home",
+		Limit:  500,
+	}
+
+	response, _ := HandleRequest(context.TODO(), request, new(hDMock.MockHomeDeviceService))
+
+	assert.Equal(t, 400, response.StatusCode)
+
+	assert.Contains(t, response.Body, "Home ID must be between 5 and 30 characters")
+	assert.Contains(t, response.Body, "Limit must be between 1 and 100")
+}
+
+func TestHandleRequest_InvalidCursor(t *testing.T) {
+
+	request := hDRequest.ListDevicesRequest{
+		HomeID: "home12122",
+		Cursor: "wrongCursor",
+	}
+
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockService.On("ListHomeDevices", mock.Anything, request.HomeID, request.RoomID, request.Status, request.Limit, request.Cursor).Return(nil, hDError.ErrInvalidCursor.New())
+
+	response, _ := HandleRequest(context.TODO(), request, mockService)
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Invalid cursor")
+}
+
+func TestHandleRequest_InternalServerError(t *testing.T) {
+
+	request := hDRequest.ListDevicesRequest{
+		HomeID: "home12122",
+	}
+
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockService.On("ListHomeDevices", mock.Anything, request.HomeID, request.RoomID, request.Status, request.Limit, request.Cursor).Return(nil, hDError.ErrListingDevices.New())
+
+	response, _ := HandleRequest(context.TODO(), request, mockService)
+
+	assert.Equal(t, 500, response.StatusCode)
+	assert.Contains(t, response.Body, "Internal Server error listing the devices")
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockRoomService)
+
+	rooms := &hDResponse.RoomListResponse{Rooms: []hDResponse.RoomResponse{
+		{ID: "8d3c1b6e-2f4a-4c51-9a57-0d6f4f1f2b11", HomeID: "home12122", Name: "Kitchen", Version: 1},
+	}}
+
+	mockService.On("ListRooms", mock.Anything, "home12122").Return(rooms, nil)
+
+	response, err := HandleRequest(context.TODO(), "home12122", mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	expectedBody, _ := json.Marshal(rooms)
+	assert.JSONEq(t, string(expectedBody), response.Body)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_EmptyHomeId(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), "", new(hDMock.MockRoomService))
+
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestHandleRequest_Error(t *testing.T) {
+	mockService := new(hDMock.MockRoomService)
+
+	mockService.On("ListRooms", mock.Anything, "home12122").Return(nil, hDError.ErrListingRooms.New())
+
+	response, _ := HandleRequest(context.TODO(), "home12122", mockService)
+
+	assert.Equal(t, 500, response.StatusCode)
+	assert.Contains(t, response.Body, "Internal Server error listing the rooms")
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	hDConstants "github.com/odhoman/home-devices/internal/constants"
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockRuleService)
+
+	executions := &hDResponse.RuleExecutionListResponse{Executions: []hDResponse.RuleExecutionResponse{
+		{ID: "1700000000000000000#c1", RuleID: "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", DeviceID: "sensor-1", Metric: "motion", Value: 1, Status: hDConstants.RuleExecutionStatusFired, CommandID: "d2f1", ExecutedAt: 1700000000},
+	}}
+
+	mockService.On("ListRuleExecutions", mock.Anything, "home12122", "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88").Return(executions, nil)
+
+	response, err := HandleRequest(context.TODO(), "home12122", "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	expectedBody, _ := json.Marshal(executions)
+	assert.JSONEq(t, string(expectedBody), response.Body)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_EmptyRuleId(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), "home12122", "", new(hDMock.MockRuleService))
+
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestHandleRequest_Errors(t *testing.T) {
+
+	tests := []struct {
+		err        error
+		statusCode int
+		message    string
+	}{
+		{hDError.ErrRuleNotFound.New(), 404, "Rule Not Found"},
+		{hDError.ErrListingRuleExecutions.New(), 500, "Internal Server error listing the rule executions"},
+	}
+
+	for _, test := range tests {
+		t.Run(hDError.From(test.err).ErrorCode, func(t *testing.T) {
+			mockService := new(hDMock.MockRuleService)
+			mockService.On("ListRuleExecutions", mock.Anything, "home12122", "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88").Return(nil, test.err)
+
+			response, _ := HandleRequest(context.TODO(), "home12122", "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", mockService)
+
+			assert.Equal(t, test.statusCode, response.StatusCode)
+			assert.Contains(t, response.Body, test.message)
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	hDHandler "github.com/odhoman/home-devices/internal/handler"
+	hDService "github.com/odhoman/home-devices/internal/service"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+func HandleRequest(ctx context.Context, homeId string, ruleService hDService.RuleService) (events.APIGatewayProxyResponse, error) {
+	return hDHandler.ListRules(ctx, homeId, ruleService)
+}
+
+func main() {
+
+	lambda.Start(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			log.Fatalf("unable to load SDK config for listRules lambda function, %v", err)
+		}
+
+		return hDHandler.WithErrorNegotiation(hDHandler.ListRulesFromAPIGateway)(ctx, request, hDService.NewRuleServiceImplFromConfig(cfg, nil))
+	})
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockSceneService)
+
+	scenes := &hDResponse.SceneListResponse{Scenes: []hDResponse.SceneResponse{
+		{ID: "2f6c8d1a-4b3e-4f7a-8c9d-0e1f2a3b4c5d", HomeID: "home12122", Name: "Movie night", Devices: []hDResponse.SceneDeviceResponse{
+			{DeviceID: "light-1", State: map[string]interface{}{"on": true}},
+		}, Version: 1},
+	}}
+
+	mockService.On("ListScenes", mock.Anything, "home12122").Return(scenes, nil)
+
+	response, err := HandleRequest(context.TODO(), "home12122", mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	expectedBody, _ := json.Marshal(scenes)
+	assert.JSONEq(t, string(expectedBody), response.Body)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_EmptyHomeId(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), "", new(hDMock.MockSceneService))
+
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestHandleRequest_Error(t *testing.T) {
+	mockService := new(hDMock.MockSceneService)
+
+	mockService.On("ListScenes", mock.Anything, "home12122").Return(nil, hDError.ErrListingScenes.New())
+
+	response, _ := HandleRequest(context.TODO(), "home12122", mockService)
+
+	assert.Equal(t, 500, response.StatusCode)
+	assert.Contains(t, response.Body, "Internal Server error listing the scenes")
+}
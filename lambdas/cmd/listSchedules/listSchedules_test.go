@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockScheduleService)
+
+	schedules := &hDResponse.ScheduleListResponse{Schedules: []hDResponse.ScheduleResponse{
+		{ID: "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", HomeID: "home12122", Name: "Lights off at midnight", Enabled: true, Cron: "0 0 * * *", Timezone: "Europe/Madrid", Version: 1},
+	}}
+
+	mockService.On("ListSchedules", mock.Anything, "home12122").Return(schedules, nil)
+
+	response, err := HandleRequest(context.TODO(), "home12122", mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	expectedBody, _ := json.Marshal(schedules)
+	assert.JSONEq(t, string(expectedBody), response.Body)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_EmptyHomeId(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), "", new(hDMock.MockScheduleService))
+
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestHandleRequest_Error(t *testing.T) {
+	mockService := new(hDMock.MockScheduleService)
+
+	mockService.On("ListSchedules", mock.Anything, "home12122").Return(nil, hDError.ErrListingSchedules.New())
+
+	response, _ := HandleRequest(context.TODO(), "home12122", mockService)
+
+	assert.Equal(t, 500, response.StatusCode)
+	assert.Contains(t, response.Body, "Internal Server error listing the schedules")
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/base64"
+	"io"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/odhoman/home-devices/internal/constants"
+	hDHandler "github.com/odhoman/home-devices/internal/handler"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/google/uuid"
+)
+
+// newAPIGatewayHTTPHandler serves a lambda behind net/http, with its errors
+// negotiated like the deployed lambdas.
+func newAPIGatewayHTTPHandler[S any](handler hDHandler.APIGatewayHandler[S], resource string, service S, pathParameters ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		request, err := toAPIGatewayProxyRequest(r, resource, pathParameters)
+		if err != nil {
+			log.Printf("Error reading the request body: %v", err)
+			writeAPIGatewayProxyResponse(w, hDResponse.NegotiateErrorResponse(hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage("Invalid request body"), r.Header.Get("Accept"), ""))
+			return
+		}
+
+		response, err := hDHandler.WithErrorNegotiation(handler)(r.Context(), request, service)
+		if err != nil {
+			// API Gateway answers with a 502 when the lambda returns an error
+			log.Printf("Error handling %v %v: %v", r.Method, r.URL.Path, err)
+			writeAPIGatewayProxyResponse(w, events.APIGatewayProxyResponse{
+				StatusCode: http.StatusBadGateway,
+				Headers:    map[string]string{"Content-Type": "application/json"},
+				Body:       `{"message": "Internal server error"}`,
+			})
+			return
+		}
+
+		writeAPIGatewayProxyResponse(w, response)
+	})
+}
+
+// localAdminHeader plays the admin value of the Lambda authorizer, since the
+// local server has no authorizer.
+const localAdminHeader = "X-Local-Admin"
+
+func toAPIGatewayProxyRequest(r *http.Request, resource string, pathParameters []string) (events.APIGatewayProxyRequest, error) {
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return events.APIGatewayProxyRequest{}, err
+	}
+
+	request := events.APIGatewayProxyRequest{
+		Resource:                        resource,
+		Path:                            r.URL.Path,
+		HTTPMethod:                      r.Method,
+		Headers:                         map[string]string{},
+		MultiValueHeaders:               map[string][]string{},
+		QueryStringParameters:           map[string]string{},
+		MultiValueQueryStringParameters: map[string][]string{},
+		PathParameters:                  map[string]string{},
+		Body:                            string(body),
+		RequestContext: events.APIGatewayProxyRequestContext{
+			RequestID:  uuid.New().String(),
+			Stage:      "local",
+			Path:       r.URL.Path,
+			HTTPMethod: r.Method,
+			Identity: events.APIGatewayRequestIdentity{
+				SourceIP:  sourceIP(r.RemoteAddr),
+				UserAgent: r.UserAgent(),
+			},
+		},
+	}
+
+	for name, values := range r.Header {
+		request.Headers[name] = values[len(values)-1]
+		request.MultiValueHeaders[name] = values
+	}
+
+	if r.Header.Get(localAdminHeader) == "true" {
+		request.RequestContext.Authorizer = map[string]interface{}{constants.AdminGroup: "true"}
+	}
+
+	for name, values := range r.URL.Query() {
+		request.QueryStringParameters[name] = values[len(values)-1]
+		request.MultiValueQueryStringParameters[name] = values
+	}
+
+	for _, name := range pathParameters {
+		request.PathParameters[name] = r.PathValue(name)
+	}
+
+	return request, nil
+}
+
+func writeAPIGatewayProxyResponse(w http.ResponseWriter, response events.APIGatewayProxyResponse) {
+
+	for name, value := range response.Headers {
+		w.Header().Set(name, value)
+	}
+
+	for name, values := range response.MultiValueHeaders {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+
+	body := []byte(response.Body)
+	if response.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(response.Body)
+		if err != nil {
+			log.Printf("Error decoding the base64 response body: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body = decoded
+	}
+
+	w.WriteHeader(response.StatusCode)
+
+	if _, err := w.Write(body); err != nil {
+		log.Printf("Error writing the response body: %v", err)
+	}
+}
+
+func sourceIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	hDConstants "github.com/odhoman/home-devices/internal/constants"
+	hDDao "github.com/odhoman/home-devices/internal/dao"
+	hDEvent "github.com/odhoman/home-devices/internal/event"
+	hDHandler "github.com/odhoman/home-devices/internal/handler"
+	hDQueue "github.com/odhoman/home-devices/internal/queue"
+	hDService "github.com/odhoman/home-devices/internal/service"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+const (
+	memoryStore   = "memory"
+	dynamoDbStore = "dynamodb"
+)
+
+// localServer serves the device lambdas on the same routes as API Gateway,
+// so the API can be used without deploying the stack.
+func main() {
+
+	addr := flag.String("addr", ":8080", "address to listen on")
+	store := flag.String("store", memoryStore, "where the devices are kept: memory or dynamodb")
+	dynamoDbEndpoint := flag.String("dynamodb-endpoint", "http://localhost:8000", "DynamoDB endpoint used by the dynamodb store, e.g. DynamoDB Local")
+	allowOrigin := flag.String("allow-origin", "", "value of the Access-Control-Allow-Origin header, CORS is disabled when empty")
+	flag.Parse()
+
+	services, err := newServices(context.Background(), *store, *dynamoDbEndpoint)
+	if err != nil {
+		log.Fatalf("unable to create the services for localServer, %v", err)
+	}
+
+	log.Printf("localServer listening on %v with the %v store", *addr, *store)
+
+	if err := http.ListenAndServe(*addr, withCORS(newRouter(services), *allowOrigin)); err != nil {
+		log.Fatalf("localServer stopped, %v", err)
+	}
+}
+
+func newRouter(services services) http.Handler {
+
+	mux := http.NewServeMux()
+
+	mux.Handle("POST /v1/device", newAPIGatewayHTTPHandler(hDHandler.CreateDeviceFromAPIGateway, "/v1/device", services.devices))
+	mux.Handle("GET /v1/device/{id}", newAPIGatewayHTTPHandler(hDHandler.GetDeviceFromAPIGateway, "/v1/device/{id}", services.devices, "id"))
+	mux.Handle("PUT /v1/device/{id}", newAPIGatewayHTTPHandler(hDHandler.UpdateDeviceFromAPIGateway, "/v1/device/{id}", services.devices, "id"))
+	mux.Handle("DELETE /v1/device/{id}", newAPIGatewayHTTPHandler(hDHandler.DeleteDeviceFromAPIGateway, "/v1/device/{id}", services.devices, "id"))
+	mux.Handle("POST /v1/device/{id}/restore", newAPIGatewayHTTPHandler(hDHandler.RestoreDeviceFromAPIGateway, "/v1/device/{id}/restore", services.devices, "id"))
+	mux.Handle("GET /v1/device/{id}/history", newAPIGatewayHTTPHandler(hDHandler.GetDeviceHistoryFromAPIGateway, "/v1/device/{id}/history", services.devices, "id"))
+	mux.Handle("GET /v1/device/{id}/state", newAPIGatewayHTTPHandler(hDHandler.GetDeviceStateFromAPIGateway, "/v1/device/{id}/state", services.deviceStates, "id"))
+	mux.Handle("PATCH /v1/device/{id}/state", newAPIGatewayHTTPHandler(hDHandler.UpdateDeviceStateFromAPIGateway, "/v1/device/{id}/state", services.deviceStates, "id"))
+	mux.Handle("POST /v1/device/{id}/commands", newAPIGatewayHTTPHandler(hDHandler.SendDeviceCommandFromAPIGateway, "/v1/device/{id}/commands", services.deviceCommands, "id"))
+	mux.Handle("GET /v1/device/{id}/commands/{cmdId}", newAPIGatewayHTTPHandler(hDHandler.GetDeviceCommandFromAPIGateway, "/v1/device/{id}/commands/{cmdId}", services.deviceCommands, "id", "cmdId"))
+	mux.Handle("POST /v1/home", newAPIGatewayHTTPHandler(hDHandler.CreateHomeFromAPIGateway, "/v1/home", services.homes))
+	mux.Handle("GET /v1/home/{homeId}", newAPIGatewayHTTPHandler(hDHandler.GetHomeFromAPIGateway, "/v1/home/{homeId}", services.homes, "homeId"))
+	mux.Handle("PUT /v1/home/{homeId}", newAPIGatewayHTTPHandler(hDHandler.UpdateHomeFromAPIGateway, "/v1/home/{homeId}", services.homes, "homeId"))
+	mux.Handle("DELETE /v1/home/{homeId}", newAPIGatewayHTTPHandler(hDHandler.DeleteHomeFromAPIGateway, "/v1/home/{homeId}", services.homes, "homeId"))
+	mux.Handle("POST /v1/home/{homeId}/rooms", newAPIGatewayHTTPHandler(hDHandler.CreateRoomFromAPIGateway, "/v1/home/{homeId}/rooms", services.rooms, "homeId"))
+	mux.Handle("GET /v1/home/{homeId}/rooms", newAPIGatewayHTTPHandler(hDHandler.ListRoomsFromAPIGateway, "/v1/home/{homeId}/rooms", services.rooms, "homeId"))
+	mux.Handle("GET /v1/home/{homeId}/rooms/{roomId}", newAPIGatewayHTTPHandler(hDHandler.GetRoomFromAPIGateway, "/v1/home/{homeId}/rooms/{roomId}", services.rooms, "homeId", "roomId"))
+	mux.Handle("PUT /v1/home/{homeId}/rooms/{roomId}", newAPIGatewayHTTPHandler(hDHandler.UpdateRoomFromAPIGateway, "/v1/home/{homeId}/rooms/{roomId}", services.rooms, "homeId", "roomId"))
+	mux.Handle("DELETE /v1/home/{homeId}/rooms/{roomId}", newAPIGatewayHTTPHandler(hDHandler.DeleteRoomFromAPIGateway, "/v1/home/{homeId}/rooms/{roomId}", services.rooms, "homeId", "roomId"))
+	mux.Handle("POST /v1/home/{homeId}/rules", newAPIGatewayHTTPHandler(hDHandler.CreateRuleFromAPIGateway, "/v1/home/{homeId}/rules", services.rules, "homeId"))
+	mux.Handle("GET /v1/home/{homeId}/rules", newAPIGatewayHTTPHandler(hDHandler.ListRulesFromAPIGateway, "/v1/home/{homeId}/rules", services.rules, "homeId"))
+	mux.Handle("GET /v1/home/{homeId}/rules/{ruleId}", newAPIGatewayHTTPHandler(hDHandler.GetRuleFromAPIGateway, "/v1/home/{homeId}/rules/{ruleId}", services.rules, "homeId", "ruleId"))
+	mux.Handle("PUT /v1/home/{homeId}/rules/{ruleId}", newAPIGatewayHTTPHandler(hDHandler.UpdateRuleFromAPIGateway, "/v1/home/{homeId}/rules/{ruleId}", services.rules, "homeId", "ruleId"))
+	mux.Handle("DELETE /v1/home/{homeId}/rules/{ruleId}", newAPIGatewayHTTPHandler(hDHandler.DeleteRuleFromAPIGateway, "/v1/home/{homeId}/rules/{ruleId}", services.rules, "homeId", "ruleId"))
+	mux.Handle("GET /v1/home/{homeId}/rules/{ruleId}/executions", newAPIGatewayHTTPHandler(hDHandler.ListRuleExecutionsFromAPIGateway, "/v1/home/{homeId}/rules/{ruleId}/executions", services.rules, "homeId", "ruleId"))
+	mux.Handle("POST /v1/home/{homeId}/schedules", newAPIGatewayHTTPHandler(hDHandler.CreateScheduleFromAPIGateway, "/v1/home/{homeId}/schedules", services.schedules, "homeId"))
+	mux.Handle("GET /v1/home/{homeId}/schedules", newAPIGatewayHTTPHandler(hDHandler.ListSchedulesFromAPIGateway, "/v1/home/{homeId}/schedules", services.schedules, "homeId"))
+	mux.Handle("GET /v1/home/{homeId}/schedules/{scheduleId}", newAPIGatewayHTTPHandler(hDHandler.GetScheduleFromAPIGateway, "/v1/home/{homeId}/schedules/{scheduleId}", services.schedules, "homeId", "scheduleId"))
+	mux.Handle("PUT /v1/home/{homeId}/schedules/{scheduleId}", newAPIGatewayHTTPHandler(hDHandler.UpdateScheduleFromAPIGateway, "/v1/home/{homeId}/schedules/{scheduleId}", services.schedules, "homeId", "scheduleId"))
+	mux.Handle("DELETE /v1/home/{homeId}/schedules/{scheduleId}", newAPIGatewayHTTPHandler(hDHandler.DeleteScheduleFromAPIGateway, "/v1/home/{homeId}/schedules/{scheduleId}", services.schedules, "homeId", "scheduleId"))
+	mux.Handle("POST /v1/home/{homeId}/scenes", newAPIGatewayHTTPHandler(hDHandler.CreateSceneFromAPIGateway, "/v1/home/{homeId}/scenes", services.scenes, "homeId"))
+	mux.Handle("GET /v1/home/{homeId}/scenes", newAPIGatewayHTTPHandler(hDHandler.ListScenesFromAPIGateway, "/v1/home/{homeId}/scenes", services.scenes, "homeId"))
+	mux.Handle("GET /v1/home/{homeId}/scenes/{sceneId}", newAPIGatewayHTTPHandler(hDHandler.GetSceneFromAPIGateway, "/v1/home/{homeId}/scenes/{sceneId}", services.scenes, "homeId", "sceneId"))
+	mux.Handle("PUT /v1/home/{homeId}/scenes/{sceneId}", newAPIGatewayHTTPHandler(hDHandler.UpdateSceneFromAPIGateway, "/v1/home/{homeId}/scenes/{sceneId}", services.scenes, "homeId", "sceneId"))
+	mux.Handle("DELETE /v1/home/{homeId}/scenes/{sceneId}", newAPIGatewayHTTPHandler(hDHandler.DeleteSceneFromAPIGateway, "/v1/home/{homeId}/scenes/{sceneId}", services.scenes, "homeId", "sceneId"))
+	mux.Handle("POST /v1/home/{homeId}/scenes/{sceneId}/activate", newAPIGatewayHTTPHandler(hDHandler.ActivateSceneFromAPIGateway, "/v1/home/{homeId}/scenes/{sceneId}/activate", services.scenes, "homeId", "sceneId"))
+	mux.Handle("GET /v1/home/{homeId}/devices", newAPIGatewayHTTPHandler(hDHandler.ListDevicesFromAPIGateway, "/v1/home/{homeId}/devices", services.devices, "homeId"))
+	mux.Handle("GET /v1/device-types", newAPIGatewayHTTPHandler(hDHandler.ListDeviceTypesFromAPIGateway, "/v1/device-types", services.deviceTypes))
+
+	return mux
+}
+
+// services are the services of the lambdas, each route gets the one its
+// lambda uses.
+type services struct {
+	devices        hDService.HomeDeviceService
+	deviceTypes    hDService.DeviceTypeService
+	deviceStates   hDService.DeviceStateService
+	deviceCommands hDService.DeviceCommandService
+	homes          hDService.HomeService
+	rooms          hDService.RoomService
+	rules          hDService.RuleService
+	schedules      hDService.ScheduleService
+	scenes         hDService.SceneService
+}
+
+// localDaos are the daos of a store.
+type localDaos struct {
+	homeDevices    hDDao.HomeDeviceDao
+	deviceHistory  hDDao.DeviceHistoryDao
+	deviceShadows  hDDao.DeviceShadowDao
+	deviceCommands hDDao.DeviceCommandDao
+	homes          hDDao.HomeDao
+	rooms          hDDao.RoomDao
+	rules          hDDao.RuleDao
+	ruleExecutions hDDao.RuleExecutionDao
+	schedules      hDDao.ScheduleDao
+	scenes         hDDao.SceneDao
+}
+
+func newServices(ctx context.Context, store string, dynamoDbEndpoint string) (services, error) {
+
+	switch store {
+	case memoryStore:
+		deviceHistory := hDDao.NewInMemoryDeviceHistoryDao()
+		rooms := hDDao.NewInMemoryRoomDao()
+		return newServicesFromDaos(localDaos{
+			homeDevices:    hDDao.NewInMemoryHomeDeviceDaoWithRooms(deviceHistory, rooms),
+			deviceHistory:  deviceHistory,
+			deviceShadows:  hDDao.NewInMemoryDeviceShadowDao(),
+			deviceCommands: hDDao.NewInMemoryDeviceCommandDao(),
+			homes:          hDDao.NewInMemoryHomeDao(),
+			rooms:          rooms,
+			rules:          hDDao.NewInMemoryRuleDao(),
+			ruleExecutions: hDDao.NewInMemoryRuleExecutionDao(),
+			schedules:      hDDao.NewInMemoryScheduleDao(),
+			scenes:         hDDao.NewInMemorySceneDao(),
+		}), nil
+	case dynamoDbStore:
+		return newDynamoDbServices(ctx, dynamoDbEndpoint)
+	default:
+		return services{}, fmt.Errorf("unknown store %v, expected %v or %v", store, memoryStore, dynamoDbStore)
+	}
+}
+
+// newServicesFromDaos shares the device service between the services.
+func newServicesFromDaos(daos localDaos) services {
+
+	devices := hDService.NewHomeDeviceServiceImpl2(daos.homeDevices, hDService.WithDeviceHistoryDao(daos.deviceHistory), hDService.WithHomeDao(daos.homes), hDService.WithRoomDao(daos.rooms), hDService.WithEventPublisher(hDEvent.NewInMemoryEventPublisher()))
+	deviceStates := hDService.NewDeviceStateServiceImpl(daos.deviceShadows, devices)
+	deviceCommands := hDService.NewDeviceCommandServiceImpl(daos.deviceCommands, devices, hDQueue.NewInMemoryCommandQueue())
+
+	return services{
+		devices:        devices,
+		deviceTypes:    hDService.NewDeviceTypeServiceImpl(),
+		deviceStates:   deviceStates,
+		deviceCommands: deviceCommands,
+		homes:          hDService.NewHomeServiceImpl(daos.homes, devices, daos.rooms, daos.rules, daos.schedules, daos.scenes),
+		rooms:          hDService.NewRoomServiceImpl(daos.rooms, daos.homes, devices),
+		rules:          hDService.NewRuleServiceImpl(daos.rules, daos.ruleExecutions, daos.homes, devices, deviceCommands),
+		schedules:      hDService.NewScheduleServiceImpl(daos.schedules, daos.homes, daos.rooms, devices, deviceCommands),
+		scenes:         hDService.NewSceneServiceImpl(daos.scenes, daos.homes, devices, deviceStates),
+	}
+}
+
+// newDynamoDbServices uses the tables and indexes created by the stack unless
+// the lambda environment variables say otherwise.
+func newDynamoDbServices(ctx context.Context, dynamoDbEndpoint string) (services, error) {
+
+	setDefaultEnv(hDConstants.TableNameHomeDevicesProperty, "HomeDevices")
+	setDefaultEnv(hDConstants.MacHomeIdIndexNameProperty, "MacHomeIdIndex")
+	setDefaultEnv(hDConstants.HomeIdIndexNameProperty, "HomeIdIndex")
+	setDefaultEnv(hDConstants.StatusIndexNameProperty, "StatusIndex")
+	setDefaultEnv(hDConstants.DeviceHistoryTableNameProperty, "HomeDeviceHistory")
+	setDefaultEnv(hDConstants.DeviceShadowTableNameProperty, "HomeDeviceShadow")
+	setDefaultEnv(hDConstants.HomeTableNameProperty, "Homes")
+	setDefaultEnv(hDConstants.RoomTableNameProperty, "HomeRooms")
+	setDefaultEnv(hDConstants.DeviceCommandTableNameProperty, "HomeDeviceCommands")
+	setDefaultEnv(hDConstants.RuleTableNameProperty, "HomeRules")
+	setDefaultEnv(hDConstants.TriggerDeviceIdIndexProperty, "TriggerDeviceIdIndex")
+	setDefaultEnv(hDConstants.RuleExecutionTableNameProperty, "HomeRuleExecutions")
+	setDefaultEnv(hDConstants.ScheduleTableNameProperty, "HomeSchedules")
+	setDefaultEnv(hDConstants.SceneTableNameProperty, "HomeScenes")
+	setDefaultEnv(hDConstants.NextRunAtIndexNameProperty, "NextRunAtIndex")
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return services{}, err
+	}
+
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	if _, err := cfg.Credentials.Retrieve(ctx); err != nil {
+		cfg.Credentials = aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "local", SecretAccessKey: "local", Source: "localServer"}, nil
+		})
+	}
+
+	client := dynamodb.NewFromConfig(cfg, func(o *dynamodb.Options) {
+		o.BaseEndpoint = aws.String(dynamoDbEndpoint)
+	})
+
+	return newServicesFromDaos(localDaos{
+		homeDevices:    hDDao.HomeDeviceDaoImpl{DynamoDbApi: client, RecordHistory: true, CountRoomDevices: true},
+		deviceHistory:  hDDao.DeviceHistoryDaoImpl{DynamoDbApi: client},
+		deviceShadows:  hDDao.DeviceShadowDaoImpl{DynamoDbApi: client},
+		deviceCommands: hDDao.DeviceCommandDaoImpl{DynamoDbApi: client},
+		homes:          hDDao.HomeDaoImpl{DynamoDbApi: client},
+		rooms:          hDDao.RoomDaoImpl{DynamoDbApi: client},
+		rules:          hDDao.RuleDaoImpl{DynamoDbApi: client},
+		ruleExecutions: hDDao.RuleExecutionDaoImpl{DynamoDbApi: client},
+		schedules:      hDDao.ScheduleDaoImpl{DynamoDbApi: client},
+		scenes:         hDDao.SceneDaoImpl{DynamoDbApi: client},
+	}), nil
+}
+
+func setDefaultEnv(key string, value string) {
+	if os.Getenv(key) == "" {
+		os.Setenv(key, value)
+	}
+}
+
+func withCORS(next http.Handler, allowOrigin string) http.Handler {
+
+	if allowOrigin == "" {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, If-Match")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,544 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	hDAudit "github.com/odhoman/home-devices/internal/audit"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalServer_DeviceLifecycle(t *testing.T) {
+
+	services, err := newServices(context.TODO(), memoryStore, "")
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(newRouter(services))
+	defer server.Close()
+
+	response := doRequest(t, http.MethodPost, server.URL+"/v1/home", `{"id":"home12122","name":"Beach House","timezone":"Europe/Madrid","owner":"user-1"}`, nil)
+	assert.Equal(t, 201, response.StatusCode)
+
+	response = doRequest(t, http.MethodPost, server.URL+"/v1/device", `{"mac":"00:1A:2B:3C:4D:5E","name":"Living Room Light","type":"light","homeId":"home12122"}`, nil)
+	assert.Equal(t, 201, response.StatusCode)
+
+	var created hDResponse.HomdeDeviceResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&created))
+	assert.NotEmpty(t, created.ID)
+
+	response = doRequest(t, http.MethodGet, server.URL+"/v1/device/"+created.ID, "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, `"1"`, response.Header.Get("ETag"))
+	assert.Equal(t, "application/json", response.Header.Get("Content-Type"))
+
+	response = doRequest(t, http.MethodPut, server.URL+"/v1/device/"+created.ID, `{"name":"Kitchen Light"}`, map[string]string{"If-Match": `"1"`})
+	assert.Equal(t, 201, response.StatusCode)
+
+	response = doRequest(t, http.MethodPut, server.URL+"/v1/device/"+created.ID, `{"name":"Bedroom Light"}`, map[string]string{"If-Match": `"1"`})
+	assert.Equal(t, 412, response.StatusCode)
+
+	response = doRequest(t, http.MethodPatch, server.URL+"/v1/device/"+created.ID+"/state", `{"desired":{"on":true,"brightness":80}}`, nil)
+	assert.Equal(t, 200, response.StatusCode)
+
+	response = doRequest(t, http.MethodPatch, server.URL+"/v1/device/"+created.ID+"/state", `{"desired":{"brightness":120}}`, nil)
+	assert.Equal(t, 400, response.StatusCode)
+
+	response = doRequest(t, http.MethodPatch, server.URL+"/v1/device/"+created.ID+"/state", `{"reported":{"on":true},"reportedVersion":1}`, nil)
+	assert.Equal(t, 412, response.StatusCode)
+
+	response = doRequest(t, http.MethodPatch, server.URL+"/v1/device/"+created.ID+"/state", `{"reported":{"on":true}}`, nil)
+	assert.Equal(t, 200, response.StatusCode)
+
+	response = doRequest(t, http.MethodGet, server.URL+"/v1/device/"+created.ID+"/state", "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+
+	var state hDResponse.DeviceStateResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&state))
+	assert.Equal(t, int64(1), state.Desired.Version)
+	assert.Equal(t, int64(1), state.Reported.Version)
+	assert.Equal(t, map[string]interface{}{"brightness": 80.0}, state.Delta)
+
+	response = doRequest(t, http.MethodGet, server.URL+"/v1/home/home12122/devices?limit=10", "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+
+	var devices hDResponse.HomeDeviceListResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&devices))
+	assert.Len(t, devices.Devices, 1)
+	assert.Equal(t, "Kitchen Light", devices.Devices[0].Name)
+
+	response = doRequest(t, http.MethodDelete, server.URL+"/v1/device/"+created.ID, "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+
+	response = doRequest(t, http.MethodGet, server.URL+"/v1/device/"+created.ID, "", nil)
+	assert.Equal(t, 404, response.StatusCode)
+
+	response = doRequest(t, http.MethodPost, server.URL+"/v1/device/"+created.ID+"/restore", "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, `"4"`, response.Header.Get("ETag"))
+
+	response = doRequest(t, http.MethodDelete, server.URL+"/v1/device/"+created.ID+"?hard=true", "", nil)
+	assert.Equal(t, 403, response.StatusCode)
+
+	response = doRequest(t, http.MethodDelete, server.URL+"/v1/device/"+created.ID+"?hard=true", "", map[string]string{localAdminHeader: "true"})
+	assert.Equal(t, 200, response.StatusCode)
+
+	response = doRequest(t, http.MethodPost, server.URL+"/v1/device/"+created.ID+"/restore", "", nil)
+	assert.Equal(t, 404, response.StatusCode)
+
+	response = doRequest(t, http.MethodGet, server.URL+"/v1/device/"+created.ID+"/history", "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+
+	var history hDResponse.DeviceHistoryResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&history))
+
+	operations := []string{}
+	for _, change := range history.Changes {
+		operations = append(operations, change.Operation)
+	}
+	assert.Equal(t, []string{hDAudit.OperationHardDelete, hDAudit.OperationRestore, hDAudit.OperationDelete, hDAudit.OperationUpdate, hDAudit.OperationCreate}, operations)
+	assert.Equal(t, hDAudit.SourceAdmin, history.Changes[0].Source)
+	assert.Equal(t, hDAudit.SourceAPI, history.Changes[3].Source)
+}
+
+func TestLocalServer_HomeLifecycle(t *testing.T) {
+
+	services, err := newServices(context.TODO(), memoryStore, "")
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(newRouter(services))
+	defer server.Close()
+
+	response := doRequest(t, http.MethodPost, server.URL+"/v1/device", `{"mac":"00:1A:2B:3C:4D:5E","name":"Living Room Light","type":"light","homeId":"home12122"}`, nil)
+	assert.Equal(t, 400, response.StatusCode)
+
+	response = doRequest(t, http.MethodPost, server.URL+"/v1/home", `{"name":"Beach House","timezone":"Europe/Madrid","owner":"user-1"}`, nil)
+	assert.Equal(t, 201, response.StatusCode)
+
+	var home hDResponse.HomeResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&home))
+	assert.NotEmpty(t, home.ID)
+
+	response = doRequest(t, http.MethodPut, server.URL+"/v1/home/"+home.ID, `{"name":"Mountain House"}`, map[string]string{"If-Match": `"1"`})
+	assert.Equal(t, 200, response.StatusCode)
+
+	response = doRequest(t, http.MethodGet, server.URL+"/v1/home/"+home.ID, "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, `"2"`, response.Header.Get("ETag"))
+
+	response = doRequest(t, http.MethodPost, server.URL+"/v1/device", `{"mac":"00:1A:2B:3C:4D:5E","name":"Living Room Light","type":"light","homeId":"`+home.ID+`"}`, nil)
+	assert.Equal(t, 201, response.StatusCode)
+
+	response = doRequest(t, http.MethodDelete, server.URL+"/v1/home/"+home.ID, "", nil)
+	assert.Equal(t, 409, response.StatusCode)
+
+	response = doRequest(t, http.MethodDelete, server.URL+"/v1/home/"+home.ID+"?strategy=cascade", "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+
+	response = doRequest(t, http.MethodGet, server.URL+"/v1/home/"+home.ID+"/devices", "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+
+	var devices hDResponse.HomeDeviceListResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&devices))
+	assert.Empty(t, devices.Devices)
+
+	response = doRequest(t, http.MethodGet, server.URL+"/v1/home/"+home.ID, "", nil)
+	assert.Equal(t, 404, response.StatusCode)
+}
+
+func TestLocalServer_RoomLifecycle(t *testing.T) {
+
+	services, err := newServices(context.TODO(), memoryStore, "")
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(newRouter(services))
+	defer server.Close()
+
+	response := doRequest(t, http.MethodPost, server.URL+"/v1/home", `{"name":"Beach House","timezone":"Europe/Madrid","owner":"user-1"}`, nil)
+	assert.Equal(t, 201, response.StatusCode)
+
+	var home hDResponse.HomeResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&home))
+
+	response = doRequest(t, http.MethodPost, server.URL+"/v1/home/"+home.ID+"/rooms", `{"name":"Kitchen"}`, nil)
+	assert.Equal(t, 201, response.StatusCode)
+
+	var room hDResponse.RoomResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&room))
+	assert.Equal(t, home.ID, room.HomeID)
+
+	response = doRequest(t, http.MethodPut, server.URL+"/v1/home/"+home.ID+"/rooms/"+room.ID, `{"name":"Big Kitchen"}`, map[string]string{"If-Match": `"1"`})
+	assert.Equal(t, 200, response.StatusCode)
+
+	response = doRequest(t, http.MethodGet, server.URL+"/v1/home/"+home.ID+"/rooms/"+room.ID, "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, `"2"`, response.Header.Get("ETag"))
+
+	response = doRequest(t, http.MethodPost, server.URL+"/v1/device", `{"mac":"00:1A:2B:3C:4D:5E","name":"Kitchen Light","type":"light","homeId":"`+home.ID+`","roomId":"`+room.ID+`"}`, nil)
+	assert.Equal(t, 201, response.StatusCode)
+
+	var device hDResponse.HomdeDeviceResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&device))
+	assert.Equal(t, room.ID, device.RoomID)
+
+	response = doRequest(t, http.MethodPost, server.URL+"/v1/device", `{"mac":"00:1A:2B:3C:4D:5F","name":"Hall Light","type":"light","homeId":"`+home.ID+`"}`, nil)
+	assert.Equal(t, 201, response.StatusCode)
+
+	response = doRequest(t, http.MethodGet, server.URL+"/v1/home/"+home.ID+"/devices?roomId="+room.ID, "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+
+	var devices hDResponse.HomeDeviceListResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&devices))
+	assert.Len(t, devices.Devices, 1)
+	assert.Equal(t, device.ID, devices.Devices[0].ID)
+
+	response = doRequest(t, http.MethodGet, server.URL+"/v1/home/"+home.ID+"/rooms", "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+
+	var rooms hDResponse.RoomListResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&rooms))
+	assert.Len(t, rooms.Rooms, 1)
+
+	response = doRequest(t, http.MethodDelete, server.URL+"/v1/home/"+home.ID+"/rooms/"+room.ID, "", nil)
+	assert.Equal(t, 409, response.StatusCode)
+
+	response = doRequest(t, http.MethodDelete, server.URL+"/v1/device/"+device.ID, "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+
+	response = doRequest(t, http.MethodDelete, server.URL+"/v1/home/"+home.ID+"/rooms/"+room.ID, "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+
+	response = doRequest(t, http.MethodGet, server.URL+"/v1/home/"+home.ID+"/rooms/"+room.ID, "", nil)
+	assert.Equal(t, 404, response.StatusCode)
+}
+
+func TestLocalServer_DeviceCommands(t *testing.T) {
+
+	services, err := newServices(context.TODO(), memoryStore, "")
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(newRouter(services))
+	defer server.Close()
+
+	response := doRequest(t, http.MethodPost, server.URL+"/v1/home", `{"name":"Beach House","timezone":"Europe/Madrid","owner":"user-1"}`, nil)
+	assert.Equal(t, 201, response.StatusCode)
+
+	var home hDResponse.HomeResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&home))
+
+	response = doRequest(t, http.MethodPost, server.URL+"/v1/device", `{"mac":"00:1A:2B:3C:4D:5E","name":"Living Room Thermostat","type":"thermostat","homeId":"`+home.ID+`"}`, nil)
+	assert.Equal(t, 201, response.StatusCode)
+
+	var device hDResponse.HomdeDeviceResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&device))
+
+	response = doRequest(t, http.MethodPost, server.URL+"/v1/device/"+device.ID+"/commands", `{"name":"setTargetTemperature","params":{"targetTemperature":21.5}}`, nil)
+	assert.Equal(t, 202, response.StatusCode)
+
+	var command hDResponse.DeviceCommandResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&command))
+	assert.Equal(t, "pending", command.Status)
+
+	response = doRequest(t, http.MethodGet, server.URL+"/v1/device/"+device.ID+"/commands/"+command.ID, "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+
+	response = doRequest(t, http.MethodPost, server.URL+"/v1/device/"+device.ID+"/commands", `{"name":"lock"}`, nil)
+	assert.Equal(t, 400, response.StatusCode)
+
+	response = doRequest(t, http.MethodGet, server.URL+"/v1/device/"+device.ID+"/commands/unknown", "", nil)
+	assert.Equal(t, 404, response.StatusCode)
+}
+
+func TestLocalServer_RuleLifecycle(t *testing.T) {
+
+	services, err := newServices(context.TODO(), memoryStore, "")
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(newRouter(services))
+	defer server.Close()
+
+	response := doRequest(t, http.MethodPost, server.URL+"/v1/home", `{"name":"Beach House","timezone":"Europe/Madrid","owner":"user-1"}`, nil)
+	assert.Equal(t, 201, response.StatusCode)
+
+	var home hDResponse.HomeResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&home))
+
+	response = doRequest(t, http.MethodPost, server.URL+"/v1/device", `{"mac":"00:1A:2B:3C:4D:5E","name":"Hall Sensor","type":"sensor","homeId":"`+home.ID+`"}`, nil)
+	assert.Equal(t, 201, response.StatusCode)
+
+	var sensor hDResponse.HomdeDeviceResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&sensor))
+
+	response = doRequest(t, http.MethodPost, server.URL+"/v1/device", `{"mac":"00:1A:2B:3C:4D:5F","name":"Hall Light","type":"light","homeId":"`+home.ID+`"}`, nil)
+	assert.Equal(t, 201, response.StatusCode)
+
+	var light hDResponse.HomdeDeviceResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&light))
+
+	ruleBody := `{"name":"Hall light at night","trigger":{"deviceId":"` + sensor.ID + `","metric":"motion","operator":"eq","value":1},"timeWindow":{"from":"22:00","to":"06:00"},"action":{"deviceId":"` + light.ID + `","command":"turnOn"}}`
+
+	response = doRequest(t, http.MethodPost, server.URL+"/v1/home/"+home.ID+"/rules", ruleBody, nil)
+	assert.Equal(t, 201, response.StatusCode)
+
+	var rule hDResponse.RuleResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&rule))
+	assert.Equal(t, home.ID, rule.HomeID)
+	assert.Equal(t, "Europe/Madrid", rule.TimeWindow.Timezone)
+
+	response = doRequest(t, http.MethodPost, server.URL+"/v1/home/"+home.ID+"/rules", strings.Replace(ruleBody, "turnOn", "lock", 1), nil)
+	assert.Equal(t, 400, response.StatusCode)
+
+	response = doRequest(t, http.MethodPut, server.URL+"/v1/home/"+home.ID+"/rules/"+rule.ID, strings.Replace(ruleBody, `"value":1`, `"value":0`, 1), map[string]string{"If-Match": `"1"`})
+	assert.Equal(t, 200, response.StatusCode)
+
+	response = doRequest(t, http.MethodGet, server.URL+"/v1/home/"+home.ID+"/rules/"+rule.ID, "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, `"2"`, response.Header.Get("ETag"))
+
+	response = doRequest(t, http.MethodGet, server.URL+"/v1/home/"+home.ID+"/rules", "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+
+	var rules hDResponse.RuleListResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&rules))
+	assert.Len(t, rules.Rules, 1)
+
+	response = doRequest(t, http.MethodGet, server.URL+"/v1/home/"+home.ID+"/rules/"+rule.ID+"/executions", "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+
+	var executions hDResponse.RuleExecutionListResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&executions))
+	assert.Empty(t, executions.Executions)
+
+	response = doRequest(t, http.MethodDelete, server.URL+"/v1/home/"+home.ID+"/rules/"+rule.ID, "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+
+	response = doRequest(t, http.MethodGet, server.URL+"/v1/home/"+home.ID+"/rules/"+rule.ID+"/executions", "", nil)
+	assert.Equal(t, 404, response.StatusCode)
+}
+
+func TestLocalServer_ScheduleLifecycle(t *testing.T) {
+
+	services, err := newServices(context.TODO(), memoryStore, "")
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(newRouter(services))
+	defer server.Close()
+
+	response := doRequest(t, http.MethodPost, server.URL+"/v1/home", `{"name":"Beach House","timezone":"Europe/Madrid","owner":"user-1"}`, nil)
+	assert.Equal(t, 201, response.StatusCode)
+
+	var home hDResponse.HomeResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&home))
+
+	response = doRequest(t, http.MethodPost, server.URL+"/v1/device", `{"mac":"00:1A:2B:3C:4D:5F","name":"Hall Light","type":"light","homeId":"`+home.ID+`"}`, nil)
+	assert.Equal(t, 201, response.StatusCode)
+
+	var light hDResponse.HomdeDeviceResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&light))
+
+	scheduleBody := `{"name":"Lights off at midnight","cron":"0 0 * * *","target":{"deviceId":"` + light.ID + `"},"command":"turnOff"}`
+
+	response = doRequest(t, http.MethodPost, server.URL+"/v1/home/"+home.ID+"/schedules", scheduleBody, nil)
+	assert.Equal(t, 201, response.StatusCode)
+
+	var schedule hDResponse.ScheduleResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&schedule))
+	assert.Equal(t, home.ID, schedule.HomeID)
+	assert.Equal(t, "Europe/Madrid", schedule.Timezone)
+	assert.NotZero(t, schedule.NextRunAt)
+
+	response = doRequest(t, http.MethodPost, server.URL+"/v1/home/"+home.ID+"/schedules", strings.Replace(scheduleBody, "0 0 * * *", "0 25 * * *", 1), nil)
+	assert.Equal(t, 400, response.StatusCode)
+
+	response = doRequest(t, http.MethodPut, server.URL+"/v1/home/"+home.ID+"/schedules/"+schedule.ID, `{"name":"Lights off at night","rrule":"FREQ=DAILY;BYHOUR=23;BYMINUTE=30","target":{"deviceType":"light"},"command":"turnOff"}`, map[string]string{"If-Match": `"1"`})
+	assert.Equal(t, 200, response.StatusCode)
+
+	response = doRequest(t, http.MethodGet, server.URL+"/v1/home/"+home.ID+"/schedules/"+schedule.ID, "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, `"2"`, response.Header.Get("ETag"))
+
+	response = doRequest(t, http.MethodGet, server.URL+"/v1/home/"+home.ID+"/schedules", "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+
+	var schedules hDResponse.ScheduleListResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&schedules))
+	assert.Len(t, schedules.Schedules, 1)
+	assert.Equal(t, "FREQ=DAILY;BYHOUR=23;BYMINUTE=30", schedules.Schedules[0].RRule)
+
+	response = doRequest(t, http.MethodDelete, server.URL+"/v1/home/"+home.ID+"/schedules/"+schedule.ID, "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+
+	response = doRequest(t, http.MethodGet, server.URL+"/v1/home/"+home.ID+"/schedules/"+schedule.ID, "", nil)
+	assert.Equal(t, 404, response.StatusCode)
+}
+
+func TestLocalServer_SceneLifecycle(t *testing.T) {
+
+	services, err := newServices(context.TODO(), memoryStore, "")
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(newRouter(services))
+	defer server.Close()
+
+	response := doRequest(t, http.MethodPost, server.URL+"/v1/home", `{"name":"Beach House","timezone":"Europe/Madrid","owner":"user-1"}`, nil)
+	assert.Equal(t, 201, response.StatusCode)
+
+	var home hDResponse.HomeResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&home))
+
+	response = doRequest(t, http.MethodPost, server.URL+"/v1/device", `{"mac":"00:1A:2B:3C:4D:60","name":"Living Room Light","type":"light","homeId":"`+home.ID+`"}`, nil)
+	assert.Equal(t, 201, response.StatusCode)
+
+	var light hDResponse.HomdeDeviceResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&light))
+
+	sceneBody := `{"name":"Movie night","devices":[{"deviceId":"` + light.ID + `","state":{"on":true,"brightness":20}}]}`
+
+	response = doRequest(t, http.MethodPost, server.URL+"/v1/home/"+home.ID+"/scenes", sceneBody, nil)
+	assert.Equal(t, 201, response.StatusCode)
+
+	var scene hDResponse.SceneResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&scene))
+	assert.Equal(t, home.ID, scene.HomeID)
+
+	response = doRequest(t, http.MethodPost, server.URL+"/v1/home/"+home.ID+"/scenes", strings.Replace(sceneBody, `"brightness":20`, `"brightness":120`, 1), nil)
+	assert.Equal(t, 400, response.StatusCode)
+
+	response = doRequest(t, http.MethodPost, server.URL+"/v1/home/"+home.ID+"/scenes/"+scene.ID+"/activate", "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+
+	var activation hDResponse.SceneActivationResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&activation))
+	assert.Len(t, activation.Results, 1)
+	assert.Equal(t, "applied", activation.Results[0].Status)
+
+	response = doRequest(t, http.MethodGet, server.URL+"/v1/device/"+light.ID+"/state", "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+
+	var state hDResponse.DeviceStateResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&state))
+	assert.Equal(t, float64(20), state.Desired.State["brightness"])
+
+	response = doRequest(t, http.MethodPut, server.URL+"/v1/home/"+home.ID+"/scenes/"+scene.ID, strings.Replace(sceneBody, "Movie night", "Reading", 1), map[string]string{"If-Match": `"1"`})
+	assert.Equal(t, 200, response.StatusCode)
+
+	response = doRequest(t, http.MethodGet, server.URL+"/v1/home/"+home.ID+"/scenes/"+scene.ID, "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, `"2"`, response.Header.Get("ETag"))
+
+	response = doRequest(t, http.MethodGet, server.URL+"/v1/home/"+home.ID+"/scenes", "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+
+	var scenes hDResponse.SceneListResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&scenes))
+	assert.Len(t, scenes.Scenes, 1)
+	assert.Equal(t, "Reading", scenes.Scenes[0].Name)
+
+	response = doRequest(t, http.MethodDelete, server.URL+"/v1/home/"+home.ID+"/scenes/"+scene.ID, "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+
+	response = doRequest(t, http.MethodPost, server.URL+"/v1/home/"+home.ID+"/scenes/"+scene.ID+"/activate", "", nil)
+	assert.Equal(t, 404, response.StatusCode)
+}
+
+func TestLocalServer_DeviceTypes(t *testing.T) {
+
+	server := httptest.NewServer(newRouter(newMemoryServices(t)))
+	defer server.Close()
+
+	response := doRequest(t, http.MethodGet, server.URL+"/v1/device-types", "", nil)
+	assert.Equal(t, 200, response.StatusCode)
+
+	var deviceTypes hDResponse.DeviceTypeListResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&deviceTypes))
+	assert.Len(t, deviceTypes.DeviceTypes, 5)
+	assert.Equal(t, "light", deviceTypes.DeviceTypes[0].Name)
+}
+
+func TestLocalServer_InvalidBody(t *testing.T) {
+
+	server := httptest.NewServer(newRouter(newMemoryServices(t)))
+	defer server.Close()
+
+	response := doRequest(t, http.MethodPost, server.URL+"/v1/device", `{"mac":`, map[string]string{"Accept": "application/problem+json"})
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Equal(t, "application/problem+json", response.Header.Get("Content-Type"))
+
+	var problem hDResponse.ProblemDetails
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&problem))
+	assert.Equal(t, "INVALID_REQUEST", problem.Code)
+	assert.NotEmpty(t, problem.Instance)
+
+	for _, accept := range []string{"", "application/json"} {
+		response = doRequest(t, http.MethodPost, server.URL+"/v1/device", `{"mac":`, map[string]string{"Accept": accept})
+
+		assert.Equal(t, 400, response.StatusCode)
+		assert.Equal(t, "application/json", response.Header.Get("Content-Type"), accept)
+	}
+}
+
+func TestLocalServer_MethodNotAllowed(t *testing.T) {
+
+	server := httptest.NewServer(newRouter(newMemoryServices(t)))
+	defer server.Close()
+
+	response := doRequest(t, http.MethodPatch, server.URL+"/v1/device/id", "", nil)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, response.StatusCode)
+}
+
+func TestLocalServer_CORS(t *testing.T) {
+
+	server := httptest.NewServer(withCORS(newRouter(newMemoryServices(t)), "http://localhost:3000"))
+	defer server.Close()
+
+	response := doRequest(t, http.MethodOptions, server.URL+"/v1/device", "", nil)
+
+	assert.Equal(t, http.StatusNoContent, response.StatusCode)
+	assert.Equal(t, "http://localhost:3000", response.Header.Get("Access-Control-Allow-Origin"))
+}
+
+func TestNewServices_UnknownStore(t *testing.T) {
+
+	_, err := newServices(context.TODO(), "redis", "")
+
+	assert.Error(t, err)
+}
+
+func newMemoryServices(t *testing.T) services {
+	t.Helper()
+
+	services, err := newServices(context.TODO(), memoryStore, "")
+	if err != nil {
+		t.Fatalf("unable to create the services, %v", err)
+	}
+
+	return services
+}
+
+func doRequest(t *testing.T, method string, url string, body string, headers map[string]string) *http.Response {
+	t.Helper()
+
+	request, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("unable to create the request, %v", err)
+	}
+
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("unable to send the request, %v", err)
+	}
+	t.Cleanup(func() { response.Body.Close() })
+
+	return response
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// SweeperReport counts the devices marked offline by an invocation, by type.
+type SweeperReport struct {
+	Offline int            `json:"offline"`
+	ByType  map[string]int `json:"byType"`
+}
+
+// HandleRequest marks offline the devices that were not seen for longer than
+// the presence timeout of their type at the time of the event, which
+// EventBridge sends every minute.
+func HandleRequest(ctx context.Context, event events.CloudWatchEvent, deviceService hDService.HomeDeviceService) error {
+
+	now := event.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	devices, err := deviceService.MarkOfflineDevices(ctx, now)
+
+	if reportJson, marshalErr := json.Marshal(countOfflineDevices(devices)); marshalErr == nil {
+		log.Printf("Presence sweeper report for %v: %s", now.UTC().Format(time.RFC3339), reportJson)
+	}
+
+	if err != nil {
+		log.Printf("Error marking the devices offline: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func countOfflineDevices(devices []hDResponse.HomdeDeviceResponse) SweeperReport {
+
+	report := SweeperReport{Offline: len(devices), ByType: map[string]int{}}
+
+	for _, device := range devices {
+		report.ByType[device.Type]++
+	}
+
+	return report
+}
+
+func main() {
+
+	lambda.Start(func(ctx context.Context, event events.CloudWatchEvent) error {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			log.Fatalf("unable to load SDK config for presence sweeper lambda function, %v", err)
+		}
+
+		return HandleRequest(ctx, event, hDService.NewHomeDeviceServiceImplFromConfig2(cfg))
+	})
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_SweepsAtTheTimeOfTheEvent(t *testing.T) {
+	mockService := new(hDMock.MockHomeDeviceService)
+	eventTime := time.Date(2024, 12, 31, 23, 0, 2, 0, time.UTC)
+
+	mockService.On("MarkOfflineDevices", mock.Anything, eventTime).Return([]hDResponse.HomdeDeviceResponse{}, nil)
+
+	err := HandleRequest(context.TODO(), events.CloudWatchEvent{Time: eventTime}, mockService)
+
+	assert.NoError(t, err)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_EventWithoutTime(t *testing.T) {
+	mockService := new(hDMock.MockHomeDeviceService)
+	before := time.Now()
+
+	mockService.On("MarkOfflineDevices", mock.Anything, mock.MatchedBy(func(now time.Time) bool {
+		return !now.Before(before)
+	})).Return([]hDResponse.HomdeDeviceResponse{}, nil)
+
+	err := HandleRequest(context.TODO(), events.CloudWatchEvent{}, mockService)
+
+	assert.NoError(t, err)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_Error(t *testing.T) {
+	mockService := new(hDMock.MockHomeDeviceService)
+
+	mockService.On("MarkOfflineDevices", mock.Anything, mock.Anything).Return(nil, hDError.ErrListingDevices.New())
+
+	err := HandleRequest(context.TODO(), events.CloudWatchEvent{Time: time.Now()}, mockService)
+
+	assert.EqualError(t, err, hDError.ErrListingDevices.Message)
+}
+
+func TestCountOfflineDevices(t *testing.T) {
+
+	report := countOfflineDevices([]hDResponse.HomdeDeviceResponse{
+		{ID: "light-1", Type: "light"},
+		{ID: "light-2", Type: "light"},
+		{ID: "plug-1", Type: "plug"},
+	})
+
+	assert.Equal(t, SweeperReport{Offline: 3, ByType: map[string]int{"light": 2, "plug": 1}}, report)
+}
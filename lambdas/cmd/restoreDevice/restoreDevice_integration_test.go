@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+	"github.com/odhoman/home-devices/internal/mock"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDService "github.com/odhoman/home-devices/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	mock.RunTestMain(m)
+}
+
+func TestRestoreHomeDevice_Success(t *testing.T) {
+
+	request := hDRequest.CreateDeviceRequest{
+		MAC:    "34:76:2B:BB:EE:46",
+		Name:   "Living Room Light",
+		Type:   "light",
+		HomeID: "home12122",
+	}
+
+	ctx := context.Background()
+	svc := mock.GetDynamoConnectionTestFromEnpoint()
+	homeDeviceServiceImpl := hDService.NewHomeDeviceServiceImpl2(dao.HomeDeviceDaoImpl{DynamoDbApi: svc})
+
+	deviceCreated, err := homeDeviceServiceImpl.CreateHomeDevice(ctx, request)
+	if err != nil {
+		t.Fatalf("Unexpected error creating new Device for testing. Error: %v", err)
+	}
+
+	if _, err := homeDeviceServiceImpl.DeleteHomeDevice(ctx, deviceCreated.ID, 0); err != nil {
+		t.Fatalf("Unexpected error deleting the Device for testing. Error: %v", err)
+	}
+
+	response, handleErr := HandleRequest(ctx, deviceCreated.ID, homeDeviceServiceImpl)
+
+	if handleErr != nil {
+		t.Fatalf("Unexpected error running TestRestoreHomeDevice_Success. Error: %v", handleErr.Error())
+	}
+
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Contains(t, response.Body, deviceCreated.ID)
+
+	_, err = homeDeviceServiceImpl.GetHomeDevice(ctx, deviceCreated.ID)
+	assert.Nil(t, err)
+}
+
+func TestRestoreHomeDevice_DeviceNotDeleted(t *testing.T) {
+
+	request := hDRequest.CreateDeviceRequest{
+		MAC:    "34:76:2B:BB:EE:47",
+		Name:   "Living Room Light",
+		Type:   "light",
+		HomeID: "home12122",
+	}
+
+	ctx := context.Background()
+	svc := mock.GetDynamoConnectionTestFromEnpoint()
+	homeDeviceServiceImpl := hDService.NewHomeDeviceServiceImpl2(dao.HomeDeviceDaoImpl{DynamoDbApi: svc})
+
+	deviceCreated, err := homeDeviceServiceImpl.CreateHomeDevice(ctx, request)
+	if err != nil {
+		t.Fatalf("Unexpected error creating new Device for testing. Error: %v", err)
+	}
+
+	response, handleErr := HandleRequest(ctx, deviceCreated.ID, homeDeviceServiceImpl)
+
+	if handleErr != nil {
+		t.Fatalf("Unexpected error running TestRestoreHomeDevice_DeviceNotDeleted. Error: %v", handleErr.Error())
+	}
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Device is not deleted")
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+
+	id := uuid.New().String()
+	device := &hDResponse.HomdeDeviceResponse{ID: id, MAC: "00:1A:2B:3C:4D:5E", HomeID: "home12122", Version: 3}
+
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockService.On("RestoreHomeDevice", mock.Anything, id).Return(device, nil)
+
+	response, err := HandleRequest(context.TODO(), id, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "\"3\"", response.Headers["ETag"])
+
+	expectedBody, _ := json.Marshal(device)
+	assert.JSONEq(t, string(expectedBody), response.Body)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_ValidationError(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), "", new(hDMock.MockHomeDeviceService))
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Field 'id' is empty. Please enter a value")
+}
+
+func TestHandleRequest_Errors(t *testing.T) {
+
+	tests := []struct {
+		err                error
+		expectedStatusCode int
+		expectedMessage    string
+	}{
+		{hDError.ErrDeviceNotFound.New(), 404, "Device Not Found"},
+		{hDError.ErrDeviceNotDeleted.New(), 400, "Device is not deleted"},
+		{hDError.ErrDeviceAlreadyExists.New(), 400, "Device Already Exist"},
+		{hDError.ErrVersionConflict.New(), 412, "The device was modified by another request"},
+		{hDError.ErrRestoringDevice.New(), 500, "Internal Server error restoring a device"},
+	}
+
+	for _, test := range tests {
+		t.Run(hDError.From(test.err).ErrorCode, func(t *testing.T) {
+
+			id := uuid.New().String()
+
+			mockService := new(hDMock.MockHomeDeviceService)
+			mockService.On("RestoreHomeDevice", mock.Anything, id).Return(nil, test.err)
+
+			response, _ := HandleRequest(context.TODO(), id, mockService)
+
+			assert.Equal(t, test.expectedStatusCode, response.StatusCode)
+			assert.Contains(t, response.Body, test.expectedMessage)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	hDConstants "github.com/odhoman/home-devices/internal/constants"
+	hDQueue "github.com/odhoman/home-devices/internal/queue"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// SchedulerReport counts the runs of an invocation.
+type SchedulerReport struct {
+	Runs           int `json:"runs"`
+	Dispatched     int `json:"dispatched"`
+	Missed         int `json:"missed"`
+	CommandsSent   int `json:"commandsSent"`
+	CommandsFailed int `json:"commandsFailed"`
+}
+
+// HandleRequest runs the schedules that are due at the time of the event, which
+// EventBridge sends every minute.
+func HandleRequest(ctx context.Context, event events.CloudWatchEvent, scheduleService hDService.ScheduleService) error {
+
+	now := event.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	runs, err := scheduleService.RunDueSchedules(ctx, now)
+
+	if reportJson, marshalErr := json.Marshal(countScheduleRuns(runs)); marshalErr == nil {
+		log.Printf("Scheduler report for %v: %s", now.UTC().Format(time.RFC3339), reportJson)
+	}
+
+	if err != nil {
+		log.Printf("Error running the due schedules: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func countScheduleRuns(runs []hDResponse.ScheduleRunResponse) SchedulerReport {
+
+	report := SchedulerReport{Runs: len(runs)}
+
+	for _, run := range runs {
+		switch run.Status {
+		case hDConstants.ScheduleRunStatusDispatched:
+			report.Dispatched++
+		case hDConstants.ScheduleRunStatusMissed:
+			report.Missed++
+		}
+		report.CommandsSent += len(run.CommandIDs)
+		report.CommandsFailed += len(run.Errors)
+	}
+
+	return report
+}
+
+func main() {
+
+	lambda.Start(func(ctx context.Context, event events.CloudWatchEvent) error {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			log.Fatalf("unable to load SDK config for scheduler lambda function, %v", err)
+		}
+
+		commandQueue, err := hDQueue.NewSQSCommandQueueFromConfig(cfg)
+		if err != nil {
+			log.Fatalf("unable to create the command queue for scheduler lambda function, %v", err)
+		}
+
+		return HandleRequest(ctx, event, hDService.NewScheduleServiceImplFromConfig(cfg, commandQueue))
+	})
+}
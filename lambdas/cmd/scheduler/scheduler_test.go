@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	hDConstants "github.com/odhoman/home-devices/internal/constants"
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_RunsAtTheTimeOfTheEvent(t *testing.T) {
+	mockService := new(hDMock.MockScheduleService)
+	eventTime := time.Date(2024, 12, 31, 23, 0, 2, 0, time.UTC)
+
+	mockService.On("RunDueSchedules", mock.Anything, eventTime).Return([]hDResponse.ScheduleRunResponse{}, nil)
+
+	err := HandleRequest(context.TODO(), events.CloudWatchEvent{Time: eventTime}, mockService)
+
+	assert.NoError(t, err)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_EventWithoutTime(t *testing.T) {
+	mockService := new(hDMock.MockScheduleService)
+	before := time.Now()
+
+	mockService.On("RunDueSchedules", mock.Anything, mock.MatchedBy(func(now time.Time) bool {
+		return !now.Before(before)
+	})).Return([]hDResponse.ScheduleRunResponse{}, nil)
+
+	err := HandleRequest(context.TODO(), events.CloudWatchEvent{}, mockService)
+
+	assert.NoError(t, err)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_Error(t *testing.T) {
+	mockService := new(hDMock.MockScheduleService)
+
+	mockService.On("RunDueSchedules", mock.Anything, mock.Anything).Return(nil, hDError.ErrListingSchedules.New())
+
+	err := HandleRequest(context.TODO(), events.CloudWatchEvent{Time: time.Now()}, mockService)
+
+	assert.EqualError(t, err, hDError.ErrListingSchedules.Message)
+}
+
+func TestCountScheduleRuns(t *testing.T) {
+
+	report := countScheduleRuns([]hDResponse.ScheduleRunResponse{
+		{Status: hDConstants.ScheduleRunStatusDispatched, CommandIDs: []string{"command-1", "command-2"}, Errors: []string{"light-3: the device is offline"}},
+		{Status: hDConstants.ScheduleRunStatusDispatched, CommandIDs: []string{"command-3"}},
+		{Status: hDConstants.ScheduleRunStatusMissed, CommandIDs: []string{}},
+	})
+
+	assert.Equal(t, SchedulerReport{Runs: 3, Dispatched: 2, Missed: 1, CommandsSent: 3, CommandsFailed: 1}, report)
+}
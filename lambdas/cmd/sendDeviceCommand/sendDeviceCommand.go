@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	hDHandler "github.com/odhoman/home-devices/internal/handler"
+	hDQueue "github.com/odhoman/home-devices/internal/queue"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDService "github.com/odhoman/home-devices/internal/service"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+func HandleRequest(ctx context.Context, id string, commandRequest hDRequest.SendDeviceCommandRequest, deviceCommandService hDService.DeviceCommandService) (events.APIGatewayProxyResponse, error) {
+	return hDHandler.SendDeviceCommand(ctx, id, commandRequest, deviceCommandService)
+}
+
+func main() {
+
+	lambda.Start(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			log.Fatalf("unable to load SDK config for sendDeviceCommand lambda function, %v", err)
+		}
+
+		commandQueue, err := hDQueue.NewSQSCommandQueueFromConfig(cfg)
+		if err != nil {
+			log.Fatalf("unable to create the command queue for sendDeviceCommand lambda function, %v", err)
+		}
+
+		return hDHandler.WithErrorNegotiation(hDHandler.SendDeviceCommandFromAPIGateway)(ctx, request, hDService.NewDeviceCommandServiceImplFromConfig(cfg, commandQueue))
+	})
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	hDConstants "github.com/odhoman/home-devices/internal/constants"
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDHandler "github.com/odhoman/home-devices/internal/handler"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockDeviceCommandService)
+
+	commandRequest := hDRequest.SendDeviceCommandRequest{
+		Name:   "setTargetTemperature",
+		Params: map[string]interface{}{"targetTemperature": 21.5},
+	}
+
+	command := &hDResponse.DeviceCommandResponse{
+		ID:        "5b0e7a0c-8c8f-4c0e-9f43-2f8f0f5f6b11",
+		DeviceID:  "id",
+		Name:      "setTargetTemperature",
+		Params:    map[string]interface{}{"targetTemperature": 21.5},
+		Status:    hDConstants.CommandStatusPending,
+		CreatedAt: 1700000000,
+		ExpiresAt: 1700000300,
+	}
+
+	mockService.On("SendDeviceCommand", mock.Anything, "id", commandRequest).Return(command, nil)
+
+	response, err := HandleRequest(context.TODO(), "id", commandRequest, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 202, response.StatusCode)
+
+	expectedBody, _ := json.Marshal(command)
+	assert.JSONEq(t, string(expectedBody), response.Body)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_ValidationError(t *testing.T) {
+	mockService := new(hDMock.MockDeviceCommandService)
+
+	response, _ := HandleRequest(context.TODO(), "id", hDRequest.SendDeviceCommandRequest{Name: "turnOn", TimeoutSeconds: 7200}, mockService)
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Timeout must be between 1 and 3600 seconds")
+	mockService.AssertNotCalled(t, "SendDeviceCommand", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleRequest_EmptyId(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), "", hDRequest.SendDeviceCommandRequest{Name: "turnOn"}, new(hDMock.MockDeviceCommandService))
+
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestHandleRequest_InvalidBody(t *testing.T) {
+
+	response, _ := hDHandler.SendDeviceCommandFromAPIGateway(context.TODO(), events.APIGatewayProxyRequest{
+		PathParameters: map[string]string{"id": "id"},
+		Body:           `{"params": "on"}`,
+	}, new(hDMock.MockDeviceCommandService))
+
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestHandleRequest_InvalidCommand(t *testing.T) {
+	mockService := new(hDMock.MockDeviceCommandService)
+	mockService.On("SendDeviceCommand", mock.Anything, "id", mock.Anything).Return(nil, hDError.ErrInvalidDeviceCommand.WithMessage("lock is not a command of the light devices"))
+
+	response, _ := HandleRequest(context.TODO(), "id", hDRequest.SendDeviceCommandRequest{Name: "lock"}, mockService)
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "lock is not a command of the light devices")
+}
+
+func TestHandleRequest_Errors(t *testing.T) {
+
+	tests := []struct {
+		err        error
+		statusCode int
+		message    string
+	}{
+		{hDError.ErrDeviceNotFound.New(), 404, "Device Not Found"},
+		{hDError.ErrSendingDeviceCommand.New(), 500, "Internal Server error sending the device command"},
+		{hDError.ErrDeviceCommandNotCreated.New(), 500, "Internal Server error creating the device command"},
+	}
+
+	for _, tt := range tests {
+		t.Run(hDError.From(tt.err).ErrorCode, func(t *testing.T) {
+			mockService := new(hDMock.MockDeviceCommandService)
+			mockService.On("SendDeviceCommand", mock.Anything, "id", mock.Anything).Return(nil, tt.err)
+
+			response, _ := HandleRequest(context.TODO(), "id", hDRequest.SendDeviceCommandRequest{Name: "turnOn"}, mockService)
+
+			assert.Equal(t, tt.statusCode, response.StatusCode)
+			assert.Contains(t, response.Body, tt.message)
+		})
+	}
+}
@@ -2,12 +2,16 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"math/rand"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
+
+	hDRequest "github.com/odhoman/home-devices/internal/request"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -35,7 +39,11 @@ func main() {
 
 	streamName := "HomeDevicesStack-HomeDevicesKinesisStream-dc38db91" // Replace with your stream in LocalStack
 
-	partitionKeys := []string{"MiPartitionKey1", "MiPartitionKey2", "MiPartitionKey3", "MiPartitionKey4"}
+	// The kinesisListener only stores the readings of existing devices
+	deviceIds := os.Args[1:]
+	if len(deviceIds) == 0 {
+		log.Fatalf("usage: testKinesis <deviceId> [<deviceId>...]")
+	}
 
 	// Channel to capture interrupt signals (Ctrl + C)
 	stop := make(chan os.Signal, 1)
@@ -50,14 +58,24 @@ func main() {
 			fmt.Println("Interrupted by user, terminating the program...")
 			return
 		default:
-			// Randomly choose a PartitionKey
-			partitionKey := partitionKeys[rand.Intn(len(partitionKeys))]
+			// Randomly choose a device, its id is the PartitionKey so its readings keep their order
+			partitionKey := deviceIds[rand.Intn(len(deviceIds))]
 
-			// Data to be sent
-			data := fmt.Sprintf("Message in partition key: %s", partitionKey)
+			// Reading to be sent
+			value := 18 + rand.Float64()*8
+			data, err := json.Marshal(hDRequest.TelemetryReadingRequest{
+				DeviceID:  partitionKey,
+				Metric:    "temperature",
+				Value:     &value,
+				Unit:      "celsius",
+				Timestamp: time.Now().UnixMilli(),
+			})
+			if err != nil {
+				log.Fatalf("unable to serialize the reading, %v", err)
+			}
 
 			// Send the record to Kinesis
-			_, err := kinesisClient.PutRecord(context.TODO(), &kinesis.PutRecordInput{
+			_, err = kinesisClient.PutRecord(context.TODO(), &kinesis.PutRecordInput{
 				StreamName:   aws.String(streamName),
 				Data:         []byte(data),
 				PartitionKey: aws.String(partitionKey),
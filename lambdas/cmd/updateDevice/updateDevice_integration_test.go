@@ -32,16 +32,16 @@ func TestHandleUpdateDevice_Success(t *testing.T) {
 	id := deviceCreated.ID
 
 	updateRequest := hDRequest.UpdateDeviceRequest{
-		Type: "alarm",
+		Type: "lock",
 	}
 
-	response, err := HandleRequest(ctx, updateRequest, deviceCreated.ID, homeDeviceServiceImpl)
+	response, err := HandleRequest(ctx, updateRequest, deviceCreated.ID, "", homeDeviceServiceImpl)
 
 	deviceReturned := GetHomeDeviceForTesting(t, ctx, homeDeviceServiceImpl, id)
 
 	assert.NoError(t, err)
 	assert.Equal(t, deviceReturned.ID, id)
-	assert.Equal(t, deviceReturned.Type, "alarm")
+	assert.Equal(t, deviceReturned.Type, "lock")
 	assert.NotNil(t, response)
 	assert.Contains(t, response.Body, "Device updated")
 	assert.Equal(t, response.StatusCode, 201)
@@ -55,10 +55,10 @@ func TestHandleUpdateDevice_DeviceNotFound(t *testing.T) {
 	homeDeviceServiceImpl := hDService.NewHomeDeviceServiceImpl2(dao.HomeDeviceDaoImpl{DynamoDbApi: svc})
 
 	updateRequest := hDRequest.UpdateDeviceRequest{
-		Type: "alarm",
+		Type: "lock",
 	}
 
-	response, err := HandleRequest(ctx, updateRequest, "fakeId", homeDeviceServiceImpl)
+	response, err := HandleRequest(ctx, updateRequest, "fakeId", "", homeDeviceServiceImpl)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, response)
@@ -71,7 +71,7 @@ func CreateHomeDeviceForTesting(t *testing.T, ctx context.Context, service hDSer
 	deviceCreated, err := service.CreateHomeDevice(ctx, device)
 
 	if err != nil {
-		t.Fatalf("Unexpected error creating new Device for testing. Error: %v", err.ErrorCode)
+		t.Fatalf("Unexpected error creating new Device for testing. Error: %v", err)
 		return nil
 	}
 
@@ -83,7 +83,7 @@ func GetHomeDeviceForTesting(t *testing.T, ctx context.Context, service hDServic
 	deviceReturned, err := service.GetHomeDevice(ctx, id)
 
 	if err != nil {
-		t.Fatalf("Unexpected error creating new Device for testing. Error: %v", err.ErrorCode)
+		t.Fatalf("Unexpected error creating new Device for testing. Error: %v", err)
 		return nil
 	}
 
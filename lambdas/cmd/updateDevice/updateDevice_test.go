@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"testing"
 
-	hDConstants "github.com/odhoman/home-devices/internal/constants"
 	hDError "github.com/odhoman/home-devices/internal/error"
 	hDMock "github.com/odhoman/home-devices/internal/mock"
 	hDRequest "github.com/odhoman/home-devices/internal/request"
@@ -30,9 +29,9 @@ func TestHandleRequest_Success(t *testing.T) {
 
 	responseMessage := &hDResponse.MessageResponse{Message: "Device updated"}
 
-	mockService.On("UpdateHomeDevice", mock.Anything, request, id).Return(nil)
+	mockService.On("UpdateHomeDevice", mock.Anything, request, id, int64(0)).Return(&hDResponse.HomdeDeviceResponse{ID: id, Version: 2}, nil)
 
-	response, err := HandleRequest(context.TODO(), request, id, mockService)
+	response, err := HandleRequest(context.TODO(), request, id, "", mockService)
 
 	assert.NoError(t, err)
 	assert.Equal(t, 201, response.StatusCode)
@@ -52,13 +51,13 @@ func TestHandleRequest_ValidationErrorFieldsLength(t *testing.T) {
 		HomeID: "homeId super large homeId super large homeId super large homeId super large homeId super large homeId super large homeId super large ",
 	}
 
-	response, _ := HandleRequest(context.TODO(), request, uuid.New().String(), new(hDMock.MockHomeDeviceService))
+	response, _ := HandleRequest(context.TODO(), request, uuid.New().String(), "", new(hDMock.MockHomeDeviceService))
 
 	assert.Equal(t, 400, response.StatusCode)
 
 	assert.Contains(t, response.Body, "Please enter a valid MAC address")
 	assert.Contains(t, response.Body, "Name must be between 3 and 50 characters")
-	assert.Contains(t, response.Body, "Type must be between 3 and 20 characters")
+	assert.Contains(t, response.Body, "Type must be one of light, lock, plug, sensor, thermostat")
 	assert.Contains(t, response.Body, "Home ID must be between 5 and 30 characters")
 }
 
@@ -75,16 +74,50 @@ func TestHandleRequest_DeviceNotFound(t *testing.T) {
 
 	mockService := new(hDMock.MockHomeDeviceService)
 
-	mockService.On("UpdateHomeDevice", mock.Anything, request, id).Return(&hDError.HomeDeviceError{
-		ErrorCode: hDConstants.ErrDeviceNotFoundCode,
-	})
+	mockService.On("UpdateHomeDevice", mock.Anything, request, id, int64(0)).Return(nil, hDError.ErrDeviceNotFound.New())
 
-	response, _ := HandleRequest(context.TODO(), request, id, mockService)
+	response, _ := HandleRequest(context.TODO(), request, id, "", mockService)
 
 	assert.Equal(t, 404, response.StatusCode)
 	assert.Contains(t, response.Body, "Device Not Found")
 }
 
+func TestHandleRequest_UnknownHome(t *testing.T) {
+
+	id := uuid.New().String()
+
+	request := hDRequest.UpdateDeviceRequest{
+		HomeID: "home99999",
+	}
+
+	mockService := new(hDMock.MockHomeDeviceService)
+
+	mockService.On("UpdateHomeDevice", mock.Anything, request, id, int64(0)).Return(nil, hDError.ErrUnknownHome.New())
+
+	response, _ := HandleRequest(context.TODO(), request, id, "", mockService)
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Home Not Found for the homeId")
+}
+
+func TestHandleRequest_UnknownRoom(t *testing.T) {
+
+	id := uuid.New().String()
+
+	request := hDRequest.UpdateDeviceRequest{
+		RoomID: uuid.New().String(),
+	}
+
+	mockService := new(hDMock.MockHomeDeviceService)
+
+	mockService.On("UpdateHomeDevice", mock.Anything, request, id, int64(0)).Return(nil, hDError.ErrUnknownRoom.New())
+
+	response, _ := HandleRequest(context.TODO(), request, id, "", mockService)
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Room Not Found for the roomId")
+}
+
 func TestHandleRequest_NoFiledToUpdate(t *testing.T) {
 
 	id := uuid.New().String()
@@ -93,11 +126,9 @@ func TestHandleRequest_NoFiledToUpdate(t *testing.T) {
 
 	mockService := new(hDMock.MockHomeDeviceService)
 
-	mockService.On("UpdateHomeDevice", mock.Anything, request, id).Return(&hDError.HomeDeviceError{
-		ErrorCode: hDConstants.ErrNoFieldToUpdateCode,
-	})
+	mockService.On("UpdateHomeDevice", mock.Anything, request, id, int64(0)).Return(nil, hDError.ErrNoFieldToUpdate.New())
 
-	response, _ := HandleRequest(context.TODO(), request, id, mockService)
+	response, _ := HandleRequest(context.TODO(), request, id, "", mockService)
 
 	assert.Equal(t, 400, response.StatusCode)
 	assert.Contains(t, response.Body, "Please enter a value property to update")
@@ -116,12 +147,71 @@ func TestHandleRequest_InternalServerError(t *testing.T) {
 
 	mockService := new(hDMock.MockHomeDeviceService)
 
-	mockService.On("UpdateHomeDevice", mock.Anything, request, id).Return(&hDError.HomeDeviceError{
-		ErrorCode: hDConstants.ErrUpdatingDeviceCode,
-	})
+	mockService.On("UpdateHomeDevice", mock.Anything, request, id, int64(0)).Return(nil, hDError.ErrUpdatingDevice.New())
 
-	response, _ := HandleRequest(context.TODO(), request, id, mockService)
+	response, _ := HandleRequest(context.TODO(), request, id, "", mockService)
 
 	assert.Equal(t, 500, response.StatusCode)
 	assert.Contains(t, response.Body, "Internal Server error updating a device")
 }
+
+func TestHandleRequest_IfMatch(t *testing.T) {
+	mockService := new(hDMock.MockHomeDeviceService)
+
+	id := uuid.New().String()
+
+	request := hDRequest.UpdateDeviceRequest{
+		Name: "Living Room Light",
+	}
+
+	mockService.On("UpdateHomeDevice", mock.Anything, request, id, int64(3)).Return(&hDResponse.HomdeDeviceResponse{ID: id, Version: 4}, nil)
+
+	response, err := HandleRequest(context.TODO(), request, id, "\"3\"", mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 201, response.StatusCode)
+	assert.Equal(t, "\"4\"", response.Headers["ETag"])
+
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_InvalidIfMatch(t *testing.T) {
+
+	request := hDRequest.UpdateDeviceRequest{
+		Name: "Living Room Light",
+	}
+
+	response, _ := HandleRequest(context.TODO(), request, uuid.New().String(), "\"abc\"", new(hDMock.MockHomeDeviceService))
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Invalid If-Match header")
+}
+
+func TestHandleRequest_WeakIfMatch(t *testing.T) {
+
+	request := hDRequest.UpdateDeviceRequest{
+		Name: "Living Room Light",
+	}
+
+	response, _ := HandleRequest(context.TODO(), request, uuid.New().String(), "W/\"3\"", new(hDMock.MockHomeDeviceService))
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Invalid If-Match header")
+}
+
+func TestHandleRequest_VersionConflict(t *testing.T) {
+
+	id := uuid.New().String()
+
+	request := hDRequest.UpdateDeviceRequest{
+		Name: "Living Room Light",
+	}
+
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockService.On("UpdateHomeDevice", mock.Anything, request, id, int64(2)).Return(nil, hDError.ErrVersionConflict.New())
+
+	response, _ := HandleRequest(context.TODO(), request, id, "\"2\"", mockService)
+
+	assert.Equal(t, 412, response.StatusCode)
+	assert.Contains(t, response.Body, "The device was modified by another request")
+}
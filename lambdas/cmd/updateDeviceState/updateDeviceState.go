@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	hDHandler "github.com/odhoman/home-devices/internal/handler"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDService "github.com/odhoman/home-devices/internal/service"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+func HandleRequest(ctx context.Context, id string, stateRequest hDRequest.UpdateDeviceStateRequest, deviceStateService hDService.DeviceStateService) (events.APIGatewayProxyResponse, error) {
+	return hDHandler.UpdateDeviceState(ctx, id, stateRequest, deviceStateService)
+}
+
+func main() {
+
+	lambda.Start(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			log.Fatalf("unable to load SDK config for updateDeviceState lambda function, %v", err)
+		}
+
+		return hDHandler.WithErrorNegotiation(hDHandler.UpdateDeviceStateFromAPIGateway)(ctx, request, hDService.NewDeviceStateServiceImplFromConfig(cfg))
+	})
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+	"github.com/odhoman/home-devices/internal/mock"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	mock.RunTestMain(m)
+}
+
+func TestUpdateDeviceState_DesiredAndReported(t *testing.T) {
+
+	svc := mock.GetDynamoConnectionTestFromEnpoint()
+	homeDeviceServiceImpl := hDService.NewHomeDeviceServiceImpl2(dao.HomeDeviceDaoImpl{DynamoDbApi: svc})
+	deviceStateServiceImpl := hDService.NewDeviceStateServiceImpl(dao.DeviceShadowDaoImpl{DynamoDbApi: svc}, homeDeviceServiceImpl)
+
+	ctx := context.Background()
+
+	device, err := homeDeviceServiceImpl.CreateHomeDevice(ctx, hDRequest.CreateDeviceRequest{
+		MAC:    "30:1A:2B:3C:4D:01",
+		Name:   "Living Room Light",
+		Type:   "light",
+		HomeID: "homeDeviceState",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error creating new Device for testing. Error: %v", err)
+	}
+
+	state := updateDeviceStateForTesting(t, device.ID, hDRequest.UpdateDeviceStateRequest{
+		Desired: map[string]interface{}{"on": true, "brightness": 80},
+	}, deviceStateServiceImpl)
+
+	assert.Equal(t, int64(1), state.Desired.Version)
+	assert.Equal(t, map[string]interface{}{"on": true, "brightness": 80.0}, state.Delta)
+
+	state = updateDeviceStateForTesting(t, device.ID, hDRequest.UpdateDeviceStateRequest{
+		Reported: map[string]interface{}{"on": true},
+	}, deviceStateServiceImpl)
+
+	assert.Equal(t, int64(1), state.Desired.Version)
+	assert.Equal(t, int64(1), state.Reported.Version)
+	assert.Equal(t, map[string]interface{}{"brightness": 80.0}, state.Delta)
+
+	response, _ := HandleRequest(ctx, device.ID, hDRequest.UpdateDeviceStateRequest{
+		Desired:        map[string]interface{}{"brightness": 60},
+		DesiredVersion: 5,
+	}, deviceStateServiceImpl)
+
+	assert.Equal(t, 412, response.StatusCode)
+}
+
+func updateDeviceStateForTesting(t *testing.T, id string, stateRequest hDRequest.UpdateDeviceStateRequest, service hDService.DeviceStateService) *hDResponse.DeviceStateResponse {
+
+	response, err := HandleRequest(context.Background(), id, stateRequest, service)
+
+	if err != nil || response.StatusCode != 200 {
+		t.Fatalf("Unexpected error updating the Device state for testing. Response: %v", response.Body)
+		return nil
+	}
+
+	var state hDResponse.DeviceStateResponse
+	if err := json.Unmarshal([]byte(response.Body), &state); err != nil {
+		t.Fatalf("Unexpected error deserializing the Device state. Error: %v", err)
+	}
+
+	return &state
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDHandler "github.com/odhoman/home-devices/internal/handler"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockDeviceStateService)
+
+	stateRequest := hDRequest.UpdateDeviceStateRequest{
+		Desired:        map[string]interface{}{"power": "on"},
+		DesiredVersion: 2,
+	}
+
+	state := &hDResponse.DeviceStateResponse{
+		DeviceID: "id",
+		Desired:  hDResponse.DeviceStateDocumentResponse{State: map[string]interface{}{"power": "on"}, Version: 3},
+		Reported: hDResponse.DeviceStateDocumentResponse{State: map[string]interface{}{"power": "off"}, Version: 5},
+		Delta:    map[string]interface{}{"power": "on"},
+	}
+
+	mockService.On("UpdateDeviceState", mock.Anything, "id", stateRequest).Return(state, nil)
+
+	response, err := HandleRequest(context.TODO(), "id", stateRequest, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	expectedBody, _ := json.Marshal(state)
+	assert.JSONEq(t, string(expectedBody), response.Body)
+}
+
+func TestHandleRequest_ValidationError(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), "id", hDRequest.UpdateDeviceStateRequest{
+		Desired:        map[string]interface{}{"power": "on"},
+		DesiredVersion: -1,
+	}, new(hDMock.MockDeviceStateService))
+
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestHandleRequest_InvalidBody(t *testing.T) {
+
+	response, _ := hDHandler.UpdateDeviceStateFromAPIGateway(context.TODO(), events.APIGatewayProxyRequest{
+		PathParameters: map[string]string{"id": "id"},
+		Body:           `{"desired": "on"}`,
+	}, new(hDMock.MockDeviceStateService))
+
+	assert.Equal(t, 400, response.StatusCode)
+}
+
+func TestHandleRequest_Errors(t *testing.T) {
+
+	tests := []struct {
+		err        error
+		statusCode int
+	}{
+		{hDError.ErrDeviceNotFound.New(), 404},
+		{hDError.ErrVersionConflict.New(), 412},
+		{hDError.ErrNoStateToUpdate.New(), 400},
+		{hDError.ErrUpdatingDeviceState.New(), 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(hDError.From(tt.err).ErrorCode, func(t *testing.T) {
+			mockService := new(hDMock.MockDeviceStateService)
+			mockService.On("UpdateDeviceState", mock.Anything, "id", mock.Anything).Return(nil, tt.err)
+
+			response, _ := HandleRequest(context.TODO(), "id", hDRequest.UpdateDeviceStateRequest{}, mockService)
+
+			assert.Equal(t, tt.statusCode, response.StatusCode)
+		})
+	}
+}
+
+func TestHandleRequest_InvalidState(t *testing.T) {
+	mockService := new(hDMock.MockDeviceStateService)
+	mockService.On("UpdateDeviceState", mock.Anything, "id", mock.Anything).Return(nil, hDError.ErrInvalidDeviceState.WithMessage("brightness must be between 0 and 100"))
+
+	response, _ := HandleRequest(context.TODO(), "id", hDRequest.UpdateDeviceStateRequest{
+		Desired: map[string]interface{}{"brightness": 120.0},
+	}, mockService)
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "brightness must be between 0 and 100")
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockHomeService)
+
+	homeRequest := hDRequest.UpdateHomeRequest{Name: "Mountain House"}
+	mockService.On("UpdateHome", mock.Anything, homeRequest, "home12122", int64(2)).Return(nil)
+
+	response, err := HandleRequest(context.TODO(), homeRequest, "home12122", `"2"`, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.JSONEq(t, `{"message": "Home updated"}`, response.Body)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_ValidationError(t *testing.T) {
+	mockService := new(hDMock.MockHomeService)
+
+	response, _ := HandleRequest(context.TODO(), hDRequest.UpdateHomeRequest{Timezone: "Europe/Nowhere"}, "home12122", "", mockService)
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Please enter a valid IANA timezone, e.g. Europe/Madrid")
+	mockService.AssertNotCalled(t, "UpdateHome", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleRequest_InvalidIfMatch(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), hDRequest.UpdateHomeRequest{Name: "Mountain House"}, "home12122", "abc", new(hDMock.MockHomeService))
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Invalid If-Match header")
+}
+
+func TestHandleRequest_Errors(t *testing.T) {
+
+	tests := []struct {
+		err        error
+		statusCode int
+		message    string
+	}{
+		{hDError.ErrHomeNotFound.New(), 404, "Home Not Found"},
+		{hDError.ErrVersionConflict.WithMessage(hDError.HomeVersionConflictMessage), 412, "The home was modified by another request"},
+		{hDError.ErrNoFieldToUpdate.New(), 400, "Please enter a value property to update"},
+		{hDError.ErrUpdatingHome.New(), 500, "Internal Server error updating a home"},
+	}
+
+	for _, test := range tests {
+		t.Run(hDError.From(test.err).ErrorCode, func(t *testing.T) {
+			mockService := new(hDMock.MockHomeService)
+			mockService.On("UpdateHome", mock.Anything, mock.Anything, "home12122", int64(0)).Return(test.err)
+
+			response, _ := HandleRequest(context.TODO(), hDRequest.UpdateHomeRequest{}, "home12122", "", mockService)
+
+			assert.Equal(t, test.statusCode, response.StatusCode)
+			assert.Contains(t, response.Body, test.message)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockRoomService)
+
+	roomRequest := hDRequest.UpdateRoomRequest{Name: "Kitchen"}
+	mockService.On("UpdateRoom", mock.Anything, roomRequest, "home12122", "8d3c1b6e-2f4a-4c51-9a57-0d6f4f1f2b11", int64(2)).Return(nil)
+
+	response, err := HandleRequest(context.TODO(), roomRequest, "home12122", "8d3c1b6e-2f4a-4c51-9a57-0d6f4f1f2b11", `"2"`, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.JSONEq(t, `{"message": "Room updated"}`, response.Body)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_ValidationError(t *testing.T) {
+	mockService := new(hDMock.MockRoomService)
+
+	response, _ := HandleRequest(context.TODO(), hDRequest.UpdateRoomRequest{Name: "K"}, "home12122", "8d3c1b6e-2f4a-4c51-9a57-0d6f4f1f2b11", "", mockService)
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Name must be between 3 and 50 characters")
+	mockService.AssertNotCalled(t, "UpdateRoom", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleRequest_InvalidIfMatch(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), hDRequest.UpdateRoomRequest{Name: "Kitchen"}, "home12122", "8d3c1b6e-2f4a-4c51-9a57-0d6f4f1f2b11", "abc", new(hDMock.MockRoomService))
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Invalid If-Match header")
+}
+
+func TestHandleRequest_Errors(t *testing.T) {
+
+	tests := []struct {
+		err        error
+		statusCode int
+		message    string
+	}{
+		{hDError.ErrRoomNotFound.New(), 404, "Room Not Found"},
+		{hDError.ErrVersionConflict.WithMessage(hDError.RoomVersionConflictMessage), 412, "The room was modified by another request"},
+		{hDError.ErrUpdatingRoom.New(), 500, "Internal Server error updating a room"},
+	}
+
+	for _, test := range tests {
+		t.Run(hDError.From(test.err).ErrorCode, func(t *testing.T) {
+			mockService := new(hDMock.MockRoomService)
+			mockService.On("UpdateRoom", mock.Anything, mock.Anything, "home12122", "8d3c1b6e-2f4a-4c51-9a57-0d6f4f1f2b11", int64(0)).Return(test.err)
+
+			response, _ := HandleRequest(context.TODO(), hDRequest.UpdateRoomRequest{Name: "Kitchen"}, "home12122", "8d3c1b6e-2f4a-4c51-9a57-0d6f4f1f2b11", "", mockService)
+
+			assert.Equal(t, test.statusCode, response.StatusCode)
+			assert.Contains(t, response.Body, test.message)
+		})
+	}
+}
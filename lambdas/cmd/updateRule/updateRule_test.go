@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockRuleService)
+
+	ruleRequest := newRuleRequest()
+	mockService.On("UpdateRule", mock.Anything, ruleRequest, "home12122", "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", int64(2)).Return(nil)
+
+	response, err := HandleRequest(context.TODO(), ruleRequest, "home12122", "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", `"2"`, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.JSONEq(t, `{"message": "Rule updated"}`, response.Body)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_ValidationError(t *testing.T) {
+	mockService := new(hDMock.MockRuleService)
+
+	ruleRequest := newRuleRequest()
+	ruleRequest.Action.Command = "on"
+
+	response, _ := HandleRequest(context.TODO(), ruleRequest, "home12122", "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", "", mockService)
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Command must be between 3 and 50 characters")
+	mockService.AssertNotCalled(t, "UpdateRule", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleRequest_InvalidIfMatch(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), newRuleRequest(), "home12122", "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", "abc", new(hDMock.MockRuleService))
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Invalid If-Match header")
+}
+
+func TestHandleRequest_Errors(t *testing.T) {
+
+	tests := []struct {
+		errorCode    string
+		errorMessage string
+		statusCode   int
+		message      string
+	}{
+		{hDError.ErrInvalidRule.Code, "motion must be 0 or 1", 400, "motion must be 0 or 1"},
+		{hDError.ErrRuleNotFound.Code, "", 404, "Rule Not Found"},
+		{hDError.ErrHomeNotFound.Code, "", 404, "Home Not Found"},
+		{hDError.ErrVersionConflict.Code, hDError.RuleVersionConflictMessage, 412, "The rule was modified by another request"},
+		{hDError.ErrUpdatingRule.Code, "", 500, "Internal Server error updating a rule"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.errorCode, func(t *testing.T) {
+			mockService := new(hDMock.MockRuleService)
+			mockService.On("UpdateRule", mock.Anything, mock.Anything, "home12122", "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", int64(0)).Return(&hDError.HomeDeviceError{ErrorCode: test.errorCode, ErrorMessage: test.errorMessage})
+
+			response, _ := HandleRequest(context.TODO(), newRuleRequest(), "home12122", "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", "", mockService)
+
+			assert.Equal(t, test.statusCode, response.StatusCode)
+			assert.Contains(t, response.Body, test.message)
+		})
+	}
+}
+
+func newRuleRequest() hDRequest.RuleRequest {
+	value := 1.0
+	return hDRequest.RuleRequest{
+		Name:    "Hallway light at night",
+		Trigger: hDRequest.RuleTrigger{DeviceID: "sensor-1", Metric: "motion", Operator: "eq", Value: &value},
+		Action:  hDRequest.RuleAction{DeviceID: "light-1", Command: "turnOn"},
+	}
+}
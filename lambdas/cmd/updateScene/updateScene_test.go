@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockSceneService)
+
+	sceneRequest := newSceneRequest()
+	mockService.On("UpdateScene", mock.Anything, sceneRequest, "home12122", "2f6c8d1a-4b3e-4f7a-8c9d-0e1f2a3b4c5d", int64(2)).Return(nil)
+
+	response, err := HandleRequest(context.TODO(), sceneRequest, "home12122", "2f6c8d1a-4b3e-4f7a-8c9d-0e1f2a3b4c5d", `"2"`, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.JSONEq(t, `{"message": "Scene updated"}`, response.Body)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_ValidationError(t *testing.T) {
+	mockService := new(hDMock.MockSceneService)
+
+	sceneRequest := newSceneRequest()
+	sceneRequest.Devices[0].DeviceID = ""
+
+	response, _ := HandleRequest(context.TODO(), sceneRequest, "home12122", "2f6c8d1a-4b3e-4f7a-8c9d-0e1f2a3b4c5d", "", mockService)
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Device ID is required")
+	mockService.AssertNotCalled(t, "UpdateScene", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleRequest_InvalidIfMatch(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), newSceneRequest(), "home12122", "2f6c8d1a-4b3e-4f7a-8c9d-0e1f2a3b4c5d", "abc", new(hDMock.MockSceneService))
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Invalid If-Match header")
+}
+
+func TestHandleRequest_Errors(t *testing.T) {
+
+	tests := []struct {
+		errorCode    string
+		errorMessage string
+		statusCode   int
+		message      string
+	}{
+		{hDError.ErrInvalidScene.Code, "the device light-1: brightness must be between 0 and 100", 400, "brightness must be between 0 and 100"},
+		{hDError.ErrSceneNotFound.Code, "", 404, "Scene Not Found"},
+		{hDError.ErrHomeNotFound.Code, "", 404, "Home Not Found"},
+		{hDError.ErrVersionConflict.Code, hDError.SceneVersionConflictMessage, 412, "The scene was modified by another request"},
+		{hDError.ErrUpdatingScene.Code, "", 500, "Internal Server error updating a scene"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.errorCode, func(t *testing.T) {
+			mockService := new(hDMock.MockSceneService)
+			mockService.On("UpdateScene", mock.Anything, mock.Anything, "home12122", "2f6c8d1a-4b3e-4f7a-8c9d-0e1f2a3b4c5d", int64(0)).Return(&hDError.HomeDeviceError{ErrorCode: test.errorCode, ErrorMessage: test.errorMessage})
+
+			response, _ := HandleRequest(context.TODO(), newSceneRequest(), "home12122", "2f6c8d1a-4b3e-4f7a-8c9d-0e1f2a3b4c5d", "", mockService)
+
+			assert.Equal(t, test.statusCode, response.StatusCode)
+			assert.Contains(t, response.Body, test.message)
+		})
+	}
+}
+
+func newSceneRequest() hDRequest.SceneRequest {
+	return hDRequest.SceneRequest{
+		Name: "Good night",
+		Devices: []hDRequest.SceneDevice{
+			{DeviceID: "light-1", State: map[string]interface{}{"on": false}},
+		},
+	}
+}
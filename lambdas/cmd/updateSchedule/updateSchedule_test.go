@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleRequest_Success(t *testing.T) {
+	mockService := new(hDMock.MockScheduleService)
+
+	scheduleRequest := newScheduleRequest()
+	mockService.On("UpdateSchedule", mock.Anything, scheduleRequest, "home12122", "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", int64(2)).Return(nil)
+
+	response, err := HandleRequest(context.TODO(), scheduleRequest, "home12122", "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", `"2"`, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.JSONEq(t, `{"message": "Schedule updated"}`, response.Body)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandleRequest_ValidationError(t *testing.T) {
+	mockService := new(hDMock.MockScheduleService)
+
+	scheduleRequest := newScheduleRequest()
+	scheduleRequest.Command = "on"
+
+	response, _ := HandleRequest(context.TODO(), scheduleRequest, "home12122", "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", "", mockService)
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Command must be between 3 and 50 characters")
+	mockService.AssertNotCalled(t, "UpdateSchedule", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleRequest_InvalidIfMatch(t *testing.T) {
+
+	response, _ := HandleRequest(context.TODO(), newScheduleRequest(), "home12122", "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", "abc", new(hDMock.MockScheduleService))
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "Invalid If-Match header")
+}
+
+func TestHandleRequest_Errors(t *testing.T) {
+
+	tests := []struct {
+		errorCode    string
+		errorMessage string
+		statusCode   int
+		message      string
+	}{
+		{hDError.ErrInvalidSchedule.Code, "the schedule does not run after now", 400, "the schedule does not run after now"},
+		{hDError.ErrScheduleNotFound.Code, "", 404, "Schedule Not Found"},
+		{hDError.ErrHomeNotFound.Code, "", 404, "Home Not Found"},
+		{hDError.ErrVersionConflict.Code, hDError.ScheduleVersionConflictMessage, 412, "The schedule was modified by another request"},
+		{hDError.ErrUpdatingSchedule.Code, "", 500, "Internal Server error updating a schedule"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.errorCode, func(t *testing.T) {
+			mockService := new(hDMock.MockScheduleService)
+			mockService.On("UpdateSchedule", mock.Anything, mock.Anything, "home12122", "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", int64(0)).Return(&hDError.HomeDeviceError{ErrorCode: test.errorCode, ErrorMessage: test.errorMessage})
+
+			response, _ := HandleRequest(context.TODO(), newScheduleRequest(), "home12122", "5b1f3a0e-7c2d-4e8f-9a61-3d2c1b0a9f88", "", mockService)
+
+			assert.Equal(t, test.statusCode, response.StatusCode)
+			assert.Contains(t, response.Body, test.message)
+		})
+	}
+}
+
+func newScheduleRequest() hDRequest.ScheduleRequest {
+	return hDRequest.ScheduleRequest{
+		Name:    "Lights off at midnight",
+		RRule:   "FREQ=DAILY;BYHOUR=0;BYMINUTE=0",
+		Target:  hDRequest.ScheduleTarget{DeviceType: "light"},
+		Command: "turnOff",
+	}
+}
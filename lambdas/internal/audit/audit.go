@@ -0,0 +1,102 @@
+package audit
+
+import (
+	"context"
+
+	response "github.com/odhoman/home-devices/internal/response"
+)
+
+// Sources of a device change.
+const (
+	SourceAPI         = "API"
+	SourceSQSListener = "SQS_LISTENER"
+	SourceAdmin       = "ADMIN"
+	SourcePresence    = "PRESENCE"
+	SourceUnknown     = "UNKNOWN"
+)
+
+// Operations recorded in the device history.
+const (
+	OperationCreate     = "CREATE"
+	OperationUpdate     = "UPDATE"
+	OperationDelete     = "DELETE"
+	OperationHardDelete = "HARD_DELETE"
+	OperationRestore    = "RESTORE"
+	// the device went online or offline, its version does not change
+	OperationStatusChange = "STATUS_CHANGE"
+)
+
+const UnknownActor = "unknown"
+
+type contextKey string
+
+const (
+	actorContextKey  contextKey = "auditActor"
+	sourceContextKey contextKey = "auditSource"
+)
+
+// WithActor returns a context that records changes as made by actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey, actor)
+}
+
+// WithSource returns a context that records changes as coming from source.
+func WithSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, sourceContextKey, source)
+}
+
+// WithDefaultSource sets the source unless the context already has one.
+func WithDefaultSource(ctx context.Context, source string) context.Context {
+	if _, ok := ctx.Value(sourceContextKey).(string); ok {
+		return ctx
+	}
+	return WithSource(ctx, source)
+}
+
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorContextKey).(string); ok && actor != "" {
+		return actor
+	}
+	return UnknownActor
+}
+
+func SourceFromContext(ctx context.Context) string {
+	if source, ok := ctx.Value(sourceContextKey).(string); ok && source != "" {
+		return source
+	}
+	return SourceUnknown
+}
+
+// Diff returns the fields that changed between before and after.
+func Diff(before *response.HomdeDeviceResponse, after *response.HomdeDeviceResponse) []response.DeviceFieldChange {
+
+	beforeFields := deviceFields(before)
+	afterFields := deviceFields(after)
+
+	changes := []response.DeviceFieldChange{}
+	for _, field := range []string{"mac", "name", "type", "homeId", "roomId"} {
+		if beforeFields[field] != afterFields[field] {
+			changes = append(changes, response.DeviceFieldChange{
+				Field:  field,
+				Before: beforeFields[field],
+				After:  afterFields[field],
+			})
+		}
+	}
+
+	return changes
+}
+
+func deviceFields(device *response.HomdeDeviceResponse) map[string]string {
+	if device == nil {
+		return map[string]string{}
+	}
+
+	return map[string]string{
+		"mac":    device.MAC,
+		"name":   device.Name,
+		"type":   device.Type,
+		"homeId": device.HomeID,
+		"roomId": device.RoomID,
+	}
+}
@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActorAndSourceFromContext(t *testing.T) {
+
+	ctx := WithSource(WithActor(context.Background(), "user-1"), SourceAdmin)
+
+	assert.Equal(t, "user-1", ActorFromContext(ctx))
+	assert.Equal(t, SourceAdmin, SourceFromContext(ctx))
+}
+
+func TestActorAndSourceFromContext_Defaults(t *testing.T) {
+
+	assert.Equal(t, UnknownActor, ActorFromContext(context.Background()))
+	assert.Equal(t, SourceUnknown, SourceFromContext(context.Background()))
+}
+
+func TestWithDefaultSource(t *testing.T) {
+
+	assert.Equal(t, SourceAPI, SourceFromContext(WithDefaultSource(context.Background(), SourceAPI)))
+	assert.Equal(t, SourceAdmin, SourceFromContext(WithDefaultSource(WithSource(context.Background(), SourceAdmin), SourceAPI)))
+}
+
+func TestDiff_Update(t *testing.T) {
+
+	before := &response.HomdeDeviceResponse{MAC: "00:1A:2B:3C:4D:5E", Name: "Light", Type: "light", HomeID: "home1"}
+	after := &response.HomdeDeviceResponse{MAC: "00:1A:2B:3C:4D:5E", Name: "Light", Type: "light", HomeID: "home2"}
+
+	assert.Equal(t, []response.DeviceFieldChange{{Field: "homeId", Before: "home1", After: "home2"}}, Diff(before, after))
+}
+
+func TestDiff_MoveClearsTheRoom(t *testing.T) {
+
+	before := &response.HomdeDeviceResponse{MAC: "00:1A:2B:3C:4D:5E", Name: "Light", Type: "light", HomeID: "home1", RoomID: "room1"}
+	after := &response.HomdeDeviceResponse{MAC: "00:1A:2B:3C:4D:5E", Name: "Light", Type: "light", HomeID: "home2"}
+
+	assert.Equal(t, []response.DeviceFieldChange{
+		{Field: "homeId", Before: "home1", After: "home2"},
+		{Field: "roomId", Before: "room1"},
+	}, Diff(before, after))
+}
+
+func TestDiff_CreateAndDelete(t *testing.T) {
+
+	device := &response.HomdeDeviceResponse{MAC: "00:1A:2B:3C:4D:5E", Name: "Light", Type: "light", HomeID: "home1"}
+
+	created := Diff(nil, device)
+	assert.Len(t, created, 4)
+	assert.Equal(t, response.DeviceFieldChange{Field: "mac", After: "00:1A:2B:3C:4D:5E"}, created[0])
+
+	deleted := Diff(device, nil)
+	assert.Len(t, deleted, 4)
+	assert.Equal(t, response.DeviceFieldChange{Field: "homeId", Before: "home1"}, deleted[3])
+
+	assert.Empty(t, Diff(nil, nil))
+}
@@ -0,0 +1,320 @@
+package capability
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Types of the values of attributes, state fields and command params.
+const (
+	ValueTypeBoolean = "boolean"
+	ValueTypeNumber  = "number"
+	ValueTypeString  = "string"
+	ValueTypeEnum    = "enum"
+)
+
+// ValueSchema describes the values accepted for an attribute, a state field or
+// a command param.
+type ValueSchema struct {
+	Type   string   `json:"type"`
+	Unit   string   `json:"unit,omitempty"`
+	Min    *float64 `json:"min,omitempty"`
+	Max    *float64 `json:"max,omitempty"`
+	Values []string `json:"values,omitempty"`
+}
+
+// Attribute is a fixed feature of the devices of a type.
+type Attribute struct {
+	Name string `json:"name"`
+	ValueSchema
+}
+
+// StateField is a key of the state of the devices of a type.
+type StateField struct {
+	Name string `json:"name"`
+	ValueSchema
+	Writable bool `json:"writable"`
+}
+
+type CommandParam struct {
+	Name string `json:"name"`
+	ValueSchema
+}
+
+type Command struct {
+	Name   string         `json:"name"`
+	Params []CommandParam `json:"params"`
+}
+
+// DefaultOfflineAfterSeconds is the presence timeout of the devices whose type
+// is not in the registry.
+const DefaultOfflineAfterSeconds = 600
+
+// DeviceType is the schema of the devices of a type.
+type DeviceType struct {
+	Name                string       `json:"name"`
+	Attributes          []Attribute  `json:"attributes"`
+	StateFields         []StateField `json:"stateFields"`
+	Commands            []Command    `json:"commands"`
+	OfflineAfterSeconds int64        `json:"offlineAfterSeconds"`
+}
+
+// Get returns the device type with the name. Names are case sensitive.
+func Get(name string) (DeviceType, bool) {
+	deviceType, found := registry[name]
+	return deviceType, found
+}
+
+// List returns the device types sorted by name.
+func List() []DeviceType {
+
+	deviceTypes := make([]DeviceType, 0, len(registry))
+	for _, name := range Names() {
+		deviceTypes = append(deviceTypes, registry[name])
+	}
+
+	return deviceTypes
+}
+
+// Names returns the names of the device types sorted.
+func Names() []string {
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// OfflineAfter returns the presence timeout of the type, or the default one
+// when the type is not in the registry.
+func OfflineAfter(name string) time.Duration {
+
+	if deviceType, found := registry[name]; found && deviceType.OfflineAfterSeconds > 0 {
+		return time.Duration(deviceType.OfflineAfterSeconds) * time.Second
+	}
+
+	return DefaultOfflineAfterSeconds * time.Second
+}
+
+// MinOfflineAfter returns the shortest presence timeout of all the types.
+func MinOfflineAfter() time.Duration {
+
+	min := OfflineAfter("")
+	for name := range registry {
+		if offlineAfter := OfflineAfter(name); offlineAfter < min {
+			min = offlineAfter
+		}
+	}
+
+	return min
+}
+
+func (dT DeviceType) StateField(name string) (StateField, bool) {
+	for _, field := range dT.StateFields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return StateField{}, false
+}
+
+func (dT DeviceType) Command(name string) (Command, bool) {
+	for _, command := range dT.Commands {
+		if command.Name == name {
+			return command, true
+		}
+	}
+	return Command{}, false
+}
+
+// ValidateState checks a patch of the desired or reported state against the
+// state fields of the type.
+func (dT DeviceType) ValidateState(patch map[string]interface{}, desired bool) []string {
+
+	var validationErrors []string
+
+	for _, key := range sortedKeys(patch) {
+
+		field, found := dT.StateField(key)
+		if !found {
+			validationErrors = append(validationErrors, fmt.Sprintf("%v is not a state field of the %v devices", key, dT.Name))
+			continue
+		}
+
+		if desired && !field.Writable {
+			validationErrors = append(validationErrors, fmt.Sprintf("%v is reported by the %v devices and can not be desired", key, dT.Name))
+			continue
+		}
+
+		if patch[key] == nil {
+			continue
+		}
+
+		if message := field.check(key, patch[key]); message != "" {
+			validationErrors = append(validationErrors, message)
+		}
+	}
+
+	return validationErrors
+}
+
+// ValidateReading checks a telemetry reading against the state fields of the
+// type.
+func (dT DeviceType) ValidateReading(metric string, value float64, unit string) []string {
+
+	field, found := dT.StateField(metric)
+	if !found || (field.Type != ValueTypeNumber && field.Type != ValueTypeBoolean) {
+		return []string{fmt.Sprintf("%v is not a metric of the %v devices", metric, dT.Name)}
+	}
+
+	if unit != "" && unit != field.Unit {
+		return []string{fmt.Sprintf("%v must be in %v", metric, field.Unit)}
+	}
+
+	if field.Type == ValueTypeBoolean {
+		if value != 0 && value != 1 {
+			return []string{fmt.Sprintf("%v must be 0 or 1", metric)}
+		}
+		return nil
+	}
+
+	if message := field.check(metric, value); message != "" {
+		return []string{message}
+	}
+
+	return nil
+}
+
+// ReadingValue returns the value of a valid reading as it is kept in the
+// state of the device: a boolean for the boolean fields and the number for
+// the rest.
+func (dT DeviceType) ReadingValue(metric string, value float64) interface{} {
+
+	if field, found := dT.StateField(metric); found && field.Type == ValueTypeBoolean {
+		return value == 1
+	}
+
+	return value
+}
+
+// ValidateCommand checks a command against the commands of the type.
+func (dT DeviceType) ValidateCommand(name string, params map[string]interface{}) []string {
+
+	command, found := dT.Command(name)
+	if !found {
+		return []string{fmt.Sprintf("%v is not a command of the %v devices", name, dT.Name)}
+	}
+
+	var validationErrors []string
+
+	for _, param := range command.Params {
+		value, sent := params[param.Name]
+		if !sent || value == nil {
+			validationErrors = append(validationErrors, fmt.Sprintf("%v is required by the %v command", param.Name, name))
+			continue
+		}
+
+		if message := param.check(param.Name, value); message != "" {
+			validationErrors = append(validationErrors, message)
+		}
+	}
+
+	for _, key := range sortedKeys(params) {
+		if !command.hasParam(key) {
+			validationErrors = append(validationErrors, fmt.Sprintf("%v is not a parameter of the %v command", key, name))
+		}
+	}
+
+	return validationErrors
+}
+
+func (c Command) hasParam(name string) bool {
+	for _, param := range c.Params {
+		if param.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// check returns why the value does not follow the schema, or an empty string.
+func (vS ValueSchema) check(name string, value interface{}) string {
+
+	switch vS.Type {
+	case ValueTypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return fmt.Sprintf("%v must be a boolean", name)
+		}
+	case ValueTypeString:
+		if _, ok := value.(string); !ok {
+			return fmt.Sprintf("%v must be a string", name)
+		}
+	case ValueTypeEnum:
+		text, ok := value.(string)
+		if !ok || !contains(vS.Values, text) {
+			return fmt.Sprintf("%v must be one of %v", name, strings.Join(vS.Values, ", "))
+		}
+	case ValueTypeNumber:
+		number, ok := toNumber(value)
+		if !ok {
+			return fmt.Sprintf("%v must be a number", name)
+		}
+		if (vS.Min != nil && number < *vS.Min) || (vS.Max != nil && number > *vS.Max) {
+			return fmt.Sprintf("%v must be %v", name, vS.describeRange())
+		}
+	}
+
+	return ""
+}
+
+func (vS ValueSchema) describeRange() string {
+	switch {
+	case vS.Min != nil && vS.Max != nil:
+		return fmt.Sprintf("between %v and %v", *vS.Min, *vS.Max)
+	case vS.Min != nil:
+		return fmt.Sprintf("at least %v", *vS.Min)
+	default:
+		return fmt.Sprintf("at most %v", *vS.Max)
+	}
+}
+
+// toNumber accepts the numbers decoded from JSON and the ones set from Go.
+func toNumber(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case float64:
+		return number, true
+	case float32:
+		return float64(number), true
+	case int:
+		return float64(number), true
+	case int64:
+		return float64(number), true
+	default:
+		return 0, false
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(document map[string]interface{}) []string {
+
+	keys := make([]string, 0, len(document))
+	for key := range document {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,158 @@
+package capability
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+
+	light, found := Get("light")
+	assert.True(t, found)
+	assert.Equal(t, "light", light.Name)
+
+	// names are case sensitive
+	_, found = Get("Light")
+	assert.False(t, found)
+
+	_, found = Get("lihgt")
+	assert.False(t, found)
+}
+
+func TestList_SortedByName(t *testing.T) {
+
+	names := []string{}
+	for _, deviceType := range List() {
+		names = append(names, deviceType.Name)
+	}
+
+	assert.Equal(t, []string{"light", "lock", "plug", "sensor", "thermostat"}, names)
+	assert.Equal(t, names, Names())
+}
+
+func TestRegistry_NamesMatchKeys(t *testing.T) {
+
+	for name, deviceType := range registry {
+		assert.Equal(t, name, deviceType.Name)
+	}
+}
+
+func TestValidateState_Desired(t *testing.T) {
+
+	light, _ := Get("light")
+
+	assert.Empty(t, light.ValidateState(decode(t, `{"on":true,"brightness":80,"colorTemperature":null}`), true))
+
+	assert.Equal(t, []string{
+		"brightness must be between 0 and 100",
+		"color is not a state field of the light devices",
+		"on must be a boolean",
+		"power is reported by the light devices and can not be desired",
+	}, light.ValidateState(decode(t, `{"on":"yes","brightness":120,"color":"red","power":12}`), true))
+}
+
+func TestValidateState_Reported(t *testing.T) {
+
+	thermostat, _ := Get("thermostat")
+
+	assert.Empty(t, thermostat.ValidateState(decode(t, `{"temperature":21.5,"humidity":40,"mode":"heat"}`), false))
+
+	assert.Equal(t, []string{
+		"mode must be one of off, heat, cool, auto",
+		"temperature must be a number",
+	}, thermostat.ValidateState(decode(t, `{"mode":"eco","temperature":"21"}`), false))
+}
+
+func TestValidateReading(t *testing.T) {
+
+	sensor, _ := Get("sensor")
+
+	assert.Empty(t, sensor.ValidateReading("temperature", 21.5, "celsius"))
+	assert.Empty(t, sensor.ValidateReading("temperature", 21.5, ""))
+
+	assert.Equal(t, []string{"temperature must be in celsius"}, sensor.ValidateReading("temperature", 70, "fahrenheit"))
+	assert.Equal(t, []string{"humidity must be between 0 and 100"}, sensor.ValidateReading("humidity", 140, ""))
+	assert.Empty(t, sensor.ValidateReading("motion", 1, ""))
+	assert.Equal(t, []string{"motion must be 0 or 1"}, sensor.ValidateReading("motion", 2, ""))
+	assert.Equal(t, []string{"pressure is not a metric of the sensor devices"}, sensor.ValidateReading("pressure", 1013, ""))
+}
+
+func TestValidateReading_EnumIsNotAMetric(t *testing.T) {
+
+	thermostat, _ := Get("thermostat")
+
+	assert.Equal(t, []string{"mode is not a metric of the thermostat devices"}, thermostat.ValidateReading("mode", 1, ""))
+}
+
+func TestReadingValue(t *testing.T) {
+
+	sensor, _ := Get("sensor")
+
+	assert.Equal(t, true, sensor.ReadingValue("motion", 1))
+	assert.Equal(t, false, sensor.ReadingValue("motion", 0))
+	assert.Equal(t, 21.5, sensor.ReadingValue("temperature", 21.5))
+}
+
+func TestValidateReading_AtLeast(t *testing.T) {
+
+	plug, _ := Get("plug")
+
+	assert.Equal(t, []string{"power must be at least 0"}, plug.ValidateReading("power", -1, "watt"))
+}
+
+func TestCommand(t *testing.T) {
+
+	thermostat, _ := Get("thermostat")
+
+	command, found := thermostat.Command("setTargetTemperature")
+	assert.True(t, found)
+	assert.Equal(t, "targetTemperature", command.Params[0].Name)
+
+	_, found = thermostat.Command("turnOn")
+	assert.False(t, found)
+}
+
+func decode(t *testing.T, document string) map[string]interface{} {
+	t.Helper()
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(document), &decoded); err != nil {
+		t.Fatalf("invalid document %v: %v", document, err)
+	}
+
+	return decoded
+}
+
+func TestValidateCommand(t *testing.T) {
+
+	light, _ := Get("light")
+
+	assert.Empty(t, light.ValidateCommand("turnOn", nil))
+	assert.Empty(t, light.ValidateCommand("setBrightness", decode(t, `{"brightness":40}`)))
+
+	assert.Equal(t, []string{"unlock is not a command of the light devices"}, light.ValidateCommand("unlock", nil))
+	assert.Equal(t, []string{"brightness is required by the setBrightness command"}, light.ValidateCommand("setBrightness", nil))
+	assert.Equal(t, []string{
+		"brightness must be between 0 and 100",
+		"color is not a parameter of the setBrightness command",
+	}, light.ValidateCommand("setBrightness", decode(t, `{"brightness":140,"color":"red"}`)))
+}
+
+func TestOfflineAfter(t *testing.T) {
+
+	assert.Equal(t, 5*time.Minute, OfflineAfter("light"))
+	assert.Equal(t, 30*time.Minute, OfflineAfter("sensor"))
+	assert.Equal(t, DefaultOfflineAfterSeconds*time.Second, OfflineAfter("camera"))
+}
+
+func TestMinOfflineAfter(t *testing.T) {
+
+	assert.Equal(t, 5*time.Minute, MinOfflineAfter())
+
+	for _, name := range Names() {
+		assert.LessOrEqual(t, MinOfflineAfter(), OfflineAfter(name))
+	}
+}
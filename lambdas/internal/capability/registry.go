@@ -0,0 +1,110 @@
+package capability
+
+// Units of the number fields. The telemetry readings use the same names.
+const (
+	UnitCelsius      = "celsius"
+	UnitKelvin       = "kelvin"
+	UnitPercent      = "percent"
+	UnitWatt         = "watt"
+	UnitKilowattHour = "kilowatt-hour"
+)
+
+// registry has the device types that can be created.
+var registry = map[string]DeviceType{
+	"light": {
+		Name: "light",
+		Attributes: []Attribute{
+			{Name: "dimmable", ValueSchema: ValueSchema{Type: ValueTypeBoolean}},
+			{Name: "colorTemperature", ValueSchema: ValueSchema{Type: ValueTypeBoolean}},
+		},
+		StateFields: []StateField{
+			{Name: "on", ValueSchema: ValueSchema{Type: ValueTypeBoolean}, Writable: true},
+			{Name: "brightness", ValueSchema: percent(), Writable: true},
+			{Name: "colorTemperature", ValueSchema: number(UnitKelvin, 2000, 6500), Writable: true},
+			{Name: "power", ValueSchema: atLeast(UnitWatt, 0)},
+		},
+		Commands: []Command{
+			{Name: "turnOn", Params: []CommandParam{}},
+			{Name: "turnOff", Params: []CommandParam{}},
+			{Name: "setBrightness", Params: []CommandParam{{Name: "brightness", ValueSchema: percent()}}},
+		},
+		OfflineAfterSeconds: 300,
+	},
+	"thermostat": {
+		Name: "thermostat",
+		Attributes: []Attribute{
+			{Name: "heating", ValueSchema: ValueSchema{Type: ValueTypeBoolean}},
+			{Name: "cooling", ValueSchema: ValueSchema{Type: ValueTypeBoolean}},
+		},
+		StateFields: []StateField{
+			{Name: "mode", ValueSchema: thermostatMode(), Writable: true},
+			{Name: "targetTemperature", ValueSchema: number(UnitCelsius, 5, 35), Writable: true},
+			{Name: "temperature", ValueSchema: number(UnitCelsius, -50, 100)},
+			{Name: "humidity", ValueSchema: percent()},
+		},
+		Commands: []Command{
+			{Name: "setMode", Params: []CommandParam{{Name: "mode", ValueSchema: thermostatMode()}}},
+			{Name: "setTargetTemperature", Params: []CommandParam{{Name: "targetTemperature", ValueSchema: number(UnitCelsius, 5, 35)}}},
+		},
+		OfflineAfterSeconds: 600,
+	},
+	"lock": {
+		Name: "lock",
+		Attributes: []Attribute{
+			{Name: "autoLock", ValueSchema: ValueSchema{Type: ValueTypeBoolean}},
+		},
+		StateFields: []StateField{
+			{Name: "locked", ValueSchema: ValueSchema{Type: ValueTypeBoolean}, Writable: true},
+			{Name: "battery", ValueSchema: percent()},
+		},
+		Commands: []Command{
+			{Name: "lock", Params: []CommandParam{}},
+			{Name: "unlock", Params: []CommandParam{}},
+		},
+		OfflineAfterSeconds: 1800,
+	},
+	"sensor": {
+		Name: "sensor",
+		Attributes: []Attribute{
+			{Name: "outdoor", ValueSchema: ValueSchema{Type: ValueTypeBoolean}},
+		},
+		StateFields: []StateField{
+			{Name: "temperature", ValueSchema: number(UnitCelsius, -50, 100)},
+			{Name: "humidity", ValueSchema: percent()},
+			{Name: "motion", ValueSchema: ValueSchema{Type: ValueTypeBoolean}},
+			{Name: "battery", ValueSchema: percent()},
+		},
+		Commands:            []Command{},
+		OfflineAfterSeconds: 1800,
+	},
+	"plug": {
+		Name:       "plug",
+		Attributes: []Attribute{},
+		StateFields: []StateField{
+			{Name: "on", ValueSchema: ValueSchema{Type: ValueTypeBoolean}, Writable: true},
+			{Name: "power", ValueSchema: atLeast(UnitWatt, 0)},
+			{Name: "energy", ValueSchema: atLeast(UnitKilowattHour, 0)},
+		},
+		Commands: []Command{
+			{Name: "turnOn", Params: []CommandParam{}},
+			{Name: "turnOff", Params: []CommandParam{}},
+		},
+		OfflineAfterSeconds: 300,
+	},
+}
+
+func number(unit string, min float64, max float64) ValueSchema {
+	return ValueSchema{Type: ValueTypeNumber, Unit: unit, Min: &min, Max: &max}
+}
+
+func atLeast(unit string, min float64) ValueSchema {
+	return ValueSchema{Type: ValueTypeNumber, Unit: unit, Min: &min}
+}
+
+func percent() ValueSchema {
+	return number(UnitPercent, 0, 100)
+}
+
+func thermostatMode() ValueSchema {
+	return ValueSchema{Type: ValueTypeEnum, Values: []string{"off", "heat", "cool", "auto"}}
+}
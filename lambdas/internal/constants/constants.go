@@ -1,34 +1,89 @@
 package constants
 
 const (
-	ErrDeviceNotCreatedErrorCode    = "DEVICE_NO_CREATED"
-	ErrDeviceNotCreatedErrorMessage = "An error occurred creating new device"
+	InternalServerErrorDefaultBodyResponse = "{\"errors\": [\"Internal Server Error\"]}"
 
-	ErrDeviceAlreadyExistsCode    = "DEVICE_ALREADY_EXISTS"
-	ErrDeviceAlreadyExistsMessage = "Device already exist for that mac and homeId"
+	ResponseOKWithMessageTemplate = "{\"message\": \"%v\"}"
 
-	ErrGettingDeviceCode    = "ERROR_GETTING_DEVICE"
-	ErrGettingDeviceMessage = "An error ocurred getting the device"
+	TableNameHomeDevicesProperty   = "HOME_DEVICE_TABLE_NAME"
+	MacHomeIdIndexNameProperty     = "MAC_HOMEID_INDEX_NAME"
+	HomeIdIndexNameProperty        = "HOME_ID_INDEX_NAME"
+	DeviceHistoryTableNameProperty = "DEVICE_HISTORY_TABLE_NAME"
+	TelemetryTableNameProperty     = "TELEMETRY_TABLE_NAME"
+	DeviceShadowTableNameProperty  = "DEVICE_SHADOW_TABLE_NAME"
+	HomeTableNameProperty          = "HOME_TABLE_NAME"
+	RoomTableNameProperty          = "ROOM_TABLE_NAME"
+	DeviceCommandTableNameProperty = "DEVICE_COMMAND_TABLE_NAME"
+	RuleTableNameProperty          = "RULE_TABLE_NAME"
+	TriggerDeviceIdIndexProperty   = "TRIGGER_DEVICE_ID_INDEX_NAME"
+	RuleExecutionTableNameProperty = "RULE_EXECUTION_TABLE_NAME"
+	ScheduleTableNameProperty      = "SCHEDULE_TABLE_NAME"
+	NextRunAtIndexNameProperty     = "NEXT_RUN_AT_INDEX_NAME"
+	StatusIndexNameProperty        = "STATUS_INDEX_NAME"
+	SceneTableNameProperty         = "SCENE_TABLE_NAME"
+	DeviceCounterTableNameProperty = "DEVICE_COUNTER_TABLE_NAME"
 
-	ErrNoFieldToUpdateCode    = "NO_FIELDS_TO_UPDATE"
-	ErrNoFieldToUpdateMessage = "Please enter a value property to update"
+	DeletedDeviceRetentionDaysProperty = "DELETED_DEVICE_RETENTION_DAYS"
+	DeadLetterQueueUrlProperty         = "DEAD_LETTER_QUEUE_URL"
+	CommandQueueUrlProperty            = "COMMAND_QUEUE_URL"
+	EventTopicArnProperty              = "EVENT_TOPIC_ARN"
 
-	ErrUpdatingDeviceCode    = "ERROR_UPDATING_DEVICE"
-	ErrUpdatingDeviceMessage = "An error occurred updating a device"
+	DefaultListDevicesLimit = 20
+	MaxListDevicesLimit     = 100
 
-	ErrDeviceNotFoundCode    = "ERROR_DEVICE_NOT_FOUND"
-	ErrDeviceNotFoundMessage = "Device Not Found"
+	DefaultDeletedDeviceRetentionDays = 30
 
-	ErrDeletingDeviceCode    = "ERROR_DELETING_DEVICE"
-	ErrDeletingDeviceMessage = "An error occurred deleting a device"
+	// the group or the claim of the authorizer that allows the hard deletes
+	AdminGroup = "admin"
 
-	ErrGettingConfigCode    = "ERROR_GETTING_CONFIG"
-	ErrGettingConfigMessage = "An error occurred deleting a device"
+	DeleteHomeStrategyCascade  = "cascade"
+	DeleteHomeStrategyReassign = "reassign"
 
-	InternalServerErrorDefaultBodyResponse = "{\"errors\": [\"Internal Server Error\"]}"
+	CommandStatusPending   = "pending"
+	CommandStatusSucceeded = "succeeded"
+	CommandStatusFailed    = "failed"
+	CommandStatusExpired   = "expired"
 
-	ResponseOKWithMessageTemplate = "{\"message\": \"%v\"}"
+	DefaultCommandTimeoutSeconds = 300
+	MaxCommandTimeoutSeconds     = 3600
+
+	RuleOperatorGreaterThan        = "gt"
+	RuleOperatorGreaterThanOrEqual = "gte"
+	RuleOperatorLessThan           = "lt"
+	RuleOperatorLessThanOrEqual    = "lte"
+	RuleOperatorEqual              = "eq"
+	RuleOperatorNotEqual           = "neq"
+
+	RuleExecutionStatusFired  = "fired"
+	RuleExecutionStatusFailed = "failed"
+
+	DefaultRuleCooldownSeconds = 300
+	MaxRuleCooldownSeconds     = 86400
+
+	// readings older than this do not trigger the rules, so a stream that
+	// falls behind does not fire old automations
+	MaxRuleReadingAgeSeconds = 300
+
+	MaxListRuleExecutionsLimit = 50
+
+	ScheduleRunStatusDispatched = "dispatched"
+	ScheduleRunStatusMissed     = "missed"
+
+	// a run more late than this is missed, so a scheduler that was down does
+	// not turn on the lights of the whole day when it comes back
+	MaxScheduleDelaySeconds = 600
+
+	SceneDeviceStatusApplied = "applied"
+	SceneDeviceStatusFailed  = "failed"
+
+	MaxSceneDevices = 50
+
+	// how many devices of a scene are updated at the same time
+	MaxSceneActivationConcurrency = 10
+
+	DeviceStatusOnline  = "online"
+	DeviceStatusOffline = "offline"
 
-	TableNameHomeDevicesProperty = "HOME_DEVICE_TABLE_NAME"
-	MacHomeIdIndexNameProperty   = "MAC_HOMEID_INDEX_NAME"
+	TelemetryTypeReading   = "telemetry"
+	TelemetryTypeHeartbeat = "heartbeat"
 )
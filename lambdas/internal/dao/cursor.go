@@ -0,0 +1,79 @@
+package dao
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// cursorAttribute keeps the DynamoDB type of every key attribute so the
+// LastEvaluatedKey can be rebuilt exactly as it was returned.
+type cursorAttribute struct {
+	Type  string `json:"t"`
+	Value string `json:"v"`
+}
+
+func encodeCursor(lastEvaluatedKey map[string]types.AttributeValue) (string, error) {
+
+	if len(lastEvaluatedKey) == 0 {
+		return "", nil
+	}
+
+	attributes := map[string]cursorAttribute{}
+
+	for key, value := range lastEvaluatedKey {
+		switch v := value.(type) {
+		case *types.AttributeValueMemberS:
+			attributes[key] = cursorAttribute{Type: "S", Value: v.Value}
+		case *types.AttributeValueMemberN:
+			attributes[key] = cursorAttribute{Type: "N", Value: v.Value}
+		default:
+			return "", errors.New("unsupported key attribute type for " + key)
+		}
+	}
+
+	jsonData, err := json.Marshal(attributes)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(jsonData), nil
+}
+
+func decodeCursor(cursor string) (map[string]types.AttributeValue, error) {
+
+	if cursor == "" {
+		return nil, nil
+	}
+
+	jsonData, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	var attributes map[string]cursorAttribute
+	if err := json.Unmarshal(jsonData, &attributes); err != nil {
+		return nil, err
+	}
+
+	if len(attributes) == 0 {
+		return nil, errors.New("empty cursor")
+	}
+
+	exclusiveStartKey := map[string]types.AttributeValue{}
+
+	for key, attribute := range attributes {
+		switch attribute.Type {
+		case "S":
+			exclusiveStartKey[key] = &types.AttributeValueMemberS{Value: attribute.Value}
+		case "N":
+			exclusiveStartKey[key] = &types.AttributeValueMemberN{Value: attribute.Value}
+		default:
+			return nil, errors.New("unsupported key attribute type for " + key)
+		}
+	}
+
+	return exclusiveStartKey, nil
+}
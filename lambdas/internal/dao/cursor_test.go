@@ -0,0 +1,44 @@
+package dao
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+
+	lastEvaluatedKey := map[string]types.AttributeValue{
+		"id":        &types.AttributeValueMemberS{Value: "id"},
+		"homeId":    &types.AttributeValueMemberS{Value: "home12122"},
+		"createdAt": &types.AttributeValueMemberN{Value: "1725940243"},
+	}
+
+	cursor, err := encodeCursor(lastEvaluatedKey)
+	assert.Nil(t, err)
+
+	decoded, err := decodeCursor(cursor)
+	assert.Nil(t, err)
+	assert.Equal(t, lastEvaluatedKey, decoded)
+}
+
+func TestCursor_EmptyKey(t *testing.T) {
+
+	cursor, err := encodeCursor(nil)
+	assert.Nil(t, err)
+	assert.Empty(t, cursor)
+
+	decoded, err := decodeCursor("")
+	assert.Nil(t, err)
+	assert.Nil(t, decoded)
+}
+
+func TestCursor_InvalidCursor(t *testing.T) {
+
+	_, err := decodeCursor("wrongCursor")
+	assert.NotNil(t, err)
+
+	_, err = decodeCursor("e30")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,88 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	constants "github.com/odhoman/home-devices/internal/constants"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingDynamoDbApi answers every call with the same error of the AWS SDK.
+type failingDynamoDbApi struct {
+	dynamoDbApi
+	err error
+}
+
+func (fDDA failingDynamoDbApi) GetItem(ctx context.Context, input *dynamodb.GetItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return nil, fDDA.err
+}
+
+func (fDDA failingDynamoDbApi) UpdateItem(ctx context.Context, input *dynamodb.UpdateItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	return nil, fDDA.err
+}
+
+func (fDDA failingDynamoDbApi) Query(ctx context.Context, input *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	return nil, fDDA.err
+}
+
+func TestHomeDeviceDao_ErrorsWrapTheAWSCause(t *testing.T) {
+
+	os.Setenv(constants.TableNameHomeDevicesProperty, "devicesTable")
+	os.Setenv(constants.MacHomeIdIndexNameProperty, "macHomeIdIndex")
+	defer os.Unsetenv(constants.TableNameHomeDevicesProperty)
+	defer os.Unsetenv(constants.MacHomeIdIndexNameProperty)
+
+	cause := &types.ProvisionedThroughputExceededException{Message: new(string)}
+	homeDeviceDao := HomeDeviceDaoImpl{DynamoDbApi: failingDynamoDbApi{err: cause}}
+
+	_, getError := homeDeviceDao.GetHomeDevice(context.Background(), "device123")
+	_, existError := homeDeviceDao.IsDeviceExist(context.Background(), "00:11:22:33:44:55", "home12122")
+	_, updateError := homeDeviceDao.UpdateHomeDevice(context.Background(), request.UpdateDeviceRequest{Name: "Kitchen Light"}, "device123", 0)
+
+	for definition, err := range map[hdError.Definition]error{hdError.ErrGettingDevice: getError, hdError.ErrUpdatingDevice: updateError} {
+		assert.ErrorIs(t, err, definition)
+		assert.ErrorIs(t, err, cause)
+
+		var throughputError *types.ProvisionedThroughputExceededException
+		assert.True(t, errors.As(err, &throughputError))
+	}
+
+	assert.ErrorIs(t, existError, hdError.ErrGettingDevice)
+	assert.ErrorIs(t, existError, cause)
+}
+
+func TestGetValuePropertyOrError_WrapsTheCause(t *testing.T) {
+
+	os.Unsetenv(constants.TableNameHomeDevicesProperty)
+
+	_, err := getValuePropertyOrError(constants.TableNameHomeDevicesProperty)
+
+	assert.ErrorIs(t, err, hdError.ErrGettingConfig)
+	assert.NotNil(t, errors.Unwrap(err))
+}
+
+func TestInMemoryDaos_ErrorsWrapTheCause(t *testing.T) {
+
+	state := map[string]interface{}{"power": make(chan int)}
+
+	_, shadowError := NewInMemoryDeviceShadowDao().UpdateDeviceShadow(context.Background(), "device123", request.DeviceShadowUpdate{Desired: state})
+	_, commandError := NewInMemoryDeviceCommandDao().SaveDeviceCommand(context.Background(), response.DeviceCommandResponse{ID: "command123", DeviceID: "device123", Params: state})
+
+	var unsupportedTypeError *json.UnsupportedTypeError
+
+	assert.ErrorIs(t, shadowError, hdError.ErrUpdatingDeviceState)
+	assert.True(t, errors.As(shadowError, &unsupportedTypeError))
+
+	assert.ErrorIs(t, commandError, hdError.ErrDeviceCommandNotCreated)
+	assert.True(t, errors.As(commandError, &unsupportedTypeError))
+}
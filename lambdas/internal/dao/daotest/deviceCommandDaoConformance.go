@@ -0,0 +1,214 @@
+package daotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	hDConstants "github.com/odhoman/home-devices/internal/constants"
+	"github.com/odhoman/home-devices/internal/dao"
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// RunDeviceCommandDaoConformanceSuite checks that a DeviceCommandDao
+// implementation follows the behaviour expected by the services.
+func RunDeviceCommandDaoConformanceSuite(t *testing.T, newDeviceCommandDao func() dao.DeviceCommandDao) {
+
+	tests := map[string]func(t *testing.T, deviceCommandDao dao.DeviceCommandDao){
+		"SaveAndGet":           testCommandSaveAndGet,
+		"SaveWithoutParams":    testCommandSaveWithoutParams,
+		"GetNotFound":          testCommandGetNotFound,
+		"GetOfAnotherDevice":   testCommandGetOfAnotherDevice,
+		"Complete":             testCommandComplete,
+		"CompleteWithError":    testCommandCompleteWithError,
+		"CompleteTwice":        testCommandCompleteTwice,
+		"CompleteNotFound":     testCommandCompleteNotFound,
+		"SavedParamsAreCopied": testCommandSavedParamsAreCopied,
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test(t, newDeviceCommandDao())
+		})
+	}
+}
+
+func testCommandSaveAndGet(t *testing.T, deviceCommandDao dao.DeviceCommandDao) {
+
+	ctx := context.Background()
+	command := newDeviceCommandForTesting(map[string]interface{}{"brightness": 40.0})
+
+	saved, err := deviceCommandDao.SaveDeviceCommand(ctx, command)
+	if err != nil {
+		t.Fatalf("expected a saved device command but got an error %v", err)
+	}
+
+	assert.Equal(t, command, *saved)
+
+	found, err := deviceCommandDao.GetDeviceCommand(ctx, command.DeviceID, command.ID)
+	if err != nil {
+		t.Fatalf("expected the device command but got an error %v", err)
+	}
+
+	assert.Equal(t, command, *found)
+}
+
+func testCommandSaveWithoutParams(t *testing.T, deviceCommandDao dao.DeviceCommandDao) {
+
+	ctx := context.Background()
+	command := newDeviceCommandForTesting(nil)
+
+	if _, err := deviceCommandDao.SaveDeviceCommand(ctx, command); err != nil {
+		t.Fatalf("expected a saved device command but got an error %v", err)
+	}
+
+	found, err := deviceCommandDao.GetDeviceCommand(ctx, command.DeviceID, command.ID)
+	if err != nil {
+		t.Fatalf("expected the device command but got an error %v", err)
+	}
+
+	assert.NotNil(t, found.Params)
+	assert.Empty(t, found.Params)
+}
+
+func testCommandGetNotFound(t *testing.T, deviceCommandDao dao.DeviceCommandDao) {
+
+	_, err := deviceCommandDao.GetDeviceCommand(context.Background(), uuid.New().String(), uuid.New().String())
+
+	assert.ErrorIs(t, err, hDError.ErrDeviceCommandNotFound)
+}
+
+func testCommandGetOfAnotherDevice(t *testing.T, deviceCommandDao dao.DeviceCommandDao) {
+
+	ctx := context.Background()
+	command := saveDeviceCommandForTesting(t, deviceCommandDao)
+
+	_, err := deviceCommandDao.GetDeviceCommand(ctx, uuid.New().String(), command.ID)
+
+	assert.ErrorIs(t, err, hDError.ErrDeviceCommandNotFound)
+}
+
+func testCommandComplete(t *testing.T, deviceCommandDao dao.DeviceCommandDao) {
+
+	ctx := context.Background()
+	command := saveDeviceCommandForTesting(t, deviceCommandDao)
+	completedAt := time.Now().Unix()
+
+	completed, err := deviceCommandDao.CompleteDeviceCommand(ctx, command.DeviceID, command.ID, hDRequest.DeviceCommandStatusUpdate{
+		Status:      hDConstants.CommandStatusSucceeded,
+		CompletedAt: completedAt,
+	})
+	if err != nil {
+		t.Fatalf("expected a completed device command but got an error %v", err)
+	}
+
+	assert.Equal(t, hDConstants.CommandStatusSucceeded, completed.Status)
+	assert.Equal(t, completedAt, completed.CompletedAt)
+	assert.Empty(t, completed.Error)
+	assert.Equal(t, command.Params, completed.Params)
+
+	found, _ := deviceCommandDao.GetDeviceCommand(ctx, command.DeviceID, command.ID)
+	assert.Equal(t, completed, found)
+}
+
+func testCommandCompleteWithError(t *testing.T, deviceCommandDao dao.DeviceCommandDao) {
+
+	ctx := context.Background()
+	command := saveDeviceCommandForTesting(t, deviceCommandDao)
+
+	completed, err := deviceCommandDao.CompleteDeviceCommand(ctx, command.DeviceID, command.ID, hDRequest.DeviceCommandStatusUpdate{
+		Status:      hDConstants.CommandStatusFailed,
+		Error:       "the bulb is broken",
+		CompletedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		t.Fatalf("expected a completed device command but got an error %v", err)
+	}
+
+	assert.Equal(t, hDConstants.CommandStatusFailed, completed.Status)
+	assert.Equal(t, "the bulb is broken", completed.Error)
+}
+
+func testCommandCompleteTwice(t *testing.T, deviceCommandDao dao.DeviceCommandDao) {
+
+	ctx := context.Background()
+	command := saveDeviceCommandForTesting(t, deviceCommandDao)
+
+	_, err := deviceCommandDao.CompleteDeviceCommand(ctx, command.DeviceID, command.ID, hDRequest.DeviceCommandStatusUpdate{
+		Status:      hDConstants.CommandStatusSucceeded,
+		CompletedAt: time.Now().Unix(),
+	})
+	assert.Nil(t, err)
+
+	_, err = deviceCommandDao.CompleteDeviceCommand(ctx, command.DeviceID, command.ID, hDRequest.DeviceCommandStatusUpdate{
+		Status:      hDConstants.CommandStatusFailed,
+		Error:       "late failure",
+		CompletedAt: time.Now().Unix(),
+	})
+	assert.ErrorIs(t, err, hDError.ErrDeviceCommandCompleted)
+
+	found, _ := deviceCommandDao.GetDeviceCommand(ctx, command.DeviceID, command.ID)
+	assert.Equal(t, hDConstants.CommandStatusSucceeded, found.Status)
+	assert.Empty(t, found.Error)
+}
+
+func testCommandCompleteNotFound(t *testing.T, deviceCommandDao dao.DeviceCommandDao) {
+
+	_, err := deviceCommandDao.CompleteDeviceCommand(context.Background(), uuid.New().String(), uuid.New().String(), hDRequest.DeviceCommandStatusUpdate{
+		Status:      hDConstants.CommandStatusSucceeded,
+		CompletedAt: time.Now().Unix(),
+	})
+
+	assert.ErrorIs(t, err, hDError.ErrDeviceCommandNotFound)
+}
+
+func testCommandSavedParamsAreCopied(t *testing.T, deviceCommandDao dao.DeviceCommandDao) {
+
+	ctx := context.Background()
+	command := newDeviceCommandForTesting(map[string]interface{}{"brightness": 40.0})
+
+	if _, err := deviceCommandDao.SaveDeviceCommand(ctx, command); err != nil {
+		t.Fatalf("expected a saved device command but got an error %v", err)
+	}
+
+	command.Params["brightness"] = 90.0
+
+	found, _ := deviceCommandDao.GetDeviceCommand(ctx, command.DeviceID, command.ID)
+	assert.Equal(t, map[string]interface{}{"brightness": 40.0}, found.Params)
+}
+
+func newDeviceCommandForTesting(params map[string]interface{}) hDResponse.DeviceCommandResponse {
+
+	now := time.Now().Unix()
+	expectedParams := params
+	if expectedParams == nil {
+		expectedParams = map[string]interface{}{}
+	}
+
+	return hDResponse.DeviceCommandResponse{
+		ID:        uuid.New().String(),
+		DeviceID:  uuid.New().String(),
+		Name:      "setBrightness",
+		Params:    expectedParams,
+		Status:    hDConstants.CommandStatusPending,
+		CreatedAt: now,
+		ExpiresAt: now + hDConstants.DefaultCommandTimeoutSeconds,
+	}
+}
+
+func saveDeviceCommandForTesting(t *testing.T, deviceCommandDao dao.DeviceCommandDao) hDResponse.DeviceCommandResponse {
+	t.Helper()
+
+	command := newDeviceCommandForTesting(map[string]interface{}{"brightness": 40.0})
+
+	if _, err := deviceCommandDao.SaveDeviceCommand(context.Background(), command); err != nil {
+		t.Fatalf("Unexpected error saving the device command for testing. Error: %v", err)
+	}
+
+	return command
+}
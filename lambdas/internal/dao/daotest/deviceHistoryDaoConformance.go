@@ -0,0 +1,168 @@
+package daotest
+
+import (
+	"context"
+	"testing"
+
+	hDAudit "github.com/odhoman/home-devices/internal/audit"
+	"github.com/odhoman/home-devices/internal/dao"
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// RunDeviceHistoryDaoConformanceSuite checks that a DeviceHistoryDao
+// implementation follows the behaviour expected by the services.
+func RunDeviceHistoryDaoConformanceSuite(t *testing.T, newDeviceHistoryDao func() dao.DeviceHistoryDao) {
+
+	tests := map[string]func(t *testing.T, deviceHistoryDao dao.DeviceHistoryDao){
+		"SaveAndList":                 testHistorySaveAndList,
+		"ListNewestFirst":             testHistoryListNewestFirst,
+		"ListPagination":              testHistoryListPagination,
+		"ListEmpty":                   testHistoryListEmpty,
+		"ListInvalidCursor":           testHistoryListInvalidCursor,
+		"ListCursorFromAnotherDevice": testHistoryListCursorFromAnotherDevice,
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test(t, newDeviceHistoryDao())
+		})
+	}
+}
+
+func testHistorySaveAndList(t *testing.T, deviceHistoryDao dao.DeviceHistoryDao) {
+
+	ctx := context.Background()
+	change := newDeviceChange(uuid.New().String(), hDAudit.OperationUpdate)
+
+	saved := saveDeviceChange(t, ctx, deviceHistoryDao, change)
+	assert.NotEmpty(t, saved.ChangeID)
+
+	history, err := deviceHistoryDao.ListDeviceHistory(ctx, change.DeviceID, 0, "")
+	if err != nil {
+		t.Fatalf("expected the device history but got an error %v", err)
+	}
+
+	assert.Equal(t, []hDResponse.DeviceChangeResponse{*saved}, history.Changes)
+	assert.Empty(t, history.NextCursor)
+}
+
+func testHistoryListNewestFirst(t *testing.T, deviceHistoryDao dao.DeviceHistoryDao) {
+
+	ctx := context.Background()
+	deviceId := uuid.New().String()
+
+	first := saveDeviceChange(t, ctx, deviceHistoryDao, newDeviceChange(deviceId, hDAudit.OperationCreate))
+	second := saveDeviceChange(t, ctx, deviceHistoryDao, newDeviceChange(deviceId, hDAudit.OperationUpdate))
+	third := saveDeviceChange(t, ctx, deviceHistoryDao, newDeviceChange(deviceId, hDAudit.OperationDelete))
+
+	history, err := deviceHistoryDao.ListDeviceHistory(ctx, deviceId, 0, "")
+	if err != nil {
+		t.Fatalf("expected the device history but got an error %v", err)
+	}
+
+	assert.Equal(t, []string{third.ChangeID, second.ChangeID, first.ChangeID}, changeIds(history.Changes))
+}
+
+func testHistoryListPagination(t *testing.T, deviceHistoryDao dao.DeviceHistoryDao) {
+
+	ctx := context.Background()
+	deviceId := uuid.New().String()
+
+	saved := []string{}
+	for i := 0; i < 5; i++ {
+		saved = append([]string{saveDeviceChange(t, ctx, deviceHistoryDao, newDeviceChange(deviceId, hDAudit.OperationUpdate)).ChangeID}, saved...)
+	}
+
+	listed := []string{}
+	cursor := ""
+	for pages := 0; pages < 10; pages++ {
+		page, err := deviceHistoryDao.ListDeviceHistory(ctx, deviceId, 2, cursor)
+		if err != nil {
+			t.Fatalf("expected a page of the device history but got an error %v", err)
+		}
+
+		assert.LessOrEqual(t, len(page.Changes), 2)
+		listed = append(listed, changeIds(page.Changes)...)
+
+		cursor = page.NextCursor
+		if cursor == "" {
+			break
+		}
+	}
+
+	assert.Empty(t, cursor)
+	assert.Equal(t, saved, listed)
+}
+
+func testHistoryListEmpty(t *testing.T, deviceHistoryDao dao.DeviceHistoryDao) {
+
+	history, err := deviceHistoryDao.ListDeviceHistory(context.Background(), uuid.New().String(), 0, "")
+	if err != nil {
+		t.Fatalf("expected an empty device history but got an error %v", err)
+	}
+
+	assert.Empty(t, history.Changes)
+	assert.Empty(t, history.NextCursor)
+}
+
+func testHistoryListInvalidCursor(t *testing.T, deviceHistoryDao dao.DeviceHistoryDao) {
+
+	_, err := deviceHistoryDao.ListDeviceHistory(context.Background(), uuid.New().String(), 0, "not-a-cursor")
+	assertErrorCode(t, hDError.ErrInvalidCursor, err)
+}
+
+func testHistoryListCursorFromAnotherDevice(t *testing.T, deviceHistoryDao dao.DeviceHistoryDao) {
+
+	ctx := context.Background()
+	deviceId := uuid.New().String()
+
+	for i := 0; i < 2; i++ {
+		saveDeviceChange(t, ctx, deviceHistoryDao, newDeviceChange(deviceId, hDAudit.OperationUpdate))
+	}
+
+	page, err := deviceHistoryDao.ListDeviceHistory(ctx, deviceId, 1, "")
+	if err != nil {
+		t.Fatalf("expected a page of the device history but got an error %v", err)
+	}
+
+	_, err = deviceHistoryDao.ListDeviceHistory(ctx, uuid.New().String(), 1, page.NextCursor)
+	assertErrorCode(t, hDError.ErrInvalidCursor, err)
+}
+
+func newDeviceChange(deviceId string, operation string) hDResponse.DeviceChangeResponse {
+	return hDResponse.DeviceChangeResponse{
+		DeviceID:  deviceId,
+		Operation: operation,
+		Actor:     "user-1",
+		Source:    hDAudit.SourceAPI,
+		ChangedAt: 1700000000,
+		Version:   2,
+		Changes: []hDResponse.DeviceFieldChange{
+			{Field: "name", Before: "Light", After: "Living Room Light"},
+			{Field: "homeId", After: "home1"},
+		},
+	}
+}
+
+func saveDeviceChange(t *testing.T, ctx context.Context, deviceHistoryDao dao.DeviceHistoryDao, change hDResponse.DeviceChangeResponse) *hDResponse.DeviceChangeResponse {
+	t.Helper()
+
+	saved, err := deviceHistoryDao.SaveDeviceChange(ctx, change)
+	if err != nil {
+		t.Fatalf("expected a saved device change but got an error %v", err)
+	}
+
+	return saved
+}
+
+func changeIds(changes []hDResponse.DeviceChangeResponse) []string {
+	ids := []string{}
+	for _, change := range changes {
+		ids = append(ids, change.ChangeID)
+	}
+	return ids
+}
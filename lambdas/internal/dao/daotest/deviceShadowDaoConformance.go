@@ -0,0 +1,162 @@
+package daotest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// RunDeviceShadowDaoConformanceSuite checks that a DeviceShadowDao
+// implementation follows the behaviour expected by the services.
+func RunDeviceShadowDaoConformanceSuite(t *testing.T, newDeviceShadowDao func() dao.DeviceShadowDao) {
+
+	tests := map[string]func(t *testing.T, deviceShadowDao dao.DeviceShadowDao){
+		"GetNeverWritten":               testShadowGetNeverWritten,
+		"UpdateDesired":                 testShadowUpdateDesired,
+		"UpdateBoth":                    testShadowUpdateBoth,
+		"DocumentsHaveTheirOwnVersions": testShadowDocumentsHaveTheirOwnVersions,
+		"UpdateVersionConflict":         testShadowUpdateVersionConflict,
+		"UpdateConflictWritesNothing":   testShadowUpdateConflictWritesNothing,
+		"UpdateNothing":                 testShadowUpdateNothing,
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test(t, newDeviceShadowDao())
+		})
+	}
+}
+
+func testShadowGetNeverWritten(t *testing.T, deviceShadowDao dao.DeviceShadowDao) {
+
+	deviceId := uuid.New().String()
+
+	deviceShadow, err := deviceShadowDao.GetDeviceShadow(context.Background(), deviceId)
+	if err != nil {
+		t.Fatalf("expected an empty device shadow but got an error %v", err)
+	}
+
+	assert.Equal(t, deviceId, deviceShadow.DeviceID)
+	assert.Empty(t, deviceShadow.Desired.State)
+	assert.NotNil(t, deviceShadow.Desired.State)
+	assert.Equal(t, int64(0), deviceShadow.Desired.Version)
+	assert.Empty(t, deviceShadow.Reported.State)
+	assert.Equal(t, int64(0), deviceShadow.Reported.Version)
+}
+
+func testShadowUpdateDesired(t *testing.T, deviceShadowDao dao.DeviceShadowDao) {
+
+	ctx := context.Background()
+	deviceId := uuid.New().String()
+
+	updated, err := deviceShadowDao.UpdateDeviceShadow(ctx, deviceId, hDRequest.DeviceShadowUpdate{
+		Desired: map[string]interface{}{"power": "on", "brightness": 80.0, "color": map[string]interface{}{"r": 255.0}},
+	})
+	if err != nil {
+		t.Fatalf("expected an updated device shadow but got an error %v", err)
+	}
+
+	assert.Equal(t, int64(1), updated.Desired.Version)
+	assert.NotZero(t, updated.Desired.UpdatedAt)
+	assert.Equal(t, int64(0), updated.Reported.Version)
+
+	deviceShadow, err := deviceShadowDao.GetDeviceShadow(ctx, deviceId)
+	if err != nil {
+		t.Fatalf("expected the device shadow but got an error %v", err)
+	}
+
+	assert.Equal(t, updated, deviceShadow)
+	assert.Equal(t, map[string]interface{}{"power": "on", "brightness": 80.0, "color": map[string]interface{}{"r": 255.0}}, deviceShadow.Desired.State)
+}
+
+func testShadowUpdateBoth(t *testing.T, deviceShadowDao dao.DeviceShadowDao) {
+
+	updated, err := deviceShadowDao.UpdateDeviceShadow(context.Background(), uuid.New().String(), hDRequest.DeviceShadowUpdate{
+		Desired:  map[string]interface{}{"power": "on"},
+		Reported: map[string]interface{}{"power": "off"},
+	})
+	if err != nil {
+		t.Fatalf("expected an updated device shadow but got an error %v", err)
+	}
+
+	assert.Equal(t, int64(1), updated.Desired.Version)
+	assert.Equal(t, int64(1), updated.Reported.Version)
+	assert.Equal(t, map[string]interface{}{"power": "off"}, updated.Reported.State)
+}
+
+func testShadowDocumentsHaveTheirOwnVersions(t *testing.T, deviceShadowDao dao.DeviceShadowDao) {
+
+	ctx := context.Background()
+	deviceId := uuid.New().String()
+
+	updateDeviceShadow(t, ctx, deviceShadowDao, deviceId, hDRequest.DeviceShadowUpdate{Desired: map[string]interface{}{"power": "on"}})
+	updateDeviceShadow(t, ctx, deviceShadowDao, deviceId, hDRequest.DeviceShadowUpdate{Desired: map[string]interface{}{"power": "off"}, DesiredVersion: 1})
+
+	// the reported document was never written, so it is still in version 0
+	updated := updateDeviceShadow(t, ctx, deviceShadowDao, deviceId, hDRequest.DeviceShadowUpdate{Reported: map[string]interface{}{"power": "off"}})
+
+	assert.Equal(t, int64(2), updated.Desired.Version)
+	assert.Equal(t, map[string]interface{}{"power": "off"}, updated.Desired.State)
+	assert.Equal(t, int64(1), updated.Reported.Version)
+}
+
+func testShadowUpdateVersionConflict(t *testing.T, deviceShadowDao dao.DeviceShadowDao) {
+
+	ctx := context.Background()
+	deviceId := uuid.New().String()
+
+	updateDeviceShadow(t, ctx, deviceShadowDao, deviceId, hDRequest.DeviceShadowUpdate{Desired: map[string]interface{}{"power": "on"}})
+
+	_, err := deviceShadowDao.UpdateDeviceShadow(ctx, deviceId, hDRequest.DeviceShadowUpdate{Desired: map[string]interface{}{"power": "off"}})
+	assertErrorCode(t, hDError.ErrVersionConflict, err)
+
+	_, err = deviceShadowDao.UpdateDeviceShadow(ctx, deviceId, hDRequest.DeviceShadowUpdate{Desired: map[string]interface{}{"power": "off"}, DesiredVersion: 2})
+	assertErrorCode(t, hDError.ErrVersionConflict, err)
+}
+
+func testShadowUpdateConflictWritesNothing(t *testing.T, deviceShadowDao dao.DeviceShadowDao) {
+
+	ctx := context.Background()
+	deviceId := uuid.New().String()
+
+	updateDeviceShadow(t, ctx, deviceShadowDao, deviceId, hDRequest.DeviceShadowUpdate{Reported: map[string]interface{}{"power": "on"}})
+
+	_, err := deviceShadowDao.UpdateDeviceShadow(ctx, deviceId, hDRequest.DeviceShadowUpdate{
+		Desired:  map[string]interface{}{"power": "off"},
+		Reported: map[string]interface{}{"power": "off"},
+	})
+	assertErrorCode(t, hDError.ErrVersionConflict, err)
+
+	deviceShadow, err := deviceShadowDao.GetDeviceShadow(ctx, deviceId)
+	if err != nil {
+		t.Fatalf("expected the device shadow but got an error %v", err)
+	}
+
+	assert.Equal(t, int64(0), deviceShadow.Desired.Version)
+	assert.Empty(t, deviceShadow.Desired.State)
+	assert.Equal(t, map[string]interface{}{"power": "on"}, deviceShadow.Reported.State)
+}
+
+func testShadowUpdateNothing(t *testing.T, deviceShadowDao dao.DeviceShadowDao) {
+
+	_, err := deviceShadowDao.UpdateDeviceShadow(context.Background(), uuid.New().String(), hDRequest.DeviceShadowUpdate{})
+	assertErrorCode(t, hDError.ErrNoStateToUpdate, err)
+}
+
+func updateDeviceShadow(t *testing.T, ctx context.Context, deviceShadowDao dao.DeviceShadowDao, deviceId string, update hDRequest.DeviceShadowUpdate) *hDResponse.DeviceStateResponse {
+	t.Helper()
+
+	updated, err := deviceShadowDao.UpdateDeviceShadow(ctx, deviceId, update)
+	if err != nil {
+		t.Fatalf("expected an updated device shadow but got an error %v", err)
+	}
+
+	return updated
+}
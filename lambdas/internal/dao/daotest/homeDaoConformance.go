@@ -0,0 +1,209 @@
+package daotest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RunHomeDaoConformanceSuite checks that a HomeDao implementation follows the
+// behaviour expected by the services.
+func RunHomeDaoConformanceSuite(t *testing.T, newHomeDao func() dao.HomeDao) {
+
+	tests := map[string]func(t *testing.T, homeDao dao.HomeDao){
+		"SaveAndGet":            testHomeSaveAndGet,
+		"SaveWithId":            testHomeSaveWithId,
+		"SaveDuplicatedId":      testHomeSaveDuplicatedId,
+		"GetNotFound":           testHomeGetNotFound,
+		"Update":                testHomeUpdate,
+		"UpdateVersionConflict": testHomeUpdateVersionConflict,
+		"UpdateNotFound":        testHomeUpdateNotFound,
+		"UpdateNothing":         testHomeUpdateNothing,
+		"Delete":                testHomeDelete,
+		"DeleteVersionConflict": testHomeDeleteVersionConflict,
+		"DeleteNotFound":        testHomeDeleteNotFound,
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test(t, newHomeDao())
+		})
+	}
+}
+
+func newCreateHomeRequest() hDRequest.CreateHomeRequest {
+	return hDRequest.CreateHomeRequest{
+		Name:     "Beach House",
+		Timezone: "Europe/Madrid",
+		Address:  "Calle Mayor 1, Valencia",
+		Owner:    "user-1",
+	}
+}
+
+func testHomeSaveAndGet(t *testing.T, homeDao dao.HomeDao) {
+
+	ctx := context.Background()
+
+	saved, err := homeDao.SaveHome(ctx, newCreateHomeRequest())
+	if err != nil {
+		t.Fatalf("expected a saved home but got an error %v", err)
+	}
+
+	assert.NotEmpty(t, saved.ID)
+	assert.LessOrEqual(t, len(saved.ID), 30)
+	assert.Equal(t, int64(1), saved.Version)
+	assert.NotZero(t, saved.CreatedAt)
+
+	home, err := homeDao.GetHome(ctx, saved.ID)
+	if err != nil {
+		t.Fatalf("expected the home but got an error %v", err)
+	}
+
+	assert.Equal(t, saved, home)
+	assert.Equal(t, "Beach House", home.Name)
+	assert.Equal(t, "Europe/Madrid", home.Timezone)
+	assert.Equal(t, "Calle Mayor 1, Valencia", home.Address)
+	assert.Equal(t, "user-1", home.Owner)
+}
+
+func testHomeSaveWithId(t *testing.T, homeDao dao.HomeDao) {
+
+	ctx := context.Background()
+	homeRequest := newCreateHomeRequest()
+	homeRequest.ID = newHomeId()
+
+	saved, err := homeDao.SaveHome(ctx, homeRequest)
+	if err != nil {
+		t.Fatalf("expected a saved home but got an error %v", err)
+	}
+
+	assert.Equal(t, homeRequest.ID, saved.ID)
+
+	_, err = homeDao.GetHome(ctx, homeRequest.ID)
+	assert.Nil(t, err)
+}
+
+func testHomeSaveDuplicatedId(t *testing.T, homeDao dao.HomeDao) {
+
+	ctx := context.Background()
+	homeRequest := newCreateHomeRequest()
+	homeRequest.ID = newHomeId()
+
+	if _, err := homeDao.SaveHome(ctx, homeRequest); err != nil {
+		t.Fatalf("expected a saved home but got an error %v", err)
+	}
+
+	homeRequest.Name = "Another House"
+	_, err := homeDao.SaveHome(ctx, homeRequest)
+	assertErrorCode(t, hDError.ErrHomeAlreadyExists, err)
+
+	home, _ := homeDao.GetHome(ctx, homeRequest.ID)
+	assert.Equal(t, "Beach House", home.Name)
+}
+
+func testHomeGetNotFound(t *testing.T, homeDao dao.HomeDao) {
+
+	_, err := homeDao.GetHome(context.Background(), newHomeId())
+	assertErrorCode(t, hDError.ErrHomeNotFound, err)
+}
+
+func testHomeUpdate(t *testing.T, homeDao dao.HomeDao) {
+
+	ctx := context.Background()
+	saved := saveHomeForTesting(t, homeDao)
+
+	err := homeDao.UpdateHome(ctx, hDRequest.UpdateHomeRequest{Name: "Mountain House", Timezone: "America/Bogota"}, saved.ID, 1)
+	if err != nil {
+		t.Fatalf("expected the home to be updated but got an error %v", err)
+	}
+
+	home, _ := homeDao.GetHome(ctx, saved.ID)
+	assert.Equal(t, "Mountain House", home.Name)
+	assert.Equal(t, "America/Bogota", home.Timezone)
+	assert.Equal(t, saved.Address, home.Address)
+	assert.Equal(t, saved.Owner, home.Owner)
+	assert.Equal(t, int64(2), home.Version)
+
+	err = homeDao.UpdateHome(ctx, hDRequest.UpdateHomeRequest{Owner: "user-2"}, saved.ID, 0)
+	assert.Nil(t, err)
+
+	home, _ = homeDao.GetHome(ctx, saved.ID)
+	assert.Equal(t, "user-2", home.Owner)
+	assert.Equal(t, int64(3), home.Version)
+}
+
+func testHomeUpdateVersionConflict(t *testing.T, homeDao dao.HomeDao) {
+
+	ctx := context.Background()
+	saved := saveHomeForTesting(t, homeDao)
+
+	err := homeDao.UpdateHome(ctx, hDRequest.UpdateHomeRequest{Name: "Mountain House"}, saved.ID, 2)
+	assertErrorCode(t, hDError.ErrVersionConflict, err)
+
+	home, _ := homeDao.GetHome(ctx, saved.ID)
+	assert.Equal(t, saved.Name, home.Name)
+	assert.Equal(t, int64(1), home.Version)
+}
+
+func testHomeUpdateNotFound(t *testing.T, homeDao dao.HomeDao) {
+
+	err := homeDao.UpdateHome(context.Background(), hDRequest.UpdateHomeRequest{Name: "Mountain House"}, newHomeId(), 0)
+	assertErrorCode(t, hDError.ErrHomeNotFound, err)
+}
+
+func testHomeUpdateNothing(t *testing.T, homeDao dao.HomeDao) {
+
+	saved := saveHomeForTesting(t, homeDao)
+
+	err := homeDao.UpdateHome(context.Background(), hDRequest.UpdateHomeRequest{}, saved.ID, 0)
+	assertErrorCode(t, hDError.ErrNoFieldToUpdate, err)
+}
+
+func testHomeDelete(t *testing.T, homeDao dao.HomeDao) {
+
+	ctx := context.Background()
+	saved := saveHomeForTesting(t, homeDao)
+
+	err := homeDao.DeleteHome(ctx, saved.ID, 1)
+	if err != nil {
+		t.Fatalf("expected the home to be deleted but got an error %v", err)
+	}
+
+	_, err = homeDao.GetHome(ctx, saved.ID)
+	assertErrorCode(t, hDError.ErrHomeNotFound, err)
+}
+
+func testHomeDeleteVersionConflict(t *testing.T, homeDao dao.HomeDao) {
+
+	ctx := context.Background()
+	saved := saveHomeForTesting(t, homeDao)
+
+	err := homeDao.DeleteHome(ctx, saved.ID, 3)
+	assertErrorCode(t, hDError.ErrVersionConflict, err)
+
+	_, err = homeDao.GetHome(ctx, saved.ID)
+	assert.Nil(t, err)
+}
+
+func testHomeDeleteNotFound(t *testing.T, homeDao dao.HomeDao) {
+
+	err := homeDao.DeleteHome(context.Background(), newHomeId(), 0)
+	assertErrorCode(t, hDError.ErrHomeNotFound, err)
+}
+
+func saveHomeForTesting(t *testing.T, homeDao dao.HomeDao) *hDResponse.HomeResponse {
+	t.Helper()
+
+	saved, err := homeDao.SaveHome(context.Background(), newCreateHomeRequest())
+	if err != nil {
+		t.Fatalf("expected a saved home but got an error %v", err)
+	}
+
+	return saved
+}
@@ -0,0 +1,950 @@
+package daotest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	hDConstants "github.com/odhoman/home-devices/internal/constants"
+	"github.com/odhoman/home-devices/internal/dao"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// RunHomeDeviceDaoConformanceSuite checks that a HomeDeviceDao implementation
+// follows the behaviour expected by the services.
+func RunHomeDeviceDaoConformanceSuite(t *testing.T, newHomeDeviceDao func() dao.HomeDeviceDao) {
+
+	tests := map[string]func(t *testing.T, homeDeviceDao dao.HomeDeviceDao){
+		"SaveAndGet":                testSaveAndGet,
+		"SaveAlreadyExists":         testSaveAlreadyExists,
+		"SaveConcurrentRequests":    testSaveConcurrentRequests,
+		"IsDeviceExist":             testIsDeviceExist,
+		"MacIsNormalized":           testMacIsNormalized,
+		"GetNotFound":               testGetNotFound,
+		"Update":                    testUpdate,
+		"UpdateModifiedAt":          testUpdateModifiedAt,
+		"UpdateNotFound":            testUpdateNotFound,
+		"UpdateMacAlreadyExists":    testUpdateMacAlreadyExists,
+		"UpdateReleasesPreviousMac": testUpdateReleasesPreviousMac,
+		"UpdateVersionConflict":     testUpdateVersionConflict,
+		"Delete":                    testDelete,
+		"DeleteNotFound":            testDeleteNotFound,
+		"DeleteVersionConflict":     testDeleteVersionConflict,
+		"DeletedDeviceIsHidden":     testDeletedDeviceIsHidden,
+		"DeletedDeviceIsReadOnly":   testDeletedDeviceIsReadOnly,
+		"GetDeleted":                testGetDeleted,
+		"GetDeletedNotDeleted":      testGetDeletedNotDeleted,
+		"GetDeletedNotFound":        testGetDeletedNotFound,
+		"Restore":                   testRestore,
+		"RestoreNotDeleted":         testRestoreNotDeleted,
+		"RestoreNotFound":           testRestoreNotFound,
+		"RestoreMacAlreadyExists":   testRestoreMacAlreadyExists,
+		"HardDelete":                testHardDelete,
+		"HardDeleteDeletedDevice":   testHardDeleteDeletedDevice,
+		"HardDeleteNotFound":        testHardDeleteNotFound,
+		"HardDeleteVersionConflict": testHardDeleteVersionConflict,
+		"ListPagination":            testListPagination,
+		"ListEmpty":                 testListEmpty,
+		"ListInvalidCursor":         testListInvalidCursor,
+		"ListCursorFromAnotherHome": testListCursorFromAnotherHome,
+		"SaveAndUpdateRoom":         testSaveAndUpdateRoom,
+		"MoveHomeClearsRoom":        testMoveHomeClearsRoom,
+		"MoveHomeWithRoom":          testMoveHomeWithRoom,
+		"ListByRoom":                testListByRoom,
+		"SavedDeviceIsOffline":      testSavedDeviceIsOffline,
+		"MarkSeen":                  testMarkSeen,
+		"MarkSeenIgnoresOlder":      testMarkSeenIgnoresOlder,
+		"MarkSeenNotFound":          testMarkSeenNotFound,
+		"UpdateKeepsPresence":       testUpdateKeepsPresence,
+		"ListStaleAndMarkOffline":   testListStaleAndMarkOffline,
+		"MarkOfflineSeenAgain":      testMarkOfflineSeenAgain,
+		"ListByStatus":              testListByStatus,
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test(t, newHomeDeviceDao())
+		})
+	}
+}
+
+func testSaveAndGet(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	request := newCreateDeviceRequest(newHomeId())
+
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, request)
+
+	assert.NotEmpty(t, saved.ID)
+	assert.Equal(t, int64(1), saved.Version)
+	assert.Equal(t, saved.CreatedAt, saved.ModifiedAt)
+
+	device := getHomeDevice(t, ctx, homeDeviceDao, saved.ID)
+
+	assert.Equal(t, *saved, *device)
+	assert.Equal(t, request.MAC, device.MAC)
+	assert.Equal(t, request.Name, device.Name)
+	assert.Equal(t, request.Type, device.Type)
+	assert.Equal(t, request.HomeID, device.HomeID)
+}
+
+func testSaveAlreadyExists(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	request := newCreateDeviceRequest(newHomeId())
+
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, request)
+
+	_, err := homeDeviceDao.SaveHomeDevice(ctx, request)
+	assertErrorCode(t, hdError.ErrDeviceAlreadyExists, err)
+	assert.Equal(t, saved.ID, hdError.From(err).Details["deviceId"])
+
+	sameMacOtherFormat := request
+	sameMacOtherFormat.MAC = strings.ToLower(strings.ReplaceAll(request.MAC, ":", "-"))
+
+	_, err = homeDeviceDao.SaveHomeDevice(ctx, sameMacOtherFormat)
+	assertErrorCode(t, hdError.ErrDeviceAlreadyExists, err)
+
+	sameMacOtherHome := request
+	sameMacOtherHome.HomeID = newHomeId()
+
+	saveHomeDevice(t, ctx, homeDeviceDao, sameMacOtherHome)
+}
+
+func testSaveConcurrentRequests(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	request := newCreateDeviceRequest(newHomeId())
+
+	const requests = 5
+	errs := make(chan error, requests)
+
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := homeDeviceDao.SaveHomeDevice(ctx, request)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	created := 0
+	for err := range errs {
+		if err == nil {
+			created++
+		} else {
+			assert.ErrorIs(t, err, hdError.ErrDeviceAlreadyExists)
+		}
+	}
+
+	assert.Equal(t, 1, created)
+}
+
+func testIsDeviceExist(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	request := newCreateDeviceRequest(newHomeId())
+
+	saveHomeDevice(t, ctx, homeDeviceDao, request)
+
+	exists, err := homeDeviceDao.IsDeviceExist(ctx, request.MAC, request.HomeID)
+	assert.Nil(t, err)
+	assert.True(t, exists)
+
+	exists, err = homeDeviceDao.IsDeviceExist(ctx, request.MAC, newHomeId())
+	assert.Nil(t, err)
+	assert.False(t, exists)
+}
+
+func testMacIsNormalized(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	request := newCreateDeviceRequest(newHomeId())
+	mac := request.MAC
+	request.MAC = strings.ToLower(strings.ReplaceAll(mac, ":", "-"))
+
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, request)
+	assert.Equal(t, mac, saved.MAC)
+	assert.Equal(t, mac, getHomeDevice(t, ctx, homeDeviceDao, saved.ID).MAC)
+
+	exists, err := homeDeviceDao.IsDeviceExist(ctx, mac, request.HomeID)
+	assert.Nil(t, err)
+	assert.True(t, exists)
+
+	exists, err = homeDeviceDao.IsDeviceExist(ctx, request.MAC, request.HomeID)
+	assert.Nil(t, err)
+	assert.True(t, exists)
+
+	other := newCreateDeviceRequest(request.HomeID)
+	otherMac := other.MAC
+	other.MAC = strings.ToLower(otherMac)
+	second := saveHomeDevice(t, ctx, homeDeviceDao, other)
+
+	updated := updateHomeDevice(t, ctx, homeDeviceDao, hDRequest.UpdateDeviceRequest{MAC: strings.ToLower(strings.ReplaceAll(otherMac, ":", "-"))}, second.ID, 0)
+	assert.Equal(t, otherMac, updated.MAC)
+
+	taken := request
+	taken.MAC = otherMac
+
+	_, err = homeDeviceDao.SaveHomeDevice(ctx, taken)
+	assertErrorCode(t, hdError.ErrDeviceAlreadyExists, err)
+}
+
+func testGetNotFound(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	_, err := homeDeviceDao.GetHomeDevice(context.Background(), uuid.New().String())
+	assertErrorCode(t, hdError.ErrDeviceNotFound, err)
+}
+
+func testUpdate(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+
+	updateRequest := hDRequest.UpdateDeviceRequest{
+		MAC:    newMac(),
+		Name:   "Kitchen Light",
+		HomeID: newHomeId(),
+	}
+
+	updated := updateHomeDevice(t, ctx, homeDeviceDao, updateRequest, saved.ID, saved.Version)
+
+	device := getHomeDevice(t, ctx, homeDeviceDao, saved.ID)
+
+	assert.Equal(t, device, updated)
+
+	assert.Equal(t, updateRequest.MAC, device.MAC)
+	assert.Equal(t, updateRequest.Name, device.Name)
+	assert.Equal(t, saved.Type, device.Type)
+	assert.Equal(t, updateRequest.HomeID, device.HomeID)
+	assert.Equal(t, saved.CreatedAt, device.CreatedAt)
+	assert.GreaterOrEqual(t, device.ModifiedAt, saved.ModifiedAt)
+	assert.Equal(t, saved.Version+1, device.Version)
+}
+
+func testUpdateModifiedAt(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+
+	// modifiedAt is stored in seconds
+	time.Sleep(1100 * time.Millisecond)
+
+	updateHomeDevice(t, ctx, homeDeviceDao, hDRequest.UpdateDeviceRequest{Name: "Kitchen Light"}, saved.ID, 0)
+
+	device := getHomeDevice(t, ctx, homeDeviceDao, saved.ID)
+
+	assert.Greater(t, device.ModifiedAt, saved.ModifiedAt)
+	assert.Equal(t, saved.CreatedAt, device.CreatedAt)
+}
+
+func testUpdateNotFound(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	id := uuid.New().String()
+
+	_, err := homeDeviceDao.UpdateHomeDevice(ctx, hDRequest.UpdateDeviceRequest{Name: "Kitchen Light"}, id, 0)
+	assertErrorCode(t, hdError.ErrDeviceNotFound, err)
+	_, err = homeDeviceDao.UpdateHomeDevice(ctx, hDRequest.UpdateDeviceRequest{HomeID: newHomeId()}, id, 0)
+	assertErrorCode(t, hdError.ErrDeviceNotFound, err)
+}
+
+func testUpdateMacAlreadyExists(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	homeId := newHomeId()
+
+	first := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(homeId))
+	second := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(homeId))
+
+	_, err := homeDeviceDao.UpdateHomeDevice(ctx, hDRequest.UpdateDeviceRequest{MAC: first.MAC}, second.ID, 0)
+	assertErrorCode(t, hdError.ErrDeviceAlreadyExists, err)
+
+	device := getHomeDevice(t, ctx, homeDeviceDao, second.ID)
+	assert.Equal(t, second.MAC, device.MAC)
+	assert.Equal(t, second.Version, device.Version)
+}
+
+func testUpdateReleasesPreviousMac(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	request := newCreateDeviceRequest(newHomeId())
+
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, request)
+
+	updateHomeDevice(t, ctx, homeDeviceDao, hDRequest.UpdateDeviceRequest{HomeID: newHomeId()}, saved.ID, 0)
+
+	saveHomeDevice(t, ctx, homeDeviceDao, request)
+}
+
+func testUpdateVersionConflict(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+
+	updateHomeDevice(t, ctx, homeDeviceDao, hDRequest.UpdateDeviceRequest{Name: "Kitchen Light"}, saved.ID, saved.Version)
+
+	_, err := homeDeviceDao.UpdateHomeDevice(ctx, hDRequest.UpdateDeviceRequest{Name: "Bedroom Light"}, saved.ID, saved.Version)
+	assertErrorCode(t, hdError.ErrVersionConflict, err)
+
+	_, err = homeDeviceDao.UpdateHomeDevice(ctx, hDRequest.UpdateDeviceRequest{HomeID: newHomeId()}, saved.ID, saved.Version)
+	assertErrorCode(t, hdError.ErrVersionConflict, err)
+
+	device := getHomeDevice(t, ctx, homeDeviceDao, saved.ID)
+	assert.Equal(t, "Kitchen Light", device.Name)
+	assert.Equal(t, saved.HomeID, device.HomeID)
+}
+
+func testDelete(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	request := newCreateDeviceRequest(newHomeId())
+
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, request)
+
+	deleted := deleteHomeDevice(t, ctx, homeDeviceDao, saved.ID, 0)
+	assert.Equal(t, saved.ID, deleted.ID)
+	assert.Equal(t, saved.Version+1, deleted.Version)
+
+	_, err := homeDeviceDao.GetHomeDevice(ctx, saved.ID)
+	assertErrorCode(t, hdError.ErrDeviceNotFound, err)
+
+	saveHomeDevice(t, ctx, homeDeviceDao, request)
+}
+
+func testDeleteNotFound(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	_, err := homeDeviceDao.DeleteHomeDevice(context.Background(), uuid.New().String(), 0)
+	assertErrorCode(t, hdError.ErrDeviceNotFound, err)
+}
+
+func testDeleteVersionConflict(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+
+	_, err := homeDeviceDao.DeleteHomeDevice(ctx, saved.ID, saved.Version+1)
+	assertErrorCode(t, hdError.ErrVersionConflict, err)
+
+	deleteHomeDevice(t, ctx, homeDeviceDao, saved.ID, saved.Version)
+}
+
+func testDeletedDeviceIsHidden(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	homeId := newHomeId()
+
+	deleted := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(homeId))
+	active := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(homeId))
+
+	deleteHomeDevice(t, ctx, homeDeviceDao, deleted.ID, 0)
+
+	exists, err := homeDeviceDao.IsDeviceExist(ctx, deleted.MAC, homeId)
+	assert.Nil(t, err)
+	assert.False(t, exists)
+
+	response, err := homeDeviceDao.ListHomeDevices(ctx, homeId, "", "", 0, "")
+	if err != nil {
+		t.Fatalf("expected a list of home devices but got an error %v", err)
+	}
+
+	assert.Len(t, response.Devices, 1)
+	assert.Equal(t, active.ID, response.Devices[0].ID)
+}
+
+func testDeletedDeviceIsReadOnly(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+
+	deleteHomeDevice(t, ctx, homeDeviceDao, saved.ID, 0)
+
+	_, err := homeDeviceDao.UpdateHomeDevice(ctx, hDRequest.UpdateDeviceRequest{Name: "Kitchen Light"}, saved.ID, 0)
+	assertErrorCode(t, hdError.ErrDeviceNotFound, err)
+	_, err = homeDeviceDao.UpdateHomeDevice(ctx, hDRequest.UpdateDeviceRequest{HomeID: newHomeId()}, saved.ID, 0)
+	assertErrorCode(t, hdError.ErrDeviceNotFound, err)
+	_, err = homeDeviceDao.DeleteHomeDevice(ctx, saved.ID, 0)
+	assertErrorCode(t, hdError.ErrDeviceNotFound, err)
+}
+
+func testGetDeleted(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	request := newCreateDeviceRequest(newHomeId())
+	request.RoomID = uuid.New().String()
+
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, request)
+	deleted := deleteHomeDevice(t, ctx, homeDeviceDao, saved.ID, 0)
+
+	device, err := homeDeviceDao.GetDeletedHomeDevice(ctx, saved.ID)
+	if err != nil {
+		t.Fatalf("expected a deleted home device but got an error %v", err)
+	}
+
+	assert.Equal(t, saved.ID, device.ID)
+	assert.Equal(t, saved.HomeID, device.HomeID)
+	assert.Equal(t, saved.RoomID, device.RoomID)
+	assert.Equal(t, deleted.Version, device.Version)
+}
+
+func testGetDeletedNotDeleted(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+
+	_, err := homeDeviceDao.GetDeletedHomeDevice(ctx, saved.ID)
+	assertErrorCode(t, hdError.ErrDeviceNotDeleted, err)
+}
+
+func testGetDeletedNotFound(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	_, err := homeDeviceDao.GetDeletedHomeDevice(context.Background(), uuid.New().String())
+	assertErrorCode(t, hdError.ErrDeviceNotFound, err)
+}
+
+func testRestore(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+
+	deleteHomeDevice(t, ctx, homeDeviceDao, saved.ID, saved.Version)
+
+	restored, err := homeDeviceDao.RestoreHomeDevice(ctx, saved.ID)
+	if err != nil {
+		t.Fatalf("expected a restored home device but got an error %v", err)
+	}
+
+	assert.Equal(t, saved.ID, restored.ID)
+	assert.Equal(t, saved.MAC, restored.MAC)
+	assert.Equal(t, saved.HomeID, restored.HomeID)
+	assert.Equal(t, saved.CreatedAt, restored.CreatedAt)
+	assert.Equal(t, saved.Version+2, restored.Version)
+
+	device := getHomeDevice(t, ctx, homeDeviceDao, saved.ID)
+	assert.Equal(t, *restored, *device)
+
+	_, err = homeDeviceDao.SaveHomeDevice(ctx, hDRequest.CreateDeviceRequest{MAC: saved.MAC, Name: saved.Name, Type: saved.Type, HomeID: saved.HomeID})
+	assertErrorCode(t, hdError.ErrDeviceAlreadyExists, err)
+}
+
+func testRestoreNotDeleted(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+
+	_, err := homeDeviceDao.RestoreHomeDevice(ctx, saved.ID)
+	assertErrorCode(t, hdError.ErrDeviceNotDeleted, err)
+}
+
+func testRestoreNotFound(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	_, err := homeDeviceDao.RestoreHomeDevice(context.Background(), uuid.New().String())
+	assertErrorCode(t, hdError.ErrDeviceNotFound, err)
+}
+
+func testRestoreMacAlreadyExists(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	request := newCreateDeviceRequest(newHomeId())
+
+	deleted := saveHomeDevice(t, ctx, homeDeviceDao, request)
+	deleteHomeDevice(t, ctx, homeDeviceDao, deleted.ID, 0)
+
+	replacement := saveHomeDevice(t, ctx, homeDeviceDao, request)
+
+	_, err := homeDeviceDao.RestoreHomeDevice(ctx, deleted.ID)
+	assertErrorCode(t, hdError.ErrDeviceAlreadyExists, err)
+
+	getHomeDevice(t, ctx, homeDeviceDao, replacement.ID)
+	_, err = homeDeviceDao.GetHomeDevice(ctx, deleted.ID)
+	assertErrorCode(t, hdError.ErrDeviceNotFound, err)
+}
+
+func testHardDelete(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	request := newCreateDeviceRequest(newHomeId())
+
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, request)
+
+	assert.Nil(t, homeDeviceDao.HardDeleteHomeDevice(ctx, saved.ID, saved.Version))
+
+	_, err := homeDeviceDao.RestoreHomeDevice(ctx, saved.ID)
+	assertErrorCode(t, hdError.ErrDeviceNotFound, err)
+
+	saveHomeDevice(t, ctx, homeDeviceDao, request)
+}
+
+func testHardDeleteDeletedDevice(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	request := newCreateDeviceRequest(newHomeId())
+
+	deleted := saveHomeDevice(t, ctx, homeDeviceDao, request)
+	deleteHomeDevice(t, ctx, homeDeviceDao, deleted.ID, 0)
+
+	replacement := saveHomeDevice(t, ctx, homeDeviceDao, request)
+
+	assert.Nil(t, homeDeviceDao.HardDeleteHomeDevice(ctx, deleted.ID, 0))
+
+	_, err := homeDeviceDao.RestoreHomeDevice(ctx, deleted.ID)
+	assertErrorCode(t, hdError.ErrDeviceNotFound, err)
+
+	// the guard now belongs to the replacement and must be kept
+	_, err = homeDeviceDao.SaveHomeDevice(ctx, request)
+	assertErrorCode(t, hdError.ErrDeviceAlreadyExists, err)
+	getHomeDevice(t, ctx, homeDeviceDao, replacement.ID)
+}
+
+func testHardDeleteNotFound(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	err := homeDeviceDao.HardDeleteHomeDevice(context.Background(), uuid.New().String(), 0)
+	assertErrorCode(t, hdError.ErrDeviceNotFound, err)
+}
+
+func testHardDeleteVersionConflict(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+
+	err := homeDeviceDao.HardDeleteHomeDevice(ctx, saved.ID, saved.Version+1)
+	assertErrorCode(t, hdError.ErrVersionConflict, err)
+
+	getHomeDevice(t, ctx, homeDeviceDao, saved.ID)
+}
+
+func testListPagination(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	homeId := newHomeId()
+
+	ids := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		ids[saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(homeId)).ID] = true
+	}
+	saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+
+	firstPage, err := homeDeviceDao.ListHomeDevices(ctx, homeId, "", "", 2, "")
+	if err != nil {
+		t.Fatalf("expected a list of home devices but got an error %v", err)
+	}
+
+	assert.Len(t, firstPage.Devices, 2)
+	assert.NotEmpty(t, firstPage.NextCursor)
+
+	secondPage, err := homeDeviceDao.ListHomeDevices(ctx, homeId, "", "", 2, firstPage.NextCursor)
+	if err != nil {
+		t.Fatalf("expected a list of home devices but got an error %v", err)
+	}
+
+	assert.Len(t, secondPage.Devices, 1)
+	assert.Empty(t, secondPage.NextCursor)
+
+	listed := map[string]bool{}
+	for _, device := range append(firstPage.Devices, secondPage.Devices...) {
+		assert.Equal(t, homeId, device.HomeID)
+		listed[device.ID] = true
+	}
+
+	assert.Equal(t, ids, listed)
+}
+
+func testListEmpty(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	response, err := homeDeviceDao.ListHomeDevices(context.Background(), newHomeId(), "", "", 0, "")
+	if err != nil {
+		t.Fatalf("expected an empty list of home devices but got an error %v", err)
+	}
+
+	assert.Empty(t, response.Devices)
+	assert.Empty(t, response.NextCursor)
+}
+
+func testListInvalidCursor(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	_, err := homeDeviceDao.ListHomeDevices(context.Background(), newHomeId(), "", "", 2, "wrongCursor")
+	assertErrorCode(t, hdError.ErrInvalidCursor, err)
+}
+
+func testListCursorFromAnotherHome(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	homeId := newHomeId()
+
+	for i := 0; i < 2; i++ {
+		saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(homeId))
+	}
+
+	page, err := homeDeviceDao.ListHomeDevices(ctx, homeId, "", "", 1, "")
+	if err != nil {
+		t.Fatalf("expected a list of home devices but got an error %v", err)
+	}
+
+	_, err = homeDeviceDao.ListHomeDevices(ctx, newHomeId(), "", "", 1, page.NextCursor)
+	assertErrorCode(t, hdError.ErrInvalidCursor, err)
+}
+
+func testSaveAndUpdateRoom(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	request := newCreateDeviceRequest(newHomeId())
+	request.RoomID = uuid.New().String()
+
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, request)
+	assert.Equal(t, request.RoomID, saved.RoomID)
+	assert.Equal(t, request.RoomID, getHomeDevice(t, ctx, homeDeviceDao, saved.ID).RoomID)
+
+	// changing other fields keeps the room
+	updateHomeDevice(t, ctx, homeDeviceDao, hDRequest.UpdateDeviceRequest{MAC: newMac(), Name: "Kitchen Light"}, saved.ID, 0)
+	assert.Equal(t, request.RoomID, getHomeDevice(t, ctx, homeDeviceDao, saved.ID).RoomID)
+
+	roomId := uuid.New().String()
+	updateHomeDevice(t, ctx, homeDeviceDao, hDRequest.UpdateDeviceRequest{RoomID: roomId}, saved.ID, 0)
+	assert.Equal(t, roomId, getHomeDevice(t, ctx, homeDeviceDao, saved.ID).RoomID)
+}
+
+func testMoveHomeClearsRoom(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	request := newCreateDeviceRequest(newHomeId())
+	request.RoomID = uuid.New().String()
+
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, request)
+
+	updateHomeDevice(t, ctx, homeDeviceDao, hDRequest.UpdateDeviceRequest{HomeID: request.HomeID}, saved.ID, 0)
+	assert.Equal(t, request.RoomID, getHomeDevice(t, ctx, homeDeviceDao, saved.ID).RoomID)
+
+	homeId := newHomeId()
+	updated := updateHomeDevice(t, ctx, homeDeviceDao, hDRequest.UpdateDeviceRequest{HomeID: homeId}, saved.ID, 0)
+
+	device := getHomeDevice(t, ctx, homeDeviceDao, saved.ID)
+	assert.Equal(t, device, updated)
+	assert.Equal(t, homeId, device.HomeID)
+	assert.Empty(t, device.RoomID)
+}
+
+func testMoveHomeWithRoom(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	request := newCreateDeviceRequest(newHomeId())
+	request.RoomID = uuid.New().String()
+
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, request)
+
+	updateRequest := hDRequest.UpdateDeviceRequest{HomeID: newHomeId(), RoomID: uuid.New().String()}
+	updateHomeDevice(t, ctx, homeDeviceDao, updateRequest, saved.ID, 0)
+
+	device := getHomeDevice(t, ctx, homeDeviceDao, saved.ID)
+	assert.Equal(t, updateRequest.HomeID, device.HomeID)
+	assert.Equal(t, updateRequest.RoomID, device.RoomID)
+}
+
+func testListByRoom(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	homeId := newHomeId()
+	roomId := uuid.New().String()
+
+	ids := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		request := newCreateDeviceRequest(homeId)
+		request.RoomID = roomId
+		ids[saveHomeDevice(t, ctx, homeDeviceDao, request).ID] = true
+	}
+	saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(homeId))
+
+	listed := map[string]bool{}
+	cursor := ""
+	for {
+		page, err := homeDeviceDao.ListHomeDevices(ctx, homeId, roomId, "", 1, cursor)
+		if err != nil {
+			t.Fatalf("expected a list of home devices but got an error %v", err)
+		}
+
+		for _, device := range page.Devices {
+			assert.Equal(t, roomId, device.RoomID)
+			listed[device.ID] = true
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	assert.Equal(t, ids, listed)
+}
+
+func testSavedDeviceIsOffline(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+
+	assert.Equal(t, hDConstants.DeviceStatusOffline, saved.Status)
+	assert.Zero(t, saved.LastSeenAt)
+	assert.Equal(t, hDConstants.DeviceStatusOffline, getHomeDevice(t, ctx, homeDeviceDao, saved.ID).Status)
+}
+
+func testMarkSeen(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+	seenAt := time.Now().Unix() - 100
+
+	online, err := homeDeviceDao.MarkHomeDeviceSeen(ctx, saved.ID, seenAt)
+	assert.Nil(t, err)
+
+	device := getHomeDevice(t, ctx, homeDeviceDao, saved.ID)
+	assert.Equal(t, hDConstants.DeviceStatusOnline, device.Status)
+	assert.Equal(t, seenAt, device.LastSeenAt)
+	assert.Equal(t, device, online)
+
+	// presence is not a change of the device
+	assert.Equal(t, saved.Version, device.Version)
+	assert.Equal(t, saved.ModifiedAt, device.ModifiedAt)
+
+	online, err = homeDeviceDao.MarkHomeDeviceSeen(ctx, saved.ID, seenAt+50)
+	assert.Nil(t, err)
+	assert.Nil(t, online)
+	assert.Equal(t, seenAt+50, getHomeDevice(t, ctx, homeDeviceDao, saved.ID).LastSeenAt)
+}
+
+func testMarkSeenIgnoresOlder(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+	seenAt := time.Now().Unix()
+
+	markHomeDeviceSeen(t, ctx, homeDeviceDao, saved.ID, seenAt)
+
+	for _, older := range []int64{seenAt, seenAt - 10} {
+		online, err := homeDeviceDao.MarkHomeDeviceSeen(ctx, saved.ID, older)
+		assert.Nil(t, err)
+		assert.Nil(t, online)
+	}
+
+	assert.Equal(t, seenAt, getHomeDevice(t, ctx, homeDeviceDao, saved.ID).LastSeenAt)
+}
+
+func testMarkSeenNotFound(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+
+	_, err := homeDeviceDao.MarkHomeDeviceSeen(ctx, uuid.New().String(), time.Now().Unix())
+	assertErrorCode(t, hdError.ErrDeviceNotFound, err)
+
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+	deleteHomeDevice(t, ctx, homeDeviceDao, saved.ID, 0)
+
+	_, err = homeDeviceDao.MarkHomeDeviceSeen(ctx, saved.ID, time.Now().Unix())
+	assertErrorCode(t, hdError.ErrDeviceNotFound, err)
+}
+
+func testUpdateKeepsPresence(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+	seenAt := time.Now().Unix()
+
+	markHomeDeviceSeen(t, ctx, homeDeviceDao, saved.ID, seenAt)
+	updateHomeDevice(t, ctx, homeDeviceDao, hDRequest.UpdateDeviceRequest{Name: "Kitchen Light"}, saved.ID, 0)
+
+	device := getHomeDevice(t, ctx, homeDeviceDao, saved.ID)
+	assert.Equal(t, "Kitchen Light", device.Name)
+	assert.Equal(t, hDConstants.DeviceStatusOnline, device.Status)
+	assert.Equal(t, seenAt, device.LastSeenAt)
+}
+
+func testListStaleAndMarkOffline(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	homeId := newHomeId()
+	now := time.Now().Unix()
+
+	stale := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(homeId))
+	markHomeDeviceSeen(t, ctx, homeDeviceDao, stale.ID, now-1000)
+
+	recent := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(homeId))
+	markHomeDeviceSeen(t, ctx, homeDeviceDao, recent.ID, now)
+
+	neverSeen := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(homeId))
+
+	staleIds := listStaleHomeDeviceIds(t, ctx, homeDeviceDao, now-500)
+	assert.True(t, staleIds[stale.ID])
+	assert.False(t, staleIds[recent.ID])
+	assert.False(t, staleIds[neverSeen.ID])
+
+	wentOffline, err := homeDeviceDao.MarkHomeDeviceOffline(ctx, stale.ID, now-1000)
+	assert.Nil(t, err)
+	assert.True(t, wentOffline)
+
+	device := getHomeDevice(t, ctx, homeDeviceDao, stale.ID)
+	assert.Equal(t, hDConstants.DeviceStatusOffline, device.Status)
+	assert.Equal(t, now-1000, device.LastSeenAt)
+	assert.False(t, listStaleHomeDeviceIds(t, ctx, homeDeviceDao, now-500)[stale.ID])
+
+	wentOffline, err = homeDeviceDao.MarkHomeDeviceOffline(ctx, stale.ID, now-1000)
+	assert.Nil(t, err)
+	assert.False(t, wentOffline)
+
+	// it comes back online with the next heartbeat
+	online, err := homeDeviceDao.MarkHomeDeviceSeen(ctx, stale.ID, now)
+	assert.Nil(t, err)
+	assert.Equal(t, getHomeDevice(t, ctx, homeDeviceDao, stale.ID), online)
+}
+
+func testMarkOfflineSeenAgain(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+	now := time.Now().Unix()
+
+	markHomeDeviceSeen(t, ctx, homeDeviceDao, saved.ID, now-1000)
+	markHomeDeviceSeen(t, ctx, homeDeviceDao, saved.ID, now)
+
+	wentOffline, err := homeDeviceDao.MarkHomeDeviceOffline(ctx, saved.ID, now-1000)
+	assert.Nil(t, err)
+	assert.False(t, wentOffline)
+	assert.Equal(t, hDConstants.DeviceStatusOnline, getHomeDevice(t, ctx, homeDeviceDao, saved.ID).Status)
+
+	wentOffline, err = homeDeviceDao.MarkHomeDeviceOffline(ctx, uuid.New().String(), now)
+	assert.Nil(t, err)
+	assert.False(t, wentOffline)
+}
+
+func testListByStatus(t *testing.T, homeDeviceDao dao.HomeDeviceDao) {
+
+	ctx := context.Background()
+	homeId := newHomeId()
+
+	online := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(homeId))
+	markHomeDeviceSeen(t, ctx, homeDeviceDao, online.ID, time.Now().Unix())
+
+	offline := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		offline[saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(homeId)).ID] = true
+	}
+
+	page, err := homeDeviceDao.ListHomeDevices(ctx, homeId, "", hDConstants.DeviceStatusOnline, 0, "")
+	assert.Nil(t, err)
+	assert.Len(t, page.Devices, 1)
+	assert.Equal(t, online.ID, page.Devices[0].ID)
+
+	page, err = homeDeviceDao.ListHomeDevices(ctx, homeId, "", hDConstants.DeviceStatusOffline, 0, "")
+	assert.Nil(t, err)
+
+	listed := map[string]bool{}
+	for _, device := range page.Devices {
+		assert.Equal(t, hDConstants.DeviceStatusOffline, device.Status)
+		listed[device.ID] = true
+	}
+	assert.Equal(t, offline, listed)
+}
+
+func newCreateDeviceRequest(homeId string) hDRequest.CreateDeviceRequest {
+	return hDRequest.CreateDeviceRequest{
+		MAC:    newMac(),
+		Name:   "Living Room Light",
+		Type:   "light",
+		HomeID: homeId,
+	}
+}
+
+func newHomeId() string {
+	return "home" + strings.ReplaceAll(uuid.New().String(), "-", "")[:12]
+}
+
+func newMac() string {
+	id := uuid.New()
+	return fmt.Sprintf("%02X:%02X:%02X:%02X:%02X:%02X", id[0], id[1], id[2], id[3], id[4], id[5])
+}
+
+func saveHomeDevice(t *testing.T, ctx context.Context, homeDeviceDao dao.HomeDeviceDao, request hDRequest.CreateDeviceRequest) *hDResponse.HomdeDeviceResponse {
+	t.Helper()
+
+	device, err := homeDeviceDao.SaveHomeDevice(ctx, request)
+	if err != nil {
+		t.Fatalf("expected a new home device but got an error %v", err)
+	}
+
+	return device
+}
+
+func deleteHomeDevice(t *testing.T, ctx context.Context, homeDeviceDao dao.HomeDeviceDao, id string, expectedVersion int64) *hDResponse.HomdeDeviceResponse {
+	t.Helper()
+
+	device, err := homeDeviceDao.DeleteHomeDevice(ctx, id, expectedVersion)
+	if err != nil {
+		t.Fatalf("expected a deleted home device but got an error %v", err)
+	}
+
+	return device
+}
+
+func getHomeDevice(t *testing.T, ctx context.Context, homeDeviceDao dao.HomeDeviceDao, id string) *hDResponse.HomdeDeviceResponse {
+	t.Helper()
+
+	device, err := homeDeviceDao.GetHomeDevice(ctx, id)
+	if err != nil {
+		t.Fatalf("expected a home device but got an error %v", err)
+	}
+
+	return device
+}
+
+func updateHomeDevice(t *testing.T, ctx context.Context, homeDeviceDao dao.HomeDeviceDao, request hDRequest.UpdateDeviceRequest, id string, expectedVersion int64) *hDResponse.HomdeDeviceResponse {
+	t.Helper()
+
+	device, err := homeDeviceDao.UpdateHomeDevice(ctx, request, id, expectedVersion)
+	if err != nil {
+		t.Fatalf("expected the home device to be updated but got an error %v", err)
+	}
+
+	return device
+}
+
+func markHomeDeviceSeen(t *testing.T, ctx context.Context, homeDeviceDao dao.HomeDeviceDao, id string, seenAt int64) {
+	t.Helper()
+
+	if _, err := homeDeviceDao.MarkHomeDeviceSeen(ctx, id, seenAt); err != nil {
+		t.Fatalf("expected the home device to be seen but got an error %v", err)
+	}
+}
+
+// listStaleHomeDeviceIds returns the ids of the stale devices.
+func listStaleHomeDeviceIds(t *testing.T, ctx context.Context, homeDeviceDao dao.HomeDeviceDao, seenBefore int64) map[string]bool {
+	t.Helper()
+
+	devices, err := homeDeviceDao.ListStaleHomeDevices(ctx, seenBefore)
+	if err != nil {
+		t.Fatalf("expected the stale home devices but got an error %v", err)
+	}
+
+	ids := map[string]bool{}
+	for _, device := range devices {
+		ids[device.ID] = true
+	}
+
+	return ids
+}
+
+func assertErrorCode(t *testing.T, expected hdError.Definition, err error) {
+	t.Helper()
+
+	if err == nil {
+		t.Fatalf("expected an error with code %v, but got nil", expected.Code)
+	}
+
+	assert.ErrorIs(t, err, expected)
+}
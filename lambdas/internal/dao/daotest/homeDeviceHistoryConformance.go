@@ -0,0 +1,233 @@
+package daotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	hDAudit "github.com/odhoman/home-devices/internal/audit"
+	hDConstants "github.com/odhoman/home-devices/internal/constants"
+	"github.com/odhoman/home-devices/internal/dao"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RunHomeDeviceHistoryConformanceSuite checks that a HomeDeviceDao that records
+// the history writes a change record with every write of a device.
+func RunHomeDeviceHistoryConformanceSuite(t *testing.T, newDaos func() (dao.HomeDeviceDao, dao.DeviceHistoryDao)) {
+
+	tests := map[string]func(t *testing.T, homeDeviceDao dao.HomeDeviceDao, deviceHistoryDao dao.DeviceHistoryDao){
+		"RecordsSave":                   testRecordsSave,
+		"RecordsUpdate":                 testRecordsUpdate,
+		"RecordsMove":                   testRecordsMove,
+		"RecordsDelete":                 testRecordsDelete,
+		"RecordsRestore":                testRecordsRestore,
+		"RecordsHardDelete":             testRecordsHardDelete,
+		"RecordsHardDeleteOfDeleted":    testRecordsHardDeleteOfDeleted,
+		"RecordsStatusChanges":          testRecordsStatusChanges,
+		"FailedWritesAreNotRecorded":    testFailedWritesAreNotRecorded,
+		"HeartbeatsAreNotRecorded":      testHeartbeatsAreNotRecorded,
+		"StaleMarkOfflineIsNotRecorded": testStaleMarkOfflineIsNotRecorded,
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			homeDeviceDao, deviceHistoryDao := newDaos()
+			test(t, homeDeviceDao, deviceHistoryDao)
+		})
+	}
+}
+
+func testRecordsSave(t *testing.T, homeDeviceDao dao.HomeDeviceDao, deviceHistoryDao dao.DeviceHistoryDao) {
+
+	ctx := hDAudit.WithSource(hDAudit.WithActor(context.Background(), "user-1"), hDAudit.SourceAPI)
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+
+	changes := listDeviceChanges(t, ctx, deviceHistoryDao, saved.ID)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, hDAudit.OperationCreate, changes[0].Operation)
+	assert.Equal(t, "user-1", changes[0].Actor)
+	assert.Equal(t, hDAudit.SourceAPI, changes[0].Source)
+	assert.Equal(t, int64(1), changes[0].Version)
+	assert.True(t, changes[0].ChangedAt > 0)
+	assert.Contains(t, changes[0].Changes, hDResponse.DeviceFieldChange{Field: "name", After: saved.Name})
+}
+
+func testRecordsUpdate(t *testing.T, homeDeviceDao dao.HomeDeviceDao, deviceHistoryDao dao.DeviceHistoryDao) {
+
+	ctx := context.Background()
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+
+	updateHomeDevice(t, ctx, homeDeviceDao, hDRequest.UpdateDeviceRequest{Name: "Kitchen Light"}, saved.ID, 0)
+
+	changes := listDeviceChanges(t, ctx, deviceHistoryDao, saved.ID)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, hDAudit.OperationUpdate, changes[0].Operation)
+	assert.Equal(t, saved.Version+1, changes[0].Version)
+	assert.Equal(t, []hDResponse.DeviceFieldChange{{Field: "name", Before: saved.Name, After: "Kitchen Light"}}, changes[0].Changes)
+}
+
+func testRecordsMove(t *testing.T, homeDeviceDao dao.HomeDeviceDao, deviceHistoryDao dao.DeviceHistoryDao) {
+
+	ctx := context.Background()
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+	otherHomeId := newHomeId()
+
+	updateHomeDevice(t, ctx, homeDeviceDao, hDRequest.UpdateDeviceRequest{HomeID: otherHomeId}, saved.ID, saved.Version)
+
+	changes := listDeviceChanges(t, ctx, deviceHistoryDao, saved.ID)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, hDAudit.OperationUpdate, changes[0].Operation)
+	assert.Equal(t, []hDResponse.DeviceFieldChange{{Field: "homeId", Before: saved.HomeID, After: otherHomeId}}, changes[0].Changes)
+}
+
+func testRecordsDelete(t *testing.T, homeDeviceDao dao.HomeDeviceDao, deviceHistoryDao dao.DeviceHistoryDao) {
+
+	ctx := context.Background()
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+
+	deleteHomeDevice(t, ctx, homeDeviceDao, saved.ID, 0)
+
+	changes := listDeviceChanges(t, ctx, deviceHistoryDao, saved.ID)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, hDAudit.OperationDelete, changes[0].Operation)
+	assert.Equal(t, saved.Version+1, changes[0].Version)
+	assert.Contains(t, changes[0].Changes, hDResponse.DeviceFieldChange{Field: "name", Before: saved.Name})
+}
+
+func testRecordsRestore(t *testing.T, homeDeviceDao dao.HomeDeviceDao, deviceHistoryDao dao.DeviceHistoryDao) {
+
+	ctx := context.Background()
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+	deleteHomeDevice(t, ctx, homeDeviceDao, saved.ID, 0)
+
+	restored, err := homeDeviceDao.RestoreHomeDevice(ctx, saved.ID)
+	assert.Nil(t, err)
+
+	changes := listDeviceChanges(t, ctx, deviceHistoryDao, saved.ID)
+	assert.Len(t, changes, 3)
+	assert.Equal(t, hDAudit.OperationRestore, changes[0].Operation)
+	assert.Equal(t, restored.Version, changes[0].Version)
+	assert.Contains(t, changes[0].Changes, hDResponse.DeviceFieldChange{Field: "name", After: saved.Name})
+}
+
+func testRecordsHardDelete(t *testing.T, homeDeviceDao dao.HomeDeviceDao, deviceHistoryDao dao.DeviceHistoryDao) {
+
+	ctx := hDAudit.WithSource(context.Background(), hDAudit.SourceAdmin)
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+
+	assert.Nil(t, homeDeviceDao.HardDeleteHomeDevice(ctx, saved.ID, 0))
+
+	changes := listDeviceChanges(t, ctx, deviceHistoryDao, saved.ID)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, hDAudit.OperationHardDelete, changes[0].Operation)
+	assert.Equal(t, hDAudit.SourceAdmin, changes[0].Source)
+	assert.Contains(t, changes[0].Changes, hDResponse.DeviceFieldChange{Field: "name", Before: saved.Name})
+}
+
+func testRecordsHardDeleteOfDeleted(t *testing.T, homeDeviceDao dao.HomeDeviceDao, deviceHistoryDao dao.DeviceHistoryDao) {
+
+	ctx := context.Background()
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+	deleteHomeDevice(t, ctx, homeDeviceDao, saved.ID, 0)
+
+	assert.Nil(t, homeDeviceDao.HardDeleteHomeDevice(ctx, saved.ID, 0))
+
+	changes := listDeviceChanges(t, ctx, deviceHistoryDao, saved.ID)
+	assert.Len(t, changes, 3)
+	assert.Equal(t, hDAudit.OperationHardDelete, changes[0].Operation)
+	assert.Empty(t, changes[0].Changes)
+}
+
+func testRecordsStatusChanges(t *testing.T, homeDeviceDao dao.HomeDeviceDao, deviceHistoryDao dao.DeviceHistoryDao) {
+
+	ctx := context.Background()
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+	seenAt := time.Now().Unix()
+
+	markHomeDeviceSeen(t, ctx, homeDeviceDao, saved.ID, seenAt)
+
+	wentOffline, err := homeDeviceDao.MarkHomeDeviceOffline(ctx, saved.ID, seenAt)
+	assert.Nil(t, err)
+	assert.True(t, wentOffline)
+
+	changes := listDeviceChanges(t, ctx, deviceHistoryDao, saved.ID)
+	assert.Len(t, changes, 3)
+
+	for _, change := range changes[:2] {
+		assert.Equal(t, hDAudit.OperationStatusChange, change.Operation)
+		assert.Equal(t, hDAudit.SourcePresence, change.Source)
+	}
+
+	assert.Equal(t, []hDResponse.DeviceFieldChange{{Field: "status", Before: hDConstants.DeviceStatusOnline, After: hDConstants.DeviceStatusOffline}}, changes[0].Changes)
+	assert.Equal(t, []hDResponse.DeviceFieldChange{{Field: "status", Before: hDConstants.DeviceStatusOffline, After: hDConstants.DeviceStatusOnline}}, changes[1].Changes)
+}
+
+func testFailedWritesAreNotRecorded(t *testing.T, homeDeviceDao dao.HomeDeviceDao, deviceHistoryDao dao.DeviceHistoryDao) {
+
+	ctx := context.Background()
+	request := newCreateDeviceRequest(newHomeId())
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, request)
+
+	_, err := homeDeviceDao.SaveHomeDevice(ctx, request)
+	assertErrorCode(t, hdError.ErrDeviceAlreadyExists, err)
+
+	_, err = homeDeviceDao.UpdateHomeDevice(ctx, hDRequest.UpdateDeviceRequest{Name: "Kitchen Light"}, saved.ID, saved.Version+1)
+	assertErrorCode(t, hdError.ErrVersionConflict, err)
+
+	_, err = homeDeviceDao.DeleteHomeDevice(ctx, saved.ID, saved.Version+1)
+	assertErrorCode(t, hdError.ErrVersionConflict, err)
+
+	_, err = homeDeviceDao.RestoreHomeDevice(ctx, saved.ID)
+	assertErrorCode(t, hdError.ErrDeviceNotDeleted, err)
+
+	changes := listDeviceChanges(t, ctx, deviceHistoryDao, saved.ID)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, hDAudit.OperationCreate, changes[0].Operation)
+}
+
+func testHeartbeatsAreNotRecorded(t *testing.T, homeDeviceDao dao.HomeDeviceDao, deviceHistoryDao dao.DeviceHistoryDao) {
+
+	ctx := context.Background()
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+	seenAt := time.Now().Unix()
+
+	markHomeDeviceSeen(t, ctx, homeDeviceDao, saved.ID, seenAt)
+	markHomeDeviceSeen(t, ctx, homeDeviceDao, saved.ID, seenAt+60)
+	markHomeDeviceSeen(t, ctx, homeDeviceDao, saved.ID, seenAt-60)
+
+	changes := listDeviceChanges(t, ctx, deviceHistoryDao, saved.ID)
+	assert.Len(t, changes, 2)
+}
+
+func testStaleMarkOfflineIsNotRecorded(t *testing.T, homeDeviceDao dao.HomeDeviceDao, deviceHistoryDao dao.DeviceHistoryDao) {
+
+	ctx := context.Background()
+	saved := saveHomeDevice(t, ctx, homeDeviceDao, newCreateDeviceRequest(newHomeId()))
+	seenAt := time.Now().Unix()
+
+	markHomeDeviceSeen(t, ctx, homeDeviceDao, saved.ID, seenAt)
+
+	wentOffline, err := homeDeviceDao.MarkHomeDeviceOffline(ctx, saved.ID, seenAt-60)
+	assert.Nil(t, err)
+	assert.False(t, wentOffline)
+
+	changes := listDeviceChanges(t, ctx, deviceHistoryDao, saved.ID)
+	assert.Len(t, changes, 2)
+}
+
+// listDeviceChanges returns the history of the device, newest first.
+func listDeviceChanges(t *testing.T, ctx context.Context, deviceHistoryDao dao.DeviceHistoryDao, deviceId string) []hDResponse.DeviceChangeResponse {
+	t.Helper()
+
+	history, err := deviceHistoryDao.ListDeviceHistory(ctx, deviceId, 0, "")
+	assert.Nil(t, err)
+	if history == nil {
+		t.FailNow()
+	}
+
+	return history.Changes
+}
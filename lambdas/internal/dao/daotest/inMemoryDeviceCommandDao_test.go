@@ -0,0 +1,13 @@
+package daotest
+
+import (
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+)
+
+func TestInMemoryDeviceCommandDao_Conformance(t *testing.T) {
+	RunDeviceCommandDaoConformanceSuite(t, func() dao.DeviceCommandDao {
+		return dao.NewInMemoryDeviceCommandDao()
+	})
+}
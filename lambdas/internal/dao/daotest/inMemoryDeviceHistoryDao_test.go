@@ -0,0 +1,13 @@
+package daotest
+
+import (
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+)
+
+func TestInMemoryDeviceHistoryDao_Conformance(t *testing.T) {
+	RunDeviceHistoryDaoConformanceSuite(t, func() dao.DeviceHistoryDao {
+		return dao.NewInMemoryDeviceHistoryDao()
+	})
+}
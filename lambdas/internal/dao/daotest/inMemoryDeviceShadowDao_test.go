@@ -0,0 +1,13 @@
+package daotest
+
+import (
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+)
+
+func TestInMemoryDeviceShadowDao_Conformance(t *testing.T) {
+	RunDeviceShadowDaoConformanceSuite(t, func() dao.DeviceShadowDao {
+		return dao.NewInMemoryDeviceShadowDao()
+	})
+}
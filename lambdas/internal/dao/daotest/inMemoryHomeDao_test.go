@@ -0,0 +1,13 @@
+package daotest
+
+import (
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+)
+
+func TestInMemoryHomeDao_Conformance(t *testing.T) {
+	RunHomeDaoConformanceSuite(t, func() dao.HomeDao {
+		return dao.NewInMemoryHomeDao()
+	})
+}
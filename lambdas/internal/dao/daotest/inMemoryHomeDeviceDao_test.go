@@ -0,0 +1,13 @@
+package daotest
+
+import (
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+)
+
+func TestInMemoryHomeDeviceDao_Conformance(t *testing.T) {
+	RunHomeDeviceDaoConformanceSuite(t, func() dao.HomeDeviceDao {
+		return dao.NewInMemoryHomeDeviceDao()
+	})
+}
@@ -0,0 +1,14 @@
+package daotest
+
+import (
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+)
+
+func TestInMemoryHomeDeviceDao_HistoryConformance(t *testing.T) {
+	RunHomeDeviceHistoryConformanceSuite(t, func() (dao.HomeDeviceDao, dao.DeviceHistoryDao) {
+		deviceHistoryDao := dao.NewInMemoryDeviceHistoryDao()
+		return dao.NewInMemoryHomeDeviceDaoWithHistory(deviceHistoryDao), deviceHistoryDao
+	})
+}
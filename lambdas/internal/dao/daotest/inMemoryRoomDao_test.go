@@ -0,0 +1,13 @@
+package daotest
+
+import (
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+)
+
+func TestInMemoryRoomDao_Conformance(t *testing.T) {
+	RunRoomDaoConformanceSuite(t, func() dao.RoomDao {
+		return dao.NewInMemoryRoomDao()
+	})
+}
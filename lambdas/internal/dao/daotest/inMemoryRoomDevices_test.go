@@ -0,0 +1,14 @@
+package daotest
+
+import (
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+)
+
+func TestInMemoryHomeDeviceDao_RoomDevicesConformance(t *testing.T) {
+	RunRoomDevicesConformanceSuite(t, func() (dao.HomeDeviceDao, dao.RoomDao) {
+		roomDao := dao.NewInMemoryRoomDao()
+		return dao.NewInMemoryHomeDeviceDaoWithRooms(nil, roomDao), roomDao
+	})
+}
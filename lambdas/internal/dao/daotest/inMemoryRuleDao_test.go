@@ -0,0 +1,13 @@
+package daotest
+
+import (
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+)
+
+func TestInMemoryRuleDao_Conformance(t *testing.T) {
+	RunRuleDaoConformanceSuite(t, func() dao.RuleDao {
+		return dao.NewInMemoryRuleDao()
+	})
+}
@@ -0,0 +1,13 @@
+package daotest
+
+import (
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+)
+
+func TestInMemoryRuleExecutionDao_Conformance(t *testing.T) {
+	RunRuleExecutionDaoConformanceSuite(t, func() dao.RuleExecutionDao {
+		return dao.NewInMemoryRuleExecutionDao()
+	})
+}
@@ -0,0 +1,13 @@
+package daotest
+
+import (
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+)
+
+func TestInMemorySceneDao_Conformance(t *testing.T) {
+	RunSceneDaoConformanceSuite(t, func() dao.SceneDao {
+		return dao.NewInMemorySceneDao()
+	})
+}
@@ -0,0 +1,13 @@
+package daotest
+
+import (
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+)
+
+func TestInMemoryScheduleDao_Conformance(t *testing.T) {
+	RunScheduleDaoConformanceSuite(t, func() dao.ScheduleDao {
+		return dao.NewInMemoryScheduleDao()
+	})
+}
@@ -0,0 +1,180 @@
+package daotest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// RunRoomDaoConformanceSuite checks that a RoomDao implementation follows the
+// behaviour expected by the services.
+func RunRoomDaoConformanceSuite(t *testing.T, newRoomDao func() dao.RoomDao) {
+
+	tests := map[string]func(t *testing.T, roomDao dao.RoomDao){
+		"SaveAndGet":            testRoomSaveAndGet,
+		"GetFromAnotherHome":    testRoomGetFromAnotherHome,
+		"GetNotFound":           testRoomGetNotFound,
+		"List":                  testRoomList,
+		"ListEmpty":             testRoomListEmpty,
+		"Update":                testRoomUpdate,
+		"UpdateVersionConflict": testRoomUpdateVersionConflict,
+		"UpdateNotFound":        testRoomUpdateNotFound,
+		"Delete":                testRoomDelete,
+		"DeleteVersionConflict": testRoomDeleteVersionConflict,
+		"DeleteNotFound":        testRoomDeleteNotFound,
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test(t, newRoomDao())
+		})
+	}
+}
+
+func testRoomSaveAndGet(t *testing.T, roomDao dao.RoomDao) {
+
+	ctx := context.Background()
+	homeId := newHomeId()
+
+	saved := saveRoomForTesting(t, roomDao, homeId)
+
+	assert.NotEmpty(t, saved.ID)
+	assert.Equal(t, homeId, saved.HomeID)
+	assert.Equal(t, "Living Room", saved.Name)
+	assert.Equal(t, int64(1), saved.Version)
+	assert.NotZero(t, saved.CreatedAt)
+
+	room, err := roomDao.GetRoom(ctx, homeId, saved.ID)
+	if err != nil {
+		t.Fatalf("expected the room but got an error %v", err)
+	}
+
+	assert.Equal(t, saved, room)
+}
+
+func testRoomGetFromAnotherHome(t *testing.T, roomDao dao.RoomDao) {
+
+	saved := saveRoomForTesting(t, roomDao, newHomeId())
+
+	_, err := roomDao.GetRoom(context.Background(), newHomeId(), saved.ID)
+	assertErrorCode(t, hDError.ErrRoomNotFound, err)
+}
+
+func testRoomGetNotFound(t *testing.T, roomDao dao.RoomDao) {
+
+	_, err := roomDao.GetRoom(context.Background(), newHomeId(), uuid.New().String())
+	assertErrorCode(t, hDError.ErrRoomNotFound, err)
+}
+
+func testRoomList(t *testing.T, roomDao dao.RoomDao) {
+
+	homeId := newHomeId()
+
+	ids := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		ids[saveRoomForTesting(t, roomDao, homeId).ID] = true
+	}
+	saveRoomForTesting(t, roomDao, newHomeId())
+
+	rooms, err := roomDao.ListRooms(context.Background(), homeId)
+	if err != nil {
+		t.Fatalf("expected the rooms but got an error %v", err)
+	}
+
+	listed := map[string]bool{}
+	for _, room := range rooms.Rooms {
+		assert.Equal(t, homeId, room.HomeID)
+		listed[room.ID] = true
+	}
+
+	assert.Equal(t, ids, listed)
+}
+
+func testRoomListEmpty(t *testing.T, roomDao dao.RoomDao) {
+
+	rooms, err := roomDao.ListRooms(context.Background(), newHomeId())
+	if err != nil {
+		t.Fatalf("expected no rooms but got an error %v", err)
+	}
+
+	assert.NotNil(t, rooms.Rooms)
+	assert.Empty(t, rooms.Rooms)
+}
+
+func testRoomUpdate(t *testing.T, roomDao dao.RoomDao) {
+
+	ctx := context.Background()
+	saved := saveRoomForTesting(t, roomDao, newHomeId())
+
+	assert.Nil(t, roomDao.UpdateRoom(ctx, hDRequest.UpdateRoomRequest{Name: "Kitchen"}, saved.HomeID, saved.ID, saved.Version))
+
+	room, err := roomDao.GetRoom(ctx, saved.HomeID, saved.ID)
+	if err != nil {
+		t.Fatalf("expected the room but got an error %v", err)
+	}
+
+	assert.Equal(t, "Kitchen", room.Name)
+	assert.Equal(t, saved.Version+1, room.Version)
+	assert.Equal(t, saved.CreatedAt, room.CreatedAt)
+}
+
+func testRoomUpdateVersionConflict(t *testing.T, roomDao dao.RoomDao) {
+
+	saved := saveRoomForTesting(t, roomDao, newHomeId())
+
+	err := roomDao.UpdateRoom(context.Background(), hDRequest.UpdateRoomRequest{Name: "Kitchen"}, saved.HomeID, saved.ID, saved.Version+1)
+	assertErrorCode(t, hDError.ErrVersionConflict, err)
+}
+
+func testRoomUpdateNotFound(t *testing.T, roomDao dao.RoomDao) {
+
+	err := roomDao.UpdateRoom(context.Background(), hDRequest.UpdateRoomRequest{Name: "Kitchen"}, newHomeId(), uuid.New().String(), 0)
+	assertErrorCode(t, hDError.ErrRoomNotFound, err)
+}
+
+func testRoomDelete(t *testing.T, roomDao dao.RoomDao) {
+
+	ctx := context.Background()
+	saved := saveRoomForTesting(t, roomDao, newHomeId())
+
+	assert.Nil(t, roomDao.DeleteRoom(ctx, saved.HomeID, saved.ID, saved.Version))
+
+	_, err := roomDao.GetRoom(ctx, saved.HomeID, saved.ID)
+	assertErrorCode(t, hDError.ErrRoomNotFound, err)
+}
+
+func testRoomDeleteVersionConflict(t *testing.T, roomDao dao.RoomDao) {
+
+	ctx := context.Background()
+	saved := saveRoomForTesting(t, roomDao, newHomeId())
+
+	err := roomDao.DeleteRoom(ctx, saved.HomeID, saved.ID, saved.Version+1)
+	assertErrorCode(t, hDError.ErrVersionConflict, err)
+
+	_, err = roomDao.GetRoom(ctx, saved.HomeID, saved.ID)
+	assert.Nil(t, err)
+}
+
+func testRoomDeleteNotFound(t *testing.T, roomDao dao.RoomDao) {
+
+	err := roomDao.DeleteRoom(context.Background(), newHomeId(), uuid.New().String(), 0)
+	assertErrorCode(t, hDError.ErrRoomNotFound, err)
+}
+
+func saveRoomForTesting(t *testing.T, roomDao dao.RoomDao, homeId string) *hDResponse.RoomResponse {
+	t.Helper()
+
+	room, err := roomDao.SaveRoom(context.Background(), homeId, hDRequest.CreateRoomRequest{Name: "Living Room"})
+	if err != nil {
+		t.Fatalf("expected a saved room but got an error %v", err)
+	}
+
+	return room
+}
@@ -0,0 +1,178 @@
+package daotest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// RunRoomDevicesConformanceSuite checks that a HomeDeviceDao that counts the
+// devices of the rooms keeps a RoomDao from deleting a room with devices, and
+// does not put a device in a room that does not exist.
+func RunRoomDevicesConformanceSuite(t *testing.T, newDaos func() (dao.HomeDeviceDao, dao.RoomDao)) {
+
+	tests := map[string]func(t *testing.T, homeDeviceDao dao.HomeDeviceDao, roomDao dao.RoomDao){
+		"RoomWithDevicesIsNotDeleted":    testRoomWithDevicesIsNotDeleted,
+		"RenameKeepsTheRoom":             testRenameKeepsTheRoom,
+		"MoveToAnotherRoomLeavesTheRoom": testMoveToAnotherRoomLeavesTheRoom,
+		"MoveToAnotherHomeLeavesTheRoom": testMoveToAnotherHomeLeavesTheRoom,
+		"DeleteLeavesTheRoom":            testDeleteLeavesTheRoom,
+		"HardDeleteLeavesTheRoom":        testHardDeleteLeavesTheRoom,
+		"RestoreEntersTheRoom":           testRestoreEntersTheRoom,
+		"SaveInUnknownRoom":              testSaveInUnknownRoom,
+		"MoveToUnknownRoom":              testMoveToUnknownRoom,
+		"RestoreInDeletedRoom":           testRestoreInDeletedRoom,
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			homeDeviceDao, roomDao := newDaos()
+			test(t, homeDeviceDao, roomDao)
+		})
+	}
+}
+
+func testRoomWithDevicesIsNotDeleted(t *testing.T, homeDeviceDao dao.HomeDeviceDao, roomDao dao.RoomDao) {
+
+	ctx := context.Background()
+	room := saveRoomForTesting(t, roomDao, newHomeId())
+	saveHomeDeviceInRoom(t, ctx, homeDeviceDao, room.HomeID, room.ID)
+
+	assertErrorCode(t, hdError.ErrRoomHasDevices, roomDao.DeleteRoom(ctx, room.HomeID, room.ID, 0))
+
+	_, err := roomDao.GetRoom(ctx, room.HomeID, room.ID)
+	assert.Nil(t, err)
+}
+
+func testRenameKeepsTheRoom(t *testing.T, homeDeviceDao dao.HomeDeviceDao, roomDao dao.RoomDao) {
+
+	ctx := context.Background()
+	room := saveRoomForTesting(t, roomDao, newHomeId())
+	saved := saveHomeDeviceInRoom(t, ctx, homeDeviceDao, room.HomeID, room.ID)
+
+	updateHomeDevice(t, ctx, homeDeviceDao, hDRequest.UpdateDeviceRequest{Name: "Kitchen Light", RoomID: room.ID}, saved.ID, 0)
+	updateHomeDevice(t, ctx, homeDeviceDao, hDRequest.UpdateDeviceRequest{MAC: newMac()}, saved.ID, 0)
+
+	assertErrorCode(t, hdError.ErrRoomHasDevices, roomDao.DeleteRoom(ctx, room.HomeID, room.ID, 0))
+}
+
+func testMoveToAnotherRoomLeavesTheRoom(t *testing.T, homeDeviceDao dao.HomeDeviceDao, roomDao dao.RoomDao) {
+
+	ctx := context.Background()
+	room := saveRoomForTesting(t, roomDao, newHomeId())
+	other := saveRoomForTesting(t, roomDao, room.HomeID)
+	saved := saveHomeDeviceInRoom(t, ctx, homeDeviceDao, room.HomeID, room.ID)
+
+	updateHomeDevice(t, ctx, homeDeviceDao, hDRequest.UpdateDeviceRequest{RoomID: other.ID}, saved.ID, 0)
+
+	assert.Nil(t, roomDao.DeleteRoom(ctx, room.HomeID, room.ID, 0))
+	assertErrorCode(t, hdError.ErrRoomHasDevices, roomDao.DeleteRoom(ctx, other.HomeID, other.ID, 0))
+}
+
+func testMoveToAnotherHomeLeavesTheRoom(t *testing.T, homeDeviceDao dao.HomeDeviceDao, roomDao dao.RoomDao) {
+
+	ctx := context.Background()
+	room := saveRoomForTesting(t, roomDao, newHomeId())
+	saved := saveHomeDeviceInRoom(t, ctx, homeDeviceDao, room.HomeID, room.ID)
+
+	updateHomeDevice(t, ctx, homeDeviceDao, hDRequest.UpdateDeviceRequest{HomeID: newHomeId()}, saved.ID, 0)
+
+	assert.Nil(t, roomDao.DeleteRoom(ctx, room.HomeID, room.ID, 0))
+}
+
+func testDeleteLeavesTheRoom(t *testing.T, homeDeviceDao dao.HomeDeviceDao, roomDao dao.RoomDao) {
+
+	ctx := context.Background()
+	room := saveRoomForTesting(t, roomDao, newHomeId())
+	saved := saveHomeDeviceInRoom(t, ctx, homeDeviceDao, room.HomeID, room.ID)
+
+	deleteHomeDevice(t, ctx, homeDeviceDao, saved.ID, 0)
+
+	assert.Nil(t, roomDao.DeleteRoom(ctx, room.HomeID, room.ID, 0))
+}
+
+func testHardDeleteLeavesTheRoom(t *testing.T, homeDeviceDao dao.HomeDeviceDao, roomDao dao.RoomDao) {
+
+	ctx := context.Background()
+	room := saveRoomForTesting(t, roomDao, newHomeId())
+	saved := saveHomeDeviceInRoom(t, ctx, homeDeviceDao, room.HomeID, room.ID)
+	deleted := saveHomeDeviceInRoom(t, ctx, homeDeviceDao, room.HomeID, room.ID)
+	deleteHomeDevice(t, ctx, homeDeviceDao, deleted.ID, 0)
+
+	assert.Nil(t, homeDeviceDao.HardDeleteHomeDevice(ctx, saved.ID, 0))
+	assert.Nil(t, homeDeviceDao.HardDeleteHomeDevice(ctx, deleted.ID, 0))
+
+	assert.Nil(t, roomDao.DeleteRoom(ctx, room.HomeID, room.ID, 0))
+}
+
+func testRestoreEntersTheRoom(t *testing.T, homeDeviceDao dao.HomeDeviceDao, roomDao dao.RoomDao) {
+
+	ctx := context.Background()
+	room := saveRoomForTesting(t, roomDao, newHomeId())
+	saved := saveHomeDeviceInRoom(t, ctx, homeDeviceDao, room.HomeID, room.ID)
+	deleteHomeDevice(t, ctx, homeDeviceDao, saved.ID, 0)
+
+	_, err := homeDeviceDao.RestoreHomeDevice(ctx, saved.ID)
+	assert.Nil(t, err)
+
+	assertErrorCode(t, hdError.ErrRoomHasDevices, roomDao.DeleteRoom(ctx, room.HomeID, room.ID, 0))
+}
+
+func testSaveInUnknownRoom(t *testing.T, homeDeviceDao dao.HomeDeviceDao, roomDao dao.RoomDao) {
+
+	ctx := context.Background()
+	request := newCreateDeviceRequest(newHomeId())
+	request.RoomID = uuid.New().String()
+
+	_, err := homeDeviceDao.SaveHomeDevice(ctx, request)
+	assertErrorCode(t, hdError.ErrUnknownRoom, err)
+
+	// the guard of the mac was not kept
+	exists, err := homeDeviceDao.IsDeviceExist(ctx, request.MAC, request.HomeID)
+	assert.Nil(t, err)
+	assert.False(t, exists)
+}
+
+func testMoveToUnknownRoom(t *testing.T, homeDeviceDao dao.HomeDeviceDao, roomDao dao.RoomDao) {
+
+	ctx := context.Background()
+	room := saveRoomForTesting(t, roomDao, newHomeId())
+	saved := saveHomeDeviceInRoom(t, ctx, homeDeviceDao, room.HomeID, room.ID)
+
+	_, err := homeDeviceDao.UpdateHomeDevice(ctx, hDRequest.UpdateDeviceRequest{RoomID: uuid.New().String()}, saved.ID, 0)
+	assertErrorCode(t, hdError.ErrUnknownRoom, err)
+
+	assert.Equal(t, saved, getHomeDevice(t, ctx, homeDeviceDao, saved.ID))
+	assertErrorCode(t, hdError.ErrRoomHasDevices, roomDao.DeleteRoom(ctx, room.HomeID, room.ID, 0))
+}
+
+func testRestoreInDeletedRoom(t *testing.T, homeDeviceDao dao.HomeDeviceDao, roomDao dao.RoomDao) {
+
+	ctx := context.Background()
+	room := saveRoomForTesting(t, roomDao, newHomeId())
+	saved := saveHomeDeviceInRoom(t, ctx, homeDeviceDao, room.HomeID, room.ID)
+	deleteHomeDevice(t, ctx, homeDeviceDao, saved.ID, 0)
+	assert.Nil(t, roomDao.DeleteRoom(ctx, room.HomeID, room.ID, 0))
+
+	_, err := homeDeviceDao.RestoreHomeDevice(ctx, saved.ID)
+	assertErrorCode(t, hdError.ErrUnknownRoom, err)
+
+	_, err = homeDeviceDao.GetDeletedHomeDevice(ctx, saved.ID)
+	assert.Nil(t, err)
+}
+
+func saveHomeDeviceInRoom(t *testing.T, ctx context.Context, homeDeviceDao dao.HomeDeviceDao, homeId string, roomId string) *hDResponse.HomdeDeviceResponse {
+	t.Helper()
+
+	request := newCreateDeviceRequest(homeId)
+	request.RoomID = roomId
+
+	return saveHomeDevice(t, ctx, homeDeviceDao, request)
+}
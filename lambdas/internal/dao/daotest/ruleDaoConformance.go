@@ -0,0 +1,370 @@
+package daotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	hDConstants "github.com/odhoman/home-devices/internal/constants"
+	"github.com/odhoman/home-devices/internal/dao"
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// RunRuleDaoConformanceSuite checks that a RuleDao implementation follows the
+// behaviour expected by the services.
+func RunRuleDaoConformanceSuite(t *testing.T, newRuleDao func() dao.RuleDao) {
+
+	tests := map[string]func(t *testing.T, ruleDao dao.RuleDao){
+		"SaveAndGet":               testRuleSaveAndGet,
+		"SaveDefaults":             testRuleSaveDefaults,
+		"GetFromAnotherHome":       testRuleGetFromAnotherHome,
+		"GetNotFound":              testRuleGetNotFound,
+		"List":                     testRuleList,
+		"ListEmpty":                testRuleListEmpty,
+		"ListTriggered":            testRuleListTriggered,
+		"Update":                   testRuleUpdate,
+		"UpdateRemovesTimeWindow":  testRuleUpdateRemovesTimeWindow,
+		"UpdateVersionConflict":    testRuleUpdateVersionConflict,
+		"UpdateNotFound":           testRuleUpdateNotFound,
+		"Delete":                   testRuleDelete,
+		"DeleteVersionConflict":    testRuleDeleteVersionConflict,
+		"DeleteNotFound":           testRuleDeleteNotFound,
+		"ClaimFiring":              testRuleClaimFiring,
+		"ClaimFiringAfterCooldown": testRuleClaimFiringAfterCooldown,
+		"ClaimFiringNotFound":      testRuleClaimFiringNotFound,
+		"ClaimFiringSameReading":   testRuleClaimFiringSameReading,
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test(t, newRuleDao())
+		})
+	}
+}
+
+func testRuleSaveAndGet(t *testing.T, ruleDao dao.RuleDao) {
+
+	ctx := context.Background()
+	homeId := newHomeId()
+	request := newRuleRequest(uuid.New().String())
+
+	saved, err := ruleDao.SaveRule(ctx, homeId, request)
+	if err != nil {
+		t.Fatalf("expected a saved rule but got an error %v", err)
+	}
+
+	assert.NotEmpty(t, saved.ID)
+	assert.Equal(t, homeId, saved.HomeID)
+	assert.Equal(t, "Hallway light at night", saved.Name)
+	assert.True(t, saved.Enabled)
+	assert.Equal(t, hDResponse.RuleTriggerResponse{DeviceID: request.Trigger.DeviceID, Metric: "motion", Operator: "eq", Value: 1}, saved.Trigger)
+	assert.Equal(t, &hDResponse.RuleTimeWindowResponse{From: "22:00", To: "06:00", Timezone: "Europe/Madrid"}, saved.TimeWindow)
+	assert.Equal(t, "light-1", saved.Action.DeviceID)
+	assert.Equal(t, "turnOn", saved.Action.Command)
+	assert.Equal(t, int64(120), saved.CooldownSeconds)
+	assert.Equal(t, int64(1), saved.Version)
+	assert.NotZero(t, saved.CreatedAt)
+
+	rule, err := ruleDao.GetRule(ctx, homeId, saved.ID)
+	if err != nil {
+		t.Fatalf("expected the rule but got an error %v", err)
+	}
+
+	assert.Equal(t, saved, rule)
+}
+
+func testRuleSaveDefaults(t *testing.T, ruleDao dao.RuleDao) {
+
+	request := newRuleRequest(uuid.New().String())
+	request.TimeWindow = nil
+	request.CooldownSeconds = 0
+	request.Action.Params = map[string]interface{}{"brightness": float64(40)}
+
+	saved, err := ruleDao.SaveRule(context.Background(), newHomeId(), request)
+	if err != nil {
+		t.Fatalf("expected a saved rule but got an error %v", err)
+	}
+
+	rule, err := ruleDao.GetRule(context.Background(), saved.HomeID, saved.ID)
+	if err != nil {
+		t.Fatalf("expected the rule but got an error %v", err)
+	}
+
+	assert.Nil(t, rule.TimeWindow)
+	assert.Equal(t, int64(hDConstants.DefaultRuleCooldownSeconds), rule.CooldownSeconds)
+	assert.Equal(t, map[string]interface{}{"brightness": float64(40)}, rule.Action.Params)
+}
+
+func testRuleGetFromAnotherHome(t *testing.T, ruleDao dao.RuleDao) {
+
+	saved := saveRuleForTesting(t, ruleDao, newHomeId(), uuid.New().String())
+
+	_, err := ruleDao.GetRule(context.Background(), newHomeId(), saved.ID)
+	assertErrorCode(t, hDError.ErrRuleNotFound, err)
+}
+
+func testRuleGetNotFound(t *testing.T, ruleDao dao.RuleDao) {
+
+	_, err := ruleDao.GetRule(context.Background(), newHomeId(), uuid.New().String())
+	assertErrorCode(t, hDError.ErrRuleNotFound, err)
+}
+
+func testRuleList(t *testing.T, ruleDao dao.RuleDao) {
+
+	homeId := newHomeId()
+
+	ids := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		ids[saveRuleForTesting(t, ruleDao, homeId, uuid.New().String()).ID] = true
+	}
+	saveRuleForTesting(t, ruleDao, newHomeId(), uuid.New().String())
+
+	rules, err := ruleDao.ListRules(context.Background(), homeId)
+	if err != nil {
+		t.Fatalf("expected the rules but got an error %v", err)
+	}
+
+	listed := map[string]bool{}
+	for _, rule := range rules.Rules {
+		assert.Equal(t, homeId, rule.HomeID)
+		listed[rule.ID] = true
+	}
+
+	assert.Equal(t, ids, listed)
+}
+
+func testRuleListEmpty(t *testing.T, ruleDao dao.RuleDao) {
+
+	rules, err := ruleDao.ListRules(context.Background(), newHomeId())
+	if err != nil {
+		t.Fatalf("expected no rules but got an error %v", err)
+	}
+
+	assert.NotNil(t, rules.Rules)
+	assert.Empty(t, rules.Rules)
+}
+
+func testRuleListTriggered(t *testing.T, ruleDao dao.RuleDao) {
+
+	deviceId := uuid.New().String()
+
+	ids := map[string]bool{
+		saveRuleForTesting(t, ruleDao, newHomeId(), deviceId).ID: true,
+		saveRuleForTesting(t, ruleDao, newHomeId(), deviceId).ID: true,
+	}
+	saveRuleForTesting(t, ruleDao, newHomeId(), uuid.New().String())
+
+	rules, err := ruleDao.ListTriggeredRules(context.Background(), deviceId)
+	if err != nil {
+		t.Fatalf("expected the rules but got an error %v", err)
+	}
+
+	listed := map[string]bool{}
+	for _, rule := range rules {
+		assert.Equal(t, deviceId, rule.Trigger.DeviceID)
+		listed[rule.ID] = true
+	}
+
+	assert.Equal(t, ids, listed)
+
+	rules, err = ruleDao.ListTriggeredRules(context.Background(), uuid.New().String())
+	assert.Nil(t, err)
+	assert.Empty(t, rules)
+}
+
+func testRuleUpdate(t *testing.T, ruleDao dao.RuleDao) {
+
+	ctx := context.Background()
+	saved := saveRuleForTesting(t, ruleDao, newHomeId(), uuid.New().String())
+	assert.Nil(t, ruleDao.ClaimRuleFiring(ctx, saved.HomeID, saved.ID, time.Now().UnixMilli(), time.Now().Unix(), saved.CooldownSeconds))
+
+	enabled := false
+	request := newRuleRequest(uuid.New().String())
+	request.Name = "Hallway light"
+	request.Enabled = &enabled
+	request.Trigger.Operator = "gte"
+
+	assert.Nil(t, ruleDao.UpdateRule(ctx, request, saved.HomeID, saved.ID, saved.Version))
+
+	rule, err := ruleDao.GetRule(ctx, saved.HomeID, saved.ID)
+	if err != nil {
+		t.Fatalf("expected the rule but got an error %v", err)
+	}
+
+	assert.Equal(t, "Hallway light", rule.Name)
+	assert.False(t, rule.Enabled)
+	assert.Equal(t, request.Trigger.DeviceID, rule.Trigger.DeviceID)
+	assert.Equal(t, "gte", rule.Trigger.Operator)
+	assert.Equal(t, saved.Version+1, rule.Version)
+	assert.Equal(t, saved.CreatedAt, rule.CreatedAt)
+	assert.NotZero(t, rule.LastFiredAt)
+}
+
+func testRuleUpdateRemovesTimeWindow(t *testing.T, ruleDao dao.RuleDao) {
+
+	ctx := context.Background()
+	saved := saveRuleForTesting(t, ruleDao, newHomeId(), uuid.New().String())
+
+	request := newRuleRequest(saved.Trigger.DeviceID)
+	request.TimeWindow = nil
+
+	assert.Nil(t, ruleDao.UpdateRule(ctx, request, saved.HomeID, saved.ID, 0))
+
+	rule, err := ruleDao.GetRule(ctx, saved.HomeID, saved.ID)
+	if err != nil {
+		t.Fatalf("expected the rule but got an error %v", err)
+	}
+
+	assert.Nil(t, rule.TimeWindow)
+}
+
+func testRuleUpdateVersionConflict(t *testing.T, ruleDao dao.RuleDao) {
+
+	saved := saveRuleForTesting(t, ruleDao, newHomeId(), uuid.New().String())
+
+	err := ruleDao.UpdateRule(context.Background(), newRuleRequest(saved.Trigger.DeviceID), saved.HomeID, saved.ID, saved.Version+1)
+	assertErrorCode(t, hDError.ErrVersionConflict, err)
+}
+
+func testRuleUpdateNotFound(t *testing.T, ruleDao dao.RuleDao) {
+
+	err := ruleDao.UpdateRule(context.Background(), newRuleRequest(uuid.New().String()), newHomeId(), uuid.New().String(), 0)
+	assertErrorCode(t, hDError.ErrRuleNotFound, err)
+}
+
+func testRuleDelete(t *testing.T, ruleDao dao.RuleDao) {
+
+	ctx := context.Background()
+	saved := saveRuleForTesting(t, ruleDao, newHomeId(), uuid.New().String())
+
+	assert.Nil(t, ruleDao.DeleteRule(ctx, saved.HomeID, saved.ID, saved.Version))
+
+	_, err := ruleDao.GetRule(ctx, saved.HomeID, saved.ID)
+	assertErrorCode(t, hDError.ErrRuleNotFound, err)
+
+	rules, err := ruleDao.ListTriggeredRules(ctx, saved.Trigger.DeviceID)
+	assert.Nil(t, err)
+	assert.Empty(t, rules)
+}
+
+func testRuleDeleteVersionConflict(t *testing.T, ruleDao dao.RuleDao) {
+
+	ctx := context.Background()
+	saved := saveRuleForTesting(t, ruleDao, newHomeId(), uuid.New().String())
+
+	err := ruleDao.DeleteRule(ctx, saved.HomeID, saved.ID, saved.Version+1)
+	assertErrorCode(t, hDError.ErrVersionConflict, err)
+
+	_, err = ruleDao.GetRule(ctx, saved.HomeID, saved.ID)
+	assert.Nil(t, err)
+}
+
+func testRuleDeleteNotFound(t *testing.T, ruleDao dao.RuleDao) {
+
+	err := ruleDao.DeleteRule(context.Background(), newHomeId(), uuid.New().String(), 0)
+	assertErrorCode(t, hDError.ErrRuleNotFound, err)
+}
+
+func testRuleClaimFiring(t *testing.T, ruleDao dao.RuleDao) {
+
+	ctx := context.Background()
+	saved := saveRuleForTesting(t, ruleDao, newHomeId(), uuid.New().String())
+	firedAt := time.Now().Unix()
+
+	assert.Nil(t, ruleDao.ClaimRuleFiring(ctx, saved.HomeID, saved.ID, firedAt*1000, firedAt, saved.CooldownSeconds))
+
+	err := ruleDao.ClaimRuleFiring(ctx, saved.HomeID, saved.ID, (firedAt+saved.CooldownSeconds-1)*1000, firedAt+saved.CooldownSeconds-1, saved.CooldownSeconds)
+	assertErrorCode(t, hDError.ErrRuleInCooldown, err)
+
+	rule, err := ruleDao.GetRule(ctx, saved.HomeID, saved.ID)
+	if err != nil {
+		t.Fatalf("expected the rule but got an error %v", err)
+	}
+
+	assert.Equal(t, firedAt, rule.LastFiredAt)
+	assert.Equal(t, saved.Version, rule.Version)
+}
+
+func testRuleClaimFiringAfterCooldown(t *testing.T, ruleDao dao.RuleDao) {
+
+	ctx := context.Background()
+	saved := saveRuleForTesting(t, ruleDao, newHomeId(), uuid.New().String())
+	firedAt := time.Now().Unix()
+
+	assert.Nil(t, ruleDao.ClaimRuleFiring(ctx, saved.HomeID, saved.ID, firedAt*1000, firedAt, saved.CooldownSeconds))
+	assert.Nil(t, ruleDao.ClaimRuleFiring(ctx, saved.HomeID, saved.ID, (firedAt+saved.CooldownSeconds)*1000, firedAt+saved.CooldownSeconds, saved.CooldownSeconds))
+
+	rule, err := ruleDao.GetRule(ctx, saved.HomeID, saved.ID)
+	if err != nil {
+		t.Fatalf("expected the rule but got an error %v", err)
+	}
+
+	assert.Equal(t, firedAt+saved.CooldownSeconds, rule.LastFiredAt)
+}
+
+func testRuleClaimFiringSameReading(t *testing.T, ruleDao dao.RuleDao) {
+
+	ctx := context.Background()
+	saved := saveRuleForTesting(t, ruleDao, newHomeId(), uuid.New().String())
+	firedAt := time.Now().Unix()
+	readingAt := firedAt * 1000
+
+	assert.Nil(t, ruleDao.ClaimRuleFiring(ctx, saved.HomeID, saved.ID, readingAt, firedAt, saved.CooldownSeconds))
+
+	// the cooldown is over, but the reading is the same or older
+	err := ruleDao.ClaimRuleFiring(ctx, saved.HomeID, saved.ID, readingAt, firedAt+saved.CooldownSeconds, saved.CooldownSeconds)
+	assertErrorCode(t, hDError.ErrRuleAlreadyFired, err)
+	err = ruleDao.ClaimRuleFiring(ctx, saved.HomeID, saved.ID, readingAt-1, firedAt+saved.CooldownSeconds, saved.CooldownSeconds)
+	assertErrorCode(t, hDError.ErrRuleAlreadyFired, err)
+
+	rule, err := ruleDao.GetRule(ctx, saved.HomeID, saved.ID)
+	if err != nil {
+		t.Fatalf("expected the rule but got an error %v", err)
+	}
+
+	assert.Equal(t, firedAt, rule.LastFiredAt)
+}
+
+func testRuleClaimFiringNotFound(t *testing.T, ruleDao dao.RuleDao) {
+
+	err := ruleDao.ClaimRuleFiring(context.Background(), newHomeId(), uuid.New().String(), time.Now().UnixMilli(), time.Now().Unix(), 60)
+	assertErrorCode(t, hDError.ErrRuleNotFound, err)
+}
+
+func newRuleRequest(triggerDeviceId string) hDRequest.RuleRequest {
+
+	value := float64(1)
+
+	return hDRequest.RuleRequest{
+		Name: "Hallway light at night",
+		Trigger: hDRequest.RuleTrigger{
+			DeviceID: triggerDeviceId,
+			Metric:   "motion",
+			Operator: "eq",
+			Value:    &value,
+		},
+		TimeWindow: &hDRequest.RuleTimeWindow{From: "22:00", To: "06:00", Timezone: "Europe/Madrid"},
+		Action: hDRequest.RuleAction{
+			DeviceID: "light-1",
+			Command:  "turnOn",
+			Params:   map[string]interface{}{},
+		},
+		CooldownSeconds: 120,
+	}
+}
+
+func saveRuleForTesting(t *testing.T, ruleDao dao.RuleDao, homeId string, triggerDeviceId string) *hDResponse.RuleResponse {
+	t.Helper()
+
+	rule, err := ruleDao.SaveRule(context.Background(), homeId, newRuleRequest(triggerDeviceId))
+	if err != nil {
+		t.Fatalf("expected a saved rule but got an error %v", err)
+	}
+
+	return rule
+}
@@ -0,0 +1,131 @@
+package daotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	hDConstants "github.com/odhoman/home-devices/internal/constants"
+	"github.com/odhoman/home-devices/internal/dao"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// RunRuleExecutionDaoConformanceSuite checks that a RuleExecutionDao
+// implementation follows the behaviour expected by the services.
+func RunRuleExecutionDaoConformanceSuite(t *testing.T, newRuleExecutionDao func() dao.RuleExecutionDao) {
+
+	tests := map[string]func(t *testing.T, ruleExecutionDao dao.RuleExecutionDao){
+		"SaveAndList":     testRuleExecutionSaveAndList,
+		"ListNewestFirst": testRuleExecutionListNewestFirst,
+		"ListLimit":       testRuleExecutionListLimit,
+		"ListEmpty":       testRuleExecutionListEmpty,
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test(t, newRuleExecutionDao())
+		})
+	}
+}
+
+func testRuleExecutionSaveAndList(t *testing.T, ruleExecutionDao dao.RuleExecutionDao) {
+
+	ruleId := uuid.New().String()
+	execution := hDResponse.RuleExecutionResponse{
+		RuleID:     ruleId,
+		DeviceID:   "sensor-1",
+		Metric:     "temperature",
+		Value:      21.5,
+		Status:     hDConstants.RuleExecutionStatusFired,
+		CommandID:  uuid.New().String(),
+		ExecutedAt: time.Now().Unix(),
+	}
+
+	saved, err := ruleExecutionDao.SaveRuleExecution(context.Background(), execution)
+	if err != nil {
+		t.Fatalf("expected a saved execution but got an error %v", err)
+	}
+
+	assert.NotEmpty(t, saved.ID)
+	execution.ID = saved.ID
+	assert.Equal(t, execution, *saved)
+
+	executions, err := ruleExecutionDao.ListRuleExecutions(context.Background(), ruleId, 0)
+	if err != nil {
+		t.Fatalf("expected the executions but got an error %v", err)
+	}
+
+	assert.Equal(t, []hDResponse.RuleExecutionResponse{execution}, executions.Executions)
+}
+
+func testRuleExecutionListNewestFirst(t *testing.T, ruleExecutionDao dao.RuleExecutionDao) {
+
+	ruleId := uuid.New().String()
+	first := saveRuleExecutionForTesting(t, ruleExecutionDao, ruleId, hDConstants.RuleExecutionStatusFired)
+	second := saveRuleExecutionForTesting(t, ruleExecutionDao, ruleId, hDConstants.RuleExecutionStatusFailed)
+	saveRuleExecutionForTesting(t, ruleExecutionDao, uuid.New().String(), hDConstants.RuleExecutionStatusFired)
+
+	executions, err := ruleExecutionDao.ListRuleExecutions(context.Background(), ruleId, 0)
+	if err != nil {
+		t.Fatalf("expected the executions but got an error %v", err)
+	}
+
+	if assert.Len(t, executions.Executions, 2) {
+		assert.Equal(t, second.ID, executions.Executions[0].ID)
+		assert.Equal(t, "boom", executions.Executions[0].Error)
+		assert.Equal(t, first.ID, executions.Executions[1].ID)
+	}
+}
+
+func testRuleExecutionListLimit(t *testing.T, ruleExecutionDao dao.RuleExecutionDao) {
+
+	ruleId := uuid.New().String()
+	for i := 0; i < 3; i++ {
+		saveRuleExecutionForTesting(t, ruleExecutionDao, ruleId, hDConstants.RuleExecutionStatusFired)
+	}
+
+	executions, err := ruleExecutionDao.ListRuleExecutions(context.Background(), ruleId, 2)
+	if err != nil {
+		t.Fatalf("expected the executions but got an error %v", err)
+	}
+
+	assert.Len(t, executions.Executions, 2)
+}
+
+func testRuleExecutionListEmpty(t *testing.T, ruleExecutionDao dao.RuleExecutionDao) {
+
+	executions, err := ruleExecutionDao.ListRuleExecutions(context.Background(), uuid.New().String(), 0)
+	if err != nil {
+		t.Fatalf("expected no executions but got an error %v", err)
+	}
+
+	assert.NotNil(t, executions.Executions)
+	assert.Empty(t, executions.Executions)
+}
+
+func saveRuleExecutionForTesting(t *testing.T, ruleExecutionDao dao.RuleExecutionDao, ruleId string, status string) *hDResponse.RuleExecutionResponse {
+	t.Helper()
+
+	execution := hDResponse.RuleExecutionResponse{
+		RuleID:     ruleId,
+		DeviceID:   "sensor-1",
+		Metric:     "motion",
+		Value:      1,
+		Status:     status,
+		ExecutedAt: time.Now().Unix(),
+	}
+
+	if status == hDConstants.RuleExecutionStatusFailed {
+		execution.Error = "boom"
+	}
+
+	saved, err := ruleExecutionDao.SaveRuleExecution(context.Background(), execution)
+	if err != nil {
+		t.Fatalf("expected a saved execution but got an error %v", err)
+	}
+
+	return saved
+}
@@ -0,0 +1,208 @@
+package daotest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// RunSceneDaoConformanceSuite checks that a SceneDao implementation follows the
+// behaviour expected by the services.
+func RunSceneDaoConformanceSuite(t *testing.T, newSceneDao func() dao.SceneDao) {
+
+	tests := map[string]func(t *testing.T, sceneDao dao.SceneDao){
+		"SaveAndGet":            testSceneSaveAndGet,
+		"GetFromAnotherHome":    testSceneGetFromAnotherHome,
+		"GetNotFound":           testSceneGetNotFound,
+		"List":                  testSceneList,
+		"ListEmpty":             testSceneListEmpty,
+		"Update":                testSceneUpdate,
+		"UpdateVersionConflict": testSceneUpdateVersionConflict,
+		"UpdateNotFound":        testSceneUpdateNotFound,
+		"Delete":                testSceneDelete,
+		"DeleteVersionConflict": testSceneDeleteVersionConflict,
+		"DeleteNotFound":        testSceneDeleteNotFound,
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test(t, newSceneDao())
+		})
+	}
+}
+
+func testSceneSaveAndGet(t *testing.T, sceneDao dao.SceneDao) {
+
+	ctx := context.Background()
+	homeId := newHomeId()
+
+	saved, err := sceneDao.SaveScene(ctx, homeId, newSceneRequest())
+	if err != nil {
+		t.Fatalf("expected a saved scene but got an error %v", err)
+	}
+
+	assert.NotEmpty(t, saved.ID)
+	assert.Equal(t, homeId, saved.HomeID)
+	assert.Equal(t, "Movie night", saved.Name)
+	assert.Equal(t, []hDResponse.SceneDeviceResponse{
+		{DeviceID: "light-1", State: map[string]interface{}{"on": true, "brightness": float64(20)}},
+		{DeviceID: "blind-1", State: map[string]interface{}{"position": float64(0)}},
+	}, saved.Devices)
+	assert.Equal(t, int64(1), saved.Version)
+	assert.NotZero(t, saved.CreatedAt)
+
+	scene, err := sceneDao.GetScene(ctx, homeId, saved.ID)
+	if err != nil {
+		t.Fatalf("expected the scene but got an error %v", err)
+	}
+
+	assert.Equal(t, saved, scene)
+}
+
+func testSceneGetFromAnotherHome(t *testing.T, sceneDao dao.SceneDao) {
+
+	saved := saveSceneForTesting(t, sceneDao, newHomeId())
+
+	_, err := sceneDao.GetScene(context.Background(), newHomeId(), saved.ID)
+	assertErrorCode(t, hDError.ErrSceneNotFound, err)
+}
+
+func testSceneGetNotFound(t *testing.T, sceneDao dao.SceneDao) {
+
+	_, err := sceneDao.GetScene(context.Background(), newHomeId(), uuid.New().String())
+	assertErrorCode(t, hDError.ErrSceneNotFound, err)
+}
+
+func testSceneList(t *testing.T, sceneDao dao.SceneDao) {
+
+	homeId := newHomeId()
+
+	ids := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		ids[saveSceneForTesting(t, sceneDao, homeId).ID] = true
+	}
+	saveSceneForTesting(t, sceneDao, newHomeId())
+
+	scenes, err := sceneDao.ListScenes(context.Background(), homeId)
+	if err != nil {
+		t.Fatalf("expected the scenes but got an error %v", err)
+	}
+
+	listed := map[string]bool{}
+	for _, scene := range scenes.Scenes {
+		assert.Equal(t, homeId, scene.HomeID)
+		assert.Len(t, scene.Devices, 2)
+		listed[scene.ID] = true
+	}
+
+	assert.Equal(t, ids, listed)
+}
+
+func testSceneListEmpty(t *testing.T, sceneDao dao.SceneDao) {
+
+	scenes, err := sceneDao.ListScenes(context.Background(), newHomeId())
+	if err != nil {
+		t.Fatalf("expected no scenes but got an error %v", err)
+	}
+
+	assert.NotNil(t, scenes.Scenes)
+	assert.Empty(t, scenes.Scenes)
+}
+
+func testSceneUpdate(t *testing.T, sceneDao dao.SceneDao) {
+
+	ctx := context.Background()
+	saved := saveSceneForTesting(t, sceneDao, newHomeId())
+
+	request := hDRequest.SceneRequest{
+		Name: "Good night",
+		Devices: []hDRequest.SceneDevice{
+			{DeviceID: "light-1", State: map[string]interface{}{"on": false}},
+		},
+	}
+
+	assert.Nil(t, sceneDao.UpdateScene(ctx, request, saved.HomeID, saved.ID, saved.Version))
+
+	scene, err := sceneDao.GetScene(ctx, saved.HomeID, saved.ID)
+	if err != nil {
+		t.Fatalf("expected the scene but got an error %v", err)
+	}
+
+	assert.Equal(t, "Good night", scene.Name)
+	assert.Equal(t, []hDResponse.SceneDeviceResponse{
+		{DeviceID: "light-1", State: map[string]interface{}{"on": false}},
+	}, scene.Devices)
+	assert.Equal(t, saved.Version+1, scene.Version)
+	assert.Equal(t, saved.CreatedAt, scene.CreatedAt)
+}
+
+func testSceneUpdateVersionConflict(t *testing.T, sceneDao dao.SceneDao) {
+
+	saved := saveSceneForTesting(t, sceneDao, newHomeId())
+
+	err := sceneDao.UpdateScene(context.Background(), newSceneRequest(), saved.HomeID, saved.ID, saved.Version+1)
+	assertErrorCode(t, hDError.ErrVersionConflict, err)
+}
+
+func testSceneUpdateNotFound(t *testing.T, sceneDao dao.SceneDao) {
+
+	err := sceneDao.UpdateScene(context.Background(), newSceneRequest(), newHomeId(), uuid.New().String(), 0)
+	assertErrorCode(t, hDError.ErrSceneNotFound, err)
+}
+
+func testSceneDelete(t *testing.T, sceneDao dao.SceneDao) {
+
+	ctx := context.Background()
+	saved := saveSceneForTesting(t, sceneDao, newHomeId())
+
+	assert.Nil(t, sceneDao.DeleteScene(ctx, saved.HomeID, saved.ID, saved.Version))
+
+	_, err := sceneDao.GetScene(ctx, saved.HomeID, saved.ID)
+	assertErrorCode(t, hDError.ErrSceneNotFound, err)
+}
+
+func testSceneDeleteVersionConflict(t *testing.T, sceneDao dao.SceneDao) {
+
+	ctx := context.Background()
+	saved := saveSceneForTesting(t, sceneDao, newHomeId())
+
+	err := sceneDao.DeleteScene(ctx, saved.HomeID, saved.ID, saved.Version+1)
+	assertErrorCode(t, hDError.ErrVersionConflict, err)
+
+	_, err = sceneDao.GetScene(ctx, saved.HomeID, saved.ID)
+	assert.Nil(t, err)
+}
+
+func testSceneDeleteNotFound(t *testing.T, sceneDao dao.SceneDao) {
+
+	err := sceneDao.DeleteScene(context.Background(), newHomeId(), uuid.New().String(), 0)
+	assertErrorCode(t, hDError.ErrSceneNotFound, err)
+}
+
+func newSceneRequest() hDRequest.SceneRequest {
+	return hDRequest.SceneRequest{
+		Name: "Movie night",
+		Devices: []hDRequest.SceneDevice{
+			{DeviceID: "light-1", State: map[string]interface{}{"on": true, "brightness": float64(20)}},
+			{DeviceID: "blind-1", State: map[string]interface{}{"position": float64(0)}},
+		},
+	}
+}
+
+func saveSceneForTesting(t *testing.T, sceneDao dao.SceneDao, homeId string) *hDResponse.SceneResponse {
+	t.Helper()
+
+	scene, err := sceneDao.SaveScene(context.Background(), homeId, newSceneRequest())
+	if err != nil {
+		t.Fatalf("expected a saved scene but got an error %v", err)
+	}
+
+	return scene
+}
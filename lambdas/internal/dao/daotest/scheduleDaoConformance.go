@@ -0,0 +1,354 @@
+package daotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/odhoman/home-devices/internal/dao"
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// RunScheduleDaoConformanceSuite checks that a ScheduleDao implementation
+// follows the behaviour expected by the services.
+func RunScheduleDaoConformanceSuite(t *testing.T, newScheduleDao func() dao.ScheduleDao) {
+
+	tests := map[string]func(t *testing.T, scheduleDao dao.ScheduleDao){
+		"SaveAndGet":            testScheduleSaveAndGet,
+		"GetFromAnotherHome":    testScheduleGetFromAnotherHome,
+		"GetNotFound":           testScheduleGetNotFound,
+		"List":                  testScheduleList,
+		"ListEmpty":             testScheduleListEmpty,
+		"ListDue":               testScheduleListDue,
+		"Update":                testScheduleUpdate,
+		"UpdateStopsRunning":    testScheduleUpdateStopsRunning,
+		"UpdateVersionConflict": testScheduleUpdateVersionConflict,
+		"UpdateNotFound":        testScheduleUpdateNotFound,
+		"Delete":                testScheduleDelete,
+		"DeleteVersionConflict": testScheduleDeleteVersionConflict,
+		"DeleteNotFound":        testScheduleDeleteNotFound,
+		"ClaimRun":              testScheduleClaimRun,
+		"ClaimLastRun":          testScheduleClaimLastRun,
+		"ClaimRunOfStoppedOne":  testScheduleClaimRunOfStoppedOne,
+		"ClaimRunNotFound":      testScheduleClaimRunNotFound,
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test(t, newScheduleDao())
+		})
+	}
+}
+
+func testScheduleSaveAndGet(t *testing.T, scheduleDao dao.ScheduleDao) {
+
+	ctx := context.Background()
+	schedule := newSchedule(newHomeId(), time.Now().Unix()+3600)
+	schedule.Params = map[string]interface{}{"brightness": float64(40)}
+
+	saved, err := scheduleDao.SaveSchedule(ctx, schedule)
+	if err != nil {
+		t.Fatalf("expected a saved schedule but got an error %v", err)
+	}
+
+	assert.Equal(t, schedule, *saved)
+
+	got, err := scheduleDao.GetSchedule(ctx, schedule.HomeID, schedule.ID)
+	if err != nil {
+		t.Fatalf("expected the schedule but got an error %v", err)
+	}
+
+	assert.Equal(t, saved, got)
+}
+
+func testScheduleGetFromAnotherHome(t *testing.T, scheduleDao dao.ScheduleDao) {
+
+	saved := saveScheduleForTesting(t, scheduleDao, newSchedule(newHomeId(), time.Now().Unix()+3600))
+
+	_, err := scheduleDao.GetSchedule(context.Background(), newHomeId(), saved.ID)
+	assertErrorCode(t, hDError.ErrScheduleNotFound, err)
+}
+
+func testScheduleGetNotFound(t *testing.T, scheduleDao dao.ScheduleDao) {
+
+	_, err := scheduleDao.GetSchedule(context.Background(), newHomeId(), uuid.New().String())
+	assertErrorCode(t, hDError.ErrScheduleNotFound, err)
+}
+
+func testScheduleList(t *testing.T, scheduleDao dao.ScheduleDao) {
+
+	homeId := newHomeId()
+
+	ids := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		ids[saveScheduleForTesting(t, scheduleDao, newSchedule(homeId, time.Now().Unix()+3600)).ID] = true
+	}
+	saveScheduleForTesting(t, scheduleDao, newSchedule(newHomeId(), time.Now().Unix()+3600))
+
+	schedules, err := scheduleDao.ListSchedules(context.Background(), homeId)
+	if err != nil {
+		t.Fatalf("expected the schedules but got an error %v", err)
+	}
+
+	listed := map[string]bool{}
+	for _, schedule := range schedules.Schedules {
+		assert.Equal(t, homeId, schedule.HomeID)
+		listed[schedule.ID] = true
+	}
+
+	assert.Equal(t, ids, listed)
+}
+
+func testScheduleListEmpty(t *testing.T, scheduleDao dao.ScheduleDao) {
+
+	schedules, err := scheduleDao.ListSchedules(context.Background(), newHomeId())
+	if err != nil {
+		t.Fatalf("expected no schedules but got an error %v", err)
+	}
+
+	assert.NotNil(t, schedules.Schedules)
+	assert.Empty(t, schedules.Schedules)
+}
+
+func testScheduleListDue(t *testing.T, scheduleDao dao.ScheduleDao) {
+
+	homeId := newHomeId()
+	now := time.Now().Unix()
+
+	late := saveScheduleForTesting(t, scheduleDao, newSchedule(homeId, now-120))
+	due := saveScheduleForTesting(t, scheduleDao, newSchedule(homeId, now))
+	saveScheduleForTesting(t, scheduleDao, newSchedule(homeId, now+60))
+	saveScheduleForTesting(t, scheduleDao, newSchedule(homeId, 0))
+
+	schedules, err := scheduleDao.ListDueSchedules(context.Background(), now)
+	if err != nil {
+		t.Fatalf("expected the due schedules but got an error %v", err)
+	}
+
+	assert.Equal(t, []string{late.ID, due.ID}, dueScheduleIds(schedules, homeId))
+}
+
+func testScheduleUpdate(t *testing.T, scheduleDao dao.ScheduleDao) {
+
+	ctx := context.Background()
+	now := time.Now().Unix()
+	saved := saveScheduleForTesting(t, scheduleDao, newSchedule(newHomeId(), now))
+	assert.Nil(t, scheduleDao.ClaimScheduleRun(ctx, saved.HomeID, saved.ID, now, now+86400))
+
+	schedule := *saved
+	schedule.Name = "Plugs off at night"
+	schedule.Enabled = true
+	schedule.Cron = ""
+	schedule.RRule = "FREQ=DAILY;BYHOUR=23;BYMINUTE=30"
+	schedule.Timezone = "UTC"
+	schedule.Target = hDResponse.ScheduleTargetResponse{DeviceType: "light", RoomID: uuid.New().String()}
+	schedule.Command = "turnOff"
+	schedule.NextRunAt = now + 3600
+	schedule.ModifiedAt = now + 1
+
+	assert.Nil(t, scheduleDao.UpdateSchedule(ctx, schedule, saved.Version))
+
+	updated, err := scheduleDao.GetSchedule(ctx, saved.HomeID, saved.ID)
+	if err != nil {
+		t.Fatalf("expected the schedule but got an error %v", err)
+	}
+
+	assert.Equal(t, "Plugs off at night", updated.Name)
+	assert.Empty(t, updated.Cron)
+	assert.Equal(t, "FREQ=DAILY;BYHOUR=23;BYMINUTE=30", updated.RRule)
+	assert.Equal(t, "UTC", updated.Timezone)
+	assert.Equal(t, schedule.Target, updated.Target)
+	assert.Equal(t, "turnOff", updated.Command)
+	assert.Equal(t, now+3600, updated.NextRunAt)
+	assert.Equal(t, now, updated.LastRunAt)
+	assert.Equal(t, saved.CreatedAt, updated.CreatedAt)
+	assert.Equal(t, now+1, updated.ModifiedAt)
+	assert.Equal(t, saved.Version+1, updated.Version)
+}
+
+func testScheduleUpdateStopsRunning(t *testing.T, scheduleDao dao.ScheduleDao) {
+
+	ctx := context.Background()
+	now := time.Now().Unix()
+	saved := saveScheduleForTesting(t, scheduleDao, newSchedule(newHomeId(), now))
+
+	schedule := *saved
+	schedule.Enabled = false
+	schedule.NextRunAt = 0
+
+	assert.Nil(t, scheduleDao.UpdateSchedule(ctx, schedule, 0))
+
+	updated, err := scheduleDao.GetSchedule(ctx, saved.HomeID, saved.ID)
+	if err != nil {
+		t.Fatalf("expected the schedule but got an error %v", err)
+	}
+
+	assert.False(t, updated.Enabled)
+	assert.Zero(t, updated.NextRunAt)
+
+	schedules, err := scheduleDao.ListDueSchedules(ctx, now)
+	assert.Nil(t, err)
+	assert.Empty(t, dueScheduleIds(schedules, saved.HomeID))
+}
+
+func testScheduleUpdateVersionConflict(t *testing.T, scheduleDao dao.ScheduleDao) {
+
+	saved := saveScheduleForTesting(t, scheduleDao, newSchedule(newHomeId(), time.Now().Unix()+3600))
+
+	err := scheduleDao.UpdateSchedule(context.Background(), *saved, saved.Version+1)
+	assertErrorCode(t, hDError.ErrVersionConflict, err)
+}
+
+func testScheduleUpdateNotFound(t *testing.T, scheduleDao dao.ScheduleDao) {
+
+	err := scheduleDao.UpdateSchedule(context.Background(), newSchedule(newHomeId(), time.Now().Unix()+3600), 0)
+	assertErrorCode(t, hDError.ErrScheduleNotFound, err)
+}
+
+func testScheduleDelete(t *testing.T, scheduleDao dao.ScheduleDao) {
+
+	ctx := context.Background()
+	now := time.Now().Unix()
+	saved := saveScheduleForTesting(t, scheduleDao, newSchedule(newHomeId(), now))
+
+	assert.Nil(t, scheduleDao.DeleteSchedule(ctx, saved.HomeID, saved.ID, saved.Version))
+
+	_, err := scheduleDao.GetSchedule(ctx, saved.HomeID, saved.ID)
+	assertErrorCode(t, hDError.ErrScheduleNotFound, err)
+
+	schedules, err := scheduleDao.ListDueSchedules(ctx, now)
+	assert.Nil(t, err)
+	assert.Empty(t, dueScheduleIds(schedules, saved.HomeID))
+}
+
+func testScheduleDeleteVersionConflict(t *testing.T, scheduleDao dao.ScheduleDao) {
+
+	ctx := context.Background()
+	saved := saveScheduleForTesting(t, scheduleDao, newSchedule(newHomeId(), time.Now().Unix()+3600))
+
+	err := scheduleDao.DeleteSchedule(ctx, saved.HomeID, saved.ID, saved.Version+1)
+	assertErrorCode(t, hDError.ErrVersionConflict, err)
+
+	_, err = scheduleDao.GetSchedule(ctx, saved.HomeID, saved.ID)
+	assert.Nil(t, err)
+}
+
+func testScheduleDeleteNotFound(t *testing.T, scheduleDao dao.ScheduleDao) {
+
+	err := scheduleDao.DeleteSchedule(context.Background(), newHomeId(), uuid.New().String(), 0)
+	assertErrorCode(t, hDError.ErrScheduleNotFound, err)
+}
+
+func testScheduleClaimRun(t *testing.T, scheduleDao dao.ScheduleDao) {
+
+	ctx := context.Background()
+	now := time.Now().Unix()
+	saved := saveScheduleForTesting(t, scheduleDao, newSchedule(newHomeId(), now))
+
+	assert.Nil(t, scheduleDao.ClaimScheduleRun(ctx, saved.HomeID, saved.ID, now, now+86400))
+
+	err := scheduleDao.ClaimScheduleRun(ctx, saved.HomeID, saved.ID, now, now+86400)
+	assertErrorCode(t, hDError.ErrScheduleAlreadyRun, err)
+
+	schedule, err := scheduleDao.GetSchedule(ctx, saved.HomeID, saved.ID)
+	if err != nil {
+		t.Fatalf("expected the schedule but got an error %v", err)
+	}
+
+	assert.Equal(t, now, schedule.LastRunAt)
+	assert.Equal(t, now+86400, schedule.NextRunAt)
+	assert.Equal(t, saved.Version, schedule.Version)
+
+	schedules, err := scheduleDao.ListDueSchedules(ctx, now)
+	assert.Nil(t, err)
+	assert.Empty(t, dueScheduleIds(schedules, saved.HomeID))
+}
+
+func testScheduleClaimLastRun(t *testing.T, scheduleDao dao.ScheduleDao) {
+
+	ctx := context.Background()
+	now := time.Now().Unix()
+	saved := saveScheduleForTesting(t, scheduleDao, newSchedule(newHomeId(), now))
+
+	assert.Nil(t, scheduleDao.ClaimScheduleRun(ctx, saved.HomeID, saved.ID, now, 0))
+
+	schedule, err := scheduleDao.GetSchedule(ctx, saved.HomeID, saved.ID)
+	if err != nil {
+		t.Fatalf("expected the schedule but got an error %v", err)
+	}
+
+	assert.Equal(t, now, schedule.LastRunAt)
+	assert.Zero(t, schedule.NextRunAt)
+
+	schedules, err := scheduleDao.ListDueSchedules(ctx, now+86400)
+	assert.Nil(t, err)
+	assert.Empty(t, dueScheduleIds(schedules, saved.HomeID))
+}
+
+func testScheduleClaimRunOfStoppedOne(t *testing.T, scheduleDao dao.ScheduleDao) {
+
+	saved := saveScheduleForTesting(t, scheduleDao, newSchedule(newHomeId(), 0))
+
+	err := scheduleDao.ClaimScheduleRun(context.Background(), saved.HomeID, saved.ID, 0, 0)
+	assertErrorCode(t, hDError.ErrScheduleAlreadyRun, err)
+}
+
+func testScheduleClaimRunNotFound(t *testing.T, scheduleDao dao.ScheduleDao) {
+
+	now := time.Now().Unix()
+
+	err := scheduleDao.ClaimScheduleRun(context.Background(), newHomeId(), uuid.New().String(), now, now+60)
+	assertErrorCode(t, hDError.ErrScheduleNotFound, err)
+}
+
+// newSchedule returns a schedule of the home that runs next at nextRunAt, or
+// does not run when it is 0.
+func newSchedule(homeId string, nextRunAt int64) hDResponse.ScheduleResponse {
+
+	now := time.Now().Unix()
+
+	return hDResponse.ScheduleResponse{
+		ID:         uuid.New().String(),
+		HomeID:     homeId,
+		Name:       "Lights off at midnight",
+		Enabled:    nextRunAt > 0,
+		Cron:       "0 0 * * *",
+		Timezone:   "Europe/Madrid",
+		Target:     hDResponse.ScheduleTargetResponse{DeviceID: uuid.New().String()},
+		Command:    "turnOff",
+		Params:     map[string]interface{}{},
+		NextRunAt:  nextRunAt,
+		CreatedAt:  now,
+		ModifiedAt: now,
+		Version:    1,
+	}
+}
+
+func saveScheduleForTesting(t *testing.T, scheduleDao dao.ScheduleDao, schedule hDResponse.ScheduleResponse) *hDResponse.ScheduleResponse {
+	t.Helper()
+
+	saved, err := scheduleDao.SaveSchedule(context.Background(), schedule)
+	if err != nil {
+		t.Fatalf("expected a saved schedule but got an error %v", err)
+	}
+
+	return saved
+}
+
+// dueScheduleIds keeps the ids of the due schedules of the home, in order; the
+// table may have the schedules of other tests.
+func dueScheduleIds(schedules []hDResponse.ScheduleResponse, homeId string) []string {
+
+	ids := []string{}
+	for _, schedule := range schedules {
+		if schedule.HomeID == homeId {
+			ids = append(ids, schedule.ID)
+		}
+	}
+
+	return ids
+}
@@ -0,0 +1,39 @@
+package dao
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	constants "github.com/odhoman/home-devices/internal/constants"
+	utils "github.com/odhoman/home-devices/internal/utils"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func isDeletedItem(item map[string]types.AttributeValue) bool {
+	_, deleted := item["deletedAt"]
+	return deleted
+}
+
+// getDeletedDeviceRetention returns how long a deleted device can be restored.
+func getDeletedDeviceRetention() time.Duration {
+
+	days := constants.DefaultDeletedDeviceRetentionDays
+
+	if value, err := utils.GetValueProperty(constants.DeletedDeviceRetentionDaysProperty); err == nil {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			days = parsed
+		} else {
+			log.Printf("Invalid %v %v, using %v days", constants.DeletedDeviceRetentionDaysProperty, value, days)
+		}
+	}
+
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// isWithinRetention tells if a device deleted at deletedAt can still be
+// restored.
+func isWithinRetention(deletedAt int64, now time.Time) bool {
+	return now.Before(time.Unix(deletedAt, 0).Add(getDeletedDeviceRetention()))
+}
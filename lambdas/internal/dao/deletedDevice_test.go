@@ -0,0 +1,45 @@
+package dao
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	constants "github.com/odhoman/home-devices/internal/constants"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDeletedDeviceRetention_Default(t *testing.T) {
+
+	os.Setenv(constants.DeletedDeviceRetentionDaysProperty, "")
+
+	assert.Equal(t, time.Duration(constants.DefaultDeletedDeviceRetentionDays)*24*time.Hour, getDeletedDeviceRetention())
+}
+
+func TestGetDeletedDeviceRetention_FromProperty(t *testing.T) {
+
+	os.Setenv(constants.DeletedDeviceRetentionDaysProperty, "7")
+	defer os.Setenv(constants.DeletedDeviceRetentionDaysProperty, "")
+
+	assert.Equal(t, 7*24*time.Hour, getDeletedDeviceRetention())
+}
+
+func TestGetDeletedDeviceRetention_InvalidProperty(t *testing.T) {
+
+	os.Setenv(constants.DeletedDeviceRetentionDaysProperty, "seven")
+	defer os.Setenv(constants.DeletedDeviceRetentionDaysProperty, "")
+
+	assert.Equal(t, time.Duration(constants.DefaultDeletedDeviceRetentionDays)*24*time.Hour, getDeletedDeviceRetention())
+}
+
+func TestIsWithinRetention(t *testing.T) {
+
+	os.Setenv(constants.DeletedDeviceRetentionDaysProperty, "1")
+	defer os.Setenv(constants.DeletedDeviceRetentionDaysProperty, "")
+
+	now := time.Now()
+
+	assert.True(t, isWithinRetention(now.Add(-23*time.Hour).Unix(), now))
+	assert.False(t, isWithinRetention(now.Add(-25*time.Hour).Unix(), now))
+}
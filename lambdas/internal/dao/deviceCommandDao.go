@@ -0,0 +1,179 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	constants "github.com/odhoman/home-devices/internal/constants"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DeviceCommandDao keeps the commands sent to the devices.
+type DeviceCommandDao interface {
+	SaveDeviceCommand(ctx context.Context, command response.DeviceCommandResponse) (*response.DeviceCommandResponse, error)
+	GetDeviceCommand(ctx context.Context, deviceId string, id string) (*response.DeviceCommandResponse, error)
+	CompleteDeviceCommand(ctx context.Context, deviceId string, id string, update request.DeviceCommandStatusUpdate) (*response.DeviceCommandResponse, error)
+}
+
+type DeviceCommandDaoImpl struct {
+	DynamoDbApi dynamoDbApi
+}
+
+func (dCDI DeviceCommandDaoImpl) SaveDeviceCommand(ctx context.Context, command response.DeviceCommandResponse) (*response.DeviceCommandResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.DeviceCommandTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	params, err := json.Marshal(command.Params)
+	if err != nil {
+		log.Printf("Error serializing the params of the command %v of the device %v: %v", command.ID, command.DeviceID, err)
+		return nil, hdError.ErrDeviceCommandNotCreated.Wrap(err)
+	}
+
+	item := map[string]types.AttributeValue{
+		"deviceId":  &types.AttributeValueMemberS{Value: command.DeviceID},
+		"id":        &types.AttributeValueMemberS{Value: command.ID},
+		"name":      &types.AttributeValueMemberS{Value: command.Name},
+		"params":    &types.AttributeValueMemberS{Value: string(params)},
+		"status":    &types.AttributeValueMemberS{Value: command.Status},
+		"createdAt": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", command.CreatedAt)},
+		"expiresAt": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", command.ExpiresAt)},
+	}
+
+	if _, err := dCDI.DynamoDbApi.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           &tableName,
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(id)"),
+	}); err != nil {
+		log.Printf("Error putting the command %v of the device %v into DynamoDB: %v", command.ID, command.DeviceID, err)
+		return nil, hdError.ErrDeviceCommandNotCreated.Wrap(err)
+	}
+
+	return mapDynamoDBItemToDeviceCommand(item, hdError.ErrDeviceCommandNotCreated)
+}
+
+func (dCDI DeviceCommandDaoImpl) GetDeviceCommand(ctx context.Context, deviceId string, id string) (*response.DeviceCommandResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.DeviceCommandTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	result, err := dCDI.DynamoDbApi.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      &tableName,
+		Key:            buildDeviceCommandKey(deviceId, id),
+		ConsistentRead: aws.Bool(true),
+	})
+
+	if err != nil {
+		log.Printf("Error getting the command %v of the device %v from DynamoDB: %v", id, deviceId, err)
+		return nil, hdError.ErrGettingDeviceCommand.Wrap(err)
+	}
+
+	if result.Item == nil {
+		return nil, hdError.ErrDeviceCommandNotFound.New()
+	}
+
+	return mapDynamoDBItemToDeviceCommand(result.Item, hdError.ErrGettingDeviceCommand)
+}
+
+// CompleteDeviceCommand moves a pending command to its final status.
+func (dCDI DeviceCommandDaoImpl) CompleteDeviceCommand(ctx context.Context, deviceId string, id string, update request.DeviceCommandStatusUpdate) (*response.DeviceCommandResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.DeviceCommandTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	setExpression := "#status = :status, completedAt = :completedAt"
+	names := map[string]string{"#status": "status"}
+	values := map[string]types.AttributeValue{
+		":status":      &types.AttributeValueMemberS{Value: update.Status},
+		":completedAt": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", update.CompletedAt)},
+		":pending":     &types.AttributeValueMemberS{Value: constants.CommandStatusPending},
+	}
+
+	if update.Error != "" {
+		setExpression += ", #error = :error"
+		names["#error"] = "error"
+		values[":error"] = &types.AttributeValueMemberS{Value: update.Error}
+	}
+
+	result, err := dCDI.DynamoDbApi.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                           &tableName,
+		Key:                                 buildDeviceCommandKey(deviceId, id),
+		UpdateExpression:                    aws.String("SET " + setExpression),
+		ConditionExpression:                 aws.String("attribute_exists(id) AND #status = :pending"),
+		ExpressionAttributeNames:            names,
+		ExpressionAttributeValues:           values,
+		ReturnValues:                        types.ReturnValueAllNew,
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+
+	if err != nil {
+
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			log.Printf("The command %v of the device %v does not exist or is not pending", id, deviceId)
+			return nil, getDeviceCommandConditionalCheckFailedError(conditionErr.Item)
+		}
+
+		log.Printf("Error updating the command %v of the device %v into DynamoDB: %v", id, deviceId, err)
+		return nil, hdError.ErrUpdatingDeviceCommand.Wrap(err)
+	}
+
+	return mapDynamoDBItemToDeviceCommand(result.Attributes, hdError.ErrUpdatingDeviceCommand)
+}
+
+// getDeviceCommandConditionalCheckFailedError tells a missing command apart
+// from a command that is already completed.
+func getDeviceCommandConditionalCheckFailedError(item map[string]types.AttributeValue) error {
+
+	if item == nil {
+		return hdError.ErrDeviceCommandNotFound.New()
+	}
+
+	return hdError.ErrDeviceCommandCompleted.New()
+}
+
+func buildDeviceCommandKey(deviceId string, id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"deviceId": &types.AttributeValueMemberS{Value: deviceId},
+		"id":       &types.AttributeValueMemberS{Value: id},
+	}
+}
+
+func mapDynamoDBItemToDeviceCommand(item map[string]types.AttributeValue, errorDefinition hdError.Definition) (*response.DeviceCommandResponse, error) {
+
+	command := response.DeviceCommandResponse{
+		ID:          getStringAttribute(item, "id"),
+		DeviceID:    getStringAttribute(item, "deviceId"),
+		Name:        getStringAttribute(item, "name"),
+		Params:      map[string]interface{}{},
+		Status:      getStringAttribute(item, "status"),
+		Error:       getStringAttribute(item, "error"),
+		CreatedAt:   getInt64Attribute(item, "createdAt"),
+		ExpiresAt:   getInt64Attribute(item, "expiresAt"),
+		CompletedAt: getInt64Attribute(item, "completedAt"),
+	}
+
+	if params := getStringAttribute(item, "params"); params != "" && params != "null" {
+		if err := json.Unmarshal([]byte(params), &command.Params); err != nil {
+			log.Printf("Error deserializing the params of the command %v of the device %v: %v", command.ID, command.DeviceID, err)
+			return nil, errorDefinition.Wrap(err)
+		}
+	}
+
+	return &command, nil
+}
@@ -0,0 +1,15 @@
+package dao_test
+
+import (
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+	"github.com/odhoman/home-devices/internal/dao/daotest"
+	"github.com/odhoman/home-devices/internal/mock"
+)
+
+func TestDeviceCommandDaoImpl_Conformance(t *testing.T) {
+	daotest.RunDeviceCommandDaoConformanceSuite(t, func() dao.DeviceCommandDao {
+		return dao.DeviceCommandDaoImpl{DynamoDbApi: mock.GetDynamoConnectionTestFromEnpoint()}
+	})
+}
@@ -0,0 +1,121 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	constants "github.com/odhoman/home-devices/internal/constants"
+	hdError "github.com/odhoman/home-devices/internal/error"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+)
+
+// Counters kept for each home: the total of its active devices and one per
+// type, e.g. TYPE#light.
+const (
+	DeviceCounterTotal      = "TOTAL"
+	deviceCounterTypePrefix = "TYPE#"
+)
+
+// deviceCounterTokenNamespace turns the id of a change, which can be longer
+// than a token of DynamoDB, into a token of 36 characters.
+var deviceCounterTokenNamespace = uuid.MustParse("3f4b2c1e-6a57-4d0e-9b8f-2d1c7e5a9f30")
+
+// DeviceCountDelta changes the number of active devices of a type in a home.
+type DeviceCountDelta struct {
+	HomeID string
+	Type   string
+	Delta  int64
+}
+
+// DeviceCounterDao keeps how many active devices each home has, in total and of
+// each type.
+type DeviceCounterDao interface {
+	AddDeviceCounts(ctx context.Context, changeId string, deltas []DeviceCountDelta) error
+}
+
+type DeviceCounterDaoImpl struct {
+	DynamoDbApi dynamoDbApi
+}
+
+func (dCDI DeviceCounterDaoImpl) AddDeviceCounts(ctx context.Context, changeId string, deltas []DeviceCountDelta) error {
+
+	counters := buildDeviceCounters(deltas)
+	if len(counters) == 0 {
+		return nil
+	}
+
+	tableName, error := getValuePropertyOrError(constants.DeviceCounterTableNameProperty)
+	if error != nil {
+		return error
+	}
+
+	transactItems := []types.TransactWriteItem{}
+	for _, counter := range counters {
+		transactItems = append(transactItems, types.TransactWriteItem{
+			Update: &types.Update{
+				TableName: &tableName,
+				Key: map[string]types.AttributeValue{
+					"homeId":  &types.AttributeValueMemberS{Value: counter.homeId},
+					"counter": &types.AttributeValueMemberS{Value: counter.name},
+				},
+				UpdateExpression:         aws.String("ADD #count :delta"),
+				ExpressionAttributeNames: map[string]string{"#count": "count"},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":delta": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", counter.delta)},
+				},
+			},
+		})
+	}
+
+	if _, err := dCDI.DynamoDbApi.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems:      transactItems,
+		ClientRequestToken: aws.String(uuid.NewSHA1(deviceCounterTokenNamespace, []byte(changeId)).String()),
+	}); err != nil {
+		log.Printf("Error adding the device counts of the change %v into DynamoDB: %v", changeId, err)
+		return hdError.ErrUpdatingDeviceCounts.Wrap(err)
+	}
+
+	return nil
+}
+
+type deviceCounter struct {
+	homeId string
+	name   string
+	delta  int64
+}
+
+// buildDeviceCounters adds up the deltas of each counter and drops the ones
+// that end in 0, like the total of a home when a device changes its type.
+func buildDeviceCounters(deltas []DeviceCountDelta) []deviceCounter {
+
+	sums := map[[2]string]int64{}
+	for _, delta := range deltas {
+		if delta.HomeID == "" || delta.Delta == 0 {
+			continue
+		}
+		sums[[2]string{delta.HomeID, DeviceCounterTotal}] += delta.Delta
+		sums[[2]string{delta.HomeID, deviceCounterTypePrefix + delta.Type}] += delta.Delta
+	}
+
+	counters := []deviceCounter{}
+	for key, sum := range sums {
+		if sum != 0 {
+			counters = append(counters, deviceCounter{homeId: key[0], name: key[1], delta: sum})
+		}
+	}
+
+	sort.Slice(counters, func(i, j int) bool {
+		if counters[i].homeId != counters[j].homeId {
+			return counters[i].homeId < counters[j].homeId
+		}
+		return counters[i].name < counters[j].name
+	})
+
+	return counters
+}
@@ -0,0 +1,60 @@
+package dao
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildDeviceCounters(t *testing.T) {
+
+	// a light of home1 becomes a switch of home2 and a sensor leaves home1
+	counters := buildDeviceCounters([]DeviceCountDelta{
+		{HomeID: "home1", Type: "light", Delta: -1},
+		{HomeID: "home2", Type: "switch", Delta: 1},
+		{HomeID: "home1", Type: "sensor", Delta: -1},
+		{HomeID: "", Type: "light", Delta: 1},
+	})
+
+	assert.Equal(t, []deviceCounter{
+		{homeId: "home1", name: DeviceCounterTotal, delta: -2},
+		{homeId: "home1", name: "TYPE#light", delta: -1},
+		{homeId: "home1", name: "TYPE#sensor", delta: -1},
+		{homeId: "home2", name: DeviceCounterTotal, delta: 1},
+		{homeId: "home2", name: "TYPE#switch", delta: 1},
+	}, counters)
+}
+
+func TestBuildDeviceCounters_DropsTheCountersThatDoNotChange(t *testing.T) {
+
+	// the type of a device changes within its home
+	counters := buildDeviceCounters([]DeviceCountDelta{
+		{HomeID: "home1", Type: "light", Delta: -1},
+		{HomeID: "home1", Type: "switch", Delta: 1},
+	})
+
+	assert.Equal(t, []deviceCounter{
+		{homeId: "home1", name: "TYPE#light", delta: -1},
+		{homeId: "home1", name: "TYPE#switch", delta: 1},
+	}, counters)
+
+	assert.Empty(t, buildDeviceCounters([]DeviceCountDelta{
+		{HomeID: "home1", Type: "light", Delta: -1},
+		{HomeID: "home1", Type: "light", Delta: 1},
+	}))
+}
+
+func TestInMemoryDeviceCounterDao_IgnoresTheSameChange(t *testing.T) {
+
+	counterDao := NewInMemoryDeviceCounterDao()
+	ctx := context.Background()
+	deltas := []DeviceCountDelta{{HomeID: "home1", Type: "light", Delta: 1}}
+
+	assert.Nil(t, counterDao.AddDeviceCounts(ctx, "change1", deltas))
+	assert.Nil(t, counterDao.AddDeviceCounts(ctx, "change1", deltas))
+	assert.Nil(t, counterDao.AddDeviceCounts(ctx, "change2", deltas))
+
+	assert.Equal(t, map[string]int64{DeviceCounterTotal: 2, "light": 2}, counterDao.Counts("home1"))
+	assert.Empty(t, counterDao.Counts("home2"))
+}
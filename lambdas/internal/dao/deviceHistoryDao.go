@@ -0,0 +1,210 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	hdAudit "github.com/odhoman/home-devices/internal/audit"
+	constants "github.com/odhoman/home-devices/internal/constants"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/google/uuid"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DeviceHistoryDao keeps the change records of the devices.
+type DeviceHistoryDao interface {
+	SaveDeviceChange(ctx context.Context, change response.DeviceChangeResponse) (*response.DeviceChangeResponse, error)
+	ListDeviceHistory(ctx context.Context, deviceId string, limit int32, cursor string) (*response.DeviceHistoryResponse, error)
+}
+
+type DeviceHistoryDaoImpl struct {
+	DynamoDbApi dynamoDbApi
+}
+
+func (dHDI DeviceHistoryDaoImpl) SaveDeviceChange(ctx context.Context, change response.DeviceChangeResponse) (*response.DeviceChangeResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.DeviceHistoryTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	change.ChangeID = buildChangeId(time.Now())
+
+	if _, err := dHDI.DynamoDbApi.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           &tableName,
+		Item:                mapDeviceChangeToDynamoDBItem(change),
+		ConditionExpression: aws.String("attribute_not_exists(changeId)"),
+	}); err != nil {
+		log.Printf("Error saving the change %v of the device %v: %v", change.Operation, change.DeviceID, err)
+		return nil, hdError.ErrSavingDeviceChange.Wrap(err)
+	}
+
+	return &change, nil
+}
+
+func (dHDI DeviceHistoryDaoImpl) ListDeviceHistory(ctx context.Context, deviceId string, limit int32, cursor string) (*response.DeviceHistoryResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.DeviceHistoryTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	exclusiveStartKey, err := decodeCursor(cursor)
+	if err != nil || (exclusiveStartKey != nil && getStringAttribute(exclusiveStartKey, "deviceId") != deviceId) {
+		log.Printf("Invalid cursor %v for the history of the device %v: %v", cursor, deviceId, err)
+		return nil, hdError.ErrInvalidCursor.Wrap(err)
+	}
+
+	result, err := dHDI.DynamoDbApi.Query(ctx, &dynamodb.QueryInput{
+		TableName:              &tableName,
+		KeyConditionExpression: aws.String("deviceId = :deviceId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":deviceId": &types.AttributeValueMemberS{Value: deviceId},
+		},
+		// newest changes first
+		ScanIndexForward:  aws.Bool(false),
+		Limit:             aws.Int32(resolveListLimit(limit)),
+		ExclusiveStartKey: exclusiveStartKey,
+	})
+
+	if err != nil {
+		log.Printf("Error listing the history of the device %v from DynamoDB: %v", deviceId, err)
+		return nil, hdError.ErrListingDeviceHistory.Wrap(err)
+	}
+
+	nextCursor, err := encodeCursor(result.LastEvaluatedKey)
+	if err != nil {
+		log.Printf("Error building the cursor for the history of the device %v: %v", deviceId, err)
+		return nil, hdError.ErrListingDeviceHistory.Wrap(err)
+	}
+
+	changes := make([]response.DeviceChangeResponse, 0, len(result.Items))
+	for _, item := range result.Items {
+		changes = append(changes, mapDynamoDBItemToDeviceChange(item))
+	}
+
+	return &response.DeviceHistoryResponse{
+		Changes:    changes,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// newDeviceChange builds the change record of a write of the device, made by
+// the actor and from the source of the context.
+func newDeviceChange(ctx context.Context, operation string, id string, before *response.HomdeDeviceResponse, after *response.HomdeDeviceResponse) response.DeviceChangeResponse {
+
+	now := time.Now()
+	change := response.DeviceChangeResponse{
+		DeviceID:  id,
+		ChangeID:  buildChangeId(now),
+		Operation: operation,
+		Actor:     hdAudit.ActorFromContext(ctx),
+		Source:    hdAudit.SourceFromContext(ctx),
+		ChangedAt: now.Unix(),
+		Changes:   hdAudit.Diff(before, after),
+	}
+
+	if after != nil {
+		change.Version = after.Version
+	} else if before != nil {
+		change.Version = before.Version + 1
+	}
+
+	return change
+}
+
+// newStatusChange builds the change record of a device that went online or
+// offline.
+func newStatusChange(ctx context.Context, id string, before string, after string) response.DeviceChangeResponse {
+
+	now := time.Now()
+	return response.DeviceChangeResponse{
+		DeviceID:  id,
+		ChangeID:  buildChangeId(now),
+		Operation: hdAudit.OperationStatusChange,
+		Actor:     hdAudit.ActorFromContext(ctx),
+		Source:    hdAudit.SourcePresence,
+		ChangedAt: now.Unix(),
+		Changes: []response.DeviceFieldChange{
+			{Field: "status", Before: before, After: after},
+		},
+	}
+}
+
+func buildPutDeviceChange(tableName string, change response.DeviceChangeResponse) types.TransactWriteItem {
+	return types.TransactWriteItem{
+		Put: &types.Put{
+			TableName:           &tableName,
+			Item:                mapDeviceChangeToDynamoDBItem(change),
+			ConditionExpression: aws.String("attribute_not_exists(changeId)"),
+		},
+	}
+}
+
+// buildChangeId returns an id that sorts the changes of a device by the time
+// they were recorded.
+func buildChangeId(now time.Time) string {
+	return fmt.Sprintf("%019d#%s", now.UnixNano(), uuid.New().String())
+}
+
+func mapDeviceChangeToDynamoDBItem(change response.DeviceChangeResponse) map[string]types.AttributeValue {
+
+	changes := make([]types.AttributeValue, 0, len(change.Changes))
+	for _, fieldChange := range change.Changes {
+		value := map[string]types.AttributeValue{
+			"field": &types.AttributeValueMemberS{Value: fieldChange.Field},
+		}
+		if fieldChange.Before != "" {
+			value["before"] = &types.AttributeValueMemberS{Value: fieldChange.Before}
+		}
+		if fieldChange.After != "" {
+			value["after"] = &types.AttributeValueMemberS{Value: fieldChange.After}
+		}
+		changes = append(changes, &types.AttributeValueMemberM{Value: value})
+	}
+
+	return map[string]types.AttributeValue{
+		"deviceId":  &types.AttributeValueMemberS{Value: change.DeviceID},
+		"changeId":  &types.AttributeValueMemberS{Value: change.ChangeID},
+		"operation": &types.AttributeValueMemberS{Value: change.Operation},
+		"actor":     &types.AttributeValueMemberS{Value: change.Actor},
+		"source":    &types.AttributeValueMemberS{Value: change.Source},
+		"changedAt": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", change.ChangedAt)},
+		"version":   &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", change.Version)},
+		"changes":   &types.AttributeValueMemberL{Value: changes},
+	}
+}
+
+func mapDynamoDBItemToDeviceChange(item map[string]types.AttributeValue) response.DeviceChangeResponse {
+
+	changes := []response.DeviceFieldChange{}
+	if list, ok := item["changes"].(*types.AttributeValueMemberL); ok {
+		for _, value := range list.Value {
+			if fieldChange, ok := value.(*types.AttributeValueMemberM); ok {
+				changes = append(changes, response.DeviceFieldChange{
+					Field:  getStringAttribute(fieldChange.Value, "field"),
+					Before: getStringAttribute(fieldChange.Value, "before"),
+					After:  getStringAttribute(fieldChange.Value, "after"),
+				})
+			}
+		}
+	}
+
+	return response.DeviceChangeResponse{
+		DeviceID:  getStringAttribute(item, "deviceId"),
+		ChangeID:  getStringAttribute(item, "changeId"),
+		Operation: getStringAttribute(item, "operation"),
+		Actor:     getStringAttribute(item, "actor"),
+		Source:    getStringAttribute(item, "source"),
+		ChangedAt: getInt64Attribute(item, "changedAt"),
+		Version:   getInt64Attribute(item, "version"),
+		Changes:   changes,
+	}
+}
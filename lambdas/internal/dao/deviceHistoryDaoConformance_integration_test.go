@@ -0,0 +1,15 @@
+package dao_test
+
+import (
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+	"github.com/odhoman/home-devices/internal/dao/daotest"
+	"github.com/odhoman/home-devices/internal/mock"
+)
+
+func TestDeviceHistoryDaoImpl_Conformance(t *testing.T) {
+	daotest.RunDeviceHistoryDaoConformanceSuite(t, func() dao.DeviceHistoryDao {
+		return dao.DeviceHistoryDaoImpl{DynamoDbApi: mock.GetDynamoConnectionTestFromEnpoint()}
+	})
+}
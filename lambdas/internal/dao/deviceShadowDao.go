@@ -0,0 +1,182 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	constants "github.com/odhoman/home-devices/internal/constants"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+	shadow "github.com/odhoman/home-devices/internal/shadow"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DeviceShadowDao keeps the desired and reported state documents of the
+// devices.
+type DeviceShadowDao interface {
+	GetDeviceShadow(ctx context.Context, deviceId string) (*response.DeviceStateResponse, error)
+	UpdateDeviceShadow(ctx context.Context, deviceId string, update request.DeviceShadowUpdate) (*response.DeviceStateResponse, error)
+}
+
+type DeviceShadowDaoImpl struct {
+	DynamoDbApi dynamoDbApi
+}
+
+// GetDeviceShadow returns empty documents in version 0 when the shadow of the
+// device was never written.
+func (dSDI DeviceShadowDaoImpl) GetDeviceShadow(ctx context.Context, deviceId string) (*response.DeviceStateResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.DeviceShadowTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	result, err := dSDI.DynamoDbApi.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &tableName,
+		Key: map[string]types.AttributeValue{
+			"deviceId": &types.AttributeValueMemberS{Value: deviceId},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+
+	if err != nil {
+		log.Printf("Error getting the shadow of the device %v from DynamoDB: %v", deviceId, err)
+		return nil, hdError.ErrGettingDeviceState.Wrap(err)
+	}
+
+	return mapDynamoDBItemToDeviceShadow(deviceId, result.Item)
+}
+
+func (dSDI DeviceShadowDaoImpl) UpdateDeviceShadow(ctx context.Context, deviceId string, update request.DeviceShadowUpdate) (*response.DeviceStateResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.DeviceShadowTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	setExpressions := []string{}
+	conditions := []string{}
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{}
+	now := time.Now().Unix()
+
+	sections := []struct {
+		name     string
+		document map[string]interface{}
+		version  int64
+	}{
+		{shadow.SectionDesired, update.Desired, update.DesiredVersion},
+		{shadow.SectionReported, update.Reported, update.ReportedVersion},
+	}
+
+	for _, section := range sections {
+
+		if section.document == nil {
+			continue
+		}
+
+		document, err := json.Marshal(section.document)
+		if err != nil {
+			log.Printf("Error serializing the %v state of the device %v: %v", section.name, deviceId, err)
+			return nil, hdError.ErrUpdatingDeviceState.Wrap(err)
+		}
+
+		names["#"+section.name] = section.name
+		names["#"+section.name+"Version"] = section.name + "Version"
+		names["#"+section.name+"UpdatedAt"] = section.name + "UpdatedAt"
+
+		values[":"+section.name] = &types.AttributeValueMemberS{Value: string(document)}
+		values[":"+section.name+"NewVersion"] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", section.version+1)}
+		values[":"+section.name+"UpdatedAt"] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", now)}
+
+		setExpressions = append(setExpressions,
+			fmt.Sprintf("#%[1]s = :%[1]s", section.name),
+			fmt.Sprintf("#%[1]sVersion = :%[1]sNewVersion", section.name),
+			fmt.Sprintf("#%[1]sUpdatedAt = :%[1]sUpdatedAt", section.name),
+		)
+
+		if section.version == 0 {
+			conditions = append(conditions, fmt.Sprintf("attribute_not_exists(#%sVersion)", section.name))
+		} else {
+			values[":"+section.name+"Version"] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", section.version)}
+			conditions = append(conditions, fmt.Sprintf("#%[1]sVersion = :%[1]sVersion", section.name))
+		}
+	}
+
+	if len(setExpressions) == 0 {
+		return nil, hdError.ErrNoStateToUpdate.New()
+	}
+
+	result, err := dSDI.DynamoDbApi.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: &tableName,
+		Key: map[string]types.AttributeValue{
+			"deviceId": &types.AttributeValueMemberS{Value: deviceId},
+		},
+		UpdateExpression:          aws.String("SET " + strings.Join(setExpressions, ", ")),
+		ConditionExpression:       aws.String(strings.Join(conditions, " AND ")),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		ReturnValues:              types.ReturnValueAllNew,
+	})
+
+	if err != nil {
+
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			log.Printf("The shadow of the device %v was modified while updating it", deviceId)
+			return nil, hdError.ErrVersionConflict.WithMessage(hdError.DeviceStateVersionConflictMessage)
+		}
+
+		log.Printf("Error updating the shadow of the device %v into DynamoDB: %v", deviceId, err)
+		return nil, hdError.ErrUpdatingDeviceState.Wrap(err)
+	}
+
+	return mapDynamoDBItemToDeviceShadow(deviceId, result.Attributes)
+}
+
+func mapDynamoDBItemToDeviceShadow(deviceId string, item map[string]types.AttributeValue) (*response.DeviceStateResponse, error) {
+
+	desired, err := mapDynamoDBItemToStateDocument(item, shadow.SectionDesired)
+	if err != nil {
+		log.Printf("Error deserializing the desired state of the device %v: %v", deviceId, err)
+		return nil, hdError.ErrGettingDeviceState.Wrap(err)
+	}
+
+	reported, err := mapDynamoDBItemToStateDocument(item, shadow.SectionReported)
+	if err != nil {
+		log.Printf("Error deserializing the reported state of the device %v: %v", deviceId, err)
+		return nil, hdError.ErrGettingDeviceState.Wrap(err)
+	}
+
+	return &response.DeviceStateResponse{
+		DeviceID: deviceId,
+		Desired:  desired,
+		Reported: reported,
+	}, nil
+}
+
+func mapDynamoDBItemToStateDocument(item map[string]types.AttributeValue, section string) (response.DeviceStateDocumentResponse, error) {
+
+	document := response.DeviceStateDocumentResponse{
+		State:     map[string]interface{}{},
+		Version:   getInt64Attribute(item, section+"Version"),
+		UpdatedAt: getInt64Attribute(item, section+"UpdatedAt"),
+	}
+
+	if value := getStringAttribute(item, section); value != "" {
+		if err := json.Unmarshal([]byte(value), &document.State); err != nil {
+			return document, err
+		}
+	}
+
+	return document, nil
+}
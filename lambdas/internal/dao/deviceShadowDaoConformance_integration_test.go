@@ -0,0 +1,15 @@
+package dao_test
+
+import (
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+	"github.com/odhoman/home-devices/internal/dao/daotest"
+	"github.com/odhoman/home-devices/internal/mock"
+)
+
+func TestDeviceShadowDaoImpl_Conformance(t *testing.T) {
+	daotest.RunDeviceShadowDaoConformanceSuite(t, func() dao.DeviceShadowDao {
+		return dao.DeviceShadowDaoImpl{DynamoDbApi: mock.GetDynamoConnectionTestFromEnpoint()}
+	})
+}
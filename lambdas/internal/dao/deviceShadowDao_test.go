@@ -0,0 +1,55 @@
+package dao
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	constants "github.com/odhoman/home-devices/internal/constants"
+	request "github.com/odhoman/home-devices/internal/request"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// updateItemRecorder keeps the last UpdateItem input and answers with the
+// attributes it was asked to set.
+type updateItemRecorder struct {
+	dynamoDbApi
+	input *dynamodb.UpdateItemInput
+}
+
+func (uIR *updateItemRecorder) UpdateItem(ctx context.Context, input *dynamodb.UpdateItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	uIR.input = input
+	return &dynamodb.UpdateItemOutput{Attributes: map[string]types.AttributeValue{
+		"deviceId":        input.Key["deviceId"],
+		"reported":        input.ExpressionAttributeValues[":reported"],
+		"reportedVersion": input.ExpressionAttributeValues[":reportedNewVersion"],
+	}}, nil
+}
+
+func TestUpdateDeviceShadow_Expression(t *testing.T) {
+
+	os.Setenv(constants.DeviceShadowTableNameProperty, "shadowTable")
+	defer os.Unsetenv(constants.DeviceShadowTableNameProperty)
+
+	recorder := &updateItemRecorder{}
+	updated, err := DeviceShadowDaoImpl{DynamoDbApi: recorder}.UpdateDeviceShadow(context.Background(), "device123", request.DeviceShadowUpdate{
+		Desired:         map[string]interface{}{"power": "on"},
+		Reported:        map[string]interface{}{"temperature": 21.5},
+		ReportedVersion: 4,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "SET #desired = :desired, #desiredVersion = :desiredNewVersion, #desiredUpdatedAt = :desiredUpdatedAt, #reported = :reported, #reportedVersion = :reportedNewVersion, #reportedUpdatedAt = :reportedUpdatedAt", *recorder.input.UpdateExpression)
+	assert.Equal(t, "attribute_not_exists(#desiredVersion) AND #reportedVersion = :reportedVersion", *recorder.input.ConditionExpression)
+	assert.Equal(t, `{"power":"on"}`, recorder.input.ExpressionAttributeValues[":desired"].(*types.AttributeValueMemberS).Value)
+	assert.Equal(t, "1", recorder.input.ExpressionAttributeValues[":desiredNewVersion"].(*types.AttributeValueMemberN).Value)
+	assert.Equal(t, "5", recorder.input.ExpressionAttributeValues[":reportedNewVersion"].(*types.AttributeValueMemberN).Value)
+	assert.NotContains(t, recorder.input.ExpressionAttributeValues, ":desiredVersion")
+
+	assert.Equal(t, map[string]interface{}{"temperature": 21.5}, updated.Reported.State)
+	assert.Equal(t, int64(5), updated.Reported.Version)
+	assert.Equal(t, map[string]interface{}{}, updated.Desired.State)
+}
@@ -0,0 +1,91 @@
+package dao
+
+import (
+	"fmt"
+
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DecodeDeviceStreamImage decodes an image of a record of the stream of the
+// devices table with the same mapping as the devices read from the table, and
+// tells if the device was soft deleted.
+func DecodeDeviceStreamImage(image map[string]events.DynamoDBAttributeValue) (*response.HomdeDeviceResponse, bool, error) {
+
+	if len(image) == 0 {
+		return nil, false, nil
+	}
+
+	item, err := mapStreamImageToDynamoDBItem(image)
+	if err != nil {
+		return nil, false, err
+	}
+
+	id := getStringAttribute(item, "id")
+	if id == "" {
+		return nil, false, fmt.Errorf("the image has no id")
+	}
+
+	if isMacHomeGuardId(id) {
+		return nil, false, nil
+	}
+
+	device := mapDynamoDBItemToDeviceResponse(item)
+	return &device, isDeletedItem(item), nil
+}
+
+func mapStreamImageToDynamoDBItem(image map[string]events.DynamoDBAttributeValue) (map[string]types.AttributeValue, error) {
+
+	item := make(map[string]types.AttributeValue, len(image))
+	for key, value := range image {
+		attribute, err := mapStreamAttributeValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("the attribute %v is not valid: %w", key, err)
+		}
+		item[key] = attribute
+	}
+
+	return item, nil
+}
+
+func mapStreamAttributeValue(value events.DynamoDBAttributeValue) (types.AttributeValue, error) {
+
+	switch value.DataType() {
+	case events.DataTypeString:
+		return &types.AttributeValueMemberS{Value: value.String()}, nil
+	case events.DataTypeNumber:
+		return &types.AttributeValueMemberN{Value: value.Number()}, nil
+	case events.DataTypeBoolean:
+		return &types.AttributeValueMemberBOOL{Value: value.Boolean()}, nil
+	case events.DataTypeNull:
+		return &types.AttributeValueMemberNULL{Value: true}, nil
+	case events.DataTypeBinary:
+		return &types.AttributeValueMemberB{Value: value.Binary()}, nil
+	case events.DataTypeStringSet:
+		return &types.AttributeValueMemberSS{Value: value.StringSet()}, nil
+	case events.DataTypeNumberSet:
+		return &types.AttributeValueMemberNS{Value: value.NumberSet()}, nil
+	case events.DataTypeBinarySet:
+		return &types.AttributeValueMemberBS{Value: value.BinarySet()}, nil
+	case events.DataTypeList:
+		list := make([]types.AttributeValue, 0, len(value.List()))
+		for _, element := range value.List() {
+			attribute, err := mapStreamAttributeValue(element)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, attribute)
+		}
+		return &types.AttributeValueMemberL{Value: list}, nil
+	case events.DataTypeMap:
+		item, err := mapStreamImageToDynamoDBItem(value.Map())
+		if err != nil {
+			return nil, err
+		}
+		return &types.AttributeValueMemberM{Value: item}, nil
+	}
+
+	return nil, fmt.Errorf("unknown data type %v", value.DataType())
+}
@@ -0,0 +1,88 @@
+package dao
+
+import (
+	"testing"
+
+	constants "github.com/odhoman/home-devices/internal/constants"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeDeviceStreamImage(t *testing.T) {
+
+	device, deleted, err := DecodeDeviceStreamImage(map[string]events.DynamoDBAttributeValue{
+		"id":         events.NewStringAttribute("device1"),
+		"mac":        events.NewStringAttribute("00:11:22:33:44:55"),
+		"name":       events.NewStringAttribute("Living Room Light"),
+		"type":       events.NewStringAttribute("light"),
+		"homeId":     events.NewStringAttribute("home1"),
+		"createdAt":  events.NewNumberAttribute("1729000000"),
+		"modifiedAt": events.NewNumberAttribute("1729000100"),
+		"version":    events.NewNumberAttribute("2"),
+		"status":     events.NewStringAttribute("online"),
+		"lastSeenAt": events.NewNumberAttribute("1729000200"),
+		// attributes that are not part of the device are ignored
+		"tags": events.NewListAttribute([]events.DynamoDBAttributeValue{
+			events.NewMapAttribute(map[string]events.DynamoDBAttributeValue{"owner": events.NewNullAttribute()}),
+		}),
+	})
+
+	assert.Nil(t, err)
+	assert.False(t, deleted)
+	assert.Equal(t, &response.HomdeDeviceResponse{
+		ID:         "device1",
+		MAC:        "00:11:22:33:44:55",
+		Name:       "Living Room Light",
+		Type:       "light",
+		HomeID:     "home1",
+		CreatedAt:  1729000000,
+		ModifiedAt: 1729000100,
+		Version:    2,
+		Status:     constants.DeviceStatusOnline,
+		LastSeenAt: 1729000200,
+	}, device)
+}
+
+func TestDecodeDeviceStreamImage_SoftDeletedDevice(t *testing.T) {
+
+	device, deleted, err := DecodeDeviceStreamImage(map[string]events.DynamoDBAttributeValue{
+		"id":        events.NewStringAttribute("device1"),
+		"homeId":    events.NewStringAttribute("home1"),
+		"deletedAt": events.NewNumberAttribute("1729000000"),
+		"expiresAt": events.NewNumberAttribute("1731592000"),
+	})
+
+	assert.Nil(t, err)
+	assert.True(t, deleted)
+	assert.Equal(t, "device1", device.ID)
+	// like the devices read from the table, a device without status is offline
+	assert.Equal(t, constants.DeviceStatusOffline, device.Status)
+}
+
+func TestDecodeDeviceStreamImage_NotADevice(t *testing.T) {
+
+	device, _, err := DecodeDeviceStreamImage(nil)
+
+	assert.Nil(t, err)
+	assert.Nil(t, device)
+
+	device, _, err = DecodeDeviceStreamImage(map[string]events.DynamoDBAttributeValue{
+		"id":                        events.NewStringAttribute(buildMacHomeGuardId("00:11:22:33:44:55", "home1")),
+		macHomeGuardDeviceAttribute: events.NewStringAttribute("device1"),
+	})
+
+	assert.Nil(t, err)
+	assert.Nil(t, device)
+}
+
+func TestDecodeDeviceStreamImage_WithoutId(t *testing.T) {
+
+	_, _, err := DecodeDeviceStreamImage(map[string]events.DynamoDBAttributeValue{
+		"id":   events.NewNumberAttribute("1"),
+		"name": events.NewStringAttribute("Living Room Light"),
+	})
+
+	assert.EqualError(t, err, "the image has no id")
+}
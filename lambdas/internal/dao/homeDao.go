@@ -0,0 +1,240 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	constants "github.com/odhoman/home-devices/internal/constants"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+)
+
+// homeIdPrefix is the prefix of the generated home ids.
+const homeIdPrefix = "home-"
+
+// HomeDao keeps the homes that the devices belong to.
+type HomeDao interface {
+	SaveHome(ctx context.Context, home request.CreateHomeRequest) (*response.HomeResponse, error)
+	GetHome(ctx context.Context, id string) (*response.HomeResponse, error)
+	UpdateHome(ctx context.Context, home request.UpdateHomeRequest, id string, expectedVersion int64) error
+	DeleteHome(ctx context.Context, id string, expectedVersion int64) error
+}
+
+type HomeDaoImpl struct {
+	DynamoDbApi dynamoDbApi
+}
+
+func (hDI HomeDaoImpl) SaveHome(ctx context.Context, home request.CreateHomeRequest) (*response.HomeResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.HomeTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	now := time.Now().Unix()
+	homeSaved := response.HomeResponse{
+		ID:         resolveValue(home.ID, newHomeId()),
+		Name:       home.Name,
+		Timezone:   home.Timezone,
+		Address:    home.Address,
+		Owner:      home.Owner,
+		CreatedAt:  now,
+		ModifiedAt: now,
+		Version:    1,
+	}
+
+	item := map[string]types.AttributeValue{
+		"id":         &types.AttributeValueMemberS{Value: homeSaved.ID},
+		"name":       &types.AttributeValueMemberS{Value: homeSaved.Name},
+		"timezone":   &types.AttributeValueMemberS{Value: homeSaved.Timezone},
+		"owner":      &types.AttributeValueMemberS{Value: homeSaved.Owner},
+		"createdAt":  &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", now)},
+		"modifiedAt": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", now)},
+		"version":    &types.AttributeValueMemberN{Value: "1"},
+	}
+
+	if homeSaved.Address != "" {
+		item["address"] = &types.AttributeValueMemberS{Value: homeSaved.Address}
+	}
+
+	if _, err := hDI.DynamoDbApi.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           &tableName,
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(id)"),
+	}); err != nil {
+
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			log.Printf("Home with id %v already exists", homeSaved.ID)
+			return nil, hdError.ErrHomeAlreadyExists.Wrap(err).WithDetail("id", homeSaved.ID)
+		}
+
+		log.Printf("Error putting home into DynamoDB: %v", err)
+		return nil, hdError.ErrHomeNotCreated.Wrap(err)
+	}
+
+	return &homeSaved, nil
+}
+
+func (hDI HomeDaoImpl) GetHome(ctx context.Context, id string) (*response.HomeResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.HomeTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	result, err := hDI.DynamoDbApi.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &tableName,
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+	})
+
+	if err != nil {
+		log.Printf("Error getting home with id %v from DynamoDB: %v", id, err)
+		return nil, hdError.ErrGettingHome.Wrap(err)
+	}
+
+	if result.Item == nil {
+		return nil, hdError.ErrHomeNotFound.New()
+	}
+
+	home := mapDynamoDBItemToHomeResponse(result.Item)
+
+	return &home, nil
+}
+
+func (hDI HomeDaoImpl) UpdateHome(ctx context.Context, home request.UpdateHomeRequest, id string, expectedVersion int64) error {
+
+	tableName, error := getValuePropertyOrError(constants.HomeTableNameProperty)
+	if error != nil {
+		return error
+	}
+
+	setExpressions := []string{"modifiedAt = :modifiedAt", "version = version + :one"}
+	expressionAttributeValues := map[string]types.AttributeValue{
+		":modifiedAt": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", time.Now().Unix())},
+		":one":        &types.AttributeValueMemberN{Value: "1"},
+	}
+	expressionAttributeNames := map[string]string{}
+
+	fields := []struct {
+		name  string
+		value string
+	}{
+		{"name", home.Name},
+		{"timezone", home.Timezone},
+		{"address", home.Address},
+		{"owner", home.Owner},
+	}
+
+	for _, field := range fields {
+		if field.value == "" {
+			continue
+		}
+
+		setExpressions = append(setExpressions, fmt.Sprintf("#%[1]s = :%[1]s", field.name))
+		expressionAttributeNames["#"+field.name] = field.name
+		expressionAttributeValues[":"+field.name] = &types.AttributeValueMemberS{Value: field.value}
+	}
+
+	if len(expressionAttributeNames) == 0 {
+		return hdError.ErrNoFieldToUpdate.New()
+	}
+
+	if _, err := hDI.DynamoDbApi.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                           &tableName,
+		Key:                                 map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}},
+		UpdateExpression:                    aws.String("SET " + strings.Join(setExpressions, ", ")),
+		ConditionExpression:                 aws.String("attribute_exists(id)" + buildVersionCondition(expectedVersion, expressionAttributeValues)),
+		ExpressionAttributeNames:            expressionAttributeNames,
+		ExpressionAttributeValues:           expressionAttributeValues,
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	}); err != nil {
+
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			log.Printf("Home with id %v was deleted or modified while updating it", id)
+			return getHomeConditionalCheckFailedError(conditionErr.Item)
+		}
+
+		log.Printf("Error updating home with id %v into DynamoDB: %v", id, err)
+		return hdError.ErrUpdatingHome.Wrap(err)
+	}
+
+	return nil
+}
+
+// DeleteHome removes the home.
+func (hDI HomeDaoImpl) DeleteHome(ctx context.Context, id string, expectedVersion int64) error {
+
+	tableName, error := getValuePropertyOrError(constants.HomeTableNameProperty)
+	if error != nil {
+		return error
+	}
+
+	expressionAttributeValues := map[string]types.AttributeValue{}
+	input := &dynamodb.DeleteItemInput{
+		TableName:                           &tableName,
+		Key:                                 map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}},
+		ConditionExpression:                 aws.String("attribute_exists(id)" + buildVersionCondition(expectedVersion, expressionAttributeValues)),
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	}
+
+	if len(expressionAttributeValues) > 0 {
+		input.ExpressionAttributeValues = expressionAttributeValues
+	}
+
+	if _, err := hDI.DynamoDbApi.DeleteItem(ctx, input); err != nil {
+
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			log.Printf("Home with id %v was deleted or modified while deleting it", id)
+			return getHomeConditionalCheckFailedError(conditionErr.Item)
+		}
+
+		log.Printf("Error deleting home with id %v into DynamoDB: %v", id, err)
+		return hdError.ErrDeletingHome.Wrap(err)
+	}
+
+	return nil
+}
+
+// getHomeConditionalCheckFailedError tells a missing home apart from a home
+// that was modified by someone else.
+func getHomeConditionalCheckFailedError(item map[string]types.AttributeValue) error {
+
+	if item == nil {
+		return hdError.ErrHomeNotFound.New()
+	}
+
+	return hdError.ErrVersionConflict.WithMessage(hdError.HomeVersionConflictMessage)
+}
+
+func newHomeId() string {
+	id := homeIdPrefix + strings.ReplaceAll(uuid.New().String(), "-", "")
+	return id[:30]
+}
+
+func mapDynamoDBItemToHomeResponse(item map[string]types.AttributeValue) response.HomeResponse {
+	return response.HomeResponse{
+		ID:         getStringAttribute(item, "id"),
+		Name:       getStringAttribute(item, "name"),
+		Timezone:   getStringAttribute(item, "timezone"),
+		Address:    getStringAttribute(item, "address"),
+		Owner:      getStringAttribute(item, "owner"),
+		CreatedAt:  getInt64Attribute(item, "createdAt"),
+		ModifiedAt: getInt64Attribute(item, "modifiedAt"),
+		Version:    getInt64Attribute(item, "version"),
+	}
+}
@@ -0,0 +1,15 @@
+package dao_test
+
+import (
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+	"github.com/odhoman/home-devices/internal/dao/daotest"
+	"github.com/odhoman/home-devices/internal/mock"
+)
+
+func TestHomeDaoImpl_Conformance(t *testing.T) {
+	daotest.RunHomeDaoConformanceSuite(t, func() dao.HomeDao {
+		return dao.HomeDaoImpl{DynamoDbApi: mock.GetDynamoConnectionTestFromEnpoint()}
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 
+	hdAudit "github.com/odhoman/home-devices/internal/audit"
 	hdError "github.com/odhoman/home-devices/internal/error"
 	utils "github.com/odhoman/home-devices/internal/utils"
 
@@ -24,18 +25,33 @@ import (
 )
 
 type HomeDeviceDao interface {
-	IsDeviceExist(ctx context.Context, mac string, homeId string) (bool, *hdError.HomeDeviceError)
-	SaveHomeDevice(ctx context.Context, device request.CreateDeviceRequest) (*response.HomdeDeviceResponse, *hdError.HomeDeviceError)
-	GetHomeDevice(ctx context.Context, id string) (*response.HomdeDeviceResponse, *hdError.HomeDeviceError)
-	UpdateHomeDevice(ctx context.Context, device request.UpdateDeviceRequest, id string) *hdError.HomeDeviceError
-	DeleteHomeDevice(ctx context.Context, id string) *hdError.HomeDeviceError
+	IsDeviceExist(ctx context.Context, mac string, homeId string) (bool, error)
+	SaveHomeDevice(ctx context.Context, device request.CreateDeviceRequest) (*response.HomdeDeviceResponse, error)
+	GetHomeDevice(ctx context.Context, id string) (*response.HomdeDeviceResponse, error)
+	UpdateHomeDevice(ctx context.Context, device request.UpdateDeviceRequest, id string, expectedVersion int64) (*response.HomdeDeviceResponse, error)
+	DeleteHomeDevice(ctx context.Context, id string, expectedVersion int64) (*response.HomdeDeviceResponse, error)
+	HardDeleteHomeDevice(ctx context.Context, id string, expectedVersion int64) error
+	GetDeletedHomeDevice(ctx context.Context, id string) (*response.HomdeDeviceResponse, error)
+	RestoreHomeDevice(ctx context.Context, id string) (*response.HomdeDeviceResponse, error)
+	ListHomeDevices(ctx context.Context, homeId string, roomId string, status string, limit int32, cursor string) (*response.HomeDeviceListResponse, error)
+	MarkHomeDeviceSeen(ctx context.Context, id string, seenAt int64) (*response.HomdeDeviceResponse, error)
+	ListStaleHomeDevices(ctx context.Context, seenBefore int64) ([]response.HomdeDeviceResponse, error)
+	MarkHomeDeviceOffline(ctx context.Context, id string, lastSeenAt int64) (bool, error)
 }
 
 type HomeDeviceDaoImpl struct {
 	DynamoDbApi dynamoDbApi
+	// RecordHistory writes the change record of every write of a device in the
+	// device history, in the same transaction
+	RecordHistory bool
+	// CountRoomDevices keeps the number of devices of each room on the room, in
+	// the same transaction, so a room with devices can not be deleted
+	CountRoomDevices bool
 }
 
-func (hDDI HomeDeviceDaoImpl) IsDeviceExist(ctx context.Context, mac string, homeId string) (bool, *hdError.HomeDeviceError) {
+func (hDDI HomeDeviceDaoImpl) IsDeviceExist(ctx context.Context, mac string, homeId string) (bool, error) {
+
+	mac = normalizeMac(mac)
 
 	tableName, error := getValuePropertyOrError(constants.TableNameHomeDevicesProperty)
 	if error != nil {
@@ -51,6 +67,7 @@ func (hDDI HomeDeviceDaoImpl) IsDeviceExist(ctx context.Context, mac string, hom
 		TableName:              &tableName,
 		IndexName:              &macHomeIdIndexName,
 		KeyConditionExpression: aws.String("mac = :mac and homeId = :homeId"),
+		FilterExpression:       aws.String("attribute_not_exists(deletedAt)"),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
 			":mac":    &types.AttributeValueMemberS{Value: mac},
 			":homeId": &types.AttributeValueMemberS{Value: homeId},
@@ -60,18 +77,17 @@ func (hDDI HomeDeviceDaoImpl) IsDeviceExist(ctx context.Context, mac string, hom
 	result, err := hDDI.DynamoDbApi.Query(ctx, input)
 
 	if err != nil {
-		fmt.Printf("Error querying the GSI: %v", err)
-		return false, &hdError.HomeDeviceError{
-			ErrorCode:    constants.ErrGettingDeviceCode,
-			ErrorMessage: constants.ErrGettingDeviceMessage,
-		}
+		log.Printf("Error querying the GSI: %v", err)
+		return false, hdError.ErrGettingDevice.Wrap(err)
 	}
 
 	return len(result.Items) > 0, nil
 
 }
 
-func (hDDI HomeDeviceDaoImpl) SaveHomeDevice(ctx context.Context, device request.CreateDeviceRequest) (*response.HomdeDeviceResponse, *hdError.HomeDeviceError) {
+func (hDDI HomeDeviceDaoImpl) SaveHomeDevice(ctx context.Context, device request.CreateDeviceRequest) (*response.HomdeDeviceResponse, error) {
+
+	device.MAC = normalizeMac(device.MAC)
 
 	tableName, error := getValuePropertyOrError(constants.TableNameHomeDevicesProperty)
 	if error != nil {
@@ -81,141 +97,976 @@ func (hDDI HomeDeviceDaoImpl) SaveHomeDevice(ctx context.Context, device request
 	id := uuid.New().String()
 	now := time.Now().Unix()
 
-	if _, err := hDDI.DynamoDbApi.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: &tableName,
-		Item: map[string]types.AttributeValue{
-			"id":         &types.AttributeValueMemberS{Value: id},
-			"mac":        &types.AttributeValueMemberS{Value: device.MAC},
-			"name":       &types.AttributeValueMemberS{Value: device.Name},
-			"type":       &types.AttributeValueMemberS{Value: device.Type},
-			"homeId":     &types.AttributeValueMemberS{Value: device.HomeID},
-			"createdAt":  &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", now)},
-			"modifiedAt": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", now)},
-		},
-	}); err != nil {
-		fmt.Printf("Error putting item into DynamoDB: %v", err)
-		return nil, &hdError.HomeDeviceError{
-			ErrorCode:    constants.ErrDeviceNotCreatedErrorCode,
-			ErrorMessage: constants.ErrDeviceNotCreatedErrorMessage,
-		}
+	item := map[string]types.AttributeValue{
+		"id":         &types.AttributeValueMemberS{Value: id},
+		"mac":        &types.AttributeValueMemberS{Value: device.MAC},
+		"name":       &types.AttributeValueMemberS{Value: device.Name},
+		"type":       &types.AttributeValueMemberS{Value: device.Type},
+		"homeId":     &types.AttributeValueMemberS{Value: device.HomeID},
+		"createdAt":  &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", now)},
+		"modifiedAt": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", now)},
+		"version":    &types.AttributeValueMemberN{Value: "1"},
+		"status":     &types.AttributeValueMemberS{Value: constants.DeviceStatusOffline},
 	}
 
-	return &response.HomdeDeviceResponse{
+	if device.RoomID != "" {
+		item["roomId"] = &types.AttributeValueMemberS{Value: device.RoomID}
+	}
+
+	saved := response.HomdeDeviceResponse{
 		ID:         id,
 		MAC:        device.MAC,
 		Name:       device.Name,
 		Type:       device.Type,
 		HomeID:     device.HomeID,
+		RoomID:     device.RoomID,
 		CreatedAt:  now,
 		ModifiedAt: now,
-	}, nil
+		Version:    1,
+		Status:     constants.DeviceStatusOffline,
+	}
+
+	deltas := hDDI.buildRoomDevicesDeltas(nil, &saved)
+
+	transactItems, error := hDDI.withRoomDevices([]types.TransactWriteItem{
+		{
+			Put: &types.Put{
+				TableName:           &tableName,
+				Item:                item,
+				ConditionExpression: aws.String("attribute_not_exists(id)"),
+			},
+		},
+		buildPutMacHomeGuard(tableName, device.MAC, device.HomeID, id),
+	}, deltas)
+	if error != nil {
+		return nil, error
+	}
+
+	transactItems, error = hDDI.withDeviceChange(transactItems, newDeviceChange(ctx, hdAudit.OperationCreate, id, nil, &saved))
+	if error != nil {
+		return nil, error
+	}
+
+	if _, err := hDDI.DynamoDbApi.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	}); err != nil {
+
+		if reasons, ok := getCancellationReasons(err); ok && isConditionalCheckFailed(reasons, 1) {
+			log.Printf("Device with mac %v and homeId %v already exists", device.MAC, device.HomeID)
+			return nil, newDeviceAlreadyExistsError(device.MAC, device.HomeID, reasons[1].Item, err)
+		}
+
+		if error := getUnknownRoomError(err, 2, deltas); error != nil {
+			return nil, error
+		}
+
+		log.Printf("Error putting item into DynamoDB: %v", err)
+		return nil, hdError.ErrDeviceNotCreated.Wrap(err)
+	}
+
+	return &saved, nil
+}
+
+// withDeviceChange adds the change record to the items of the transaction when
+// the dao records the history.
+func (hDDI HomeDeviceDaoImpl) withDeviceChange(transactItems []types.TransactWriteItem, change response.DeviceChangeResponse) ([]types.TransactWriteItem, error) {
+
+	if !hDDI.RecordHistory {
+		return transactItems, nil
+	}
+
+	tableName, error := getValuePropertyOrError(constants.DeviceHistoryTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	return append(transactItems, buildPutDeviceChange(tableName, change)), nil
+}
+
+// buildRoomDevicesDeltas returns the rooms the device leaves and enters when
+// the dao counts the devices of the rooms.
+func (hDDI HomeDeviceDaoImpl) buildRoomDevicesDeltas(before *response.HomdeDeviceResponse, after *response.HomdeDeviceResponse) []roomDevicesDelta {
+
+	if !hDDI.CountRoomDevices {
+		return nil
+	}
+
+	return buildRoomDevicesDeltas(before, after)
+}
+
+// withRoomDevices adds the updates of the number of devices of the rooms to
+// the items of the transaction.
+func (hDDI HomeDeviceDaoImpl) withRoomDevices(transactItems []types.TransactWriteItem, deltas []roomDevicesDelta) ([]types.TransactWriteItem, error) {
+
+	if len(deltas) == 0 {
+		return transactItems, nil
+	}
+
+	tableName, error := getValuePropertyOrError(constants.RoomTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	for _, delta := range deltas {
+		transactItems = append(transactItems, buildAddRoomDevices(tableName, delta))
+	}
+
+	return transactItems, nil
+}
+
+// getUnknownRoomError returns the error of a room that was deleted while
+// writing the device, its updates being the items of the transaction from the
+// index from.
+func getUnknownRoomError(err error, from int, deltas []roomDevicesDelta) error {
+
+	reasons, ok := getCancellationReasons(err)
+	if !ok {
+		return nil
+	}
+
+	for i, delta := range deltas {
+		if isConditionalCheckFailed(reasons, from+i) {
+			log.Printf("Room %v of the home %v was deleted while writing a device", delta.roomId, delta.homeId)
+			return hdError.ErrUnknownRoom.New().WithDetail("roomId", delta.roomId)
+		}
+	}
+
+	return nil
 }
 
-func (hDDI HomeDeviceDaoImpl) GetHomeDevice(ctx context.Context, id string) (*response.HomdeDeviceResponse, *hdError.HomeDeviceError) {
+func (hDDI HomeDeviceDaoImpl) GetHomeDevice(ctx context.Context, id string) (*response.HomdeDeviceResponse, error) {
 
 	tableName, error := getValuePropertyOrError(constants.TableNameHomeDevicesProperty)
 	if error != nil {
 		return nil, error
 	}
 
+	item, error := hDDI.getActiveDeviceItem(ctx, tableName, id, false)
+	if error != nil {
+		return nil, error
+	}
+
+	device := mapDynamoDBItemToDeviceResponse(item)
+
+	return &device, nil
+}
+
+func (hDDI HomeDeviceDaoImpl) getDeviceItem(ctx context.Context, tableName string, id string, consistentRead bool) (map[string]types.AttributeValue, error) {
+
+	if isMacHomeGuardId(id) {
+		return nil, hdError.ErrDeviceNotFound.New()
+	}
+
 	result, err := hDDI.DynamoDbApi.GetItem(ctx, &dynamodb.GetItemInput{
 		TableName: &tableName,
 		Key: map[string]types.AttributeValue{
 			"id": &types.AttributeValueMemberS{Value: id},
 		},
+		ConsistentRead: aws.Bool(consistentRead),
 	})
 
 	if err != nil {
 		log.Printf("Error getting item with it %v DynamoDB: %v", id, err)
-		return nil, &hdError.HomeDeviceError{
-			ErrorCode:    constants.ErrGettingDeviceCode,
-			ErrorMessage: constants.ErrDeviceNotCreatedErrorMessage,
-		}
+		return nil, hdError.ErrGettingDevice.Wrap(err)
 	}
 
 	if result.Item == nil {
-		return nil, &hdError.HomeDeviceError{
-			ErrorCode:    constants.ErrDeviceNotFoundCode,
-			ErrorMessage: constants.ErrDeviceNotFoundMessage,
+		return nil, hdError.ErrDeviceNotFound.New()
+	}
+
+	return result.Item, nil
+}
+
+// getActiveDeviceItem works like getDeviceItem but a soft deleted device is
+// not found.
+func (hDDI HomeDeviceDaoImpl) getActiveDeviceItem(ctx context.Context, tableName string, id string, consistentRead bool) (map[string]types.AttributeValue, error) {
+
+	item, error := hDDI.getDeviceItem(ctx, tableName, id, consistentRead)
+	if error != nil {
+		return nil, error
+	}
+
+	if isDeletedItem(item) {
+		return nil, hdError.ErrDeviceNotFound.New()
+	}
+
+	return item, nil
+}
+
+// UpdateHomeDevice returns the device as it is stored after the update.
+func (hDDI HomeDeviceDaoImpl) UpdateHomeDevice(ctx context.Context, device request.UpdateDeviceRequest, id string, expectedVersion int64) (*response.HomdeDeviceResponse, error) {
+
+	device.MAC = normalizeMac(device.MAC)
+
+	updateInput, error := buidUpdateInput(device, id, expectedVersion)
+	if error != nil {
+		return nil, error
+	}
+
+	if isMacHomeGuardId(id) {
+		return nil, hdError.ErrDeviceNotFound.New()
+	}
+
+	if device.MAC != "" || device.HomeID != "" || hDDI.RecordHistory || (device.RoomID != "" && hDDI.CountRoomDevices) {
+		return hDDI.updateHomeDeviceAndGuard(ctx, device, id, expectedVersion, updateInput)
+	}
+
+	return hDDI.updateHomeDeviceItem(ctx, id, updateInput)
+}
+
+func (hDDI HomeDeviceDaoImpl) updateHomeDeviceItem(ctx context.Context, id string, updateInput *dynamodb.UpdateItemInput) (*response.HomdeDeviceResponse, error) {
+
+	result, err := hDDI.DynamoDbApi.UpdateItem(ctx, updateInput)
+	if err != nil {
+		log.Printf("Error updating item with id %v into DynamoDB: %v", id, err)
+		return nil, getUpdateError(err)
+	}
+
+	updated := mapDynamoDBItemToDeviceResponse(result.Attributes)
+
+	return &updated, nil
+}
+
+// updateHomeDeviceAndGuard moves the mac + homeId guard item in the same
+// transaction as the device update when any of those two fields change, and
+// records the change in the history.
+func (hDDI HomeDeviceDaoImpl) updateHomeDeviceAndGuard(ctx context.Context, device request.UpdateDeviceRequest, id string, expectedVersion int64, updateInput *dynamodb.UpdateItemInput) (*response.HomdeDeviceResponse, error) {
+
+	current, error := hDDI.getActiveDeviceItem(ctx, *updateInput.TableName, id, true)
+	if error != nil {
+		return nil, error
+	}
+
+	if error := checkExpectedVersion(current, expectedVersion); error != nil {
+		return nil, error
+	}
+
+	currentMac := getStringAttribute(current, "mac")
+	currentHomeId := getStringAttribute(current, "homeId")
+	newMac := resolveValue(device.MAC, currentMac)
+	newHomeId := resolveValue(device.HomeID, currentHomeId)
+
+	movesGuard := buildMacHomeGuardId(currentMac, currentHomeId) != buildMacHomeGuardId(newMac, newHomeId)
+
+	// a room belongs to a home, the device leaves it when it moves to another
+	// home without a room of the new one
+	leavesRoom := newHomeId != currentHomeId && device.RoomID == ""
+	if leavesRoom {
+		updateInput.UpdateExpression = aws.String(*updateInput.UpdateExpression + " REMOVE roomId")
+	}
+
+	before := mapDynamoDBItemToDeviceResponse(current)
+	updated := mapDynamoDBItemToDeviceResponse(buildTransactedDeviceItem(current, updateInput.ExpressionAttributeValues, leavesRoom))
+	deltas := hDDI.buildRoomDevicesDeltas(&before, &updated)
+
+	if !movesGuard && !hDDI.RecordHistory && len(deltas) == 0 {
+		return hDDI.updateHomeDeviceItem(ctx, id, updateInput)
+	}
+
+	conditionExpression := *updateInput.ConditionExpression + " AND mac = :currentMac AND homeId = :currentHomeId"
+	updateInput.ExpressionAttributeValues[":currentMac"] = &types.AttributeValueMemberS{Value: currentMac}
+	updateInput.ExpressionAttributeValues[":currentHomeId"] = &types.AttributeValueMemberS{Value: currentHomeId}
+
+	if expectedVersion <= 0 {
+		conditionExpression += buildVersionCondition(hDDI.resolveConditionVersion(expectedVersion, current), updateInput.ExpressionAttributeValues)
+	}
+
+	transactItems := []types.TransactWriteItem{
+		{
+			Update: &types.Update{
+				TableName:                           updateInput.TableName,
+				Key:                                 updateInput.Key,
+				UpdateExpression:                    updateInput.UpdateExpression,
+				ExpressionAttributeValues:           updateInput.ExpressionAttributeValues,
+				ExpressionAttributeNames:            updateInput.ExpressionAttributeNames,
+				ConditionExpression:                 aws.String(conditionExpression),
+				ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+			},
+		},
+	}
+
+	if movesGuard {
+		transactItems = append(transactItems,
+			buildDeleteMacHomeGuard(*updateInput.TableName, currentMac, currentHomeId),
+			buildPutMacHomeGuard(*updateInput.TableName, newMac, newHomeId, id))
+	}
+
+	roomItemsFrom := len(transactItems)
+
+	transactItems, error = hDDI.withRoomDevices(transactItems, deltas)
+	if error != nil {
+		return nil, error
+	}
+
+	transactItems, error = hDDI.withDeviceChange(transactItems, newDeviceChange(ctx, hdAudit.OperationUpdate, id, &before, &updated))
+	if error != nil {
+		return nil, error
+	}
+
+	if _, err := hDDI.DynamoDbApi.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	}); err != nil {
+
+		if reasons, ok := getCancellationReasons(err); ok {
+			if movesGuard && isConditionalCheckFailed(reasons, 2) {
+				log.Printf("Device with mac %v and homeId %v already exists, update of %v failed", newMac, newHomeId, id)
+				return nil, newDeviceAlreadyExistsError(newMac, newHomeId, reasons[2].Item, err)
+			}
+
+			if isConditionalCheckFailed(reasons, 0) {
+				log.Printf("Device with id %v was deleted or modified while updating it", id)
+				return nil, getConditionalCheckFailedError(reasons[0].Item)
+			}
+		}
+
+		if error := getUnknownRoomError(err, roomItemsFrom, deltas); error != nil {
+			return nil, error
 		}
+
+		log.Printf("Error updating item with id %v into DynamoDB: %v", id, err)
+		return nil, hdError.ErrUpdatingDevice.Wrap(err)
 	}
 
-	device := mapDynamoDBItemToDeviceResponse(result.Item)
+	return &updated, nil
+}
+
+// buildTransactedDeviceItem returns the device item written by the transaction
+// of updateHomeDeviceAndGuard, as TransactWriteItems does not return the items
+// it writes.
+func buildTransactedDeviceItem(current map[string]types.AttributeValue, expressionAttributeValues map[string]types.AttributeValue, leavesRoom bool) map[string]types.AttributeValue {
 
-	return &device, nil
+	item := make(map[string]types.AttributeValue, len(current))
+	for name, value := range current {
+		item[name] = value
+	}
+
+	for _, name := range []string{"mac", "name", "type", "homeId", "roomId", "modifiedAt"} {
+		if value, ok := expressionAttributeValues[":"+name]; ok {
+			item[name] = value
+		}
+	}
+
+	if leavesRoom {
+		delete(item, "roomId")
+	}
+
+	item["version"] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", getInt64Attribute(current, "version")+1)}
+
+	return item
 }
 
-func (hDDI HomeDeviceDaoImpl) UpdateHomeDevice(ctx context.Context, device request.UpdateDeviceRequest, id string) *hdError.HomeDeviceError {
+// DeleteHomeDevice soft deletes the device: it is kept with deletedAt and
+// expiresAt until the retention window is over and its mac + homeId guard is
+// released.
+func (hDDI HomeDeviceDaoImpl) DeleteHomeDevice(ctx context.Context, id string, expectedVersion int64) (*response.HomdeDeviceResponse, error) {
 
-	updateInput, error := buidUpdateInput(device, id)
+	tableName, error := getValuePropertyOrError(constants.TableNameHomeDevicesProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	current, error := hDDI.getActiveDeviceItem(ctx, tableName, id, true)
+	if error != nil {
+		if errors.Is(error, hdError.ErrDeviceNotFound) {
+			log.Printf("Record with id %v does not exist, delete failed", id)
+		}
+		return nil, error
+	}
+
+	if error := checkExpectedVersion(current, expectedVersion); error != nil {
+		return nil, error
+	}
+
+	currentMac := getStringAttribute(current, "mac")
+	currentHomeId := getStringAttribute(current, "homeId")
+	now := time.Now()
+
+	expressionAttributeValues := map[string]types.AttributeValue{
+		":currentMac":    &types.AttributeValueMemberS{Value: currentMac},
+		":currentHomeId": &types.AttributeValueMemberS{Value: currentHomeId},
+		":deletedAt":     &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", now.Unix())},
+		":expiresAt":     &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", now.Add(getDeletedDeviceRetention()).Unix())},
+		":zero":          &types.AttributeValueMemberN{Value: "0"},
+		":one":           &types.AttributeValueMemberN{Value: "1"},
+	}
+
+	before := mapDynamoDBItemToDeviceResponse(current)
+	deltas := hDDI.buildRoomDevicesDeltas(&before, nil)
+
+	transactItems, error := hDDI.withRoomDevices([]types.TransactWriteItem{
+		{
+			Update: &types.Update{
+				TableName: &tableName,
+				Key: map[string]types.AttributeValue{
+					"id": &types.AttributeValueMemberS{Value: id},
+				},
+				UpdateExpression:                    aws.String("SET deletedAt = :deletedAt, expiresAt = :expiresAt, version = if_not_exists(version, :zero) + :one"),
+				ConditionExpression:                 aws.String("attribute_exists(id) AND attribute_not_exists(deletedAt) AND mac = :currentMac AND homeId = :currentHomeId" + buildVersionCondition(hDDI.resolveConditionVersion(expectedVersion, current), expressionAttributeValues)),
+				ExpressionAttributeValues:           expressionAttributeValues,
+				ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+			},
+		},
+		buildDeleteMacHomeGuard(tableName, currentMac, currentHomeId),
+	}, deltas)
+	if error != nil {
+		return nil, error
+	}
+
+	transactItems, error = hDDI.withDeviceChange(transactItems, newDeviceChange(ctx, hdAudit.OperationDelete, id, &before, nil))
+	if error != nil {
+		return nil, error
+	}
+
+	if _, err := hDDI.DynamoDbApi.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	}); err != nil {
+
+		if reasons, ok := getCancellationReasons(err); ok && isConditionalCheckFailed(reasons, 0) {
+			log.Printf("Device with id %v was deleted or modified while deleting it", id)
+			return nil, getConditionalCheckFailedError(reasons[0].Item)
+		}
+
+		if error := getUnknownRoomError(err, 2, deltas); error != nil {
+			return nil, error
+		}
+
+		log.Printf("Error deleting item with id %v into DynamoDB: %v", id, err)
+		return nil, hdError.ErrDeletingDevice.Wrap(err)
+	}
+
+	deleted := before
+	deleted.Version++
+
+	return &deleted, nil
+}
+
+// HardDeleteHomeDevice removes the device from the table, whether it is
+// active or soft deleted.
+func (hDDI HomeDeviceDaoImpl) HardDeleteHomeDevice(ctx context.Context, id string, expectedVersion int64) error {
+
+	tableName, error := getValuePropertyOrError(constants.TableNameHomeDevicesProperty)
 	if error != nil {
 		return error
 	}
 
-	if _, err := hDDI.DynamoDbApi.UpdateItem(ctx, updateInput); err != nil {
+	current, error := hDDI.getDeviceItem(ctx, tableName, id, true)
+	if error != nil {
+		if errors.Is(error, hdError.ErrDeviceNotFound) {
+			log.Printf("Record with id %v does not exist, hard delete failed", id)
+		}
+		return error
+	}
+
+	if error := checkExpectedVersion(current, expectedVersion); error != nil {
+		return error
+	}
+
+	key := map[string]types.AttributeValue{
+		"id": &types.AttributeValueMemberS{Value: id},
+	}
+
+	// the guard of a soft deleted device was already released, and its values
+	// are in the change record of the soft delete
+	if isDeletedItem(current) {
+
+		expressionAttributeValues := map[string]types.AttributeValue{}
+		deleteItem := &types.Delete{
+			TableName:                           &tableName,
+			Key:                                 key,
+			ConditionExpression:                 aws.String("attribute_exists(deletedAt)" + buildVersionCondition(hDDI.resolveConditionVersion(expectedVersion, current), expressionAttributeValues)),
+			ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+		}
+
+		if len(expressionAttributeValues) > 0 {
+			deleteItem.ExpressionAttributeValues = expressionAttributeValues
+		}
 
-		var conditionErr *types.ConditionalCheckFailedException
-		if errors.As(err, &conditionErr) {
-			return &hdError.HomeDeviceError{
-				ErrorCode:    constants.ErrDeviceNotFoundCode,
-				ErrorMessage: constants.ErrDeviceNotFoundMessage,
+		transactItems, error := hDDI.withDeviceChange([]types.TransactWriteItem{{Delete: deleteItem}}, newDeviceChange(ctx, hdAudit.OperationHardDelete, id, nil, nil))
+		if error != nil {
+			return error
+		}
+
+		if _, err := hDDI.DynamoDbApi.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+			TransactItems: transactItems,
+		}); err != nil {
+
+			if reasons, ok := getCancellationReasons(err); ok && isConditionalCheckFailed(reasons, 0) {
+				log.Printf("Device with id %v was restored or modified while deleting it", id)
+				return getConditionalCheckFailedError(reasons[0].Item)
 			}
+
+			log.Printf("Error deleting item with id %v into DynamoDB: %v", id, err)
+			return hdError.ErrDeletingDevice.Wrap(err)
 		}
 
-		log.Printf("Error updating item with id %v into DynamoDB: %v", id, err)
-		return &hdError.HomeDeviceError{
-			ErrorCode:    constants.ErrUpdatingDeviceCode,
-			ErrorMessage: constants.ErrUpdatingDeviceMessage,
+		return nil
+	}
+
+	currentMac := getStringAttribute(current, "mac")
+	currentHomeId := getStringAttribute(current, "homeId")
+	before := mapDynamoDBItemToDeviceResponse(current)
+
+	expressionAttributeValues := map[string]types.AttributeValue{
+		":currentMac":    &types.AttributeValueMemberS{Value: currentMac},
+		":currentHomeId": &types.AttributeValueMemberS{Value: currentHomeId},
+	}
+
+	deltas := hDDI.buildRoomDevicesDeltas(&before, nil)
+
+	transactItems, error := hDDI.withRoomDevices([]types.TransactWriteItem{
+		{
+			Delete: &types.Delete{
+				TableName:                           &tableName,
+				Key:                                 key,
+				ConditionExpression:                 aws.String("attribute_exists(id) AND attribute_not_exists(deletedAt) AND mac = :currentMac AND homeId = :currentHomeId" + buildVersionCondition(hDDI.resolveConditionVersion(expectedVersion, current), expressionAttributeValues)),
+				ExpressionAttributeValues:           expressionAttributeValues,
+				ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+			},
+		},
+		buildDeleteMacHomeGuard(tableName, currentMac, currentHomeId),
+	}, deltas)
+	if error != nil {
+		return error
+	}
+
+	transactItems, error = hDDI.withDeviceChange(transactItems, newDeviceChange(ctx, hdAudit.OperationHardDelete, id, &before, nil))
+	if error != nil {
+		return error
+	}
+
+	if _, err := hDDI.DynamoDbApi.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	}); err != nil {
+
+		if reasons, ok := getCancellationReasons(err); ok && isConditionalCheckFailed(reasons, 0) {
+			log.Printf("Device with id %v was deleted or modified while deleting it", id)
+			return getConditionalCheckFailedError(reasons[0].Item)
+		}
+
+		if error := getUnknownRoomError(err, 2, deltas); error != nil {
+			return error
 		}
+
+		log.Printf("Error deleting item with id %v into DynamoDB: %v", id, err)
+		return hdError.ErrDeletingDevice.Wrap(err)
 	}
 
 	return nil
 }
 
-func (hDDI HomeDeviceDaoImpl) DeleteHomeDevice(ctx context.Context, id string) *hdError.HomeDeviceError {
+// GetDeletedHomeDevice returns a soft deleted device that can still be
+// restored.
+func (hDDI HomeDeviceDaoImpl) GetDeletedHomeDevice(ctx context.Context, id string) (*response.HomdeDeviceResponse, error) {
 
 	tableName, error := getValuePropertyOrError(constants.TableNameHomeDevicesProperty)
 	if error != nil {
-		return error
+		return nil, error
 	}
 
-	if _, err := hDDI.DynamoDbApi.DeleteItem(ctx, &dynamodb.DeleteItemInput{
-		TableName: &tableName,
-		Key: map[string]types.AttributeValue{
-			"id": &types.AttributeValueMemberS{Value: id},
+	item, error := hDDI.getDeletedDeviceItem(ctx, tableName, id)
+	if error != nil {
+		return nil, error
+	}
+
+	device := mapDynamoDBItemToDeviceResponse(item)
+	return &device, nil
+}
+
+func (hDDI HomeDeviceDaoImpl) getDeletedDeviceItem(ctx context.Context, tableName string, id string) (map[string]types.AttributeValue, error) {
+
+	item, error := hDDI.getDeviceItem(ctx, tableName, id, true)
+	if error != nil {
+		return nil, error
+	}
+
+	if !isDeletedItem(item) {
+		return nil, hdError.ErrDeviceNotDeleted.New()
+	}
+
+	deletedAt := getInt64Attribute(item, "deletedAt")
+
+	if !isWithinRetention(deletedAt, time.Now()) {
+		log.Printf("Device with id %v was deleted at %v, out of the retention window", id, deletedAt)
+		return nil, hdError.ErrDeviceNotFound.New()
+	}
+
+	return item, nil
+}
+
+// RestoreHomeDevice undoes a soft delete while the device is within the
+// retention window.
+func (hDDI HomeDeviceDaoImpl) RestoreHomeDevice(ctx context.Context, id string) (*response.HomdeDeviceResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.TableNameHomeDevicesProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	current, error := hDDI.getDeletedDeviceItem(ctx, tableName, id)
+	if error != nil {
+		return nil, error
+	}
+
+	deletedAt := getInt64Attribute(current, "deletedAt")
+
+	device := mapDynamoDBItemToDeviceResponse(current)
+	device.ModifiedAt = time.Now().Unix()
+	device.Version++
+
+	deltas := hDDI.buildRoomDevicesDeltas(nil, &device)
+
+	transactItems, error := hDDI.withRoomDevices([]types.TransactWriteItem{
+		{
+			Update: &types.Update{
+				TableName: &tableName,
+				Key: map[string]types.AttributeValue{
+					"id": &types.AttributeValueMemberS{Value: id},
+				},
+				UpdateExpression:    aws.String("SET modifiedAt = :modifiedAt, version = if_not_exists(version, :zero) + :one REMOVE deletedAt, expiresAt"),
+				ConditionExpression: aws.String("deletedAt = :deletedAt"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":deletedAt":  &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", deletedAt)},
+					":modifiedAt": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", device.ModifiedAt)},
+					":zero":       &types.AttributeValueMemberN{Value: "0"},
+					":one":        &types.AttributeValueMemberN{Value: "1"},
+				},
+				ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+			},
 		},
-		ConditionExpression: aws.String("attribute_exists(id)"),
+		buildPutMacHomeGuard(tableName, device.MAC, device.HomeID, id),
+	}, deltas)
+	if error != nil {
+		return nil, error
+	}
+
+	transactItems, error = hDDI.withDeviceChange(transactItems, newDeviceChange(ctx, hdAudit.OperationRestore, id, nil, &device))
+	if error != nil {
+		return nil, error
+	}
+
+	if _, err := hDDI.DynamoDbApi.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
 	}); err != nil {
-		var conditionErr *types.ConditionalCheckFailedException
-		if errors.As(err, &conditionErr) {
-			log.Printf("Record with id %v does not exist, delete failed", id)
-			return &hdError.HomeDeviceError{
-				ErrorCode:    constants.ErrDeviceNotFoundCode,
-				ErrorMessage: constants.ErrDeviceNotFoundMessage,
+
+		if reasons, ok := getCancellationReasons(err); ok {
+			if isConditionalCheckFailed(reasons, 1) {
+				log.Printf("Device with mac %v and homeId %v already exists, restore of %v failed", device.MAC, device.HomeID, id)
+				return nil, newDeviceAlreadyExistsError(device.MAC, device.HomeID, reasons[1].Item, err)
+			}
+
+			if isConditionalCheckFailed(reasons, 0) {
+				log.Printf("Device with id %v was restored or deleted while restoring it", id)
+				return nil, getRestoreConditionalCheckFailedError(reasons[0].Item)
 			}
 		}
 
-		log.Printf("Error deleting item with id %v into DynamoDB: %v", id, err)
-		return &hdError.HomeDeviceError{
-			ErrorCode:    constants.ErrDeletingDeviceCode,
-			ErrorMessage: constants.ErrDeletingDeviceMessage,
+		if error := getUnknownRoomError(err, 2, deltas); error != nil {
+			return nil, error
 		}
 
+		log.Printf("Error restoring item with id %v into DynamoDB: %v", id, err)
+		return nil, hdError.ErrRestoringDevice.Wrap(err)
 	}
 
-	return nil
+	return &device, nil
+}
+
+// ListHomeDevices returns a page of the devices of a home, only the ones of the
+// room when roomId is not empty and the ones with the status when status is not
+// empty.
+func (hDDI HomeDeviceDaoImpl) ListHomeDevices(ctx context.Context, homeId string, roomId string, status string, limit int32, cursor string) (*response.HomeDeviceListResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.TableNameHomeDevicesProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	homeIdIndexName, error := getValuePropertyOrError(constants.HomeIdIndexNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	exclusiveStartKey, err := decodeCursor(cursor)
+	if err != nil || (exclusiveStartKey != nil && getStringAttribute(exclusiveStartKey, "homeId") != homeId) {
+		log.Printf("Invalid cursor %v for homeId %v: %v", cursor, homeId, err)
+		return nil, hdError.ErrInvalidCursor.Wrap(err)
+	}
+
+	filterExpression := "attribute_not_exists(deletedAt)"
+	expressionAttributeValues := map[string]types.AttributeValue{
+		":homeId": &types.AttributeValueMemberS{Value: homeId},
+	}
+
+	if roomId != "" {
+		filterExpression += " AND roomId = :roomId"
+		expressionAttributeValues[":roomId"] = &types.AttributeValueMemberS{Value: roomId}
+	}
+
+	var expressionAttributeNames map[string]string
+	if status != "" {
+		// the devices created before the presence have no status, they
+		// were never seen
+		if status == constants.DeviceStatusOffline {
+			filterExpression += " AND (attribute_not_exists(#status) OR #status = :status)"
+		} else {
+			filterExpression += " AND #status = :status"
+		}
+		expressionAttributeValues[":status"] = &types.AttributeValueMemberS{Value: status}
+		expressionAttributeNames = map[string]string{"#status": "status"}
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:                 &tableName,
+		IndexName:                 &homeIdIndexName,
+		KeyConditionExpression:    aws.String("homeId = :homeId"),
+		ExpressionAttributeValues: expressionAttributeValues,
+		ExpressionAttributeNames:  expressionAttributeNames,
+		FilterExpression:          aws.String(filterExpression),
+		Limit:                     aws.Int32(resolveListLimit(limit)),
+		ExclusiveStartKey:         exclusiveStartKey,
+	}
+
+	result, err := hDDI.DynamoDbApi.Query(ctx, input)
+
+	if err != nil {
+		log.Printf("Error listing devices for homeId %v from DynamoDB: %v", homeId, err)
+		return nil, hdError.ErrListingDevices.Wrap(err)
+	}
+
+	nextCursor, err := encodeCursor(result.LastEvaluatedKey)
+	if err != nil {
+		log.Printf("Error building the cursor for homeId %v: %v", homeId, err)
+		return nil, hdError.ErrListingDevices.Wrap(err)
+	}
+
+	devices := make([]response.HomdeDeviceResponse, 0, len(result.Items))
+	for _, item := range result.Items {
+		devices = append(devices, mapDynamoDBItemToDeviceResponse(item))
+	}
+
+	return &response.HomeDeviceListResponse{
+		Devices:    devices,
+		NextCursor: nextCursor,
+	}, nil
 }
 
-func buidUpdateInput(device request.UpdateDeviceRequest, id string) (*dynamodb.UpdateItemInput, *hdError.HomeDeviceError) {
+// MarkHomeDeviceSeen sets the time the device was last seen and marks it
+// online.
+func (hDDI HomeDeviceDaoImpl) MarkHomeDeviceSeen(ctx context.Context, id string, seenAt int64) (*response.HomdeDeviceResponse, error) {
 
 	tableName, error := getValuePropertyOrError(constants.TableNameHomeDevicesProperty)
 	if error != nil {
 		return nil, error
 	}
 
-	updateExpression := "SET modifiedAt = :modifiedAt"
+	if isMacHomeGuardId(id) {
+		return nil, hdError.ErrDeviceNotFound.New()
+	}
+
+	key := map[string]types.AttributeValue{
+		"id": &types.AttributeValueMemberS{Value: id},
+	}
+
+	expressionAttributeValues := map[string]types.AttributeValue{
+		":seenAt": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", seenAt)},
+		":online": &types.AttributeValueMemberS{Value: constants.DeviceStatusOnline},
+	}
+
+	// the heartbeats of an online device do not change its status
+	_, err := hDDI.DynamoDbApi.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           &tableName,
+		Key:                 key,
+		UpdateExpression:    aws.String("SET lastSeenAt = :seenAt"),
+		ConditionExpression: aws.String("attribute_exists(id) AND attribute_not_exists(deletedAt) AND #status = :online AND (attribute_not_exists(lastSeenAt) OR lastSeenAt < :seenAt)"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues:           expressionAttributeValues,
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+
+	if err == nil {
+		return nil, nil
+	}
+
+	var conditionErr *types.ConditionalCheckFailedException
+	if !errors.As(err, &conditionErr) {
+		log.Printf("Error marking the device %v as seen into DynamoDB: %v", id, err)
+		return nil, hdError.ErrUpdatingDevice.Wrap(err)
+	}
+
+	if conditionErr.Item == nil || isDeletedItem(conditionErr.Item) {
+		return nil, hdError.ErrDeviceNotFound.New()
+	}
+
+	if getStringAttribute(conditionErr.Item, "status") == constants.DeviceStatusOnline {
+		// it was already seen at that time or later
+		return nil, nil
+	}
+
+	transactItems, error := hDDI.withDeviceChange([]types.TransactWriteItem{
+		{
+			Update: &types.Update{
+				TableName:           &tableName,
+				Key:                 key,
+				UpdateExpression:    aws.String("SET lastSeenAt = :seenAt, #status = :online"),
+				ConditionExpression: aws.String("attribute_exists(id) AND attribute_not_exists(deletedAt) AND (attribute_not_exists(#status) OR #status <> :online) AND (attribute_not_exists(lastSeenAt) OR lastSeenAt < :seenAt)"),
+				ExpressionAttributeNames: map[string]string{
+					"#status": "status",
+				},
+				ExpressionAttributeValues:           expressionAttributeValues,
+				ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+			},
+		},
+	}, newStatusChange(ctx, id, constants.DeviceStatusOffline, constants.DeviceStatusOnline))
+	if error != nil {
+		return nil, error
+	}
+
+	if _, err := hDDI.DynamoDbApi.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	}); err != nil {
+
+		if reasons, ok := getCancellationReasons(err); ok && isConditionalCheckFailed(reasons, 0) {
+			if reasons[0].Item == nil || isDeletedItem(reasons[0].Item) {
+				return nil, hdError.ErrDeviceNotFound.New()
+			}
+			// another reading marked it online in the meantime
+			return nil, nil
+		}
+
+		log.Printf("Error marking the device %v as seen into DynamoDB: %v", id, err)
+		return nil, hdError.ErrUpdatingDevice.Wrap(err)
+	}
+
+	device := mapDynamoDBItemToDeviceResponse(conditionErr.Item)
+	device.LastSeenAt = seenAt
+	device.Status = constants.DeviceStatusOnline
+
+	return &device, nil
+}
+
+// ListStaleHomeDevices returns the online devices that were last seen before
+// seenBefore, from the StatusIndex, sorted by the time they were last seen.
+func (hDDI HomeDeviceDaoImpl) ListStaleHomeDevices(ctx context.Context, seenBefore int64) ([]response.HomdeDeviceResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.TableNameHomeDevicesProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	statusIndexName, error := getValuePropertyOrError(constants.StatusIndexNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:              &tableName,
+		IndexName:              &statusIndexName,
+		KeyConditionExpression: aws.String("#status = :online AND lastSeenAt < :seenBefore"),
+		FilterExpression:       aws.String("attribute_not_exists(deletedAt)"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":online":     &types.AttributeValueMemberS{Value: constants.DeviceStatusOnline},
+			":seenBefore": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", seenBefore)},
+		},
+	}
+
+	devices := []response.HomdeDeviceResponse{}
+
+	for {
+		result, err := hDDI.DynamoDbApi.Query(ctx, input)
+		if err != nil {
+			log.Printf("Error listing the devices seen before %v from DynamoDB: %v", seenBefore, err)
+			return nil, hdError.ErrListingDevices.Wrap(err)
+		}
+
+		for _, item := range result.Items {
+			devices = append(devices, mapDynamoDBItemToDeviceResponse(item))
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return devices, nil
+		}
+
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// MarkHomeDeviceOffline marks the device offline when it is still online and
+// was last seen at lastSeenAt.
+func (hDDI HomeDeviceDaoImpl) MarkHomeDeviceOffline(ctx context.Context, id string, lastSeenAt int64) (bool, error) {
+
+	tableName, error := getValuePropertyOrError(constants.TableNameHomeDevicesProperty)
+	if error != nil {
+		return false, error
+	}
+
+	transactItems, error := hDDI.withDeviceChange([]types.TransactWriteItem{
+		{
+			Update: &types.Update{
+				TableName: &tableName,
+				Key: map[string]types.AttributeValue{
+					"id": &types.AttributeValueMemberS{Value: id},
+				},
+				UpdateExpression:    aws.String("SET #status = :offline"),
+				ConditionExpression: aws.String("#status = :online AND lastSeenAt = :lastSeenAt AND attribute_not_exists(deletedAt)"),
+				ExpressionAttributeNames: map[string]string{
+					"#status": "status",
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":online":     &types.AttributeValueMemberS{Value: constants.DeviceStatusOnline},
+					":offline":    &types.AttributeValueMemberS{Value: constants.DeviceStatusOffline},
+					":lastSeenAt": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", lastSeenAt)},
+				},
+			},
+		},
+	}, newStatusChange(ctx, id, constants.DeviceStatusOnline, constants.DeviceStatusOffline))
+	if error != nil {
+		return false, error
+	}
+
+	if _, err := hDDI.DynamoDbApi.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	}); err != nil {
+
+		if reasons, ok := getCancellationReasons(err); ok && isConditionalCheckFailed(reasons, 0) {
+			return false, nil
+		}
+
+		log.Printf("Error marking the device %v as offline into DynamoDB: %v", id, err)
+		return false, hdError.ErrUpdatingDevice.Wrap(err)
+	}
+
+	return true, nil
+}
+
+func resolveListLimit(limit int32) int32 {
+	if limit <= 0 {
+		return constants.DefaultListDevicesLimit
+	}
+
+	if limit > constants.MaxListDevicesLimit {
+		return constants.MaxListDevicesLimit
+	}
+
+	return limit
+}
+
+func buidUpdateInput(device request.UpdateDeviceRequest, id string, expectedVersion int64) (*dynamodb.UpdateItemInput, error) {
+
+	tableName, error := getValuePropertyOrError(constants.TableNameHomeDevicesProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	updateExpression := "SET modifiedAt = :modifiedAt, version = if_not_exists(version, :zero) + :one"
 	expressionAttributeValues := map[string]types.AttributeValue{
 		":modifiedAt": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", time.Now().Unix())},
+		":zero":       &types.AttributeValueMemberN{Value: "0"},
+		":one":        &types.AttributeValueMemberN{Value: "1"},
 	}
 
 	expressionAttributeNames := map[string]string{}
@@ -242,12 +1093,19 @@ func buidUpdateInput(device request.UpdateDeviceRequest, id string) (*dynamodb.U
 		expressionAttributeValues[":homeId"] = &types.AttributeValueMemberS{Value: device.HomeID}
 	}
 
+	if device.RoomID != "" {
+		updateExpression += ", roomId = :roomId"
+		expressionAttributeValues[":roomId"] = &types.AttributeValueMemberS{Value: device.RoomID}
+	}
+
 	updateInput := &dynamodb.UpdateItemInput{
-		TableName:                 &tableName,
-		Key:                       map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}},
-		UpdateExpression:          aws.String(updateExpression),
-		ExpressionAttributeValues: expressionAttributeValues,
-		ConditionExpression:       aws.String("attribute_exists(id)"),
+		TableName:                           &tableName,
+		Key:                                 map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}},
+		UpdateExpression:                    aws.String(updateExpression),
+		ExpressionAttributeValues:           expressionAttributeValues,
+		ConditionExpression:                 aws.String("attribute_exists(id) AND attribute_not_exists(deletedAt)" + buildVersionCondition(expectedVersion, expressionAttributeValues)),
+		ReturnValues:                        types.ReturnValueAllNew,
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
 	}
 
 	if len(expressionAttributeNames) > 0 {
@@ -257,14 +1115,84 @@ func buidUpdateInput(device request.UpdateDeviceRequest, id string) (*dynamodb.U
 	return updateInput, nil
 }
 
-func getValuePropertyOrError(fieldName string) (string, *hdError.HomeDeviceError) {
+// buildVersionCondition returns the condition that makes a write fail when the
+// stored version is not the expected one.
+func buildVersionCondition(expectedVersion int64, expressionAttributeValues map[string]types.AttributeValue) string {
+	if expectedVersion <= 0 {
+		return ""
+	}
+
+	expressionAttributeValues[":expectedVersion"] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", expectedVersion)}
+	return " AND version = :expectedVersion"
+}
+
+// resolveConditionVersion returns the version a write of the current item is
+// conditioned on. A write recorded in the history is always made against the
+// version that was read, so its change record is exact.
+func (hDDI HomeDeviceDaoImpl) resolveConditionVersion(expectedVersion int64, current map[string]types.AttributeValue) int64 {
+	if expectedVersion <= 0 && hDDI.RecordHistory {
+		return getInt64Attribute(current, "version")
+	}
+	return expectedVersion
+}
+
+func checkExpectedVersion(item map[string]types.AttributeValue, expectedVersion int64) error {
+	if expectedVersion > 0 && getInt64Attribute(item, "version") != expectedVersion {
+		return hdError.ErrVersionConflict.New()
+	}
+	return nil
+}
+
+func getUpdateError(err error) error {
+
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return getConditionalCheckFailedError(conditionErr.Item)
+	}
+
+	return hdError.ErrUpdatingDevice.Wrap(err)
+}
+
+// getConditionalCheckFailedError tells a missing or deleted device apart from
+// a device that was modified by someone else using the item returned by the
+// failed condition.
+func getConditionalCheckFailedError(item map[string]types.AttributeValue) error {
+
+	if item == nil || isDeletedItem(item) {
+		return hdError.ErrDeviceNotFound.New()
+	}
+
+	return hdError.ErrVersionConflict.New()
+}
+
+// getRestoreConditionalCheckFailedError is the counterpart of
+// getConditionalCheckFailedError for restores, where the device is expected
+// to be deleted.
+func getRestoreConditionalCheckFailedError(item map[string]types.AttributeValue) error {
+
+	if item == nil {
+		return hdError.ErrDeviceNotFound.New()
+	}
+
+	if !isDeletedItem(item) {
+		return hdError.ErrDeviceNotDeleted.New()
+	}
+
+	return hdError.ErrVersionConflict.New()
+}
+
+func resolveValue(value string, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+func getValuePropertyOrError(fieldName string) (string, error) {
 	value, error := utils.GetValueProperty(fieldName)
 
 	if error != nil {
-		return "", &hdError.HomeDeviceError{
-			ErrorCode:    constants.ErrGettingConfigCode,
-			ErrorMessage: constants.ErrGettingConfigMessage,
-		}
+		return "", hdError.ErrGettingConfig.Wrap(error)
 	}
 
 	return value, nil
@@ -277,8 +1205,12 @@ func mapDynamoDBItemToDeviceResponse(item map[string]types.AttributeValue) respo
 		Name:       getStringAttribute(item, "name"),
 		Type:       getStringAttribute(item, "type"),
 		HomeID:     getStringAttribute(item, "homeId"),
+		RoomID:     getStringAttribute(item, "roomId"),
 		CreatedAt:  getInt64Attribute(item, "createdAt"),
 		ModifiedAt: getInt64Attribute(item, "modifiedAt"),
+		Version:    getInt64Attribute(item, "version"),
+		Status:     resolveValue(getStringAttribute(item, "status"), constants.DeviceStatusOffline),
+		LastSeenAt: getInt64Attribute(item, "lastSeenAt"),
 	}
 }
 
@@ -0,0 +1,15 @@
+package dao_test
+
+import (
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+	"github.com/odhoman/home-devices/internal/dao/daotest"
+	"github.com/odhoman/home-devices/internal/mock"
+)
+
+func TestHomeDeviceDaoImpl_Conformance(t *testing.T) {
+	daotest.RunHomeDeviceDaoConformanceSuite(t, func() dao.HomeDeviceDao {
+		return dao.HomeDeviceDaoImpl{DynamoDbApi: mock.GetDynamoConnectionTestFromEnpoint()}
+	})
+}
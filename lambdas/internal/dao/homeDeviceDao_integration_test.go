@@ -3,9 +3,11 @@ package dao
 import (
 	"context"
 	"fmt"
+	"sync"
 	"testing"
 
-	hDConstants "github.com/odhoman/home-devices/internal/constants"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
 	hdError "github.com/odhoman/home-devices/internal/error"
 	"github.com/odhoman/home-devices/internal/mock"
 	hDRequest "github.com/odhoman/home-devices/internal/request"
@@ -30,14 +32,14 @@ func TestIsDeviceExist_Success(t *testing.T) {
 	response, err := executeSaveHomeDevice(ctx, request, homeDeviceDaoImpl)
 
 	if err != nil {
-		fmt.Println(err.ErrorCode)
+		fmt.Println(err)
 		return
 	}
 
 	IsDeviceExist, err := homeDeviceDaoImpl.IsDeviceExist(ctx, response.MAC, response.HomeID)
 
 	if err != nil {
-		t.Fatalf("expected a bool but got an error %v", err.ErrorCode)
+		t.Fatalf("expected a bool but got an error %v", err)
 	}
 
 	assert.True(t, IsDeviceExist)
@@ -52,7 +54,7 @@ func TestIsDeviceExist_False_Success(t *testing.T) {
 	IsDeviceExist, err := homeDeviceServiceImpl.IsDeviceExist(ctx, "AB:CD:EF:01:23:45", "home412")
 
 	if err != nil {
-		t.Fatalf("expected a bool but got an error %v", err.ErrorCode)
+		t.Fatalf("expected a bool but got an error %v", err)
 	}
 
 	assert.False(t, IsDeviceExist)
@@ -70,7 +72,7 @@ func TestSaveHomeDevice_Success(t *testing.T) {
 	response, err := executeSaveHomeDevice(context.TODO(), request, homeDeviceServiceImpl)
 
 	if err != nil {
-		t.Fatalf("expected a new home device but got an error %v", err.ErrorCode)
+		t.Fatalf("expected a new home device but got an error %v", err)
 	}
 
 	assert.Equal(t, request.HomeID, response.HomeID)
@@ -90,14 +92,14 @@ func TestGetHomeDevice_Success(t *testing.T) {
 	response, err := executeSaveHomeDevice(ctx, request, homeDeviceServiceImpl)
 
 	if err != nil {
-		fmt.Println(err.ErrorCode)
+		fmt.Println(err)
 		return
 	}
 
 	getHomeDeviceResponse, err := homeDeviceServiceImpl.GetHomeDevice(ctx, response.ID)
 
 	if err != nil {
-		t.Fatalf("expected a home device but got an error %v", err.ErrorCode)
+		t.Fatalf("expected a home device but got an error %v", err)
 	}
 
 	assert.Equal(t, getHomeDeviceResponse.HomeID, response.HomeID)
@@ -119,7 +121,7 @@ func TestGetHomeDevice_NoExist(t *testing.T) {
 	}
 
 	assert.NotNil(t, err)
-	assert.Equal(t, hDConstants.ErrDeviceNotFoundCode, err.ErrorCode)
+	assert.ErrorIs(t, err, hdError.ErrDeviceNotFound)
 }
 
 func TestUpdateHomeDevice_Success(t *testing.T) {
@@ -144,11 +146,11 @@ func TestUpdateHomeDevice_Success(t *testing.T) {
 	response, err := homeDeviceServiceImpl.SaveHomeDevice(context.Background(), request)
 
 	if err != nil {
-		t.Fatalf("expected a new home device, testing TestUpdateHomeDevice_Success but got an error %v", err.ErrorCode)
+		t.Fatalf("expected a new home device, testing TestUpdateHomeDevice_Success but got an error %v", err)
 	}
 
-	if err := homeDeviceServiceImpl.UpdateHomeDevice(context.Background(), updateRequest, response.ID); err != nil {
-		t.Fatalf("expecting update a home device , testing TestUpdateHomeDevice_Success but got an error %v", err.ErrorCode)
+	if _, err := homeDeviceServiceImpl.UpdateHomeDevice(context.Background(), updateRequest, response.ID, 0); err != nil {
+		t.Fatalf("expecting update a home device , testing TestUpdateHomeDevice_Success but got an error %v", err)
 	}
 
 	assert.Nil(t, err)
@@ -164,13 +166,13 @@ func TestUpdateHomeDevice_NoExist(t *testing.T) {
 	}
 
 	homeDeviceServiceImpl := createHomeDeviceDaoImpl()
-	err := homeDeviceServiceImpl.UpdateHomeDevice(context.Background(), updateRequest, "fakeID")
+	_, err := homeDeviceServiceImpl.UpdateHomeDevice(context.Background(), updateRequest, "fakeID", 0)
 
 	if err == nil {
 		t.Fatal("expected an error when updating a non-existent home device, but got nil")
 	}
 
-	assert.Equal(t, hDConstants.ErrDeviceNotFoundCode, err.ErrorCode)
+	assert.ErrorIs(t, err, hdError.ErrDeviceNotFound)
 }
 
 func TestDeleteHomeDevice_Success(t *testing.T) {
@@ -187,11 +189,11 @@ func TestDeleteHomeDevice_Success(t *testing.T) {
 	response, err := executeSaveHomeDevice(ctx, request, homeDeviceServiceImpl)
 
 	if err != nil {
-		t.Fatalf("expected a nil error creating a new device to test TestDeleteHomeDevice_Success, but got %v", err.ErrorCode)
+		t.Fatalf("expected a nil error creating a new device to test TestDeleteHomeDevice_Success, but got %v", err)
 	}
 
-	if err := homeDeviceServiceImpl.DeleteHomeDevice(context.Background(), response.ID); err != nil {
-		t.Fatalf("expected a nil error when deleting a home device, but got %v", err.ErrorCode)
+	if _, err := homeDeviceServiceImpl.DeleteHomeDevice(context.Background(), response.ID, 0); err != nil {
+		t.Fatalf("expected a nil error when deleting a home device, but got %v", err)
 	}
 
 }
@@ -199,14 +201,386 @@ func TestDeleteHomeDevice_Success(t *testing.T) {
 func TestDeleteHomeDevice_NoExist(t *testing.T) {
 
 	homeDeviceServiceImpl := createHomeDeviceDaoImpl()
-	err := homeDeviceServiceImpl.DeleteHomeDevice(context.Background(), "fakeID")
+	_, err := homeDeviceServiceImpl.DeleteHomeDevice(context.Background(), "fakeID", 0)
 
 	if err == nil {
 		t.Fatal("expected an error when deleting a non-existent home device, but got nil")
 	}
 
-	assert.Equal(t, hDConstants.ErrDeviceNotFoundCode, err.ErrorCode)
+	assert.ErrorIs(t, err, hdError.ErrDeviceNotFound)
+
+}
+
+func TestSaveHomeDevice_AlreadyExists(t *testing.T) {
+
+	request := hDRequest.CreateDeviceRequest{
+		MAC:    "30:1A:2B:3C:4D:01",
+		Name:   "Living Room Light",
+		Type:   "light",
+		HomeID: "homeGuard",
+	}
+	ctx := context.Background()
+	homeDeviceDaoImpl := createHomeDeviceDaoImpl()
+
+	if _, err := executeSaveHomeDevice(ctx, request, homeDeviceDaoImpl); err != nil {
+		t.Fatalf("expected a new home device but got an error %v", err)
+	}
+
+	_, err := executeSaveHomeDevice(ctx, request, homeDeviceDaoImpl)
+
+	if err == nil {
+		t.Fatal("expected an error when saving a duplicated home device, but got nil")
+	}
+
+	assert.ErrorIs(t, err, hdError.ErrDeviceAlreadyExists)
+}
+
+func TestSaveHomeDevice_ConcurrentRequests(t *testing.T) {
+
+	request := hDRequest.CreateDeviceRequest{
+		MAC:    "30:1A:2B:3C:4D:02",
+		Name:   "Living Room Light",
+		Type:   "light",
+		HomeID: "homeGuard",
+	}
+	ctx := context.Background()
+	homeDeviceDaoImpl := createHomeDeviceDaoImpl()
+
+	const requests = 5
+	errs := make(chan error, requests)
+
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := executeSaveHomeDevice(ctx, request, homeDeviceDaoImpl)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	created := 0
+	for err := range errs {
+		if err == nil {
+			created++
+		}
+	}
+
+	assert.Equal(t, 1, created)
+}
+
+func TestUpdateHomeDevice_MacAlreadyExists(t *testing.T) {
+
+	ctx := context.Background()
+	homeDeviceDaoImpl := createHomeDeviceDaoImpl()
+
+	first, err := executeSaveHomeDevice(ctx, hDRequest.CreateDeviceRequest{MAC: "30:1A:2B:3C:4D:03", Name: "Living Room Light", Type: "light", HomeID: "homeGuard"}, homeDeviceDaoImpl)
+	if err != nil {
+		t.Fatalf("expected a new home device but got an error %v", err)
+	}
+
+	second, err := executeSaveHomeDevice(ctx, hDRequest.CreateDeviceRequest{MAC: "30:1A:2B:3C:4D:04", Name: "Living Room Light", Type: "light", HomeID: "homeGuard"}, homeDeviceDaoImpl)
+	if err != nil {
+		t.Fatalf("expected a new home device but got an error %v", err)
+	}
+
+	_, updateErr := homeDeviceDaoImpl.UpdateHomeDevice(ctx, hDRequest.UpdateDeviceRequest{MAC: first.MAC}, second.ID, 0)
+
+	if updateErr == nil {
+		t.Fatal("expected an error when updating to a duplicated mac, but got nil")
+	}
+
+	assert.ErrorIs(t, updateErr, hdError.ErrDeviceAlreadyExists)
+}
+
+func TestUpdateHomeDevice_ReleasesPreviousMac(t *testing.T) {
+
+	request := hDRequest.CreateDeviceRequest{
+		MAC:    "30:1A:2B:3C:4D:05",
+		Name:   "Living Room Light",
+		Type:   "light",
+		HomeID: "homeGuard",
+	}
+	ctx := context.Background()
+	homeDeviceDaoImpl := createHomeDeviceDaoImpl()
+
+	response, err := executeSaveHomeDevice(ctx, request, homeDeviceDaoImpl)
+	if err != nil {
+		t.Fatalf("expected a new home device but got an error %v", err)
+	}
+
+	if _, err := homeDeviceDaoImpl.UpdateHomeDevice(ctx, hDRequest.UpdateDeviceRequest{HomeID: "homeGuardMoved"}, response.ID, 0); err != nil {
+		t.Fatalf("expecting update a home device but got an error %v", err)
+	}
+
+	_, err = executeSaveHomeDevice(ctx, request, homeDeviceDaoImpl)
+
+	assert.Nil(t, err)
+}
+
+func TestDeleteHomeDevice_ReleasesMac(t *testing.T) {
+
+	request := hDRequest.CreateDeviceRequest{
+		MAC:    "30:1A:2B:3C:4D:06",
+		Name:   "Living Room Light",
+		Type:   "light",
+		HomeID: "homeGuard",
+	}
+	ctx := context.Background()
+	homeDeviceDaoImpl := createHomeDeviceDaoImpl()
+
+	response, err := executeSaveHomeDevice(ctx, request, homeDeviceDaoImpl)
+	if err != nil {
+		t.Fatalf("expected a new home device but got an error %v", err)
+	}
+
+	if _, err := homeDeviceDaoImpl.DeleteHomeDevice(ctx, response.ID, 0); err != nil {
+		t.Fatalf("expected a nil error when deleting a home device, but got %v", err)
+	}
+
+	_, err = executeSaveHomeDevice(ctx, request, homeDeviceDaoImpl)
+
+	assert.Nil(t, err)
+}
+
+func TestDeleteHomeDevice_SoftDeletesWithTTL(t *testing.T) {
+
+	request := hDRequest.CreateDeviceRequest{
+		MAC:    "30:1A:2B:3C:4D:16",
+		Name:   "Living Room Light",
+		Type:   "light",
+		HomeID: "homeSoftDelete",
+	}
+	ctx := context.Background()
+	homeDeviceDaoImpl := createHomeDeviceDaoImpl()
+
+	response, err := executeSaveHomeDevice(ctx, request, homeDeviceDaoImpl)
+	if err != nil {
+		t.Fatalf("expected a new home device but got an error %v", err)
+	}
+
+	if _, err := homeDeviceDaoImpl.DeleteHomeDevice(ctx, response.ID, 0); err != nil {
+		t.Fatalf("expected a nil error when deleting a home device, but got %v", err)
+	}
+
+	item, err := homeDeviceDaoImpl.getDeviceItem(ctx, "HomeDevices", response.ID, true)
+	if err != nil {
+		t.Fatalf("expected the soft deleted item but got an error %v", err)
+	}
+
+	deletedAt := getInt64Attribute(item, "deletedAt")
+	expiresAt := getInt64Attribute(item, "expiresAt")
+
+	assert.NotZero(t, deletedAt)
+	assert.Equal(t, deletedAt+int64(getDeletedDeviceRetention().Seconds()), expiresAt)
+	assert.Equal(t, response.Version+1, getInt64Attribute(item, "version"))
+}
+
+func TestGetHomeDevice_GuardItemIsNotADevice(t *testing.T) {
+
+	request := hDRequest.CreateDeviceRequest{
+		MAC:    "30:1A:2B:3C:4D:07",
+		Name:   "Living Room Light",
+		Type:   "light",
+		HomeID: "homeGuard",
+	}
+	ctx := context.Background()
+	homeDeviceDaoImpl := createHomeDeviceDaoImpl()
+
+	if _, err := executeSaveHomeDevice(ctx, request, homeDeviceDaoImpl); err != nil {
+		t.Fatalf("expected a new home device but got an error %v", err)
+	}
+
+	_, err := homeDeviceDaoImpl.GetHomeDevice(ctx, buildMacHomeGuardId(request.MAC, request.HomeID))
+
+	if err == nil {
+		t.Fatal("expected an error when getting a guard item, but got nil")
+	}
+
+	assert.ErrorIs(t, err, hdError.ErrDeviceNotFound)
+}
+
+func TestUpdateHomeDevice_IncrementsVersion(t *testing.T) {
+
+	request := hDRequest.CreateDeviceRequest{
+		MAC:    "40:1A:2B:3C:4D:01",
+		Name:   "Living Room Light",
+		Type:   "light",
+		HomeID: "homeVersion",
+	}
+	ctx := context.Background()
+	homeDeviceDaoImpl := createHomeDeviceDaoImpl()
+
+	response, err := executeSaveHomeDevice(ctx, request, homeDeviceDaoImpl)
+	if err != nil {
+		t.Fatalf("expected a new home device but got an error %v", err)
+	}
+
+	assert.Equal(t, int64(1), response.Version)
+
+	if _, err := homeDeviceDaoImpl.UpdateHomeDevice(ctx, hDRequest.UpdateDeviceRequest{Name: "Kitchen Light"}, response.ID, 1); err != nil {
+		t.Fatalf("expecting update a home device but got an error %v", err)
+	}
+
+	if _, err := homeDeviceDaoImpl.UpdateHomeDevice(ctx, hDRequest.UpdateDeviceRequest{HomeID: "homeVersionMoved"}, response.ID, 2); err != nil {
+		t.Fatalf("expecting update a home device but got an error %v", err)
+	}
+
+	device, err := homeDeviceDaoImpl.GetHomeDevice(ctx, response.ID)
+	if err != nil {
+		t.Fatalf("expected a home device but got an error %v", err)
+	}
+
+	assert.Equal(t, int64(3), device.Version)
+	assert.Equal(t, "Kitchen Light", device.Name)
+	assert.Equal(t, "homeVersionMoved", device.HomeID)
+}
+
+func TestUpdateHomeDevice_VersionConflict(t *testing.T) {
+
+	request := hDRequest.CreateDeviceRequest{
+		MAC:    "40:1A:2B:3C:4D:02",
+		Name:   "Living Room Light",
+		Type:   "light",
+		HomeID: "homeVersion",
+	}
+	ctx := context.Background()
+	homeDeviceDaoImpl := createHomeDeviceDaoImpl()
+
+	response, err := executeSaveHomeDevice(ctx, request, homeDeviceDaoImpl)
+	if err != nil {
+		t.Fatalf("expected a new home device but got an error %v", err)
+	}
+
+	if _, err := homeDeviceDaoImpl.UpdateHomeDevice(ctx, hDRequest.UpdateDeviceRequest{Name: "Kitchen Light"}, response.ID, 1); err != nil {
+		t.Fatalf("expecting update a home device but got an error %v", err)
+	}
+
+	_, err = homeDeviceDaoImpl.UpdateHomeDevice(ctx, hDRequest.UpdateDeviceRequest{Name: "Bedroom Light"}, response.ID, 1)
+	if err == nil {
+		t.Fatal("expected an error when updating with a stale version, but got nil")
+	}
+	assert.ErrorIs(t, err, hdError.ErrVersionConflict)
+
+	_, err = homeDeviceDaoImpl.UpdateHomeDevice(ctx, hDRequest.UpdateDeviceRequest{MAC: "40:1A:2B:3C:4D:03"}, response.ID, 1)
+	if err == nil {
+		t.Fatal("expected an error when updating the mac with a stale version, but got nil")
+	}
+	assert.ErrorIs(t, err, hdError.ErrVersionConflict)
+}
+
+func TestDeleteHomeDevice_VersionConflict(t *testing.T) {
+
+	request := hDRequest.CreateDeviceRequest{
+		MAC:    "40:1A:2B:3C:4D:04",
+		Name:   "Living Room Light",
+		Type:   "light",
+		HomeID: "homeVersion",
+	}
+	ctx := context.Background()
+	homeDeviceDaoImpl := createHomeDeviceDaoImpl()
+
+	response, err := executeSaveHomeDevice(ctx, request, homeDeviceDaoImpl)
+	if err != nil {
+		t.Fatalf("expected a new home device but got an error %v", err)
+	}
+
+	_, err = homeDeviceDaoImpl.DeleteHomeDevice(ctx, response.ID, 2)
+	if err == nil {
+		t.Fatal("expected an error when deleting with a stale version, but got nil")
+	}
+	assert.ErrorIs(t, err, hdError.ErrVersionConflict)
+
+	_, err = homeDeviceDaoImpl.DeleteHomeDevice(ctx, response.ID, 1)
+	assert.Nil(t, err)
+}
+
+func TestListHomeDevices_Success(t *testing.T) {
+
+	ctx := context.Background()
+	homeDeviceDaoImpl := createHomeDeviceDaoImpl()
+
+	macs := []string{"20:1A:2B:3C:4D:01", "20:1A:2B:3C:4D:02", "20:1A:2B:3C:4D:03"}
+	for _, mac := range macs {
+		if _, err := executeSaveHomeDevice(ctx, hDRequest.CreateDeviceRequest{
+			MAC:    mac,
+			Name:   "Living Room Light",
+			Type:   "light",
+			HomeID: "homeListDao",
+		}, homeDeviceDaoImpl); err != nil {
+			t.Fatalf("expected a new home device but got an error %v", err)
+		}
+	}
+
+	firstPage, err := homeDeviceDaoImpl.ListHomeDevices(ctx, "homeListDao", "", "", 2, "")
+
+	if err != nil {
+		t.Fatalf("expected a list of home devices but got an error %v", err)
+	}
+
+	assert.Len(t, firstPage.Devices, 2)
+	assert.NotEmpty(t, firstPage.NextCursor)
+
+	secondPage, err := homeDeviceDaoImpl.ListHomeDevices(ctx, "homeListDao", "", "", 2, firstPage.NextCursor)
+
+	if err != nil {
+		t.Fatalf("expected a list of home devices but got an error %v", err)
+	}
+
+	assert.Len(t, secondPage.Devices, 1)
+	assert.Empty(t, secondPage.NextCursor)
+	assert.NotEqual(t, firstPage.Devices[0].ID, secondPage.Devices[0].ID)
+	assert.NotEqual(t, firstPage.Devices[1].ID, secondPage.Devices[0].ID)
+}
+
+func TestListHomeDevices_Empty(t *testing.T) {
+
+	homeDeviceDaoImpl := createHomeDeviceDaoImpl()
+
+	response, err := homeDeviceDaoImpl.ListHomeDevices(context.Background(), "homeWithoutDevices", "", "", 0, "")
+
+	if err != nil {
+		t.Fatalf("expected an empty list of home devices but got an error %v", err)
+	}
+
+	assert.Empty(t, response.Devices)
+	assert.Empty(t, response.NextCursor)
+}
+
+func TestListHomeDevices_InvalidCursor(t *testing.T) {
+
+	homeDeviceDaoImpl := createHomeDeviceDaoImpl()
+
+	_, err := homeDeviceDaoImpl.ListHomeDevices(context.Background(), "homeListDao", "", "", 2, "wrongCursor")
+
+	if err == nil {
+		t.Fatal("expected an error when listing with an invalid cursor, but got nil")
+	}
+
+	assert.ErrorIs(t, err, hdError.ErrInvalidCursor)
+}
+
+func TestListHomeDevices_CursorFromAnotherHome(t *testing.T) {
+
+	cursor, err := encodeCursor(map[string]types.AttributeValue{
+		"id":        &types.AttributeValueMemberS{Value: "id"},
+		"homeId":    &types.AttributeValueMemberS{Value: "anotherHome"},
+		"createdAt": &types.AttributeValueMemberN{Value: "1725940243"},
+	})
+
+	if err != nil {
+		t.Fatalf("expected a cursor but got an error %v", err)
+	}
+
+	_, listErr := createHomeDeviceDaoImpl().ListHomeDevices(context.Background(), "homeListDao", "", "", 2, cursor)
+
+	if listErr == nil {
+		t.Fatal("expected an error when listing with a cursor from another home, but got nil")
+	}
 
+	assert.ErrorIs(t, listErr, hdError.ErrInvalidCursor)
 }
 
 func createHomeDeviceDaoImpl() HomeDeviceDaoImpl {
@@ -214,6 +588,6 @@ func createHomeDeviceDaoImpl() HomeDeviceDaoImpl {
 	return HomeDeviceDaoImpl{DynamoDbApi: svc}
 }
 
-func executeSaveHomeDevice(ctx context.Context, request hDRequest.CreateDeviceRequest, homeDeviceServiceImpl HomeDeviceDaoImpl) (*hDResponse.HomdeDeviceResponse, *hdError.HomeDeviceError) {
+func executeSaveHomeDevice(ctx context.Context, request hDRequest.CreateDeviceRequest, homeDeviceServiceImpl HomeDeviceDaoImpl) (*hDResponse.HomdeDeviceResponse, error) {
 	return homeDeviceServiceImpl.SaveHomeDevice(ctx, request)
 }
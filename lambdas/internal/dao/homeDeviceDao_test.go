@@ -0,0 +1,156 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	hdAudit "github.com/odhoman/home-devices/internal/audit"
+	constants "github.com/odhoman/home-devices/internal/constants"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	request "github.com/odhoman/home-devices/internal/request"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// transactionRecorder answers GetItem with item and keeps the last
+// TransactWriteItems input.
+type transactionRecorder struct {
+	dynamoDbApi
+	item  map[string]types.AttributeValue
+	input *dynamodb.TransactWriteItemsInput
+	err   error
+}
+
+func (tR *transactionRecorder) GetItem(ctx context.Context, input *dynamodb.GetItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{Item: tR.item}, nil
+}
+
+func (tR *transactionRecorder) TransactWriteItems(ctx context.Context, input *dynamodb.TransactWriteItemsInput, opts ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	tR.input = input
+	return &dynamodb.TransactWriteItemsOutput{}, tR.err
+}
+
+func newTransactionRecorder() *transactionRecorder {
+	return &transactionRecorder{item: map[string]types.AttributeValue{
+		"id":      &types.AttributeValueMemberS{Value: "device123"},
+		"mac":     &types.AttributeValueMemberS{Value: "00:11:22:33:44:55"},
+		"name":    &types.AttributeValueMemberS{Value: "Light"},
+		"type":    &types.AttributeValueMemberS{Value: "light"},
+		"homeId":  &types.AttributeValueMemberS{Value: "home123"},
+		"version": &types.AttributeValueMemberN{Value: "3"},
+	}}
+}
+
+func setHomeDeviceTables(t *testing.T) {
+	os.Setenv(constants.TableNameHomeDevicesProperty, "devicesTable")
+	os.Setenv(constants.DeviceHistoryTableNameProperty, "historyTable")
+	t.Cleanup(func() {
+		os.Unsetenv(constants.TableNameHomeDevicesProperty)
+		os.Unsetenv(constants.DeviceHistoryTableNameProperty)
+	})
+}
+
+func TestUpdateHomeDevice_RecordsTheChangeInTheTransaction(t *testing.T) {
+
+	setHomeDeviceTables(t)
+
+	recorder := newTransactionRecorder()
+	ctx := hdAudit.WithSource(hdAudit.WithActor(context.Background(), "user-1"), hdAudit.SourceAPI)
+
+	updated, err := HomeDeviceDaoImpl{DynamoDbApi: recorder, RecordHistory: true}.UpdateHomeDevice(ctx, request.UpdateDeviceRequest{Name: "Kitchen Light"}, "device123", 0)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Kitchen Light", updated.Name)
+	assert.Equal(t, int64(4), updated.Version)
+
+	items := recorder.input.TransactItems
+	assert.Len(t, items, 2)
+
+	// the update is made against the version that was read
+	assert.Contains(t, *items[0].Update.ConditionExpression, "version = :expectedVersion")
+	assert.Equal(t, "3", items[0].Update.ExpressionAttributeValues[":expectedVersion"].(*types.AttributeValueMemberN).Value)
+
+	assert.Equal(t, "historyTable", *items[1].Put.TableName)
+
+	change := mapDynamoDBItemToDeviceChange(items[1].Put.Item)
+	assert.Equal(t, "device123", change.DeviceID)
+	assert.Equal(t, hdAudit.OperationUpdate, change.Operation)
+	assert.Equal(t, "user-1", change.Actor)
+	assert.Equal(t, hdAudit.SourceAPI, change.Source)
+	assert.Equal(t, int64(4), change.Version)
+	assert.Len(t, change.Changes, 1)
+	assert.Equal(t, "Kitchen Light", change.Changes[0].After)
+}
+
+func TestDeleteHomeDevice_FailedTransactionIsReturned(t *testing.T) {
+
+	setHomeDeviceTables(t)
+
+	recorder := newTransactionRecorder()
+	recorder.err = errors.New("the history table is not available")
+
+	_, err := HomeDeviceDaoImpl{DynamoDbApi: recorder, RecordHistory: true}.DeleteHomeDevice(context.Background(), "device123", 0)
+
+	assert.ErrorIs(t, err, hdError.ErrDeletingDevice)
+	assert.Len(t, recorder.input.TransactItems, 3)
+	assert.Equal(t, "historyTable", *recorder.input.TransactItems[2].Put.TableName)
+}
+
+func TestMarkHomeDeviceOffline_WithoutHistory(t *testing.T) {
+
+	setHomeDeviceTables(t)
+
+	recorder := newTransactionRecorder()
+
+	wentOffline, err := HomeDeviceDaoImpl{DynamoDbApi: recorder}.MarkHomeDeviceOffline(context.Background(), "device123", 1729000000)
+
+	assert.Nil(t, err)
+	assert.True(t, wentOffline)
+	assert.Len(t, recorder.input.TransactItems, 1)
+}
+
+func TestUpdateHomeDevice_MovesTheDeviceBetweenRoomsInTheTransaction(t *testing.T) {
+
+	setHomeDeviceTables(t)
+	os.Setenv(constants.RoomTableNameProperty, "roomTable")
+	defer os.Unsetenv(constants.RoomTableNameProperty)
+
+	recorder := newTransactionRecorder()
+	recorder.item["roomId"] = &types.AttributeValueMemberS{Value: "room1"}
+
+	_, err := HomeDeviceDaoImpl{DynamoDbApi: recorder, CountRoomDevices: true}.UpdateHomeDevice(context.Background(), request.UpdateDeviceRequest{RoomID: "room2"}, "device123", 0)
+
+	assert.Nil(t, err)
+
+	items := recorder.input.TransactItems
+	assert.Len(t, items, 3)
+
+	for i, expected := range []struct{ roomId, delta string }{{"room1", "-1"}, {"room2", "1"}} {
+		assert.Equal(t, "roomTable", *items[i+1].Update.TableName)
+		assert.Equal(t, "home123", items[i+1].Update.Key["homeId"].(*types.AttributeValueMemberS).Value)
+		assert.Equal(t, expected.roomId, items[i+1].Update.Key["id"].(*types.AttributeValueMemberS).Value)
+		assert.Equal(t, expected.delta, items[i+1].Update.ExpressionAttributeValues[":delta"].(*types.AttributeValueMemberN).Value)
+	}
+}
+
+func TestUpdateHomeDevice_DeletedRoomIsUnknown(t *testing.T) {
+
+	setHomeDeviceTables(t)
+	os.Setenv(constants.RoomTableNameProperty, "roomTable")
+	defer os.Unsetenv(constants.RoomTableNameProperty)
+
+	recorder := newTransactionRecorder()
+	recorder.err = &types.TransactionCanceledException{CancellationReasons: []types.CancellationReason{
+		{Code: aws.String("None")},
+		{Code: aws.String(conditionalCheckFailedCode)},
+	}}
+
+	_, err := HomeDeviceDaoImpl{DynamoDbApi: recorder, CountRoomDevices: true}.UpdateHomeDevice(context.Background(), request.UpdateDeviceRequest{RoomID: "room2"}, "device123", 0)
+
+	assert.ErrorIs(t, err, hdError.ErrUnknownRoom)
+}
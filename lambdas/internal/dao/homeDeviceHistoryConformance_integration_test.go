@@ -0,0 +1,16 @@
+package dao_test
+
+import (
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+	"github.com/odhoman/home-devices/internal/dao/daotest"
+	"github.com/odhoman/home-devices/internal/mock"
+)
+
+func TestHomeDeviceDaoImpl_HistoryConformance(t *testing.T) {
+	daotest.RunHomeDeviceHistoryConformanceSuite(t, func() (dao.HomeDeviceDao, dao.DeviceHistoryDao) {
+		dynamoDbApi := mock.GetDynamoConnectionTestFromEnpoint()
+		return dao.HomeDeviceDaoImpl{DynamoDbApi: dynamoDbApi, RecordHistory: true}, dao.DeviceHistoryDaoImpl{DynamoDbApi: dynamoDbApi}
+	})
+}
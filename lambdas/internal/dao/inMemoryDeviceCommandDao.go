@@ -0,0 +1,100 @@
+package dao
+
+import (
+	"context"
+	"sync"
+
+	constants "github.com/odhoman/home-devices/internal/constants"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+)
+
+// InMemoryDeviceCommandDao is a thread safe DeviceCommandDao that keeps the
+// commands in memory, for tests and local runs.
+type InMemoryDeviceCommandDao struct {
+	mutex    sync.RWMutex
+	commands map[string]response.DeviceCommandResponse
+}
+
+func NewInMemoryDeviceCommandDao() *InMemoryDeviceCommandDao {
+	return &InMemoryDeviceCommandDao{
+		commands: map[string]response.DeviceCommandResponse{},
+	}
+}
+
+func (iMDCD *InMemoryDeviceCommandDao) SaveDeviceCommand(ctx context.Context, command response.DeviceCommandResponse) (*response.DeviceCommandResponse, error) {
+
+	params, err := copyState(command.Params)
+	if err != nil {
+		return nil, hdError.ErrDeviceCommandNotCreated.Wrap(err)
+	}
+	command.Params = params
+
+	iMDCD.mutex.Lock()
+	defer iMDCD.mutex.Unlock()
+
+	key := deviceCommandKey(command.DeviceID, command.ID)
+	if _, exists := iMDCD.commands[key]; exists {
+		return nil, hdError.ErrDeviceCommandNotCreated.New()
+	}
+
+	iMDCD.commands[key] = command
+
+	return iMDCD.copyCommand(key)
+}
+
+func (iMDCD *InMemoryDeviceCommandDao) GetDeviceCommand(ctx context.Context, deviceId string, id string) (*response.DeviceCommandResponse, error) {
+
+	iMDCD.mutex.RLock()
+	defer iMDCD.mutex.RUnlock()
+
+	key := deviceCommandKey(deviceId, id)
+	if _, exists := iMDCD.commands[key]; !exists {
+		return nil, hdError.ErrDeviceCommandNotFound.New()
+	}
+
+	return iMDCD.copyCommand(key)
+}
+
+func (iMDCD *InMemoryDeviceCommandDao) CompleteDeviceCommand(ctx context.Context, deviceId string, id string, update request.DeviceCommandStatusUpdate) (*response.DeviceCommandResponse, error) {
+
+	iMDCD.mutex.Lock()
+	defer iMDCD.mutex.Unlock()
+
+	key := deviceCommandKey(deviceId, id)
+	current, exists := iMDCD.commands[key]
+	if !exists {
+		return nil, hdError.ErrDeviceCommandNotFound.New()
+	}
+
+	if current.Status != constants.CommandStatusPending {
+		return nil, hdError.ErrDeviceCommandCompleted.New()
+	}
+
+	current.Status = update.Status
+	current.Error = resolveValue(update.Error, current.Error)
+	current.CompletedAt = update.CompletedAt
+
+	iMDCD.commands[key] = current
+
+	return iMDCD.copyCommand(key)
+}
+
+// copyCommand must be called holding the mutex.
+func (iMDCD *InMemoryDeviceCommandDao) copyCommand(key string) (*response.DeviceCommandResponse, error) {
+
+	command := iMDCD.commands[key]
+
+	params, err := copyState(command.Params)
+	if err != nil {
+		return nil, hdError.ErrGettingDeviceCommand.Wrap(err)
+	}
+	command.Params = params
+
+	return &command, nil
+}
+
+func deviceCommandKey(deviceId string, id string) string {
+	return deviceId + "#" + id
+}
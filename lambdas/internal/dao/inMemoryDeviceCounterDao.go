@@ -0,0 +1,57 @@
+package dao
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// InMemoryDeviceCounterDao is a thread safe DeviceCounterDao that keeps the
+// counters in memory.
+type InMemoryDeviceCounterDao struct {
+	mutex    sync.RWMutex
+	counters map[string]map[string]int64
+	changes  map[string]bool
+}
+
+func NewInMemoryDeviceCounterDao() *InMemoryDeviceCounterDao {
+	return &InMemoryDeviceCounterDao{
+		counters: map[string]map[string]int64{},
+		changes:  map[string]bool{},
+	}
+}
+
+func (iMDCD *InMemoryDeviceCounterDao) AddDeviceCounts(ctx context.Context, changeId string, deltas []DeviceCountDelta) error {
+
+	iMDCD.mutex.Lock()
+	defer iMDCD.mutex.Unlock()
+
+	if iMDCD.changes[changeId] {
+		return nil
+	}
+	iMDCD.changes[changeId] = true
+
+	for _, counter := range buildDeviceCounters(deltas) {
+		if iMDCD.counters[counter.homeId] == nil {
+			iMDCD.counters[counter.homeId] = map[string]int64{}
+		}
+		iMDCD.counters[counter.homeId][counter.name] += counter.delta
+	}
+
+	return nil
+}
+
+// Counts returns the counters of the home by name: the total, with the name
+// TOTAL, and the number of devices of each type, with the type as its name.
+func (iMDCD *InMemoryDeviceCounterDao) Counts(homeId string) map[string]int64 {
+
+	iMDCD.mutex.RLock()
+	defer iMDCD.mutex.RUnlock()
+
+	counts := map[string]int64{}
+	for name, count := range iMDCD.counters[homeId] {
+		counts[strings.TrimPrefix(name, deviceCounterTypePrefix)] = count
+	}
+
+	return counts
+}
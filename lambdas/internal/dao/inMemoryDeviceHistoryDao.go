@@ -0,0 +1,81 @@
+package dao
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	hdError "github.com/odhoman/home-devices/internal/error"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// InMemoryDeviceHistoryDao is a thread safe DeviceHistoryDao that keeps the
+// change records in memory, newest first, with the same cursors as
+// DeviceHistoryDaoImpl.
+type InMemoryDeviceHistoryDao struct {
+	mutex   sync.RWMutex
+	changes map[string][]response.DeviceChangeResponse
+}
+
+func NewInMemoryDeviceHistoryDao() *InMemoryDeviceHistoryDao {
+	return &InMemoryDeviceHistoryDao{
+		changes: map[string][]response.DeviceChangeResponse{},
+	}
+}
+
+func (iMDHD *InMemoryDeviceHistoryDao) SaveDeviceChange(ctx context.Context, change response.DeviceChangeResponse) (*response.DeviceChangeResponse, error) {
+
+	iMDHD.mutex.Lock()
+	defer iMDHD.mutex.Unlock()
+
+	change.ChangeID = buildChangeId(time.Now())
+	change.Changes = append([]response.DeviceFieldChange{}, change.Changes...)
+
+	iMDHD.changes[change.DeviceID] = append([]response.DeviceChangeResponse{change}, iMDHD.changes[change.DeviceID]...)
+
+	return &change, nil
+}
+
+func (iMDHD *InMemoryDeviceHistoryDao) ListDeviceHistory(ctx context.Context, deviceId string, limit int32, cursor string) (*response.DeviceHistoryResponse, error) {
+
+	exclusiveStartKey, err := decodeCursor(cursor)
+	if err != nil || (exclusiveStartKey != nil && getStringAttribute(exclusiveStartKey, "deviceId") != deviceId) {
+		return nil, hdError.ErrInvalidCursor.New()
+	}
+
+	iMDHD.mutex.RLock()
+	defer iMDHD.mutex.RUnlock()
+
+	changes := iMDHD.changes[deviceId]
+
+	start := 0
+	if exclusiveStartKey != nil {
+		lastChangeId := getStringAttribute(exclusiveStartKey, "changeId")
+		for start < len(changes) && changes[start].ChangeID >= lastChangeId {
+			start++
+		}
+	}
+
+	pageSize := int(resolveListLimit(limit))
+	end := start + pageSize
+	if end > len(changes) {
+		end = len(changes)
+	}
+
+	page := &response.DeviceHistoryResponse{
+		Changes: append([]response.DeviceChangeResponse{}, changes[start:end]...),
+	}
+
+	// Like a DynamoDB Query, a full page always returns a cursor.
+	if end-start == pageSize {
+		last := changes[end-1]
+		page.NextCursor, _ = encodeCursor(map[string]types.AttributeValue{
+			"deviceId": &types.AttributeValueMemberS{Value: last.DeviceID},
+			"changeId": &types.AttributeValueMemberS{Value: last.ChangeID},
+		})
+	}
+
+	return page, nil
+}
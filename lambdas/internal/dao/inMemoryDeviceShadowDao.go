@@ -0,0 +1,116 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	hdError "github.com/odhoman/home-devices/internal/error"
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+)
+
+// InMemoryDeviceShadowDao is a thread safe DeviceShadowDao that keeps the
+// shadows in memory with the same version checks as DeviceShadowDaoImpl.
+type InMemoryDeviceShadowDao struct {
+	mutex   sync.RWMutex
+	shadows map[string]response.DeviceStateResponse
+}
+
+func NewInMemoryDeviceShadowDao() *InMemoryDeviceShadowDao {
+	return &InMemoryDeviceShadowDao{
+		shadows: map[string]response.DeviceStateResponse{},
+	}
+}
+
+func (iMDSD *InMemoryDeviceShadowDao) GetDeviceShadow(ctx context.Context, deviceId string) (*response.DeviceStateResponse, error) {
+
+	iMDSD.mutex.RLock()
+	defer iMDSD.mutex.RUnlock()
+
+	return iMDSD.copyShadow(deviceId)
+}
+
+func (iMDSD *InMemoryDeviceShadowDao) UpdateDeviceShadow(ctx context.Context, deviceId string, update request.DeviceShadowUpdate) (*response.DeviceStateResponse, error) {
+
+	if update.Desired == nil && update.Reported == nil {
+		return nil, hdError.ErrNoStateToUpdate.New()
+	}
+
+	iMDSD.mutex.Lock()
+	defer iMDSD.mutex.Unlock()
+
+	current := iMDSD.shadows[deviceId]
+
+	if (update.Desired != nil && update.DesiredVersion != current.Desired.Version) ||
+		(update.Reported != nil && update.ReportedVersion != current.Reported.Version) {
+		return nil, hdError.ErrVersionConflict.WithMessage(hdError.DeviceStateVersionConflictMessage)
+	}
+
+	now := time.Now().Unix()
+
+	if update.Desired != nil {
+		state, err := copyState(update.Desired)
+		if err != nil {
+			return nil, hdError.ErrUpdatingDeviceState.Wrap(err)
+		}
+		current.Desired = response.DeviceStateDocumentResponse{State: state, Version: current.Desired.Version + 1, UpdatedAt: now}
+	}
+
+	if update.Reported != nil {
+		state, err := copyState(update.Reported)
+		if err != nil {
+			return nil, hdError.ErrUpdatingDeviceState.Wrap(err)
+		}
+		current.Reported = response.DeviceStateDocumentResponse{State: state, Version: current.Reported.Version + 1, UpdatedAt: now}
+	}
+
+	iMDSD.shadows[deviceId] = current
+
+	return iMDSD.copyShadow(deviceId)
+}
+
+// copyShadow returns a copy of the shadow, so the callers can not change the
+// stored documents.
+func (iMDSD *InMemoryDeviceShadowDao) copyShadow(deviceId string) (*response.DeviceStateResponse, error) {
+
+	stored := iMDSD.shadows[deviceId]
+
+	desired, err := copyState(stored.Desired.State)
+	if err != nil {
+		return nil, hdError.ErrGettingDeviceState.Wrap(err)
+	}
+
+	reported, err := copyState(stored.Reported.State)
+	if err != nil {
+		return nil, hdError.ErrGettingDeviceState.Wrap(err)
+	}
+
+	stored.DeviceID = deviceId
+	stored.Desired.State = desired
+	stored.Reported.State = reported
+
+	return &stored, nil
+}
+
+// copyState makes a deep copy of a document through JSON, which is also how
+// DeviceShadowDaoImpl stores it, so both return the same values.
+func copyState(state map[string]interface{}) (map[string]interface{}, error) {
+
+	copied := map[string]interface{}{}
+	if state == nil {
+		return copied, nil
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil, err
+	}
+
+	return copied, nil
+}
@@ -0,0 +1,119 @@
+package dao
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	hdError "github.com/odhoman/home-devices/internal/error"
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+)
+
+// InMemoryHomeDao is a thread safe HomeDao that keeps the homes in memory,
+// for tests and local runs.
+type InMemoryHomeDao struct {
+	mutex sync.RWMutex
+	homes map[string]response.HomeResponse
+}
+
+func NewInMemoryHomeDao() *InMemoryHomeDao {
+	return &InMemoryHomeDao{
+		homes: map[string]response.HomeResponse{},
+	}
+}
+
+func (iMHD *InMemoryHomeDao) SaveHome(ctx context.Context, home request.CreateHomeRequest) (*response.HomeResponse, error) {
+
+	iMHD.mutex.Lock()
+	defer iMHD.mutex.Unlock()
+
+	id := resolveValue(home.ID, newHomeId())
+	if _, exists := iMHD.homes[id]; exists {
+		return nil, hdError.ErrHomeAlreadyExists.New().WithDetail("id", id)
+	}
+
+	now := time.Now().Unix()
+	homeSaved := response.HomeResponse{
+		ID:         id,
+		Name:       home.Name,
+		Timezone:   home.Timezone,
+		Address:    home.Address,
+		Owner:      home.Owner,
+		CreatedAt:  now,
+		ModifiedAt: now,
+		Version:    1,
+	}
+
+	iMHD.homes[id] = homeSaved
+
+	return &homeSaved, nil
+}
+
+func (iMHD *InMemoryHomeDao) GetHome(ctx context.Context, id string) (*response.HomeResponse, error) {
+
+	iMHD.mutex.RLock()
+	defer iMHD.mutex.RUnlock()
+
+	home, exists := iMHD.homes[id]
+	if !exists {
+		return nil, hdError.ErrHomeNotFound.New()
+	}
+
+	return &home, nil
+}
+
+func (iMHD *InMemoryHomeDao) UpdateHome(ctx context.Context, home request.UpdateHomeRequest, id string, expectedVersion int64) error {
+
+	if home.Name == "" && home.Timezone == "" && home.Address == "" && home.Owner == "" {
+		return hdError.ErrNoFieldToUpdate.New()
+	}
+
+	iMHD.mutex.Lock()
+	defer iMHD.mutex.Unlock()
+
+	current, error := iMHD.checkHome(id, expectedVersion)
+	if error != nil {
+		return error
+	}
+
+	current.Name = resolveValue(home.Name, current.Name)
+	current.Timezone = resolveValue(home.Timezone, current.Timezone)
+	current.Address = resolveValue(home.Address, current.Address)
+	current.Owner = resolveValue(home.Owner, current.Owner)
+	current.ModifiedAt = time.Now().Unix()
+	current.Version++
+
+	iMHD.homes[id] = current
+
+	return nil
+}
+
+func (iMHD *InMemoryHomeDao) DeleteHome(ctx context.Context, id string, expectedVersion int64) error {
+
+	iMHD.mutex.Lock()
+	defer iMHD.mutex.Unlock()
+
+	if _, error := iMHD.checkHome(id, expectedVersion); error != nil {
+		return error
+	}
+
+	delete(iMHD.homes, id)
+
+	return nil
+}
+
+// checkHome must be called holding the mutex.
+func (iMHD *InMemoryHomeDao) checkHome(id string, expectedVersion int64) (response.HomeResponse, error) {
+
+	current, exists := iMHD.homes[id]
+	if !exists {
+		return current, hdError.ErrHomeNotFound.New()
+	}
+
+	if expectedVersion > 0 && current.Version != expectedVersion {
+		return current, hdError.ErrVersionConflict.WithMessage(hdError.HomeVersionConflictMessage)
+	}
+
+	return current, nil
+}
@@ -0,0 +1,452 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	hdAudit "github.com/odhoman/home-devices/internal/audit"
+	constants "github.com/odhoman/home-devices/internal/constants"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+)
+
+// InMemoryHomeDeviceDao is a thread safe HomeDeviceDao that keeps the devices
+// in memory.
+type InMemoryHomeDeviceDao struct {
+	mutex   sync.RWMutex
+	devices map[string]response.HomdeDeviceResponse
+	guards  map[string]string
+	// deletedAt keeps the soft deleted devices by id
+	deletedAt        map[string]int64
+	deviceHistoryDao *InMemoryDeviceHistoryDao
+	roomDao          *InMemoryRoomDao
+}
+
+func NewInMemoryHomeDeviceDao() *InMemoryHomeDeviceDao {
+	return &InMemoryHomeDeviceDao{
+		devices:   map[string]response.HomdeDeviceResponse{},
+		guards:    map[string]string{},
+		deletedAt: map[string]int64{},
+	}
+}
+
+// NewInMemoryHomeDeviceDaoWithHistory records every write of a device in the
+// deviceHistoryDao, like HomeDeviceDaoImpl with RecordHistory.
+func NewInMemoryHomeDeviceDaoWithHistory(deviceHistoryDao *InMemoryDeviceHistoryDao) *InMemoryHomeDeviceDao {
+	homeDeviceDao := NewInMemoryHomeDeviceDao()
+	homeDeviceDao.deviceHistoryDao = deviceHistoryDao
+	return homeDeviceDao
+}
+
+// NewInMemoryHomeDeviceDaoWithRooms works like
+// NewInMemoryHomeDeviceDaoWithHistory and keeps the number of devices of the
+// rooms of the roomDao, like HomeDeviceDaoImpl with CountRoomDevices.
+func NewInMemoryHomeDeviceDaoWithRooms(deviceHistoryDao *InMemoryDeviceHistoryDao, roomDao *InMemoryRoomDao) *InMemoryHomeDeviceDao {
+	homeDeviceDao := NewInMemoryHomeDeviceDaoWithHistory(deviceHistoryDao)
+	homeDeviceDao.roomDao = roomDao
+	return homeDeviceDao
+}
+
+// addRoomDevices must be called holding the mutex.
+func (iMHDD *InMemoryHomeDeviceDao) addRoomDevices(before *response.HomdeDeviceResponse, after *response.HomdeDeviceResponse) error {
+	if iMHDD.roomDao == nil {
+		return nil
+	}
+	return iMHDD.roomDao.addRoomDevices(buildRoomDevicesDeltas(before, after))
+}
+
+// saveDeviceChange must be called holding the mutex.
+func (iMHDD *InMemoryHomeDeviceDao) saveDeviceChange(ctx context.Context, change response.DeviceChangeResponse) {
+	if iMHDD.deviceHistoryDao != nil {
+		iMHDD.deviceHistoryDao.SaveDeviceChange(ctx, change)
+	}
+}
+
+func (iMHDD *InMemoryHomeDeviceDao) IsDeviceExist(ctx context.Context, mac string, homeId string) (bool, error) {
+
+	mac = normalizeMac(mac)
+
+	iMHDD.mutex.RLock()
+	defer iMHDD.mutex.RUnlock()
+
+	for id, device := range iMHDD.devices {
+		if _, deleted := iMHDD.deletedAt[id]; !deleted && device.MAC == mac && device.HomeID == homeId {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (iMHDD *InMemoryHomeDeviceDao) SaveHomeDevice(ctx context.Context, device request.CreateDeviceRequest) (*response.HomdeDeviceResponse, error) {
+
+	device.MAC = normalizeMac(device.MAC)
+
+	iMHDD.mutex.Lock()
+	defer iMHDD.mutex.Unlock()
+
+	guardId := buildMacHomeGuardId(device.MAC, device.HomeID)
+	if deviceId, exists := iMHDD.guards[guardId]; exists {
+		return nil, hdError.ErrDeviceAlreadyExists.New().WithDetail("mac", device.MAC).WithDetail("homeId", device.HomeID).WithDetail("deviceId", deviceId)
+	}
+
+	now := time.Now().Unix()
+	deviceSaved := response.HomdeDeviceResponse{
+		ID:         uuid.New().String(),
+		MAC:        device.MAC,
+		Name:       device.Name,
+		Type:       device.Type,
+		HomeID:     device.HomeID,
+		RoomID:     device.RoomID,
+		CreatedAt:  now,
+		ModifiedAt: now,
+		Version:    1,
+		Status:     constants.DeviceStatusOffline,
+	}
+
+	if error := iMHDD.addRoomDevices(nil, &deviceSaved); error != nil {
+		return nil, error
+	}
+
+	iMHDD.devices[deviceSaved.ID] = deviceSaved
+	iMHDD.guards[guardId] = deviceSaved.ID
+	iMHDD.saveDeviceChange(ctx, newDeviceChange(ctx, hdAudit.OperationCreate, deviceSaved.ID, nil, &deviceSaved))
+
+	return &deviceSaved, nil
+}
+
+func (iMHDD *InMemoryHomeDeviceDao) GetHomeDevice(ctx context.Context, id string) (*response.HomdeDeviceResponse, error) {
+
+	iMHDD.mutex.RLock()
+	defer iMHDD.mutex.RUnlock()
+
+	device, error := iMHDD.getActiveDevice(id)
+	if error != nil {
+		return nil, error
+	}
+
+	return &device, nil
+}
+
+// getActiveDevice must be called holding the mutex.
+func (iMHDD *InMemoryHomeDeviceDao) getActiveDevice(id string) (response.HomdeDeviceResponse, error) {
+
+	device, exists := iMHDD.devices[id]
+	if _, deleted := iMHDD.deletedAt[id]; !exists || deleted {
+		return response.HomdeDeviceResponse{}, hdError.ErrDeviceNotFound.New()
+	}
+
+	return device, nil
+}
+
+func (iMHDD *InMemoryHomeDeviceDao) UpdateHomeDevice(ctx context.Context, device request.UpdateDeviceRequest, id string, expectedVersion int64) (*response.HomdeDeviceResponse, error) {
+
+	device.MAC = normalizeMac(device.MAC)
+
+	iMHDD.mutex.Lock()
+	defer iMHDD.mutex.Unlock()
+
+	current, error := iMHDD.getActiveDevice(id)
+	if error != nil {
+		return nil, error
+	}
+
+	if expectedVersion > 0 && current.Version != expectedVersion {
+		return nil, hdError.ErrVersionConflict.New()
+	}
+
+	updated := current
+	updated.MAC = resolveValue(device.MAC, current.MAC)
+	updated.Name = resolveValue(device.Name, current.Name)
+	updated.Type = resolveValue(device.Type, current.Type)
+	updated.HomeID = resolveValue(device.HomeID, current.HomeID)
+	updated.RoomID = device.RoomID
+	if device.RoomID == "" && updated.HomeID == current.HomeID {
+		updated.RoomID = current.RoomID
+	}
+	updated.ModifiedAt = time.Now().Unix()
+	updated.Version = current.Version + 1
+
+	currentGuardId := buildMacHomeGuardId(current.MAC, current.HomeID)
+	updatedGuardId := buildMacHomeGuardId(updated.MAC, updated.HomeID)
+
+	if currentGuardId != updatedGuardId {
+		if deviceId, exists := iMHDD.guards[updatedGuardId]; exists {
+			return nil, hdError.ErrDeviceAlreadyExists.New().WithDetail("mac", updated.MAC).WithDetail("homeId", updated.HomeID).WithDetail("deviceId", deviceId)
+		}
+	}
+
+	if error := iMHDD.addRoomDevices(&current, &updated); error != nil {
+		return nil, error
+	}
+
+	if currentGuardId != updatedGuardId {
+		delete(iMHDD.guards, currentGuardId)
+		iMHDD.guards[updatedGuardId] = id
+	}
+
+	iMHDD.devices[id] = updated
+	iMHDD.saveDeviceChange(ctx, newDeviceChange(ctx, hdAudit.OperationUpdate, id, &current, &updated))
+
+	return &updated, nil
+}
+
+func (iMHDD *InMemoryHomeDeviceDao) DeleteHomeDevice(ctx context.Context, id string, expectedVersion int64) (*response.HomdeDeviceResponse, error) {
+
+	iMHDD.mutex.Lock()
+	defer iMHDD.mutex.Unlock()
+
+	current, error := iMHDD.getActiveDevice(id)
+	if error != nil {
+		return nil, error
+	}
+
+	if expectedVersion > 0 && current.Version != expectedVersion {
+		return nil, hdError.ErrVersionConflict.New()
+	}
+
+	if error := iMHDD.addRoomDevices(&current, nil); error != nil {
+		return nil, error
+	}
+
+	iMHDD.saveDeviceChange(ctx, newDeviceChange(ctx, hdAudit.OperationDelete, id, &current, nil))
+
+	current.Version++
+
+	iMHDD.devices[id] = current
+	iMHDD.deletedAt[id] = time.Now().Unix()
+	delete(iMHDD.guards, buildMacHomeGuardId(current.MAC, current.HomeID))
+
+	return &current, nil
+}
+
+func (iMHDD *InMemoryHomeDeviceDao) HardDeleteHomeDevice(ctx context.Context, id string, expectedVersion int64) error {
+
+	iMHDD.mutex.Lock()
+	defer iMHDD.mutex.Unlock()
+
+	current, exists := iMHDD.devices[id]
+	if !exists {
+		return hdError.ErrDeviceNotFound.New()
+	}
+
+	if expectedVersion > 0 && current.Version != expectedVersion {
+		return hdError.ErrVersionConflict.New()
+	}
+
+	if _, deleted := iMHDD.deletedAt[id]; !deleted {
+		if error := iMHDD.addRoomDevices(&current, nil); error != nil {
+			return error
+		}
+		delete(iMHDD.guards, buildMacHomeGuardId(current.MAC, current.HomeID))
+		iMHDD.saveDeviceChange(ctx, newDeviceChange(ctx, hdAudit.OperationHardDelete, id, &current, nil))
+	} else {
+		iMHDD.saveDeviceChange(ctx, newDeviceChange(ctx, hdAudit.OperationHardDelete, id, nil, nil))
+	}
+
+	delete(iMHDD.deletedAt, id)
+	delete(iMHDD.devices, id)
+
+	return nil
+}
+
+func (iMHDD *InMemoryHomeDeviceDao) GetDeletedHomeDevice(ctx context.Context, id string) (*response.HomdeDeviceResponse, error) {
+
+	iMHDD.mutex.RLock()
+	defer iMHDD.mutex.RUnlock()
+
+	device, error := iMHDD.getDeletedDevice(id)
+	if error != nil {
+		return nil, error
+	}
+
+	return &device, nil
+}
+
+// getDeletedDevice must be called holding the mutex.
+func (iMHDD *InMemoryHomeDeviceDao) getDeletedDevice(id string) (response.HomdeDeviceResponse, error) {
+
+	device, exists := iMHDD.devices[id]
+	if !exists {
+		return response.HomdeDeviceResponse{}, hdError.ErrDeviceNotFound.New()
+	}
+
+	deletedAt, deleted := iMHDD.deletedAt[id]
+	if !deleted {
+		return response.HomdeDeviceResponse{}, hdError.ErrDeviceNotDeleted.New()
+	}
+
+	if !isWithinRetention(deletedAt, time.Now()) {
+		return response.HomdeDeviceResponse{}, hdError.ErrDeviceNotFound.New()
+	}
+
+	return device, nil
+}
+
+func (iMHDD *InMemoryHomeDeviceDao) RestoreHomeDevice(ctx context.Context, id string) (*response.HomdeDeviceResponse, error) {
+
+	iMHDD.mutex.Lock()
+	defer iMHDD.mutex.Unlock()
+
+	current, error := iMHDD.getDeletedDevice(id)
+	if error != nil {
+		return nil, error
+	}
+
+	guardId := buildMacHomeGuardId(current.MAC, current.HomeID)
+	if deviceId, exists := iMHDD.guards[guardId]; exists {
+		return nil, hdError.ErrDeviceAlreadyExists.New().WithDetail("mac", current.MAC).WithDetail("homeId", current.HomeID).WithDetail("deviceId", deviceId)
+	}
+
+	if error := iMHDD.addRoomDevices(nil, &current); error != nil {
+		return nil, error
+	}
+
+	current.ModifiedAt = time.Now().Unix()
+	current.Version++
+
+	iMHDD.devices[id] = current
+	iMHDD.guards[guardId] = id
+	delete(iMHDD.deletedAt, id)
+	iMHDD.saveDeviceChange(ctx, newDeviceChange(ctx, hdAudit.OperationRestore, id, nil, &current))
+
+	return &current, nil
+}
+
+func (iMHDD *InMemoryHomeDeviceDao) ListHomeDevices(ctx context.Context, homeId string, roomId string, status string, limit int32, cursor string) (*response.HomeDeviceListResponse, error) {
+
+	exclusiveStartKey, err := decodeCursor(cursor)
+	if err != nil || (exclusiveStartKey != nil && getStringAttribute(exclusiveStartKey, "homeId") != homeId) {
+		return nil, hdError.ErrInvalidCursor.New()
+	}
+
+	iMHDD.mutex.RLock()
+	defer iMHDD.mutex.RUnlock()
+
+	devices := []response.HomdeDeviceResponse{}
+	for _, device := range iMHDD.devices {
+		if device.HomeID == homeId {
+			devices = append(devices, device)
+		}
+	}
+
+	// Same order as the HomeIdIndex: by creation date, and by id for the
+	// devices created in the same second.
+	sort.Slice(devices, func(i, j int) bool {
+		if devices[i].CreatedAt != devices[j].CreatedAt {
+			return devices[i].CreatedAt < devices[j].CreatedAt
+		}
+		return devices[i].ID < devices[j].ID
+	})
+
+	start := 0
+	if exclusiveStartKey != nil {
+		lastCreatedAt := getInt64Attribute(exclusiveStartKey, "createdAt")
+		lastId := getStringAttribute(exclusiveStartKey, "id")
+		for start < len(devices) && (devices[start].CreatedAt < lastCreatedAt || (devices[start].CreatedAt == lastCreatedAt && devices[start].ID <= lastId)) {
+			start++
+		}
+	}
+
+	pageSize := int(resolveListLimit(limit))
+	end := start + pageSize
+	if end > len(devices) {
+		end = len(devices)
+	}
+
+	// like a DynamoDB Query, the limit counts the devices filtered out
+	page := &response.HomeDeviceListResponse{
+		Devices: []response.HomdeDeviceResponse{},
+	}
+
+	for _, device := range devices[start:end] {
+		if _, deleted := iMHDD.deletedAt[device.ID]; !deleted && (roomId == "" || device.RoomID == roomId) && (status == "" || device.Status == status) {
+			page.Devices = append(page.Devices, device)
+		}
+	}
+
+	if end-start == pageSize {
+		last := devices[end-1]
+		page.NextCursor, _ = encodeCursor(map[string]types.AttributeValue{
+			"id":        &types.AttributeValueMemberS{Value: last.ID},
+			"homeId":    &types.AttributeValueMemberS{Value: last.HomeID},
+			"createdAt": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", last.CreatedAt)},
+		})
+	}
+
+	return page, nil
+}
+
+func (iMHDD *InMemoryHomeDeviceDao) MarkHomeDeviceSeen(ctx context.Context, id string, seenAt int64) (*response.HomdeDeviceResponse, error) {
+
+	iMHDD.mutex.Lock()
+	defer iMHDD.mutex.Unlock()
+
+	current, error := iMHDD.getActiveDevice(id)
+	if error != nil {
+		return nil, error
+	}
+
+	if current.LastSeenAt >= seenAt {
+		return nil, nil
+	}
+
+	wasOffline := current.Status != constants.DeviceStatusOnline
+
+	current.LastSeenAt = seenAt
+	current.Status = constants.DeviceStatusOnline
+	iMHDD.devices[id] = current
+
+	if !wasOffline {
+		return nil, nil
+	}
+
+	iMHDD.saveDeviceChange(ctx, newStatusChange(ctx, id, constants.DeviceStatusOffline, constants.DeviceStatusOnline))
+
+	return &current, nil
+}
+
+// ListStaleHomeDevices returns the stale devices sorted by the time they were
+// last seen, like the sort key of the StatusIndex.
+func (iMHDD *InMemoryHomeDeviceDao) ListStaleHomeDevices(ctx context.Context, seenBefore int64) ([]response.HomdeDeviceResponse, error) {
+
+	iMHDD.mutex.RLock()
+	defer iMHDD.mutex.RUnlock()
+
+	devices := []response.HomdeDeviceResponse{}
+	for id, device := range iMHDD.devices {
+		if _, deleted := iMHDD.deletedAt[id]; !deleted && device.Status == constants.DeviceStatusOnline && device.LastSeenAt < seenBefore {
+			devices = append(devices, device)
+		}
+	}
+
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].LastSeenAt < devices[j].LastSeenAt
+	})
+
+	return devices, nil
+}
+
+func (iMHDD *InMemoryHomeDeviceDao) MarkHomeDeviceOffline(ctx context.Context, id string, lastSeenAt int64) (bool, error) {
+
+	iMHDD.mutex.Lock()
+	defer iMHDD.mutex.Unlock()
+
+	current, error := iMHDD.getActiveDevice(id)
+	if error != nil || current.Status != constants.DeviceStatusOnline || current.LastSeenAt != lastSeenAt {
+		return false, nil
+	}
+
+	current.Status = constants.DeviceStatusOffline
+	iMHDD.devices[id] = current
+	iMHDD.saveDeviceChange(ctx, newStatusChange(ctx, id, constants.DeviceStatusOnline, constants.DeviceStatusOffline))
+
+	return true, nil
+}
@@ -0,0 +1,158 @@
+package dao
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	hdError "github.com/odhoman/home-devices/internal/error"
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/google/uuid"
+)
+
+// InMemoryRoomDao is a thread safe RoomDao that keeps the rooms in memory,
+// for tests and local runs.
+type InMemoryRoomDao struct {
+	mutex sync.RWMutex
+	// rooms keeps the rooms by homeId and id
+	rooms map[string]map[string]response.RoomResponse
+	// devices keeps the number of devices of the rooms by homeId and id
+	devices map[[2]string]int64
+}
+
+func NewInMemoryRoomDao() *InMemoryRoomDao {
+	return &InMemoryRoomDao{
+		rooms:   map[string]map[string]response.RoomResponse{},
+		devices: map[[2]string]int64{},
+	}
+}
+
+func (iMRD *InMemoryRoomDao) SaveRoom(ctx context.Context, homeId string, room request.CreateRoomRequest) (*response.RoomResponse, error) {
+
+	iMRD.mutex.Lock()
+	defer iMRD.mutex.Unlock()
+
+	now := time.Now().Unix()
+	roomSaved := response.RoomResponse{
+		ID:         uuid.New().String(),
+		HomeID:     homeId,
+		Name:       room.Name,
+		CreatedAt:  now,
+		ModifiedAt: now,
+		Version:    1,
+	}
+
+	if _, exists := iMRD.rooms[homeId]; !exists {
+		iMRD.rooms[homeId] = map[string]response.RoomResponse{}
+	}
+	iMRD.rooms[homeId][roomSaved.ID] = roomSaved
+
+	return &roomSaved, nil
+}
+
+func (iMRD *InMemoryRoomDao) GetRoom(ctx context.Context, homeId string, id string) (*response.RoomResponse, error) {
+
+	iMRD.mutex.RLock()
+	defer iMRD.mutex.RUnlock()
+
+	room, error := iMRD.checkRoom(homeId, id, 0)
+	if error != nil {
+		return nil, error
+	}
+
+	return &room, nil
+}
+
+// ListRooms returns the rooms sorted by id, like the sort key of the table.
+func (iMRD *InMemoryRoomDao) ListRooms(ctx context.Context, homeId string) (*response.RoomListResponse, error) {
+
+	iMRD.mutex.RLock()
+	defer iMRD.mutex.RUnlock()
+
+	rooms := []response.RoomResponse{}
+	for _, room := range iMRD.rooms[homeId] {
+		rooms = append(rooms, room)
+	}
+
+	sort.Slice(rooms, func(i, j int) bool {
+		return rooms[i].ID < rooms[j].ID
+	})
+
+	return &response.RoomListResponse{Rooms: rooms}, nil
+}
+
+func (iMRD *InMemoryRoomDao) UpdateRoom(ctx context.Context, room request.UpdateRoomRequest, homeId string, id string, expectedVersion int64) error {
+
+	iMRD.mutex.Lock()
+	defer iMRD.mutex.Unlock()
+
+	current, error := iMRD.checkRoom(homeId, id, expectedVersion)
+	if error != nil {
+		return error
+	}
+
+	current.Name = room.Name
+	current.ModifiedAt = time.Now().Unix()
+	current.Version++
+
+	iMRD.rooms[homeId][id] = current
+
+	return nil
+}
+
+func (iMRD *InMemoryRoomDao) DeleteRoom(ctx context.Context, homeId string, id string, expectedVersion int64) error {
+
+	iMRD.mutex.Lock()
+	defer iMRD.mutex.Unlock()
+
+	if _, error := iMRD.checkRoom(homeId, id, expectedVersion); error != nil {
+		return error
+	}
+
+	if iMRD.devices[[2]string{homeId, id}] > 0 {
+		return hdError.ErrRoomHasDevices.New()
+	}
+
+	delete(iMRD.rooms[homeId], id)
+	delete(iMRD.devices, [2]string{homeId, id})
+
+	return nil
+}
+
+// addRoomDevices changes the number of devices of the rooms, of none of them
+// when one does not exist.
+func (iMRD *InMemoryRoomDao) addRoomDevices(deltas []roomDevicesDelta) error {
+
+	iMRD.mutex.Lock()
+	defer iMRD.mutex.Unlock()
+
+	for _, delta := range deltas {
+		if _, exists := iMRD.rooms[delta.homeId][delta.roomId]; !exists {
+			return hdError.ErrUnknownRoom.New().WithDetail("roomId", delta.roomId)
+		}
+	}
+
+	for _, delta := range deltas {
+		iMRD.devices[[2]string{delta.homeId, delta.roomId}] += delta.delta
+	}
+
+	return nil
+}
+
+// checkRoom must be called holding the mutex.
+func (iMRD *InMemoryRoomDao) checkRoom(homeId string, id string, expectedVersion int64) (response.RoomResponse, error) {
+
+	current, exists := iMRD.rooms[homeId][id]
+	if !exists {
+		return current, hdError.ErrRoomNotFound.New()
+	}
+
+	if expectedVersion > 0 && current.Version != expectedVersion {
+		return current, hdError.ErrVersionConflict.WithMessage(hdError.RoomVersionConflictMessage)
+	}
+
+	return current, nil
+}
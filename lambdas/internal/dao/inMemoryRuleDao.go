@@ -0,0 +1,185 @@
+package dao
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	hdError "github.com/odhoman/home-devices/internal/error"
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/google/uuid"
+)
+
+// InMemoryRuleDao is a thread safe RuleDao that keeps the rules in memory,
+// for tests and local runs.
+type InMemoryRuleDao struct {
+	mutex sync.RWMutex
+	// rules keeps the rules by homeId and id
+	rules map[string]map[string]response.RuleResponse
+	// lastFiredReadingAt keeps the time of the reading that last fired each
+	// rule by id
+	lastFiredReadingAt map[string]int64
+}
+
+func NewInMemoryRuleDao() *InMemoryRuleDao {
+	return &InMemoryRuleDao{
+		rules:              map[string]map[string]response.RuleResponse{},
+		lastFiredReadingAt: map[string]int64{},
+	}
+}
+
+func (iMRD *InMemoryRuleDao) SaveRule(ctx context.Context, homeId string, rule request.RuleRequest) (*response.RuleResponse, error) {
+
+	iMRD.mutex.Lock()
+	defer iMRD.mutex.Unlock()
+
+	now := time.Now().Unix()
+	ruleSaved := mapRuleRequestToRuleResponse(rule)
+	ruleSaved.ID = uuid.New().String()
+	ruleSaved.HomeID = homeId
+	ruleSaved.CreatedAt = now
+	ruleSaved.ModifiedAt = now
+	ruleSaved.Version = 1
+
+	if _, exists := iMRD.rules[homeId]; !exists {
+		iMRD.rules[homeId] = map[string]response.RuleResponse{}
+	}
+	iMRD.rules[homeId][ruleSaved.ID] = ruleSaved
+
+	return &ruleSaved, nil
+}
+
+func (iMRD *InMemoryRuleDao) GetRule(ctx context.Context, homeId string, id string) (*response.RuleResponse, error) {
+
+	iMRD.mutex.RLock()
+	defer iMRD.mutex.RUnlock()
+
+	rule, error := iMRD.checkRule(homeId, id, 0)
+	if error != nil {
+		return nil, error
+	}
+
+	return &rule, nil
+}
+
+// ListRules returns the rules sorted by id, like the sort key of the table.
+func (iMRD *InMemoryRuleDao) ListRules(ctx context.Context, homeId string) (*response.RuleListResponse, error) {
+
+	iMRD.mutex.RLock()
+	defer iMRD.mutex.RUnlock()
+
+	rules := []response.RuleResponse{}
+	for _, rule := range iMRD.rules[homeId] {
+		rules = append(rules, rule)
+	}
+
+	sortRules(rules)
+
+	return &response.RuleListResponse{Rules: rules}, nil
+}
+
+func (iMRD *InMemoryRuleDao) ListTriggeredRules(ctx context.Context, deviceId string) ([]response.RuleResponse, error) {
+
+	iMRD.mutex.RLock()
+	defer iMRD.mutex.RUnlock()
+
+	rules := []response.RuleResponse{}
+	for _, homeRules := range iMRD.rules {
+		for _, rule := range homeRules {
+			if rule.Trigger.DeviceID == deviceId {
+				rules = append(rules, rule)
+			}
+		}
+	}
+
+	sortRules(rules)
+
+	return rules, nil
+}
+
+func (iMRD *InMemoryRuleDao) UpdateRule(ctx context.Context, rule request.RuleRequest, homeId string, id string, expectedVersion int64) error {
+
+	iMRD.mutex.Lock()
+	defer iMRD.mutex.Unlock()
+
+	current, error := iMRD.checkRule(homeId, id, expectedVersion)
+	if error != nil {
+		return error
+	}
+
+	ruleUpdated := mapRuleRequestToRuleResponse(rule)
+	ruleUpdated.ID = current.ID
+	ruleUpdated.HomeID = current.HomeID
+	ruleUpdated.LastFiredAt = current.LastFiredAt
+	ruleUpdated.CreatedAt = current.CreatedAt
+	ruleUpdated.ModifiedAt = time.Now().Unix()
+	ruleUpdated.Version = current.Version + 1
+
+	iMRD.rules[homeId][id] = ruleUpdated
+
+	return nil
+}
+
+func (iMRD *InMemoryRuleDao) DeleteRule(ctx context.Context, homeId string, id string, expectedVersion int64) error {
+
+	iMRD.mutex.Lock()
+	defer iMRD.mutex.Unlock()
+
+	if _, error := iMRD.checkRule(homeId, id, expectedVersion); error != nil {
+		return error
+	}
+
+	delete(iMRD.rules[homeId], id)
+	delete(iMRD.lastFiredReadingAt, id)
+
+	return nil
+}
+
+func (iMRD *InMemoryRuleDao) ClaimRuleFiring(ctx context.Context, homeId string, id string, readingAt int64, firedAt int64, cooldownSeconds int64) error {
+
+	iMRD.mutex.Lock()
+	defer iMRD.mutex.Unlock()
+
+	current, error := iMRD.checkRule(homeId, id, 0)
+	if error != nil {
+		return error
+	}
+
+	if lastFiredReadingAt, fired := iMRD.lastFiredReadingAt[id]; fired && lastFiredReadingAt >= readingAt {
+		return hdError.ErrRuleAlreadyFired.New()
+	}
+
+	if current.LastFiredAt != 0 && current.LastFiredAt > firedAt-cooldownSeconds {
+		return hdError.ErrRuleInCooldown.New()
+	}
+
+	current.LastFiredAt = firedAt
+	iMRD.rules[homeId][id] = current
+	iMRD.lastFiredReadingAt[id] = readingAt
+
+	return nil
+}
+
+// checkRule must be called holding the mutex.
+func (iMRD *InMemoryRuleDao) checkRule(homeId string, id string, expectedVersion int64) (response.RuleResponse, error) {
+
+	current, exists := iMRD.rules[homeId][id]
+	if !exists {
+		return current, hdError.ErrRuleNotFound.New()
+	}
+
+	if expectedVersion > 0 && current.Version != expectedVersion {
+		return current, hdError.ErrVersionConflict.WithMessage(hdError.RuleVersionConflictMessage)
+	}
+
+	return current, nil
+}
+
+func sortRules(rules []response.RuleResponse) {
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].ID < rules[j].ID
+	})
+}
@@ -0,0 +1,51 @@
+package dao
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	response "github.com/odhoman/home-devices/internal/response"
+)
+
+// InMemoryRuleExecutionDao is a thread safe RuleExecutionDao that keeps the
+// executions in memory, newest first.
+type InMemoryRuleExecutionDao struct {
+	mutex      sync.RWMutex
+	executions map[string][]response.RuleExecutionResponse
+}
+
+func NewInMemoryRuleExecutionDao() *InMemoryRuleExecutionDao {
+	return &InMemoryRuleExecutionDao{
+		executions: map[string][]response.RuleExecutionResponse{},
+	}
+}
+
+func (iMRED *InMemoryRuleExecutionDao) SaveRuleExecution(ctx context.Context, execution response.RuleExecutionResponse) (*response.RuleExecutionResponse, error) {
+
+	iMRED.mutex.Lock()
+	defer iMRED.mutex.Unlock()
+
+	execution.ID = buildChangeId(time.Now())
+
+	iMRED.executions[execution.RuleID] = append([]response.RuleExecutionResponse{execution}, iMRED.executions[execution.RuleID]...)
+
+	return &execution, nil
+}
+
+func (iMRED *InMemoryRuleExecutionDao) ListRuleExecutions(ctx context.Context, ruleId string, limit int32) (*response.RuleExecutionListResponse, error) {
+
+	iMRED.mutex.RLock()
+	defer iMRED.mutex.RUnlock()
+
+	executions := iMRED.executions[ruleId]
+
+	end := int(resolveRuleExecutionsLimit(limit))
+	if end > len(executions) {
+		end = len(executions)
+	}
+
+	return &response.RuleExecutionListResponse{
+		Executions: append([]response.RuleExecutionResponse{}, executions[:end]...),
+	}, nil
+}
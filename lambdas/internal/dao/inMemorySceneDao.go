@@ -0,0 +1,132 @@
+package dao
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	hdError "github.com/odhoman/home-devices/internal/error"
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/google/uuid"
+)
+
+// InMemorySceneDao is a thread safe SceneDao that keeps the scenes in memory,
+// for tests and local runs.
+type InMemorySceneDao struct {
+	mutex sync.RWMutex
+	// scenes keeps the scenes by homeId and id
+	scenes map[string]map[string]response.SceneResponse
+}
+
+func NewInMemorySceneDao() *InMemorySceneDao {
+	return &InMemorySceneDao{
+		scenes: map[string]map[string]response.SceneResponse{},
+	}
+}
+
+func (iMSD *InMemorySceneDao) SaveScene(ctx context.Context, homeId string, scene request.SceneRequest) (*response.SceneResponse, error) {
+
+	iMSD.mutex.Lock()
+	defer iMSD.mutex.Unlock()
+
+	now := time.Now().Unix()
+	sceneSaved := response.SceneResponse{
+		ID:         uuid.New().String(),
+		HomeID:     homeId,
+		Name:       scene.Name,
+		Devices:    mapSceneDevicesToResponse(scene.Devices),
+		CreatedAt:  now,
+		ModifiedAt: now,
+		Version:    1,
+	}
+
+	if _, exists := iMSD.scenes[homeId]; !exists {
+		iMSD.scenes[homeId] = map[string]response.SceneResponse{}
+	}
+	iMSD.scenes[homeId][sceneSaved.ID] = sceneSaved
+
+	return &sceneSaved, nil
+}
+
+func (iMSD *InMemorySceneDao) GetScene(ctx context.Context, homeId string, id string) (*response.SceneResponse, error) {
+
+	iMSD.mutex.RLock()
+	defer iMSD.mutex.RUnlock()
+
+	scene, error := iMSD.checkScene(homeId, id, 0)
+	if error != nil {
+		return nil, error
+	}
+
+	return &scene, nil
+}
+
+// ListScenes returns the scenes sorted by id, like the sort key of the table.
+func (iMSD *InMemorySceneDao) ListScenes(ctx context.Context, homeId string) (*response.SceneListResponse, error) {
+
+	iMSD.mutex.RLock()
+	defer iMSD.mutex.RUnlock()
+
+	scenes := []response.SceneResponse{}
+	for _, scene := range iMSD.scenes[homeId] {
+		scenes = append(scenes, scene)
+	}
+
+	sort.Slice(scenes, func(i, j int) bool {
+		return scenes[i].ID < scenes[j].ID
+	})
+
+	return &response.SceneListResponse{Scenes: scenes}, nil
+}
+
+func (iMSD *InMemorySceneDao) UpdateScene(ctx context.Context, scene request.SceneRequest, homeId string, id string, expectedVersion int64) error {
+
+	iMSD.mutex.Lock()
+	defer iMSD.mutex.Unlock()
+
+	current, error := iMSD.checkScene(homeId, id, expectedVersion)
+	if error != nil {
+		return error
+	}
+
+	current.Name = scene.Name
+	current.Devices = mapSceneDevicesToResponse(scene.Devices)
+	current.ModifiedAt = time.Now().Unix()
+	current.Version++
+
+	iMSD.scenes[homeId][id] = current
+
+	return nil
+}
+
+func (iMSD *InMemorySceneDao) DeleteScene(ctx context.Context, homeId string, id string, expectedVersion int64) error {
+
+	iMSD.mutex.Lock()
+	defer iMSD.mutex.Unlock()
+
+	if _, error := iMSD.checkScene(homeId, id, expectedVersion); error != nil {
+		return error
+	}
+
+	delete(iMSD.scenes[homeId], id)
+
+	return nil
+}
+
+// checkScene must be called holding the mutex.
+func (iMSD *InMemorySceneDao) checkScene(homeId string, id string, expectedVersion int64) (response.SceneResponse, error) {
+
+	current, exists := iMSD.scenes[homeId][id]
+	if !exists {
+		return current, hdError.ErrSceneNotFound.New()
+	}
+
+	if expectedVersion > 0 && current.Version != expectedVersion {
+		return current, hdError.ErrVersionConflict.WithMessage(hdError.SceneVersionConflictMessage)
+	}
+
+	return current, nil
+}
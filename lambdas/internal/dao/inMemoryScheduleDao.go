@@ -0,0 +1,174 @@
+package dao
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	hdError "github.com/odhoman/home-devices/internal/error"
+	response "github.com/odhoman/home-devices/internal/response"
+)
+
+// InMemoryScheduleDao is a thread safe ScheduleDao that keeps the schedules in
+// memory, for tests and local runs.
+type InMemoryScheduleDao struct {
+	mutex sync.RWMutex
+	// schedules keeps the schedules by homeId and id
+	schedules map[string]map[string]response.ScheduleResponse
+}
+
+func NewInMemoryScheduleDao() *InMemoryScheduleDao {
+	return &InMemoryScheduleDao{
+		schedules: map[string]map[string]response.ScheduleResponse{},
+	}
+}
+
+func (iMSD *InMemoryScheduleDao) SaveSchedule(ctx context.Context, schedule response.ScheduleResponse) (*response.ScheduleResponse, error) {
+
+	iMSD.mutex.Lock()
+	defer iMSD.mutex.Unlock()
+
+	if schedule.Params == nil {
+		schedule.Params = map[string]interface{}{}
+	}
+
+	if _, exists := iMSD.schedules[schedule.HomeID]; !exists {
+		iMSD.schedules[schedule.HomeID] = map[string]response.ScheduleResponse{}
+	}
+
+	if _, exists := iMSD.schedules[schedule.HomeID][schedule.ID]; exists {
+		return nil, hdError.ErrScheduleNotCreated.New()
+	}
+
+	iMSD.schedules[schedule.HomeID][schedule.ID] = schedule
+
+	return &schedule, nil
+}
+
+func (iMSD *InMemoryScheduleDao) GetSchedule(ctx context.Context, homeId string, id string) (*response.ScheduleResponse, error) {
+
+	iMSD.mutex.RLock()
+	defer iMSD.mutex.RUnlock()
+
+	schedule, error := iMSD.checkSchedule(homeId, id, 0)
+	if error != nil {
+		return nil, error
+	}
+
+	return &schedule, nil
+}
+
+// ListSchedules returns the schedules sorted by id, like the sort key of the
+// table.
+func (iMSD *InMemoryScheduleDao) ListSchedules(ctx context.Context, homeId string) (*response.ScheduleListResponse, error) {
+
+	iMSD.mutex.RLock()
+	defer iMSD.mutex.RUnlock()
+
+	schedules := []response.ScheduleResponse{}
+	for _, schedule := range iMSD.schedules[homeId] {
+		schedules = append(schedules, schedule)
+	}
+
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].ID < schedules[j].ID
+	})
+
+	return &response.ScheduleListResponse{Schedules: schedules}, nil
+}
+
+// ListDueSchedules returns the due schedules sorted by their next run, like
+// the sort key of the index.
+func (iMSD *InMemoryScheduleDao) ListDueSchedules(ctx context.Context, now int64) ([]response.ScheduleResponse, error) {
+
+	iMSD.mutex.RLock()
+	defer iMSD.mutex.RUnlock()
+
+	schedules := []response.ScheduleResponse{}
+	for _, homeSchedules := range iMSD.schedules {
+		for _, schedule := range homeSchedules {
+			if schedule.NextRunAt > 0 && schedule.NextRunAt <= now {
+				schedules = append(schedules, schedule)
+			}
+		}
+	}
+
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].NextRunAt < schedules[j].NextRunAt
+	})
+
+	return schedules, nil
+}
+
+func (iMSD *InMemoryScheduleDao) UpdateSchedule(ctx context.Context, schedule response.ScheduleResponse, expectedVersion int64) error {
+
+	iMSD.mutex.Lock()
+	defer iMSD.mutex.Unlock()
+
+	current, error := iMSD.checkSchedule(schedule.HomeID, schedule.ID, expectedVersion)
+	if error != nil {
+		return error
+	}
+
+	if schedule.Params == nil {
+		schedule.Params = map[string]interface{}{}
+	}
+
+	schedule.LastRunAt = current.LastRunAt
+	schedule.CreatedAt = current.CreatedAt
+	schedule.Version = current.Version + 1
+
+	iMSD.schedules[schedule.HomeID][schedule.ID] = schedule
+
+	return nil
+}
+
+func (iMSD *InMemoryScheduleDao) DeleteSchedule(ctx context.Context, homeId string, id string, expectedVersion int64) error {
+
+	iMSD.mutex.Lock()
+	defer iMSD.mutex.Unlock()
+
+	if _, error := iMSD.checkSchedule(homeId, id, expectedVersion); error != nil {
+		return error
+	}
+
+	delete(iMSD.schedules[homeId], id)
+
+	return nil
+}
+
+func (iMSD *InMemoryScheduleDao) ClaimScheduleRun(ctx context.Context, homeId string, id string, runAt int64, nextRunAt int64) error {
+
+	iMSD.mutex.Lock()
+	defer iMSD.mutex.Unlock()
+
+	current, error := iMSD.checkSchedule(homeId, id, 0)
+	if error != nil {
+		return error
+	}
+
+	if current.NextRunAt == 0 || current.NextRunAt != runAt {
+		return hdError.ErrScheduleAlreadyRun.New()
+	}
+
+	current.LastRunAt = runAt
+	current.NextRunAt = nextRunAt
+	iMSD.schedules[homeId][id] = current
+
+	return nil
+}
+
+// checkSchedule must be called holding the mutex.
+func (iMSD *InMemoryScheduleDao) checkSchedule(homeId string, id string, expectedVersion int64) (response.ScheduleResponse, error) {
+
+	current, exists := iMSD.schedules[homeId][id]
+	if !exists {
+		return current, hdError.ErrScheduleNotFound.New()
+	}
+
+	if expectedVersion > 0 && current.Version != expectedVersion {
+		return current, hdError.ErrVersionConflict.WithMessage(hdError.ScheduleVersionConflictMessage)
+	}
+
+	return current, nil
+}
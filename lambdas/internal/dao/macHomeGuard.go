@@ -0,0 +1,87 @@
+package dao
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	hdError "github.com/odhoman/home-devices/internal/error"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// The uniqueness of mac + homeId is enforced with a guard item stored in the
+// same table.
+const (
+	macHomeGuardPrefix          = "MAC#"
+	conditionalCheckFailedCode  = "ConditionalCheckFailed"
+	macHomeGuardDeviceAttribute = "deviceId"
+)
+
+// normalizeMac returns the form the macs are stored in, upper case and with
+// colons, so the devices, their index and their guards use the same value
+// whatever the format of the request.
+func normalizeMac(mac string) string {
+	return strings.ToUpper(strings.ReplaceAll(mac, "-", ":"))
+}
+
+func buildMacHomeGuardId(mac string, homeId string) string {
+	return fmt.Sprintf("%s%s#HOME#%s", macHomeGuardPrefix, mac, homeId)
+}
+
+func isMacHomeGuardId(id string) bool {
+	return strings.HasPrefix(id, macHomeGuardPrefix)
+}
+
+func buildPutMacHomeGuard(tableName string, mac string, homeId string, deviceId string) types.TransactWriteItem {
+	return types.TransactWriteItem{
+		Put: &types.Put{
+			TableName: &tableName,
+			Item: map[string]types.AttributeValue{
+				"id":                        &types.AttributeValueMemberS{Value: buildMacHomeGuardId(mac, homeId)},
+				macHomeGuardDeviceAttribute: &types.AttributeValueMemberS{Value: deviceId},
+			},
+			ConditionExpression:                 aws.String("attribute_not_exists(id)"),
+			ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+		},
+	}
+}
+
+// newDeviceAlreadyExistsError returns the error of a mac + homeId pair that is
+// taken, with the id of the device holding it when the guard is known.
+func newDeviceAlreadyExistsError(mac string, homeId string, guard map[string]types.AttributeValue, cause error) *hdError.HomeDeviceError {
+
+	alreadyExistsError := hdError.ErrDeviceAlreadyExists.Wrap(cause).WithDetail("mac", mac).WithDetail("homeId", homeId)
+
+	if deviceId, ok := guard[macHomeGuardDeviceAttribute].(*types.AttributeValueMemberS); ok {
+		alreadyExistsError.WithDetail("deviceId", deviceId.Value)
+	}
+
+	return alreadyExistsError
+}
+
+func buildDeleteMacHomeGuard(tableName string, mac string, homeId string) types.TransactWriteItem {
+	return types.TransactWriteItem{
+		Delete: &types.Delete{
+			TableName: &tableName,
+			Key: map[string]types.AttributeValue{
+				"id": &types.AttributeValueMemberS{Value: buildMacHomeGuardId(mac, homeId)},
+			},
+		},
+	}
+}
+
+// getCancellationReasons returns the reasons of a cancelled transaction, one
+// per item and in the same order the items were sent.
+func getCancellationReasons(err error) ([]types.CancellationReason, bool) {
+	var canceledErr *types.TransactionCanceledException
+	if errors.As(err, &canceledErr) {
+		return canceledErr.CancellationReasons, true
+	}
+	return nil, false
+}
+
+func isConditionalCheckFailed(reasons []types.CancellationReason, index int) bool {
+	return index < len(reasons) && aws.ToString(reasons[index].Code) == conditionalCheckFailedCode
+}
@@ -0,0 +1,42 @@
+package dao
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeMac(t *testing.T) {
+	assert.Equal(t, "0A:1B:2C:3D:4E:5F", normalizeMac("0a-1b-2c-3d-4e-5f"))
+	assert.Equal(t, "0A:1B:2C:3D:4E:5F", normalizeMac("0A:1B:2C:3D:4E:5F"))
+	assert.Equal(t, "", normalizeMac(""))
+}
+
+func TestBuildMacHomeGuardId(t *testing.T) {
+	assert.Equal(t, "MAC#0A:1B:2C:3D:4E:5F#HOME#home12122", buildMacHomeGuardId("0A:1B:2C:3D:4E:5F", "home12122"))
+	assert.True(t, isMacHomeGuardId(buildMacHomeGuardId("0A:1B:2C:3D:4E:5F", "home12122")))
+	assert.False(t, isMacHomeGuardId("9a335b29-eec2-4dbc-8fc8-508f5433741e"))
+}
+
+func TestGetCancellationReasons(t *testing.T) {
+
+	err := &types.TransactionCanceledException{
+		CancellationReasons: []types.CancellationReason{
+			{Code: aws.String("None")},
+			{Code: aws.String(conditionalCheckFailedCode)},
+		},
+	}
+
+	reasons, ok := getCancellationReasons(err)
+
+	assert.True(t, ok)
+	assert.False(t, isConditionalCheckFailed(reasons, 0))
+	assert.True(t, isConditionalCheckFailed(reasons, 1))
+	assert.False(t, isConditionalCheckFailed(reasons, 2))
+
+	_, ok = getCancellationReasons(errors.New("another error"))
+	assert.False(t, ok)
+}
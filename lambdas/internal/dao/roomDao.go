@@ -0,0 +1,283 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	constants "github.com/odhoman/home-devices/internal/constants"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+)
+
+// RoomDao keeps the rooms of the homes.
+type RoomDao interface {
+	SaveRoom(ctx context.Context, homeId string, room request.CreateRoomRequest) (*response.RoomResponse, error)
+	GetRoom(ctx context.Context, homeId string, id string) (*response.RoomResponse, error)
+	ListRooms(ctx context.Context, homeId string) (*response.RoomListResponse, error)
+	UpdateRoom(ctx context.Context, room request.UpdateRoomRequest, homeId string, id string, expectedVersion int64) error
+	DeleteRoom(ctx context.Context, homeId string, id string, expectedVersion int64) error
+}
+
+type RoomDaoImpl struct {
+	DynamoDbApi dynamoDbApi
+}
+
+func (rDI RoomDaoImpl) SaveRoom(ctx context.Context, homeId string, room request.CreateRoomRequest) (*response.RoomResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.RoomTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	now := time.Now().Unix()
+	roomSaved := response.RoomResponse{
+		ID:         uuid.New().String(),
+		HomeID:     homeId,
+		Name:       room.Name,
+		CreatedAt:  now,
+		ModifiedAt: now,
+		Version:    1,
+	}
+
+	if _, err := rDI.DynamoDbApi.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: &tableName,
+		Item: map[string]types.AttributeValue{
+			"homeId":     &types.AttributeValueMemberS{Value: roomSaved.HomeID},
+			"id":         &types.AttributeValueMemberS{Value: roomSaved.ID},
+			"name":       &types.AttributeValueMemberS{Value: roomSaved.Name},
+			"createdAt":  &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", now)},
+			"modifiedAt": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", now)},
+			"version":    &types.AttributeValueMemberN{Value: "1"},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(id)"),
+	}); err != nil {
+		log.Printf("Error putting room into DynamoDB: %v", err)
+		return nil, hdError.ErrRoomNotCreated.Wrap(err)
+	}
+
+	return &roomSaved, nil
+}
+
+func (rDI RoomDaoImpl) GetRoom(ctx context.Context, homeId string, id string) (*response.RoomResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.RoomTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	result, err := rDI.DynamoDbApi.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &tableName,
+		Key:       buildRoomKey(homeId, id),
+	})
+
+	if err != nil {
+		log.Printf("Error getting room %v of the home %v from DynamoDB: %v", id, homeId, err)
+		return nil, hdError.ErrGettingRoom.Wrap(err)
+	}
+
+	if result.Item == nil {
+		return nil, hdError.ErrRoomNotFound.New()
+	}
+
+	room := mapDynamoDBItemToRoomResponse(result.Item)
+
+	return &room, nil
+}
+
+// ListRooms returns every room of the home.
+func (rDI RoomDaoImpl) ListRooms(ctx context.Context, homeId string) (*response.RoomListResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.RoomTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	rooms := []response.RoomResponse{}
+	var exclusiveStartKey map[string]types.AttributeValue
+
+	for {
+		result, err := rDI.DynamoDbApi.Query(ctx, &dynamodb.QueryInput{
+			TableName:              &tableName,
+			KeyConditionExpression: aws.String("homeId = :homeId"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":homeId": &types.AttributeValueMemberS{Value: homeId},
+			},
+			ExclusiveStartKey: exclusiveStartKey,
+		})
+
+		if err != nil {
+			log.Printf("Error listing rooms for homeId %v from DynamoDB: %v", homeId, err)
+			return nil, hdError.ErrListingRooms.Wrap(err)
+		}
+
+		for _, item := range result.Items {
+			rooms = append(rooms, mapDynamoDBItemToRoomResponse(item))
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return &response.RoomListResponse{Rooms: rooms}, nil
+		}
+
+		exclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+func (rDI RoomDaoImpl) UpdateRoom(ctx context.Context, room request.UpdateRoomRequest, homeId string, id string, expectedVersion int64) error {
+
+	tableName, error := getValuePropertyOrError(constants.RoomTableNameProperty)
+	if error != nil {
+		return error
+	}
+
+	expressionAttributeValues := map[string]types.AttributeValue{
+		":name":       &types.AttributeValueMemberS{Value: room.Name},
+		":modifiedAt": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", time.Now().Unix())},
+		":one":        &types.AttributeValueMemberN{Value: "1"},
+	}
+
+	if _, err := rDI.DynamoDbApi.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                           &tableName,
+		Key:                                 buildRoomKey(homeId, id),
+		UpdateExpression:                    aws.String("SET #name = :name, modifiedAt = :modifiedAt, version = version + :one"),
+		ConditionExpression:                 aws.String("attribute_exists(id)" + buildVersionCondition(expectedVersion, expressionAttributeValues)),
+		ExpressionAttributeNames:            map[string]string{"#name": "name"},
+		ExpressionAttributeValues:           expressionAttributeValues,
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	}); err != nil {
+
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			log.Printf("Room %v of the home %v was deleted or modified while updating it", id, homeId)
+			return getRoomConditionalCheckFailedError(conditionErr.Item)
+		}
+
+		log.Printf("Error updating room %v of the home %v into DynamoDB: %v", id, homeId, err)
+		return hdError.ErrUpdatingRoom.Wrap(err)
+	}
+
+	return nil
+}
+
+// DeleteRoom removes the room, unless devices were put in it by a
+// HomeDeviceDaoImpl with CountRoomDevices.
+func (rDI RoomDaoImpl) DeleteRoom(ctx context.Context, homeId string, id string, expectedVersion int64) error {
+
+	tableName, error := getValuePropertyOrError(constants.RoomTableNameProperty)
+	if error != nil {
+		return error
+	}
+
+	expressionAttributeValues := map[string]types.AttributeValue{
+		":zero": &types.AttributeValueMemberN{Value: "0"},
+	}
+
+	if _, err := rDI.DynamoDbApi.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                           &tableName,
+		Key:                                 buildRoomKey(homeId, id),
+		ConditionExpression:                 aws.String("attribute_exists(id) AND (attribute_not_exists(devices) OR devices <= :zero)" + buildVersionCondition(expectedVersion, expressionAttributeValues)),
+		ExpressionAttributeValues:           expressionAttributeValues,
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	}); err != nil {
+
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			log.Printf("Room %v of the home %v was deleted, modified or given devices while deleting it", id, homeId)
+			return getDeleteRoomConditionalCheckFailedError(conditionErr.Item)
+		}
+
+		log.Printf("Error deleting room %v of the home %v into DynamoDB: %v", id, homeId, err)
+		return hdError.ErrDeletingRoom.Wrap(err)
+	}
+
+	return nil
+}
+
+func getRoomConditionalCheckFailedError(item map[string]types.AttributeValue) error {
+
+	if item == nil {
+		return hdError.ErrRoomNotFound.New()
+	}
+
+	return hdError.ErrVersionConflict.WithMessage(hdError.RoomVersionConflictMessage)
+}
+
+func getDeleteRoomConditionalCheckFailedError(item map[string]types.AttributeValue) error {
+
+	if item != nil && getInt64Attribute(item, "devices") > 0 {
+		return hdError.ErrRoomHasDevices.New()
+	}
+
+	return getRoomConditionalCheckFailedError(item)
+}
+
+// roomDevicesDelta changes the number of devices of a room.
+type roomDevicesDelta struct {
+	homeId string
+	roomId string
+	delta  int64
+}
+
+// buildRoomDevicesDeltas returns the rooms a device leaves and enters with a
+// write, before being nil when it is created or restored and after when it is
+// deleted.
+func buildRoomDevicesDeltas(before *response.HomdeDeviceResponse, after *response.HomdeDeviceResponse) []roomDevicesDelta {
+
+	deltas := []roomDevicesDelta{}
+
+	if before != nil && after != nil && before.HomeID == after.HomeID && before.RoomID == after.RoomID {
+		return deltas
+	}
+
+	if before != nil && before.RoomID != "" {
+		deltas = append(deltas, roomDevicesDelta{homeId: before.HomeID, roomId: before.RoomID, delta: -1})
+	}
+
+	if after != nil && after.RoomID != "" {
+		deltas = append(deltas, roomDevicesDelta{homeId: after.HomeID, roomId: after.RoomID, delta: 1})
+	}
+
+	return deltas
+}
+
+// buildAddRoomDevices changes the number of devices of a room, which must
+// still exist.
+func buildAddRoomDevices(tableName string, delta roomDevicesDelta) types.TransactWriteItem {
+	return types.TransactWriteItem{
+		Update: &types.Update{
+			TableName:           &tableName,
+			Key:                 buildRoomKey(delta.homeId, delta.roomId),
+			UpdateExpression:    aws.String("ADD devices :delta"),
+			ConditionExpression: aws.String("attribute_exists(id)"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":delta": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", delta.delta)},
+			},
+		},
+	}
+}
+
+func buildRoomKey(homeId string, id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"homeId": &types.AttributeValueMemberS{Value: homeId},
+		"id":     &types.AttributeValueMemberS{Value: id},
+	}
+}
+
+func mapDynamoDBItemToRoomResponse(item map[string]types.AttributeValue) response.RoomResponse {
+	return response.RoomResponse{
+		ID:         getStringAttribute(item, "id"),
+		HomeID:     getStringAttribute(item, "homeId"),
+		Name:       getStringAttribute(item, "name"),
+		CreatedAt:  getInt64Attribute(item, "createdAt"),
+		ModifiedAt: getInt64Attribute(item, "modifiedAt"),
+		Version:    getInt64Attribute(item, "version"),
+	}
+}
@@ -0,0 +1,16 @@
+package dao_test
+
+import (
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+	"github.com/odhoman/home-devices/internal/dao/daotest"
+	"github.com/odhoman/home-devices/internal/mock"
+)
+
+func TestHomeDeviceDaoImpl_RoomDevicesConformance(t *testing.T) {
+	daotest.RunRoomDevicesConformanceSuite(t, func() (dao.HomeDeviceDao, dao.RoomDao) {
+		dynamoDbApi := mock.GetDynamoConnectionTestFromEnpoint()
+		return dao.HomeDeviceDaoImpl{DynamoDbApi: dynamoDbApi, CountRoomDevices: true}, dao.RoomDaoImpl{DynamoDbApi: dynamoDbApi}
+	})
+}
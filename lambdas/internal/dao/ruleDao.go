@@ -0,0 +1,471 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	constants "github.com/odhoman/home-devices/internal/constants"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+)
+
+// RuleDao keeps the rules of the homes.
+type RuleDao interface {
+	SaveRule(ctx context.Context, homeId string, rule request.RuleRequest) (*response.RuleResponse, error)
+	GetRule(ctx context.Context, homeId string, id string) (*response.RuleResponse, error)
+	ListRules(ctx context.Context, homeId string) (*response.RuleListResponse, error)
+	ListTriggeredRules(ctx context.Context, deviceId string) ([]response.RuleResponse, error)
+	UpdateRule(ctx context.Context, rule request.RuleRequest, homeId string, id string, expectedVersion int64) error
+	DeleteRule(ctx context.Context, homeId string, id string, expectedVersion int64) error
+	ClaimRuleFiring(ctx context.Context, homeId string, id string, readingAt int64, firedAt int64, cooldownSeconds int64) error
+}
+
+type RuleDaoImpl struct {
+	DynamoDbApi dynamoDbApi
+}
+
+func (rDI RuleDaoImpl) SaveRule(ctx context.Context, homeId string, rule request.RuleRequest) (*response.RuleResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.RuleTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	now := time.Now().Unix()
+	ruleSaved := mapRuleRequestToRuleResponse(rule)
+	ruleSaved.ID = uuid.New().String()
+	ruleSaved.HomeID = homeId
+	ruleSaved.CreatedAt = now
+	ruleSaved.ModifiedAt = now
+	ruleSaved.Version = 1
+
+	item, err := mapRuleToDynamoDBItem(ruleSaved)
+	if err != nil {
+		log.Printf("Error serializing the action params of the rule %v of the home %v: %v", ruleSaved.ID, homeId, err)
+		return nil, hdError.ErrRuleNotCreated.Wrap(err)
+	}
+
+	if _, err := rDI.DynamoDbApi.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           &tableName,
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(id)"),
+	}); err != nil {
+		log.Printf("Error putting rule into DynamoDB: %v", err)
+		return nil, hdError.ErrRuleNotCreated.Wrap(err)
+	}
+
+	return &ruleSaved, nil
+}
+
+func (rDI RuleDaoImpl) GetRule(ctx context.Context, homeId string, id string) (*response.RuleResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.RuleTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	result, err := rDI.DynamoDbApi.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &tableName,
+		Key:       buildRuleKey(homeId, id),
+	})
+
+	if err != nil {
+		log.Printf("Error getting rule %v of the home %v from DynamoDB: %v", id, homeId, err)
+		return nil, hdError.ErrGettingRule.Wrap(err)
+	}
+
+	if result.Item == nil {
+		return nil, hdError.ErrRuleNotFound.New()
+	}
+
+	rule := mapDynamoDBItemToRuleResponse(result.Item)
+
+	return &rule, nil
+}
+
+// ListRules returns every rule of the home.
+func (rDI RuleDaoImpl) ListRules(ctx context.Context, homeId string) (*response.RuleListResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.RuleTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	rules, err := rDI.queryRules(ctx, &dynamodb.QueryInput{
+		TableName:              &tableName,
+		KeyConditionExpression: aws.String("homeId = :homeId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":homeId": &types.AttributeValueMemberS{Value: homeId},
+		},
+	})
+
+	if err != nil {
+		log.Printf("Error listing rules for homeId %v from DynamoDB: %v", homeId, err)
+		return nil, hdError.ErrListingRules.Wrap(err)
+	}
+
+	return &response.RuleListResponse{Rules: rules}, nil
+}
+
+// ListTriggeredRules returns the rules whose trigger is a metric of the device,
+// sorted by id.
+func (rDI RuleDaoImpl) ListTriggeredRules(ctx context.Context, deviceId string) ([]response.RuleResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.RuleTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	triggerDeviceIdIndexName, error := getValuePropertyOrError(constants.TriggerDeviceIdIndexProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	rules, err := rDI.queryRules(ctx, &dynamodb.QueryInput{
+		TableName:              &tableName,
+		IndexName:              &triggerDeviceIdIndexName,
+		KeyConditionExpression: aws.String("triggerDeviceId = :triggerDeviceId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":triggerDeviceId": &types.AttributeValueMemberS{Value: deviceId},
+		},
+	})
+
+	if err != nil {
+		log.Printf("Error listing the rules triggered by the device %v from DynamoDB: %v", deviceId, err)
+		return nil, hdError.ErrListingRules.Wrap(err)
+	}
+
+	return rules, nil
+}
+
+// UpdateRule replaces the rule.
+func (rDI RuleDaoImpl) UpdateRule(ctx context.Context, rule request.RuleRequest, homeId string, id string, expectedVersion int64) error {
+
+	tableName, error := getValuePropertyOrError(constants.RuleTableNameProperty)
+	if error != nil {
+		return error
+	}
+
+	ruleUpdated := mapRuleRequestToRuleResponse(rule)
+	params, err := json.Marshal(ruleUpdated.Action.Params)
+	if err != nil {
+		log.Printf("Error serializing the action params of the rule %v of the home %v: %v", id, homeId, err)
+		return hdError.ErrUpdatingRule.Wrap(err)
+	}
+
+	updateExpression := "SET #name = :name, enabled = :enabled, triggerDeviceId = :triggerDeviceId, triggerMetric = :triggerMetric, " +
+		"triggerOperator = :triggerOperator, triggerValue = :triggerValue, actionDeviceId = :actionDeviceId, " +
+		"actionCommand = :actionCommand, actionParams = :actionParams, cooldownSeconds = :cooldownSeconds, " +
+		"modifiedAt = :modifiedAt, version = version + :one"
+
+	expressionAttributeValues := map[string]types.AttributeValue{
+		":name":            &types.AttributeValueMemberS{Value: ruleUpdated.Name},
+		":enabled":         &types.AttributeValueMemberBOOL{Value: ruleUpdated.Enabled},
+		":triggerDeviceId": &types.AttributeValueMemberS{Value: ruleUpdated.Trigger.DeviceID},
+		":triggerMetric":   &types.AttributeValueMemberS{Value: ruleUpdated.Trigger.Metric},
+		":triggerOperator": &types.AttributeValueMemberS{Value: ruleUpdated.Trigger.Operator},
+		":triggerValue":    &types.AttributeValueMemberN{Value: strconv.FormatFloat(ruleUpdated.Trigger.Value, 'f', -1, 64)},
+		":actionDeviceId":  &types.AttributeValueMemberS{Value: ruleUpdated.Action.DeviceID},
+		":actionCommand":   &types.AttributeValueMemberS{Value: ruleUpdated.Action.Command},
+		":actionParams":    &types.AttributeValueMemberS{Value: string(params)},
+		":cooldownSeconds": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", ruleUpdated.CooldownSeconds)},
+		":modifiedAt":      &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", time.Now().Unix())},
+		":one":             &types.AttributeValueMemberN{Value: "1"},
+	}
+
+	if ruleUpdated.TimeWindow != nil {
+		updateExpression += ", windowFrom = :windowFrom, windowTo = :windowTo, windowTimezone = :windowTimezone"
+		expressionAttributeValues[":windowFrom"] = &types.AttributeValueMemberS{Value: ruleUpdated.TimeWindow.From}
+		expressionAttributeValues[":windowTo"] = &types.AttributeValueMemberS{Value: ruleUpdated.TimeWindow.To}
+		expressionAttributeValues[":windowTimezone"] = &types.AttributeValueMemberS{Value: ruleUpdated.TimeWindow.Timezone}
+	} else {
+		updateExpression += " REMOVE windowFrom, windowTo, windowTimezone"
+	}
+
+	if _, err := rDI.DynamoDbApi.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                           &tableName,
+		Key:                                 buildRuleKey(homeId, id),
+		UpdateExpression:                    aws.String(updateExpression),
+		ConditionExpression:                 aws.String("attribute_exists(id)" + buildVersionCondition(expectedVersion, expressionAttributeValues)),
+		ExpressionAttributeNames:            map[string]string{"#name": "name"},
+		ExpressionAttributeValues:           expressionAttributeValues,
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	}); err != nil {
+
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			log.Printf("Rule %v of the home %v was deleted or modified while updating it", id, homeId)
+			return getRuleConditionalCheckFailedError(conditionErr.Item)
+		}
+
+		log.Printf("Error updating rule %v of the home %v into DynamoDB: %v", id, homeId, err)
+		return hdError.ErrUpdatingRule.Wrap(err)
+	}
+
+	return nil
+}
+
+func (rDI RuleDaoImpl) DeleteRule(ctx context.Context, homeId string, id string, expectedVersion int64) error {
+
+	tableName, error := getValuePropertyOrError(constants.RuleTableNameProperty)
+	if error != nil {
+		return error
+	}
+
+	expressionAttributeValues := map[string]types.AttributeValue{}
+	input := &dynamodb.DeleteItemInput{
+		TableName:                           &tableName,
+		Key:                                 buildRuleKey(homeId, id),
+		ConditionExpression:                 aws.String("attribute_exists(id)" + buildVersionCondition(expectedVersion, expressionAttributeValues)),
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	}
+
+	if len(expressionAttributeValues) > 0 {
+		input.ExpressionAttributeValues = expressionAttributeValues
+	}
+
+	if _, err := rDI.DynamoDbApi.DeleteItem(ctx, input); err != nil {
+
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			log.Printf("Rule %v of the home %v was deleted or modified while deleting it", id, homeId)
+			return getRuleConditionalCheckFailedError(conditionErr.Item)
+		}
+
+		log.Printf("Error deleting rule %v of the home %v into DynamoDB: %v", id, homeId, err)
+		return hdError.ErrDeletingRule.Wrap(err)
+	}
+
+	return nil
+}
+
+// ClaimRuleFiring records that the reading taken at readingAt fires the rule at
+// firedAt, unless the rule already fired less than cooldownSeconds before or
+// for the same reading or a later one.
+func (rDI RuleDaoImpl) ClaimRuleFiring(ctx context.Context, homeId string, id string, readingAt int64, firedAt int64, cooldownSeconds int64) error {
+
+	tableName, error := getValuePropertyOrError(constants.RuleTableNameProperty)
+	if error != nil {
+		return error
+	}
+
+	if _, err := rDI.DynamoDbApi.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:        &tableName,
+		Key:              buildRuleKey(homeId, id),
+		UpdateExpression: aws.String("SET lastFiredAt = :firedAt, lastFiredReadingAt = :readingAt"),
+		ConditionExpression: aws.String("attribute_exists(id) AND (attribute_not_exists(lastFiredAt) OR lastFiredAt <= :threshold) AND " +
+			"(attribute_not_exists(lastFiredReadingAt) OR lastFiredReadingAt < :readingAt)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":firedAt":   &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", firedAt)},
+			":readingAt": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", readingAt)},
+			":threshold": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", firedAt-cooldownSeconds)},
+		},
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	}); err != nil {
+
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			if conditionErr.Item == nil {
+				return hdError.ErrRuleNotFound.New()
+			}
+
+			if getInt64Attribute(conditionErr.Item, "lastFiredReadingAt") >= readingAt {
+				return hdError.ErrRuleAlreadyFired.New()
+			}
+
+			return hdError.ErrRuleInCooldown.New()
+		}
+
+		log.Printf("Error claiming the firing of the rule %v of the home %v into DynamoDB: %v", id, homeId, err)
+		return hdError.ErrUpdatingRule.Wrap(err)
+	}
+
+	return nil
+}
+
+// queryRules reads every page of the query.
+func (rDI RuleDaoImpl) queryRules(ctx context.Context, input *dynamodb.QueryInput) ([]response.RuleResponse, error) {
+
+	rules := []response.RuleResponse{}
+
+	for {
+		result, err := rDI.DynamoDbApi.Query(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			rules = append(rules, mapDynamoDBItemToRuleResponse(item))
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return rules, nil
+		}
+
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+func getRuleConditionalCheckFailedError(item map[string]types.AttributeValue) error {
+
+	if item == nil {
+		return hdError.ErrRuleNotFound.New()
+	}
+
+	return hdError.ErrVersionConflict.WithMessage(hdError.RuleVersionConflictMessage)
+}
+
+func buildRuleKey(homeId string, id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"homeId": &types.AttributeValueMemberS{Value: homeId},
+		"id":     &types.AttributeValueMemberS{Value: id},
+	}
+}
+
+// mapRuleRequestToRuleResponse copies the fields of the request, with the
+// defaults of enabled and the cooldown.
+func mapRuleRequestToRuleResponse(rule request.RuleRequest) response.RuleResponse {
+
+	ruleResponse := response.RuleResponse{
+		Name:            rule.Name,
+		Enabled:         rule.Enabled == nil || *rule.Enabled,
+		CooldownSeconds: rule.CooldownSeconds,
+		Trigger: response.RuleTriggerResponse{
+			DeviceID: rule.Trigger.DeviceID,
+			Metric:   rule.Trigger.Metric,
+			Operator: rule.Trigger.Operator,
+		},
+		Action: response.RuleActionResponse{
+			DeviceID: rule.Action.DeviceID,
+			Command:  rule.Action.Command,
+			Params:   rule.Action.Params,
+		},
+	}
+
+	if rule.Trigger.Value != nil {
+		ruleResponse.Trigger.Value = *rule.Trigger.Value
+	}
+
+	if ruleResponse.CooldownSeconds <= 0 {
+		ruleResponse.CooldownSeconds = constants.DefaultRuleCooldownSeconds
+	}
+
+	if ruleResponse.Action.Params == nil {
+		ruleResponse.Action.Params = map[string]interface{}{}
+	}
+
+	if rule.TimeWindow != nil {
+		ruleResponse.TimeWindow = &response.RuleTimeWindowResponse{
+			From:     rule.TimeWindow.From,
+			To:       rule.TimeWindow.To,
+			Timezone: rule.TimeWindow.Timezone,
+		}
+	}
+
+	return ruleResponse
+}
+
+// mapRuleToDynamoDBItem flattens the trigger, the time window and the action
+// into attributes of the item; the trigger device id is the key of the index.
+func mapRuleToDynamoDBItem(rule response.RuleResponse) (map[string]types.AttributeValue, error) {
+
+	params, err := json.Marshal(rule.Action.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	item := map[string]types.AttributeValue{
+		"homeId":          &types.AttributeValueMemberS{Value: rule.HomeID},
+		"id":              &types.AttributeValueMemberS{Value: rule.ID},
+		"name":            &types.AttributeValueMemberS{Value: rule.Name},
+		"enabled":         &types.AttributeValueMemberBOOL{Value: rule.Enabled},
+		"triggerDeviceId": &types.AttributeValueMemberS{Value: rule.Trigger.DeviceID},
+		"triggerMetric":   &types.AttributeValueMemberS{Value: rule.Trigger.Metric},
+		"triggerOperator": &types.AttributeValueMemberS{Value: rule.Trigger.Operator},
+		"triggerValue":    &types.AttributeValueMemberN{Value: strconv.FormatFloat(rule.Trigger.Value, 'f', -1, 64)},
+		"actionDeviceId":  &types.AttributeValueMemberS{Value: rule.Action.DeviceID},
+		"actionCommand":   &types.AttributeValueMemberS{Value: rule.Action.Command},
+		"actionParams":    &types.AttributeValueMemberS{Value: string(params)},
+		"cooldownSeconds": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", rule.CooldownSeconds)},
+		"createdAt":       &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", rule.CreatedAt)},
+		"modifiedAt":      &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", rule.ModifiedAt)},
+		"version":         &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", rule.Version)},
+	}
+
+	if rule.TimeWindow != nil {
+		item["windowFrom"] = &types.AttributeValueMemberS{Value: rule.TimeWindow.From}
+		item["windowTo"] = &types.AttributeValueMemberS{Value: rule.TimeWindow.To}
+		item["windowTimezone"] = &types.AttributeValueMemberS{Value: rule.TimeWindow.Timezone}
+	}
+
+	return item, nil
+}
+
+func mapDynamoDBItemToRuleResponse(item map[string]types.AttributeValue) response.RuleResponse {
+
+	rule := response.RuleResponse{
+		ID:      getStringAttribute(item, "id"),
+		HomeID:  getStringAttribute(item, "homeId"),
+		Name:    getStringAttribute(item, "name"),
+		Enabled: getBoolAttribute(item, "enabled"),
+		Trigger: response.RuleTriggerResponse{
+			DeviceID: getStringAttribute(item, "triggerDeviceId"),
+			Metric:   getStringAttribute(item, "triggerMetric"),
+			Operator: getStringAttribute(item, "triggerOperator"),
+			Value:    getFloat64Attribute(item, "triggerValue"),
+		},
+		Action: response.RuleActionResponse{
+			DeviceID: getStringAttribute(item, "actionDeviceId"),
+			Command:  getStringAttribute(item, "actionCommand"),
+			Params:   map[string]interface{}{},
+		},
+		CooldownSeconds: getInt64Attribute(item, "cooldownSeconds"),
+		LastFiredAt:     getInt64Attribute(item, "lastFiredAt"),
+		CreatedAt:       getInt64Attribute(item, "createdAt"),
+		ModifiedAt:      getInt64Attribute(item, "modifiedAt"),
+		Version:         getInt64Attribute(item, "version"),
+	}
+
+	if params := getStringAttribute(item, "actionParams"); params != "" {
+		if err := json.Unmarshal([]byte(params), &rule.Action.Params); err != nil {
+			log.Printf("Error reading the action params of the rule %v: %v", rule.ID, err)
+		}
+	}
+
+	if from := getStringAttribute(item, "windowFrom"); from != "" {
+		rule.TimeWindow = &response.RuleTimeWindowResponse{
+			From:     from,
+			To:       getStringAttribute(item, "windowTo"),
+			Timezone: getStringAttribute(item, "windowTimezone"),
+		}
+	}
+
+	return rule
+}
+
+func getBoolAttribute(item map[string]types.AttributeValue, key string) bool {
+	if v, ok := item[key].(*types.AttributeValueMemberBOOL); ok {
+		return v.Value
+	}
+	return false
+}
+
+func getFloat64Attribute(item map[string]types.AttributeValue, key string) float64 {
+	if v, ok := item[key].(*types.AttributeValueMemberN); ok {
+		if value, err := strconv.ParseFloat(v.Value, 64); err == nil {
+			return value
+		}
+	}
+	return 0
+}
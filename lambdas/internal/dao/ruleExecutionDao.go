@@ -0,0 +1,125 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	constants "github.com/odhoman/home-devices/internal/constants"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// RuleExecutionDao keeps the execution log of the rules.
+type RuleExecutionDao interface {
+	SaveRuleExecution(ctx context.Context, execution response.RuleExecutionResponse) (*response.RuleExecutionResponse, error)
+	ListRuleExecutions(ctx context.Context, ruleId string, limit int32) (*response.RuleExecutionListResponse, error)
+}
+
+type RuleExecutionDaoImpl struct {
+	DynamoDbApi dynamoDbApi
+}
+
+func (rEDI RuleExecutionDaoImpl) SaveRuleExecution(ctx context.Context, execution response.RuleExecutionResponse) (*response.RuleExecutionResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.RuleExecutionTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	execution.ID = buildChangeId(time.Now())
+
+	if _, err := rEDI.DynamoDbApi.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           &tableName,
+		Item:                mapRuleExecutionToDynamoDBItem(execution),
+		ConditionExpression: aws.String("attribute_not_exists(id)"),
+	}); err != nil {
+		log.Printf("Error saving the execution of the rule %v: %v", execution.RuleID, err)
+		return nil, hdError.ErrSavingRuleExecution.Wrap(err)
+	}
+
+	return &execution, nil
+}
+
+// ListRuleExecutions returns the last executions of the rule, newest first.
+func (rEDI RuleExecutionDaoImpl) ListRuleExecutions(ctx context.Context, ruleId string, limit int32) (*response.RuleExecutionListResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.RuleExecutionTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	result, err := rEDI.DynamoDbApi.Query(ctx, &dynamodb.QueryInput{
+		TableName:              &tableName,
+		KeyConditionExpression: aws.String("ruleId = :ruleId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":ruleId": &types.AttributeValueMemberS{Value: ruleId},
+		},
+		// newest executions first
+		ScanIndexForward: aws.Bool(false),
+		Limit:            aws.Int32(resolveRuleExecutionsLimit(limit)),
+	})
+
+	if err != nil {
+		log.Printf("Error listing the executions of the rule %v from DynamoDB: %v", ruleId, err)
+		return nil, hdError.ErrListingRuleExecutions.Wrap(err)
+	}
+
+	executions := make([]response.RuleExecutionResponse, 0, len(result.Items))
+	for _, item := range result.Items {
+		executions = append(executions, mapDynamoDBItemToRuleExecution(item))
+	}
+
+	return &response.RuleExecutionListResponse{Executions: executions}, nil
+}
+
+func resolveRuleExecutionsLimit(limit int32) int32 {
+	if limit <= 0 || limit > constants.MaxListRuleExecutionsLimit {
+		return constants.MaxListRuleExecutionsLimit
+	}
+
+	return limit
+}
+
+func mapRuleExecutionToDynamoDBItem(execution response.RuleExecutionResponse) map[string]types.AttributeValue {
+
+	item := map[string]types.AttributeValue{
+		"ruleId":     &types.AttributeValueMemberS{Value: execution.RuleID},
+		"id":         &types.AttributeValueMemberS{Value: execution.ID},
+		"deviceId":   &types.AttributeValueMemberS{Value: execution.DeviceID},
+		"metric":     &types.AttributeValueMemberS{Value: execution.Metric},
+		"value":      &types.AttributeValueMemberN{Value: strconv.FormatFloat(execution.Value, 'f', -1, 64)},
+		"status":     &types.AttributeValueMemberS{Value: execution.Status},
+		"executedAt": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", execution.ExecutedAt)},
+	}
+
+	if execution.CommandID != "" {
+		item["commandId"] = &types.AttributeValueMemberS{Value: execution.CommandID}
+	}
+
+	if execution.Error != "" {
+		item["error"] = &types.AttributeValueMemberS{Value: execution.Error}
+	}
+
+	return item
+}
+
+func mapDynamoDBItemToRuleExecution(item map[string]types.AttributeValue) response.RuleExecutionResponse {
+	return response.RuleExecutionResponse{
+		ID:         getStringAttribute(item, "id"),
+		RuleID:     getStringAttribute(item, "ruleId"),
+		DeviceID:   getStringAttribute(item, "deviceId"),
+		Metric:     getStringAttribute(item, "metric"),
+		Value:      getFloat64Attribute(item, "value"),
+		Status:     getStringAttribute(item, "status"),
+		CommandID:  getStringAttribute(item, "commandId"),
+		Error:      getStringAttribute(item, "error"),
+		ExecutedAt: getInt64Attribute(item, "executedAt"),
+	}
+}
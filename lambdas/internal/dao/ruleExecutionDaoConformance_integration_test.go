@@ -0,0 +1,15 @@
+package dao_test
+
+import (
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+	"github.com/odhoman/home-devices/internal/dao/daotest"
+	"github.com/odhoman/home-devices/internal/mock"
+)
+
+func TestRuleExecutionDaoImpl_Conformance(t *testing.T) {
+	daotest.RunRuleExecutionDaoConformanceSuite(t, func() dao.RuleExecutionDao {
+		return dao.RuleExecutionDaoImpl{DynamoDbApi: mock.GetDynamoConnectionTestFromEnpoint()}
+	})
+}
@@ -0,0 +1,279 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	constants "github.com/odhoman/home-devices/internal/constants"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+)
+
+// SceneDao keeps the scenes of the homes.
+type SceneDao interface {
+	SaveScene(ctx context.Context, homeId string, scene request.SceneRequest) (*response.SceneResponse, error)
+	GetScene(ctx context.Context, homeId string, id string) (*response.SceneResponse, error)
+	ListScenes(ctx context.Context, homeId string) (*response.SceneListResponse, error)
+	UpdateScene(ctx context.Context, scene request.SceneRequest, homeId string, id string, expectedVersion int64) error
+	DeleteScene(ctx context.Context, homeId string, id string, expectedVersion int64) error
+}
+
+type SceneDaoImpl struct {
+	DynamoDbApi dynamoDbApi
+}
+
+func (sDI SceneDaoImpl) SaveScene(ctx context.Context, homeId string, scene request.SceneRequest) (*response.SceneResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.SceneTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	now := time.Now().Unix()
+	sceneSaved := response.SceneResponse{
+		ID:         uuid.New().String(),
+		HomeID:     homeId,
+		Name:       scene.Name,
+		Devices:    mapSceneDevicesToResponse(scene.Devices),
+		CreatedAt:  now,
+		ModifiedAt: now,
+		Version:    1,
+	}
+
+	item, err := mapSceneToDynamoDBItem(sceneSaved)
+	if err != nil {
+		log.Printf("Error serializing the devices of the scene %v of the home %v: %v", sceneSaved.ID, homeId, err)
+		return nil, hdError.ErrSceneNotCreated.Wrap(err)
+	}
+
+	if _, err := sDI.DynamoDbApi.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           &tableName,
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(id)"),
+	}); err != nil {
+		log.Printf("Error putting scene into DynamoDB: %v", err)
+		return nil, hdError.ErrSceneNotCreated.Wrap(err)
+	}
+
+	return &sceneSaved, nil
+}
+
+func (sDI SceneDaoImpl) GetScene(ctx context.Context, homeId string, id string) (*response.SceneResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.SceneTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	result, err := sDI.DynamoDbApi.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &tableName,
+		Key:       buildSceneKey(homeId, id),
+	})
+
+	if err != nil {
+		log.Printf("Error getting scene %v of the home %v from DynamoDB: %v", id, homeId, err)
+		return nil, hdError.ErrGettingScene.Wrap(err)
+	}
+
+	if result.Item == nil {
+		return nil, hdError.ErrSceneNotFound.New()
+	}
+
+	scene := mapDynamoDBItemToSceneResponse(result.Item)
+
+	return &scene, nil
+}
+
+// ListScenes returns every scene of the home.
+func (sDI SceneDaoImpl) ListScenes(ctx context.Context, homeId string) (*response.SceneListResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.SceneTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:              &tableName,
+		KeyConditionExpression: aws.String("homeId = :homeId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":homeId": &types.AttributeValueMemberS{Value: homeId},
+		},
+	}
+
+	scenes := []response.SceneResponse{}
+
+	for {
+		result, err := sDI.DynamoDbApi.Query(ctx, input)
+		if err != nil {
+			log.Printf("Error listing scenes for homeId %v from DynamoDB: %v", homeId, err)
+			return nil, hdError.ErrListingScenes.Wrap(err)
+		}
+
+		for _, item := range result.Items {
+			scenes = append(scenes, mapDynamoDBItemToSceneResponse(item))
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return &response.SceneListResponse{Scenes: scenes}, nil
+		}
+
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+func (sDI SceneDaoImpl) UpdateScene(ctx context.Context, scene request.SceneRequest, homeId string, id string, expectedVersion int64) error {
+
+	tableName, error := getValuePropertyOrError(constants.SceneTableNameProperty)
+	if error != nil {
+		return error
+	}
+
+	devices, err := json.Marshal(mapSceneDevicesToResponse(scene.Devices))
+	if err != nil {
+		log.Printf("Error serializing the devices of the scene %v of the home %v: %v", id, homeId, err)
+		return hdError.ErrUpdatingScene.Wrap(err)
+	}
+
+	expressionAttributeValues := map[string]types.AttributeValue{
+		":name":       &types.AttributeValueMemberS{Value: scene.Name},
+		":devices":    &types.AttributeValueMemberS{Value: string(devices)},
+		":modifiedAt": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", time.Now().Unix())},
+		":one":        &types.AttributeValueMemberN{Value: "1"},
+	}
+
+	if _, err := sDI.DynamoDbApi.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                           &tableName,
+		Key:                                 buildSceneKey(homeId, id),
+		UpdateExpression:                    aws.String("SET #name = :name, devices = :devices, modifiedAt = :modifiedAt, version = version + :one"),
+		ConditionExpression:                 aws.String("attribute_exists(id)" + buildVersionCondition(expectedVersion, expressionAttributeValues)),
+		ExpressionAttributeNames:            map[string]string{"#name": "name"},
+		ExpressionAttributeValues:           expressionAttributeValues,
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	}); err != nil {
+
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			log.Printf("Scene %v of the home %v was deleted or modified while updating it", id, homeId)
+			return getSceneConditionalCheckFailedError(conditionErr.Item)
+		}
+
+		log.Printf("Error updating scene %v of the home %v into DynamoDB: %v", id, homeId, err)
+		return hdError.ErrUpdatingScene.Wrap(err)
+	}
+
+	return nil
+}
+
+func (sDI SceneDaoImpl) DeleteScene(ctx context.Context, homeId string, id string, expectedVersion int64) error {
+
+	tableName, error := getValuePropertyOrError(constants.SceneTableNameProperty)
+	if error != nil {
+		return error
+	}
+
+	expressionAttributeValues := map[string]types.AttributeValue{}
+	input := &dynamodb.DeleteItemInput{
+		TableName:                           &tableName,
+		Key:                                 buildSceneKey(homeId, id),
+		ConditionExpression:                 aws.String("attribute_exists(id)" + buildVersionCondition(expectedVersion, expressionAttributeValues)),
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	}
+
+	if len(expressionAttributeValues) > 0 {
+		input.ExpressionAttributeValues = expressionAttributeValues
+	}
+
+	if _, err := sDI.DynamoDbApi.DeleteItem(ctx, input); err != nil {
+
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			log.Printf("Scene %v of the home %v was deleted or modified while deleting it", id, homeId)
+			return getSceneConditionalCheckFailedError(conditionErr.Item)
+		}
+
+		log.Printf("Error deleting scene %v of the home %v into DynamoDB: %v", id, homeId, err)
+		return hdError.ErrDeletingScene.Wrap(err)
+	}
+
+	return nil
+}
+
+func getSceneConditionalCheckFailedError(item map[string]types.AttributeValue) error {
+
+	if item == nil {
+		return hdError.ErrSceneNotFound.New()
+	}
+
+	return hdError.ErrVersionConflict.WithMessage(hdError.SceneVersionConflictMessage)
+}
+
+func buildSceneKey(homeId string, id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"homeId": &types.AttributeValueMemberS{Value: homeId},
+		"id":     &types.AttributeValueMemberS{Value: id},
+	}
+}
+
+func mapSceneDevicesToResponse(devices []request.SceneDevice) []response.SceneDeviceResponse {
+
+	sceneDevices := []response.SceneDeviceResponse{}
+	for _, device := range devices {
+		sceneDevices = append(sceneDevices, response.SceneDeviceResponse{
+			DeviceID: device.DeviceID,
+			State:    device.State,
+		})
+	}
+
+	return sceneDevices
+}
+
+// mapSceneToDynamoDBItem keeps the devices and their states as a JSON
+// document, like the params of the commands; they are only read whole.
+func mapSceneToDynamoDBItem(scene response.SceneResponse) (map[string]types.AttributeValue, error) {
+
+	devices, err := json.Marshal(scene.Devices)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]types.AttributeValue{
+		"homeId":     &types.AttributeValueMemberS{Value: scene.HomeID},
+		"id":         &types.AttributeValueMemberS{Value: scene.ID},
+		"name":       &types.AttributeValueMemberS{Value: scene.Name},
+		"devices":    &types.AttributeValueMemberS{Value: string(devices)},
+		"createdAt":  &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", scene.CreatedAt)},
+		"modifiedAt": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", scene.ModifiedAt)},
+		"version":    &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", scene.Version)},
+	}, nil
+}
+
+func mapDynamoDBItemToSceneResponse(item map[string]types.AttributeValue) response.SceneResponse {
+
+	scene := response.SceneResponse{
+		ID:         getStringAttribute(item, "id"),
+		HomeID:     getStringAttribute(item, "homeId"),
+		Name:       getStringAttribute(item, "name"),
+		Devices:    []response.SceneDeviceResponse{},
+		CreatedAt:  getInt64Attribute(item, "createdAt"),
+		ModifiedAt: getInt64Attribute(item, "modifiedAt"),
+		Version:    getInt64Attribute(item, "version"),
+	}
+
+	if devices := getStringAttribute(item, "devices"); devices != "" {
+		if err := json.Unmarshal([]byte(devices), &scene.Devices); err != nil {
+			log.Printf("Error reading the devices of the scene %v: %v", scene.ID, err)
+		}
+	}
+
+	return scene
+}
@@ -0,0 +1,400 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	constants "github.com/odhoman/home-devices/internal/constants"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// scheduleRunStateActive is the only value of runState, the partition key of
+// the index of the next runs.
+const scheduleRunStateActive = "active"
+
+// ScheduleDao keeps the schedules of the homes.
+type ScheduleDao interface {
+	SaveSchedule(ctx context.Context, schedule response.ScheduleResponse) (*response.ScheduleResponse, error)
+	GetSchedule(ctx context.Context, homeId string, id string) (*response.ScheduleResponse, error)
+	ListSchedules(ctx context.Context, homeId string) (*response.ScheduleListResponse, error)
+	ListDueSchedules(ctx context.Context, now int64) ([]response.ScheduleResponse, error)
+	UpdateSchedule(ctx context.Context, schedule response.ScheduleResponse, expectedVersion int64) error
+	DeleteSchedule(ctx context.Context, homeId string, id string, expectedVersion int64) error
+	ClaimScheduleRun(ctx context.Context, homeId string, id string, runAt int64, nextRunAt int64) error
+}
+
+type ScheduleDaoImpl struct {
+	DynamoDbApi dynamoDbApi
+}
+
+func (sDI ScheduleDaoImpl) SaveSchedule(ctx context.Context, schedule response.ScheduleResponse) (*response.ScheduleResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.ScheduleTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	item, err := mapScheduleToDynamoDBItem(schedule)
+	if err != nil {
+		log.Printf("Error serializing the params of the schedule %v of the home %v: %v", schedule.ID, schedule.HomeID, err)
+		return nil, hdError.ErrScheduleNotCreated.Wrap(err)
+	}
+
+	if _, err := sDI.DynamoDbApi.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           &tableName,
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(id)"),
+	}); err != nil {
+		log.Printf("Error putting schedule into DynamoDB: %v", err)
+		return nil, hdError.ErrScheduleNotCreated.Wrap(err)
+	}
+
+	scheduleSaved := mapDynamoDBItemToScheduleResponse(item)
+
+	return &scheduleSaved, nil
+}
+
+func (sDI ScheduleDaoImpl) GetSchedule(ctx context.Context, homeId string, id string) (*response.ScheduleResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.ScheduleTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	result, err := sDI.DynamoDbApi.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &tableName,
+		Key:       buildScheduleKey(homeId, id),
+	})
+
+	if err != nil {
+		log.Printf("Error getting schedule %v of the home %v from DynamoDB: %v", id, homeId, err)
+		return nil, hdError.ErrGettingSchedule.Wrap(err)
+	}
+
+	if result.Item == nil {
+		return nil, hdError.ErrScheduleNotFound.New()
+	}
+
+	schedule := mapDynamoDBItemToScheduleResponse(result.Item)
+
+	return &schedule, nil
+}
+
+// ListSchedules returns every schedule of the home.
+func (sDI ScheduleDaoImpl) ListSchedules(ctx context.Context, homeId string) (*response.ScheduleListResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.ScheduleTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	schedules, err := sDI.querySchedules(ctx, &dynamodb.QueryInput{
+		TableName:              &tableName,
+		KeyConditionExpression: aws.String("homeId = :homeId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":homeId": &types.AttributeValueMemberS{Value: homeId},
+		},
+	})
+
+	if err != nil {
+		log.Printf("Error listing schedules for homeId %v from DynamoDB: %v", homeId, err)
+		return nil, hdError.ErrListingSchedules.Wrap(err)
+	}
+
+	return &response.ScheduleListResponse{Schedules: schedules}, nil
+}
+
+// ListDueSchedules returns the schedules of every home whose next run is not
+// after now, the oldest run first.
+func (sDI ScheduleDaoImpl) ListDueSchedules(ctx context.Context, now int64) ([]response.ScheduleResponse, error) {
+
+	tableName, error := getValuePropertyOrError(constants.ScheduleTableNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	nextRunAtIndexName, error := getValuePropertyOrError(constants.NextRunAtIndexNameProperty)
+	if error != nil {
+		return nil, error
+	}
+
+	schedules, err := sDI.querySchedules(ctx, &dynamodb.QueryInput{
+		TableName:              &tableName,
+		IndexName:              &nextRunAtIndexName,
+		KeyConditionExpression: aws.String("runState = :runState AND nextRunAt <= :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":runState": &types.AttributeValueMemberS{Value: scheduleRunStateActive},
+			":now":      &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", now)},
+		},
+	})
+
+	if err != nil {
+		log.Printf("Error listing the due schedules from DynamoDB: %v", err)
+		return nil, hdError.ErrListingSchedules.Wrap(err)
+	}
+
+	return schedules, nil
+}
+
+// UpdateSchedule replaces the schedule and its next run.
+func (sDI ScheduleDaoImpl) UpdateSchedule(ctx context.Context, schedule response.ScheduleResponse, expectedVersion int64) error {
+
+	tableName, error := getValuePropertyOrError(constants.ScheduleTableNameProperty)
+	if error != nil {
+		return error
+	}
+
+	params, err := json.Marshal(schedule.Params)
+	if err != nil {
+		log.Printf("Error serializing the params of the schedule %v of the home %v: %v", schedule.ID, schedule.HomeID, err)
+		return hdError.ErrUpdatingSchedule.Wrap(err)
+	}
+
+	updateExpression := "SET #name = :name, enabled = :enabled, cron = :cron, rrule = :rrule, #timezone = :timezone, " +
+		"targetDeviceId = :targetDeviceId, targetDeviceType = :targetDeviceType, targetRoomId = :targetRoomId, " +
+		"command = :command, params = :params, modifiedAt = :modifiedAt, version = version + :one"
+
+	expressionAttributeValues := map[string]types.AttributeValue{
+		":name":             &types.AttributeValueMemberS{Value: schedule.Name},
+		":enabled":          &types.AttributeValueMemberBOOL{Value: schedule.Enabled},
+		":cron":             &types.AttributeValueMemberS{Value: schedule.Cron},
+		":rrule":            &types.AttributeValueMemberS{Value: schedule.RRule},
+		":timezone":         &types.AttributeValueMemberS{Value: schedule.Timezone},
+		":targetDeviceId":   &types.AttributeValueMemberS{Value: schedule.Target.DeviceID},
+		":targetDeviceType": &types.AttributeValueMemberS{Value: schedule.Target.DeviceType},
+		":targetRoomId":     &types.AttributeValueMemberS{Value: schedule.Target.RoomID},
+		":command":          &types.AttributeValueMemberS{Value: schedule.Command},
+		":params":           &types.AttributeValueMemberS{Value: string(params)},
+		":modifiedAt":       &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", schedule.ModifiedAt)},
+		":one":              &types.AttributeValueMemberN{Value: "1"},
+	}
+
+	if schedule.NextRunAt > 0 {
+		updateExpression += ", runState = :runState, nextRunAt = :nextRunAt"
+		expressionAttributeValues[":runState"] = &types.AttributeValueMemberS{Value: scheduleRunStateActive}
+		expressionAttributeValues[":nextRunAt"] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", schedule.NextRunAt)}
+	} else {
+		updateExpression += " REMOVE runState, nextRunAt"
+	}
+
+	if _, err := sDI.DynamoDbApi.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                           &tableName,
+		Key:                                 buildScheduleKey(schedule.HomeID, schedule.ID),
+		UpdateExpression:                    aws.String(updateExpression),
+		ConditionExpression:                 aws.String("attribute_exists(id)" + buildVersionCondition(expectedVersion, expressionAttributeValues)),
+		ExpressionAttributeNames:            map[string]string{"#name": "name", "#timezone": "timezone"},
+		ExpressionAttributeValues:           expressionAttributeValues,
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	}); err != nil {
+
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			log.Printf("Schedule %v of the home %v was deleted or modified while updating it", schedule.ID, schedule.HomeID)
+			return getScheduleConditionalCheckFailedError(conditionErr.Item)
+		}
+
+		log.Printf("Error updating schedule %v of the home %v into DynamoDB: %v", schedule.ID, schedule.HomeID, err)
+		return hdError.ErrUpdatingSchedule.Wrap(err)
+	}
+
+	return nil
+}
+
+func (sDI ScheduleDaoImpl) DeleteSchedule(ctx context.Context, homeId string, id string, expectedVersion int64) error {
+
+	tableName, error := getValuePropertyOrError(constants.ScheduleTableNameProperty)
+	if error != nil {
+		return error
+	}
+
+	expressionAttributeValues := map[string]types.AttributeValue{}
+	input := &dynamodb.DeleteItemInput{
+		TableName:                           &tableName,
+		Key:                                 buildScheduleKey(homeId, id),
+		ConditionExpression:                 aws.String("attribute_exists(id)" + buildVersionCondition(expectedVersion, expressionAttributeValues)),
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	}
+
+	if len(expressionAttributeValues) > 0 {
+		input.ExpressionAttributeValues = expressionAttributeValues
+	}
+
+	if _, err := sDI.DynamoDbApi.DeleteItem(ctx, input); err != nil {
+
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			log.Printf("Schedule %v of the home %v was deleted or modified while deleting it", id, homeId)
+			return getScheduleConditionalCheckFailedError(conditionErr.Item)
+		}
+
+		log.Printf("Error deleting schedule %v of the home %v into DynamoDB: %v", id, homeId, err)
+		return hdError.ErrDeletingSchedule.Wrap(err)
+	}
+
+	return nil
+}
+
+// ClaimScheduleRun records that the schedule ran at runAt and moves it to its
+// next run, or out of the index when nextRunAt is 0, unless its next run is not
+// runAt anymore.
+func (sDI ScheduleDaoImpl) ClaimScheduleRun(ctx context.Context, homeId string, id string, runAt int64, nextRunAt int64) error {
+
+	tableName, error := getValuePropertyOrError(constants.ScheduleTableNameProperty)
+	if error != nil {
+		return error
+	}
+
+	updateExpression := "SET lastRunAt = :runAt"
+	expressionAttributeValues := map[string]types.AttributeValue{
+		":runAt": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", runAt)},
+	}
+
+	if nextRunAt > 0 {
+		updateExpression += ", nextRunAt = :nextRunAt"
+		expressionAttributeValues[":nextRunAt"] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", nextRunAt)}
+	} else {
+		updateExpression += " REMOVE runState, nextRunAt"
+	}
+
+	if _, err := sDI.DynamoDbApi.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                           &tableName,
+		Key:                                 buildScheduleKey(homeId, id),
+		UpdateExpression:                    aws.String(updateExpression),
+		ConditionExpression:                 aws.String("attribute_exists(id) AND nextRunAt = :runAt"),
+		ExpressionAttributeValues:           expressionAttributeValues,
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	}); err != nil {
+
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			if conditionErr.Item == nil {
+				return hdError.ErrScheduleNotFound.New()
+			}
+
+			return hdError.ErrScheduleAlreadyRun.New()
+		}
+
+		log.Printf("Error claiming the run of the schedule %v of the home %v into DynamoDB: %v", id, homeId, err)
+		return hdError.ErrUpdatingSchedule.Wrap(err)
+	}
+
+	return nil
+}
+
+// querySchedules reads every page of the query.
+func (sDI ScheduleDaoImpl) querySchedules(ctx context.Context, input *dynamodb.QueryInput) ([]response.ScheduleResponse, error) {
+
+	schedules := []response.ScheduleResponse{}
+
+	for {
+		result, err := sDI.DynamoDbApi.Query(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range result.Items {
+			schedules = append(schedules, mapDynamoDBItemToScheduleResponse(item))
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return schedules, nil
+		}
+
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+func getScheduleConditionalCheckFailedError(item map[string]types.AttributeValue) error {
+
+	if item == nil {
+		return hdError.ErrScheduleNotFound.New()
+	}
+
+	return hdError.ErrVersionConflict.WithMessage(hdError.ScheduleVersionConflictMessage)
+}
+
+func buildScheduleKey(homeId string, id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"homeId": &types.AttributeValueMemberS{Value: homeId},
+		"id":     &types.AttributeValueMemberS{Value: id},
+	}
+}
+
+// mapScheduleToDynamoDBItem flattens the target into attributes of the item.
+func mapScheduleToDynamoDBItem(schedule response.ScheduleResponse) (map[string]types.AttributeValue, error) {
+
+	params, err := json.Marshal(schedule.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	item := map[string]types.AttributeValue{
+		"homeId":           &types.AttributeValueMemberS{Value: schedule.HomeID},
+		"id":               &types.AttributeValueMemberS{Value: schedule.ID},
+		"name":             &types.AttributeValueMemberS{Value: schedule.Name},
+		"enabled":          &types.AttributeValueMemberBOOL{Value: schedule.Enabled},
+		"cron":             &types.AttributeValueMemberS{Value: schedule.Cron},
+		"rrule":            &types.AttributeValueMemberS{Value: schedule.RRule},
+		"timezone":         &types.AttributeValueMemberS{Value: schedule.Timezone},
+		"targetDeviceId":   &types.AttributeValueMemberS{Value: schedule.Target.DeviceID},
+		"targetDeviceType": &types.AttributeValueMemberS{Value: schedule.Target.DeviceType},
+		"targetRoomId":     &types.AttributeValueMemberS{Value: schedule.Target.RoomID},
+		"command":          &types.AttributeValueMemberS{Value: schedule.Command},
+		"params":           &types.AttributeValueMemberS{Value: string(params)},
+		"createdAt":        &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", schedule.CreatedAt)},
+		"modifiedAt":       &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", schedule.ModifiedAt)},
+		"version":          &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", schedule.Version)},
+	}
+
+	if schedule.NextRunAt > 0 {
+		item["runState"] = &types.AttributeValueMemberS{Value: scheduleRunStateActive}
+		item["nextRunAt"] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", schedule.NextRunAt)}
+	}
+
+	if schedule.LastRunAt > 0 {
+		item["lastRunAt"] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", schedule.LastRunAt)}
+	}
+
+	return item, nil
+}
+
+func mapDynamoDBItemToScheduleResponse(item map[string]types.AttributeValue) response.ScheduleResponse {
+
+	schedule := response.ScheduleResponse{
+		ID:       getStringAttribute(item, "id"),
+		HomeID:   getStringAttribute(item, "homeId"),
+		Name:     getStringAttribute(item, "name"),
+		Enabled:  getBoolAttribute(item, "enabled"),
+		Cron:     getStringAttribute(item, "cron"),
+		RRule:    getStringAttribute(item, "rrule"),
+		Timezone: getStringAttribute(item, "timezone"),
+		Target: response.ScheduleTargetResponse{
+			DeviceID:   getStringAttribute(item, "targetDeviceId"),
+			DeviceType: getStringAttribute(item, "targetDeviceType"),
+			RoomID:     getStringAttribute(item, "targetRoomId"),
+		},
+		Command:    getStringAttribute(item, "command"),
+		Params:     map[string]interface{}{},
+		NextRunAt:  getInt64Attribute(item, "nextRunAt"),
+		LastRunAt:  getInt64Attribute(item, "lastRunAt"),
+		CreatedAt:  getInt64Attribute(item, "createdAt"),
+		ModifiedAt: getInt64Attribute(item, "modifiedAt"),
+		Version:    getInt64Attribute(item, "version"),
+	}
+
+	if params := getStringAttribute(item, "params"); params != "" {
+		if err := json.Unmarshal([]byte(params), &schedule.Params); err != nil {
+			log.Printf("Error reading the params of the schedule %v: %v", schedule.ID, err)
+		}
+	}
+
+	return schedule
+}
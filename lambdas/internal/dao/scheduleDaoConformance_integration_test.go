@@ -0,0 +1,15 @@
+package dao_test
+
+import (
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/dao"
+	"github.com/odhoman/home-devices/internal/dao/daotest"
+	"github.com/odhoman/home-devices/internal/mock"
+)
+
+func TestScheduleDaoImpl_Conformance(t *testing.T) {
+	daotest.RunScheduleDaoConformanceSuite(t, func() dao.ScheduleDao {
+		return dao.ScheduleDaoImpl{DynamoDbApi: mock.GetDynamoConnectionTestFromEnpoint()}
+	})
+}
@@ -0,0 +1,71 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	constants "github.com/odhoman/home-devices/internal/constants"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	request "github.com/odhoman/home-devices/internal/request"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// TelemetryDao keeps the telemetry readings of the devices, sorted by time
+// within each device.
+type TelemetryDao interface {
+	SaveTelemetryReading(ctx context.Context, reading request.TelemetryReadingRequest) error
+}
+
+type TelemetryDaoImpl struct {
+	DynamoDbApi dynamoDbApi
+}
+
+// SaveTelemetryReading writes the reading.
+func (tDI TelemetryDaoImpl) SaveTelemetryReading(ctx context.Context, reading request.TelemetryReadingRequest) error {
+
+	tableName, error := getValuePropertyOrError(constants.TelemetryTableNameProperty)
+	if error != nil {
+		return error
+	}
+
+	if _, err := tDI.DynamoDbApi.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: &tableName,
+		Item:      mapTelemetryReadingToDynamoDBItem(reading),
+	}); err != nil {
+		log.Printf("Error saving the %v reading of the device %v: %v", reading.Metric, reading.DeviceID, err)
+		return hdError.ErrSavingTelemetryReading.Wrap(err)
+	}
+
+	return nil
+}
+
+// buildReadingId returns the sort key of a reading: the timestamp first, so
+// the readings of a device are sorted by time, and then the metric, so the
+// readings of several metrics taken at the same time are kept apart.
+func buildReadingId(timestamp int64, metric string) string {
+	return fmt.Sprintf("%013d#%s", timestamp, metric)
+}
+
+func mapTelemetryReadingToDynamoDBItem(reading request.TelemetryReadingRequest) map[string]types.AttributeValue {
+
+	item := map[string]types.AttributeValue{
+		"deviceId":  &types.AttributeValueMemberS{Value: reading.DeviceID},
+		"readingId": &types.AttributeValueMemberS{Value: buildReadingId(reading.Timestamp, reading.Metric)},
+		"metric":    &types.AttributeValueMemberS{Value: reading.Metric},
+		"timestamp": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", reading.Timestamp)},
+	}
+
+	if reading.Value != nil {
+		item["value"] = &types.AttributeValueMemberN{Value: strconv.FormatFloat(*reading.Value, 'f', -1, 64)}
+	}
+
+	if reading.Unit != "" {
+		item["unit"] = &types.AttributeValueMemberS{Value: reading.Unit}
+	}
+
+	return item
+}
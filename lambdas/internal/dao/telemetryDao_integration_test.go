@@ -0,0 +1,46 @@
+package dao
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/odhoman/home-devices/internal/mock"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveTelemetryReading_Success(t *testing.T) {
+
+	ctx := context.Background()
+	client := mock.GetDynamoConnectionTestFromEnpoint()
+	telemetryDaoImpl := TelemetryDaoImpl{DynamoDbApi: client}
+
+	value := 21.5
+	reading := hDRequest.TelemetryReadingRequest{
+		DeviceID:  "telemetryDevice",
+		Metric:    "temperature",
+		Value:     &value,
+		Unit:      "celsius",
+		Timestamp: 1729000000000,
+	}
+
+	assert.Nil(t, telemetryDaoImpl.SaveTelemetryReading(ctx, reading))
+	// the same reading delivered again by Kinesis
+	assert.Nil(t, telemetryDaoImpl.SaveTelemetryReading(ctx, reading))
+
+	result, err := client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String("HomeDeviceTelemetry"),
+		KeyConditionExpression: aws.String("deviceId = :deviceId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":deviceId": &types.AttributeValueMemberS{Value: "telemetryDevice"},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, result.Items, 1)
+	assert.Equal(t, "1729000000000#temperature", getStringAttribute(result.Items[0], "readingId"))
+	assert.Equal(t, "21.5", result.Items[0]["value"].(*types.AttributeValueMemberN).Value)
+}
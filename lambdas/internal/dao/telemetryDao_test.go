@@ -0,0 +1,47 @@
+package dao
+
+import (
+	"testing"
+
+	request "github.com/odhoman/home-devices/internal/request"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildReadingId_SortsByTimestamp(t *testing.T) {
+	assert.Equal(t, "1729000000000#temperature", buildReadingId(1729000000000, "temperature"))
+	assert.Less(t, buildReadingId(999999999999, "temperature"), buildReadingId(1000000000000, "humidity"))
+}
+
+func TestMapTelemetryReadingToDynamoDBItem(t *testing.T) {
+
+	value := 21.5
+	item := mapTelemetryReadingToDynamoDBItem(request.TelemetryReadingRequest{
+		DeviceID:  "device123",
+		Metric:    "temperature",
+		Value:     &value,
+		Unit:      "celsius",
+		Timestamp: 1729000000000,
+	})
+
+	assert.Equal(t, "device123", item["deviceId"].(*types.AttributeValueMemberS).Value)
+	assert.Equal(t, "1729000000000#temperature", item["readingId"].(*types.AttributeValueMemberS).Value)
+	assert.Equal(t, "21.5", item["value"].(*types.AttributeValueMemberN).Value)
+	assert.Equal(t, "celsius", item["unit"].(*types.AttributeValueMemberS).Value)
+	assert.Equal(t, "1729000000000", item["timestamp"].(*types.AttributeValueMemberN).Value)
+}
+
+func TestMapTelemetryReadingToDynamoDBItem_WithoutUnit(t *testing.T) {
+
+	value := 0.0
+	item := mapTelemetryReadingToDynamoDBItem(request.TelemetryReadingRequest{
+		DeviceID:  "device123",
+		Metric:    "motion",
+		Value:     &value,
+		Timestamp: 1729000000000,
+	})
+
+	assert.Equal(t, "0", item["value"].(*types.AttributeValueMemberN).Value)
+	assert.NotContains(t, item, "unit")
+}
@@ -1,6 +1,73 @@
 package error
 
+import (
+	"errors"
+)
+
+// HomeDeviceError is the error returned by the DAOs and the services.
 type HomeDeviceError struct {
-	ErrorCode    string
-	ErrorMessage string
+	ErrorCode    string            `json:"code"`
+	ErrorMessage string            `json:"message"`
+	Details      map[string]string `json:"details,omitempty"`
+	Cause        error             `json:"-"`
+}
+
+func (hDE *HomeDeviceError) Error() string {
+
+	if hDE.Cause == nil {
+		return hDE.ErrorMessage
+	}
+
+	return hDE.ErrorMessage + ": " + hDE.Cause.Error()
+}
+
+func (hDE *HomeDeviceError) Unwrap() error {
+	return hDE.Cause
+}
+
+// Is tells if the error has the code of the target, a definition of the
+// registry or another HomeDeviceError, so errors.Is(err, ErrDeviceNotFound)
+// matches any device not found error whatever its message.
+func (hDE *HomeDeviceError) Is(target error) bool {
+
+	switch t := target.(type) {
+	case Definition:
+		return hDE.ErrorCode == t.Code
+	case *HomeDeviceError:
+		return hDE.ErrorCode == t.ErrorCode
+	}
+
+	return false
+}
+
+// WithDetail adds a detail to the error and returns it.
+func (hDE *HomeDeviceError) WithDetail(name string, value string) *HomeDeviceError {
+
+	if hDE.Details == nil {
+		hDE.Details = map[string]string{}
+	}
+
+	hDE.Details[name] = value
+	return hDE
+}
+
+// From returns the HomeDeviceError of the chain of the error, or an
+// ErrInternal caused by the error when there is none.
+func From(err error) *HomeDeviceError {
+
+	if err == nil {
+		return nil
+	}
+
+	var homeDeviceError *HomeDeviceError
+	if errors.As(err, &homeDeviceError) {
+		return homeDeviceError
+	}
+
+	var definition Definition
+	if errors.As(err, &definition) {
+		return definition.New()
+	}
+
+	return ErrInternal.Wrap(err)
 }
@@ -0,0 +1,67 @@
+package error
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHomeDeviceError_WrapsTheCause(t *testing.T) {
+
+	cause := errors.New("ResourceNotFoundException: the table does not exist")
+	err := fmt.Errorf("updating the device device1: %w", ErrUpdatingDevice.Wrap(cause))
+
+	assert.ErrorIs(t, err, ErrUpdatingDevice)
+	assert.ErrorIs(t, err, cause)
+	assert.NotErrorIs(t, err, ErrDeletingDevice)
+	assert.EqualError(t, err, "updating the device device1: "+ErrUpdatingDevice.Message+": "+cause.Error())
+
+	var homeDeviceError *HomeDeviceError
+	assert.True(t, errors.As(err, &homeDeviceError))
+	assert.Equal(t, ErrUpdatingDevice.Code, homeDeviceError.ErrorCode)
+	assert.Equal(t, cause, homeDeviceError.Unwrap())
+}
+
+func TestHomeDeviceError_IsMatchesTheCode(t *testing.T) {
+
+	err := ErrVersionConflict.WithMessage(HomeVersionConflictMessage)
+
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	assert.ErrorIs(t, err, ErrVersionConflict.New())
+	assert.EqualError(t, err, HomeVersionConflictMessage)
+}
+
+func TestHomeDeviceError_JSONHasNoCause(t *testing.T) {
+
+	err := ErrDeviceAlreadyExists.Wrap(errors.New("ConditionalCheckFailed")).WithDetail("mac", "00:11:22:33:44:55").WithDetail("deviceId", "device1")
+
+	body, marshalError := json.Marshal(err)
+
+	assert.Nil(t, marshalError)
+	assert.JSONEq(t, `{
+		"code": "DEVICE_ALREADY_EXISTS",
+		"message": "`+ErrDeviceAlreadyExists.Message+`",
+		"details": {"mac": "00:11:22:33:44:55", "deviceId": "device1"}
+	}`, string(body))
+
+	body, _ = json.Marshal(ErrDeviceNotFound.New())
+	assert.NotContains(t, string(body), "details")
+}
+
+func TestFrom(t *testing.T) {
+
+	assert.Nil(t, From(nil))
+
+	homeDeviceError := ErrDeviceNotFound.New()
+	assert.Same(t, homeDeviceError, From(fmt.Errorf("getting the device: %w", homeDeviceError)))
+
+	assert.Equal(t, ErrRoomNotFound.Code, From(ErrRoomNotFound).ErrorCode)
+
+	cause := errors.New("unexpected end of JSON input")
+	internalError := From(cause)
+	assert.Equal(t, ErrInternal.Code, internalError.ErrorCode)
+	assert.Equal(t, cause, internalError.Cause)
+}
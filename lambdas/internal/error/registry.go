@@ -0,0 +1,680 @@
+package error
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Definition is the registered meaning of an error code: the message of the
+// errors created with it, the HTTP status of the responses, the message shown
+// to the clients and whether trying again may succeed.
+type Definition struct {
+	Code          string
+	Message       string
+	Status        int
+	PublicMessage string
+	Retryable     bool
+}
+
+// New returns an error with the code and the message of the definition.
+func (d Definition) New() *HomeDeviceError {
+	return &HomeDeviceError{ErrorCode: d.Code, ErrorMessage: d.Message}
+}
+
+// WithMessage returns an error with the code of the definition and a message
+// with the details of the failure.
+func (d Definition) WithMessage(message string) *HomeDeviceError {
+	return &HomeDeviceError{ErrorCode: d.Code, ErrorMessage: message}
+}
+
+// Wrap returns an error with the code and the message of the definition caused
+// by err.
+func (d Definition) Wrap(err error) *HomeDeviceError {
+	return &HomeDeviceError{ErrorCode: d.Code, ErrorMessage: d.Message, Cause: err}
+}
+
+// Error makes the definitions targets of errors.Is.
+func (d Definition) Error() string {
+	return d.Message
+}
+
+// PublicMessageOf returns the message of the error that can be shown to the
+// clients.
+func (d Definition) PublicMessageOf(err *HomeDeviceError) string {
+
+	if d.PublicMessage != "" {
+		return d.PublicMessage
+	}
+
+	if err.ErrorMessage != "" {
+		return err.ErrorMessage
+	}
+
+	return d.Message
+}
+
+var registry = map[string]Definition{}
+
+// unknownDefinition is used for the codes that are not registered, they are
+// handled as an unexpected failure.
+var unknownDefinition = Definition{
+	Status:        http.StatusInternalServerError,
+	PublicMessage: "Internal Server Error",
+	Retryable:     true,
+}
+
+func define(d Definition) Definition {
+
+	if _, ok := registry[d.Code]; ok {
+		panic(fmt.Sprintf("the error code %v is defined twice", d.Code))
+	}
+
+	registry[d.Code] = d
+	return d
+}
+
+// Lookup returns the definition of the code, or the one of an unexpected
+// failure when the code is not registered.
+func Lookup(code string) Definition {
+
+	if d, ok := registry[code]; ok {
+		return d
+	}
+
+	unknown := unknownDefinition
+	unknown.Code = code
+	return unknown
+}
+
+// The messages of the version conflicts of the device states and of the
+// resources other than the devices, used with ErrVersionConflict.
+const (
+	DeviceStateVersionConflictMessage = "The device state was modified by another request"
+	HomeVersionConflictMessage        = "The home was modified by another request"
+	RoomVersionConflictMessage        = "The room was modified by another request"
+	RuleVersionConflictMessage        = "The rule was modified by another request"
+	ScheduleVersionConflictMessage    = "The schedule was modified by another request"
+	SceneVersionConflictMessage       = "The scene was modified by another request"
+)
+
+// Every error code of the service is defined once here.
+var (
+	ErrInternal = define(Definition{
+		Code:          "INTERNAL_ERROR",
+		Message:       "An unexpected error occurred",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server Error",
+		Retryable:     true,
+	})
+
+	ErrInvalidRequest = define(Definition{
+		Code:    "INVALID_REQUEST",
+		Message: "The request is not valid",
+		Status:  http.StatusBadRequest,
+	})
+
+	ErrValidation = define(Definition{
+		Code:    "VALIDATION_ERROR",
+		Message: "One or more parameters of the request are not valid",
+		Status:  http.StatusBadRequest,
+	})
+
+	ErrDeviceNotCreated = define(Definition{
+		Code:          "DEVICE_NO_CREATED",
+		Message:       "An error occurred creating new device",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error creating a new device",
+		Retryable:     true,
+	})
+
+	ErrDeviceAlreadyExists = define(Definition{
+		Code:          "DEVICE_ALREADY_EXISTS",
+		Message:       "Device already exist for that mac and homeId",
+		Status:        http.StatusBadRequest,
+		PublicMessage: "Device Already Exist",
+	})
+
+	ErrGettingDevice = define(Definition{
+		Code:          "ERROR_GETTING_DEVICE",
+		Message:       "An error occurred getting the device",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error getting the device",
+		Retryable:     true,
+	})
+
+	ErrNoFieldToUpdate = define(Definition{
+		Code:          "NO_FIELDS_TO_UPDATE",
+		Message:       "Please enter a value property to update",
+		Status:        http.StatusBadRequest,
+		PublicMessage: "Please enter a value property to update",
+	})
+
+	ErrUpdatingDevice = define(Definition{
+		Code:          "ERROR_UPDATING_DEVICE",
+		Message:       "An error occurred updating a device",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error updating a device",
+		Retryable:     true,
+	})
+
+	ErrDeviceNotFound = define(Definition{
+		Code:          "ERROR_DEVICE_NOT_FOUND",
+		Message:       "Device Not Found",
+		Status:        http.StatusNotFound,
+		PublicMessage: "Device Not Found",
+	})
+
+	ErrDeletingDevice = define(Definition{
+		Code:          "ERROR_DELETING_DEVICE",
+		Message:       "An error occurred deleting a device",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error deleting a device",
+		Retryable:     true,
+	})
+
+	ErrGettingConfig = define(Definition{
+		Code:          "ERROR_GETTING_CONFIG",
+		Message:       "An error occurred getting the configuration",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error getting the configuration",
+	})
+
+	ErrVersionConflict = define(Definition{
+		Code:      "ERROR_VERSION_CONFLICT",
+		Message:   "The device was modified by another request",
+		Status:    http.StatusPreconditionFailed,
+		Retryable: true,
+	})
+
+	ErrListingDevices = define(Definition{
+		Code:          "ERROR_LISTING_DEVICES",
+		Message:       "An error occurred listing the devices",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error listing the devices",
+		Retryable:     true,
+	})
+
+	ErrInvalidCursor = define(Definition{
+		Code:          "INVALID_CURSOR",
+		Message:       "The cursor provided is not valid",
+		Status:        http.StatusBadRequest,
+		PublicMessage: "Invalid cursor",
+	})
+
+	ErrDeviceNotDeleted = define(Definition{
+		Code:          "DEVICE_NOT_DELETED",
+		Message:       "The device is not deleted",
+		Status:        http.StatusBadRequest,
+		PublicMessage: "Device is not deleted",
+	})
+
+	ErrHardDeleteNotAllowed = define(Definition{
+		Code:          "HARD_DELETE_NOT_ALLOWED",
+		Message:       "Only an admin can hard delete a device",
+		Status:        http.StatusForbidden,
+		PublicMessage: "Only an admin can hard delete a device",
+	})
+
+	ErrRestoringDevice = define(Definition{
+		Code:          "ERROR_RESTORING_DEVICE",
+		Message:       "An error occurred restoring a device",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error restoring a device",
+		Retryable:     true,
+	})
+
+	ErrSavingDeviceChange = define(Definition{
+		Code:          "ERROR_SAVING_DEVICE_CHANGE",
+		Message:       "An error occurred saving the device change",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error saving the device change",
+		Retryable:     true,
+	})
+
+	ErrListingDeviceHistory = define(Definition{
+		Code:          "ERROR_LISTING_DEVICE_HISTORY",
+		Message:       "An error occurred listing the device history",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error getting the device history",
+		Retryable:     true,
+	})
+
+	ErrSavingTelemetryReading = define(Definition{
+		Code:          "ERROR_SAVING_TELEMETRY_READING",
+		Message:       "An error occurred saving the telemetry reading",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error saving the telemetry reading",
+		Retryable:     true,
+	})
+
+	ErrGettingDeviceState = define(Definition{
+		Code:          "ERROR_GETTING_DEVICE_STATE",
+		Message:       "An error occurred getting the device state",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error getting the device state",
+		Retryable:     true,
+	})
+
+	ErrUpdatingDeviceState = define(Definition{
+		Code:          "ERROR_UPDATING_DEVICE_STATE",
+		Message:       "An error occurred updating the device state",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error updating the device state",
+		Retryable:     true,
+	})
+
+	ErrNoStateToUpdate = define(Definition{
+		Code:          "NO_STATE_TO_UPDATE",
+		Message:       "Please enter a desired or reported state to update",
+		Status:        http.StatusBadRequest,
+		PublicMessage: "Please enter a desired or reported state to update",
+	})
+
+	ErrInvalidDeviceState = define(Definition{
+		Code:    "INVALID_DEVICE_STATE",
+		Message: "The state does not match the type of the device",
+		Status:  http.StatusBadRequest,
+	})
+
+	ErrHomeNotCreated = define(Definition{
+		Code:          "HOME_NOT_CREATED",
+		Message:       "An error occurred creating new home",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error creating a new home",
+		Retryable:     true,
+	})
+
+	ErrHomeAlreadyExists = define(Definition{
+		Code:          "HOME_ALREADY_EXISTS",
+		Message:       "Home already exist for that id",
+		Status:        http.StatusBadRequest,
+		PublicMessage: "Home Already Exist",
+	})
+
+	ErrGettingHome = define(Definition{
+		Code:          "ERROR_GETTING_HOME",
+		Message:       "An error occurred getting the home",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error getting the home",
+		Retryable:     true,
+	})
+
+	ErrHomeNotFound = define(Definition{
+		Code:          "ERROR_HOME_NOT_FOUND",
+		Message:       "Home Not Found",
+		Status:        http.StatusNotFound,
+		PublicMessage: "Home Not Found",
+	})
+
+	ErrUpdatingHome = define(Definition{
+		Code:          "ERROR_UPDATING_HOME",
+		Message:       "An error occurred updating a home",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error updating a home",
+		Retryable:     true,
+	})
+
+	ErrDeletingHome = define(Definition{
+		Code:          "ERROR_DELETING_HOME",
+		Message:       "An error occurred deleting a home",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error deleting a home",
+		Retryable:     true,
+	})
+
+	ErrUnknownHome = define(Definition{
+		Code:          "UNKNOWN_HOME",
+		Message:       "There is no home for the homeId",
+		Status:        http.StatusBadRequest,
+		PublicMessage: "Home Not Found for the homeId",
+	})
+
+	ErrHomeHasDevices = define(Definition{
+		Code:          "HOME_HAS_DEVICES",
+		Message:       "The home has devices, a cascade or reassign strategy is needed to delete it",
+		Status:        http.StatusConflict,
+		PublicMessage: "The home has devices, please choose the cascade or reassign strategy",
+	})
+
+	ErrInvalidTargetHome = define(Definition{
+		Code:          "INVALID_TARGET_HOME",
+		Message:       "The devices can not be reassigned to the home that is deleted",
+		Status:        http.StatusBadRequest,
+		PublicMessage: "The target home must be another home",
+	})
+
+	ErrUnknownTargetHome = define(Definition{
+		Code:          "UNKNOWN_TARGET_HOME",
+		Message:       "There is no home for the targetHomeId",
+		Status:        http.StatusBadRequest,
+		PublicMessage: "Target Home Not Found",
+	})
+
+	ErrDeviceInTargetHome = define(Definition{
+		Code:          "DEVICE_IN_TARGET_HOME",
+		Message:       "A device of the home already exists for its mac in the target home",
+		Status:        http.StatusConflict,
+		PublicMessage: "A device of the home already exists in the target home",
+	})
+
+	ErrRoomNotCreated = define(Definition{
+		Code:          "ROOM_NOT_CREATED",
+		Message:       "An error occurred creating new room",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error creating a new room",
+		Retryable:     true,
+	})
+
+	ErrGettingRoom = define(Definition{
+		Code:          "ERROR_GETTING_ROOM",
+		Message:       "An error occurred getting the room",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error getting the room",
+		Retryable:     true,
+	})
+
+	ErrRoomNotFound = define(Definition{
+		Code:          "ERROR_ROOM_NOT_FOUND",
+		Message:       "Room Not Found",
+		Status:        http.StatusNotFound,
+		PublicMessage: "Room Not Found",
+	})
+
+	ErrListingRooms = define(Definition{
+		Code:          "ERROR_LISTING_ROOMS",
+		Message:       "An error occurred listing the rooms",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error listing the rooms",
+		Retryable:     true,
+	})
+
+	ErrUpdatingRoom = define(Definition{
+		Code:          "ERROR_UPDATING_ROOM",
+		Message:       "An error occurred updating a room",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error updating a room",
+		Retryable:     true,
+	})
+
+	ErrDeletingRoom = define(Definition{
+		Code:          "ERROR_DELETING_ROOM",
+		Message:       "An error occurred deleting a room",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error deleting a room",
+		Retryable:     true,
+	})
+
+	ErrUnknownRoom = define(Definition{
+		Code:          "UNKNOWN_ROOM",
+		Message:       "There is no room for the roomId in the home of the device",
+		Status:        http.StatusBadRequest,
+		PublicMessage: "Room Not Found for the roomId",
+	})
+
+	ErrRoomHasDevices = define(Definition{
+		Code:          "ROOM_HAS_DEVICES",
+		Message:       "The room has devices, they must be moved to another room first",
+		Status:        http.StatusConflict,
+		PublicMessage: "The room has devices, please move them to another room first",
+	})
+
+	ErrInvalidDeviceCommand = define(Definition{
+		Code:    "INVALID_DEVICE_COMMAND",
+		Message: "The command is not a command of the type of the device",
+		Status:  http.StatusBadRequest,
+	})
+
+	ErrDeviceCommandNotCreated = define(Definition{
+		Code:          "DEVICE_COMMAND_NOT_CREATED",
+		Message:       "An error occurred creating the device command",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error creating the device command",
+		Retryable:     true,
+	})
+
+	ErrSendingDeviceCommand = define(Definition{
+		Code:          "ERROR_SENDING_DEVICE_COMMAND",
+		Message:       "An error occurred sending the command to the device",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error sending the device command",
+		Retryable:     true,
+	})
+
+	ErrGettingDeviceCommand = define(Definition{
+		Code:          "ERROR_GETTING_DEVICE_COMMAND",
+		Message:       "An error occurred getting the device command",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error getting the device command",
+		Retryable:     true,
+	})
+
+	ErrDeviceCommandNotFound = define(Definition{
+		Code:          "ERROR_DEVICE_COMMAND_NOT_FOUND",
+		Message:       "Device Command Not Found",
+		Status:        http.StatusNotFound,
+		PublicMessage: "Device Command Not Found",
+	})
+
+	ErrUpdatingDeviceCommand = define(Definition{
+		Code:          "ERROR_UPDATING_DEVICE_COMMAND",
+		Message:       "An error occurred updating the device command",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error updating the device command",
+		Retryable:     true,
+	})
+
+	ErrDeviceCommandCompleted = define(Definition{
+		Code:    "DEVICE_COMMAND_COMPLETED",
+		Message: "The device command is not pending anymore",
+		Status:  http.StatusConflict,
+	})
+
+	ErrRuleNotCreated = define(Definition{
+		Code:          "RULE_NOT_CREATED",
+		Message:       "An error occurred creating new rule",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error creating a new rule",
+		Retryable:     true,
+	})
+
+	ErrGettingRule = define(Definition{
+		Code:          "ERROR_GETTING_RULE",
+		Message:       "An error occurred getting the rule",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error getting the rule",
+		Retryable:     true,
+	})
+
+	ErrRuleNotFound = define(Definition{
+		Code:          "ERROR_RULE_NOT_FOUND",
+		Message:       "Rule Not Found",
+		Status:        http.StatusNotFound,
+		PublicMessage: "Rule Not Found",
+	})
+
+	ErrListingRules = define(Definition{
+		Code:          "ERROR_LISTING_RULES",
+		Message:       "An error occurred listing the rules",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error listing the rules",
+		Retryable:     true,
+	})
+
+	ErrUpdatingRule = define(Definition{
+		Code:          "ERROR_UPDATING_RULE",
+		Message:       "An error occurred updating a rule",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error updating a rule",
+		Retryable:     true,
+	})
+
+	ErrDeletingRule = define(Definition{
+		Code:          "ERROR_DELETING_RULE",
+		Message:       "An error occurred deleting a rule",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error deleting a rule",
+		Retryable:     true,
+	})
+
+	ErrInvalidRule = define(Definition{
+		Code:    "INVALID_RULE",
+		Message: "The trigger or the action of the rule are not valid for their devices",
+		Status:  http.StatusBadRequest,
+	})
+
+	ErrRuleInCooldown = define(Definition{
+		Code:    "RULE_IN_COOLDOWN",
+		Message: "The rule fired less than its cooldown ago",
+		Status:  http.StatusConflict,
+	})
+
+	ErrRuleAlreadyFired = define(Definition{
+		Code:    "RULE_ALREADY_FIRED",
+		Message: "The rule already fired for the reading or a later one",
+		Status:  http.StatusConflict,
+	})
+
+	ErrSavingRuleExecution = define(Definition{
+		Code:          "ERROR_SAVING_RULE_EXECUTION",
+		Message:       "An error occurred saving the rule execution",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error saving the rule execution",
+		Retryable:     true,
+	})
+
+	ErrListingRuleExecutions = define(Definition{
+		Code:          "ERROR_LISTING_RULE_EXECUTIONS",
+		Message:       "An error occurred listing the rule executions",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error listing the rule executions",
+		Retryable:     true,
+	})
+
+	ErrScheduleNotCreated = define(Definition{
+		Code:          "SCHEDULE_NOT_CREATED",
+		Message:       "An error occurred creating new schedule",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error creating a new schedule",
+		Retryable:     true,
+	})
+
+	ErrGettingSchedule = define(Definition{
+		Code:          "ERROR_GETTING_SCHEDULE",
+		Message:       "An error occurred getting the schedule",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error getting the schedule",
+		Retryable:     true,
+	})
+
+	ErrScheduleNotFound = define(Definition{
+		Code:          "ERROR_SCHEDULE_NOT_FOUND",
+		Message:       "Schedule Not Found",
+		Status:        http.StatusNotFound,
+		PublicMessage: "Schedule Not Found",
+	})
+
+	ErrListingSchedules = define(Definition{
+		Code:          "ERROR_LISTING_SCHEDULES",
+		Message:       "An error occurred listing the schedules",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error listing the schedules",
+		Retryable:     true,
+	})
+
+	ErrUpdatingSchedule = define(Definition{
+		Code:          "ERROR_UPDATING_SCHEDULE",
+		Message:       "An error occurred updating a schedule",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error updating a schedule",
+		Retryable:     true,
+	})
+
+	ErrDeletingSchedule = define(Definition{
+		Code:          "ERROR_DELETING_SCHEDULE",
+		Message:       "An error occurred deleting a schedule",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error deleting a schedule",
+		Retryable:     true,
+	})
+
+	ErrInvalidSchedule = define(Definition{
+		Code:    "INVALID_SCHEDULE",
+		Message: "The times, the target or the command of the schedule are not valid",
+		Status:  http.StatusBadRequest,
+	})
+
+	ErrScheduleAlreadyRun = define(Definition{
+		Code:    "SCHEDULE_ALREADY_RUN",
+		Message: "The schedule already ran at that time",
+		Status:  http.StatusConflict,
+	})
+
+	ErrSceneNotCreated = define(Definition{
+		Code:          "SCENE_NOT_CREATED",
+		Message:       "An error occurred creating new scene",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error creating a new scene",
+		Retryable:     true,
+	})
+
+	ErrGettingScene = define(Definition{
+		Code:          "ERROR_GETTING_SCENE",
+		Message:       "An error occurred getting the scene",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error getting the scene",
+		Retryable:     true,
+	})
+
+	ErrSceneNotFound = define(Definition{
+		Code:          "ERROR_SCENE_NOT_FOUND",
+		Message:       "Scene Not Found",
+		Status:        http.StatusNotFound,
+		PublicMessage: "Scene Not Found",
+	})
+
+	ErrListingScenes = define(Definition{
+		Code:          "ERROR_LISTING_SCENES",
+		Message:       "An error occurred listing the scenes",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error listing the scenes",
+		Retryable:     true,
+	})
+
+	ErrUpdatingScene = define(Definition{
+		Code:          "ERROR_UPDATING_SCENE",
+		Message:       "An error occurred updating a scene",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error updating a scene",
+		Retryable:     true,
+	})
+
+	ErrDeletingScene = define(Definition{
+		Code:          "ERROR_DELETING_SCENE",
+		Message:       "An error occurred deleting a scene",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error deleting a scene",
+		Retryable:     true,
+	})
+
+	ErrInvalidScene = define(Definition{
+		Code:    "INVALID_SCENE",
+		Message: "The devices or the states of the scene are not valid",
+		Status:  http.StatusBadRequest,
+	})
+
+	ErrActivatingScene = define(Definition{
+		Code:          "ERROR_ACTIVATING_SCENE",
+		Message:       "An error occurred activating the scene",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error activating the scene",
+		Retryable:     true,
+	})
+
+	ErrUpdatingDeviceCounts = define(Definition{
+		Code:          "ERROR_UPDATING_DEVICE_COUNTS",
+		Message:       "An error occurred updating the device counts",
+		Status:        http.StatusInternalServerError,
+		PublicMessage: "Internal Server error updating the device counts",
+		Retryable:     true,
+	})
+)
@@ -0,0 +1,66 @@
+package error
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_EveryDefinitionIsComplete(t *testing.T) {
+
+	for code, definition := range registry {
+		assert.Equal(t, code, definition.Code)
+		assert.NotEmpty(t, definition.Message, code)
+		assert.NotZero(t, definition.Status, code)
+
+		// the failures of the service do not show their details to the clients
+		if definition.Status >= http.StatusInternalServerError {
+			assert.True(t, strings.HasPrefix(strings.ToLower(definition.PublicMessage), "internal server error"), code)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+
+	definition := Lookup(ErrDeviceNotFound.Code)
+
+	assert.Equal(t, http.StatusNotFound, definition.Status)
+	assert.Equal(t, "Device Not Found", definition.PublicMessage)
+	assert.False(t, definition.Retryable)
+
+	assert.True(t, Lookup(ErrGettingDevice.Code).Retryable)
+	assert.False(t, Lookup(ErrGettingConfig.Code).Retryable)
+}
+
+func TestLookup_UnknownCode(t *testing.T) {
+
+	definition := Lookup("ERROR_NOT_REGISTERED")
+
+	assert.Equal(t, "ERROR_NOT_REGISTERED", definition.Code)
+	assert.Equal(t, http.StatusInternalServerError, definition.Status)
+	assert.Equal(t, "Internal Server Error", definition.PublicMessageOf(&HomeDeviceError{ErrorCode: "ERROR_NOT_REGISTERED"}))
+	assert.True(t, definition.Retryable)
+}
+
+func TestPublicMessageOf(t *testing.T) {
+
+	// the details of an invalid request are shown
+	err := ErrInvalidRule.WithMessage("motion must be 0 or 1")
+	assert.Equal(t, "motion must be 0 or 1", Lookup(err.ErrorCode).PublicMessageOf(err))
+
+	err = ErrVersionConflict.WithMessage(HomeVersionConflictMessage)
+	assert.Equal(t, HomeVersionConflictMessage, Lookup(err.ErrorCode).PublicMessageOf(err))
+
+	err = ErrVersionConflict.WithMessage("")
+	assert.Equal(t, ErrVersionConflict.Message, Lookup(err.ErrorCode).PublicMessageOf(err))
+
+	// the internal message is not
+	err = ErrUpdatingDevice.WithMessage("the table is not available")
+	assert.Equal(t, "Internal Server error updating a device", Lookup(err.ErrorCode).PublicMessageOf(err))
+}
+
+func TestDefine_TwiceTheSameCode(t *testing.T) {
+	assert.Panics(t, func() { define(Definition{Code: ErrDeviceNotFound.Code}) })
+}
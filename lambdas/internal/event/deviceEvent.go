@@ -0,0 +1,79 @@
+package event
+
+import (
+	"time"
+
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/google/uuid"
+)
+
+// Attributes of the CloudEvents 1.0 envelope that are the same for every
+// event.
+const (
+	SpecVersion     = "1.0"
+	Source          = "/home-devices/devices"
+	DataContentType = "application/json"
+)
+
+// Types of the device events.
+const (
+	TypeDeviceCreated = "com.odhoman.homedevices.DeviceCreated"
+	TypeDeviceUpdated = "com.odhoman.homedevices.DeviceUpdated"
+	TypeDeviceDeleted = "com.odhoman.homedevices.DeviceDeleted"
+	// the device changed its home or its room
+	TypeDeviceMoved = "com.odhoman.homedevices.DeviceMoved"
+	// the device went online or offline
+	TypeDeviceStatusChanged = "com.odhoman.homedevices.DeviceStatusChanged"
+)
+
+// DeviceEvent is a change of a device in the CloudEvents 1.0 JSON format.
+type DeviceEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject"`
+	Time            string          `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            DeviceEventData `json:"data"`
+}
+
+// DeviceEventData has the device before and after the change, null when it did
+// not exist, and the operation, actor and source of the device history.
+type DeviceEventData struct {
+	Operation string                          `json:"operation"`
+	Actor     string                          `json:"actor"`
+	Source    string                          `json:"source"`
+	Before    *hDResponse.HomdeDeviceResponse `json:"before"`
+	After     *hDResponse.HomdeDeviceResponse `json:"after"`
+}
+
+// NewDeviceEvent returns an event of the type for the device with a new id.
+func NewDeviceEvent(eventType string, deviceId string, at time.Time, data DeviceEventData) DeviceEvent {
+	return DeviceEvent{
+		SpecVersion:     SpecVersion,
+		ID:              uuid.NewString(),
+		Source:          Source,
+		Type:            eventType,
+		Subject:         deviceId,
+		Time:            at.UTC().Format(time.RFC3339),
+		DataContentType: DataContentType,
+		Data:            data,
+	}
+}
+
+// HomeID returns the home of the device after the change, or before it when
+// the device no longer exists.
+func (dE DeviceEvent) HomeID() string {
+
+	if dE.Data.After != nil {
+		return dE.Data.After.HomeID
+	}
+
+	if dE.Data.Before != nil {
+		return dE.Data.Before.HomeID
+	}
+
+	return ""
+}
@@ -0,0 +1,108 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	hDConstants "github.com/odhoman/home-devices/internal/constants"
+	hDUtils "github.com/odhoman/home-devices/internal/utils"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+// Message attributes added to the events, so the subscriptions can filter
+// them without reading the body.
+const (
+	TypeAttribute   = "type"
+	HomeIdAttribute = "homeId"
+)
+
+// EventPublisher publishes the device events to the teams that react to them.
+type EventPublisher interface {
+	Publish(ctx context.Context, event DeviceEvent) error
+}
+
+type snsApi interface {
+	Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+type SNSEventPublisher struct {
+	SnsApi   snsApi
+	TopicArn string
+}
+
+func (sEP SNSEventPublisher) Publish(ctx context.Context, event DeviceEvent) error {
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	messageAttributes := map[string]types.MessageAttributeValue{
+		TypeAttribute: stringAttribute(event.Type),
+	}
+
+	if homeId := event.HomeID(); homeId != "" {
+		messageAttributes[HomeIdAttribute] = stringAttribute(homeId)
+	}
+
+	_, err = sEP.SnsApi.Publish(ctx, &sns.PublishInput{
+		TopicArn:          aws.String(sEP.TopicArn),
+		Message:           aws.String(string(body)),
+		MessageAttributes: messageAttributes,
+	})
+
+	return err
+}
+
+func stringAttribute(value string) types.MessageAttributeValue {
+	return types.MessageAttributeValue{
+		DataType:    aws.String("String"),
+		StringValue: aws.String(value),
+	}
+}
+
+// NewSNSEventPublisherFromConfig publishes the events to the topic of the
+// EVENT_TOPIC_ARN environment variable.
+func NewSNSEventPublisherFromConfig(cfg aws.Config) (EventPublisher, error) {
+
+	topicArn, err := hDUtils.GetValueProperty(hDConstants.EventTopicArnProperty)
+	if err != nil {
+		return nil, err
+	}
+
+	return SNSEventPublisher{SnsApi: sns.NewFromConfig(cfg), TopicArn: topicArn}, nil
+}
+
+// InMemoryEventPublisher keeps the published events in memory, for tests and
+// local runs.
+type InMemoryEventPublisher struct {
+	mutex  sync.RWMutex
+	events []DeviceEvent
+}
+
+func NewInMemoryEventPublisher() *InMemoryEventPublisher {
+	return &InMemoryEventPublisher{events: []DeviceEvent{}}
+}
+
+func (iMEP *InMemoryEventPublisher) Publish(ctx context.Context, event DeviceEvent) error {
+
+	iMEP.mutex.Lock()
+	defer iMEP.mutex.Unlock()
+
+	iMEP.events = append(iMEP.events, event)
+
+	return nil
+}
+
+// Events returns the events published so far, in order.
+func (iMEP *InMemoryEventPublisher) Events() []DeviceEvent {
+
+	iMEP.mutex.RLock()
+	defer iMEP.mutex.RUnlock()
+
+	return append([]DeviceEvent{}, iMEP.events...)
+}
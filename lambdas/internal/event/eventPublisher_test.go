@@ -0,0 +1,97 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSnsApi struct {
+	input *sns.PublishInput
+	err   error
+}
+
+func (f *fakeSnsApi) Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	f.input = params
+	return &sns.PublishOutput{}, f.err
+}
+
+func TestSNSEventPublisher_Publish(t *testing.T) {
+
+	api := &fakeSnsApi{}
+	publisher := SNSEventPublisher{SnsApi: api, TopicArn: "arn:aws:sns:us-east-1:123456789012:device-events"}
+
+	deviceEvent := NewDeviceEvent(TypeDeviceMoved, "device1", time.Date(2024, 10, 15, 13, 46, 40, 0, time.UTC), DeviceEventData{
+		Operation: "UPDATE",
+		Actor:     "user-1",
+		Source:    "SQS_LISTENER",
+		Before:    &hDResponse.HomdeDeviceResponse{ID: "device1", HomeID: "home1", Version: 1},
+		After:     &hDResponse.HomdeDeviceResponse{ID: "device1", HomeID: "home2", Version: 2},
+	})
+
+	err := publisher.Publish(context.TODO(), deviceEvent)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "arn:aws:sns:us-east-1:123456789012:device-events", *api.input.TopicArn)
+	assert.Equal(t, TypeDeviceMoved, *api.input.MessageAttributes[TypeAttribute].StringValue)
+	assert.Equal(t, "home2", *api.input.MessageAttributes[HomeIdAttribute].StringValue)
+
+	var message map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(*api.input.Message), &message))
+	assert.Equal(t, "1.0", message["specversion"])
+	assert.Equal(t, deviceEvent.ID, message["id"])
+	assert.Equal(t, "/home-devices/devices", message["source"])
+	assert.Equal(t, "com.odhoman.homedevices.DeviceMoved", message["type"])
+	assert.Equal(t, "device1", message["subject"])
+	assert.Equal(t, "2024-10-15T13:46:40Z", message["time"])
+	assert.Equal(t, "application/json", message["datacontenttype"])
+
+	data := message["data"].(map[string]interface{})
+	assert.Equal(t, "UPDATE", data["operation"])
+	assert.Equal(t, "home1", data["before"].(map[string]interface{})["homeId"])
+	assert.Equal(t, "home2", data["after"].(map[string]interface{})["homeId"])
+}
+
+func TestSNSEventPublisher_PublishDeletedDevice(t *testing.T) {
+
+	api := &fakeSnsApi{}
+	publisher := SNSEventPublisher{SnsApi: api, TopicArn: "device-events"}
+
+	err := publisher.Publish(context.TODO(), NewDeviceEvent(TypeDeviceDeleted, "device1", time.Now(), DeviceEventData{
+		Before: &hDResponse.HomdeDeviceResponse{ID: "device1", HomeID: "home1"},
+	}))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "home1", *api.input.MessageAttributes[HomeIdAttribute].StringValue)
+	assert.Contains(t, *api.input.Message, `"after":null`)
+}
+
+func TestSNSEventPublisher_PublishError(t *testing.T) {
+
+	publisher := SNSEventPublisher{SnsApi: &fakeSnsApi{err: errors.New("throttled")}, TopicArn: "device-events"}
+
+	err := publisher.Publish(context.TODO(), NewDeviceEvent(TypeDeviceCreated, "device1", time.Now(), DeviceEventData{}))
+
+	assert.Error(t, err)
+}
+
+func TestInMemoryEventPublisher_Publish(t *testing.T) {
+
+	publisher := NewInMemoryEventPublisher()
+
+	assert.NoError(t, publisher.Publish(context.TODO(), NewDeviceEvent(TypeDeviceCreated, "device1", time.Now(), DeviceEventData{})))
+	assert.NoError(t, publisher.Publish(context.TODO(), NewDeviceEvent(TypeDeviceDeleted, "device1", time.Now(), DeviceEventData{})))
+
+	events := publisher.Events()
+	assert.Len(t, events, 2)
+	assert.Equal(t, TypeDeviceCreated, events[0].Type)
+	assert.Equal(t, TypeDeviceDeleted, events[1].Type)
+	assert.NotEqual(t, events[0].ID, events[1].ID)
+}
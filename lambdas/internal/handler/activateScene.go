@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"context"
+	"log"
+
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ActivateSceneFromAPIGateway reads the home id and the scene id from the
+// path of an API Gateway request and activates the scene.
+func ActivateSceneFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, sceneService hDService.SceneService) (events.APIGatewayProxyResponse, error) {
+	return ActivateScene(ctx, request.PathParameters["homeId"], request.PathParameters["sceneId"], sceneService)
+}
+
+// ActivateScene returns 200 with the result of each device, even when some of
+// them failed; the client reads the status of each one.
+func ActivateScene(ctx context.Context, homeId string, id string, sceneService hDService.SceneService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("homeId", homeId); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	if err := hDValidation.CheckEmptyString("sceneId", id); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	activation, err := sceneService.ActivateScene(ctx, homeId, id)
+
+	if err != nil {
+		log.Println(err)
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnAPIGatewayProxyResponse(200, activation), nil
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	hDAudit "github.com/odhoman/home-devices/internal/audit"
+	"github.com/odhoman/home-devices/internal/constants"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const anonymousActor = "anonymous"
+
+// withAuditContext records the changes made by an API Gateway request as
+// coming from source and made by the caller of the request.
+func withAuditContext(ctx context.Context, request events.APIGatewayProxyRequest, source string) context.Context {
+	return hDAudit.WithSource(hDAudit.WithActor(ctx, getActor(request)), source)
+}
+
+// getActor returns the principal set by the authorizer, the subject of the
+// JWT claims or the IAM user of the request, in that order.
+func getActor(request events.APIGatewayProxyRequest) string {
+
+	authorizer := request.RequestContext.Authorizer
+
+	if principalId, ok := authorizer["principalId"].(string); ok && principalId != "" {
+		return principalId
+	}
+
+	if claims, ok := authorizer["claims"].(map[string]interface{}); ok {
+		if sub, ok := claims["sub"].(string); ok && sub != "" {
+			return sub
+		}
+	}
+
+	if request.RequestContext.Identity.UserArn != "" {
+		return request.RequestContext.Identity.UserArn
+	}
+
+	return anonymousActor
+}
+
+// isAdmin tells whether the caller is an admin, either with the admin value of
+// a Lambda authorizer or with the admin group of the Cognito claims.
+func isAdmin(request events.APIGatewayProxyRequest) bool {
+
+	authorizer := request.RequestContext.Authorizer
+
+	if admin, ok := authorizer[constants.AdminGroup]; ok && fmt.Sprint(admin) == "true" {
+		return true
+	}
+
+	claims, ok := authorizer["claims"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	// API Gateway passes the groups as one string, e.g. "[admin users]"
+	switch groups := claims["cognito:groups"].(type) {
+	case string:
+		for _, group := range strings.FieldsFunc(groups, isGroupSeparator) {
+			if group == constants.AdminGroup {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, group := range groups {
+			if group == constants.AdminGroup {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func isGroupSeparator(r rune) bool {
+	return r == ',' || r == ' ' || r == '[' || r == ']'
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	hDAudit "github.com/odhoman/home-devices/internal/audit"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithAuditContext(t *testing.T) {
+
+	ctx := withAuditContext(context.TODO(), events.APIGatewayProxyRequest{
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Authorizer: map[string]interface{}{"principalId": "user-1"},
+		},
+	}, hDAudit.SourceAPI)
+
+	assert.Equal(t, "user-1", hDAudit.ActorFromContext(ctx))
+	assert.Equal(t, hDAudit.SourceAPI, hDAudit.SourceFromContext(ctx))
+}
+
+func TestGetActor(t *testing.T) {
+
+	tests := map[string]struct {
+		requestContext events.APIGatewayProxyRequestContext
+		expected       string
+	}{
+		"Claims": {
+			requestContext: events.APIGatewayProxyRequestContext{
+				Authorizer: map[string]interface{}{"claims": map[string]interface{}{"sub": "user-2"}},
+			},
+			expected: "user-2",
+		},
+		"IAM": {
+			requestContext: events.APIGatewayProxyRequestContext{
+				Identity: events.APIGatewayRequestIdentity{UserArn: "arn:aws:iam::123456789012:user/admin"},
+			},
+			expected: "arn:aws:iam::123456789012:user/admin",
+		},
+		"Anonymous": {
+			expected: anonymousActor,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, getActor(events.APIGatewayProxyRequest{RequestContext: test.requestContext}))
+		})
+	}
+}
+
+func TestIsAdmin(t *testing.T) {
+
+	tests := map[string]struct {
+		authorizer map[string]interface{}
+		expected   bool
+	}{
+		"LambdaAuthorizer": {
+			authorizer: map[string]interface{}{"principalId": "user-1", "admin": "true"},
+			expected:   true,
+		},
+		"LambdaAuthorizerNotAdmin": {
+			authorizer: map[string]interface{}{"principalId": "user-1", "admin": false},
+			expected:   false,
+		},
+		"CognitoGroups": {
+			authorizer: map[string]interface{}{"claims": map[string]interface{}{"cognito:groups": "[users admin]"}},
+			expected:   true,
+		},
+		"CognitoGroupsList": {
+			authorizer: map[string]interface{}{"claims": map[string]interface{}{"cognito:groups": []interface{}{"admin"}}},
+			expected:   true,
+		},
+		"CognitoOtherGroups": {
+			authorizer: map[string]interface{}{"claims": map[string]interface{}{"cognito:groups": "administrators,users"}},
+			expected:   false,
+		},
+		"NoAuthorizer": {
+			expected: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, isAdmin(events.APIGatewayProxyRequest{
+				RequestContext: events.APIGatewayProxyRequestContext{Authorizer: test.authorizer},
+			}))
+		})
+	}
+}
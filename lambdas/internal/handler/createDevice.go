@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	hDAudit "github.com/odhoman/home-devices/internal/audit"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// CreateDeviceFromAPIGateway decodes the body of an API Gateway request and
+// creates the device.
+func CreateDeviceFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, deviceService hDService.HomeDeviceService) (events.APIGatewayProxyResponse, error) {
+
+	var createDeviceRequest hDRequest.CreateDeviceRequest
+	if err := json.Unmarshal([]byte(request.Body), &createDeviceRequest); err != nil {
+		log.Printf("Error deserializing JSON for createDevice lambda function: %v", err)
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(fmt.Sprintf("Invalid request body: %v", err)), nil
+	}
+
+	return CreateDevice(withAuditContext(ctx, request, hDAudit.SourceAPI), createDeviceRequest, deviceService)
+}
+
+func CreateDevice(ctx context.Context, device hDRequest.CreateDeviceRequest, deviceService hDService.HomeDeviceService) (events.APIGatewayProxyResponse, error) {
+
+	if invalidParams := hDValidation.ValidateRequestParams(ctx, device); len(invalidParams) > 0 {
+		return hDResponse.ReturnValidationErrorAPIGatewayProxyResponse(invalidParams), nil
+	}
+
+	deviceCreated, err := deviceService.CreateHomeDevice(ctx, device)
+
+	if err != nil {
+		log.Println(err)
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnAPIGatewayProxyResponse(201, deviceCreated), nil
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// CreateHomeFromAPIGateway decodes the body of an API Gateway request and
+// creates the home.
+func CreateHomeFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, homeService hDService.HomeService) (events.APIGatewayProxyResponse, error) {
+
+	var createHomeRequest hDRequest.CreateHomeRequest
+	if err := json.Unmarshal([]byte(request.Body), &createHomeRequest); err != nil {
+		log.Printf("Error deserializing JSON for createHome lambda function: %v", err)
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(fmt.Sprintf("Invalid request body: %v", err)), nil
+	}
+
+	return CreateHome(ctx, createHomeRequest, homeService)
+}
+
+func CreateHome(ctx context.Context, home hDRequest.CreateHomeRequest, homeService hDService.HomeService) (events.APIGatewayProxyResponse, error) {
+
+	if invalidParams := hDValidation.ValidateRequestParams(ctx, home); len(invalidParams) > 0 {
+		return hDResponse.ReturnValidationErrorAPIGatewayProxyResponse(invalidParams), nil
+	}
+
+	homeCreated, err := homeService.CreateHome(ctx, home)
+
+	if err != nil {
+		log.Println(err)
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnAPIGatewayProxyResponse(201, homeCreated), nil
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// CreateRoomFromAPIGateway decodes the body and the home id of an API Gateway
+// request and creates the room in the home.
+func CreateRoomFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, roomService hDService.RoomService) (events.APIGatewayProxyResponse, error) {
+
+	var createRoomRequest hDRequest.CreateRoomRequest
+	if err := json.Unmarshal([]byte(request.Body), &createRoomRequest); err != nil {
+		log.Printf("Error deserializing JSON for createRoom lambda function: %v", err)
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(fmt.Sprintf("Invalid request body: %v", err)), nil
+	}
+
+	return CreateRoom(ctx, request.PathParameters["homeId"], createRoomRequest, roomService)
+}
+
+func CreateRoom(ctx context.Context, homeId string, room hDRequest.CreateRoomRequest, roomService hDService.RoomService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("homeId", homeId); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	if invalidParams := hDValidation.ValidateRequestParams(ctx, room); len(invalidParams) > 0 {
+		return hDResponse.ReturnValidationErrorAPIGatewayProxyResponse(invalidParams), nil
+	}
+
+	roomCreated, err := roomService.CreateRoom(ctx, homeId, room)
+
+	if err != nil {
+		log.Println(err)
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnAPIGatewayProxyResponse(201, roomCreated), nil
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// CreateRuleFromAPIGateway decodes the body and the home id of an API Gateway
+// request and creates the rule in the home.
+func CreateRuleFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, ruleService hDService.RuleService) (events.APIGatewayProxyResponse, error) {
+
+	var ruleRequest hDRequest.RuleRequest
+	if err := json.Unmarshal([]byte(request.Body), &ruleRequest); err != nil {
+		log.Printf("Error deserializing JSON for createRule lambda function: %v", err)
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(fmt.Sprintf("Invalid request body: %v", err)), nil
+	}
+
+	return CreateRule(ctx, request.PathParameters["homeId"], ruleRequest, ruleService)
+}
+
+func CreateRule(ctx context.Context, homeId string, rule hDRequest.RuleRequest, ruleService hDService.RuleService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("homeId", homeId); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	if invalidParams := hDValidation.ValidateRequestParams(ctx, rule); len(invalidParams) > 0 {
+		return hDResponse.ReturnValidationErrorAPIGatewayProxyResponse(invalidParams), nil
+	}
+
+	ruleCreated, err := ruleService.CreateRule(ctx, homeId, rule)
+
+	if err != nil {
+		log.Println(err)
+
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnAPIGatewayProxyResponse(201, ruleCreated), nil
+}
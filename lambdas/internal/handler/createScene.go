@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// CreateSceneFromAPIGateway decodes the body and the home id of an API
+// Gateway request and creates the scene in the home.
+func CreateSceneFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, sceneService hDService.SceneService) (events.APIGatewayProxyResponse, error) {
+
+	var sceneRequest hDRequest.SceneRequest
+	if err := json.Unmarshal([]byte(request.Body), &sceneRequest); err != nil {
+		log.Printf("Error deserializing JSON for createScene lambda function: %v", err)
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(fmt.Sprintf("Invalid request body: %v", err)), nil
+	}
+
+	return CreateScene(ctx, request.PathParameters["homeId"], sceneRequest, sceneService)
+}
+
+func CreateScene(ctx context.Context, homeId string, scene hDRequest.SceneRequest, sceneService hDService.SceneService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("homeId", homeId); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	if invalidParams := hDValidation.ValidateRequestParams(ctx, scene); len(invalidParams) > 0 {
+		return hDResponse.ReturnValidationErrorAPIGatewayProxyResponse(invalidParams), nil
+	}
+
+	sceneCreated, err := sceneService.CreateScene(ctx, homeId, scene)
+
+	if err != nil {
+		log.Println(err)
+
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnAPIGatewayProxyResponse(201, sceneCreated), nil
+}
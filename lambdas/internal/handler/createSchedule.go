@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// CreateScheduleFromAPIGateway decodes the body and the home id of an API
+// Gateway request and creates the schedule in the home.
+func CreateScheduleFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, scheduleService hDService.ScheduleService) (events.APIGatewayProxyResponse, error) {
+
+	var scheduleRequest hDRequest.ScheduleRequest
+	if err := json.Unmarshal([]byte(request.Body), &scheduleRequest); err != nil {
+		log.Printf("Error deserializing JSON for createSchedule lambda function: %v", err)
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(fmt.Sprintf("Invalid request body: %v", err)), nil
+	}
+
+	return CreateSchedule(ctx, request.PathParameters["homeId"], scheduleRequest, scheduleService)
+}
+
+func CreateSchedule(ctx context.Context, homeId string, schedule hDRequest.ScheduleRequest, scheduleService hDService.ScheduleService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("homeId", homeId); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	if invalidParams := hDValidation.ValidateRequestParams(ctx, schedule); len(invalidParams) > 0 {
+		return hDResponse.ReturnValidationErrorAPIGatewayProxyResponse(invalidParams), nil
+	}
+
+	scheduleCreated, err := scheduleService.CreateSchedule(ctx, homeId, schedule)
+
+	if err != nil {
+		log.Println(err)
+
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnAPIGatewayProxyResponse(201, scheduleCreated), nil
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+
+	hDAudit "github.com/odhoman/home-devices/internal/audit"
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDUtils "github.com/odhoman/home-devices/internal/utils"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// DeleteDeviceFromAPIGateway reads the device id, the If-Match header and the
+// hard query parameter of an API Gateway request and deletes the device.
+func DeleteDeviceFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, deviceService hDService.HomeDeviceService) (events.APIGatewayProxyResponse, error) {
+
+	hard := false
+	if value := request.QueryStringParameters["hard"]; value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage("hard must be true or false"), nil
+		}
+		hard = parsed
+	}
+
+	source := hDAudit.SourceAPI
+	if hard {
+		if !isAdmin(request) {
+			return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(hDError.ErrHardDeleteNotAllowed.New()), nil
+		}
+		source = hDAudit.SourceAdmin
+	}
+
+	return DeleteDevice(withAuditContext(ctx, request, source), request.PathParameters["id"], hDUtils.GetHeader(request.Headers, "If-Match"), hard, deviceService)
+}
+
+// DeleteDevice soft deletes the device, so it can be restored within the
+// retention window, unless hard is true.
+func DeleteDevice(ctx context.Context, id string, ifMatch string, hard bool, deviceService hDService.HomeDeviceService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("id", id); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	expectedVersion, parseErr := hDUtils.ParseVersionETag(ifMatch)
+	if parseErr != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage("Invalid If-Match header"), nil
+	}
+
+	if hard {
+		if err := deviceService.HardDeleteHomeDevice(ctx, id, expectedVersion); err != nil {
+			return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+		}
+
+		return hDResponse.ReturnOKWithMessageAPIGatewayProxyResponse(200, "Device deleted"), nil
+	}
+
+	deleted, err := deviceService.DeleteHomeDevice(ctx, id, expectedVersion)
+	if err != nil {
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	response := hDResponse.ReturnOKWithMessageAPIGatewayProxyResponse(200, "Device deleted")
+	response.Headers["ETag"] = hDUtils.BuildVersionETag(deleted.Version)
+
+	return response, nil
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	hDAudit "github.com/odhoman/home-devices/internal/audit"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDeleteDeviceFromAPIGateway_Hard(t *testing.T) {
+
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockService.On("HardDeleteHomeDevice", mock.MatchedBy(func(ctx context.Context) bool {
+		return hDAudit.SourceFromContext(ctx) == hDAudit.SourceAdmin
+	}), "id", int64(2)).Return(nil)
+
+	response, err := DeleteDeviceFromAPIGateway(context.TODO(), events.APIGatewayProxyRequest{
+		PathParameters:        map[string]string{"id": "id"},
+		QueryStringParameters: map[string]string{"hard": "true"},
+		Headers:               map[string]string{"if-match": "\"2\""},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Authorizer: map[string]interface{}{"claims": map[string]interface{}{"sub": "user-1", "cognito:groups": "[admin users]"}},
+		},
+	}, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	mockService.AssertExpectations(t)
+}
+
+func TestDeleteDeviceFromAPIGateway_HardNotAdmin(t *testing.T) {
+
+	mockService := new(hDMock.MockHomeDeviceService)
+
+	response, err := DeleteDeviceFromAPIGateway(context.TODO(), events.APIGatewayProxyRequest{
+		PathParameters:        map[string]string{"id": "id"},
+		QueryStringParameters: map[string]string{"hard": "true"},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Authorizer: map[string]interface{}{"claims": map[string]interface{}{"sub": "user-1", "cognito:groups": "[users]"}},
+		},
+	}, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 403, response.StatusCode)
+	assert.Contains(t, response.Body, "Only an admin can hard delete a device")
+	mockService.AssertNotCalled(t, "HardDeleteHomeDevice", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDeleteDeviceFromAPIGateway_Soft(t *testing.T) {
+
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockService.On("DeleteHomeDevice", mock.MatchedBy(func(ctx context.Context) bool {
+		return hDAudit.SourceFromContext(ctx) == hDAudit.SourceAPI
+	}), "id", int64(0)).Return(&hDResponse.HomdeDeviceResponse{ID: "id", Version: 2}, nil)
+
+	response, err := DeleteDeviceFromAPIGateway(context.TODO(), events.APIGatewayProxyRequest{
+		PathParameters: map[string]string{"id": "id"},
+	}, mockService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	mockService.AssertExpectations(t)
+}
+
+func TestDeleteDeviceFromAPIGateway_InvalidHard(t *testing.T) {
+
+	response, _ := DeleteDeviceFromAPIGateway(context.TODO(), events.APIGatewayProxyRequest{
+		PathParameters:        map[string]string{"id": "id"},
+		QueryStringParameters: map[string]string{"hard": "yes please"},
+	}, new(hDMock.MockHomeDeviceService))
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Contains(t, response.Body, "hard must be true or false")
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"context"
+
+	hDAudit "github.com/odhoman/home-devices/internal/audit"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDUtils "github.com/odhoman/home-devices/internal/utils"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// DeleteHomeFromAPIGateway reads the home id, the If-Match header and the
+// strategy and targetHomeId query parameters of an API Gateway request and
+// deletes the home.
+func DeleteHomeFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, homeService hDService.HomeService) (events.APIGatewayProxyResponse, error) {
+
+	deleteHomeRequest := hDRequest.DeleteHomeRequest{
+		Strategy:     request.QueryStringParameters["strategy"],
+		TargetHomeID: request.QueryStringParameters["targetHomeId"],
+	}
+
+	// the devices deleted or moved with the home are recorded in their history
+	return DeleteHome(withAuditContext(ctx, request, hDAudit.SourceAPI), request.PathParameters["homeId"], deleteHomeRequest, hDUtils.GetHeader(request.Headers, "If-Match"), homeService)
+}
+
+func DeleteHome(ctx context.Context, id string, deleteHomeRequest hDRequest.DeleteHomeRequest, ifMatch string, homeService hDService.HomeService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("homeId", id); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	if invalidParams := hDValidation.ValidateRequestParams(ctx, deleteHomeRequest); len(invalidParams) > 0 {
+		return hDResponse.ReturnValidationErrorAPIGatewayProxyResponse(invalidParams), nil
+	}
+
+	expectedVersion, parseErr := hDUtils.ParseVersionETag(ifMatch)
+	if parseErr != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage("Invalid If-Match header"), nil
+	}
+
+	if err := homeService.DeleteHome(ctx, id, deleteHomeRequest, expectedVersion); err != nil {
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnOKWithMessageAPIGatewayProxyResponse(200, "Home deleted"), nil
+}
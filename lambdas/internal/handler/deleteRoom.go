@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"context"
+
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDUtils "github.com/odhoman/home-devices/internal/utils"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// DeleteRoomFromAPIGateway reads the home and room ids and the If-Match
+// header of an API Gateway request and deletes the room.
+func DeleteRoomFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, roomService hDService.RoomService) (events.APIGatewayProxyResponse, error) {
+	return DeleteRoom(ctx, request.PathParameters["homeId"], request.PathParameters["roomId"], hDUtils.GetHeader(request.Headers, "If-Match"), roomService)
+}
+
+func DeleteRoom(ctx context.Context, homeId string, id string, ifMatch string, roomService hDService.RoomService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("homeId", homeId); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	if err := hDValidation.CheckEmptyString("roomId", id); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	expectedVersion, parseErr := hDUtils.ParseVersionETag(ifMatch)
+	if parseErr != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage("Invalid If-Match header"), nil
+	}
+
+	if err := roomService.DeleteRoom(ctx, homeId, id, expectedVersion); err != nil {
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnOKWithMessageAPIGatewayProxyResponse(200, "Room deleted"), nil
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"context"
+
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDUtils "github.com/odhoman/home-devices/internal/utils"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// DeleteRuleFromAPIGateway reads the home and rule ids and the If-Match
+// header of an API Gateway request and deletes the rule.
+func DeleteRuleFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, ruleService hDService.RuleService) (events.APIGatewayProxyResponse, error) {
+	return DeleteRule(ctx, request.PathParameters["homeId"], request.PathParameters["ruleId"], hDUtils.GetHeader(request.Headers, "If-Match"), ruleService)
+}
+
+func DeleteRule(ctx context.Context, homeId string, id string, ifMatch string, ruleService hDService.RuleService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("homeId", homeId); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	if err := hDValidation.CheckEmptyString("ruleId", id); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	expectedVersion, parseErr := hDUtils.ParseVersionETag(ifMatch)
+	if parseErr != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage("Invalid If-Match header"), nil
+	}
+
+	if err := ruleService.DeleteRule(ctx, homeId, id, expectedVersion); err != nil {
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnOKWithMessageAPIGatewayProxyResponse(200, "Rule deleted"), nil
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"context"
+
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDUtils "github.com/odhoman/home-devices/internal/utils"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// DeleteSceneFromAPIGateway reads the home and scene ids and the
+// If-Match header of an API Gateway request and deletes the scene.
+func DeleteSceneFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, sceneService hDService.SceneService) (events.APIGatewayProxyResponse, error) {
+	return DeleteScene(ctx, request.PathParameters["homeId"], request.PathParameters["sceneId"], hDUtils.GetHeader(request.Headers, "If-Match"), sceneService)
+}
+
+func DeleteScene(ctx context.Context, homeId string, id string, ifMatch string, sceneService hDService.SceneService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("homeId", homeId); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	if err := hDValidation.CheckEmptyString("sceneId", id); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	expectedVersion, parseErr := hDUtils.ParseVersionETag(ifMatch)
+	if parseErr != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage("Invalid If-Match header"), nil
+	}
+
+	if err := sceneService.DeleteScene(ctx, homeId, id, expectedVersion); err != nil {
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnOKWithMessageAPIGatewayProxyResponse(200, "Scene deleted"), nil
+}
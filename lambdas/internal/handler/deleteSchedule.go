@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"context"
+
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDUtils "github.com/odhoman/home-devices/internal/utils"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// DeleteScheduleFromAPIGateway reads the home and schedule ids and the
+// If-Match header of an API Gateway request and deletes the schedule.
+func DeleteScheduleFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, scheduleService hDService.ScheduleService) (events.APIGatewayProxyResponse, error) {
+	return DeleteSchedule(ctx, request.PathParameters["homeId"], request.PathParameters["scheduleId"], hDUtils.GetHeader(request.Headers, "If-Match"), scheduleService)
+}
+
+func DeleteSchedule(ctx context.Context, homeId string, id string, ifMatch string, scheduleService hDService.ScheduleService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("homeId", homeId); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	if err := hDValidation.CheckEmptyString("scheduleId", id); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	expectedVersion, parseErr := hDUtils.ParseVersionETag(ifMatch)
+	if parseErr != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage("Invalid If-Match header"), nil
+	}
+
+	if err := scheduleService.DeleteSchedule(ctx, homeId, id, expectedVersion); err != nil {
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnOKWithMessageAPIGatewayProxyResponse(200, "Schedule deleted"), nil
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"context"
+
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDUtils "github.com/odhoman/home-devices/internal/utils"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// APIGatewayHandler is the signature of the FromAPIGateway functions, S is
+// the service of their resource.
+type APIGatewayHandler[S any] func(ctx context.Context, request events.APIGatewayProxyRequest, service S) (events.APIGatewayProxyResponse, error)
+
+// WithErrorNegotiation answers the errors of the handler in the format chosen
+// by the Accept header of the request, see NegotiateErrorResponse, and with
+// the validation messages in the language chosen by its Accept-Language
+// header.
+func WithErrorNegotiation[S any](handler APIGatewayHandler[S]) APIGatewayHandler[S] {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest, service S) (events.APIGatewayProxyResponse, error) {
+
+		language := hDValidation.NegotiateLanguage(hDUtils.GetHeader(request.Headers, "Accept-Language"))
+
+		response, err := handler(hDValidation.WithLanguage(ctx, language), request, service)
+		if err != nil {
+			return response, err
+		}
+
+		return hDResponse.NegotiateErrorResponse(response, hDUtils.GetHeader(request.Headers, "Accept"), request.RequestContext.RequestID), nil
+	}
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	hDError "github.com/odhoman/home-devices/internal/error"
+	hDMock "github.com/odhoman/home-devices/internal/mock"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newRequest(accept string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		PathParameters: map[string]string{"id": "id"},
+		Headers:        map[string]string{"accept": accept},
+		RequestContext: events.APIGatewayProxyRequestContext{RequestID: "c6af9ac6-7b61-11e6-9a41-93e8deadbeef"},
+	}
+}
+
+func TestWithErrorNegotiation_Problem(t *testing.T) {
+
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockService.On("GetHomeDevice", mock.Anything, "id").Return(nil, hDError.ErrDeviceNotFound.New())
+
+	for _, accept := range []string{"application/problem+json", "application/json;q=0.5, application/problem+json", "application/problem+json, */*"} {
+		response, err := WithErrorNegotiation(GetDeviceFromAPIGateway)(context.TODO(), newRequest(accept), mockService)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 404, response.StatusCode)
+		assert.Equal(t, "application/problem+json", response.Headers["Content-Type"], accept)
+		assert.JSONEq(t, `{
+			"type": "urn:home-devices:problem:ERROR_DEVICE_NOT_FOUND",
+			"title": "Device Not Found",
+			"status": 404,
+			"detail": "Device Not Found",
+			"instance": "c6af9ac6-7b61-11e6-9a41-93e8deadbeef",
+			"code": "ERROR_DEVICE_NOT_FOUND"
+		}`, response.Body)
+	}
+}
+
+func TestWithErrorNegotiation_Legacy(t *testing.T) {
+
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockService.On("GetHomeDevice", mock.Anything, "id").Return(nil, hDError.ErrDeviceNotFound.New())
+
+	for _, accept := range []string{"", "*/*", "application/json", "application/json, text/plain, */*", "application/problem+json;q=0.5, application/json", "application/problem+json, application/json", "application/problem+json;q=0"} {
+		response, err := WithErrorNegotiation(GetDeviceFromAPIGateway)(context.TODO(), newRequest(accept), mockService)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 404, response.StatusCode)
+		assert.Equal(t, "application/json", response.Headers["Content-Type"], accept)
+		assert.JSONEq(t, `{"errors": ["Device Not Found"]}`, response.Body)
+	}
+}
+
+func TestWithErrorNegotiation_InvalidParams(t *testing.T) {
+
+	request := newRequest(hDResponse.ProblemJSONContentType)
+	request.Body = `{"mac": "00:11:22:33:44", "name": "Living Room Light", "type": "toaster", "homeId": "home12122"}`
+
+	response, err := WithErrorNegotiation(CreateDeviceFromAPIGateway)(context.TODO(), request, new(hDMock.MockHomeDeviceService))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 400, response.StatusCode)
+
+	var problem hDResponse.ProblemDetails
+	assert.NoError(t, json.Unmarshal([]byte(response.Body), &problem))
+	assert.Equal(t, hDError.ErrValidation.Code, problem.Code)
+	assert.Equal(t, []string{"mac", "type"}, []string{problem.InvalidParams[0].Name, problem.InvalidParams[1].Name})
+	assert.Equal(t, "Please enter a valid MAC address", problem.InvalidParams[0].Reason)
+
+	request.Headers["accept"] = "application/json"
+	response, _ = WithErrorNegotiation(CreateDeviceFromAPIGateway)(context.TODO(), request, new(hDMock.MockHomeDeviceService))
+
+	assert.JSONEq(t, `{"errors": ["Please enter a valid MAC address", "`+problem.InvalidParams[1].Reason+`"]}`, response.Body)
+}
+
+func TestWithErrorNegotiation_InternalErrorHasNoDetails(t *testing.T) {
+
+	mockService := new(hDMock.MockHomeDeviceService)
+	mockService.On("GetHomeDevice", mock.Anything, "id").Return(nil, hDError.ErrGettingDevice.New().WithDetail("table", "HomeDevices"))
+
+	response, _ := WithErrorNegotiation(GetDeviceFromAPIGateway)(context.TODO(), newRequest(hDResponse.ProblemJSONContentType), mockService)
+
+	var problem hDResponse.ProblemDetails
+	assert.NoError(t, json.Unmarshal([]byte(response.Body), &problem))
+	assert.Equal(t, 500, problem.Status)
+	assert.Equal(t, hDError.ErrGettingDevice.PublicMessage, problem.Detail)
+	assert.Empty(t, problem.Details)
+}
+
+func TestWithErrorNegotiation_AcceptLanguage(t *testing.T) {
+
+	request := newRequest(hDResponse.ProblemJSONContentType)
+	request.Headers["accept-language"] = "es-ES,es;q=0.9,en;q=0.8"
+	request.Body = `{"mac": "00:11:22:33:44:55", "type": "light", "homeId": "home12122"}`
+
+	response, _ := WithErrorNegotiation(CreateDeviceFromAPIGateway)(context.TODO(), request, new(hDMock.MockHomeDeviceService))
+
+	var problem hDResponse.ProblemDetails
+	assert.NoError(t, json.Unmarshal([]byte(response.Body), &problem))
+	assert.Equal(t, []hDResponse.InvalidParam{{Name: "name", Reason: "name es obligatorio", Rule: "required", MessageKey: "required"}}, problem.InvalidParams)
+	assert.Equal(t, "name es obligatorio", problem.Detail)
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"context"
+
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDUtils "github.com/odhoman/home-devices/internal/utils"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// GetDeviceFromAPIGateway reads the device id from the path of an API Gateway
+// request and returns the device.
+func GetDeviceFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, deviceService hDService.HomeDeviceService) (events.APIGatewayProxyResponse, error) {
+	return GetDevice(ctx, request.PathParameters["id"], deviceService)
+}
+
+func GetDevice(ctx context.Context, id string, deviceService hDService.HomeDeviceService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("id", id); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	device, err := deviceService.GetHomeDevice(ctx, id)
+
+	if err != nil {
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	response := hDResponse.ReturnAPIGatewayProxyResponse(200, device)
+	response.Headers["ETag"] = hDUtils.BuildVersionETag(device.Version)
+
+	return response, nil
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"context"
+
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// GetDeviceCommandFromAPIGateway reads the device id and the command id from
+// the path of an API Gateway request and returns the command.
+func GetDeviceCommandFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, deviceCommandService hDService.DeviceCommandService) (events.APIGatewayProxyResponse, error) {
+	return GetDeviceCommand(ctx, request.PathParameters["id"], request.PathParameters["cmdId"], deviceCommandService)
+}
+
+func GetDeviceCommand(ctx context.Context, deviceId string, id string, deviceCommandService hDService.DeviceCommandService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("id", deviceId); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	if err := hDValidation.CheckEmptyString("cmdId", id); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	command, err := deviceCommandService.GetDeviceCommand(ctx, deviceId, id)
+
+	if err != nil {
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnAPIGatewayProxyResponse(200, command), nil
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// GetDeviceHistoryFromAPIGateway reads the device id from the path and the
+// limit and cursor from the query string of an API Gateway request and
+// returns the changes of the device, newest first.
+func GetDeviceHistoryFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, deviceService hDService.HomeDeviceService) (events.APIGatewayProxyResponse, error) {
+
+	historyRequest, parseErr := BuildDeviceHistoryRequest(request)
+	if parseErr != nil {
+		log.Printf("Error parsing query parameters for getDeviceHistory lambda function: %v", parseErr)
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage("Limit must be a number"), nil
+	}
+
+	return GetDeviceHistory(ctx, historyRequest, deviceService)
+}
+
+func GetDeviceHistory(ctx context.Context, historyRequest hDRequest.DeviceHistoryRequest, deviceService hDService.HomeDeviceService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("id", historyRequest.ID); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	if invalidParams := hDValidation.ValidateRequestParams(ctx, historyRequest); len(invalidParams) > 0 {
+		return hDResponse.ReturnValidationErrorAPIGatewayProxyResponse(invalidParams), nil
+	}
+
+	history, err := deviceService.GetDeviceHistory(ctx, historyRequest.ID, historyRequest.Limit, historyRequest.Cursor)
+
+	if err != nil {
+		log.Println(err)
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnAPIGatewayProxyResponse(200, history), nil
+}
+
+func BuildDeviceHistoryRequest(request events.APIGatewayProxyRequest) (hDRequest.DeviceHistoryRequest, error) {
+
+	historyRequest := hDRequest.DeviceHistoryRequest{
+		ID:     request.PathParameters["id"],
+		Cursor: request.QueryStringParameters["cursor"],
+	}
+
+	if limit := request.QueryStringParameters["limit"]; limit != "" {
+		value, err := strconv.ParseInt(limit, 10, 32)
+		if err != nil {
+			return historyRequest, err
+		}
+		historyRequest.Limit = int32(value)
+	}
+
+	return historyRequest, nil
+}
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"context"
+	"log"
+
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// GetDeviceStateFromAPIGateway reads the device id from the path of an API
+// Gateway request and returns the desired and reported state of the device.
+func GetDeviceStateFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, deviceStateService hDService.DeviceStateService) (events.APIGatewayProxyResponse, error) {
+	return GetDeviceState(ctx, request.PathParameters["id"], deviceStateService)
+}
+
+func GetDeviceState(ctx context.Context, id string, deviceStateService hDService.DeviceStateService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("id", id); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	state, err := deviceStateService.GetDeviceState(ctx, id)
+
+	if err != nil {
+		log.Println(err)
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnAPIGatewayProxyResponse(200, state), nil
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"context"
+
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDUtils "github.com/odhoman/home-devices/internal/utils"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// GetHomeFromAPIGateway reads the home id from the path of an API Gateway
+// request and returns the home.
+func GetHomeFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, homeService hDService.HomeService) (events.APIGatewayProxyResponse, error) {
+	return GetHome(ctx, request.PathParameters["homeId"], homeService)
+}
+
+func GetHome(ctx context.Context, id string, homeService hDService.HomeService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("homeId", id); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	home, err := homeService.GetHome(ctx, id)
+
+	if err != nil {
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	response := hDResponse.ReturnAPIGatewayProxyResponse(200, home)
+	response.Headers["ETag"] = hDUtils.BuildVersionETag(home.Version)
+
+	return response, nil
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"context"
+
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDUtils "github.com/odhoman/home-devices/internal/utils"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// GetRoomFromAPIGateway reads the home id and the room id from the path of an
+// API Gateway request and returns the room.
+func GetRoomFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, roomService hDService.RoomService) (events.APIGatewayProxyResponse, error) {
+	return GetRoom(ctx, request.PathParameters["homeId"], request.PathParameters["roomId"], roomService)
+}
+
+func GetRoom(ctx context.Context, homeId string, id string, roomService hDService.RoomService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("homeId", homeId); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	if err := hDValidation.CheckEmptyString("roomId", id); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	room, err := roomService.GetRoom(ctx, homeId, id)
+
+	if err != nil {
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	response := hDResponse.ReturnAPIGatewayProxyResponse(200, room)
+	response.Headers["ETag"] = hDUtils.BuildVersionETag(room.Version)
+
+	return response, nil
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"context"
+
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDUtils "github.com/odhoman/home-devices/internal/utils"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// GetRuleFromAPIGateway reads the home id and the rule id from the path of an
+// API Gateway request and returns the rule.
+func GetRuleFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, ruleService hDService.RuleService) (events.APIGatewayProxyResponse, error) {
+	return GetRule(ctx, request.PathParameters["homeId"], request.PathParameters["ruleId"], ruleService)
+}
+
+func GetRule(ctx context.Context, homeId string, id string, ruleService hDService.RuleService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("homeId", homeId); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	if err := hDValidation.CheckEmptyString("ruleId", id); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	rule, err := ruleService.GetRule(ctx, homeId, id)
+
+	if err != nil {
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	response := hDResponse.ReturnAPIGatewayProxyResponse(200, rule)
+	response.Headers["ETag"] = hDUtils.BuildVersionETag(rule.Version)
+
+	return response, nil
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"context"
+
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDUtils "github.com/odhoman/home-devices/internal/utils"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// GetSceneFromAPIGateway reads the home id and the scene id from the
+// path of an API Gateway request and returns the scene.
+func GetSceneFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, sceneService hDService.SceneService) (events.APIGatewayProxyResponse, error) {
+	return GetScene(ctx, request.PathParameters["homeId"], request.PathParameters["sceneId"], sceneService)
+}
+
+func GetScene(ctx context.Context, homeId string, id string, sceneService hDService.SceneService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("homeId", homeId); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	if err := hDValidation.CheckEmptyString("sceneId", id); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	scene, err := sceneService.GetScene(ctx, homeId, id)
+
+	if err != nil {
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	response := hDResponse.ReturnAPIGatewayProxyResponse(200, scene)
+	response.Headers["ETag"] = hDUtils.BuildVersionETag(scene.Version)
+
+	return response, nil
+}
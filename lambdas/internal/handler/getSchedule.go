@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"context"
+
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDUtils "github.com/odhoman/home-devices/internal/utils"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// GetScheduleFromAPIGateway reads the home id and the schedule id from the
+// path of an API Gateway request and returns the schedule.
+func GetScheduleFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, scheduleService hDService.ScheduleService) (events.APIGatewayProxyResponse, error) {
+	return GetSchedule(ctx, request.PathParameters["homeId"], request.PathParameters["scheduleId"], scheduleService)
+}
+
+func GetSchedule(ctx context.Context, homeId string, id string, scheduleService hDService.ScheduleService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("homeId", homeId); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	if err := hDValidation.CheckEmptyString("scheduleId", id); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	schedule, err := scheduleService.GetSchedule(ctx, homeId, id)
+
+	if err != nil {
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	response := hDResponse.ReturnAPIGatewayProxyResponse(200, schedule)
+	response.Headers["ETag"] = hDUtils.BuildVersionETag(schedule.Version)
+
+	return response, nil
+}
@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"context"
+
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ListDeviceTypesFromAPIGateway returns the schemas of the device types.
+func ListDeviceTypesFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, deviceTypeService hDService.DeviceTypeService) (events.APIGatewayProxyResponse, error) {
+	return ListDeviceTypes(ctx, deviceTypeService)
+}
+
+func ListDeviceTypes(ctx context.Context, deviceTypeService hDService.DeviceTypeService) (events.APIGatewayProxyResponse, error) {
+	return hDResponse.ReturnAPIGatewayProxyResponse(200, deviceTypeService.ListDeviceTypes(ctx)), nil
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ListDevicesFromAPIGateway reads the homeId from the path and the roomId,
+// status, limit and cursor from the query string of an API Gateway request and lists
+// the devices of the home.
+func ListDevicesFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, deviceService hDService.HomeDeviceService) (events.APIGatewayProxyResponse, error) {
+
+	listDevicesRequest, parseErr := BuildListDevicesRequest(request)
+	if parseErr != nil {
+		log.Printf("Error parsing query parameters for listDevices lambda function: %v", parseErr)
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage("Limit must be a number"), nil
+	}
+
+	return ListDevices(ctx, listDevicesRequest, deviceService)
+}
+
+func ListDevices(ctx context.Context, listRequest hDRequest.ListDevicesRequest, deviceService hDService.HomeDeviceService) (events.APIGatewayProxyResponse, error) {
+
+	if invalidParams := hDValidation.ValidateRequestParams(ctx, listRequest); len(invalidParams) > 0 {
+		return hDResponse.ReturnValidationErrorAPIGatewayProxyResponse(invalidParams), nil
+	}
+
+	devices, err := deviceService.ListHomeDevices(ctx, listRequest.HomeID, listRequest.RoomID, listRequest.Status, listRequest.Limit, listRequest.Cursor)
+
+	if err != nil {
+		log.Println(err)
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnAPIGatewayProxyResponse(200, devices), nil
+}
+
+func BuildListDevicesRequest(request events.APIGatewayProxyRequest) (hDRequest.ListDevicesRequest, error) {
+
+	listDevicesRequest := hDRequest.ListDevicesRequest{
+		HomeID: request.PathParameters["homeId"],
+		RoomID: request.QueryStringParameters["roomId"],
+		Status: request.QueryStringParameters["status"],
+		Cursor: request.QueryStringParameters["cursor"],
+	}
+
+	if limit := request.QueryStringParameters["limit"]; limit != "" {
+		value, err := strconv.ParseInt(limit, 10, 32)
+		if err != nil {
+			return listDevicesRequest, err
+		}
+		listDevicesRequest.Limit = int32(value)
+	}
+
+	return listDevicesRequest, nil
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"testing"
+
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildListDevicesRequest_Success(t *testing.T) {
+
+	request := events.APIGatewayProxyRequest{
+		PathParameters:        map[string]string{"homeId": "home12122"},
+		QueryStringParameters: map[string]string{"limit": "10", "cursor": "cursor"},
+	}
+
+	listDevicesRequest, err := BuildListDevicesRequest(request)
+
+	assert.NoError(t, err)
+	assert.Equal(t, hDRequest.ListDevicesRequest{HomeID: "home12122", Limit: 10, Cursor: "cursor"}, listDevicesRequest)
+}
+
+func TestBuildListDevicesRequest_InvalidLimit(t *testing.T) {
+
+	request := events.APIGatewayProxyRequest{
+		PathParameters:        map[string]string{"homeId": "home12122"},
+		QueryStringParameters: map[string]string{"limit": "ten"},
+	}
+
+	_, err := BuildListDevicesRequest(request)
+
+	assert.Error(t, err)
+}
+
+func TestBuildListDevicesRequest_RoomId(t *testing.T) {
+
+	request := events.APIGatewayProxyRequest{
+		PathParameters:        map[string]string{"homeId": "home12122"},
+		QueryStringParameters: map[string]string{"roomId": "8d3c1b6e-2f4a-4c51-9a57-0d6f4f1f2b11"},
+	}
+
+	listDevicesRequest, err := BuildListDevicesRequest(request)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "8d3c1b6e-2f4a-4c51-9a57-0d6f4f1f2b11", listDevicesRequest.RoomID)
+}
+
+func TestBuildListDevicesRequest_Status(t *testing.T) {
+
+	request := events.APIGatewayProxyRequest{
+		PathParameters:        map[string]string{"homeId": "home12122"},
+		QueryStringParameters: map[string]string{"status": "offline"},
+	}
+
+	listDevicesRequest, err := BuildListDevicesRequest(request)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "offline", listDevicesRequest.Status)
+}
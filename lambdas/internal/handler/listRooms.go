@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"context"
+
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ListRoomsFromAPIGateway reads the home id from the path of an API Gateway
+// request and lists the rooms of the home.
+func ListRoomsFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, roomService hDService.RoomService) (events.APIGatewayProxyResponse, error) {
+	return ListRooms(ctx, request.PathParameters["homeId"], roomService)
+}
+
+func ListRooms(ctx context.Context, homeId string, roomService hDService.RoomService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("homeId", homeId); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	rooms, err := roomService.ListRooms(ctx, homeId)
+
+	if err != nil {
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnAPIGatewayProxyResponse(200, rooms), nil
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"context"
+
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ListRuleExecutionsFromAPIGateway reads the home id and the rule id from the
+// path of an API Gateway request and returns the last executions of the rule.
+func ListRuleExecutionsFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, ruleService hDService.RuleService) (events.APIGatewayProxyResponse, error) {
+	return ListRuleExecutions(ctx, request.PathParameters["homeId"], request.PathParameters["ruleId"], ruleService)
+}
+
+func ListRuleExecutions(ctx context.Context, homeId string, ruleId string, ruleService hDService.RuleService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("homeId", homeId); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	if err := hDValidation.CheckEmptyString("ruleId", ruleId); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	executions, err := ruleService.ListRuleExecutions(ctx, homeId, ruleId)
+
+	if err != nil {
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnAPIGatewayProxyResponse(200, executions), nil
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"context"
+
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ListRulesFromAPIGateway reads the home id from the path of an API Gateway
+// request and lists the rules of the home.
+func ListRulesFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, ruleService hDService.RuleService) (events.APIGatewayProxyResponse, error) {
+	return ListRules(ctx, request.PathParameters["homeId"], ruleService)
+}
+
+func ListRules(ctx context.Context, homeId string, ruleService hDService.RuleService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("homeId", homeId); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	rules, err := ruleService.ListRules(ctx, homeId)
+
+	if err != nil {
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnAPIGatewayProxyResponse(200, rules), nil
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"context"
+
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ListScenesFromAPIGateway reads the home id from the path of an API Gateway
+// request and lists the scenes of the home.
+func ListScenesFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, sceneService hDService.SceneService) (events.APIGatewayProxyResponse, error) {
+	return ListScenes(ctx, request.PathParameters["homeId"], sceneService)
+}
+
+func ListScenes(ctx context.Context, homeId string, sceneService hDService.SceneService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("homeId", homeId); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	scenes, err := sceneService.ListScenes(ctx, homeId)
+
+	if err != nil {
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnAPIGatewayProxyResponse(200, scenes), nil
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"context"
+
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ListSchedulesFromAPIGateway reads the home id from the path of an API Gateway
+// request and lists the schedules of the home.
+func ListSchedulesFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, scheduleService hDService.ScheduleService) (events.APIGatewayProxyResponse, error) {
+	return ListSchedules(ctx, request.PathParameters["homeId"], scheduleService)
+}
+
+func ListSchedules(ctx context.Context, homeId string, scheduleService hDService.ScheduleService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("homeId", homeId); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	schedules, err := scheduleService.ListSchedules(ctx, homeId)
+
+	if err != nil {
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnAPIGatewayProxyResponse(200, schedules), nil
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"context"
+	"log"
+
+	hDAudit "github.com/odhoman/home-devices/internal/audit"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDUtils "github.com/odhoman/home-devices/internal/utils"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// RestoreDeviceFromAPIGateway reads the device id from the path of an API
+// Gateway request and restores the deleted device.
+func RestoreDeviceFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, deviceService hDService.HomeDeviceService) (events.APIGatewayProxyResponse, error) {
+	return RestoreDevice(withAuditContext(ctx, request, hDAudit.SourceAPI), request.PathParameters["id"], deviceService)
+}
+
+func RestoreDevice(ctx context.Context, id string, deviceService hDService.HomeDeviceService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("id", id); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	device, err := deviceService.RestoreHomeDevice(ctx, id)
+
+	if err != nil {
+		log.Println(err)
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	response := hDResponse.ReturnAPIGatewayProxyResponse(200, device)
+	response.Headers["ETag"] = hDUtils.BuildVersionETag(device.Version)
+
+	return response, nil
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// SendDeviceCommandFromAPIGateway decodes the body and the device id of an
+// API Gateway request and sends the command to the device.
+func SendDeviceCommandFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, deviceCommandService hDService.DeviceCommandService) (events.APIGatewayProxyResponse, error) {
+
+	var commandRequest hDRequest.SendDeviceCommandRequest
+	if err := json.Unmarshal([]byte(request.Body), &commandRequest); err != nil {
+		log.Printf("Error deserializing JSON: %v", err)
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(fmt.Sprintf("Invalid request body: %v", err)), nil
+	}
+
+	return SendDeviceCommand(ctx, request.PathParameters["id"], commandRequest, deviceCommandService)
+}
+
+// SendDeviceCommand returns 202 with the pending command.
+func SendDeviceCommand(ctx context.Context, id string, commandRequest hDRequest.SendDeviceCommandRequest, deviceCommandService hDService.DeviceCommandService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("id", id); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	if invalidParams := hDValidation.ValidateRequestParams(ctx, commandRequest); len(invalidParams) > 0 {
+		return hDResponse.ReturnValidationErrorAPIGatewayProxyResponse(invalidParams), nil
+	}
+
+	command, err := deviceCommandService.SendDeviceCommand(ctx, id, commandRequest)
+
+	if err != nil {
+		log.Println(err)
+
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnAPIGatewayProxyResponse(202, command), nil
+}
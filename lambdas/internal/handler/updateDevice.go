@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	hDAudit "github.com/odhoman/home-devices/internal/audit"
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDUtils "github.com/odhoman/home-devices/internal/utils"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// UpdateDeviceFromAPIGateway decodes the body, the device id and the
+// If-Match header of an API Gateway request and updates the device.
+func UpdateDeviceFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, deviceService hDService.HomeDeviceService) (events.APIGatewayProxyResponse, error) {
+
+	var updateDeviceRequest hDRequest.UpdateDeviceRequest
+	if err := json.Unmarshal([]byte(request.Body), &updateDeviceRequest); err != nil {
+		log.Printf("Error deserializing JSON: %v", err)
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(fmt.Sprintf("Invalid request body: %v", err)), nil
+	}
+
+	return UpdateDevice(withAuditContext(ctx, request, hDAudit.SourceAPI), updateDeviceRequest, request.PathParameters["id"], hDUtils.GetHeader(request.Headers, "If-Match"), deviceService)
+}
+
+func UpdateDevice(ctx context.Context, device hDRequest.UpdateDeviceRequest, id string, ifMatch string, deviceService hDService.HomeDeviceService) (events.APIGatewayProxyResponse, error) {
+
+	if invalidParams := hDValidation.ValidateRequestParams(ctx, device); len(invalidParams) > 0 {
+		return hDResponse.ReturnValidationErrorAPIGatewayProxyResponse(invalidParams), nil
+	}
+
+	expectedVersion, parseErr := hDUtils.ParseVersionETag(ifMatch)
+	if parseErr != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage("Invalid If-Match header"), nil
+	}
+
+	updated, err := deviceService.UpdateHomeDevice(ctx, device, id, expectedVersion)
+	if err != nil {
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	response := hDResponse.ReturnOKWithMessageAPIGatewayProxyResponse(201, "Device updated")
+	response.Headers["ETag"] = hDUtils.BuildVersionETag(updated.Version)
+
+	return response, nil
+}
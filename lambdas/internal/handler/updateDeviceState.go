@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// UpdateDeviceStateFromAPIGateway decodes the body and the device id of an
+// API Gateway request and merges the body into the state of the device.
+func UpdateDeviceStateFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, deviceStateService hDService.DeviceStateService) (events.APIGatewayProxyResponse, error) {
+
+	var stateRequest hDRequest.UpdateDeviceStateRequest
+	if err := json.Unmarshal([]byte(request.Body), &stateRequest); err != nil {
+		log.Printf("Error deserializing JSON: %v", err)
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(fmt.Sprintf("Invalid request body: %v", err)), nil
+	}
+
+	return UpdateDeviceState(ctx, request.PathParameters["id"], stateRequest, deviceStateService)
+}
+
+func UpdateDeviceState(ctx context.Context, id string, stateRequest hDRequest.UpdateDeviceStateRequest, deviceStateService hDService.DeviceStateService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("id", id); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	if invalidParams := hDValidation.ValidateRequestParams(ctx, stateRequest); len(invalidParams) > 0 {
+		return hDResponse.ReturnValidationErrorAPIGatewayProxyResponse(invalidParams), nil
+	}
+
+	state, err := deviceStateService.UpdateDeviceState(ctx, id, stateRequest)
+
+	if err != nil {
+		log.Println(err)
+
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnAPIGatewayProxyResponse(200, state), nil
+}
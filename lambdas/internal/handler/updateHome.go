@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDUtils "github.com/odhoman/home-devices/internal/utils"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// UpdateHomeFromAPIGateway decodes the body, the home id and the If-Match
+// header of an API Gateway request and updates the home.
+func UpdateHomeFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, homeService hDService.HomeService) (events.APIGatewayProxyResponse, error) {
+
+	var updateHomeRequest hDRequest.UpdateHomeRequest
+	if err := json.Unmarshal([]byte(request.Body), &updateHomeRequest); err != nil {
+		log.Printf("Error deserializing JSON: %v", err)
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(fmt.Sprintf("Invalid request body: %v", err)), nil
+	}
+
+	return UpdateHome(ctx, updateHomeRequest, request.PathParameters["homeId"], hDUtils.GetHeader(request.Headers, "If-Match"), homeService)
+}
+
+func UpdateHome(ctx context.Context, home hDRequest.UpdateHomeRequest, id string, ifMatch string, homeService hDService.HomeService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("homeId", id); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	if invalidParams := hDValidation.ValidateRequestParams(ctx, home); len(invalidParams) > 0 {
+		return hDResponse.ReturnValidationErrorAPIGatewayProxyResponse(invalidParams), nil
+	}
+
+	expectedVersion, parseErr := hDUtils.ParseVersionETag(ifMatch)
+	if parseErr != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage("Invalid If-Match header"), nil
+	}
+
+	if err := homeService.UpdateHome(ctx, home, id, expectedVersion); err != nil {
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnOKWithMessageAPIGatewayProxyResponse(200, "Home updated"), nil
+}
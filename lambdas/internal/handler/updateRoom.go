@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDUtils "github.com/odhoman/home-devices/internal/utils"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// UpdateRoomFromAPIGateway decodes the body, the home and room ids and the
+// If-Match header of an API Gateway request and updates the room.
+func UpdateRoomFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, roomService hDService.RoomService) (events.APIGatewayProxyResponse, error) {
+
+	var updateRoomRequest hDRequest.UpdateRoomRequest
+	if err := json.Unmarshal([]byte(request.Body), &updateRoomRequest); err != nil {
+		log.Printf("Error deserializing JSON: %v", err)
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(fmt.Sprintf("Invalid request body: %v", err)), nil
+	}
+
+	return UpdateRoom(ctx, updateRoomRequest, request.PathParameters["homeId"], request.PathParameters["roomId"], hDUtils.GetHeader(request.Headers, "If-Match"), roomService)
+}
+
+func UpdateRoom(ctx context.Context, room hDRequest.UpdateRoomRequest, homeId string, id string, ifMatch string, roomService hDService.RoomService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("homeId", homeId); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	if err := hDValidation.CheckEmptyString("roomId", id); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	if invalidParams := hDValidation.ValidateRequestParams(ctx, room); len(invalidParams) > 0 {
+		return hDResponse.ReturnValidationErrorAPIGatewayProxyResponse(invalidParams), nil
+	}
+
+	expectedVersion, parseErr := hDUtils.ParseVersionETag(ifMatch)
+	if parseErr != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage("Invalid If-Match header"), nil
+	}
+
+	if err := roomService.UpdateRoom(ctx, room, homeId, id, expectedVersion); err != nil {
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnOKWithMessageAPIGatewayProxyResponse(200, "Room updated"), nil
+}
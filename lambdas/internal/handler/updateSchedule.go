@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDService "github.com/odhoman/home-devices/internal/service"
+	hDUtils "github.com/odhoman/home-devices/internal/utils"
+	hDValidation "github.com/odhoman/home-devices/internal/validation"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// UpdateScheduleFromAPIGateway decodes the body, the home and schedule ids and
+// the If-Match header of an API Gateway request and replaces the schedule.
+func UpdateScheduleFromAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest, scheduleService hDService.ScheduleService) (events.APIGatewayProxyResponse, error) {
+
+	var scheduleRequest hDRequest.ScheduleRequest
+	if err := json.Unmarshal([]byte(request.Body), &scheduleRequest); err != nil {
+		log.Printf("Error deserializing JSON: %v", err)
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(fmt.Sprintf("Invalid request body: %v", err)), nil
+	}
+
+	return UpdateSchedule(ctx, scheduleRequest, request.PathParameters["homeId"], request.PathParameters["scheduleId"], hDUtils.GetHeader(request.Headers, "If-Match"), scheduleService)
+}
+
+func UpdateSchedule(ctx context.Context, schedule hDRequest.ScheduleRequest, homeId string, id string, ifMatch string, scheduleService hDService.ScheduleService) (events.APIGatewayProxyResponse, error) {
+
+	if err := hDValidation.CheckEmptyString("homeId", homeId); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	if err := hDValidation.CheckEmptyString("scheduleId", id); err != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage(err.Error()), nil
+	}
+
+	if invalidParams := hDValidation.ValidateRequestParams(ctx, schedule); len(invalidParams) > 0 {
+		return hDResponse.ReturnValidationErrorAPIGatewayProxyResponse(invalidParams), nil
+	}
+
+	expectedVersion, parseErr := hDUtils.ParseVersionETag(ifMatch)
+	if parseErr != nil {
+		return hDResponse.BadRequestErrorAPIGatewayProxyResponseSingleMessage("Invalid If-Match header"), nil
+	}
+
+	if err := scheduleService.UpdateSchedule(ctx, schedule, homeId, id, expectedVersion); err != nil {
+		log.Println(err)
+		return hDResponse.ReturnHomeDeviceErrorAPIGatewayProxyResponse(err), nil
+	}
+
+	return hDResponse.ReturnOKWithMessageAPIGatewayProxyResponse(200, "Schedule updated"), nil
+}
@@ -0,0 +1,18 @@
+package mock
+
+import (
+	"context"
+
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCommandQueue struct {
+	mock.Mock
+}
+
+func (m *MockCommandQueue) Publish(ctx context.Context, command response.DeviceCommandResponse) error {
+	args := m.Called(ctx, command)
+	return args.Error(0)
+}
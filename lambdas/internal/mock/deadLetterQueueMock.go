@@ -0,0 +1,17 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockDeadLetterQueue struct {
+	mock.Mock
+}
+
+func (m *MockDeadLetterQueue) Send(ctx context.Context, message events.SQSMessage, reason string, errorMessage string) error {
+	args := m.Called(ctx, message, reason, errorMessage)
+	return args.Error(0)
+}
@@ -0,0 +1,38 @@
+package mock
+
+import (
+	"context"
+
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockDeviceCommandDao struct {
+	mock.Mock
+}
+
+func (m *MockDeviceCommandDao) SaveDeviceCommand(ctx context.Context, command response.DeviceCommandResponse) (*response.DeviceCommandResponse, error) {
+	args := m.Called(ctx, command)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.DeviceCommandResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockDeviceCommandDao) GetDeviceCommand(ctx context.Context, deviceId string, id string) (*response.DeviceCommandResponse, error) {
+	args := m.Called(ctx, deviceId, id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.DeviceCommandResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockDeviceCommandDao) CompleteDeviceCommand(ctx context.Context, deviceId string, id string, update request.DeviceCommandStatusUpdate) (*response.DeviceCommandResponse, error) {
+	args := m.Called(ctx, deviceId, id, update)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.DeviceCommandResponse), nil
+	}
+	return nil, args.Error(1)
+}
@@ -0,0 +1,38 @@
+package mock
+
+import (
+	"context"
+
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockDeviceCommandService struct {
+	mock.Mock
+}
+
+func (m *MockDeviceCommandService) SendDeviceCommand(ctx context.Context, deviceId string, command request.SendDeviceCommandRequest) (*response.DeviceCommandResponse, error) {
+	args := m.Called(ctx, deviceId, command)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.DeviceCommandResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockDeviceCommandService) GetDeviceCommand(ctx context.Context, deviceId string, id string) (*response.DeviceCommandResponse, error) {
+	args := m.Called(ctx, deviceId, id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.DeviceCommandResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockDeviceCommandService) AcknowledgeDeviceCommand(ctx context.Context, ack request.DeviceCommandAck) (*response.DeviceCommandResponse, error) {
+	args := m.Called(ctx, ack)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.DeviceCommandResponse), nil
+	}
+	return nil, args.Error(1)
+}
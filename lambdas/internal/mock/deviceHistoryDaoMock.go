@@ -0,0 +1,29 @@
+package mock
+
+import (
+	"context"
+
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockDeviceHistoryDao struct {
+	mock.Mock
+}
+
+func (m *MockDeviceHistoryDao) SaveDeviceChange(ctx context.Context, change response.DeviceChangeResponse) (*response.DeviceChangeResponse, error) {
+	args := m.Called(ctx, change)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.DeviceChangeResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockDeviceHistoryDao) ListDeviceHistory(ctx context.Context, deviceId string, limit int32, cursor string) (*response.DeviceHistoryResponse, error) {
+	args := m.Called(ctx, deviceId, limit, cursor)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.DeviceHistoryResponse), nil
+	}
+	return nil, args.Error(1)
+}
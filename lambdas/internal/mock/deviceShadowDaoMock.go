@@ -0,0 +1,30 @@
+package mock
+
+import (
+	"context"
+
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockDeviceShadowDao struct {
+	mock.Mock
+}
+
+func (m *MockDeviceShadowDao) GetDeviceShadow(ctx context.Context, deviceId string) (*response.DeviceStateResponse, error) {
+	args := m.Called(ctx, deviceId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.DeviceStateResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockDeviceShadowDao) UpdateDeviceShadow(ctx context.Context, deviceId string, update request.DeviceShadowUpdate) (*response.DeviceStateResponse, error) {
+	args := m.Called(ctx, deviceId, update)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.DeviceStateResponse), nil
+	}
+	return nil, args.Error(1)
+}
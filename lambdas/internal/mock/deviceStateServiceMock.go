@@ -0,0 +1,30 @@
+package mock
+
+import (
+	"context"
+
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockDeviceStateService struct {
+	mock.Mock
+}
+
+func (m *MockDeviceStateService) GetDeviceState(ctx context.Context, id string) (*response.DeviceStateResponse, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.DeviceStateResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockDeviceStateService) UpdateDeviceState(ctx context.Context, id string, state request.UpdateDeviceStateRequest) (*response.DeviceStateResponse, error) {
+	args := m.Called(ctx, id, state)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.DeviceStateResponse), nil
+	}
+	return nil, args.Error(1)
+}
@@ -0,0 +1,21 @@
+package mock
+
+import (
+	"context"
+
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockDeviceTypeService struct {
+	mock.Mock
+}
+
+func (m *MockDeviceTypeService) ListDeviceTypes(ctx context.Context) *response.DeviceTypeListResponse {
+	args := m.Called(ctx)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.DeviceTypeListResponse)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package mock
+
+import (
+	"context"
+
+	event "github.com/odhoman/home-devices/internal/event"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockEventPublisher struct {
+	mock.Mock
+}
+
+func (m *MockEventPublisher) Publish(ctx context.Context, deviceEvent event.DeviceEvent) error {
+	args := m.Called(ctx, deviceEvent)
+	return args.Error(0)
+}
@@ -3,7 +3,6 @@ package mock
 import (
 	"context"
 
-	hdError "github.com/odhoman/home-devices/internal/error"
 	request "github.com/odhoman/home-devices/internal/request"
 	hdREsponse "github.com/odhoman/home-devices/internal/response"
 	"github.com/stretchr/testify/mock"
@@ -13,36 +12,90 @@ type MockHomeDeviceDao struct {
 	mock.Mock
 }
 
-func (m *MockHomeDeviceDao) IsDeviceExist(ctx context.Context, MAC, HomeID string) (bool, *hdError.HomeDeviceError) {
+func (m *MockHomeDeviceDao) IsDeviceExist(ctx context.Context, MAC, HomeID string) (bool, error) {
 	args := m.Called(ctx, MAC, HomeID)
-	return args.Bool(0), args.Get(1).(*hdError.HomeDeviceError)
+	return args.Bool(0), args.Error(1)
 }
 
-func (m *MockHomeDeviceDao) SaveHomeDevice(ctx context.Context, device request.CreateDeviceRequest) (*hdREsponse.HomdeDeviceResponse, *hdError.HomeDeviceError) {
+func (m *MockHomeDeviceDao) SaveHomeDevice(ctx context.Context, device request.CreateDeviceRequest) (*hdREsponse.HomdeDeviceResponse, error) {
 	args := m.Called(ctx, device)
-	return args.Get(0).(*hdREsponse.HomdeDeviceResponse), args.Get(1).(*hdError.HomeDeviceError)
+	return args.Get(0).(*hdREsponse.HomdeDeviceResponse), args.Error(1)
 }
 
-func (m *MockHomeDeviceDao) DeleteHomeDevice(ctx context.Context, id string) *hdError.HomeDeviceError {
-	args := m.Called(ctx, id)
+func (m *MockHomeDeviceDao) DeleteHomeDevice(ctx context.Context, id string, expectedVersion int64) (*hdREsponse.HomdeDeviceResponse, error) {
+	args := m.Called(ctx, id, expectedVersion)
 	if args.Get(0) != nil {
-		return args.Get(0).(*hdError.HomeDeviceError)
+		return args.Get(0).(*hdREsponse.HomdeDeviceResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockHomeDeviceDao) HardDeleteHomeDevice(ctx context.Context, id string, expectedVersion int64) error {
+	args := m.Called(ctx, id, expectedVersion)
+	if args.Get(0) != nil {
+		return args.Error(0)
 	}
 	return nil
 }
 
-func (m *MockHomeDeviceDao) GetHomeDevice(ctx context.Context, id string) (*hdREsponse.HomdeDeviceResponse, *hdError.HomeDeviceError) {
+func (m *MockHomeDeviceDao) GetDeletedHomeDevice(ctx context.Context, id string) (*hdREsponse.HomdeDeviceResponse, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) != nil {
 		return args.Get(0).(*hdREsponse.HomdeDeviceResponse), nil
 	}
-	return nil, args.Get(1).(*hdError.HomeDeviceError)
+	return nil, args.Error(1)
 }
 
-func (m *MockHomeDeviceDao) UpdateHomeDevice(ctx context.Context, device request.UpdateDeviceRequest, id string) *hdError.HomeDeviceError {
-	args := m.Called(ctx, device, id)
+func (m *MockHomeDeviceDao) RestoreHomeDevice(ctx context.Context, id string) (*hdREsponse.HomdeDeviceResponse, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) != nil {
-		return args.Get(0).(*hdError.HomeDeviceError)
+		return args.Get(0).(*hdREsponse.HomdeDeviceResponse), nil
 	}
-	return nil
+	return nil, args.Error(1)
+}
+
+func (m *MockHomeDeviceDao) GetHomeDevice(ctx context.Context, id string) (*hdREsponse.HomdeDeviceResponse, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*hdREsponse.HomdeDeviceResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockHomeDeviceDao) UpdateHomeDevice(ctx context.Context, device request.UpdateDeviceRequest, id string, expectedVersion int64) (*hdREsponse.HomdeDeviceResponse, error) {
+	args := m.Called(ctx, device, id, expectedVersion)
+	if args.Get(0) != nil {
+		return args.Get(0).(*hdREsponse.HomdeDeviceResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockHomeDeviceDao) ListHomeDevices(ctx context.Context, homeId string, roomId string, status string, limit int32, cursor string) (*hdREsponse.HomeDeviceListResponse, error) {
+	args := m.Called(ctx, homeId, roomId, status, limit, cursor)
+	if args.Get(0) != nil {
+		return args.Get(0).(*hdREsponse.HomeDeviceListResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockHomeDeviceDao) MarkHomeDeviceSeen(ctx context.Context, id string, seenAt int64) (*hdREsponse.HomdeDeviceResponse, error) {
+	args := m.Called(ctx, id, seenAt)
+	if args.Get(0) != nil {
+		return args.Get(0).(*hdREsponse.HomdeDeviceResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockHomeDeviceDao) ListStaleHomeDevices(ctx context.Context, seenBefore int64) ([]hdREsponse.HomdeDeviceResponse, error) {
+	args := m.Called(ctx, seenBefore)
+	if args.Get(0) != nil {
+		return args.Get(0).([]hdREsponse.HomdeDeviceResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockHomeDeviceDao) MarkHomeDeviceOffline(ctx context.Context, id string, lastSeenAt int64) (bool, error) {
+	args := m.Called(ctx, id, lastSeenAt)
+	err := args.Error(1)
+	return args.Bool(0), err
 }
@@ -2,8 +2,8 @@ package mock
 
 import (
 	"context"
+	"time"
 
-	hdError "github.com/odhoman/home-devices/internal/error"
 	request "github.com/odhoman/home-devices/internal/request"
 	response "github.com/odhoman/home-devices/internal/response"
 
@@ -14,34 +14,81 @@ type MockHomeDeviceService struct {
 	mock.Mock
 }
 
-func (m *MockHomeDeviceService) CreateHomeDevice(ctx context.Context, device request.CreateDeviceRequest) (*response.HomdeDeviceResponse, *hdError.HomeDeviceError) {
+func (m *MockHomeDeviceService) CreateHomeDevice(ctx context.Context, device request.CreateDeviceRequest) (*response.HomdeDeviceResponse, error) {
 	args := m.Called(ctx, device)
 	if args.Get(0) != nil {
 		return args.Get(0).(*response.HomdeDeviceResponse), nil
 	}
-	return nil, args.Get(1).(*hdError.HomeDeviceError)
+	return nil, args.Error(1)
 }
 
-func (m *MockHomeDeviceService) GetHomeDevice(ctx context.Context, id string) (*response.HomdeDeviceResponse, *hdError.HomeDeviceError) {
+func (m *MockHomeDeviceService) GetHomeDevice(ctx context.Context, id string) (*response.HomdeDeviceResponse, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) != nil {
 		return args.Get(0).(*response.HomdeDeviceResponse), nil
 	}
-	return nil, args.Get(1).(*hdError.HomeDeviceError)
+	return nil, args.Error(1)
 }
 
-func (m *MockHomeDeviceService) UpdateHomeDevice(ctx context.Context, device request.UpdateDeviceRequest, id string) *hdError.HomeDeviceError {
-	args := m.Called(ctx, device, id)
+func (m *MockHomeDeviceService) UpdateHomeDevice(ctx context.Context, device request.UpdateDeviceRequest, id string, expectedVersion int64) (*response.HomdeDeviceResponse, error) {
+	args := m.Called(ctx, device, id, expectedVersion)
 	if args.Get(0) != nil {
-		return args.Get(0).(*hdError.HomeDeviceError)
+		return args.Get(0).(*response.HomdeDeviceResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockHomeDeviceService) DeleteHomeDevice(ctx context.Context, id string, expectedVersion int64) (*response.HomdeDeviceResponse, error) {
+	args := m.Called(ctx, id, expectedVersion)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.HomdeDeviceResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockHomeDeviceService) HardDeleteHomeDevice(ctx context.Context, id string, expectedVersion int64) error {
+	args := m.Called(ctx, id, expectedVersion)
+	if args.Get(0) != nil {
+		return args.Error(0)
 	}
 	return nil
 }
 
-func (m *MockHomeDeviceService) DeleteHomeDevice(ctx context.Context, id string) *hdError.HomeDeviceError {
+func (m *MockHomeDeviceService) RestoreHomeDevice(ctx context.Context, id string) (*response.HomdeDeviceResponse, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) != nil {
-		return args.Get(0).(*hdError.HomeDeviceError)
+		return args.Get(0).(*response.HomdeDeviceResponse), nil
 	}
-	return nil
+	return nil, args.Error(1)
+}
+
+func (m *MockHomeDeviceService) ListHomeDevices(ctx context.Context, homeId string, roomId string, status string, limit int32, cursor string) (*response.HomeDeviceListResponse, error) {
+	args := m.Called(ctx, homeId, roomId, status, limit, cursor)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.HomeDeviceListResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockHomeDeviceService) GetDeviceHistory(ctx context.Context, id string, limit int32, cursor string) (*response.DeviceHistoryResponse, error) {
+	args := m.Called(ctx, id, limit, cursor)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.DeviceHistoryResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockHomeDeviceService) MarkDeviceSeen(ctx context.Context, id string, seenAt time.Time) (bool, error) {
+	args := m.Called(ctx, id, seenAt)
+	err := args.Error(1)
+	return args.Bool(0), err
+}
+
+// MarkOfflineDevices returns the devices and the error, marking can fail
+// after some devices were marked.
+func (m *MockHomeDeviceService) MarkOfflineDevices(ctx context.Context, now time.Time) ([]response.HomdeDeviceResponse, error) {
+	args := m.Called(ctx, now)
+	devices, _ := args.Get(0).([]response.HomdeDeviceResponse)
+	err := args.Error(1)
+	return devices, err
 }
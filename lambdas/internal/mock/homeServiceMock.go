@@ -0,0 +1,46 @@
+package mock
+
+import (
+	"context"
+
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockHomeService struct {
+	mock.Mock
+}
+
+func (m *MockHomeService) CreateHome(ctx context.Context, home request.CreateHomeRequest) (*response.HomeResponse, error) {
+	args := m.Called(ctx, home)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.HomeResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockHomeService) GetHome(ctx context.Context, id string) (*response.HomeResponse, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.HomeResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockHomeService) UpdateHome(ctx context.Context, home request.UpdateHomeRequest, id string, expectedVersion int64) error {
+	args := m.Called(ctx, home, id, expectedVersion)
+	if args.Get(0) != nil {
+		return args.Error(0)
+	}
+	return nil
+}
+
+func (m *MockHomeService) DeleteHome(ctx context.Context, id string, deleteRequest request.DeleteHomeRequest, expectedVersion int64) error {
+	args := m.Called(ctx, id, deleteRequest, expectedVersion)
+	if args.Get(0) != nil {
+		return args.Error(0)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package mock
+
+import (
+	"context"
+
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRoomDao struct {
+	mock.Mock
+}
+
+func (m *MockRoomDao) SaveRoom(ctx context.Context, homeId string, room request.CreateRoomRequest) (*response.RoomResponse, error) {
+	args := m.Called(ctx, homeId, room)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.RoomResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRoomDao) GetRoom(ctx context.Context, homeId string, id string) (*response.RoomResponse, error) {
+	args := m.Called(ctx, homeId, id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.RoomResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRoomDao) ListRooms(ctx context.Context, homeId string) (*response.RoomListResponse, error) {
+	args := m.Called(ctx, homeId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.RoomListResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRoomDao) UpdateRoom(ctx context.Context, room request.UpdateRoomRequest, homeId string, id string, expectedVersion int64) error {
+	args := m.Called(ctx, room, homeId, id, expectedVersion)
+	if args.Get(0) != nil {
+		return args.Error(0)
+	}
+	return nil
+}
+
+func (m *MockRoomDao) DeleteRoom(ctx context.Context, homeId string, id string, expectedVersion int64) error {
+	args := m.Called(ctx, homeId, id, expectedVersion)
+	if args.Get(0) != nil {
+		return args.Error(0)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package mock
+
+import (
+	"context"
+
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRuleDao struct {
+	mock.Mock
+}
+
+func (m *MockRuleDao) SaveRule(ctx context.Context, homeId string, rule request.RuleRequest) (*response.RuleResponse, error) {
+	args := m.Called(ctx, homeId, rule)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.RuleResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRuleDao) GetRule(ctx context.Context, homeId string, id string) (*response.RuleResponse, error) {
+	args := m.Called(ctx, homeId, id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.RuleResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRuleDao) ListRules(ctx context.Context, homeId string) (*response.RuleListResponse, error) {
+	args := m.Called(ctx, homeId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.RuleListResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRuleDao) ListTriggeredRules(ctx context.Context, deviceId string) ([]response.RuleResponse, error) {
+	args := m.Called(ctx, deviceId)
+	if args.Get(0) != nil {
+		return args.Get(0).([]response.RuleResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRuleDao) UpdateRule(ctx context.Context, rule request.RuleRequest, homeId string, id string, expectedVersion int64) error {
+	args := m.Called(ctx, rule, homeId, id, expectedVersion)
+	if args.Get(0) != nil {
+		return args.Error(0)
+	}
+	return nil
+}
+
+func (m *MockRuleDao) DeleteRule(ctx context.Context, homeId string, id string, expectedVersion int64) error {
+	args := m.Called(ctx, homeId, id, expectedVersion)
+	if args.Get(0) != nil {
+		return args.Error(0)
+	}
+	return nil
+}
+
+func (m *MockRuleDao) ClaimRuleFiring(ctx context.Context, homeId string, id string, readingAt int64, firedAt int64, cooldownSeconds int64) error {
+	args := m.Called(ctx, homeId, id, readingAt, firedAt, cooldownSeconds)
+	if args.Get(0) != nil {
+		return args.Error(0)
+	}
+	return nil
+}
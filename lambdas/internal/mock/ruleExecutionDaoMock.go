@@ -0,0 +1,29 @@
+package mock
+
+import (
+	"context"
+
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRuleExecutionDao struct {
+	mock.Mock
+}
+
+func (m *MockRuleExecutionDao) SaveRuleExecution(ctx context.Context, execution response.RuleExecutionResponse) (*response.RuleExecutionResponse, error) {
+	args := m.Called(ctx, execution)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.RuleExecutionResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRuleExecutionDao) ListRuleExecutions(ctx context.Context, ruleId string, limit int32) (*response.RuleExecutionListResponse, error) {
+	args := m.Called(ctx, ruleId, limit)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.RuleExecutionListResponse), nil
+	}
+	return nil, args.Error(1)
+}
@@ -0,0 +1,71 @@
+package mock
+
+import (
+	"context"
+
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRuleService struct {
+	mock.Mock
+}
+
+func (m *MockRuleService) CreateRule(ctx context.Context, homeId string, rule request.RuleRequest) (*response.RuleResponse, error) {
+	args := m.Called(ctx, homeId, rule)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.RuleResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRuleService) GetRule(ctx context.Context, homeId string, id string) (*response.RuleResponse, error) {
+	args := m.Called(ctx, homeId, id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.RuleResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRuleService) ListRules(ctx context.Context, homeId string) (*response.RuleListResponse, error) {
+	args := m.Called(ctx, homeId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.RuleListResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRuleService) UpdateRule(ctx context.Context, rule request.RuleRequest, homeId string, id string, expectedVersion int64) error {
+	args := m.Called(ctx, rule, homeId, id, expectedVersion)
+	if args.Get(0) != nil {
+		return args.Error(0)
+	}
+	return nil
+}
+
+func (m *MockRuleService) DeleteRule(ctx context.Context, homeId string, id string, expectedVersion int64) error {
+	args := m.Called(ctx, homeId, id, expectedVersion)
+	if args.Get(0) != nil {
+		return args.Error(0)
+	}
+	return nil
+}
+
+func (m *MockRuleService) ListRuleExecutions(ctx context.Context, homeId string, ruleId string) (*response.RuleExecutionListResponse, error) {
+	args := m.Called(ctx, homeId, ruleId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.RuleExecutionListResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+// EvaluateRules returns the executions and the error, the evaluation can fail
+// after some rules fired.
+func (m *MockRuleService) EvaluateRules(ctx context.Context, reading request.TelemetryReadingRequest) ([]response.RuleExecutionResponse, error) {
+	args := m.Called(ctx, reading)
+	executions, _ := args.Get(0).([]response.RuleExecutionResponse)
+	err := args.Error(1)
+	return executions, err
+}
@@ -0,0 +1,54 @@
+package mock
+
+import (
+	"context"
+
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSceneDao struct {
+	mock.Mock
+}
+
+func (m *MockSceneDao) SaveScene(ctx context.Context, homeId string, scene request.SceneRequest) (*response.SceneResponse, error) {
+	args := m.Called(ctx, homeId, scene)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.SceneResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockSceneDao) GetScene(ctx context.Context, homeId string, id string) (*response.SceneResponse, error) {
+	args := m.Called(ctx, homeId, id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.SceneResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockSceneDao) ListScenes(ctx context.Context, homeId string) (*response.SceneListResponse, error) {
+	args := m.Called(ctx, homeId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.SceneListResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockSceneDao) UpdateScene(ctx context.Context, scene request.SceneRequest, homeId string, id string, expectedVersion int64) error {
+	args := m.Called(ctx, scene, homeId, id, expectedVersion)
+	if args.Get(0) != nil {
+		return args.Error(0)
+	}
+	return nil
+}
+
+func (m *MockSceneDao) DeleteScene(ctx context.Context, homeId string, id string, expectedVersion int64) error {
+	args := m.Called(ctx, homeId, id, expectedVersion)
+	if args.Get(0) != nil {
+		return args.Error(0)
+	}
+	return nil
+}
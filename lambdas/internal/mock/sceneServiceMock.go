@@ -0,0 +1,62 @@
+package mock
+
+import (
+	"context"
+
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSceneService struct {
+	mock.Mock
+}
+
+func (m *MockSceneService) CreateScene(ctx context.Context, homeId string, scene request.SceneRequest) (*response.SceneResponse, error) {
+	args := m.Called(ctx, homeId, scene)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.SceneResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockSceneService) GetScene(ctx context.Context, homeId string, id string) (*response.SceneResponse, error) {
+	args := m.Called(ctx, homeId, id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.SceneResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockSceneService) ListScenes(ctx context.Context, homeId string) (*response.SceneListResponse, error) {
+	args := m.Called(ctx, homeId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.SceneListResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockSceneService) UpdateScene(ctx context.Context, scene request.SceneRequest, homeId string, id string, expectedVersion int64) error {
+	args := m.Called(ctx, scene, homeId, id, expectedVersion)
+	if args.Get(0) != nil {
+		return args.Error(0)
+	}
+	return nil
+}
+
+func (m *MockSceneService) DeleteScene(ctx context.Context, homeId string, id string, expectedVersion int64) error {
+	args := m.Called(ctx, homeId, id, expectedVersion)
+	if args.Get(0) != nil {
+		return args.Error(0)
+	}
+	return nil
+}
+
+func (m *MockSceneService) ActivateScene(ctx context.Context, homeId string, id string) (*response.SceneActivationResponse, error) {
+	args := m.Called(ctx, homeId, id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.SceneActivationResponse), nil
+	}
+	return nil, args.Error(1)
+}
@@ -0,0 +1,69 @@
+package mock
+
+import (
+	"context"
+
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockScheduleDao struct {
+	mock.Mock
+}
+
+func (m *MockScheduleDao) SaveSchedule(ctx context.Context, schedule response.ScheduleResponse) (*response.ScheduleResponse, error) {
+	args := m.Called(ctx, schedule)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.ScheduleResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockScheduleDao) GetSchedule(ctx context.Context, homeId string, id string) (*response.ScheduleResponse, error) {
+	args := m.Called(ctx, homeId, id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.ScheduleResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockScheduleDao) ListSchedules(ctx context.Context, homeId string) (*response.ScheduleListResponse, error) {
+	args := m.Called(ctx, homeId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.ScheduleListResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockScheduleDao) ListDueSchedules(ctx context.Context, now int64) ([]response.ScheduleResponse, error) {
+	args := m.Called(ctx, now)
+	if args.Get(0) != nil {
+		return args.Get(0).([]response.ScheduleResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockScheduleDao) UpdateSchedule(ctx context.Context, schedule response.ScheduleResponse, expectedVersion int64) error {
+	args := m.Called(ctx, schedule, expectedVersion)
+	if args.Get(0) != nil {
+		return args.Error(0)
+	}
+	return nil
+}
+
+func (m *MockScheduleDao) DeleteSchedule(ctx context.Context, homeId string, id string, expectedVersion int64) error {
+	args := m.Called(ctx, homeId, id, expectedVersion)
+	if args.Get(0) != nil {
+		return args.Error(0)
+	}
+	return nil
+}
+
+func (m *MockScheduleDao) ClaimScheduleRun(ctx context.Context, homeId string, id string, runAt int64, nextRunAt int64) error {
+	args := m.Called(ctx, homeId, id, runAt, nextRunAt)
+	if args.Get(0) != nil {
+		return args.Error(0)
+	}
+	return nil
+}
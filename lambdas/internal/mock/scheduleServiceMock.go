@@ -0,0 +1,64 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockScheduleService struct {
+	mock.Mock
+}
+
+func (m *MockScheduleService) CreateSchedule(ctx context.Context, homeId string, schedule request.ScheduleRequest) (*response.ScheduleResponse, error) {
+	args := m.Called(ctx, homeId, schedule)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.ScheduleResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockScheduleService) GetSchedule(ctx context.Context, homeId string, id string) (*response.ScheduleResponse, error) {
+	args := m.Called(ctx, homeId, id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.ScheduleResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockScheduleService) ListSchedules(ctx context.Context, homeId string) (*response.ScheduleListResponse, error) {
+	args := m.Called(ctx, homeId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*response.ScheduleListResponse), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockScheduleService) UpdateSchedule(ctx context.Context, schedule request.ScheduleRequest, homeId string, id string, expectedVersion int64) error {
+	args := m.Called(ctx, schedule, homeId, id, expectedVersion)
+	if args.Get(0) != nil {
+		return args.Error(0)
+	}
+	return nil
+}
+
+func (m *MockScheduleService) DeleteSchedule(ctx context.Context, homeId string, id string, expectedVersion int64) error {
+	args := m.Called(ctx, homeId, id, expectedVersion)
+	if args.Get(0) != nil {
+		return args.Error(0)
+	}
+	return nil
+}
+
+// RunDueSchedules returns the runs and the error, running can fail after some
+// schedules ran.
+func (m *MockScheduleService) RunDueSchedules(ctx context.Context, now time.Time) ([]response.ScheduleRunResponse, error) {
+	args := m.Called(ctx, now)
+	runs, _ := args.Get(0).([]response.ScheduleRunResponse)
+	err := args.Error(1)
+	return runs, err
+}
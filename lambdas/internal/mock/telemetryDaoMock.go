@@ -0,0 +1,21 @@
+package mock
+
+import (
+	"context"
+
+	request "github.com/odhoman/home-devices/internal/request"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTelemetryDao struct {
+	mock.Mock
+}
+
+func (m *MockTelemetryDao) SaveTelemetryReading(ctx context.Context, reading request.TelemetryReadingRequest) error {
+	args := m.Called(ctx, reading)
+	if args.Get(0) != nil {
+		return args.Error(0)
+	}
+	return nil
+}
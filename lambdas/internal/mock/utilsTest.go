@@ -22,11 +22,39 @@ import (
 func MockEnvVars() {
 	os.Setenv(hDConstants.TableNameHomeDevicesProperty, "table")
 	os.Setenv(hDConstants.MacHomeIdIndexNameProperty, "index")
+	os.Setenv(hDConstants.HomeIdIndexNameProperty, "homeIndex")
+	os.Setenv(hDConstants.StatusIndexNameProperty, "statusIndex")
+	os.Setenv(hDConstants.DeviceHistoryTableNameProperty, "historyTable")
+	os.Setenv(hDConstants.TelemetryTableNameProperty, "telemetryTable")
+	os.Setenv(hDConstants.DeviceShadowTableNameProperty, "shadowTable")
+	os.Setenv(hDConstants.HomeTableNameProperty, "homeTable")
+	os.Setenv(hDConstants.RoomTableNameProperty, "roomTable")
+	os.Setenv(hDConstants.DeviceCommandTableNameProperty, "commandTable")
+	os.Setenv(hDConstants.RuleTableNameProperty, "ruleTable")
+	os.Setenv(hDConstants.TriggerDeviceIdIndexProperty, "triggerIndex")
+	os.Setenv(hDConstants.RuleExecutionTableNameProperty, "ruleExecutionTable")
+	os.Setenv(hDConstants.ScheduleTableNameProperty, "scheduleTable")
+	os.Setenv(hDConstants.NextRunAtIndexNameProperty, "nextRunAtIndex")
+	os.Setenv(hDConstants.SceneTableNameProperty, "sceneTable")
 }
 
 func ClearEnvVars() {
 	os.Setenv(hDConstants.TableNameHomeDevicesProperty, "")
 	os.Setenv(hDConstants.MacHomeIdIndexNameProperty, "")
+	os.Setenv(hDConstants.HomeIdIndexNameProperty, "")
+	os.Setenv(hDConstants.StatusIndexNameProperty, "")
+	os.Setenv(hDConstants.DeviceHistoryTableNameProperty, "")
+	os.Setenv(hDConstants.TelemetryTableNameProperty, "")
+	os.Setenv(hDConstants.DeviceShadowTableNameProperty, "")
+	os.Setenv(hDConstants.HomeTableNameProperty, "")
+	os.Setenv(hDConstants.RoomTableNameProperty, "")
+	os.Setenv(hDConstants.DeviceCommandTableNameProperty, "")
+	os.Setenv(hDConstants.RuleTableNameProperty, "")
+	os.Setenv(hDConstants.TriggerDeviceIdIndexProperty, "")
+	os.Setenv(hDConstants.RuleExecutionTableNameProperty, "")
+	os.Setenv(hDConstants.ScheduleTableNameProperty, "")
+	os.Setenv(hDConstants.NextRunAtIndexNameProperty, "")
+	os.Setenv(hDConstants.SceneTableNameProperty, "")
 }
 
 func Setup(t *testing.M) (string, func(t *testing.M)) {
@@ -109,6 +137,18 @@ func Setup(t *testing.M) (string, func(t *testing.M)) {
 				AttributeName: aws.String("homeId"),
 				AttributeType: types.ScalarAttributeTypeS,
 			},
+			{
+				AttributeName: aws.String("createdAt"),
+				AttributeType: types.ScalarAttributeTypeN,
+			},
+			{
+				AttributeName: aws.String("status"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+			{
+				AttributeName: aws.String("lastSeenAt"),
+				AttributeType: types.ScalarAttributeTypeN,
+			},
 		},
 		KeySchema: []types.KeySchemaElement{
 			{
@@ -137,6 +177,46 @@ func Setup(t *testing.M) (string, func(t *testing.M)) {
 					WriteCapacityUnits: aws.Int64(100),
 				},
 			},
+			{
+				IndexName: aws.String("HomeIdIndex"),
+				KeySchema: []types.KeySchemaElement{
+					{
+						AttributeName: aws.String("homeId"),
+						KeyType:       types.KeyTypeHash,
+					},
+					{
+						AttributeName: aws.String("createdAt"),
+						KeyType:       types.KeyTypeRange,
+					},
+				},
+				Projection: &types.Projection{
+					ProjectionType: types.ProjectionTypeAll,
+				},
+				ProvisionedThroughput: &types.ProvisionedThroughput{
+					ReadCapacityUnits:  aws.Int64(100),
+					WriteCapacityUnits: aws.Int64(100),
+				},
+			},
+			{
+				IndexName: aws.String("StatusIndex"),
+				KeySchema: []types.KeySchemaElement{
+					{
+						AttributeName: aws.String("status"),
+						KeyType:       types.KeyTypeHash,
+					},
+					{
+						AttributeName: aws.String("lastSeenAt"),
+						KeyType:       types.KeyTypeRange,
+					},
+				},
+				Projection: &types.Projection{
+					ProjectionType: types.ProjectionTypeAll,
+				},
+				ProvisionedThroughput: &types.ProvisionedThroughput{
+					ReadCapacityUnits:  aws.Int64(100),
+					WriteCapacityUnits: aws.Int64(100),
+				},
+			},
 		},
 		ProvisionedThroughput: &types.ProvisionedThroughput{
 			ReadCapacityUnits:  aws.Int64(100),
@@ -148,8 +228,382 @@ func Setup(t *testing.M) (string, func(t *testing.M)) {
 		log.Fatalf("Failed to create table, %v", err)
 	}
 
+	_, err = svc.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName: aws.String("HomeDeviceHistory"),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{
+				AttributeName: aws.String("deviceId"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+			{
+				AttributeName: aws.String("changeId"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{
+				AttributeName: aws.String("deviceId"),
+				KeyType:       types.KeyTypeHash,
+			},
+			{
+				AttributeName: aws.String("changeId"),
+				KeyType:       types.KeyTypeRange,
+			},
+		},
+		ProvisionedThroughput: &types.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(100),
+			WriteCapacityUnits: aws.Int64(100),
+		},
+	})
+
+	if err != nil {
+		log.Fatalf("Failed to create history table, %v", err)
+	}
+
+	_, err = svc.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName: aws.String("HomeDeviceTelemetry"),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{
+				AttributeName: aws.String("deviceId"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+			{
+				AttributeName: aws.String("readingId"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{
+				AttributeName: aws.String("deviceId"),
+				KeyType:       types.KeyTypeHash,
+			},
+			{
+				AttributeName: aws.String("readingId"),
+				KeyType:       types.KeyTypeRange,
+			},
+		},
+		ProvisionedThroughput: &types.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(100),
+			WriteCapacityUnits: aws.Int64(100),
+		},
+	})
+
+	if err != nil {
+		log.Fatalf("Failed to create telemetry table, %v", err)
+	}
+
+	_, err = svc.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName: aws.String("HomeDeviceShadow"),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{
+				AttributeName: aws.String("deviceId"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{
+				AttributeName: aws.String("deviceId"),
+				KeyType:       types.KeyTypeHash,
+			},
+		},
+		ProvisionedThroughput: &types.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(100),
+			WriteCapacityUnits: aws.Int64(100),
+		},
+	})
+
+	if err != nil {
+		log.Fatalf("Failed to create shadow table, %v", err)
+	}
+
+	_, err = svc.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName: aws.String("Homes"),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{
+				AttributeName: aws.String("id"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{
+				AttributeName: aws.String("id"),
+				KeyType:       types.KeyTypeHash,
+			},
+		},
+		ProvisionedThroughput: &types.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(100),
+			WriteCapacityUnits: aws.Int64(100),
+		},
+	})
+
+	if err != nil {
+		log.Fatalf("Failed to create home table, %v", err)
+	}
+
+	_, err = svc.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName: aws.String("HomeRooms"),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{
+				AttributeName: aws.String("homeId"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+			{
+				AttributeName: aws.String("id"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{
+				AttributeName: aws.String("homeId"),
+				KeyType:       types.KeyTypeHash,
+			},
+			{
+				AttributeName: aws.String("id"),
+				KeyType:       types.KeyTypeRange,
+			},
+		},
+		ProvisionedThroughput: &types.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(100),
+			WriteCapacityUnits: aws.Int64(100),
+		},
+	})
+
+	if err != nil {
+		log.Fatalf("Failed to create room table, %v", err)
+	}
+
+	_, err = svc.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName: aws.String("HomeDeviceCommands"),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{
+				AttributeName: aws.String("deviceId"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+			{
+				AttributeName: aws.String("id"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{
+				AttributeName: aws.String("deviceId"),
+				KeyType:       types.KeyTypeHash,
+			},
+			{
+				AttributeName: aws.String("id"),
+				KeyType:       types.KeyTypeRange,
+			},
+		},
+		ProvisionedThroughput: &types.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(100),
+			WriteCapacityUnits: aws.Int64(100),
+		},
+	})
+
+	if err != nil {
+		log.Fatalf("Failed to create device command table, %v", err)
+	}
+
+	_, err = svc.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName: aws.String("HomeRules"),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{
+				AttributeName: aws.String("homeId"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+			{
+				AttributeName: aws.String("id"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+			{
+				AttributeName: aws.String("triggerDeviceId"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{
+				AttributeName: aws.String("homeId"),
+				KeyType:       types.KeyTypeHash,
+			},
+			{
+				AttributeName: aws.String("id"),
+				KeyType:       types.KeyTypeRange,
+			},
+		},
+		GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{
+			{
+				IndexName: aws.String("TriggerDeviceIdIndex"),
+				KeySchema: []types.KeySchemaElement{
+					{
+						AttributeName: aws.String("triggerDeviceId"),
+						KeyType:       types.KeyTypeHash,
+					},
+					{
+						AttributeName: aws.String("id"),
+						KeyType:       types.KeyTypeRange,
+					},
+				},
+				Projection: &types.Projection{
+					ProjectionType: types.ProjectionTypeAll,
+				},
+				ProvisionedThroughput: &types.ProvisionedThroughput{
+					ReadCapacityUnits:  aws.Int64(100),
+					WriteCapacityUnits: aws.Int64(100),
+				},
+			},
+		},
+		ProvisionedThroughput: &types.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(100),
+			WriteCapacityUnits: aws.Int64(100),
+		},
+	})
+
+	if err != nil {
+		log.Fatalf("Failed to create rule table, %v", err)
+	}
+
+	_, err = svc.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName: aws.String("HomeRuleExecutions"),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{
+				AttributeName: aws.String("ruleId"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+			{
+				AttributeName: aws.String("id"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{
+				AttributeName: aws.String("ruleId"),
+				KeyType:       types.KeyTypeHash,
+			},
+			{
+				AttributeName: aws.String("id"),
+				KeyType:       types.KeyTypeRange,
+			},
+		},
+		ProvisionedThroughput: &types.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(100),
+			WriteCapacityUnits: aws.Int64(100),
+		},
+	})
+
+	if err != nil {
+		log.Fatalf("Failed to create rule execution table, %v", err)
+	}
+
+	_, err = svc.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName: aws.String("HomeSchedules"),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{
+				AttributeName: aws.String("homeId"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+			{
+				AttributeName: aws.String("id"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+			{
+				AttributeName: aws.String("runState"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+			{
+				AttributeName: aws.String("nextRunAt"),
+				AttributeType: types.ScalarAttributeTypeN,
+			},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{
+				AttributeName: aws.String("homeId"),
+				KeyType:       types.KeyTypeHash,
+			},
+			{
+				AttributeName: aws.String("id"),
+				KeyType:       types.KeyTypeRange,
+			},
+		},
+		GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{
+			{
+				IndexName: aws.String("NextRunAtIndex"),
+				KeySchema: []types.KeySchemaElement{
+					{
+						AttributeName: aws.String("runState"),
+						KeyType:       types.KeyTypeHash,
+					},
+					{
+						AttributeName: aws.String("nextRunAt"),
+						KeyType:       types.KeyTypeRange,
+					},
+				},
+				Projection: &types.Projection{
+					ProjectionType: types.ProjectionTypeAll,
+				},
+				ProvisionedThroughput: &types.ProvisionedThroughput{
+					ReadCapacityUnits:  aws.Int64(100),
+					WriteCapacityUnits: aws.Int64(100),
+				},
+			},
+		},
+		ProvisionedThroughput: &types.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(100),
+			WriteCapacityUnits: aws.Int64(100),
+		},
+	})
+
+	if err != nil {
+		log.Fatalf("Failed to create schedule table, %v", err)
+	}
+
+	_, err = svc.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName: aws.String("HomeScenes"),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{
+				AttributeName: aws.String("homeId"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+			{
+				AttributeName: aws.String("id"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{
+				AttributeName: aws.String("homeId"),
+				KeyType:       types.KeyTypeHash,
+			},
+			{
+				AttributeName: aws.String("id"),
+				KeyType:       types.KeyTypeRange,
+			},
+		},
+		ProvisionedThroughput: &types.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(100),
+			WriteCapacityUnits: aws.Int64(100),
+		},
+	})
+
+	if err != nil {
+		log.Fatalf("Failed to create scene table, %v", err)
+	}
+
 	os.Setenv(hDConstants.TableNameHomeDevicesProperty, "HomeDevices")
 	os.Setenv(hDConstants.MacHomeIdIndexNameProperty, "MacHomeIdIndex")
+	os.Setenv(hDConstants.HomeIdIndexNameProperty, "HomeIdIndex")
+	os.Setenv(hDConstants.StatusIndexNameProperty, "StatusIndex")
+	os.Setenv(hDConstants.DeviceHistoryTableNameProperty, "HomeDeviceHistory")
+	os.Setenv(hDConstants.TelemetryTableNameProperty, "HomeDeviceTelemetry")
+	os.Setenv(hDConstants.DeviceShadowTableNameProperty, "HomeDeviceShadow")
+	os.Setenv(hDConstants.HomeTableNameProperty, "Homes")
+	os.Setenv(hDConstants.RoomTableNameProperty, "HomeRooms")
+	os.Setenv(hDConstants.DeviceCommandTableNameProperty, "HomeDeviceCommands")
+	os.Setenv(hDConstants.RuleTableNameProperty, "HomeRules")
+	os.Setenv(hDConstants.TriggerDeviceIdIndexProperty, "TriggerDeviceIdIndex")
+	os.Setenv(hDConstants.RuleExecutionTableNameProperty, "HomeRuleExecutions")
+	os.Setenv(hDConstants.ScheduleTableNameProperty, "HomeSchedules")
+	os.Setenv(hDConstants.NextRunAtIndexNameProperty, "NextRunAtIndex")
+	os.Setenv(hDConstants.SceneTableNameProperty, "HomeScenes")
 
 	fmt.Println("Setup finished...")
 
@@ -0,0 +1,108 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	hDConstants "github.com/odhoman/home-devices/internal/constants"
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+	hDUtils "github.com/odhoman/home-devices/internal/utils"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// DeviceIdAttribute is added to the commands, so the consumers can route them
+// to the device without reading the body.
+const DeviceIdAttribute = "deviceId"
+
+// CommandMessage is the body of the messages sent to the devices.
+type CommandMessage struct {
+	CommandID string                 `json:"commandId"`
+	DeviceID  string                 `json:"deviceId"`
+	Name      string                 `json:"name"`
+	Params    map[string]interface{} `json:"params"`
+	ExpiresAt int64                  `json:"expiresAt"`
+}
+
+// CommandQueue sends the commands to the devices.
+type CommandQueue interface {
+	Publish(ctx context.Context, command hDResponse.DeviceCommandResponse) error
+}
+
+type SQSCommandQueue struct {
+	SqsApi   sqsApi
+	QueueUrl string
+}
+
+func (sCQ SQSCommandQueue) Publish(ctx context.Context, command hDResponse.DeviceCommandResponse) error {
+
+	body, err := json.Marshal(newCommandMessage(command))
+	if err != nil {
+		return err
+	}
+
+	_, err = sCQ.SqsApi.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:    aws.String(sCQ.QueueUrl),
+		MessageBody: aws.String(string(body)),
+		MessageAttributes: map[string]types.MessageAttributeValue{
+			DeviceIdAttribute: stringAttribute(command.DeviceID),
+		},
+	})
+
+	return err
+}
+
+// NewSQSCommandQueueFromConfig sends the commands to the queue of the
+// COMMAND_QUEUE_URL environment variable.
+func NewSQSCommandQueueFromConfig(cfg aws.Config) (CommandQueue, error) {
+
+	queueUrl, err := hDUtils.GetValueProperty(hDConstants.CommandQueueUrlProperty)
+	if err != nil {
+		return nil, err
+	}
+
+	return SQSCommandQueue{SqsApi: sqs.NewFromConfig(cfg), QueueUrl: queueUrl}, nil
+}
+
+// InMemoryCommandQueue keeps the published commands in memory, for tests and
+// local runs.
+type InMemoryCommandQueue struct {
+	mutex    sync.RWMutex
+	messages []CommandMessage
+}
+
+func NewInMemoryCommandQueue() *InMemoryCommandQueue {
+	return &InMemoryCommandQueue{messages: []CommandMessage{}}
+}
+
+func (iMCQ *InMemoryCommandQueue) Publish(ctx context.Context, command hDResponse.DeviceCommandResponse) error {
+
+	iMCQ.mutex.Lock()
+	defer iMCQ.mutex.Unlock()
+
+	iMCQ.messages = append(iMCQ.messages, newCommandMessage(command))
+
+	return nil
+}
+
+// Messages returns the commands published so far, in order.
+func (iMCQ *InMemoryCommandQueue) Messages() []CommandMessage {
+
+	iMCQ.mutex.RLock()
+	defer iMCQ.mutex.RUnlock()
+
+	return append([]CommandMessage{}, iMCQ.messages...)
+}
+
+func newCommandMessage(command hDResponse.DeviceCommandResponse) CommandMessage {
+	return CommandMessage{
+		CommandID: command.ID,
+		DeviceID:  command.DeviceID,
+		Name:      command.Name,
+		Params:    command.Params,
+		ExpiresAt: command.ExpiresAt,
+	}
+}
@@ -0,0 +1,54 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	hDResponse "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQSCommandQueue_Publish(t *testing.T) {
+
+	api := &fakeSqsApi{}
+	commandQueue := SQSCommandQueue{SqsApi: api, QueueUrl: "https://sqs.us-east-1.amazonaws.com/123456789012/commands"}
+
+	err := commandQueue.Publish(context.TODO(), hDResponse.DeviceCommandResponse{
+		ID:        "command1",
+		DeviceID:  "device1",
+		Name:      "setBrightness",
+		Params:    map[string]interface{}{"brightness": 40.0},
+		Status:    "pending",
+		CreatedAt: 1729000000,
+		ExpiresAt: 1729000300,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://sqs.us-east-1.amazonaws.com/123456789012/commands", *api.input.QueueUrl)
+	assert.JSONEq(t, `{"commandId":"command1","deviceId":"device1","name":"setBrightness","params":{"brightness":40},"expiresAt":1729000300}`, *api.input.MessageBody)
+	assert.Equal(t, "device1", *api.input.MessageAttributes[DeviceIdAttribute].StringValue)
+}
+
+func TestSQSCommandQueue_PublishError(t *testing.T) {
+
+	commandQueue := SQSCommandQueue{SqsApi: &fakeSqsApi{err: errors.New("throttled")}, QueueUrl: "commands"}
+
+	err := commandQueue.Publish(context.TODO(), hDResponse.DeviceCommandResponse{ID: "command1", DeviceID: "device1", Name: "turnOn"})
+
+	assert.Error(t, err)
+}
+
+func TestInMemoryCommandQueue_Publish(t *testing.T) {
+
+	commandQueue := NewInMemoryCommandQueue()
+
+	assert.NoError(t, commandQueue.Publish(context.TODO(), hDResponse.DeviceCommandResponse{ID: "command1", DeviceID: "device1", Name: "turnOn"}))
+	assert.NoError(t, commandQueue.Publish(context.TODO(), hDResponse.DeviceCommandResponse{ID: "command2", DeviceID: "device1", Name: "turnOff"}))
+
+	messages := commandQueue.Messages()
+	assert.Len(t, messages, 2)
+	assert.Equal(t, "command1", messages[0].CommandID)
+	assert.Equal(t, "turnOff", messages[1].Name)
+}
@@ -0,0 +1,77 @@
+package queue
+
+import (
+	"context"
+
+	hDConstants "github.com/odhoman/home-devices/internal/constants"
+	hDUtils "github.com/odhoman/home-devices/internal/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// Message attributes added to the messages sent to the dead-letter queue.
+const (
+	ReasonAttribute          = "reason"
+	ErrorMessageAttribute    = "errorMessage"
+	SourceMessageIdAttribute = "sourceMessageId"
+)
+
+// DeadLetterQueue keeps the messages that can not be processed, whatever the
+// number of retries, with the reason why.
+type DeadLetterQueue interface {
+	Send(ctx context.Context, message events.SQSMessage, reason string, errorMessage string) error
+}
+
+type sqsApi interface {
+	SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+}
+
+type SQSDeadLetterQueue struct {
+	SqsApi   sqsApi
+	QueueUrl string
+}
+
+func (sDLQ SQSDeadLetterQueue) Send(ctx context.Context, message events.SQSMessage, reason string, errorMessage string) error {
+
+	messageAttributes := map[string]types.MessageAttributeValue{
+		ReasonAttribute: stringAttribute(reason),
+	}
+
+	if errorMessage != "" {
+		messageAttributes[ErrorMessageAttribute] = stringAttribute(errorMessage)
+	}
+
+	if message.MessageId != "" {
+		messageAttributes[SourceMessageIdAttribute] = stringAttribute(message.MessageId)
+	}
+
+	_, err := sDLQ.SqsApi.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:          aws.String(sDLQ.QueueUrl),
+		MessageBody:       aws.String(message.Body),
+		MessageAttributes: messageAttributes,
+	})
+
+	return err
+}
+
+func stringAttribute(value string) types.MessageAttributeValue {
+	return types.MessageAttributeValue{
+		DataType:    aws.String("String"),
+		StringValue: aws.String(value),
+	}
+}
+
+// NewSQSDeadLetterQueueFromConfig sends the messages to the queue of the
+// DEAD_LETTER_QUEUE_URL environment variable.
+func NewSQSDeadLetterQueueFromConfig(cfg aws.Config) (DeadLetterQueue, error) {
+
+	queueUrl, err := hDUtils.GetValueProperty(hDConstants.DeadLetterQueueUrlProperty)
+	if err != nil {
+		return nil, err
+	}
+
+	return SQSDeadLetterQueue{SqsApi: sqs.NewFromConfig(cfg), QueueUrl: queueUrl}, nil
+}
@@ -0,0 +1,45 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSqsApi struct {
+	input *sqs.SendMessageInput
+	err   error
+}
+
+func (f *fakeSqsApi) SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+	f.input = params
+	return &sqs.SendMessageOutput{}, f.err
+}
+
+func TestSQSDeadLetterQueue_Send(t *testing.T) {
+
+	api := &fakeSqsApi{}
+	deadLetterQueue := SQSDeadLetterQueue{SqsApi: api, QueueUrl: "https://sqs.us-east-1.amazonaws.com/123456789012/dlq"}
+
+	err := deadLetterQueue.Send(context.TODO(), events.SQSMessage{MessageId: "message1", Body: `{ invalid json }`}, "INVALID_MESSAGE", "unexpected character")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://sqs.us-east-1.amazonaws.com/123456789012/dlq", *api.input.QueueUrl)
+	assert.Equal(t, `{ invalid json }`, *api.input.MessageBody)
+	assert.Equal(t, "INVALID_MESSAGE", *api.input.MessageAttributes[ReasonAttribute].StringValue)
+	assert.Equal(t, "unexpected character", *api.input.MessageAttributes[ErrorMessageAttribute].StringValue)
+	assert.Equal(t, "message1", *api.input.MessageAttributes[SourceMessageIdAttribute].StringValue)
+}
+
+func TestSQSDeadLetterQueue_SendError(t *testing.T) {
+
+	deadLetterQueue := SQSDeadLetterQueue{SqsApi: &fakeSqsApi{err: errors.New("throttled")}, QueueUrl: "dlq"}
+
+	err := deadLetterQueue.Send(context.TODO(), events.SQSMessage{Body: "{}"}, "VALIDATION_ERROR", "")
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,10 @@
+package request
+
+// CreateHomeRequest registers a home.
+type CreateHomeRequest struct {
+	ID       string `json:"id" validate:"omitempty,min=5,max=30"`
+	Name     string `json:"name" validate:"required,min=3,max=50"`
+	Timezone string `json:"timezone" validate:"required,timezone"`
+	Address  string `json:"address" validate:"omitempty,max=200"`
+	Owner    string `json:"owner" validate:"required,min=3,max=100"`
+}
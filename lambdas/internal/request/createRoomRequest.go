@@ -0,0 +1,6 @@
+package request
+
+// CreateRoomRequest adds a room to a home. The id of the room is generated.
+type CreateRoomRequest struct {
+	Name string `json:"name" validate:"required,min=3,max=50"`
+}
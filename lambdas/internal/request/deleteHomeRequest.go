@@ -0,0 +1,8 @@
+package request
+
+// DeleteHomeRequest says what happens to the devices of a deleted home: cascade
+// deletes them and reassign moves them to TargetHomeID.
+type DeleteHomeRequest struct {
+	Strategy     string `json:"strategy" validate:"omitempty,oneof=cascade reassign"`
+	TargetHomeID string `json:"targetHomeId" validate:"required_if=Strategy reassign,omitempty,min=5,max=30"`
+}
@@ -0,0 +1,17 @@
+package request
+
+// DeviceCommandAck is sent by a device once it applied a command, or could not
+// apply it.
+type DeviceCommandAck struct {
+	CommandID string `json:"commandId" validate:"required,uuid"`
+	DeviceID  string `json:"deviceId" validate:"required"`
+	Status    string `json:"status" validate:"required,oneof=succeeded failed"`
+	Error     string `json:"error" validate:"omitempty,max=200"`
+}
+
+// DeviceCommandStatusUpdate completes a pending command.
+type DeviceCommandStatusUpdate struct {
+	Status      string
+	Error       string
+	CompletedAt int64
+}
@@ -0,0 +1,7 @@
+package request
+
+type DeviceHistoryRequest struct {
+	ID     string `json:"id"`
+	Limit  int32  `json:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor string `json:"cursor"`
+}
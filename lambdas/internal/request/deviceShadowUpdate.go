@@ -0,0 +1,10 @@
+package request
+
+// DeviceShadowUpdate replaces the documents of a device shadow that are not
+// nil.
+type DeviceShadowUpdate struct {
+	Desired         map[string]interface{}
+	DesiredVersion  int64
+	Reported        map[string]interface{}
+	ReportedVersion int64
+}
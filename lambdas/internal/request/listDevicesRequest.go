@@ -0,0 +1,9 @@
+package request
+
+type ListDevicesRequest struct {
+	HomeID string `json:"homeId" validate:"required,min=5,max=30"`
+	RoomID string `json:"roomId" validate:"omitempty,uuid"`
+	Status string `json:"status" validate:"omitempty,oneof=online offline"`
+	Limit  int32  `json:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor string `json:"cursor"`
+}
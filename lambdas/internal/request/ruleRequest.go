@@ -0,0 +1,34 @@
+package request
+
+// RuleRequest creates a rule of a home, or replaces all of it.
+type RuleRequest struct {
+	Name            string          `json:"name" validate:"required,min=3,max=50"`
+	Enabled         *bool           `json:"enabled"`
+	Trigger         RuleTrigger     `json:"trigger"`
+	TimeWindow      *RuleTimeWindow `json:"timeWindow"`
+	Action          RuleAction      `json:"action"`
+	CooldownSeconds int64           `json:"cooldownSeconds" validate:"omitempty,min=1,max=86400"`
+}
+
+// RuleTrigger compares the readings of a metric of a device with Value.
+type RuleTrigger struct {
+	DeviceID string   `json:"deviceId" validate:"required"`
+	Metric   string   `json:"metric" validate:"required,max=50"`
+	Operator string   `json:"operator" validate:"required,oneof=gt gte lt lte eq neq"`
+	Value    *float64 `json:"value" validate:"required"`
+}
+
+// RuleTimeWindow limits the rule to the readings between From and To, in HH:MM
+// of the timezone.
+type RuleTimeWindow struct {
+	From     string `json:"from" validate:"required,datetime=15:04"`
+	To       string `json:"to" validate:"required,datetime=15:04"`
+	Timezone string `json:"timezone" validate:"omitempty,timezone"`
+}
+
+// RuleAction is the command sent when the rule fires.
+type RuleAction struct {
+	DeviceID string                 `json:"deviceId" validate:"required"`
+	Command  string                 `json:"command" validate:"required,min=3,max=50"`
+	Params   map[string]interface{} `json:"params"`
+}
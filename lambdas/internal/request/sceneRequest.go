@@ -0,0 +1,14 @@
+package request
+
+// SceneRequest creates a scene of a home, or replaces all of it.
+type SceneRequest struct {
+	Name    string        `json:"name" validate:"required,min=3,max=50"`
+	Devices []SceneDevice `json:"devices" validate:"required,min=1,max=50,dive"`
+}
+
+// SceneDevice is the desired state of a device of the scene, e.g.
+// {"on": true, "brightness": 20}.
+type SceneDevice struct {
+	DeviceID string                 `json:"deviceId" validate:"required"`
+	State    map[string]interface{} `json:"state" validate:"required,min=1"`
+}
@@ -0,0 +1,21 @@
+package request
+
+// ScheduleRequest creates a schedule of a home, or replaces all of it.
+type ScheduleRequest struct {
+	Name     string                 `json:"name" validate:"required,min=3,max=50"`
+	Enabled  *bool                  `json:"enabled"`
+	Cron     string                 `json:"cron" validate:"required_without=RRule,excluded_with=RRule,max=100"`
+	RRule    string                 `json:"rrule" validate:"max=200"`
+	Timezone string                 `json:"timezone" validate:"omitempty,timezone"`
+	Target   ScheduleTarget         `json:"target"`
+	Command  string                 `json:"command" validate:"required,min=3,max=50"`
+	Params   map[string]interface{} `json:"params"`
+}
+
+// ScheduleTarget is a device of the home, or a group: the devices of the home
+// with the type, only the ones of the room when there is one.
+type ScheduleTarget struct {
+	DeviceID   string `json:"deviceId" validate:"required_without=DeviceType,excluded_with=DeviceType"`
+	DeviceType string `json:"deviceType" validate:"omitempty,deviceType"`
+	RoomID     string `json:"roomId" validate:"omitempty,uuid,excluded_with=DeviceID"`
+}
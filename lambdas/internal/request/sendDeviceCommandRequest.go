@@ -0,0 +1,8 @@
+package request
+
+// SendDeviceCommandRequest is a command for a device.
+type SendDeviceCommandRequest struct {
+	Name           string                 `json:"name" validate:"required,min=3,max=50"`
+	Params         map[string]interface{} `json:"params"`
+	TimeoutSeconds int64                  `json:"timeoutSeconds" validate:"omitempty,min=1,max=3600"`
+}
@@ -0,0 +1,11 @@
+package request
+
+// TelemetryReadingRequest is a reading sent by a device to the Kinesis stream.
+type TelemetryReadingRequest struct {
+	Type      string   `json:"type" validate:"omitempty,oneof=telemetry heartbeat"`
+	DeviceID  string   `json:"deviceId" validate:"required"`
+	Metric    string   `json:"metric" validate:"required_unless=Type heartbeat,max=50"`
+	Value     *float64 `json:"value" validate:"required_unless=Type heartbeat"`
+	Unit      string   `json:"unit" validate:"omitempty,max=20"`
+	Timestamp int64    `json:"timestamp" validate:"required,min=1"`
+}
@@ -0,0 +1,10 @@
+package request
+
+// UpdateDeviceStateRequest is a JSON merge patch of the desired and reported
+// documents of a device shadow.
+type UpdateDeviceStateRequest struct {
+	Desired         map[string]interface{} `json:"desired"`
+	Reported        map[string]interface{} `json:"reported"`
+	DesiredVersion  int64                  `json:"desiredVersion" validate:"omitempty,min=1"`
+	ReportedVersion int64                  `json:"reportedVersion" validate:"omitempty,min=1"`
+}
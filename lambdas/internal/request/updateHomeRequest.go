@@ -0,0 +1,8 @@
+package request
+
+type UpdateHomeRequest struct {
+	Name     string `json:"name" validate:"omitempty,min=3,max=50"`
+	Timezone string `json:"timezone" validate:"omitempty,timezone"`
+	Address  string `json:"address" validate:"omitempty,max=200"`
+	Owner    string `json:"owner" validate:"omitempty,min=3,max=100"`
+}
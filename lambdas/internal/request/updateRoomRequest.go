@@ -0,0 +1,5 @@
+package request
+
+type UpdateRoomRequest struct {
+	Name string `json:"name" validate:"required,min=3,max=50"`
+}
@@ -0,0 +1,25 @@
+package common
+
+// DeviceChangeResponse is an immutable record of a change made to a device.
+type DeviceChangeResponse struct {
+	DeviceID  string              `json:"deviceId"`
+	ChangeID  string              `json:"changeId"`
+	Operation string              `json:"operation"`
+	Actor     string              `json:"actor"`
+	Source    string              `json:"source"`
+	ChangedAt int64               `json:"changedAt"`
+	Version   int64               `json:"version,omitempty"`
+	Changes   []DeviceFieldChange `json:"changes"`
+}
+
+// DeviceFieldChange holds the value of a field before and after a change.
+type DeviceFieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+type DeviceHistoryResponse struct {
+	Changes    []DeviceChangeResponse `json:"changes"`
+	NextCursor string                 `json:"nextCursor,omitempty"`
+}
@@ -0,0 +1,14 @@
+package common
+
+// DeviceCommandResponse is a command sent to a device.
+type DeviceCommandResponse struct {
+	ID          string                 `json:"id"`
+	DeviceID    string                 `json:"deviceId"`
+	Name        string                 `json:"name"`
+	Params      map[string]interface{} `json:"params"`
+	Status      string                 `json:"status"`
+	Error       string                 `json:"error,omitempty"`
+	CreatedAt   int64                  `json:"createdAt"`
+	ExpiresAt   int64                  `json:"expiresAt"`
+	CompletedAt int64                  `json:"completedAt,omitempty"`
+}
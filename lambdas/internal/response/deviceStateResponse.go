@@ -0,0 +1,17 @@
+package common
+
+// DeviceStateDocumentResponse is one of the documents of a device shadow.
+type DeviceStateDocumentResponse struct {
+	State     map[string]interface{} `json:"state"`
+	Version   int64                  `json:"version"`
+	UpdatedAt int64                  `json:"updatedAt,omitempty"`
+}
+
+// DeviceStateResponse is the shadow of a device: the state the apps want it
+// to be in, the state it reported and the delta between them.
+type DeviceStateResponse struct {
+	DeviceID string                      `json:"deviceId"`
+	Desired  DeviceStateDocumentResponse `json:"desired"`
+	Reported DeviceStateDocumentResponse `json:"reported"`
+	Delta    map[string]interface{}      `json:"delta"`
+}
@@ -0,0 +1,7 @@
+package common
+
+import capability "github.com/odhoman/home-devices/internal/capability"
+
+type DeviceTypeListResponse struct {
+	DeviceTypes []capability.DeviceType `json:"deviceTypes"`
+}
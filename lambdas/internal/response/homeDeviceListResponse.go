@@ -0,0 +1,6 @@
+package common
+
+type HomeDeviceListResponse struct {
+	Devices    []HomdeDeviceResponse `json:"devices"`
+	NextCursor string                `json:"nextCursor,omitempty"`
+}
@@ -1,11 +1,16 @@
 package common
 
+// HomdeDeviceResponse is a device of a home.
 type HomdeDeviceResponse struct {
 	ID         string `json:"id"`
 	MAC        string `json:"mac"`
 	Name       string `json:"name"`
 	Type       string `json:"type"`
 	HomeID     string `json:"homeId"`
+	RoomID     string `json:"roomId,omitempty"`
 	CreatedAt  int64  `json:"createdAt"`
 	ModifiedAt int64  `json:"modifiedAt"`
+	Version    int64  `json:"version"`
+	Status     string `json:"status"`
+	LastSeenAt int64  `json:"lastSeenAt,omitempty"`
 }
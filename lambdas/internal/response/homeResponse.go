@@ -0,0 +1,12 @@
+package common
+
+type HomeResponse struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Timezone   string `json:"timezone"`
+	Address    string `json:"address,omitempty"`
+	Owner      string `json:"owner"`
+	CreatedAt  int64  `json:"createdAt"`
+	ModifiedAt int64  `json:"modifiedAt"`
+	Version    int64  `json:"version"`
+}
@@ -0,0 +1,209 @@
+package common
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	hdError "github.com/odhoman/home-devices/internal/error"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const (
+	ProblemJSONContentType = "application/problem+json"
+	JSONContentType        = "application/json"
+
+	// the type of a problem is the code of the error, e.g.
+	// urn:home-devices:problem:ERROR_DEVICE_NOT_FOUND
+	problemTypePrefix = "urn:home-devices:problem:"
+)
+
+// ProblemDetails is the body of the error responses, as defined by RFC 7807.
+type ProblemDetails struct {
+	Type          string            `json:"type"`
+	Title         string            `json:"title"`
+	Status        int               `json:"status"`
+	Detail        string            `json:"detail,omitempty"`
+	Instance      string            `json:"instance,omitempty"`
+	Code          string            `json:"code"`
+	InvalidParams []InvalidParam    `json:"invalidParams,omitempty"`
+	Details       map[string]string `json:"details,omitempty"`
+}
+
+// InvalidParam is a field of the request that is not valid, named by its path
+// in the JSON of the request, e.g. target.deviceId.
+type InvalidParam struct {
+	Name       string            `json:"name"`
+	Reason     string            `json:"reason"`
+	Rule       string            `json:"rule"`
+	Params     map[string]string `json:"params,omitempty"`
+	MessageKey string            `json:"messageKey"`
+}
+
+// NewProblemDetails returns the problem of the error, with the status and the
+// public message registered for its code.
+func NewProblemDetails(err error) ProblemDetails {
+
+	homeDeviceError := hdError.From(err)
+	definition := hdError.Lookup(homeDeviceError.ErrorCode)
+
+	problem := ProblemDetails{
+		Type:   problemTypePrefix + homeDeviceError.ErrorCode,
+		Title:  definition.PublicMessage,
+		Status: definition.Status,
+		Detail: definition.PublicMessageOf(homeDeviceError),
+		Code:   homeDeviceError.ErrorCode,
+	}
+
+	if problem.Title == "" {
+		problem.Title = definition.Message
+	}
+
+	if definition.Status < http.StatusInternalServerError {
+		problem.Details = homeDeviceError.Details
+	}
+
+	return problem
+}
+
+// NewValidationProblemDetails returns the problem of a request with fields
+// that are not valid.
+func NewValidationProblemDetails(invalidParams []InvalidParam) ProblemDetails {
+
+	reasons := make([]string, 0, len(invalidParams))
+	for _, invalidParam := range invalidParams {
+		reasons = append(reasons, invalidParam.Reason)
+	}
+
+	problem := NewProblemDetails(hdError.ErrValidation.WithMessage(strings.Join(reasons, "; ")))
+	problem.InvalidParams = invalidParams
+
+	return problem
+}
+
+func ReturnProblemAPIGatewayProxyResponse(problem ProblemDetails) events.APIGatewayProxyResponse {
+
+	jsonData, marshalError := json.Marshal(problem)
+
+	if marshalError != nil {
+		log.Printf("Error converting the problem to JSON. Problem: %v - Error: %v", problem, marshalError)
+		return ReturnDefaultInternalServerErrorResponse()
+	}
+
+	response := createDefaultAPIGatewayProxyResponse(problem.Status, jsonData)
+	response.Headers["Content-Type"] = ProblemJSONContentType
+
+	return response
+}
+
+// NegotiateErrorResponse returns a problem response in the format the client
+// accepts.
+func NegotiateErrorResponse(response events.APIGatewayProxyResponse, accept string, requestId string) events.APIGatewayProxyResponse {
+
+	if response.Headers["Content-Type"] != ProblemJSONContentType {
+		return response
+	}
+
+	var problem ProblemDetails
+	if err := json.Unmarshal([]byte(response.Body), &problem); err != nil {
+		log.Printf("Error reading the problem of the response. Body: %v - Error: %v", response.Body, err)
+		return response
+	}
+
+	negotiated := ReturnProblemAPIGatewayProxyResponse(withInstance(problem, requestId))
+	if !PrefersProblemJSON(accept) {
+		negotiated = ReturnErrorResponseAPIGatewayProxyResponse(legacyErrors(problem), response.StatusCode)
+	}
+
+	for name, value := range response.Headers {
+		if name != "Content-Type" {
+			negotiated.Headers[name] = value
+		}
+	}
+
+	return negotiated
+}
+
+func withInstance(problem ProblemDetails, requestId string) ProblemDetails {
+	problem.Instance = requestId
+	return problem
+}
+
+// legacyErrors returns the messages of the {"errors": [...]} body: one per
+// field that is not valid, or the detail of the problem.
+func legacyErrors(problem ProblemDetails) []string {
+
+	if len(problem.InvalidParams) == 0 {
+		return []string{problem.Detail}
+	}
+
+	errors := make([]string, 0, len(problem.InvalidParams))
+	for _, invalidParam := range problem.InvalidParams {
+		errors = append(errors, invalidParam.Reason)
+	}
+
+	return errors
+}
+
+// PrefersProblemJSON tells if the Accept header of a request prefers
+// application/problem+json to application/json.
+func PrefersProblemJSON(accept string) bool {
+
+	problemQuality, problemSpecificity := acceptQuality(accept, ProblemJSONContentType)
+	jsonQuality, jsonSpecificity := acceptQuality(accept, JSONContentType)
+
+	if problemSpecificity < 2 || problemQuality <= 0 {
+		return false
+	}
+
+	if problemQuality != jsonQuality {
+		return problemQuality > jsonQuality
+	}
+
+	return problemSpecificity > jsonSpecificity
+}
+
+// acceptQuality returns the quality the Accept header gives to the media type,
+// taken from its most specific range, and how specific that range is: 2 for the
+// media type itself, 1 for type/* and 0 for */*.
+func acceptQuality(accept string, mediaType string) (float64, int) {
+
+	if strings.TrimSpace(accept) == "" {
+		return 1, 0
+	}
+
+	quality, specificity := 0.0, -1
+	for _, mediaRange := range strings.Split(accept, ",") {
+
+		parameters := strings.Split(mediaRange, ";")
+		name := strings.ToLower(strings.TrimSpace(parameters[0]))
+
+		rangeSpecificity := -1
+		switch {
+		case name == mediaType:
+			rangeSpecificity = 2
+		case name == strings.SplitN(mediaType, "/", 2)[0]+"/*":
+			rangeSpecificity = 1
+		case name == "*/*":
+			rangeSpecificity = 0
+		}
+
+		if rangeSpecificity <= specificity {
+			continue
+		}
+
+		quality, specificity = 1, rangeSpecificity
+		for _, parameter := range parameters[1:] {
+			if value, found := strings.CutPrefix(strings.TrimSpace(parameter), "q="); found {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = parsed
+				}
+			}
+		}
+	}
+
+	return quality, specificity
+}
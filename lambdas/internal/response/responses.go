@@ -3,8 +3,10 @@ package common
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	constants "github.com/odhoman/home-devices/internal/constants"
+	hdError "github.com/odhoman/home-devices/internal/error"
 
 	"github.com/aws/aws-lambda-go/events"
 )
@@ -33,28 +35,24 @@ func ReturnAPIGatewayProxyResponse(code int, body interface{}) events.APIGateway
 
 }
 
-func ReturnInternalServerErrorAPIGatewayProxyResponse(errors []string) events.APIGatewayProxyResponse {
-	return ReturnErrorResponseAPIGatewayProxyResponse(errors, 500)
-}
-
-func ReturnNotFoundErrorAPIGatewayProxyResponse(errors []string) events.APIGatewayProxyResponse {
-	return ReturnErrorResponseAPIGatewayProxyResponse(errors, 404)
+func ReturnBadRequestErrorAPIGatewayProxyResponse(errors []string) events.APIGatewayProxyResponse {
+	return ReturnProblemAPIGatewayProxyResponse(NewProblemDetails(hdError.ErrInvalidRequest.WithMessage(strings.Join(errors, "; "))))
 }
 
-func ReturnBadRequestErrorAPIGatewayProxyResponse(errors []string) events.APIGatewayProxyResponse {
-	return ReturnErrorResponseAPIGatewayProxyResponse(errors, 400)
+// ReturnValidationErrorAPIGatewayProxyResponse returns the problem of a request
+// with fields that are not valid.
+func ReturnValidationErrorAPIGatewayProxyResponse(invalidParams []InvalidParam) events.APIGatewayProxyResponse {
+	return ReturnProblemAPIGatewayProxyResponse(NewValidationProblemDetails(invalidParams))
 }
 
 func BadRequestErrorAPIGatewayProxyResponseSingleMessage(message string) events.APIGatewayProxyResponse {
 	return ReturnBadRequestErrorAPIGatewayProxyResponse([]string{message})
 }
 
-func InternalServerErrorAPIGatewayProxyResponseSingleMessage(message string) events.APIGatewayProxyResponse {
-	return ReturnInternalServerErrorAPIGatewayProxyResponse([]string{message})
-}
-
-func ReturnNotFoundErrorAPIGatewayProxyResponseSingleMessage(message string) events.APIGatewayProxyResponse {
-	return ReturnNotFoundErrorAPIGatewayProxyResponse([]string{message})
+// ReturnHomeDeviceErrorAPIGatewayProxyResponse returns the problem of the error,
+// with the status and the public message registered for its code.
+func ReturnHomeDeviceErrorAPIGatewayProxyResponse(err error) events.APIGatewayProxyResponse {
+	return ReturnProblemAPIGatewayProxyResponse(NewProblemDetails(err))
 }
 
 func ReturnDefaultInternalServerErrorResponse() events.APIGatewayProxyResponse {
@@ -0,0 +1,5 @@
+package common
+
+type RoomListResponse struct {
+	Rooms []RoomResponse `json:"rooms"`
+}
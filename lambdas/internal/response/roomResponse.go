@@ -0,0 +1,10 @@
+package common
+
+type RoomResponse struct {
+	ID         string `json:"id"`
+	HomeID     string `json:"homeId"`
+	Name       string `json:"name"`
+	CreatedAt  int64  `json:"createdAt"`
+	ModifiedAt int64  `json:"modifiedAt"`
+	Version    int64  `json:"version"`
+}
@@ -0,0 +1,20 @@
+package common
+
+// RuleExecutionResponse is an entry of the execution log of a rule: the
+// reading that fired it and the command that was sent, or the error sending
+// it.
+type RuleExecutionResponse struct {
+	ID         string  `json:"id"`
+	RuleID     string  `json:"ruleId"`
+	DeviceID   string  `json:"deviceId"`
+	Metric     string  `json:"metric"`
+	Value      float64 `json:"value"`
+	Status     string  `json:"status"`
+	CommandID  string  `json:"commandId,omitempty"`
+	Error      string  `json:"error,omitempty"`
+	ExecutedAt int64   `json:"executedAt"`
+}
+
+type RuleExecutionListResponse struct {
+	Executions []RuleExecutionResponse `json:"executions"`
+}
@@ -0,0 +1,40 @@
+package common
+
+// RuleResponse is an automation of a home.
+type RuleResponse struct {
+	ID              string                  `json:"id"`
+	HomeID          string                  `json:"homeId"`
+	Name            string                  `json:"name"`
+	Enabled         bool                    `json:"enabled"`
+	Trigger         RuleTriggerResponse     `json:"trigger"`
+	TimeWindow      *RuleTimeWindowResponse `json:"timeWindow,omitempty"`
+	Action          RuleActionResponse      `json:"action"`
+	CooldownSeconds int64                   `json:"cooldownSeconds"`
+	LastFiredAt     int64                   `json:"lastFiredAt,omitempty"`
+	CreatedAt       int64                   `json:"createdAt"`
+	ModifiedAt      int64                   `json:"modifiedAt"`
+	Version         int64                   `json:"version"`
+}
+
+type RuleTriggerResponse struct {
+	DeviceID string  `json:"deviceId"`
+	Metric   string  `json:"metric"`
+	Operator string  `json:"operator"`
+	Value    float64 `json:"value"`
+}
+
+type RuleTimeWindowResponse struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Timezone string `json:"timezone"`
+}
+
+type RuleActionResponse struct {
+	DeviceID string                 `json:"deviceId"`
+	Command  string                 `json:"command"`
+	Params   map[string]interface{} `json:"params"`
+}
+
+type RuleListResponse struct {
+	Rules []RuleResponse `json:"rules"`
+}
@@ -0,0 +1,39 @@
+package common
+
+// SceneResponse is a named set of desired states of devices of a home.
+type SceneResponse struct {
+	ID         string                `json:"id"`
+	HomeID     string                `json:"homeId"`
+	Name       string                `json:"name"`
+	Devices    []SceneDeviceResponse `json:"devices"`
+	CreatedAt  int64                 `json:"createdAt"`
+	ModifiedAt int64                 `json:"modifiedAt"`
+	Version    int64                 `json:"version"`
+}
+
+type SceneDeviceResponse struct {
+	DeviceID string                 `json:"deviceId"`
+	State    map[string]interface{} `json:"state"`
+}
+
+type SceneListResponse struct {
+	Scenes []SceneResponse `json:"scenes"`
+}
+
+// SceneActivationResponse has the result of each device of the scene, in the
+// order of the scene.
+type SceneActivationResponse struct {
+	SceneID     string                      `json:"sceneId"`
+	HomeID      string                      `json:"homeId"`
+	ActivatedAt int64                       `json:"activatedAt"`
+	Results     []SceneDeviceResultResponse `json:"results"`
+}
+
+// SceneDeviceResultResponse is applied with the version of the desired state
+// that has the state of the scene, or failed with the error.
+type SceneDeviceResultResponse struct {
+	DeviceID       string `json:"deviceId"`
+	Status         string `json:"status"`
+	DesiredVersion int64  `json:"desiredVersion,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
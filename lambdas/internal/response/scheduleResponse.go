@@ -0,0 +1,40 @@
+package common
+
+// ScheduleResponse is a schedule of a home.
+type ScheduleResponse struct {
+	ID         string                 `json:"id"`
+	HomeID     string                 `json:"homeId"`
+	Name       string                 `json:"name"`
+	Enabled    bool                   `json:"enabled"`
+	Cron       string                 `json:"cron,omitempty"`
+	RRule      string                 `json:"rrule,omitempty"`
+	Timezone   string                 `json:"timezone"`
+	Target     ScheduleTargetResponse `json:"target"`
+	Command    string                 `json:"command"`
+	Params     map[string]interface{} `json:"params"`
+	NextRunAt  int64                  `json:"nextRunAt,omitempty"`
+	LastRunAt  int64                  `json:"lastRunAt,omitempty"`
+	CreatedAt  int64                  `json:"createdAt"`
+	ModifiedAt int64                  `json:"modifiedAt"`
+	Version    int64                  `json:"version"`
+}
+
+type ScheduleTargetResponse struct {
+	DeviceID   string `json:"deviceId,omitempty"`
+	DeviceType string `json:"deviceType,omitempty"`
+	RoomID     string `json:"roomId,omitempty"`
+}
+
+type ScheduleListResponse struct {
+	Schedules []ScheduleResponse `json:"schedules"`
+}
+
+// ScheduleRunResponse is a run of a schedule by the scheduler.
+type ScheduleRunResponse struct {
+	ScheduleID string   `json:"scheduleId"`
+	HomeID     string   `json:"homeId"`
+	RunAt      int64    `json:"runAt"`
+	Status     string   `json:"status"`
+	CommandIDs []string `json:"commandIds"`
+	Errors     []string `json:"errors,omitempty"`
+}
@@ -0,0 +1,157 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// cronField describes one of the five fields of a cron expression.
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	cronMinute     = cronField{name: "minute", min: 0, max: 59}
+	cronHour       = cronField{name: "hour", min: 0, max: 23}
+	cronDayOfMonth = cronField{name: "day of the month", min: 1, max: 31}
+	cronMonth      = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}}
+	// 7 is Sunday too
+	cronDayOfWeek = cronField{name: "day of the week", min: 0, max: 7, names: map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}}
+)
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a cron expression with the five standard fields: minute,
+// hour, day of the month, month and day of the week.
+func ParseCron(expression string) (Spec, error) {
+
+	expression = strings.TrimSpace(expression)
+	if macro, found := cronMacros[strings.ToLower(expression)]; found {
+		expression = macro
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return Spec{}, fmt.Errorf("the cron expression must have 5 fields: minute, hour, day of the month, month and day of the week")
+	}
+
+	minutes, err := parseCronField(fields[0], cronMinute)
+	if err != nil {
+		return Spec{}, err
+	}
+
+	hours, err := parseCronField(fields[1], cronHour)
+	if err != nil {
+		return Spec{}, err
+	}
+
+	daysOfMonth, err := parseCronField(fields[2], cronDayOfMonth)
+	if err != nil {
+		return Spec{}, err
+	}
+
+	months, err := parseCronField(fields[3], cronMonth)
+	if err != nil {
+		return Spec{}, err
+	}
+
+	daysOfWeek, err := parseCronField(fields[4], cronDayOfWeek)
+	if err != nil {
+		return Spec{}, err
+	}
+
+	if has(daysOfWeek, 7) {
+		daysOfWeek = daysOfWeek&^bits(7) | bits(0)
+	}
+
+	return Spec{
+		minutes:     minutes,
+		hours:       hours,
+		daysOfMonth: daysOfMonth,
+		months:      months,
+		daysOfWeek:  daysOfWeek,
+		daysOr:      !strings.HasPrefix(fields[2], "*") && !strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+func parseCronField(value string, field cronField) (uint64, error) {
+
+	var set uint64
+
+	for _, part := range strings.Split(value, ",") {
+
+		rangePart, step, hasStep := part, 1, false
+		if index := strings.Index(part, "/"); index >= 0 {
+			rangePart = part[:index]
+			parsedStep, err := strconv.Atoi(part[index+1:])
+			if err != nil || parsedStep < 1 {
+				return 0, fmt.Errorf("%v is not a valid step of the %v", part[index+1:], field.name)
+			}
+			step, hasStep = parsedStep, true
+		}
+
+		from, to := field.min, field.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if from, err = parseCronValue(bounds[0], field); err != nil {
+				return 0, err
+			}
+			if to, err = parseCronValue(bounds[1], field); err != nil {
+				return 0, err
+			}
+			if from > to {
+				return 0, fmt.Errorf("%v is not a valid range of the %v", rangePart, field.name)
+			}
+		default:
+			var err error
+			if from, err = parseCronValue(rangePart, field); err != nil {
+				return 0, err
+			}
+			// a single value with a step, e.g. 5/15, runs from the value
+			// to the end of the field
+			if !hasStep {
+				to = from
+			}
+		}
+
+		for v := from; v <= to; v += step {
+			set |= bits(v)
+		}
+	}
+
+	return set, nil
+}
+
+func parseCronValue(value string, field cronField) (int, error) {
+
+	if number, found := field.names[strings.ToUpper(value)]; found {
+		return number, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < field.min || number > field.max {
+		return 0, fmt.Errorf("%v is not a valid %v, it must be between %d and %d", value, field.name, field.min, field.max)
+	}
+
+	return number, nil
+}
@@ -0,0 +1,74 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCron_Next(t *testing.T) {
+
+	madrid, _ := time.LoadLocation("Europe/Madrid")
+	// a Wednesday
+	after := time.Date(2024, 10, 16, 10, 17, 30, 0, madrid)
+
+	tests := []struct {
+		expression string
+		expected   time.Time
+	}{
+		{"* * * * *", time.Date(2024, 10, 16, 10, 18, 0, 0, madrid)},
+		{"0 0 * * *", time.Date(2024, 10, 17, 0, 0, 0, 0, madrid)},
+		{"@midnight", time.Date(2024, 10, 17, 0, 0, 0, 0, madrid)},
+		{"*/15 * * * *", time.Date(2024, 10, 16, 10, 30, 0, 0, madrid)},
+		{"5/20 * * * *", time.Date(2024, 10, 16, 10, 25, 0, 0, madrid)},
+		// a step of 1 still runs from the value to the end of the field
+		{"5/1 * * * *", time.Date(2024, 10, 16, 10, 18, 0, 0, madrid)},
+		{"20/1 * * * *", time.Date(2024, 10, 16, 10, 20, 0, 0, madrid)},
+		{"30 7 * * MON-FRI", time.Date(2024, 10, 17, 7, 30, 0, 0, madrid)},
+		{"0 9 * * 0", time.Date(2024, 10, 20, 9, 0, 0, 0, madrid)},
+		{"0 9 * * 7", time.Date(2024, 10, 20, 9, 0, 0, 0, madrid)},
+		{"0 22 1,15 * *", time.Date(2024, 11, 1, 22, 0, 0, 0, madrid)},
+		{"0 0 1 jan *", time.Date(2025, 1, 1, 0, 0, 0, 0, madrid)},
+		{"0 12 29 2 *", time.Date(2028, 2, 29, 12, 0, 0, 0, madrid)},
+		// both day fields are restricted, so either of them matches
+		{"0 8 20 * FRI", time.Date(2024, 10, 18, 8, 0, 0, 0, madrid)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			spec, err := ParseCron(test.expression)
+			assert.NoError(t, err)
+
+			next, found := spec.Next(after)
+
+			assert.True(t, found)
+			assert.True(t, test.expected.Equal(next), "expected %v, got %v", test.expected, next)
+		})
+	}
+}
+
+func TestParseCron_Errors(t *testing.T) {
+
+	tests := []struct {
+		expression string
+		message    string
+	}{
+		{"0 0 * *", "the cron expression must have 5 fields: minute, hour, day of the month, month and day of the week"},
+		{"60 * * * *", "60 is not a valid minute, it must be between 0 and 59"},
+		{"0 24 * * *", "24 is not a valid hour, it must be between 0 and 23"},
+		{"0 0 0 * *", "0 is not a valid day of the month, it must be between 1 and 31"},
+		{"0 0 * FOO *", "FOO is not a valid month, it must be between 1 and 12"},
+		{"0 0 * * 8", "8 is not a valid day of the week, it must be between 0 and 7"},
+		{"*/0 * * * *", "0 is not a valid step of the minute"},
+		{"0 10-5 * * *", "10-5 is not a valid range of the hour"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			_, err := ParseCron(test.expression)
+
+			assert.EqualError(t, err, test.message)
+		})
+	}
+}
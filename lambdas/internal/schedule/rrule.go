@@ -0,0 +1,183 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var rruleWeekdays = map[string]int{
+	"SU": 0, "MO": 1, "TU": 2, "WE": 3, "TH": 4, "FR": 5, "SA": 6,
+}
+
+// ParseRRule parses a recurrence rule of RFC 5545, e.g.
+// FREQ=WEEKLY;BYDAY=MO,WE,FR;BYHOUR=7;BYMINUTE=30.
+func ParseRRule(rule string, start time.Time) (Spec, error) {
+
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+
+	parts := map[string]string{}
+	for _, part := range strings.Split(rule, ";") {
+		if part == "" {
+			continue
+		}
+
+		keyValue := strings.SplitN(part, "=", 2)
+		if len(keyValue) != 2 || keyValue[1] == "" {
+			return Spec{}, fmt.Errorf("%v is not a valid part of the RRULE", part)
+		}
+
+		key := strings.ToUpper(keyValue[0])
+		if _, found := parts[key]; found {
+			return Spec{}, fmt.Errorf("%v is repeated in the RRULE", key)
+		}
+		parts[key] = strings.ToUpper(keyValue[1])
+	}
+
+	spec := Spec{
+		interval: 1,
+		start:    start.Truncate(time.Minute),
+	}
+
+	frequency, found := parts["FREQ"]
+	if !found {
+		return Spec{}, fmt.Errorf("the RRULE must have a FREQ")
+	}
+
+	switch frequency {
+	case FrequencyMinutely, FrequencyHourly, FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly:
+		spec.frequency = frequency
+	default:
+		return Spec{}, fmt.Errorf("%v is not a supported FREQ, it must be one of MINUTELY, HOURLY, DAILY, WEEKLY, MONTHLY or YEARLY", frequency)
+	}
+
+	var err error
+	for key, value := range parts {
+		switch key {
+		case "FREQ":
+		case "INTERVAL":
+			spec.interval, err = strconv.Atoi(value)
+			if err != nil || spec.interval < 1 {
+				return Spec{}, fmt.Errorf("%v is not a valid INTERVAL, it must be a positive number", value)
+			}
+		case "UNTIL":
+			spec.until, err = parseRRuleUntil(value, start.Location())
+		case "BYMONTH":
+			spec.months, err = parseRRuleNumbers(key, value, 1, 12)
+		case "BYMONTHDAY":
+			spec.daysOfMonth, err = parseRRuleNumbers(key, value, 1, 31)
+		case "BYDAY":
+			spec.daysOfWeek, err = parseRRuleWeekdays(value)
+		case "BYHOUR":
+			spec.hours, err = parseRRuleNumbers(key, value, 0, 23)
+		case "BYMINUTE":
+			spec.minutes, err = parseRRuleNumbers(key, value, 0, 59)
+		default:
+			return Spec{}, fmt.Errorf("%v is not supported in the RRULE", key)
+		}
+
+		if err != nil {
+			return Spec{}, err
+		}
+	}
+
+	spec.fillFromStart()
+
+	return spec, nil
+}
+
+// fillFromStart takes the parts that the rule does not give from the start.
+func (s *Spec) fillFromStart() {
+
+	order := map[string]int{
+		FrequencyMinutely: 0,
+		FrequencyHourly:   1,
+		FrequencyDaily:    2,
+		FrequencyWeekly:   3,
+		FrequencyMonthly:  4,
+		FrequencyYearly:   5,
+	}[s.frequency]
+
+	if s.minutes == 0 {
+		s.minutes = fromStart(order > 0, s.start.Minute(), 0, 59)
+	}
+
+	if s.hours == 0 {
+		s.hours = fromStart(order > 1, s.start.Hour(), 0, 23)
+	}
+
+	if s.months == 0 {
+		s.months = fromStart(order > 4, int(s.start.Month()), 1, 12)
+	}
+
+	// a WEEKLY rule runs on the day of the week of the start and a MONTHLY
+	// or YEARLY one on its day of the month, unless the rule gives the days
+	if s.daysOfMonth == 0 && s.daysOfWeek == 0 {
+		s.daysOfWeek = fromStart(order == 3, int(s.start.Weekday()), 0, 6)
+		s.daysOfMonth = fromStart(order > 3, s.start.Day(), 1, 31)
+		return
+	}
+
+	if s.daysOfMonth == 0 {
+		s.daysOfMonth = bitRange(1, 31)
+	}
+
+	if s.daysOfWeek == 0 {
+		s.daysOfWeek = bitRange(0, 6)
+	}
+}
+
+func fromStart(useStart bool, value int, min int, max int) uint64 {
+
+	if useStart {
+		return bits(value)
+	}
+
+	return bitRange(min, max)
+}
+
+func parseRRuleNumbers(key string, value string, min int, max int) (uint64, error) {
+
+	var set uint64
+	for _, part := range strings.Split(value, ",") {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < min || number > max {
+			return 0, fmt.Errorf("%v is not a valid %v, it must be between %d and %d", part, key, min, max)
+		}
+		set |= bits(number)
+	}
+
+	return set, nil
+}
+
+func parseRRuleWeekdays(value string) (uint64, error) {
+
+	var set uint64
+	for _, part := range strings.Split(value, ",") {
+		weekday, found := rruleWeekdays[part]
+		if !found {
+			return 0, fmt.Errorf("%v is not a valid BYDAY, it must be one of MO, TU, WE, TH, FR, SA or SU", part)
+		}
+		set |= bits(weekday)
+	}
+
+	return set, nil
+}
+
+func parseRRuleUntil(value string, location *time.Location) (time.Time, error) {
+
+	if until, err := time.Parse("20060102T150405Z", value); err == nil {
+		return until, nil
+	}
+
+	if until, err := time.ParseInLocation("20060102T150405", value, location); err == nil {
+		return until, nil
+	}
+
+	if until, err := time.ParseInLocation("20060102", value, location); err == nil {
+		return until.AddDate(0, 0, 1).Add(-time.Second), nil
+	}
+
+	return time.Time{}, fmt.Errorf("%v is not a valid UNTIL, e.g. 20251231 or 20251231T235959Z", value)
+}
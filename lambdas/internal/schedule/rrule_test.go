@@ -0,0 +1,101 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRRule_Next(t *testing.T) {
+
+	madrid, _ := time.LoadLocation("Europe/Madrid")
+	// the schedule was created on a Monday
+	start := time.Date(2024, 10, 14, 8, 45, 0, 0, madrid)
+	after := time.Date(2024, 10, 16, 10, 17, 0, 0, madrid)
+
+	tests := []struct {
+		rule     string
+		expected time.Time
+	}{
+		{"FREQ=DAILY;BYHOUR=0;BYMINUTE=0", time.Date(2024, 10, 17, 0, 0, 0, 0, madrid)},
+		{"RRULE:FREQ=DAILY;BYHOUR=22;BYMINUTE=30", time.Date(2024, 10, 16, 22, 30, 0, 0, madrid)},
+		// the hour and the minute are the ones of the start
+		{"FREQ=DAILY", time.Date(2024, 10, 17, 8, 45, 0, 0, madrid)},
+		{"FREQ=DAILY;INTERVAL=3;BYHOUR=7;BYMINUTE=0", time.Date(2024, 10, 17, 7, 0, 0, 0, madrid)},
+		{"FREQ=WEEKLY;BYDAY=MO,WE,FR;BYHOUR=7;BYMINUTE=30", time.Date(2024, 10, 18, 7, 30, 0, 0, madrid)},
+		{"FREQ=WEEKLY", time.Date(2024, 10, 21, 8, 45, 0, 0, madrid)},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", time.Date(2024, 10, 29, 8, 45, 0, 0, madrid)},
+		{"FREQ=MONTHLY;BYMONTHDAY=1;BYHOUR=9", time.Date(2024, 11, 1, 9, 45, 0, 0, madrid)},
+		{"FREQ=MONTHLY", time.Date(2024, 11, 14, 8, 45, 0, 0, madrid)},
+		{"FREQ=YEARLY;BYMONTH=12;BYMONTHDAY=24;BYHOUR=20;BYMINUTE=0", time.Date(2024, 12, 24, 20, 0, 0, 0, madrid)},
+		{"FREQ=HOURLY;INTERVAL=4", time.Date(2024, 10, 16, 12, 45, 0, 0, madrid)},
+		{"FREQ=MINUTELY;INTERVAL=15", time.Date(2024, 10, 16, 10, 30, 0, 0, madrid)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.rule, func(t *testing.T) {
+			spec, err := ParseRRule(test.rule, start)
+			assert.NoError(t, err)
+
+			next, found := spec.Next(after)
+
+			assert.True(t, found)
+			assert.True(t, test.expected.Equal(next), "expected %v, got %v", test.expected, next)
+		})
+	}
+}
+
+func TestParseRRule_NoRunBeforeTheStart(t *testing.T) {
+
+	start := time.Date(2024, 10, 14, 8, 45, 0, 0, time.UTC)
+
+	spec, err := ParseRRule("FREQ=DAILY;BYHOUR=9;BYMINUTE=0", start)
+	assert.NoError(t, err)
+
+	next, found := spec.Next(time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC))
+
+	assert.True(t, found)
+	assert.Equal(t, time.Date(2024, 10, 14, 9, 0, 0, 0, time.UTC), next)
+}
+
+func TestParseRRule_Until(t *testing.T) {
+
+	start := time.Date(2024, 10, 14, 8, 45, 0, 0, time.UTC)
+
+	spec, err := ParseRRule("FREQ=DAILY;UNTIL=20241016", start)
+	assert.NoError(t, err)
+
+	next, found := spec.Next(time.Date(2024, 10, 15, 12, 0, 0, 0, time.UTC))
+	assert.True(t, found)
+	assert.Equal(t, time.Date(2024, 10, 16, 8, 45, 0, 0, time.UTC), next)
+
+	_, found = spec.Next(next)
+	assert.False(t, found)
+}
+
+func TestParseRRule_Errors(t *testing.T) {
+
+	tests := []struct {
+		rule    string
+		message string
+	}{
+		{"BYHOUR=7", "the RRULE must have a FREQ"},
+		{"FREQ=SECONDLY", "SECONDLY is not a supported FREQ, it must be one of MINUTELY, HOURLY, DAILY, WEEKLY, MONTHLY or YEARLY"},
+		{"FREQ=DAILY;INTERVAL=0", "0 is not a valid INTERVAL, it must be a positive number"},
+		{"FREQ=DAILY;BYHOUR=25", "25 is not a valid BYHOUR, it must be between 0 and 23"},
+		{"FREQ=MONTHLY;BYDAY=1MO", "1MO is not a valid BYDAY, it must be one of MO, TU, WE, TH, FR, SA or SU"},
+		{"FREQ=DAILY;COUNT=10", "COUNT is not supported in the RRULE"},
+		{"FREQ=DAILY;UNTIL=tomorrow", "TOMORROW is not a valid UNTIL, e.g. 20251231 or 20251231T235959Z"},
+		{"FREQ=DAILY;FREQ=WEEKLY", "FREQ is repeated in the RRULE"},
+		{"FREQ=DAILY;BYHOUR", "BYHOUR is not a valid part of the RRULE"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.rule, func(t *testing.T) {
+			_, err := ParseRRule(test.rule, time.Now())
+
+			assert.EqualError(t, err, test.message)
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package schedule
+
+import (
+	"time"
+)
+
+// maxSearchYears bounds the search of the next run, so a spec that never
+// matches, e.g. the 30th of February, does not loop forever.
+const maxSearchYears = 5
+
+// Frequencies of an RRULE. A cron expression has no frequency.
+const (
+	FrequencyMinutely = "MINUTELY"
+	FrequencyHourly   = "HOURLY"
+	FrequencyDaily    = "DAILY"
+	FrequencyWeekly   = "WEEKLY"
+	FrequencyMonthly  = "MONTHLY"
+	FrequencyYearly   = "YEARLY"
+)
+
+// Spec is a parsed cron expression or RRULE.
+type Spec struct {
+	minutes     uint64
+	hours       uint64
+	daysOfMonth uint64
+	months      uint64
+	daysOfWeek  uint64
+
+	// cron matches a day when either of the day fields matches it, if both
+	// are restricted
+	daysOr bool
+
+	// the RRULE runs every interval periods of its frequency, counted from
+	// start, and not after until
+	frequency string
+	interval  int
+	start     time.Time
+	until     time.Time
+}
+
+// Next returns the first minute strictly after the given time that the spec
+// matches, in the location of after.
+func (s Spec) Next(after time.Time) (time.Time, bool) {
+
+	location := after.Location()
+	limit := after.AddDate(maxSearchYears, 0, 0)
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	lastWallMinute := wallMinute(after)
+
+	for t.Before(limit) {
+
+		if !s.until.IsZero() && t.After(s.until) {
+			return time.Time{}, false
+		}
+
+		// the clocks went back, this minute of the day already happened
+		if wallMinute(t) <= lastWallMinute {
+			t = t.Add(time.Minute)
+			continue
+		}
+		lastWallMinute = wallMinute(t)
+
+		if !s.matchesDay(t) {
+			t = advance(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, location))
+			continue
+		}
+
+		// counting the minutes, unlike time.Date, does not skip the first
+		// of two hours with the same time when the clocks go back
+		if !s.matchesHour(t) {
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+			continue
+		}
+
+		if !s.matchesMinute(t) {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t, true
+	}
+
+	return time.Time{}, false
+}
+
+func (s Spec) matchesDay(t time.Time) bool {
+
+	if !has(s.months, int(t.Month())) {
+		return false
+	}
+
+	dayOfMonth := has(s.daysOfMonth, t.Day())
+	dayOfWeek := has(s.daysOfWeek, int(t.Weekday()))
+
+	if s.daysOr {
+		if !dayOfMonth && !dayOfWeek {
+			return false
+		}
+	} else if !dayOfMonth || !dayOfWeek {
+		return false
+	}
+
+	start := s.start.In(t.Location())
+
+	switch s.frequency {
+	case FrequencyDaily:
+		return s.inInterval(civilDay(t) - civilDay(start))
+	case FrequencyWeekly:
+		return s.inInterval(civilWeek(t) - civilWeek(start))
+	case FrequencyMonthly:
+		return s.inInterval((t.Year()*12 + int(t.Month())) - (start.Year()*12 + int(start.Month())))
+	case FrequencyYearly:
+		return s.inInterval(t.Year() - start.Year())
+	}
+
+	return true
+}
+
+func (s Spec) matchesHour(t time.Time) bool {
+
+	if !has(s.hours, t.Hour()) {
+		return false
+	}
+
+	if s.frequency == FrequencyHourly {
+		start := s.start.In(t.Location())
+		startHour := time.Date(start.Year(), start.Month(), start.Day(), start.Hour(), 0, 0, 0, t.Location())
+		return s.inInterval(int(t.Sub(startHour) / time.Hour))
+	}
+
+	return true
+}
+
+func (s Spec) matchesMinute(t time.Time) bool {
+
+	if !has(s.minutes, t.Minute()) {
+		return false
+	}
+
+	if s.frequency == FrequencyMinutely {
+		return s.inInterval(int(t.Sub(s.start.Truncate(time.Minute)) / time.Minute))
+	}
+
+	return true
+}
+
+// inInterval checks the number of periods since the start.
+func (s Spec) inInterval(periods int) bool {
+	return periods >= 0 && periods%s.interval == 0
+}
+
+// advance moves to the candidate, or to the next minute when the candidate is
+// not after t, which happens when the clocks go back.
+func advance(t time.Time, candidate time.Time) time.Time {
+
+	if candidate.After(t) {
+		return candidate
+	}
+
+	return t.Add(time.Minute)
+}
+
+// civilDay is the number of days since 1970-01-01 of the date of t in its
+// location.
+func civilDay(t time.Time) int {
+	return int(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
+
+// wallMinute is the number of minutes since 1970-01-01 of the time on the
+// clock of the location of t.
+func wallMinute(t time.Time) int {
+	return civilDay(t)*24*60 + t.Hour()*60 + t.Minute()
+}
+
+// civilWeek is the number of weeks, starting on Monday, since the one of
+// 1970-01-01, which was a Thursday.
+func civilWeek(t time.Time) int {
+	return floorDiv(civilDay(t)+3, 7)
+}
+
+func floorDiv(a int, b int) int {
+
+	if a < 0 {
+		return -((-a + b - 1) / b)
+	}
+
+	return a / b
+}
+
+func has(set uint64, value int) bool {
+	return set&(1<<uint(value)) != 0
+}
+
+func bits(values ...int) uint64 {
+
+	var set uint64
+	for _, value := range values {
+		set |= 1 << uint(value)
+	}
+
+	return set
+}
+
+func bitRange(min int, max int) uint64 {
+
+	var set uint64
+	for value := min; value <= max; value++ {
+		set |= 1 << uint(value)
+	}
+
+	return set
+}
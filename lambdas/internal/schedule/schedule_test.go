@@ -0,0 +1,60 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNext_IsInTheLocationOfAfter(t *testing.T) {
+
+	spec, err := ParseCron("0 0 * * *")
+	assert.NoError(t, err)
+
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	next, found := spec.Next(time.Date(2024, 10, 16, 12, 0, 0, 0, time.UTC).In(tokyo))
+
+	assert.True(t, found)
+	assert.Equal(t, time.Date(2024, 10, 17, 0, 0, 0, 0, tokyo), next)
+	assert.Equal(t, time.Date(2024, 10, 16, 15, 0, 0, 0, time.UTC), next.UTC())
+}
+
+func TestNext_SkipsTheMissingHourWhenTheClocksGoForward(t *testing.T) {
+
+	madrid, _ := time.LoadLocation("Europe/Madrid")
+	spec, err := ParseCron("30 2 * * *")
+	assert.NoError(t, err)
+
+	// 2:30 does not exist on the 31st of March of 2024 in Madrid
+	next, found := spec.Next(time.Date(2024, 3, 30, 12, 0, 0, 0, madrid))
+
+	assert.True(t, found)
+	assert.Equal(t, time.Date(2024, 4, 1, 2, 30, 0, 0, madrid), next)
+}
+
+func TestNext_RunsOnceWhenTheClocksGoBack(t *testing.T) {
+
+	madrid, _ := time.LoadLocation("Europe/Madrid")
+	spec, err := ParseCron("30 2 * * *")
+	assert.NoError(t, err)
+
+	// 2:30 happens twice on the 27th of October of 2024 in Madrid
+	first, found := spec.Next(time.Date(2024, 10, 27, 1, 0, 0, 0, madrid))
+	assert.True(t, found)
+	assert.Equal(t, time.Date(2024, 10, 27, 0, 30, 0, 0, time.UTC), first.UTC())
+
+	second, found := spec.Next(first)
+	assert.True(t, found)
+	assert.Equal(t, time.Date(2024, 10, 28, 2, 30, 0, 0, madrid), second)
+}
+
+func TestNext_NeverMatches(t *testing.T) {
+
+	spec, err := ParseCron("0 0 30 2 *")
+	assert.NoError(t, err)
+
+	_, found := spec.Next(time.Date(2024, 10, 16, 12, 0, 0, 0, time.UTC))
+
+	assert.False(t, found)
+}
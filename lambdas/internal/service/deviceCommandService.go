@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	capability "github.com/odhoman/home-devices/internal/capability"
+	constants "github.com/odhoman/home-devices/internal/constants"
+	dao "github.com/odhoman/home-devices/internal/dao"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	queue "github.com/odhoman/home-devices/internal/queue"
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/google/uuid"
+)
+
+type DeviceCommandService interface {
+	SendDeviceCommand(ctx context.Context, deviceId string, command request.SendDeviceCommandRequest) (*response.DeviceCommandResponse, error)
+	GetDeviceCommand(ctx context.Context, deviceId string, id string) (*response.DeviceCommandResponse, error)
+	AcknowledgeDeviceCommand(ctx context.Context, ack request.DeviceCommandAck) (*response.DeviceCommandResponse, error)
+}
+
+type DeviceCommandServiceImpl struct {
+	deviceCommandDao dao.DeviceCommandDao
+	deviceService    HomeDeviceService
+	commandQueue     queue.CommandQueue
+}
+
+// SendDeviceCommand checks the command against the type of the device, keeps it
+// as pending and sends it to the device.
+func (dCSI DeviceCommandServiceImpl) SendDeviceCommand(ctx context.Context, deviceId string, commandRequest request.SendDeviceCommandRequest) (*response.DeviceCommandResponse, error) {
+
+	if err := dCSI.checkDeviceCommandDao(hdError.ErrDeviceCommandNotCreated); err != nil {
+		return nil, err
+	}
+
+	if dCSI.commandQueue == nil {
+		log.Printf("The command queue is not configured")
+		return nil, hdError.ErrSendingDeviceCommand.New()
+	}
+
+	device, err := dCSI.deviceService.GetHomeDevice(ctx, deviceId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateDeviceCommand(device.Type, commandRequest); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	command, err := dCSI.deviceCommandDao.SaveDeviceCommand(ctx, response.DeviceCommandResponse{
+		ID:        uuid.New().String(),
+		DeviceID:  deviceId,
+		Name:      commandRequest.Name,
+		Params:    commandRequest.Params,
+		Status:    constants.CommandStatusPending,
+		CreatedAt: now,
+		ExpiresAt: now + resolveCommandTimeout(commandRequest.TimeoutSeconds),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := dCSI.commandQueue.Publish(ctx, *command); err != nil {
+		log.Printf("Error sending the command %v to the device %v: %v", command.ID, deviceId, err)
+
+		if _, err := dCSI.deviceCommandDao.CompleteDeviceCommand(ctx, deviceId, command.ID, request.DeviceCommandStatusUpdate{
+			Status:      constants.CommandStatusFailed,
+			Error:       hdError.ErrSendingDeviceCommand.Message,
+			CompletedAt: time.Now().Unix(),
+		}); err != nil {
+			log.Printf("Error keeping the command %v of the device %v as failed: %v", command.ID, deviceId, err)
+		}
+
+		return nil, hdError.ErrSendingDeviceCommand.New()
+	}
+
+	return command, nil
+}
+
+// GetDeviceCommand returns a command of a device.
+func (dCSI DeviceCommandServiceImpl) GetDeviceCommand(ctx context.Context, deviceId string, id string) (*response.DeviceCommandResponse, error) {
+
+	if err := dCSI.checkDeviceCommandDao(hdError.ErrGettingDeviceCommand); err != nil {
+		return nil, err
+	}
+
+	command, err := dCSI.deviceCommandDao.GetDeviceCommand(ctx, deviceId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if command.Status == constants.CommandStatusPending && time.Now().Unix() > command.ExpiresAt {
+		command.Status = constants.CommandStatusExpired
+	}
+
+	return command, nil
+}
+
+// AcknowledgeDeviceCommand completes a pending command with the status sent by
+// the device.
+func (dCSI DeviceCommandServiceImpl) AcknowledgeDeviceCommand(ctx context.Context, ack request.DeviceCommandAck) (*response.DeviceCommandResponse, error) {
+
+	if err := dCSI.checkDeviceCommandDao(hdError.ErrUpdatingDeviceCommand); err != nil {
+		return nil, err
+	}
+
+	command, err := dCSI.deviceCommandDao.GetDeviceCommand(ctx, ack.DeviceID, ack.CommandID)
+	if err != nil {
+		return nil, err
+	}
+
+	if command.Status != constants.CommandStatusPending {
+		return nil, hdError.ErrDeviceCommandCompleted.New()
+	}
+
+	update := request.DeviceCommandStatusUpdate{
+		Status:      ack.Status,
+		Error:       ack.Error,
+		CompletedAt: time.Now().Unix(),
+	}
+
+	if update.CompletedAt > command.ExpiresAt {
+		log.Printf("The command %v of the device %v was acknowledged after it expired", ack.CommandID, ack.DeviceID)
+		update.Status = constants.CommandStatusExpired
+		update.Error = fmt.Sprintf("acknowledged as %v after it expired", ack.Status)
+	}
+
+	return dCSI.deviceCommandDao.CompleteDeviceCommand(ctx, ack.DeviceID, ack.CommandID, update)
+}
+
+// validateDeviceCommand checks the command against the commands of the device
+// type.
+func validateDeviceCommand(deviceType string, commandRequest request.SendDeviceCommandRequest) error {
+
+	schema, found := capability.Get(deviceType)
+	if !found {
+		log.Printf("The device type %v has no commands", deviceType)
+		return hdError.ErrInvalidDeviceCommand.New()
+	}
+
+	errors := schema.ValidateCommand(commandRequest.Name, commandRequest.Params)
+	if len(errors) == 0 {
+		return nil
+	}
+
+	log.Printf("Invalid command for the %v device: %v", deviceType, errors)
+	return hdError.ErrInvalidDeviceCommand.WithMessage(strings.Join(errors, "; "))
+}
+
+func resolveCommandTimeout(timeoutSeconds int64) int64 {
+	if timeoutSeconds > 0 {
+		return timeoutSeconds
+	}
+	return constants.DefaultCommandTimeoutSeconds
+}
+
+func (dCSI DeviceCommandServiceImpl) checkDeviceCommandDao(errorDefinition hdError.Definition) error {
+
+	if dCSI.deviceCommandDao == nil {
+		log.Printf("The device commands are not configured")
+		return errorDefinition.New()
+	}
+
+	return nil
+}
+
+// NewDeviceCommandServiceImplFromConfig uses the DynamoDB daos.
+func NewDeviceCommandServiceImplFromConfig(cfg aws.Config, commandQueue queue.CommandQueue) DeviceCommandService {
+	client := dynamodb.NewFromConfig(cfg)
+	return NewDeviceCommandServiceImpl(dao.DeviceCommandDaoImpl{DynamoDbApi: client}, newHomeDeviceServiceFromConfig(cfg, client), commandQueue)
+}
+
+// NewDeviceCommandServiceImpl reads the devices, and their types, with the
+// deviceService and sends the commands through the commandQueue.
+func NewDeviceCommandServiceImpl(deviceCommandDao dao.DeviceCommandDao, deviceService HomeDeviceService, commandQueue queue.CommandQueue) DeviceCommandService {
+	return DeviceCommandServiceImpl{deviceCommandDao: deviceCommandDao, deviceService: deviceService, commandQueue: commandQueue}
+}
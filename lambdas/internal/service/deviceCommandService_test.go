@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/odhoman/home-devices/internal/constants"
+	"github.com/odhoman/home-devices/internal/dao"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	hdMock "github.com/odhoman/home-devices/internal/mock"
+	"github.com/odhoman/home-devices/internal/queue"
+	"github.com/odhoman/home-devices/internal/request"
+	hdREsponse "github.com/odhoman/home-devices/internal/response"
+	"github.com/stretchr/testify/assert"
+	mock "github.com/stretchr/testify/mock"
+)
+
+func TestSendDeviceCommand_Success(t *testing.T) {
+	service, commandQueue, device := newCommandServiceForTesting(t, "light")
+	ctx := context.Background()
+
+	command, err := service.SendDeviceCommand(ctx, device.ID, request.SendDeviceCommandRequest{
+		Name:   "setBrightness",
+		Params: map[string]interface{}{"brightness": 40.0},
+	})
+
+	assert.Nil(t, err)
+	assert.NotEmpty(t, command.ID)
+	assert.Equal(t, constants.CommandStatusPending, command.Status)
+	assert.Equal(t, command.CreatedAt+constants.DefaultCommandTimeoutSeconds, command.ExpiresAt)
+
+	messages := commandQueue.Messages()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, command.ID, messages[0].CommandID)
+	assert.Equal(t, device.ID, messages[0].DeviceID)
+	assert.Equal(t, map[string]interface{}{"brightness": 40.0}, messages[0].Params)
+
+	found, err := service.GetDeviceCommand(ctx, device.ID, command.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, command, found)
+}
+
+func TestSendDeviceCommand_Timeout(t *testing.T) {
+	service, _, device := newCommandServiceForTesting(t, "lock")
+
+	command, err := service.SendDeviceCommand(context.Background(), device.ID, request.SendDeviceCommandRequest{Name: "unlock", TimeoutSeconds: 30})
+
+	assert.Nil(t, err)
+	assert.Equal(t, command.CreatedAt+30, command.ExpiresAt)
+}
+
+func TestSendDeviceCommand_InvalidForTheDeviceType(t *testing.T) {
+	service, commandQueue, device := newCommandServiceForTesting(t, "light")
+
+	_, err := service.SendDeviceCommand(context.Background(), device.ID, request.SendDeviceCommandRequest{
+		Name:   "setBrightness",
+		Params: map[string]interface{}{"brightness": 140.0},
+	})
+
+	assert.ErrorIs(t, err, hdError.ErrInvalidDeviceCommand)
+	assert.EqualError(t, err, "brightness must be between 0 and 100")
+	assert.Empty(t, commandQueue.Messages())
+}
+
+func TestSendDeviceCommand_DeviceTypeWithoutCommands(t *testing.T) {
+	service, commandQueue, device := newCommandServiceForTesting(t, "sensor")
+
+	_, err := service.SendDeviceCommand(context.Background(), device.ID, request.SendDeviceCommandRequest{Name: "turnOn"})
+
+	assert.ErrorIs(t, err, hdError.ErrInvalidDeviceCommand)
+	assert.EqualError(t, err, "turnOn is not a command of the sensor devices")
+	assert.Empty(t, commandQueue.Messages())
+}
+
+func TestSendDeviceCommand_UnknownDeviceType(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	service := DeviceCommandServiceImpl{deviceService: HomeDeviceServiceImpl{homeDeviceDao: mockDao}, deviceCommandDao: dao.NewInMemoryDeviceCommandDao(), commandQueue: queue.NewInMemoryCommandQueue()}
+
+	ctx := context.Background()
+	mockDao.On("GetHomeDevice", ctx, "id").Return(&hdREsponse.HomdeDeviceResponse{ID: "id", Type: "alarm"}, nil)
+
+	_, err := service.SendDeviceCommand(ctx, "id", request.SendDeviceCommandRequest{Name: "turnOn"})
+
+	assert.ErrorIs(t, err, hdError.ErrInvalidDeviceCommand)
+}
+
+func TestSendDeviceCommand_DeviceNotFound(t *testing.T) {
+	service, _, _ := newCommandServiceForTesting(t, "light")
+
+	_, err := service.SendDeviceCommand(context.Background(), "00000000-0000-0000-0000-000000000000", request.SendDeviceCommandRequest{Name: "turnOn"})
+
+	assert.ErrorIs(t, err, hdError.ErrDeviceNotFound)
+}
+
+func TestSendDeviceCommand_PublishErrorKeepsTheCommandAsFailed(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	mockQueue := new(hdMock.MockCommandQueue)
+	commandDao := dao.NewInMemoryDeviceCommandDao()
+	service := DeviceCommandServiceImpl{deviceService: HomeDeviceServiceImpl{homeDeviceDao: mockDao}, deviceCommandDao: commandDao, commandQueue: mockQueue}
+
+	ctx := context.Background()
+	mockDao.On("GetHomeDevice", ctx, "id").Return(&hdREsponse.HomdeDeviceResponse{ID: "id", Type: "plug"}, nil)
+
+	var published hdREsponse.DeviceCommandResponse
+	mockQueue.On("Publish", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		published = args.Get(1).(hdREsponse.DeviceCommandResponse)
+	}).Return(errors.New("throttled"))
+
+	_, err := service.SendDeviceCommand(ctx, "id", request.SendDeviceCommandRequest{Name: "turnOff"})
+	assert.ErrorIs(t, err, hdError.ErrSendingDeviceCommand)
+
+	command, err := service.GetDeviceCommand(ctx, "id", published.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, constants.CommandStatusFailed, command.Status)
+	assert.Equal(t, hdError.ErrSendingDeviceCommand.Message, command.Error)
+}
+
+func TestSendDeviceCommand_NotConfigured(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+
+	_, err := DeviceCommandServiceImpl{deviceService: HomeDeviceServiceImpl{homeDeviceDao: mockDao}}.SendDeviceCommand(context.Background(), "id", request.SendDeviceCommandRequest{Name: "turnOn"})
+	assert.ErrorIs(t, err, hdError.ErrDeviceCommandNotCreated)
+
+	_, err = DeviceCommandServiceImpl{deviceService: HomeDeviceServiceImpl{homeDeviceDao: mockDao}, deviceCommandDao: dao.NewInMemoryDeviceCommandDao()}.SendDeviceCommand(context.Background(), "id", request.SendDeviceCommandRequest{Name: "turnOn"})
+	assert.ErrorIs(t, err, hdError.ErrSendingDeviceCommand)
+
+	mockDao.AssertNotCalled(t, "GetHomeDevice", mock.Anything, mock.Anything)
+}
+
+func TestGetDeviceCommand_Expired(t *testing.T) {
+	commandDao := dao.NewInMemoryDeviceCommandDao()
+	service := DeviceCommandServiceImpl{deviceService: HomeDeviceServiceImpl{homeDeviceDao: new(hdMock.MockHomeDeviceDao)}, deviceCommandDao: commandDao}
+	ctx := context.Background()
+
+	saveCommandForTesting(t, commandDao, "device1", "command1", time.Now().Unix()-10)
+
+	command, err := service.GetDeviceCommand(ctx, "device1", "command1")
+
+	assert.Nil(t, err)
+	assert.Equal(t, constants.CommandStatusExpired, command.Status)
+}
+
+func TestGetDeviceCommand_NotFound(t *testing.T) {
+	service := DeviceCommandServiceImpl{deviceService: HomeDeviceServiceImpl{homeDeviceDao: new(hdMock.MockHomeDeviceDao)}, deviceCommandDao: dao.NewInMemoryDeviceCommandDao()}
+
+	_, err := service.GetDeviceCommand(context.Background(), "device1", "command1")
+
+	assert.ErrorIs(t, err, hdError.ErrDeviceCommandNotFound)
+}
+
+func TestAcknowledgeDeviceCommand(t *testing.T) {
+
+	tests := []struct {
+		name           string
+		ack            request.DeviceCommandAck
+		expectedStatus string
+		expectedError  string
+	}{
+		{"succeeded", request.DeviceCommandAck{Status: constants.CommandStatusSucceeded}, constants.CommandStatusSucceeded, ""},
+		{"failed", request.DeviceCommandAck{Status: constants.CommandStatusFailed, Error: "jammed"}, constants.CommandStatusFailed, "jammed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commandDao := dao.NewInMemoryDeviceCommandDao()
+			service := DeviceCommandServiceImpl{deviceService: HomeDeviceServiceImpl{homeDeviceDao: new(hdMock.MockHomeDeviceDao)}, deviceCommandDao: commandDao}
+			saveCommandForTesting(t, commandDao, "device1", "command1", time.Now().Unix()+60)
+
+			tt.ack.DeviceID = "device1"
+			tt.ack.CommandID = "command1"
+			command, err := service.AcknowledgeDeviceCommand(context.Background(), tt.ack)
+
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expectedStatus, command.Status)
+			assert.Equal(t, tt.expectedError, command.Error)
+			assert.NotZero(t, command.CompletedAt)
+		})
+	}
+}
+
+func TestAcknowledgeDeviceCommand_AfterItExpired(t *testing.T) {
+	commandDao := dao.NewInMemoryDeviceCommandDao()
+	service := DeviceCommandServiceImpl{deviceService: HomeDeviceServiceImpl{homeDeviceDao: new(hdMock.MockHomeDeviceDao)}, deviceCommandDao: commandDao}
+	saveCommandForTesting(t, commandDao, "device1", "command1", time.Now().Unix()-10)
+
+	command, err := service.AcknowledgeDeviceCommand(context.Background(), request.DeviceCommandAck{DeviceID: "device1", CommandID: "command1", Status: constants.CommandStatusSucceeded})
+
+	assert.Nil(t, err)
+	assert.Equal(t, constants.CommandStatusExpired, command.Status)
+	assert.Equal(t, "acknowledged as succeeded after it expired", command.Error)
+}
+
+func TestAcknowledgeDeviceCommand_Twice(t *testing.T) {
+	commandDao := dao.NewInMemoryDeviceCommandDao()
+	service := DeviceCommandServiceImpl{deviceService: HomeDeviceServiceImpl{homeDeviceDao: new(hdMock.MockHomeDeviceDao)}, deviceCommandDao: commandDao}
+	saveCommandForTesting(t, commandDao, "device1", "command1", time.Now().Unix()+60)
+
+	ack := request.DeviceCommandAck{DeviceID: "device1", CommandID: "command1", Status: constants.CommandStatusSucceeded}
+
+	_, err := service.AcknowledgeDeviceCommand(context.Background(), ack)
+	assert.Nil(t, err)
+
+	_, err = service.AcknowledgeDeviceCommand(context.Background(), ack)
+	assert.ErrorIs(t, err, hdError.ErrDeviceCommandCompleted)
+}
+
+func TestAcknowledgeDeviceCommand_NotFound(t *testing.T) {
+	service := DeviceCommandServiceImpl{deviceService: HomeDeviceServiceImpl{homeDeviceDao: new(hdMock.MockHomeDeviceDao)}, deviceCommandDao: dao.NewInMemoryDeviceCommandDao()}
+
+	_, err := service.AcknowledgeDeviceCommand(context.Background(), request.DeviceCommandAck{DeviceID: "device1", CommandID: "command1", Status: constants.CommandStatusSucceeded})
+
+	assert.ErrorIs(t, err, hdError.ErrDeviceCommandNotFound)
+}
+
+// newCommandServiceForTesting returns a service with in-memory daos and queue,
+// and a device of the given type.
+func newCommandServiceForTesting(t *testing.T, deviceType string) (DeviceCommandService, *queue.InMemoryCommandQueue, *hdREsponse.HomdeDeviceResponse) {
+	t.Helper()
+
+	commandQueue := queue.NewInMemoryCommandQueue()
+	deviceService := NewHomeDeviceServiceImpl2(dao.NewInMemoryHomeDeviceDao())
+	service := NewDeviceCommandServiceImpl(dao.NewInMemoryDeviceCommandDao(), deviceService, commandQueue)
+
+	device, err := deviceService.CreateHomeDevice(context.Background(), request.CreateDeviceRequest{
+		MAC:    "00:11:22:33:44:55",
+		Name:   "Living Room Device",
+		Type:   deviceType,
+		HomeID: "home1",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error creating the device for testing. Error: %v", err)
+	}
+
+	return service, commandQueue, device
+}
+
+func saveCommandForTesting(t *testing.T, commandDao dao.DeviceCommandDao, deviceId string, id string, expiresAt int64) {
+	t.Helper()
+
+	if _, err := commandDao.SaveDeviceCommand(context.Background(), hdREsponse.DeviceCommandResponse{
+		ID:        id,
+		DeviceID:  deviceId,
+		Name:      "turnOn",
+		Status:    constants.CommandStatusPending,
+		CreatedAt: expiresAt - constants.DefaultCommandTimeoutSeconds,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		t.Fatalf("Unexpected error saving the command for testing. Error: %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	capability "github.com/odhoman/home-devices/internal/capability"
+	dao "github.com/odhoman/home-devices/internal/dao"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+	shadow "github.com/odhoman/home-devices/internal/shadow"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+type DeviceStateService interface {
+	GetDeviceState(ctx context.Context, id string) (*response.DeviceStateResponse, error)
+	UpdateDeviceState(ctx context.Context, id string, state request.UpdateDeviceStateRequest) (*response.DeviceStateResponse, error)
+}
+
+type DeviceStateServiceImpl struct {
+	deviceShadowDao dao.DeviceShadowDao
+	deviceService   HomeDeviceService
+}
+
+// GetDeviceState returns the shadow of a device with the delta between the
+// desired and the reported state.
+func (dSSI DeviceStateServiceImpl) GetDeviceState(ctx context.Context, id string) (*response.DeviceStateResponse, error) {
+
+	if err := dSSI.checkDeviceShadowDao(hdError.ErrGettingDeviceState); err != nil {
+		return nil, err
+	}
+
+	if _, err := dSSI.deviceService.GetHomeDevice(ctx, id); err != nil {
+		return nil, err
+	}
+
+	deviceShadow, err := dSSI.deviceShadowDao.GetDeviceShadow(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return withDelta(deviceShadow), nil
+}
+
+// UpdateDeviceState merges the patches into the desired and reported state of a
+// device.
+func (dSSI DeviceStateServiceImpl) UpdateDeviceState(ctx context.Context, id string, state request.UpdateDeviceStateRequest) (*response.DeviceStateResponse, error) {
+
+	if state.Desired == nil && state.Reported == nil {
+		return nil, hdError.ErrNoStateToUpdate.New()
+	}
+
+	if err := dSSI.checkDeviceShadowDao(hdError.ErrUpdatingDeviceState); err != nil {
+		return nil, err
+	}
+
+	device, err := dSSI.deviceService.GetHomeDevice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateDeviceState(device.Type, state); err != nil {
+		return nil, err
+	}
+
+	versionChecked := (state.Desired != nil && state.DesiredVersion > 0) || (state.Reported != nil && state.ReportedVersion > 0)
+
+	for attempt := 1; ; attempt++ {
+
+		current, err := dSSI.deviceShadowDao.GetDeviceShadow(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		update := request.DeviceShadowUpdate{}
+		if state.Desired != nil {
+			update.Desired = shadow.Merge(current.Desired.State, state.Desired)
+			update.DesiredVersion = resolveVersion(state.DesiredVersion, current.Desired.Version)
+		}
+		if state.Reported != nil {
+			update.Reported = shadow.Merge(current.Reported.State, state.Reported)
+			update.ReportedVersion = resolveVersion(state.ReportedVersion, current.Reported.Version)
+		}
+
+		updated, err := dSSI.deviceShadowDao.UpdateDeviceShadow(ctx, id, update)
+		if err == nil {
+			return withDelta(updated), nil
+		}
+
+		if !errors.Is(err, hdError.ErrVersionConflict) || versionChecked || attempt == maxAuditedWriteAttempts {
+			return nil, err
+		}
+
+		log.Printf("The state of the device %v changed while it was written, trying again", id)
+	}
+}
+
+func (dSSI DeviceStateServiceImpl) checkDeviceShadowDao(errorDefinition hdError.Definition) error {
+
+	if dSSI.deviceShadowDao == nil {
+		log.Printf("The device state is not configured")
+		return errorDefinition.New()
+	}
+
+	return nil
+}
+
+// validateDeviceState checks the patches against the schema of the device type.
+func validateDeviceState(deviceType string, state request.UpdateDeviceStateRequest) error {
+
+	schema, found := capability.Get(deviceType)
+	if !found {
+		return nil
+	}
+
+	errors := append(schema.ValidateState(state.Desired, true), schema.ValidateState(state.Reported, false)...)
+	if len(errors) == 0 {
+		return nil
+	}
+
+	log.Printf("Invalid state for the %v device: %v", deviceType, errors)
+	return hdError.ErrInvalidDeviceState.WithMessage(strings.Join(errors, "; "))
+}
+
+func withDelta(deviceShadow *response.DeviceStateResponse) *response.DeviceStateResponse {
+	deviceShadow.Delta = shadow.Delta(deviceShadow.Desired.State, deviceShadow.Reported.State)
+	return deviceShadow
+}
+
+func resolveVersion(expectedVersion int64, currentVersion int64) int64 {
+	if expectedVersion > 0 {
+		return expectedVersion
+	}
+	return currentVersion
+}
+
+// NewDeviceStateServiceImplFromConfig uses the DynamoDB daos.
+func NewDeviceStateServiceImplFromConfig(cfg aws.Config) DeviceStateService {
+	client := dynamodb.NewFromConfig(cfg)
+	return NewDeviceStateServiceImpl(dao.DeviceShadowDaoImpl{DynamoDbApi: client}, newHomeDeviceServiceFromConfig(cfg, client))
+}
+
+// NewDeviceStateServiceImpl reads the devices, and their types, with the
+// deviceService.
+func NewDeviceStateServiceImpl(deviceShadowDao dao.DeviceShadowDao, deviceService HomeDeviceService) DeviceStateService {
+	return DeviceStateServiceImpl{deviceShadowDao: deviceShadowDao, deviceService: deviceService}
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	hdError "github.com/odhoman/home-devices/internal/error"
+	hdMock "github.com/odhoman/home-devices/internal/mock"
+	"github.com/odhoman/home-devices/internal/request"
+	hdREsponse "github.com/odhoman/home-devices/internal/response"
+	"github.com/stretchr/testify/assert"
+	mock "github.com/stretchr/testify/mock"
+)
+
+func TestGetDeviceState_Success(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	mockShadowDao := new(hdMock.MockDeviceShadowDao)
+	service := DeviceStateServiceImpl{deviceService: HomeDeviceServiceImpl{homeDeviceDao: mockDao}, deviceShadowDao: mockShadowDao}
+
+	ctx := context.Background()
+
+	mockDao.On("GetHomeDevice", ctx, "id").Return(&hdREsponse.HomdeDeviceResponse{ID: "id"}, nil)
+	mockShadowDao.On("GetDeviceShadow", ctx, "id").Return(newDeviceState("id", map[string]interface{}{"power": "on", "brightness": 80.0}, 2, map[string]interface{}{"power": "on", "brightness": 40.0}, 7), nil)
+
+	state, err := service.GetDeviceState(ctx, "id")
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"brightness": 80.0}, state.Delta)
+	assert.Equal(t, int64(2), state.Desired.Version)
+	assert.Equal(t, int64(7), state.Reported.Version)
+}
+
+func TestGetDeviceState_DeviceNotFound(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	mockShadowDao := new(hdMock.MockDeviceShadowDao)
+	service := DeviceStateServiceImpl{deviceService: HomeDeviceServiceImpl{homeDeviceDao: mockDao}, deviceShadowDao: mockShadowDao}
+
+	ctx := context.Background()
+
+	mockDao.On("GetHomeDevice", ctx, "id").Return(nil, hdError.ErrDeviceNotFound.New())
+
+	_, err := service.GetDeviceState(ctx, "id")
+
+	assert.ErrorIs(t, err, hdError.ErrDeviceNotFound)
+	mockShadowDao.AssertNotCalled(t, "GetDeviceShadow", mock.Anything, mock.Anything)
+}
+
+func TestGetDeviceState_NotConfigured(t *testing.T) {
+	service := DeviceStateServiceImpl{deviceService: HomeDeviceServiceImpl{homeDeviceDao: new(hdMock.MockHomeDeviceDao)}}
+
+	_, err := service.GetDeviceState(context.Background(), "id")
+
+	assert.ErrorIs(t, err, hdError.ErrGettingDeviceState)
+}
+
+func TestUpdateDeviceState_MergesIntoLatestDocument(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	mockShadowDao := new(hdMock.MockDeviceShadowDao)
+	service := DeviceStateServiceImpl{deviceService: HomeDeviceServiceImpl{homeDeviceDao: mockDao}, deviceShadowDao: mockShadowDao}
+
+	ctx := context.Background()
+
+	mockDao.On("GetHomeDevice", ctx, "id").Return(&hdREsponse.HomdeDeviceResponse{ID: "id"}, nil)
+	mockShadowDao.On("GetDeviceShadow", ctx, "id").Return(newDeviceState("id", map[string]interface{}{"power": "off", "mode": "eco"}, 2, map[string]interface{}{"power": "off"}, 7), nil)
+	mockShadowDao.On("UpdateDeviceShadow", ctx, "id", request.DeviceShadowUpdate{
+		Desired:        map[string]interface{}{"power": "on"},
+		DesiredVersion: 2,
+	}).Return(newDeviceState("id", map[string]interface{}{"power": "on"}, 3, map[string]interface{}{"power": "off"}, 7), nil)
+
+	state, err := service.UpdateDeviceState(ctx, "id", request.UpdateDeviceStateRequest{
+		Desired: map[string]interface{}{"power": "on", "mode": nil},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), state.Desired.Version)
+	assert.Equal(t, map[string]interface{}{"power": "on"}, state.Delta)
+	mockShadowDao.AssertExpectations(t)
+}
+
+func TestUpdateDeviceState_RetriesOnConflict(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	mockShadowDao := new(hdMock.MockDeviceShadowDao)
+	service := DeviceStateServiceImpl{deviceService: HomeDeviceServiceImpl{homeDeviceDao: mockDao}, deviceShadowDao: mockShadowDao}
+
+	ctx := context.Background()
+
+	mockDao.On("GetHomeDevice", ctx, "id").Return(&hdREsponse.HomdeDeviceResponse{ID: "id"}, nil)
+	mockShadowDao.On("GetDeviceShadow", ctx, "id").Return(newDeviceState("id", nil, 0, map[string]interface{}{"temperature": 20.0}, 7), nil).Once()
+	mockShadowDao.On("GetDeviceShadow", ctx, "id").Return(newDeviceState("id", nil, 0, map[string]interface{}{"temperature": 20.5}, 8), nil).Once()
+	mockShadowDao.On("UpdateDeviceShadow", ctx, "id", mock.MatchedBy(func(update request.DeviceShadowUpdate) bool {
+		return update.ReportedVersion == 7
+	})).Return(nil, hdError.ErrVersionConflict.New())
+	mockShadowDao.On("UpdateDeviceShadow", ctx, "id", request.DeviceShadowUpdate{
+		Reported:        map[string]interface{}{"temperature": 20.5, "humidity": 40.0},
+		ReportedVersion: 8,
+	}).Return(newDeviceState("id", nil, 0, map[string]interface{}{"temperature": 20.5, "humidity": 40.0}, 9), nil)
+
+	state, err := service.UpdateDeviceState(ctx, "id", request.UpdateDeviceStateRequest{
+		Reported: map[string]interface{}{"humidity": 40.0},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(9), state.Reported.Version)
+	mockShadowDao.AssertNumberOfCalls(t, "UpdateDeviceShadow", 2)
+}
+
+func TestUpdateDeviceState_VersionConflict(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	mockShadowDao := new(hdMock.MockDeviceShadowDao)
+	service := DeviceStateServiceImpl{deviceService: HomeDeviceServiceImpl{homeDeviceDao: mockDao}, deviceShadowDao: mockShadowDao}
+
+	ctx := context.Background()
+
+	mockDao.On("GetHomeDevice", ctx, "id").Return(&hdREsponse.HomdeDeviceResponse{ID: "id"}, nil)
+	mockShadowDao.On("GetDeviceShadow", ctx, "id").Return(newDeviceState("id", map[string]interface{}{"power": "off"}, 3, nil, 0), nil)
+	mockShadowDao.On("UpdateDeviceShadow", ctx, "id", request.DeviceShadowUpdate{
+		Desired:        map[string]interface{}{"power": "on"},
+		DesiredVersion: 2,
+	}).Return(nil, hdError.ErrVersionConflict.New())
+
+	_, err := service.UpdateDeviceState(ctx, "id", request.UpdateDeviceStateRequest{
+		Desired:        map[string]interface{}{"power": "on"},
+		DesiredVersion: 2,
+	})
+
+	assert.ErrorIs(t, err, hdError.ErrVersionConflict)
+	mockShadowDao.AssertNumberOfCalls(t, "UpdateDeviceShadow", 1)
+}
+
+func TestUpdateDeviceState_NoState(t *testing.T) {
+	mockShadowDao := new(hdMock.MockDeviceShadowDao)
+	service := DeviceStateServiceImpl{deviceService: HomeDeviceServiceImpl{homeDeviceDao: new(hdMock.MockHomeDeviceDao)}, deviceShadowDao: mockShadowDao}
+
+	_, err := service.UpdateDeviceState(context.Background(), "id", request.UpdateDeviceStateRequest{DesiredVersion: 2})
+
+	assert.ErrorIs(t, err, hdError.ErrNoStateToUpdate)
+	mockShadowDao.AssertNotCalled(t, "UpdateDeviceShadow", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateDeviceState_InvalidForTheDeviceType(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	mockShadowDao := new(hdMock.MockDeviceShadowDao)
+	service := DeviceStateServiceImpl{deviceService: HomeDeviceServiceImpl{homeDeviceDao: mockDao}, deviceShadowDao: mockShadowDao}
+
+	ctx := context.Background()
+
+	mockDao.On("GetHomeDevice", ctx, "id").Return(&hdREsponse.HomdeDeviceResponse{ID: "id", Type: "light"}, nil)
+
+	_, err := service.UpdateDeviceState(ctx, "id", request.UpdateDeviceStateRequest{
+		Desired:  map[string]interface{}{"brightness": 120.0, "power": 10.0},
+		Reported: map[string]interface{}{"on": "yes"},
+	})
+
+	assert.ErrorIs(t, err, hdError.ErrInvalidDeviceState)
+	assert.EqualError(t, err, "brightness must be between 0 and 100; power is reported by the light devices and can not be desired; on must be a boolean")
+	mockShadowDao.AssertNotCalled(t, "GetDeviceShadow", mock.Anything, mock.Anything)
+}
+
+func TestUpdateDeviceState_ValidForTheDeviceType(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	mockShadowDao := new(hdMock.MockDeviceShadowDao)
+	service := DeviceStateServiceImpl{deviceService: HomeDeviceServiceImpl{homeDeviceDao: mockDao}, deviceShadowDao: mockShadowDao}
+
+	ctx := context.Background()
+
+	mockDao.On("GetHomeDevice", ctx, "id").Return(&hdREsponse.HomdeDeviceResponse{ID: "id", Type: "thermostat"}, nil)
+	mockShadowDao.On("GetDeviceShadow", ctx, "id").Return(newDeviceState("id", nil, 0, nil, 0), nil)
+	mockShadowDao.On("UpdateDeviceShadow", ctx, "id", request.DeviceShadowUpdate{
+		Desired:  map[string]interface{}{"mode": "heat", "targetTemperature": 21.5},
+		Reported: map[string]interface{}{"temperature": 19.0},
+	}).Return(newDeviceState("id", map[string]interface{}{"mode": "heat", "targetTemperature": 21.5}, 1, map[string]interface{}{"temperature": 19.0}, 1), nil)
+
+	_, err := service.UpdateDeviceState(ctx, "id", request.UpdateDeviceStateRequest{
+		Desired:  map[string]interface{}{"mode": "heat", "targetTemperature": 21.5},
+		Reported: map[string]interface{}{"temperature": 19.0},
+	})
+
+	assert.Nil(t, err)
+	mockShadowDao.AssertExpectations(t)
+}
+
+func newDeviceState(id string, desired map[string]interface{}, desiredVersion int64, reported map[string]interface{}, reportedVersion int64) *hdREsponse.DeviceStateResponse {
+	if desired == nil {
+		desired = map[string]interface{}{}
+	}
+	if reported == nil {
+		reported = map[string]interface{}{}
+	}
+	return &hdREsponse.DeviceStateResponse{
+		DeviceID: id,
+		Desired:  hdREsponse.DeviceStateDocumentResponse{State: desired, Version: desiredVersion},
+		Reported: hdREsponse.DeviceStateDocumentResponse{State: reported, Version: reportedVersion},
+	}
+}
@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+
+	capability "github.com/odhoman/home-devices/internal/capability"
+	response "github.com/odhoman/home-devices/internal/response"
+)
+
+type DeviceTypeService interface {
+	ListDeviceTypes(ctx context.Context) *response.DeviceTypeListResponse
+}
+
+type DeviceTypeServiceImpl struct{}
+
+// ListDeviceTypes returns the schemas of the device types, so the clients can
+// discover the attributes, state fields and commands of each type.
+func (dTSI DeviceTypeServiceImpl) ListDeviceTypes(ctx context.Context) *response.DeviceTypeListResponse {
+	return &response.DeviceTypeListResponse{DeviceTypes: capability.List()}
+}
+
+func NewDeviceTypeServiceImpl() DeviceTypeService {
+	return DeviceTypeServiceImpl{}
+}
@@ -2,54 +2,108 @@ package service
 
 import (
 	"context"
+	"errors"
+	"log"
+	"time"
 
+	hdAudit "github.com/odhoman/home-devices/internal/audit"
 	hdError "github.com/odhoman/home-devices/internal/error"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-	constants "github.com/odhoman/home-devices/internal/constants"
+
 	dao "github.com/odhoman/home-devices/internal/dao"
+	event "github.com/odhoman/home-devices/internal/event"
 	request "github.com/odhoman/home-devices/internal/request"
 	response "github.com/odhoman/home-devices/internal/response"
 )
 
 type HomeDeviceService interface {
-	CreateHomeDevice(ctx context.Context, device request.CreateDeviceRequest) (*response.HomdeDeviceResponse, *hdError.HomeDeviceError)
-	GetHomeDevice(ctx context.Context, id string) (*response.HomdeDeviceResponse, *hdError.HomeDeviceError)
-	UpdateHomeDevice(ctx context.Context, device request.UpdateDeviceRequest, id string) *hdError.HomeDeviceError
-	DeleteHomeDevice(ctx context.Context, id string) *hdError.HomeDeviceError
+	CreateHomeDevice(ctx context.Context, device request.CreateDeviceRequest) (*response.HomdeDeviceResponse, error)
+	GetHomeDevice(ctx context.Context, id string) (*response.HomdeDeviceResponse, error)
+	UpdateHomeDevice(ctx context.Context, device request.UpdateDeviceRequest, id string, expectedVersion int64) (*response.HomdeDeviceResponse, error)
+	DeleteHomeDevice(ctx context.Context, id string, expectedVersion int64) (*response.HomdeDeviceResponse, error)
+	HardDeleteHomeDevice(ctx context.Context, id string, expectedVersion int64) error
+	RestoreHomeDevice(ctx context.Context, id string) (*response.HomdeDeviceResponse, error)
+	ListHomeDevices(ctx context.Context, homeId string, roomId string, status string, limit int32, cursor string) (*response.HomeDeviceListResponse, error)
+	MarkDeviceSeen(ctx context.Context, id string, seenAt time.Time) (bool, error)
+	MarkOfflineDevices(ctx context.Context, now time.Time) ([]response.HomdeDeviceResponse, error)
+	GetDeviceHistory(ctx context.Context, id string, limit int32, cursor string) (*response.DeviceHistoryResponse, error)
 }
 
+// maxAuditedWriteAttempts is how many times an update or delete without an
+// expected version is tried when the device changes between reading it for
+// the history and writing it.
+const maxAuditedWriteAttempts = 3
+
 type HomeDeviceServiceImpl struct {
-	homeDeviceDao dao.HomeDeviceDao
+	homeDeviceDao    dao.HomeDeviceDao
+	deviceHistoryDao dao.DeviceHistoryDao
+	homeDao          dao.HomeDao
+	roomDao          dao.RoomDao
+	eventPublisher   event.EventPublisher
 }
 
-func (hDDI HomeDeviceServiceImpl) CreateHomeDevice(ctx context.Context, device request.CreateDeviceRequest) (*response.HomdeDeviceResponse, *hdError.HomeDeviceError) {
+type HomeDeviceServiceOption func(*HomeDeviceServiceImpl)
 
-	dao := hDDI.homeDeviceDao
+// WithDeviceHistoryDao reads the device history, which the homeDeviceDao
+// records with every write of a device.
+func WithDeviceHistoryDao(deviceHistoryDao dao.DeviceHistoryDao) HomeDeviceServiceOption {
+	return func(hDDI *HomeDeviceServiceImpl) {
+		hDDI.deviceHistoryDao = deviceHistoryDao
+	}
+}
 
-	isExist, err := dao.IsDeviceExist(ctx, device.MAC, device.HomeID)
-	if err != nil {
+// WithHomeDao keeps the homes, and makes the devices belong to a registered
+// home.
+func WithHomeDao(homeDao dao.HomeDao) HomeDeviceServiceOption {
+	return func(hDDI *HomeDeviceServiceImpl) {
+		hDDI.homeDao = homeDao
+	}
+}
+
+// WithRoomDao keeps the rooms of the homes, and makes the roomId of the
+// devices point to a room of their home.
+func WithRoomDao(roomDao dao.RoomDao) HomeDeviceServiceOption {
+	return func(hDDI *HomeDeviceServiceImpl) {
+		hDDI.roomDao = roomDao
+	}
+}
+
+// WithEventPublisher publishes an event for every create, update, delete,
+// restore and change of the status of a device.
+func WithEventPublisher(eventPublisher event.EventPublisher) HomeDeviceServiceOption {
+	return func(hDDI *HomeDeviceServiceImpl) {
+		hDDI.eventPublisher = eventPublisher
+	}
+}
+
+func (hDDI HomeDeviceServiceImpl) CreateHomeDevice(ctx context.Context, device request.CreateDeviceRequest) (*response.HomdeDeviceResponse, error) {
+
+	if err := checkHomeExists(ctx, hDDI.homeDao, device.HomeID, hdError.ErrUnknownHome); err != nil {
 		return nil, err
 	}
 
-	if isExist {
-		return nil, &hdError.HomeDeviceError{
-			ErrorCode:    constants.ErrDeviceAlreadyExistsCode,
-			ErrorMessage: constants.ErrDeviceAlreadyExistsMessage,
+	if device.RoomID != "" {
+		if err := checkRoomExists(ctx, hDDI.roomDao, device.HomeID, device.RoomID); err != nil {
+			return nil, err
 		}
 	}
 
+	dao := hDDI.homeDeviceDao
+
 	response, saveDeviceError := dao.SaveHomeDevice(ctx, device)
 	if saveDeviceError != nil {
 		return nil, saveDeviceError
 	}
 
+	hDDI.publishDeviceEvent(ctx, hdAudit.OperationCreate, response.ID, nil, response)
+
 	return response, nil
 
 }
 
-func (hDDI HomeDeviceServiceImpl) GetHomeDevice(ctx context.Context, id string) (*response.HomdeDeviceResponse, *hdError.HomeDeviceError) {
+func (hDDI HomeDeviceServiceImpl) GetHomeDevice(ctx context.Context, id string) (*response.HomdeDeviceResponse, error) {
 
 	dao := hDDI.homeDeviceDao
 
@@ -61,31 +115,300 @@ func (hDDI HomeDeviceServiceImpl) GetHomeDevice(ctx context.Context, id string)
 	return result, nil
 }
 
-func (hDDI HomeDeviceServiceImpl) UpdateHomeDevice(ctx context.Context, device request.UpdateDeviceRequest, id string) *hdError.HomeDeviceError {
+func (hDDI HomeDeviceServiceImpl) UpdateHomeDevice(ctx context.Context, device request.UpdateDeviceRequest, id string, expectedVersion int64) (*response.HomdeDeviceResponse, error) {
+
+	if device.MAC == "" && device.Name == "" && device.Type == "" && device.HomeID == "" && device.RoomID == "" {
+		return nil, hdError.ErrNoFieldToUpdate.New()
+	}
+
+	if device.HomeID != "" {
+		if err := checkHomeExists(ctx, hDDI.homeDao, device.HomeID, hdError.ErrUnknownHome); err != nil {
+			return nil, err
+		}
+	}
+
+	if device.RoomID != "" {
+		if err := hDDI.checkDeviceRoomExists(ctx, device, id); err != nil {
+			return nil, err
+		}
+	}
+
+	dao := hDDI.homeDeviceDao
+
+	if !hDDI.recordsChanges() {
+		return dao.UpdateHomeDevice(ctx, device, id, expectedVersion)
+	}
+
+	var updated *response.HomdeDeviceResponse
+
+	err := hDDI.auditedWrite(ctx, id, expectedVersion, func(before *response.HomdeDeviceResponse, version int64) error {
+		after, err := dao.UpdateHomeDevice(ctx, device, id, version)
+		if err != nil {
+			return err
+		}
+
+		hDDI.publishDeviceEvent(ctx, hdAudit.OperationUpdate, id, before, after)
+		updated = after
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+func (hDDI HomeDeviceServiceImpl) DeleteHomeDevice(ctx context.Context, id string, expectedVersion int64) (*response.HomdeDeviceResponse, error) {
+	dao := hDDI.homeDeviceDao
+
+	if !hDDI.recordsChanges() {
+		return dao.DeleteHomeDevice(ctx, id, expectedVersion)
+	}
+
+	var deleted *response.HomdeDeviceResponse
 
-	if device.MAC == "" && device.Name == "" && device.Type == "" && device.HomeID == "" {
-		return &hdError.HomeDeviceError{
-			ErrorCode:    constants.ErrNoFieldToUpdateCode,
-			ErrorMessage: constants.ErrNoFieldToUpdateMessage,
+	err := hDDI.auditedWrite(ctx, id, expectedVersion, func(before *response.HomdeDeviceResponse, version int64) error {
+		device, err := dao.DeleteHomeDevice(ctx, id, version)
+		if err != nil {
+			return err
 		}
+
+		hDDI.publishDeviceEvent(ctx, hdAudit.OperationDelete, id, before, nil)
+		deleted = device
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	return deleted, nil
+}
+
+func (hDDI HomeDeviceServiceImpl) HardDeleteHomeDevice(ctx context.Context, id string, expectedVersion int64) error {
 	dao := hDDI.homeDeviceDao
 
-	return dao.UpdateHomeDevice(ctx, device, id)
+	if !hDDI.recordsChanges() {
+		return dao.HardDeleteHomeDevice(ctx, id, expectedVersion)
+	}
+
+	// a soft deleted device can not be read, its values are in the history
+	before, err := dao.GetHomeDevice(ctx, id)
+	if err != nil && !errors.Is(err, hdError.ErrDeviceNotFound) {
+		return err
+	}
+
+	if before == nil {
+		if err := dao.HardDeleteHomeDevice(ctx, id, expectedVersion); err != nil {
+			return err
+		}
+
+		hDDI.publishDeviceEvent(ctx, hdAudit.OperationHardDelete, id, nil, nil)
+		return nil
+	}
+
+	return hDDI.auditedWriteFrom(ctx, id, expectedVersion, before, func(before *response.HomdeDeviceResponse, version int64) error {
+		if err := dao.HardDeleteHomeDevice(ctx, id, version); err != nil {
+			return err
+		}
+
+		hDDI.publishDeviceEvent(ctx, hdAudit.OperationHardDelete, id, before, nil)
+		return nil
+	})
 }
 
-func (hDDI HomeDeviceServiceImpl) DeleteHomeDevice(ctx context.Context, id string) *hdError.HomeDeviceError {
+func (hDDI HomeDeviceServiceImpl) RestoreHomeDevice(ctx context.Context, id string) (*response.HomdeDeviceResponse, error) {
 	dao := hDDI.homeDeviceDao
-	return dao.DeleteHomeDevice(ctx, id)
+
+	if err := hDDI.checkDeletedDeviceHomeExists(ctx, id); err != nil {
+		return nil, err
+	}
+
+	device, err := dao.RestoreHomeDevice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	hDDI.publishDeviceEvent(ctx, hdAudit.OperationRestore, id, nil, device)
+
+	return device, nil
 }
 
-func NewHomeDeviceServiceImplFromConfig2(cfg aws.Config) HomeDeviceService {
-	client := dynamodb.NewFromConfig(cfg)
-	dao := dao.HomeDeviceDaoImpl{DynamoDbApi: client}
-	return NewHomeDeviceServiceImpl2(dao)
+func (hDDI HomeDeviceServiceImpl) ListHomeDevices(ctx context.Context, homeId string, roomId string, status string, limit int32, cursor string) (*response.HomeDeviceListResponse, error) {
+	dao := hDDI.homeDeviceDao
+	return dao.ListHomeDevices(ctx, homeId, roomId, status, limit, cursor)
 }
 
-func NewHomeDeviceServiceImpl2(dao dao.HomeDeviceDao) HomeDeviceService {
-	return HomeDeviceServiceImpl{homeDeviceDao: dao}
+func (hDDI HomeDeviceServiceImpl) GetDeviceHistory(ctx context.Context, id string, limit int32, cursor string) (*response.DeviceHistoryResponse, error) {
+
+	if hDDI.deviceHistoryDao == nil {
+		log.Printf("The device history is not configured")
+		return nil, hdError.ErrListingDeviceHistory.New()
+	}
+
+	return hDDI.deviceHistoryDao.ListDeviceHistory(ctx, id, limit, cursor)
+}
+
+// checkDeviceRoomExists checks the room of an update against the home the
+// device ends up in, which is the current one when the update does not move
+// the device.
+func (hDDI HomeDeviceServiceImpl) checkDeviceRoomExists(ctx context.Context, device request.UpdateDeviceRequest, id string) error {
+
+	if hDDI.roomDao == nil {
+		return nil
+	}
+
+	homeId := device.HomeID
+	if homeId == "" {
+		current, err := hDDI.homeDeviceDao.GetHomeDevice(ctx, id)
+		if err != nil {
+			return err
+		}
+		homeId = current.HomeID
+	}
+
+	return checkRoomExists(ctx, hDDI.roomDao, homeId, device.RoomID)
+}
+
+// checkDeletedDeviceHomeExists checks that the home and the room of a deleted
+// device were not deleted after it, so it is not restored into them.
+func (hDDI HomeDeviceServiceImpl) checkDeletedDeviceHomeExists(ctx context.Context, id string) error {
+
+	if hDDI.homeDao == nil && hDDI.roomDao == nil {
+		return nil
+	}
+
+	deleted, err := hDDI.homeDeviceDao.GetDeletedHomeDevice(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := checkHomeExists(ctx, hDDI.homeDao, deleted.HomeID, hdError.ErrUnknownHome); err != nil {
+		return err
+	}
+
+	if deleted.RoomID == "" {
+		return nil
+	}
+
+	return checkRoomExists(ctx, hDDI.roomDao, deleted.HomeID, deleted.RoomID)
+}
+
+// auditedWrite reads the device before writing it, so the history gets the
+// exact values that were changed.
+func (hDDI HomeDeviceServiceImpl) auditedWrite(ctx context.Context, id string, expectedVersion int64, write func(before *response.HomdeDeviceResponse, version int64) error) error {
+
+	before, err := hDDI.homeDeviceDao.GetHomeDevice(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return hDDI.auditedWriteFrom(ctx, id, expectedVersion, before, write)
+}
+
+func (hDDI HomeDeviceServiceImpl) auditedWriteFrom(ctx context.Context, id string, expectedVersion int64, before *response.HomdeDeviceResponse, write func(before *response.HomdeDeviceResponse, version int64) error) error {
+
+	for attempt := 1; ; attempt++ {
+
+		version := expectedVersion
+		if version <= 0 {
+			version = before.Version
+		}
+
+		err := write(before, version)
+		if err == nil || !errors.Is(err, hdError.ErrVersionConflict) || expectedVersion > 0 || attempt == maxAuditedWriteAttempts {
+			return err
+		}
+
+		log.Printf("The device %v changed while it was written, trying again", id)
+
+		before, err = hDDI.homeDeviceDao.GetHomeDevice(ctx, id)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// recordsChanges tells whether the changes of the devices are recorded in the
+// history or published, so the device is written at the version that was read.
+func (hDDI HomeDeviceServiceImpl) recordsChanges() bool {
+	return hDDI.deviceHistoryDao != nil || hDDI.eventPublisher != nil
+}
+
+func (hDDI HomeDeviceServiceImpl) publishDeviceEvent(ctx context.Context, operation string, id string, before *response.HomdeDeviceResponse, after *response.HomdeDeviceResponse) {
+
+	if hDDI.eventPublisher == nil {
+		return
+	}
+
+	deviceEvent := event.NewDeviceEvent(deviceEventType(operation, before, after), id, time.Now(), event.DeviceEventData{
+		Operation: operation,
+		Actor:     hdAudit.ActorFromContext(ctx),
+		Source:    hdAudit.SourceFromContext(ctx),
+		Before:    before,
+		After:     after,
+	})
+
+	if err := hDDI.eventPublisher.Publish(ctx, deviceEvent); err != nil {
+		log.Printf("Error publishing the %v event of the device %v: %v", deviceEvent.Type, id, err)
+	}
+}
+
+// deviceEventType maps the operation of the history to the type of its event.
+func deviceEventType(operation string, before *response.HomdeDeviceResponse, after *response.HomdeDeviceResponse) string {
+
+	switch operation {
+	case hdAudit.OperationCreate, hdAudit.OperationRestore:
+		return event.TypeDeviceCreated
+	case hdAudit.OperationDelete, hdAudit.OperationHardDelete:
+		return event.TypeDeviceDeleted
+	case hdAudit.OperationStatusChange:
+		return event.TypeDeviceStatusChanged
+	}
+
+	if before != nil && after != nil && (before.HomeID != after.HomeID || before.RoomID != after.RoomID) {
+		return event.TypeDeviceMoved
+	}
+
+	return event.TypeDeviceUpdated
+}
+
+func resolveValue(value string, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// NewHomeDeviceServiceImplFromConfig2 uses the DynamoDB daos and publishes the
+// events to SNS.
+func NewHomeDeviceServiceImplFromConfig2(cfg aws.Config, options ...HomeDeviceServiceOption) HomeDeviceService {
+	return newHomeDeviceServiceFromConfig(cfg, dynamodb.NewFromConfig(cfg), options...)
+}
+
+// newHomeDeviceServiceFromConfig builds the device service of the other
+// services with their DynamoDB client, so every change of a device is
+// recorded and published whichever service makes it.
+func newHomeDeviceServiceFromConfig(cfg aws.Config, client *dynamodb.Client, options ...HomeDeviceServiceOption) HomeDeviceService {
+	homeDeviceDao := dao.HomeDeviceDaoImpl{DynamoDbApi: client, RecordHistory: true, CountRoomDevices: true}
+	deviceHistoryDao := dao.DeviceHistoryDaoImpl{DynamoDbApi: client}
+	homeDao := dao.HomeDaoImpl{DynamoDbApi: client}
+	roomDao := dao.RoomDaoImpl{DynamoDbApi: client}
+	defaultOptions := []HomeDeviceServiceOption{WithDeviceHistoryDao(deviceHistoryDao), WithHomeDao(homeDao), WithRoomDao(roomDao)}
+
+	// the lambdas that only read the devices have no topic for the events
+	if eventPublisher, err := event.NewSNSEventPublisherFromConfig(cfg); err == nil {
+		defaultOptions = append(defaultOptions, WithEventPublisher(eventPublisher))
+	} else {
+		log.Printf("The device events are not published: %v", err)
+	}
+
+	return NewHomeDeviceServiceImpl2(homeDeviceDao, append(defaultOptions, options...)...)
+}
+
+func NewHomeDeviceServiceImpl2(dao dao.HomeDeviceDao, options ...HomeDeviceServiceOption) HomeDeviceService {
+	service := HomeDeviceServiceImpl{homeDeviceDao: dao}
+	for _, option := range options {
+		option(&service)
+	}
+	return service
 }
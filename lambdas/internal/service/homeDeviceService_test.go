@@ -2,10 +2,14 @@ package service
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
-	"github.com/odhoman/home-devices/internal/constants"
+	hdAudit "github.com/odhoman/home-devices/internal/audit"
+	"github.com/odhoman/home-devices/internal/dao"
 	hdError "github.com/odhoman/home-devices/internal/error"
+	"github.com/odhoman/home-devices/internal/event"
 	hdMock "github.com/odhoman/home-devices/internal/mock"
 	"github.com/odhoman/home-devices/internal/request"
 	hdREsponse "github.com/odhoman/home-devices/internal/response"
@@ -20,25 +24,12 @@ func TestCreateHomeDevice_DeviceExist(t *testing.T) {
 	ctx := context.Background()
 	deviceRequest := request.CreateDeviceRequest{MAC: "00:11:22:33:44:55", HomeID: "home1"}
 
-	mockDao.On("IsDeviceExist", ctx, deviceRequest.MAC, deviceRequest.HomeID).Return(true, (*hdError.HomeDeviceError)(nil))
+	mockDao.On("SaveHomeDevice", ctx, deviceRequest).Return((*hdREsponse.HomdeDeviceResponse)(nil), hdError.ErrDeviceAlreadyExists.New())
 
 	_, err := service.CreateHomeDevice(ctx, deviceRequest)
 	assert.NotNil(t, err)
-	assert.Equal(t, constants.ErrDeviceAlreadyExistsCode, err.ErrorCode)
-}
-
-func TestCreateHomeDevice_ErrorVerifyingIfDeviceExist(t *testing.T) {
-	mockDao := new(hdMock.MockHomeDeviceDao)
-	service := HomeDeviceServiceImpl{homeDeviceDao: mockDao}
-
-	ctx := context.Background()
-	deviceRequest := request.CreateDeviceRequest{MAC: "00:11:22:33:44:55", HomeID: "home1"}
-
-	mockDao.On("IsDeviceExist", ctx, deviceRequest.MAC, deviceRequest.HomeID).Return(false, &hdError.HomeDeviceError{ErrorCode: constants.ErrDeviceAlreadyExistsCode})
-
-	_, err := service.CreateHomeDevice(ctx, deviceRequest)
-	assert.NotNil(t, err)
-	assert.Equal(t, constants.ErrDeviceAlreadyExistsCode, err.ErrorCode)
+	assert.ErrorIs(t, err, hdError.ErrDeviceAlreadyExists)
+	mockDao.AssertNotCalled(t, "IsDeviceExist", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestCreateHomeDevice_Success(t *testing.T) {
@@ -48,12 +39,12 @@ func TestCreateHomeDevice_Success(t *testing.T) {
 	ctx := context.Background()
 	deviceRequest := request.CreateDeviceRequest{MAC: "00:11:22:33:44:55", HomeID: "home1"}
 
-	mockDao.On("IsDeviceExist", ctx, deviceRequest.MAC, deviceRequest.HomeID).Return(false, (*hdError.HomeDeviceError)(nil))
-	mockDao.On("SaveHomeDevice", ctx, deviceRequest).Return(&hdREsponse.HomdeDeviceResponse{}, (*hdError.HomeDeviceError)(nil))
+	mockDao.On("SaveHomeDevice", ctx, deviceRequest).Return(&hdREsponse.HomdeDeviceResponse{}, nil)
 
 	resp, err := service.CreateHomeDevice(ctx, deviceRequest)
 	assert.Nil(t, err)
 	assert.NotNil(t, resp)
+	mockDao.AssertNotCalled(t, "IsDeviceExist", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestCreateHomeDevice_ErrorSavingDevice(t *testing.T) {
@@ -63,12 +54,11 @@ func TestCreateHomeDevice_ErrorSavingDevice(t *testing.T) {
 	ctx := context.Background()
 	deviceRequest := request.CreateDeviceRequest{MAC: "00:11:22:33:44:55", HomeID: "home1"}
 
-	mockDao.On("IsDeviceExist", ctx, deviceRequest.MAC, deviceRequest.HomeID).Return(false, (*hdError.HomeDeviceError)(nil))
 	mockDao.On("SaveHomeDevice", ctx, deviceRequest).Return(&hdREsponse.HomdeDeviceResponse{}, &hdError.HomeDeviceError{ErrorCode: "save_error"})
 
 	_, err := service.CreateHomeDevice(ctx, deviceRequest)
 	assert.NotNil(t, err)
-	assert.Equal(t, "save_error", err.ErrorCode)
+	assert.Equal(t, "save_error", hdError.From(err).ErrorCode)
 }
 
 func TestUpdateHomeDevice_Success(t *testing.T) {
@@ -78,10 +68,25 @@ func TestUpdateHomeDevice_Success(t *testing.T) {
 	ctx := context.Background()
 	deviceRequest := request.UpdateDeviceRequest{MAC: "00:11:22:33:44:55", HomeID: "home1"}
 
-	mockDao.On("UpdateHomeDevice", ctx, deviceRequest, "id").Return((*hdError.HomeDeviceError)(nil))
+	mockDao.On("UpdateHomeDevice", ctx, deviceRequest, "id", int64(0)).Return(&hdREsponse.HomdeDeviceResponse{ID: "id", Version: 2}, nil)
 
-	err := service.UpdateHomeDevice(ctx, deviceRequest, "id")
+	updated, err := service.UpdateHomeDevice(ctx, deviceRequest, "id", 0)
 	assert.Nil(t, err)
+	assert.Equal(t, int64(2), updated.Version)
+}
+
+func TestUpdateHomeDevice_ExpectedVersion(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	service := HomeDeviceServiceImpl{homeDeviceDao: mockDao}
+
+	ctx := context.Background()
+	deviceRequest := request.UpdateDeviceRequest{Name: "Living Room Light"}
+
+	mockDao.On("UpdateHomeDevice", ctx, deviceRequest, "id", int64(3)).Return(nil, hdError.ErrVersionConflict.New())
+
+	_, err := service.UpdateHomeDevice(ctx, deviceRequest, "id", 3)
+	assert.NotNil(t, err)
+	assert.ErrorIs(t, err, hdError.ErrVersionConflict)
 }
 
 func TestUpdateHomeDevice_NoFieldToUpdate(t *testing.T) {
@@ -91,9 +96,9 @@ func TestUpdateHomeDevice_NoFieldToUpdate(t *testing.T) {
 	ctx := context.Background()
 	deviceRequest := request.UpdateDeviceRequest{}
 
-	err := service.UpdateHomeDevice(ctx, deviceRequest, "id")
+	_, err := service.UpdateHomeDevice(ctx, deviceRequest, "id", 0)
 	assert.NotNil(t, err)
-	assert.Equal(t, constants.ErrNoFieldToUpdateCode, err.ErrorCode)
+	assert.ErrorIs(t, err, hdError.ErrNoFieldToUpdate)
 }
 
 func TestUpdateHomeDevice_Error(t *testing.T) {
@@ -103,11 +108,11 @@ func TestUpdateHomeDevice_Error(t *testing.T) {
 	ctx := context.Background()
 	deviceRequest := request.UpdateDeviceRequest{MAC: "00:11:22:33:44:55", HomeID: "home1"}
 
-	mockDao.On("UpdateHomeDevice", ctx, deviceRequest, "id").Return(&hdError.HomeDeviceError{ErrorCode: "save_error"})
+	mockDao.On("UpdateHomeDevice", ctx, deviceRequest, "id", int64(0)).Return(nil, &hdError.HomeDeviceError{ErrorCode: "save_error"})
 
-	err := service.UpdateHomeDevice(ctx, deviceRequest, "id")
+	_, err := service.UpdateHomeDevice(ctx, deviceRequest, "id", 0)
 	assert.NotNil(t, err)
-	assert.Equal(t, "save_error", err.ErrorCode)
+	assert.Equal(t, "save_error", hdError.From(err).ErrorCode)
 }
 
 func TestGetHomeDevice_Success(t *testing.T) {
@@ -116,7 +121,7 @@ func TestGetHomeDevice_Success(t *testing.T) {
 
 	ctx := context.Background()
 
-	mockDao.On("GetHomeDevice", ctx, "id").Return(&hdREsponse.HomdeDeviceResponse{MAC: "00:11:22:33:44:55", HomeID: "home1"}, (*hdError.HomeDeviceError)(nil))
+	mockDao.On("GetHomeDevice", ctx, "id").Return(&hdREsponse.HomdeDeviceResponse{MAC: "00:11:22:33:44:55", HomeID: "home1"}, nil)
 
 	response, err := service.GetHomeDevice(ctx, "id")
 
@@ -136,7 +141,7 @@ func TestGetHomeDevice_Error(t *testing.T) {
 	_, err := service.GetHomeDevice(ctx, "id")
 
 	assert.NotNil(t, err)
-	assert.Equal(t, "get_error", err.ErrorCode)
+	assert.Equal(t, "get_error", hdError.From(err).ErrorCode)
 }
 
 func TestDeleteHomeDevice_Success(t *testing.T) {
@@ -144,10 +149,11 @@ func TestDeleteHomeDevice_Success(t *testing.T) {
 	service := HomeDeviceServiceImpl{homeDeviceDao: mockDao}
 
 	ctx := context.Background()
-	mockDao.On("DeleteHomeDevice", ctx, "id").Return((*hdError.HomeDeviceError)(nil))
-	err := service.DeleteHomeDevice(ctx, "id")
+	mockDao.On("DeleteHomeDevice", ctx, "id", int64(0)).Return(&hdREsponse.HomdeDeviceResponse{ID: "id", Version: 2}, nil)
+	deleted, err := service.DeleteHomeDevice(ctx, "id", 0)
 
 	assert.Nil(t, err)
+	assert.Equal(t, int64(2), deleted.Version)
 
 }
 
@@ -157,10 +163,513 @@ func TestDeleteHomeDevice_Error(t *testing.T) {
 
 	ctx := context.Background()
 
-	mockDao.On("DeleteHomeDevice", ctx, mock.Anything).Return(&hdError.HomeDeviceError{ErrorCode: "delete_error"})
+	mockDao.On("DeleteHomeDevice", ctx, mock.Anything, int64(0)).Return(nil, &hdError.HomeDeviceError{ErrorCode: "delete_error"})
+
+	_, err := service.DeleteHomeDevice(ctx, "id", 0)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "delete_error", hdError.From(err).ErrorCode)
+}
+
+func TestHardDeleteHomeDevice_Success(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	service := HomeDeviceServiceImpl{homeDeviceDao: mockDao}
+
+	ctx := context.Background()
+	mockDao.On("HardDeleteHomeDevice", ctx, "id", int64(2)).Return(nil)
+	err := service.HardDeleteHomeDevice(ctx, "id", 2)
+
+	assert.Nil(t, err)
+	mockDao.AssertNotCalled(t, "DeleteHomeDevice", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRestoreHomeDevice_Success(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	service := HomeDeviceServiceImpl{homeDeviceDao: mockDao}
+
+	ctx := context.Background()
+	mockDao.On("RestoreHomeDevice", ctx, "id").Return(&hdREsponse.HomdeDeviceResponse{ID: "id", Version: 3}, nil)
+
+	response, err := service.RestoreHomeDevice(ctx, "id")
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), response.Version)
+}
+
+func TestRestoreHomeDevice_Error(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	service := HomeDeviceServiceImpl{homeDeviceDao: mockDao}
+
+	ctx := context.Background()
+	mockDao.On("RestoreHomeDevice", ctx, "id").Return(nil, hdError.ErrDeviceNotDeleted.New())
+
+	_, err := service.RestoreHomeDevice(ctx, "id")
+
+	assert.NotNil(t, err)
+	assert.ErrorIs(t, err, hdError.ErrDeviceNotDeleted)
+}
+
+func TestRestoreHomeDevice_UnknownHomeIsNotRestored(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	mockHomeDao := new(hdMock.MockHomeDao)
+	service := HomeDeviceServiceImpl{homeDeviceDao: mockDao, homeDao: mockHomeDao}
+
+	ctx := context.Background()
+	mockDao.On("GetDeletedHomeDevice", ctx, "id").Return(&hdREsponse.HomdeDeviceResponse{ID: "id", HomeID: "home1", Version: 2}, nil)
+	mockHomeDao.On("GetHome", ctx, "home1").Return(nil, hdError.ErrHomeNotFound.New())
+
+	_, err := service.RestoreHomeDevice(ctx, "id")
+
+	assert.ErrorIs(t, err, hdError.ErrUnknownHome)
+	mockDao.AssertNotCalled(t, "RestoreHomeDevice", mock.Anything, mock.Anything)
+}
+
+func TestListHomeDevices_Success(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	service := HomeDeviceServiceImpl{homeDeviceDao: mockDao}
+
+	ctx := context.Background()
+
+	mockDao.On("ListHomeDevices", ctx, "home1", "", "", int32(10), "").Return(&hdREsponse.HomeDeviceListResponse{
+		Devices:    []hdREsponse.HomdeDeviceResponse{{MAC: "00:11:22:33:44:55", HomeID: "home1"}},
+		NextCursor: "cursor",
+	}, nil)
+
+	response, err := service.ListHomeDevices(ctx, "home1", "", "", 10, "")
+
+	assert.Nil(t, err)
+	assert.Len(t, response.Devices, 1)
+	assert.Equal(t, "cursor", response.NextCursor)
+}
+
+func TestListHomeDevices_Error(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	service := HomeDeviceServiceImpl{homeDeviceDao: mockDao}
+
+	ctx := context.Background()
+
+	mockDao.On("ListHomeDevices", ctx, "home1", "", "", int32(10), "cursor").Return(nil, hdError.ErrInvalidCursor.New())
+
+	_, err := service.ListHomeDevices(ctx, "home1", "", "", 10, "cursor")
+
+	assert.NotNil(t, err)
+	assert.ErrorIs(t, err, hdError.ErrInvalidCursor)
+}
+
+func newHistoryServiceForTesting() HomeDeviceService {
+	deviceHistoryDao := dao.NewInMemoryDeviceHistoryDao()
+	return NewHomeDeviceServiceImpl2(dao.NewInMemoryHomeDeviceDaoWithHistory(deviceHistoryDao), WithDeviceHistoryDao(deviceHistoryDao))
+}
+
+func lastDeviceChange(t *testing.T, service HomeDeviceService, id string) hdREsponse.DeviceChangeResponse {
+	t.Helper()
+
+	history, err := service.GetDeviceHistory(context.Background(), id, 1, "")
+	assert.Nil(t, err)
+	assert.Len(t, history.Changes, 1)
+
+	return history.Changes[0]
+}
+
+func TestCreateHomeDevice_RecordsChange(t *testing.T) {
+	service := newHistoryServiceForTesting()
+
+	ctx := hdAudit.WithSource(hdAudit.WithActor(context.Background(), "user-1"), hdAudit.SourceAPI)
+
+	device, err := service.CreateHomeDevice(ctx, request.CreateDeviceRequest{MAC: "00:11:22:33:44:55", Name: "Light", Type: "light", HomeID: "home1"})
+	assert.Nil(t, err)
+
+	change := lastDeviceChange(t, service, device.ID)
+	assert.Equal(t, hdAudit.OperationCreate, change.Operation)
+	assert.Equal(t, "user-1", change.Actor)
+	assert.Equal(t, hdAudit.SourceAPI, change.Source)
+	assert.Equal(t, int64(1), change.Version)
+	assert.Len(t, change.Changes, 4)
+	assert.True(t, change.ChangedAt > 0)
+}
+
+func TestUpdateHomeDevice_RecordsChange(t *testing.T) {
+	service := newHistoryServiceForTesting()
+
+	ctx := context.Background()
+	device, _ := service.CreateHomeDevice(ctx, request.CreateDeviceRequest{MAC: "00:11:22:33:44:55", Name: "Light", Type: "light", HomeID: "home1"})
+
+	updated, err := service.UpdateHomeDevice(ctx, request.UpdateDeviceRequest{Name: "Living Room Light"}, device.ID, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), updated.Version)
+
+	change := lastDeviceChange(t, service, device.ID)
+	assert.Equal(t, hdAudit.OperationUpdate, change.Operation)
+	assert.Equal(t, int64(2), change.Version)
+	assert.Equal(t, []hdREsponse.DeviceFieldChange{{Field: "name", Before: "Light", After: "Living Room Light"}}, change.Changes)
+}
+
+func TestUpdateHomeDevice_RetriesOnConflict(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	mockHistoryDao := new(hdMock.MockDeviceHistoryDao)
+	service := NewHomeDeviceServiceImpl2(mockDao, WithDeviceHistoryDao(mockHistoryDao))
+
+	ctx := context.Background()
+	deviceRequest := request.UpdateDeviceRequest{Name: "Living Room Light"}
+
+	mockDao.On("GetHomeDevice", ctx, "id").Return(&hdREsponse.HomdeDeviceResponse{ID: "id", Version: 4}, nil).Once()
+	mockDao.On("GetHomeDevice", ctx, "id").Return(&hdREsponse.HomdeDeviceResponse{ID: "id", Version: 5}, nil).Once()
+	mockDao.On("UpdateHomeDevice", ctx, deviceRequest, "id", int64(4)).Return(nil, hdError.ErrVersionConflict.New())
+	mockDao.On("UpdateHomeDevice", ctx, deviceRequest, "id", int64(5)).Return(&hdREsponse.HomdeDeviceResponse{ID: "id", Name: "Living Room Light", Version: 6}, nil)
+
+	updated, err := service.UpdateHomeDevice(ctx, deviceRequest, "id", 0)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(6), updated.Version)
+	mockDao.AssertExpectations(t)
+}
+
+func TestUpdateHomeDevice_ExpectedVersionConflictIsNotRetried(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	mockHistoryDao := new(hdMock.MockDeviceHistoryDao)
+	service := NewHomeDeviceServiceImpl2(mockDao, WithDeviceHistoryDao(mockHistoryDao))
+
+	ctx := context.Background()
+	deviceRequest := request.UpdateDeviceRequest{Name: "Living Room Light"}
+
+	mockDao.On("GetHomeDevice", ctx, "id").Return(&hdREsponse.HomdeDeviceResponse{ID: "id", Version: 4}, nil)
+	mockDao.On("UpdateHomeDevice", ctx, deviceRequest, "id", int64(3)).Return(nil, hdError.ErrVersionConflict.New())
+
+	_, err := service.UpdateHomeDevice(ctx, deviceRequest, "id", 3)
+
+	assert.NotNil(t, err)
+	assert.ErrorIs(t, err, hdError.ErrVersionConflict)
+	mockDao.AssertNumberOfCalls(t, "UpdateHomeDevice", 1)
+}
+
+func TestUpdateHomeDevice_ConflictIsNotRecorded(t *testing.T) {
+	service := newHistoryServiceForTesting()
+
+	ctx := context.Background()
+	device, _ := service.CreateHomeDevice(ctx, request.CreateDeviceRequest{MAC: "00:11:22:33:44:55", Name: "Light", Type: "light", HomeID: "home1"})
+
+	_, err := service.UpdateHomeDevice(ctx, request.UpdateDeviceRequest{Name: "Living Room Light"}, device.ID, 3)
+	assert.ErrorIs(t, err, hdError.ErrVersionConflict)
+
+	assert.Equal(t, hdAudit.OperationCreate, lastDeviceChange(t, service, device.ID).Operation)
+}
+
+func TestDeleteHomeDevice_RecordsChange(t *testing.T) {
+	service := newHistoryServiceForTesting()
+
+	ctx := context.Background()
+	device, _ := service.CreateHomeDevice(ctx, request.CreateDeviceRequest{MAC: "00:11:22:33:44:55", Name: "Light", Type: "light", HomeID: "home1"})
+
+	_, err := service.DeleteHomeDevice(ctx, device.ID, 0)
+	assert.Nil(t, err)
+
+	change := lastDeviceChange(t, service, device.ID)
+	assert.Equal(t, hdAudit.OperationDelete, change.Operation)
+	assert.Equal(t, int64(2), change.Version)
+	assert.Contains(t, change.Changes, hdREsponse.DeviceFieldChange{Field: "name", Before: "Light"})
+}
+
+func TestHardDeleteHomeDevice_RecordsChangeOfDeletedDevice(t *testing.T) {
+	service := newHistoryServiceForTesting()
+
+	ctx := hdAudit.WithSource(context.Background(), hdAudit.SourceAdmin)
+	device, _ := service.CreateHomeDevice(ctx, request.CreateDeviceRequest{MAC: "00:11:22:33:44:55", Name: "Light", Type: "light", HomeID: "home1"})
+	_, _ = service.DeleteHomeDevice(ctx, device.ID, 0)
+
+	err := service.HardDeleteHomeDevice(ctx, device.ID, 0)
+	assert.Nil(t, err)
+
+	change := lastDeviceChange(t, service, device.ID)
+	assert.Equal(t, hdAudit.OperationHardDelete, change.Operation)
+	assert.Equal(t, hdAudit.SourceAdmin, change.Source)
+	assert.Empty(t, change.Changes)
+}
+
+func TestRestoreHomeDevice_RecordsChange(t *testing.T) {
+	service := newHistoryServiceForTesting()
+
+	ctx := context.Background()
+	device, _ := service.CreateHomeDevice(ctx, request.CreateDeviceRequest{MAC: "00:11:22:33:44:55", Name: "Light", Type: "light", HomeID: "home1"})
+	_, _ = service.DeleteHomeDevice(ctx, device.ID, 0)
+
+	_, err := service.RestoreHomeDevice(ctx, device.ID)
+	assert.Nil(t, err)
+
+	change := lastDeviceChange(t, service, device.ID)
+	assert.Equal(t, hdAudit.OperationRestore, change.Operation)
+	assert.Equal(t, int64(3), change.Version)
+}
+
+func TestHomeDeviceService_PublishesEvents(t *testing.T) {
+	homeDao := dao.NewInMemoryHomeDao()
+	roomDao := dao.NewInMemoryRoomDao()
+	publisher := event.NewInMemoryEventPublisher()
+	service := NewHomeDeviceServiceImpl2(dao.NewInMemoryHomeDeviceDao(), WithHomeDao(homeDao), WithRoomDao(roomDao), WithEventPublisher(publisher))
+
+	ctx := hdAudit.WithSource(hdAudit.WithActor(context.Background(), "user-1"), hdAudit.SourceAPI)
+	home, _ := homeDao.SaveHome(ctx, request.CreateHomeRequest{Name: "Home", Timezone: "UTC"})
+	otherHome, _ := homeDao.SaveHome(ctx, request.CreateHomeRequest{Name: "Other Home", Timezone: "UTC"})
+
+	device, err := service.CreateHomeDevice(ctx, request.CreateDeviceRequest{MAC: "00:11:22:33:44:55", Name: "Light", Type: "light", HomeID: home.ID})
+	assert.Nil(t, err)
+	_, err = service.UpdateHomeDevice(ctx, request.UpdateDeviceRequest{Name: "Living Room Light"}, device.ID, 0)
+	assert.Nil(t, err)
+	_, err = service.UpdateHomeDevice(ctx, request.UpdateDeviceRequest{HomeID: otherHome.ID}, device.ID, 0)
+	assert.Nil(t, err)
+	_, err = service.DeleteHomeDevice(ctx, device.ID, 0)
+	assert.Nil(t, err)
+	_, err = service.RestoreHomeDevice(ctx, device.ID)
+	assert.Nil(t, err)
+
+	events := publisher.Events()
+	assert.Len(t, events, 5)
+
+	types := []string{}
+	for _, deviceEvent := range events {
+		types = append(types, deviceEvent.Type)
+		assert.Equal(t, event.SpecVersion, deviceEvent.SpecVersion)
+		assert.Equal(t, event.Source, deviceEvent.Source)
+		assert.Equal(t, device.ID, deviceEvent.Subject)
+		assert.NotEmpty(t, deviceEvent.ID)
+		assert.Equal(t, "user-1", deviceEvent.Data.Actor)
+		assert.Equal(t, hdAudit.SourceAPI, deviceEvent.Data.Source)
+	}
+	assert.Equal(t, []string{event.TypeDeviceCreated, event.TypeDeviceUpdated, event.TypeDeviceMoved, event.TypeDeviceDeleted, event.TypeDeviceCreated}, types)
+
+	created := events[0].Data
+	assert.Nil(t, created.Before)
+	assert.Equal(t, "Light", created.After.Name)
+
+	updated := events[1].Data
+	assert.Equal(t, "Light", updated.Before.Name)
+	assert.Equal(t, "Living Room Light", updated.After.Name)
+	assert.Equal(t, int64(2), updated.After.Version)
+
+	moved := events[2].Data
+	assert.Equal(t, home.ID, moved.Before.HomeID)
+	assert.Equal(t, otherHome.ID, moved.After.HomeID)
+
+	deleted := events[3].Data
+	assert.Equal(t, otherHome.ID, deleted.Before.HomeID)
+	assert.Nil(t, deleted.After)
+
+	restored := events[4].Data
+	assert.Equal(t, hdAudit.OperationRestore, restored.Operation)
+	assert.Nil(t, restored.Before)
+	assert.Equal(t, otherHome.ID, restored.After.HomeID)
+}
+
+func TestHomeDeviceService_FailedChangeDoesNotPublishEvent(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	publisher := event.NewInMemoryEventPublisher()
+	service := NewHomeDeviceServiceImpl2(mockDao, WithEventPublisher(publisher))
+
+	ctx := context.Background()
+	deviceRequest := request.UpdateDeviceRequest{Name: "Living Room Light"}
+
+	mockDao.On("GetHomeDevice", ctx, "id").Return(&hdREsponse.HomdeDeviceResponse{ID: "id", Version: 4}, nil)
+	mockDao.On("UpdateHomeDevice", ctx, deviceRequest, "id", int64(3)).Return(nil, hdError.ErrVersionConflict.New())
+
+	_, err := service.UpdateHomeDevice(ctx, deviceRequest, "id", 3)
+
+	assert.ErrorIs(t, err, hdError.ErrVersionConflict)
+	assert.Empty(t, publisher.Events())
+}
+
+func TestHomeDeviceService_PublishErrorIsNotReturned(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	mockPublisher := new(hdMock.MockEventPublisher)
+	service := NewHomeDeviceServiceImpl2(mockDao, WithEventPublisher(mockPublisher))
+
+	ctx := context.Background()
+
+	mockDao.On("GetHomeDevice", ctx, "id").Return(&hdREsponse.HomdeDeviceResponse{ID: "id", HomeID: "home1", Version: 2}, nil)
+	mockDao.On("DeleteHomeDevice", ctx, "id", int64(2)).Return(&hdREsponse.HomdeDeviceResponse{ID: "id", Version: 3}, nil)
+	mockPublisher.On("Publish", ctx, mock.MatchedBy(func(deviceEvent event.DeviceEvent) bool {
+		return deviceEvent.Type == event.TypeDeviceDeleted && deviceEvent.HomeID() == "home1"
+	})).Return(errors.New("throttled"))
+
+	_, err := service.DeleteHomeDevice(ctx, "id", 0)
+
+	assert.Nil(t, err)
+	mockPublisher.AssertExpectations(t)
+}
+
+func TestHomeDeviceService_EveryChangePublishesOneEvent(t *testing.T) {
+
+	now := time.Now()
+
+	changes := []struct {
+		name      string
+		eventType string
+		// prepare brings the device to the state the change needs, its events
+		// are not counted
+		prepare func(ctx context.Context, service HomeDeviceService, device *hdREsponse.HomdeDeviceResponse) error
+		change  func(ctx context.Context, service HomeDeviceService, device *hdREsponse.HomdeDeviceResponse, otherHomeId string) error
+	}{
+		{
+			name:      "CreateHomeDevice",
+			eventType: event.TypeDeviceCreated,
+			change: func(ctx context.Context, service HomeDeviceService, device *hdREsponse.HomdeDeviceResponse, otherHomeId string) error {
+				_, err := service.CreateHomeDevice(ctx, request.CreateDeviceRequest{MAC: "00:11:22:33:44:66", Name: "Lamp", Type: "light", HomeID: device.HomeID})
+				return err
+			},
+		},
+		{
+			name:      "UpdateHomeDevice",
+			eventType: event.TypeDeviceUpdated,
+			change: func(ctx context.Context, service HomeDeviceService, device *hdREsponse.HomdeDeviceResponse, otherHomeId string) error {
+				_, err := service.UpdateHomeDevice(ctx, request.UpdateDeviceRequest{Name: "Living Room Light"}, device.ID, 0)
+				return err
+			},
+		},
+		{
+			name:      "UpdateHomeDevice to another home",
+			eventType: event.TypeDeviceMoved,
+			change: func(ctx context.Context, service HomeDeviceService, device *hdREsponse.HomdeDeviceResponse, otherHomeId string) error {
+				_, err := service.UpdateHomeDevice(ctx, request.UpdateDeviceRequest{HomeID: otherHomeId}, device.ID, device.Version)
+				return err
+			},
+		},
+		{
+			name:      "DeleteHomeDevice",
+			eventType: event.TypeDeviceDeleted,
+			change: func(ctx context.Context, service HomeDeviceService, device *hdREsponse.HomdeDeviceResponse, otherHomeId string) error {
+				_, err := service.DeleteHomeDevice(ctx, device.ID, 0)
+				return err
+			},
+		},
+		{
+			name:      "HardDeleteHomeDevice",
+			eventType: event.TypeDeviceDeleted,
+			change: func(ctx context.Context, service HomeDeviceService, device *hdREsponse.HomdeDeviceResponse, otherHomeId string) error {
+				return service.HardDeleteHomeDevice(ctx, device.ID, 0)
+			},
+		},
+		{
+			name:      "HardDeleteHomeDevice of a deleted device",
+			eventType: event.TypeDeviceDeleted,
+			prepare: func(ctx context.Context, service HomeDeviceService, device *hdREsponse.HomdeDeviceResponse) error {
+				_, err := service.DeleteHomeDevice(ctx, device.ID, 0)
+				return err
+			},
+			change: func(ctx context.Context, service HomeDeviceService, device *hdREsponse.HomdeDeviceResponse, otherHomeId string) error {
+				return service.HardDeleteHomeDevice(ctx, device.ID, 0)
+			},
+		},
+		{
+			name:      "RestoreHomeDevice",
+			eventType: event.TypeDeviceCreated,
+			prepare: func(ctx context.Context, service HomeDeviceService, device *hdREsponse.HomdeDeviceResponse) error {
+				_, err := service.DeleteHomeDevice(ctx, device.ID, 0)
+				return err
+			},
+			change: func(ctx context.Context, service HomeDeviceService, device *hdREsponse.HomdeDeviceResponse, otherHomeId string) error {
+				_, err := service.RestoreHomeDevice(ctx, device.ID)
+				return err
+			},
+		},
+		{
+			name:      "MarkDeviceSeen",
+			eventType: event.TypeDeviceStatusChanged,
+			change: func(ctx context.Context, service HomeDeviceService, device *hdREsponse.HomdeDeviceResponse, otherHomeId string) error {
+				_, err := service.MarkDeviceSeen(ctx, device.ID, now)
+				return err
+			},
+		},
+		{
+			name:      "MarkOfflineDevices",
+			eventType: event.TypeDeviceStatusChanged,
+			prepare: func(ctx context.Context, service HomeDeviceService, device *hdREsponse.HomdeDeviceResponse) error {
+				_, err := service.MarkDeviceSeen(ctx, device.ID, now.Add(-time.Hour))
+				return err
+			},
+			change: func(ctx context.Context, service HomeDeviceService, device *hdREsponse.HomdeDeviceResponse, otherHomeId string) error {
+				_, err := service.MarkOfflineDevices(ctx, now)
+				return err
+			},
+		},
+	}
+
+	for _, tc := range changes {
+		t.Run(tc.name, func(t *testing.T) {
+			homeDao := dao.NewInMemoryHomeDao()
+			publisher := event.NewInMemoryEventPublisher()
+			deviceHistoryDao := dao.NewInMemoryDeviceHistoryDao()
+			service := NewHomeDeviceServiceImpl2(dao.NewInMemoryHomeDeviceDaoWithHistory(deviceHistoryDao), WithDeviceHistoryDao(deviceHistoryDao), WithHomeDao(homeDao), WithEventPublisher(publisher))
+
+			ctx := context.Background()
+			home, _ := homeDao.SaveHome(ctx, request.CreateHomeRequest{Name: "Home", Timezone: "UTC"})
+			otherHome, _ := homeDao.SaveHome(ctx, request.CreateHomeRequest{Name: "Other Home", Timezone: "UTC"})
+
+			device, err := service.CreateHomeDevice(ctx, request.CreateDeviceRequest{MAC: "00:11:22:33:44:55", Name: "Light", Type: "light", HomeID: home.ID})
+			assert.Nil(t, err)
+
+			if tc.prepare != nil {
+				assert.Nil(t, tc.prepare(ctx, service, device))
+			}
+
+			published := len(publisher.Events())
+
+			assert.Nil(t, tc.change(ctx, service, device, otherHome.ID))
+
+			events := publisher.Events()[published:]
+			if assert.Len(t, events, 1) {
+				assert.Equal(t, tc.eventType, events[0].Type)
+			}
+		})
+	}
+}
+
+func TestHomeDeviceService_UpdateEventHasTheStoredDevice(t *testing.T) {
+	homeDao := dao.NewInMemoryHomeDao()
+	roomDao := dao.NewInMemoryRoomDao()
+	publisher := event.NewInMemoryEventPublisher()
+	service := NewHomeDeviceServiceImpl2(dao.NewInMemoryHomeDeviceDao(), WithHomeDao(homeDao), WithRoomDao(roomDao), WithEventPublisher(publisher))
+
+	ctx := context.Background()
+	home, _ := homeDao.SaveHome(ctx, request.CreateHomeRequest{Name: "Home", Timezone: "UTC"})
+	otherHome, _ := homeDao.SaveHome(ctx, request.CreateHomeRequest{Name: "Other Home", Timezone: "UTC"})
+	room, _ := roomDao.SaveRoom(ctx, home.ID, request.CreateRoomRequest{Name: "Living Room"})
+
+	device, err := service.CreateHomeDevice(ctx, request.CreateDeviceRequest{MAC: "00:11:22:33:44:55", Name: "Light", Type: "light", HomeID: home.ID, RoomID: room.ID})
+	assert.Nil(t, err)
+
+	_, err = service.UpdateHomeDevice(ctx, request.UpdateDeviceRequest{HomeID: otherHome.ID}, device.ID, 0)
+	assert.Nil(t, err)
+
+	stored, err := service.GetHomeDevice(ctx, device.ID)
+	assert.Nil(t, err)
+
+	events := publisher.Events()
+	assert.Equal(t, stored, events[len(events)-1].Data.After)
+	assert.Empty(t, stored.RoomID)
+}
+
+func TestGetDeviceHistory_Success(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	mockHistoryDao := new(hdMock.MockDeviceHistoryDao)
+	service := NewHomeDeviceServiceImpl2(mockDao, WithDeviceHistoryDao(mockHistoryDao))
+
+	ctx := context.Background()
+
+	mockHistoryDao.On("ListDeviceHistory", ctx, "id", int32(10), "").Return(&hdREsponse.DeviceHistoryResponse{
+		Changes:    []hdREsponse.DeviceChangeResponse{{DeviceID: "id", Operation: hdAudit.OperationCreate}},
+		NextCursor: "cursor",
+	}, nil)
+
+	response, err := service.GetDeviceHistory(ctx, "id", 10, "")
+
+	assert.Nil(t, err)
+	assert.Len(t, response.Changes, 1)
+	assert.Equal(t, "cursor", response.NextCursor)
+}
+
+func TestGetDeviceHistory_NotConfigured(t *testing.T) {
+	service := NewHomeDeviceServiceImpl2(new(hdMock.MockHomeDeviceDao))
 
-	err := service.DeleteHomeDevice(ctx, "id")
+	_, err := service.GetDeviceHistory(context.Background(), "id", 10, "")
 
 	assert.NotNil(t, err)
-	assert.Equal(t, "delete_error", err.ErrorCode)
+	assert.ErrorIs(t, err, hdError.ErrListingDeviceHistory)
 }
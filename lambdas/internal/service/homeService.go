@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	constants "github.com/odhoman/home-devices/internal/constants"
+	dao "github.com/odhoman/home-devices/internal/dao"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+type HomeService interface {
+	CreateHome(ctx context.Context, home request.CreateHomeRequest) (*response.HomeResponse, error)
+	GetHome(ctx context.Context, id string) (*response.HomeResponse, error)
+	UpdateHome(ctx context.Context, home request.UpdateHomeRequest, id string, expectedVersion int64) error
+	DeleteHome(ctx context.Context, id string, deleteRequest request.DeleteHomeRequest, expectedVersion int64) error
+}
+
+type HomeServiceImpl struct {
+	homeDao       dao.HomeDao
+	deviceService HomeDeviceService
+	roomDao       dao.RoomDao
+	ruleDao       dao.RuleDao
+	scheduleDao   dao.ScheduleDao
+	sceneDao      dao.SceneDao
+}
+
+func (hSI HomeServiceImpl) CreateHome(ctx context.Context, home request.CreateHomeRequest) (*response.HomeResponse, error) {
+
+	if err := hSI.checkHomeDao(hdError.ErrHomeNotCreated); err != nil {
+		return nil, err
+	}
+
+	return hSI.homeDao.SaveHome(ctx, home)
+}
+
+func (hSI HomeServiceImpl) GetHome(ctx context.Context, id string) (*response.HomeResponse, error) {
+
+	if err := hSI.checkHomeDao(hdError.ErrGettingHome); err != nil {
+		return nil, err
+	}
+
+	return hSI.homeDao.GetHome(ctx, id)
+}
+
+func (hSI HomeServiceImpl) UpdateHome(ctx context.Context, home request.UpdateHomeRequest, id string, expectedVersion int64) error {
+
+	if home.Name == "" && home.Timezone == "" && home.Address == "" && home.Owner == "" {
+		return hdError.ErrNoFieldToUpdate.New()
+	}
+
+	if err := hSI.checkHomeDao(hdError.ErrUpdatingHome); err != nil {
+		return err
+	}
+
+	return hSI.homeDao.UpdateHome(ctx, home, id, expectedVersion)
+}
+
+// DeleteHome deletes a home after deleting (cascade) or moving (reassign) its
+// devices.
+func (hSI HomeServiceImpl) DeleteHome(ctx context.Context, id string, deleteRequest request.DeleteHomeRequest, expectedVersion int64) error {
+
+	if err := hSI.checkHomeDao(hdError.ErrDeletingHome); err != nil {
+		return err
+	}
+
+	home, err := hSI.homeDao.GetHome(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if expectedVersion > 0 && home.Version != expectedVersion {
+		return hdError.ErrVersionConflict.WithMessage(hdError.HomeVersionConflictMessage)
+	}
+
+	if deleteRequest.Strategy == constants.DeleteHomeStrategyReassign {
+		if deleteRequest.TargetHomeID == id {
+			return hdError.ErrInvalidTargetHome.New()
+		}
+
+		if err := checkHomeExists(ctx, hSI.homeDao, deleteRequest.TargetHomeID, hdError.ErrUnknownTargetHome); err != nil {
+			return err
+		}
+	}
+
+	deviceIds, err := listAllHomeDeviceIds(ctx, hSI.deviceService, id, "")
+	if err != nil {
+		return err
+	}
+
+	if len(deviceIds) > 0 && deleteRequest.Strategy == "" {
+		return hdError.ErrHomeHasDevices.New()
+	}
+
+	for _, deviceId := range deviceIds {
+
+		var err error
+		switch deleteRequest.Strategy {
+		case constants.DeleteHomeStrategyCascade:
+			_, err = hSI.deviceService.DeleteHomeDevice(ctx, deviceId, 0)
+		case constants.DeleteHomeStrategyReassign:
+			_, err = hSI.deviceService.UpdateHomeDevice(ctx, request.UpdateDeviceRequest{HomeID: deleteRequest.TargetHomeID}, deviceId, 0)
+			if err != nil && errors.Is(err, hdError.ErrDeviceAlreadyExists) {
+				inTargetHomeError := hdError.ErrDeviceInTargetHome.New().WithDetail("deviceId", deviceId).WithDetail("targetHomeId", deleteRequest.TargetHomeID)
+				if conflictingDeviceId, ok := hdError.From(err).Details["deviceId"]; ok {
+					inTargetHomeError.WithDetail("conflictingDeviceId", conflictingDeviceId)
+				}
+				err = inTargetHomeError
+			}
+		}
+
+		// a device deleted in the meantime is already out of the home
+		if err != nil && !errors.Is(err, hdError.ErrDeviceNotFound) {
+			log.Printf("Error applying the %v strategy to the device %v of the home %v: %v", deleteRequest.Strategy, deviceId, id, err)
+			return err
+		}
+	}
+
+	if err := deleteAllRooms(ctx, hSI.roomDao, id); err != nil {
+		return err
+	}
+
+	if err := deleteAllRules(ctx, hSI.ruleDao, id); err != nil {
+		return err
+	}
+
+	if err := deleteAllSchedules(ctx, hSI.scheduleDao, id); err != nil {
+		return err
+	}
+
+	if err := deleteAllScenes(ctx, hSI.sceneDao, id); err != nil {
+		return err
+	}
+
+	return hSI.homeDao.DeleteHome(ctx, id, expectedVersion)
+}
+
+// listAllHomeDeviceIds reads every page of the devices of a home, or of one of
+// its rooms, before they are changed, so deleting or moving them does not
+// shift the pages.
+func listAllHomeDeviceIds(ctx context.Context, deviceService HomeDeviceService, homeId string, roomId string) ([]string, error) {
+
+	devices, err := listAllHomeDevices(ctx, deviceService, homeId, roomId)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceIds := []string{}
+	for _, device := range devices {
+		deviceIds = append(deviceIds, device.ID)
+	}
+
+	return deviceIds, nil
+}
+
+// listAllHomeDevices reads every page of the devices of a home, or of one of
+// its rooms.
+func listAllHomeDevices(ctx context.Context, deviceService HomeDeviceService, homeId string, roomId string) ([]response.HomdeDeviceResponse, error) {
+
+	devices := []response.HomdeDeviceResponse{}
+	cursor := ""
+
+	for {
+		page, err := deviceService.ListHomeDevices(ctx, homeId, roomId, "", constants.MaxListDevicesLimit, cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		devices = append(devices, page.Devices...)
+
+		if page.NextCursor == "" {
+			return devices, nil
+		}
+
+		cursor = page.NextCursor
+	}
+}
+
+// checkHomeExists rejects a homeId that is not a registered home with the error
+// of unknownHome, a home of the path is not found while a home referenced by a
+// request is not valid.
+func checkHomeExists(ctx context.Context, homeDao dao.HomeDao, homeId string, unknownHome hdError.Definition) error {
+
+	if homeDao == nil {
+		return nil
+	}
+
+	_, err := homeDao.GetHome(ctx, homeId)
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, hdError.ErrHomeNotFound) {
+		log.Printf("There is no home with id %v", homeId)
+		return unknownHome.New().WithDetail("homeId", homeId)
+	}
+
+	return err
+}
+
+func (hSI HomeServiceImpl) checkHomeDao(errorDefinition hdError.Definition) error {
+
+	if hSI.homeDao == nil {
+		log.Printf("The homes are not configured")
+		return errorDefinition.New()
+	}
+
+	return nil
+}
+
+// NewHomeServiceImplFromConfig uses the DynamoDB daos.
+func NewHomeServiceImplFromConfig(cfg aws.Config) HomeService {
+	client := dynamodb.NewFromConfig(cfg)
+	return NewHomeServiceImpl(dao.HomeDaoImpl{DynamoDbApi: client}, newHomeDeviceServiceFromConfig(cfg, client), dao.RoomDaoImpl{DynamoDbApi: client},
+		dao.RuleDaoImpl{DynamoDbApi: client}, dao.ScheduleDaoImpl{DynamoDbApi: client}, dao.SceneDaoImpl{DynamoDbApi: client})
+}
+
+// NewHomeServiceImpl deletes or moves the devices of a deleted home with the
+// deviceService, and deletes its rooms, rules, schedules and scenes with the
+// daos that are not nil.
+func NewHomeServiceImpl(homeDao dao.HomeDao, deviceService HomeDeviceService, roomDao dao.RoomDao, ruleDao dao.RuleDao, scheduleDao dao.ScheduleDao, sceneDao dao.SceneDao) HomeService {
+	return HomeServiceImpl{homeDao: homeDao, deviceService: deviceService, roomDao: roomDao, ruleDao: ruleDao, scheduleDao: scheduleDao, sceneDao: sceneDao}
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/constants"
+	"github.com/odhoman/home-devices/internal/dao"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	hdMock "github.com/odhoman/home-devices/internal/mock"
+	"github.com/odhoman/home-devices/internal/request"
+	hdREsponse "github.com/odhoman/home-devices/internal/response"
+	"github.com/stretchr/testify/assert"
+	mock "github.com/stretchr/testify/mock"
+)
+
+func TestCreateHomeDevice_UnknownHome(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	mockHomeDao := new(hdMock.MockHomeDao)
+	service := HomeDeviceServiceImpl{homeDeviceDao: mockDao, homeDao: mockHomeDao}
+
+	ctx := context.Background()
+	deviceRequest := request.CreateDeviceRequest{MAC: "00:11:22:33:44:55", HomeID: "home1"}
+
+	mockHomeDao.On("GetHome", ctx, "home1").Return(nil, hdError.ErrHomeNotFound.New())
+
+	_, err := service.CreateHomeDevice(ctx, deviceRequest)
+
+	assert.ErrorIs(t, err, hdError.ErrUnknownHome)
+	mockDao.AssertNotCalled(t, "SaveHomeDevice", mock.Anything, mock.Anything)
+}
+
+func TestCreateHomeDevice_ErrorGettingHome(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	mockHomeDao := new(hdMock.MockHomeDao)
+	service := HomeDeviceServiceImpl{homeDeviceDao: mockDao, homeDao: mockHomeDao}
+
+	ctx := context.Background()
+	deviceRequest := request.CreateDeviceRequest{MAC: "00:11:22:33:44:55", HomeID: "home1"}
+
+	mockHomeDao.On("GetHome", ctx, "home1").Return(nil, hdError.ErrGettingHome.New())
+
+	_, err := service.CreateHomeDevice(ctx, deviceRequest)
+
+	assert.ErrorIs(t, err, hdError.ErrGettingHome)
+	mockDao.AssertNotCalled(t, "SaveHomeDevice", mock.Anything, mock.Anything)
+}
+
+func TestCreateHomeDevice_KnownHome(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	mockHomeDao := new(hdMock.MockHomeDao)
+	service := HomeDeviceServiceImpl{homeDeviceDao: mockDao, homeDao: mockHomeDao}
+
+	ctx := context.Background()
+	deviceRequest := request.CreateDeviceRequest{MAC: "00:11:22:33:44:55", HomeID: "home1"}
+
+	mockHomeDao.On("GetHome", ctx, "home1").Return(&hdREsponse.HomeResponse{ID: "home1"}, nil)
+	mockDao.On("SaveHomeDevice", ctx, deviceRequest).Return(&hdREsponse.HomdeDeviceResponse{ID: "id"}, nil)
+
+	device, err := service.CreateHomeDevice(ctx, deviceRequest)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "id", device.ID)
+}
+
+func TestUpdateHomeDevice_UnknownHome(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	mockHomeDao := new(hdMock.MockHomeDao)
+	service := HomeDeviceServiceImpl{homeDeviceDao: mockDao, homeDao: mockHomeDao}
+
+	ctx := context.Background()
+
+	mockHomeDao.On("GetHome", ctx, "home2").Return(nil, hdError.ErrHomeNotFound.New())
+
+	_, err := service.UpdateHomeDevice(ctx, request.UpdateDeviceRequest{HomeID: "home2"}, "id", 0)
+
+	assert.ErrorIs(t, err, hdError.ErrUnknownHome)
+	mockDao.AssertNotCalled(t, "UpdateHomeDevice", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateHomeDevice_WithoutHomeIdDoesNotCheckTheHome(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	mockHomeDao := new(hdMock.MockHomeDao)
+	service := HomeDeviceServiceImpl{homeDeviceDao: mockDao, homeDao: mockHomeDao}
+
+	ctx := context.Background()
+	deviceRequest := request.UpdateDeviceRequest{Name: "Kitchen Light"}
+
+	mockDao.On("UpdateHomeDevice", ctx, deviceRequest, "id", int64(0)).Return(&hdREsponse.HomdeDeviceResponse{ID: "id"}, nil)
+
+	_, err := service.UpdateHomeDevice(ctx, deviceRequest, "id", 0)
+
+	assert.Nil(t, err)
+	mockHomeDao.AssertNotCalled(t, "GetHome", mock.Anything, mock.Anything)
+}
+
+func TestCreateHome_NotConfigured(t *testing.T) {
+	service := HomeServiceImpl{}
+
+	_, err := service.CreateHome(context.Background(), request.CreateHomeRequest{Name: "Beach House"})
+
+	assert.ErrorIs(t, err, hdError.ErrHomeNotCreated)
+}
+
+func TestUpdateHome_NoFields(t *testing.T) {
+	mockHomeDao := new(hdMock.MockHomeDao)
+	service := HomeServiceImpl{homeDao: mockHomeDao}
+
+	err := service.UpdateHome(context.Background(), request.UpdateHomeRequest{}, "home1", 0)
+
+	assert.ErrorIs(t, err, hdError.ErrNoFieldToUpdate)
+	mockHomeDao.AssertNotCalled(t, "UpdateHome", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDeleteHome_WithoutDevices(t *testing.T) {
+	service, home, _ := newHomeServiceForTesting(t, 0)
+
+	err := service.DeleteHome(context.Background(), home.ID, request.DeleteHomeRequest{}, 1)
+	assert.Nil(t, err)
+
+	_, err = service.GetHome(context.Background(), home.ID)
+	assert.ErrorIs(t, err, hdError.ErrHomeNotFound)
+}
+
+func TestDeleteHome_WithDevicesNeedsAStrategy(t *testing.T) {
+	service, home, devices := newHomeServiceForTesting(t, 2)
+	ctx := context.Background()
+
+	err := service.DeleteHome(ctx, home.ID, request.DeleteHomeRequest{}, 0)
+	assert.ErrorIs(t, err, hdError.ErrHomeHasDevices)
+
+	_, err = service.GetHome(ctx, home.ID)
+	assert.Nil(t, err)
+
+	_, err = service.GetHomeDevice(ctx, devices[0].ID)
+	assert.Nil(t, err)
+}
+
+func TestDeleteHome_Cascade(t *testing.T) {
+	service, home, devices := newHomeServiceForTesting(t, 3)
+	ctx := context.Background()
+
+	err := service.DeleteHome(ctx, home.ID, request.DeleteHomeRequest{Strategy: constants.DeleteHomeStrategyCascade}, 0)
+	assert.Nil(t, err)
+
+	for _, device := range devices {
+		_, err := service.GetHomeDevice(ctx, device.ID)
+		assert.ErrorIs(t, err, hdError.ErrDeviceNotFound)
+	}
+
+	_, err = service.GetHome(ctx, home.ID)
+	assert.ErrorIs(t, err, hdError.ErrHomeNotFound)
+}
+
+func TestDeleteHome_Reassign(t *testing.T) {
+	service, home, devices := newHomeServiceForTesting(t, 3)
+	ctx := context.Background()
+
+	target, _ := service.CreateHome(ctx, request.CreateHomeRequest{Name: "Mountain House", Timezone: "UTC", Owner: "user-1"})
+
+	err := service.DeleteHome(ctx, home.ID, request.DeleteHomeRequest{Strategy: constants.DeleteHomeStrategyReassign, TargetHomeID: target.ID}, 0)
+	assert.Nil(t, err)
+
+	for _, device := range devices {
+		moved, err := service.GetHomeDevice(ctx, device.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, target.ID, moved.HomeID)
+	}
+
+	_, err = service.GetHome(ctx, home.ID)
+	assert.ErrorIs(t, err, hdError.ErrHomeNotFound)
+}
+
+func TestDeleteHome_ReassignToUnknownHome(t *testing.T) {
+	service, home, devices := newHomeServiceForTesting(t, 1)
+	ctx := context.Background()
+
+	err := service.DeleteHome(ctx, home.ID, request.DeleteHomeRequest{Strategy: constants.DeleteHomeStrategyReassign, TargetHomeID: "home99999"}, 0)
+	assert.ErrorIs(t, err, hdError.ErrUnknownTargetHome)
+
+	device, _ := service.GetHomeDevice(ctx, devices[0].ID)
+	assert.Equal(t, home.ID, device.HomeID)
+}
+
+func TestDeleteHome_ReassignToHomeWithTheSameDevice(t *testing.T) {
+	service, home, devices := newHomeServiceForTesting(t, 1)
+	ctx := context.Background()
+
+	target, _ := service.CreateHome(ctx, request.CreateHomeRequest{Name: "Mountain House", Timezone: "UTC", Owner: "user-1"})
+	service.CreateHomeDevice(ctx, request.CreateDeviceRequest{MAC: devices[0].MAC, Name: "Kitchen Light", Type: "light", HomeID: target.ID})
+
+	err := service.DeleteHome(ctx, home.ID, request.DeleteHomeRequest{Strategy: constants.DeleteHomeStrategyReassign, TargetHomeID: target.ID}, 0)
+	assert.ErrorIs(t, err, hdError.ErrDeviceInTargetHome)
+
+	_, err = service.GetHome(ctx, home.ID)
+	assert.Nil(t, err)
+}
+
+func TestDeleteHome_ReassignToItself(t *testing.T) {
+	service, home, _ := newHomeServiceForTesting(t, 1)
+
+	err := service.DeleteHome(context.Background(), home.ID, request.DeleteHomeRequest{Strategy: constants.DeleteHomeStrategyReassign, TargetHomeID: home.ID}, 0)
+
+	assert.ErrorIs(t, err, hdError.ErrInvalidTargetHome)
+}
+
+func TestDeleteHome_VersionConflict(t *testing.T) {
+	service, home, devices := newHomeServiceForTesting(t, 1)
+	ctx := context.Background()
+
+	err := service.DeleteHome(ctx, home.ID, request.DeleteHomeRequest{Strategy: constants.DeleteHomeStrategyCascade}, 2)
+	assert.ErrorIs(t, err, hdError.ErrVersionConflict)
+
+	_, err = service.GetHomeDevice(ctx, devices[0].ID)
+	assert.Nil(t, err)
+}
+
+func TestDeleteHome_NotFound(t *testing.T) {
+	service, _, _ := newHomeServiceForTesting(t, 0)
+
+	err := service.DeleteHome(context.Background(), "home99999", request.DeleteHomeRequest{}, 0)
+
+	assert.ErrorIs(t, err, hdError.ErrHomeNotFound)
+}
+
+// homeTestService changes the homes and their devices.
+type homeTestService struct {
+	HomeService
+	HomeDeviceService
+}
+
+// newHomeServiceForTesting returns a service with in-memory daos and a home
+// with the given number of devices.
+func newHomeServiceForTesting(t *testing.T, devicesCount int) (homeTestService, *hdREsponse.HomeResponse, []*hdREsponse.HomdeDeviceResponse) {
+	t.Helper()
+
+	homeDao := dao.NewInMemoryHomeDao()
+	deviceService := NewHomeDeviceServiceImpl2(dao.NewInMemoryHomeDeviceDao(), WithHomeDao(homeDao))
+	service := homeTestService{HomeService: NewHomeServiceImpl(homeDao, deviceService, nil, nil, nil, nil), HomeDeviceService: deviceService}
+	ctx := context.Background()
+
+	home, err := service.CreateHome(ctx, request.CreateHomeRequest{Name: "Beach House", Timezone: "Europe/Madrid", Owner: "user-1"})
+	if err != nil {
+		t.Fatalf("Unexpected error creating the home for testing. Error: %v", err)
+	}
+
+	devices := []*hdREsponse.HomdeDeviceResponse{}
+	for i := 0; i < devicesCount; i++ {
+		device, err := service.CreateHomeDevice(ctx, request.CreateDeviceRequest{
+			MAC:    fmt.Sprintf("00:11:22:33:44:%02d", i),
+			Name:   "Living Room Light",
+			Type:   "light",
+			HomeID: home.ID,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error creating the device for testing. Error: %v", err)
+		}
+		devices = append(devices, device)
+	}
+
+	return service, home, devices
+}
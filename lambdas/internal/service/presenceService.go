@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	hdAudit "github.com/odhoman/home-devices/internal/audit"
+	capability "github.com/odhoman/home-devices/internal/capability"
+	constants "github.com/odhoman/home-devices/internal/constants"
+	response "github.com/odhoman/home-devices/internal/response"
+)
+
+// MarkDeviceSeen records that the device sent telemetry or a heartbeat at
+// seenAt.
+func (hDDI HomeDeviceServiceImpl) MarkDeviceSeen(ctx context.Context, id string, seenAt time.Time) (bool, error) {
+
+	online, err := hDDI.homeDeviceDao.MarkHomeDeviceSeen(ctx, id, seenAt.Unix())
+	if err != nil {
+		return false, err
+	}
+
+	if online == nil {
+		return false, nil
+	}
+
+	offline := *online
+	offline.Status = constants.DeviceStatusOffline
+	hDDI.publishStatusChange(ctx, &offline, online)
+
+	return true, nil
+}
+
+// MarkOfflineDevices marks offline the online devices that were not seen for
+// longer than the presence timeout of their type, and records each of them in
+// the device history.
+func (hDDI HomeDeviceServiceImpl) MarkOfflineDevices(ctx context.Context, now time.Time) ([]response.HomdeDeviceResponse, error) {
+
+	marked := []response.HomdeDeviceResponse{}
+
+	// the shortest timeout finds the candidates of every type, the timeout
+	// of each one is checked below
+	devices, err := hDDI.homeDeviceDao.ListStaleHomeDevices(ctx, now.Add(-capability.MinOfflineAfter()).Unix())
+	if err != nil {
+		return marked, err
+	}
+
+	for _, device := range devices {
+
+		if now.Sub(time.Unix(device.LastSeenAt, 0)) <= capability.OfflineAfter(device.Type) {
+			continue
+		}
+
+		wentOffline, err := hDDI.homeDeviceDao.MarkHomeDeviceOffline(ctx, device.ID, device.LastSeenAt)
+		if err != nil {
+			return marked, err
+		}
+
+		if !wentOffline {
+			continue
+		}
+
+		log.Printf("The %v device %v of the home %v is offline, it was last seen at %v", device.Type, device.ID, device.HomeID, device.LastSeenAt)
+
+		online := device
+		device.Status = constants.DeviceStatusOffline
+		hDDI.publishStatusChange(ctx, &online, &device)
+		marked = append(marked, device)
+	}
+
+	return marked, nil
+}
+
+func (hDDI HomeDeviceServiceImpl) publishStatusChange(ctx context.Context, before *response.HomdeDeviceResponse, after *response.HomdeDeviceResponse) {
+	hDDI.publishDeviceEvent(hdAudit.WithSource(ctx, hdAudit.SourcePresence), hdAudit.OperationStatusChange, after.ID, before, after)
+}
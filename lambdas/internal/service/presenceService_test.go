@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	hdAudit "github.com/odhoman/home-devices/internal/audit"
+	"github.com/odhoman/home-devices/internal/constants"
+	"github.com/odhoman/home-devices/internal/dao"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	hdMock "github.com/odhoman/home-devices/internal/mock"
+	"github.com/odhoman/home-devices/internal/request"
+	hdREsponse "github.com/odhoman/home-devices/internal/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type presenceTestEnv struct {
+	service HomeDeviceService
+	light   *hdREsponse.HomdeDeviceResponse
+	sensor  *hdREsponse.HomdeDeviceResponse
+}
+
+func newPresenceServiceForTesting(t *testing.T) presenceTestEnv {
+	t.Helper()
+
+	deviceHistoryDao := dao.NewInMemoryDeviceHistoryDao()
+	service := NewHomeDeviceServiceImpl2(dao.NewInMemoryHomeDeviceDaoWithHistory(deviceHistoryDao), WithDeviceHistoryDao(deviceHistoryDao))
+	ctx := context.Background()
+
+	light, err := service.CreateHomeDevice(ctx, request.CreateDeviceRequest{MAC: "00:11:22:33:44:01", Name: "Living Room Light", Type: "light", HomeID: "home12345"})
+	assert.Nil(t, err)
+
+	sensor, err := service.CreateHomeDevice(ctx, request.CreateDeviceRequest{MAC: "00:11:22:33:44:02", Name: "Hall Sensor", Type: "sensor", HomeID: "home12345"})
+	assert.Nil(t, err)
+
+	return presenceTestEnv{service: service, light: light, sensor: sensor}
+}
+
+// statusChanges returns the status changes in the history of the device.
+func (env presenceTestEnv) statusChanges(t *testing.T, id string) []hdREsponse.DeviceChangeResponse {
+	t.Helper()
+
+	history, err := env.service.GetDeviceHistory(context.Background(), id, 0, "")
+	assert.Nil(t, err)
+
+	changes := []hdREsponse.DeviceChangeResponse{}
+	for _, change := range history.Changes {
+		if change.Operation == hdAudit.OperationStatusChange {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes
+}
+
+func TestMarkDeviceSeen_ComesOnline(t *testing.T) {
+	env := newPresenceServiceForTesting(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	cameOnline, err := env.service.MarkDeviceSeen(ctx, env.light.ID, now)
+
+	assert.Nil(t, err)
+	assert.True(t, cameOnline)
+
+	device, _ := env.service.GetHomeDevice(ctx, env.light.ID)
+	assert.Equal(t, constants.DeviceStatusOnline, device.Status)
+	assert.Equal(t, now.Unix(), device.LastSeenAt)
+
+	changes := env.statusChanges(t, env.light.ID)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, hdAudit.SourcePresence, changes[0].Source)
+	assert.Equal(t, []hdREsponse.DeviceFieldChange{{Field: "status", Before: "offline", After: "online"}}, changes[0].Changes)
+
+	// the next heartbeats do not change the status
+	cameOnline, err = env.service.MarkDeviceSeen(ctx, env.light.ID, now.Add(time.Minute))
+
+	assert.Nil(t, err)
+	assert.False(t, cameOnline)
+	assert.Len(t, env.statusChanges(t, env.light.ID), 1)
+}
+
+func TestMarkDeviceSeen_UnknownDevice(t *testing.T) {
+	env := newPresenceServiceForTesting(t)
+
+	_, err := env.service.MarkDeviceSeen(context.Background(), "unknown", time.Now())
+
+	assert.ErrorIs(t, err, hdError.ErrDeviceNotFound)
+}
+
+func TestMarkOfflineDevices_TimeoutOfTheType(t *testing.T) {
+	env := newPresenceServiceForTesting(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	// both were seen 10 minutes ago, the light times out after 5 and the
+	// sensor after 30
+	seenAt := now.Add(-10 * time.Minute)
+	env.service.MarkDeviceSeen(ctx, env.light.ID, seenAt)
+	env.service.MarkDeviceSeen(ctx, env.sensor.ID, seenAt)
+
+	marked, err := env.service.MarkOfflineDevices(ctx, now)
+
+	assert.Nil(t, err)
+	assert.Len(t, marked, 1)
+	assert.Equal(t, env.light.ID, marked[0].ID)
+	assert.Equal(t, constants.DeviceStatusOffline, marked[0].Status)
+
+	light, _ := env.service.GetHomeDevice(ctx, env.light.ID)
+	assert.Equal(t, constants.DeviceStatusOffline, light.Status)
+	assert.Equal(t, seenAt.Unix(), light.LastSeenAt)
+
+	sensor, _ := env.service.GetHomeDevice(ctx, env.sensor.ID)
+	assert.Equal(t, constants.DeviceStatusOnline, sensor.Status)
+
+	changes := env.statusChanges(t, env.light.ID)
+	assert.Len(t, changes, 2)
+	offline := changeToStatus(changes, constants.DeviceStatusOffline)
+	assert.Equal(t, hdAudit.SourcePresence, offline.Source)
+	assert.Equal(t, []hdREsponse.DeviceFieldChange{{Field: "status", Before: "online", After: "offline"}}, offline.Changes)
+
+	// the sweeper runs again and there is nothing left to mark
+	marked, err = env.service.MarkOfflineDevices(ctx, now.Add(time.Minute))
+
+	assert.Nil(t, err)
+	assert.Empty(t, marked)
+}
+
+func TestMarkOfflineDevices_ExactlyTheTimeout(t *testing.T) {
+	env := newPresenceServiceForTesting(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	env.service.MarkDeviceSeen(ctx, env.light.ID, now.Add(-5*time.Minute))
+
+	marked, err := env.service.MarkOfflineDevices(ctx, now)
+
+	assert.Nil(t, err)
+	assert.Empty(t, marked)
+}
+
+func TestMarkOfflineDevices_SeenWhileMarking(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	service := HomeDeviceServiceImpl{homeDeviceDao: mockDao}
+	ctx := context.Background()
+	now := time.Now()
+	device := hdREsponse.HomdeDeviceResponse{ID: "device1", Type: "light", Status: constants.DeviceStatusOnline, LastSeenAt: now.Add(-time.Hour).Unix()}
+
+	mockDao.On("ListStaleHomeDevices", ctx, mock.Anything).Return([]hdREsponse.HomdeDeviceResponse{device}, nil)
+	mockDao.On("MarkHomeDeviceOffline", ctx, "device1", device.LastSeenAt).Return(false, nil)
+
+	marked, err := service.MarkOfflineDevices(ctx, now)
+
+	assert.Nil(t, err)
+	assert.Empty(t, marked)
+}
+
+func TestMarkOfflineDevices_Error(t *testing.T) {
+	mockDao := new(hdMock.MockHomeDeviceDao)
+	service := HomeDeviceServiceImpl{homeDeviceDao: mockDao}
+	ctx := context.Background()
+	now := time.Now()
+
+	mockDao.On("ListStaleHomeDevices", ctx, now.Add(-5*time.Minute).Unix()).Return(nil, hdError.ErrListingDevices.New())
+
+	_, err := service.MarkOfflineDevices(ctx, now)
+
+	assert.ErrorIs(t, err, hdError.ErrListingDevices)
+}
+
+func changeToStatus(changes []hdREsponse.DeviceChangeResponse, status string) hdREsponse.DeviceChangeResponse {
+	for _, change := range changes {
+		if change.Changes[0].After == status {
+			return change
+		}
+	}
+	return hdREsponse.DeviceChangeResponse{}
+}
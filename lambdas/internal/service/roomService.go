@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	dao "github.com/odhoman/home-devices/internal/dao"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+type RoomService interface {
+	CreateRoom(ctx context.Context, homeId string, room request.CreateRoomRequest) (*response.RoomResponse, error)
+	GetRoom(ctx context.Context, homeId string, id string) (*response.RoomResponse, error)
+	ListRooms(ctx context.Context, homeId string) (*response.RoomListResponse, error)
+	UpdateRoom(ctx context.Context, room request.UpdateRoomRequest, homeId string, id string, expectedVersion int64) error
+	DeleteRoom(ctx context.Context, homeId string, id string, expectedVersion int64) error
+}
+
+type RoomServiceImpl struct {
+	roomDao       dao.RoomDao
+	homeDao       dao.HomeDao
+	deviceService HomeDeviceService
+}
+
+func (rSI RoomServiceImpl) CreateRoom(ctx context.Context, homeId string, room request.CreateRoomRequest) (*response.RoomResponse, error) {
+
+	if err := rSI.checkRoomDao(hdError.ErrRoomNotCreated); err != nil {
+		return nil, err
+	}
+
+	if err := checkHomeExists(ctx, rSI.homeDao, homeId, hdError.ErrHomeNotFound); err != nil {
+		return nil, err
+	}
+
+	return rSI.roomDao.SaveRoom(ctx, homeId, room)
+}
+
+func (rSI RoomServiceImpl) GetRoom(ctx context.Context, homeId string, id string) (*response.RoomResponse, error) {
+
+	if err := rSI.checkRoomDao(hdError.ErrGettingRoom); err != nil {
+		return nil, err
+	}
+
+	return rSI.roomDao.GetRoom(ctx, homeId, id)
+}
+
+func (rSI RoomServiceImpl) ListRooms(ctx context.Context, homeId string) (*response.RoomListResponse, error) {
+
+	if err := rSI.checkRoomDao(hdError.ErrListingRooms); err != nil {
+		return nil, err
+	}
+
+	return rSI.roomDao.ListRooms(ctx, homeId)
+}
+
+func (rSI RoomServiceImpl) UpdateRoom(ctx context.Context, room request.UpdateRoomRequest, homeId string, id string, expectedVersion int64) error {
+
+	if room.Name == "" {
+		return hdError.ErrNoFieldToUpdate.New()
+	}
+
+	if err := rSI.checkRoomDao(hdError.ErrUpdatingRoom); err != nil {
+		return err
+	}
+
+	return rSI.roomDao.UpdateRoom(ctx, room, homeId, id, expectedVersion)
+}
+
+// DeleteRoom deletes a room without devices. The roomDao refuses it too when a
+// device is put in the room after its devices are listed.
+func (rSI RoomServiceImpl) DeleteRoom(ctx context.Context, homeId string, id string, expectedVersion int64) error {
+
+	if err := rSI.checkRoomDao(hdError.ErrDeletingRoom); err != nil {
+		return err
+	}
+
+	room, err := rSI.roomDao.GetRoom(ctx, homeId, id)
+	if err != nil {
+		return err
+	}
+
+	if expectedVersion > 0 && room.Version != expectedVersion {
+		return hdError.ErrVersionConflict.WithMessage(hdError.RoomVersionConflictMessage)
+	}
+
+	deviceIds, err := listAllHomeDeviceIds(ctx, rSI.deviceService, homeId, id)
+	if err != nil {
+		return err
+	}
+
+	if len(deviceIds) > 0 {
+		return hdError.ErrRoomHasDevices.New()
+	}
+
+	return rSI.roomDao.DeleteRoom(ctx, homeId, id, expectedVersion)
+}
+
+func (rSI RoomServiceImpl) checkRoomDao(errorDefinition hdError.Definition) error {
+
+	if rSI.roomDao == nil {
+		log.Printf("The rooms are not configured")
+		return errorDefinition.New()
+	}
+
+	return nil
+}
+
+// deleteAllRooms deletes the rooms of a home that is being deleted, once its
+// devices are gone or moved to another home.
+func deleteAllRooms(ctx context.Context, roomDao dao.RoomDao, homeId string) error {
+
+	if roomDao == nil {
+		return nil
+	}
+
+	rooms, err := roomDao.ListRooms(ctx, homeId)
+	if err != nil {
+		return err
+	}
+
+	for _, room := range rooms.Rooms {
+		// a room deleted in the meantime is already gone
+		if err := roomDao.DeleteRoom(ctx, homeId, room.ID, 0); err != nil && !errors.Is(err, hdError.ErrRoomNotFound) {
+			log.Printf("Error deleting the room %v of the home %v: %v", room.ID, homeId, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkRoomExists rejects a roomId that is not a room of the home.
+func checkRoomExists(ctx context.Context, roomDao dao.RoomDao, homeId string, roomId string) error {
+
+	if roomDao == nil {
+		return nil
+	}
+
+	_, err := roomDao.GetRoom(ctx, homeId, roomId)
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, hdError.ErrRoomNotFound) {
+		log.Printf("There is no room with id %v in the home %v", roomId, homeId)
+		return hdError.ErrUnknownRoom.New().WithDetail("roomId", roomId)
+	}
+
+	return err
+}
+
+// NewRoomServiceImplFromConfig uses the DynamoDB daos.
+func NewRoomServiceImplFromConfig(cfg aws.Config) RoomService {
+	client := dynamodb.NewFromConfig(cfg)
+	return NewRoomServiceImpl(dao.RoomDaoImpl{DynamoDbApi: client}, dao.HomeDaoImpl{DynamoDbApi: client}, newHomeDeviceServiceFromConfig(cfg, client))
+}
+
+// NewRoomServiceImpl checks the homes of the rooms with the homeDao, when it
+// is not nil, and the devices of a room before deleting it with the
+// deviceService.
+func NewRoomServiceImpl(roomDao dao.RoomDao, homeDao dao.HomeDao, deviceService HomeDeviceService) RoomService {
+	return RoomServiceImpl{roomDao: roomDao, homeDao: homeDao, deviceService: deviceService}
+}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/constants"
+	"github.com/odhoman/home-devices/internal/dao"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	hdMock "github.com/odhoman/home-devices/internal/mock"
+	"github.com/odhoman/home-devices/internal/request"
+	hdREsponse "github.com/odhoman/home-devices/internal/response"
+	"github.com/stretchr/testify/assert"
+	mock "github.com/stretchr/testify/mock"
+)
+
+func TestCreateRoom_UnknownHome(t *testing.T) {
+	mockHomeDao := new(hdMock.MockHomeDao)
+	mockRoomDao := new(hdMock.MockRoomDao)
+	service := RoomServiceImpl{roomDao: mockRoomDao, homeDao: mockHomeDao}
+
+	ctx := context.Background()
+
+	mockHomeDao.On("GetHome", ctx, "home1").Return(nil, hdError.ErrHomeNotFound.New())
+
+	_, err := service.CreateRoom(ctx, "home1", request.CreateRoomRequest{Name: "Kitchen"})
+
+	assert.ErrorIs(t, err, hdError.ErrHomeNotFound)
+	mockRoomDao.AssertNotCalled(t, "SaveRoom", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateRoom_NotConfigured(t *testing.T) {
+	service := RoomServiceImpl{}
+
+	_, err := service.CreateRoom(context.Background(), "home1", request.CreateRoomRequest{Name: "Kitchen"})
+
+	assert.ErrorIs(t, err, hdError.ErrRoomNotCreated)
+}
+
+func TestUpdateRoom_NoFields(t *testing.T) {
+	mockRoomDao := new(hdMock.MockRoomDao)
+	service := RoomServiceImpl{roomDao: mockRoomDao}
+
+	err := service.UpdateRoom(context.Background(), request.UpdateRoomRequest{}, "home1", "room1", 0)
+
+	assert.ErrorIs(t, err, hdError.ErrNoFieldToUpdate)
+	mockRoomDao.AssertNotCalled(t, "UpdateRoom", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateHomeDevice_UnknownRoom(t *testing.T) {
+	service, home, _ := newRoomServiceForTesting(t)
+
+	_, err := service.CreateHomeDevice(context.Background(), request.CreateDeviceRequest{
+		MAC:    "00:11:22:33:44:55",
+		Name:   "Kitchen Light",
+		Type:   "light",
+		HomeID: home.ID,
+		RoomID: "8d3c1b6e-2f4a-4c51-9a57-0d6f4f1f2b11",
+	})
+
+	assert.ErrorIs(t, err, hdError.ErrUnknownRoom)
+}
+
+func TestCreateHomeDevice_RoomOfAnotherHome(t *testing.T) {
+	service, _, room := newRoomServiceForTesting(t)
+	ctx := context.Background()
+
+	other, _ := service.CreateHome(ctx, request.CreateHomeRequest{Name: "Mountain House", Timezone: "UTC", Owner: "user-1"})
+
+	_, err := service.CreateHomeDevice(ctx, request.CreateDeviceRequest{
+		MAC:    "00:11:22:33:44:55",
+		Name:   "Kitchen Light",
+		Type:   "light",
+		HomeID: other.ID,
+		RoomID: room.ID,
+	})
+
+	assert.ErrorIs(t, err, hdError.ErrUnknownRoom)
+}
+
+func TestUpdateHomeDevice_RoomOfTheCurrentHome(t *testing.T) {
+	service, home, room := newRoomServiceForTesting(t)
+	ctx := context.Background()
+
+	device := createDeviceForRoomTesting(t, service, home.ID, "")
+
+	_, err := service.UpdateHomeDevice(ctx, request.UpdateDeviceRequest{RoomID: room.ID}, device.ID, 0)
+	assert.Nil(t, err)
+
+	updated, _ := service.GetHomeDevice(ctx, device.ID)
+	assert.Equal(t, room.ID, updated.RoomID)
+
+	_, err = service.UpdateHomeDevice(ctx, request.UpdateDeviceRequest{RoomID: "8d3c1b6e-2f4a-4c51-9a57-0d6f4f1f2b11"}, device.ID, 0)
+	assert.ErrorIs(t, err, hdError.ErrUnknownRoom)
+}
+
+func TestUpdateHomeDevice_MoveToAnotherHomeClearsTheRoom(t *testing.T) {
+	service, home, room := newRoomServiceForTesting(t)
+	ctx := context.Background()
+
+	device := createDeviceForRoomTesting(t, service, home.ID, room.ID)
+	other, _ := service.CreateHome(ctx, request.CreateHomeRequest{Name: "Mountain House", Timezone: "UTC", Owner: "user-1"})
+
+	// the room is checked against the home the device is moved to
+	_, err := service.UpdateHomeDevice(ctx, request.UpdateDeviceRequest{HomeID: other.ID, RoomID: room.ID}, device.ID, 0)
+	assert.ErrorIs(t, err, hdError.ErrUnknownRoom)
+
+	_, err = service.UpdateHomeDevice(ctx, request.UpdateDeviceRequest{HomeID: other.ID}, device.ID, 0)
+	assert.Nil(t, err)
+
+	moved, _ := service.GetHomeDevice(ctx, device.ID)
+	assert.Equal(t, other.ID, moved.HomeID)
+	assert.Empty(t, moved.RoomID)
+}
+
+func TestDeleteRoom_WithDevices(t *testing.T) {
+	service, home, room := newRoomServiceForTesting(t)
+	ctx := context.Background()
+
+	device := createDeviceForRoomTesting(t, service, home.ID, room.ID)
+
+	err := service.DeleteRoom(ctx, home.ID, room.ID, 0)
+	assert.ErrorIs(t, err, hdError.ErrRoomHasDevices)
+
+	other, _ := service.CreateRoom(ctx, home.ID, request.CreateRoomRequest{Name: "Kitchen"})
+	_, err = service.UpdateHomeDevice(ctx, request.UpdateDeviceRequest{RoomID: other.ID}, device.ID, 0)
+	assert.Nil(t, err)
+
+	assert.Nil(t, service.DeleteRoom(ctx, home.ID, room.ID, 0))
+
+	_, err = service.GetRoom(ctx, home.ID, room.ID)
+	assert.ErrorIs(t, err, hdError.ErrRoomNotFound)
+}
+
+func TestDeleteRoom_DeviceAddedAfterListingTheDevices(t *testing.T) {
+	roomDao := dao.NewInMemoryRoomDao()
+	deviceService := NewHomeDeviceServiceImpl2(dao.NewInMemoryHomeDeviceDaoWithRooms(nil, roomDao), WithRoomDao(roomDao))
+	ctx := context.Background()
+
+	room, _ := roomDao.SaveRoom(ctx, "home1", request.CreateRoomRequest{Name: "Living Room"})
+	createDeviceForRoomTesting(t, deviceService, "home1", room.ID)
+
+	// the devices were listed before the device was added to the room
+	roomService := NewRoomServiceImpl(roomDao, nil, NewHomeDeviceServiceImpl2(dao.NewInMemoryHomeDeviceDao()))
+
+	err := roomService.DeleteRoom(ctx, "home1", room.ID, 0)
+	assert.ErrorIs(t, err, hdError.ErrRoomHasDevices)
+
+	_, err = roomDao.GetRoom(ctx, "home1", room.ID)
+	assert.Nil(t, err)
+}
+
+func TestDeleteRoom_VersionConflict(t *testing.T) {
+	service, home, room := newRoomServiceForTesting(t)
+
+	err := service.DeleteRoom(context.Background(), home.ID, room.ID, room.Version+1)
+
+	assert.ErrorIs(t, err, hdError.ErrVersionConflict)
+}
+
+func TestDeleteHome_DeletesTheRooms(t *testing.T) {
+	service, home, room := newRoomServiceForTesting(t)
+	ctx := context.Background()
+
+	target, _ := service.CreateHome(ctx, request.CreateHomeRequest{Name: "Mountain House", Timezone: "UTC", Owner: "user-1"})
+	device := createDeviceForRoomTesting(t, service, home.ID, room.ID)
+
+	err := service.DeleteHome(ctx, home.ID, request.DeleteHomeRequest{Strategy: constants.DeleteHomeStrategyReassign, TargetHomeID: target.ID}, 0)
+	assert.Nil(t, err)
+
+	moved, _ := service.GetHomeDevice(ctx, device.ID)
+	assert.Equal(t, target.ID, moved.HomeID)
+	assert.Empty(t, moved.RoomID)
+
+	rooms, _ := service.ListRooms(ctx, home.ID)
+	assert.Empty(t, rooms.Rooms)
+}
+
+func TestRestoreHomeDevice_KeepsTheRoom(t *testing.T) {
+	service, home, room := newRoomServiceForTesting(t)
+	ctx := context.Background()
+
+	device := createDeviceForRoomTesting(t, service, home.ID, room.ID)
+	_, err := service.DeleteHomeDevice(ctx, device.ID, 0)
+	assert.Nil(t, err)
+
+	restored, err := service.RestoreHomeDevice(ctx, device.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, room.ID, restored.RoomID)
+}
+
+func TestRestoreHomeDevice_UnknownRoom(t *testing.T) {
+	service, home, room := newRoomServiceForTesting(t)
+	ctx := context.Background()
+
+	device := createDeviceForRoomTesting(t, service, home.ID, room.ID)
+	_, err := service.DeleteHomeDevice(ctx, device.ID, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, service.DeleteRoom(ctx, home.ID, room.ID, 0))
+
+	_, err = service.RestoreHomeDevice(ctx, device.ID)
+	assert.ErrorIs(t, err, hdError.ErrUnknownRoom)
+
+	_, err = service.GetHomeDevice(ctx, device.ID)
+	assert.ErrorIs(t, err, hdError.ErrDeviceNotFound)
+}
+
+func TestRestoreHomeDevice_UnknownHome(t *testing.T) {
+	service, home, _ := newRoomServiceForTesting(t)
+	ctx := context.Background()
+
+	device := createDeviceForRoomTesting(t, service, home.ID, "")
+	assert.Nil(t, service.DeleteHome(ctx, home.ID, request.DeleteHomeRequest{Strategy: constants.DeleteHomeStrategyCascade}, 0))
+
+	_, err := service.RestoreHomeDevice(ctx, device.ID)
+	assert.ErrorIs(t, err, hdError.ErrUnknownHome)
+
+	_, err = service.GetHomeDevice(ctx, device.ID)
+	assert.ErrorIs(t, err, hdError.ErrDeviceNotFound)
+}
+
+// roomTestService changes the homes, their rooms and their devices.
+type roomTestService struct {
+	HomeService
+	RoomService
+	HomeDeviceService
+}
+
+// newRoomServiceForTesting returns a service with in-memory daos and a home
+// with a room.
+func newRoomServiceForTesting(t *testing.T) (roomTestService, *hdREsponse.HomeResponse, *hdREsponse.RoomResponse) {
+	t.Helper()
+
+	homeDao := dao.NewInMemoryHomeDao()
+	roomDao := dao.NewInMemoryRoomDao()
+	deviceService := NewHomeDeviceServiceImpl2(dao.NewInMemoryHomeDeviceDaoWithRooms(nil, roomDao), WithHomeDao(homeDao), WithRoomDao(roomDao))
+	service := roomTestService{
+		HomeService:       NewHomeServiceImpl(homeDao, deviceService, roomDao, nil, nil, nil),
+		RoomService:       NewRoomServiceImpl(roomDao, homeDao, deviceService),
+		HomeDeviceService: deviceService,
+	}
+	ctx := context.Background()
+
+	home, err := service.CreateHome(ctx, request.CreateHomeRequest{Name: "Beach House", Timezone: "Europe/Madrid", Owner: "user-1"})
+	if err != nil {
+		t.Fatalf("Unexpected error creating the home for testing. Error: %v", err)
+	}
+
+	room, err := service.CreateRoom(ctx, home.ID, request.CreateRoomRequest{Name: "Living Room"})
+	if err != nil {
+		t.Fatalf("Unexpected error creating the room for testing. Error: %v", err)
+	}
+
+	return service, home, room
+}
+
+func createDeviceForRoomTesting(t *testing.T, service HomeDeviceService, homeId string, roomId string) *hdREsponse.HomdeDeviceResponse {
+	t.Helper()
+
+	device, err := service.CreateHomeDevice(context.Background(), request.CreateDeviceRequest{
+		MAC:    "00:11:22:33:44:55",
+		Name:   "Living Room Light",
+		Type:   "light",
+		HomeID: homeId,
+		RoomID: roomId,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error creating the device for testing. Error: %v", err)
+	}
+
+	return device
+}
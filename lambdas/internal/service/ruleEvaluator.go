@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	_ "time/tzdata"
+
+	constants "github.com/odhoman/home-devices/internal/constants"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+)
+
+// EvaluateRules fires the rules of the device whose condition the reading
+// meets.
+func (rSI RuleServiceImpl) EvaluateRules(ctx context.Context, reading request.TelemetryReadingRequest) ([]response.RuleExecutionResponse, error) {
+
+	executions := []response.RuleExecutionResponse{}
+
+	if rSI.ruleDao == nil || reading.Value == nil {
+		return executions, nil
+	}
+
+	readAt := time.UnixMilli(reading.Timestamp)
+	if time.Since(readAt) > constants.MaxRuleReadingAgeSeconds*time.Second {
+		log.Printf("The %v reading of the device %v is too old to fire rules", reading.Metric, reading.DeviceID)
+		return executions, nil
+	}
+
+	rules, err := rSI.ruleDao.ListTriggeredRules(ctx, reading.DeviceID)
+	if err != nil {
+		return executions, err
+	}
+
+	for _, rule := range rules {
+
+		if !rule.Enabled || rule.Trigger.Metric != reading.Metric ||
+			!compareRuleValue(rule.Trigger.Operator, *reading.Value, rule.Trigger.Value) ||
+			!isInTimeWindow(rule.TimeWindow, readAt) {
+			continue
+		}
+
+		firedAt := time.Now().Unix()
+		if err := rSI.ruleDao.ClaimRuleFiring(ctx, rule.HomeID, rule.ID, reading.Timestamp, firedAt, rule.CooldownSeconds); err != nil {
+			if errors.Is(err, hdError.ErrRuleInCooldown) || errors.Is(err, hdError.ErrRuleAlreadyFired) || errors.Is(err, hdError.ErrRuleNotFound) {
+				continue
+			}
+			return executions, err
+		}
+
+		execution := response.RuleExecutionResponse{
+			RuleID:     rule.ID,
+			DeviceID:   reading.DeviceID,
+			Metric:     reading.Metric,
+			Value:      *reading.Value,
+			Status:     constants.RuleExecutionStatusFired,
+			ExecutedAt: firedAt,
+		}
+
+		command, err := rSI.commandService.SendDeviceCommand(ctx, rule.Action.DeviceID, request.SendDeviceCommandRequest{
+			Name:   rule.Action.Command,
+			Params: rule.Action.Params,
+		})
+		if err != nil {
+			log.Printf("Error sending the action of the rule %v of the home %v: %v", rule.ID, rule.HomeID, err)
+			execution.Status = constants.RuleExecutionStatusFailed
+			execution.Error = hdError.From(err).ErrorMessage
+		} else {
+			execution.CommandID = command.ID
+		}
+
+		executions = append(executions, rSI.recordRuleExecution(ctx, execution))
+	}
+
+	return executions, nil
+}
+
+// recordRuleExecution saves the execution in the log.
+func (rSI RuleServiceImpl) recordRuleExecution(ctx context.Context, execution response.RuleExecutionResponse) response.RuleExecutionResponse {
+
+	if rSI.ruleExecutionDao == nil {
+		return execution
+	}
+
+	saved, err := rSI.ruleExecutionDao.SaveRuleExecution(ctx, execution)
+	if err != nil {
+		log.Printf("Error recording the execution of the rule %v: %v", execution.RuleID, err)
+		return execution
+	}
+
+	return *saved
+}
+
+func compareRuleValue(operator string, value float64, threshold float64) bool {
+
+	switch operator {
+	case constants.RuleOperatorGreaterThan:
+		return value > threshold
+	case constants.RuleOperatorGreaterThanOrEqual:
+		return value >= threshold
+	case constants.RuleOperatorLessThan:
+		return value < threshold
+	case constants.RuleOperatorLessThanOrEqual:
+		return value <= threshold
+	case constants.RuleOperatorEqual:
+		return value == threshold
+	case constants.RuleOperatorNotEqual:
+		return value != threshold
+	}
+
+	return false
+}
+
+// isInTimeWindow checks the time of the reading in the timezone of the window.
+func isInTimeWindow(timeWindow *response.RuleTimeWindowResponse, readAt time.Time) bool {
+
+	if timeWindow == nil {
+		return true
+	}
+
+	location, err := time.LoadLocation(timeWindow.Timezone)
+	if err != nil {
+		log.Printf("Unknown timezone %v of a rule, using UTC: %v", timeWindow.Timezone, err)
+		location = time.UTC
+	}
+
+	from, fromErr := time.Parse("15:04", timeWindow.From)
+	to, toErr := time.Parse("15:04", timeWindow.To)
+	if fromErr != nil || toErr != nil {
+		log.Printf("Invalid time window %v-%v of a rule", timeWindow.From, timeWindow.To)
+		return false
+	}
+
+	local := readAt.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	fromMinute := from.Hour()*60 + from.Minute()
+	toMinute := to.Hour()*60 + to.Minute()
+
+	if fromMinute <= toMinute {
+		return minute >= fromMinute && minute < toMinute
+	}
+
+	return minute >= fromMinute || minute < toMinute
+}
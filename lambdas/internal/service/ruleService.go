@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	capability "github.com/odhoman/home-devices/internal/capability"
+	constants "github.com/odhoman/home-devices/internal/constants"
+	dao "github.com/odhoman/home-devices/internal/dao"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	queue "github.com/odhoman/home-devices/internal/queue"
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// defaultTimezone is the timezone of the time windows of the rules and of the
+// schedules when their home has none, or the service has no homes configured.
+const defaultTimezone = "UTC"
+
+type RuleService interface {
+	CreateRule(ctx context.Context, homeId string, rule request.RuleRequest) (*response.RuleResponse, error)
+	GetRule(ctx context.Context, homeId string, id string) (*response.RuleResponse, error)
+	ListRules(ctx context.Context, homeId string) (*response.RuleListResponse, error)
+	UpdateRule(ctx context.Context, rule request.RuleRequest, homeId string, id string, expectedVersion int64) error
+	DeleteRule(ctx context.Context, homeId string, id string, expectedVersion int64) error
+	ListRuleExecutions(ctx context.Context, homeId string, ruleId string) (*response.RuleExecutionListResponse, error)
+	EvaluateRules(ctx context.Context, reading request.TelemetryReadingRequest) ([]response.RuleExecutionResponse, error)
+}
+
+type RuleServiceImpl struct {
+	ruleDao          dao.RuleDao
+	ruleExecutionDao dao.RuleExecutionDao
+	homeDao          dao.HomeDao
+	deviceService    HomeDeviceService
+	commandService   DeviceCommandService
+}
+
+// CreateRule checks that the trigger and the action are valid for their
+// devices, which must be devices of the home, and saves the rule.
+func (rSI RuleServiceImpl) CreateRule(ctx context.Context, homeId string, rule request.RuleRequest) (*response.RuleResponse, error) {
+
+	if err := rSI.checkRuleDao(hdError.ErrRuleNotCreated); err != nil {
+		return nil, err
+	}
+
+	rule, err := rSI.prepareRule(ctx, homeId, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	return rSI.ruleDao.SaveRule(ctx, homeId, rule)
+}
+
+func (rSI RuleServiceImpl) GetRule(ctx context.Context, homeId string, id string) (*response.RuleResponse, error) {
+
+	if err := rSI.checkRuleDao(hdError.ErrGettingRule); err != nil {
+		return nil, err
+	}
+
+	return rSI.ruleDao.GetRule(ctx, homeId, id)
+}
+
+func (rSI RuleServiceImpl) ListRules(ctx context.Context, homeId string) (*response.RuleListResponse, error) {
+
+	if err := rSI.checkRuleDao(hdError.ErrListingRules); err != nil {
+		return nil, err
+	}
+
+	return rSI.ruleDao.ListRules(ctx, homeId)
+}
+
+// UpdateRule replaces the rule, with the same checks as CreateRule.
+func (rSI RuleServiceImpl) UpdateRule(ctx context.Context, rule request.RuleRequest, homeId string, id string, expectedVersion int64) error {
+
+	if err := rSI.checkRuleDao(hdError.ErrUpdatingRule); err != nil {
+		return err
+	}
+
+	rule, err := rSI.prepareRule(ctx, homeId, rule)
+	if err != nil {
+		return err
+	}
+
+	return rSI.ruleDao.UpdateRule(ctx, rule, homeId, id, expectedVersion)
+}
+
+func (rSI RuleServiceImpl) DeleteRule(ctx context.Context, homeId string, id string, expectedVersion int64) error {
+
+	if err := rSI.checkRuleDao(hdError.ErrDeletingRule); err != nil {
+		return err
+	}
+
+	return rSI.ruleDao.DeleteRule(ctx, homeId, id, expectedVersion)
+}
+
+// ListRuleExecutions returns the last executions of a rule of the home,
+// newest first.
+func (rSI RuleServiceImpl) ListRuleExecutions(ctx context.Context, homeId string, ruleId string) (*response.RuleExecutionListResponse, error) {
+
+	if err := rSI.checkRuleDao(hdError.ErrListingRuleExecutions); err != nil {
+		return nil, err
+	}
+
+	if rSI.ruleExecutionDao == nil {
+		log.Printf("The rule executions are not configured")
+		return nil, hdError.ErrListingRuleExecutions.New()
+	}
+
+	if _, err := rSI.ruleDao.GetRule(ctx, homeId, ruleId); err != nil {
+		return nil, err
+	}
+
+	return rSI.ruleExecutionDao.ListRuleExecutions(ctx, ruleId, constants.MaxListRuleExecutionsLimit)
+}
+
+// prepareRule validates the rule for the home and resolves the timezone of its
+// time window.
+func (rSI RuleServiceImpl) prepareRule(ctx context.Context, homeId string, rule request.RuleRequest) (request.RuleRequest, error) {
+
+	timezone, err := getHomeTimezone(ctx, rSI.homeDao, homeId)
+	if err != nil {
+		return rule, err
+	}
+
+	if err := rSI.validateRule(ctx, homeId, rule); err != nil {
+		return rule, err
+	}
+
+	if rule.TimeWindow != nil && rule.TimeWindow.Timezone == "" {
+		timeWindow := *rule.TimeWindow
+		timeWindow.Timezone = timezone
+		rule.TimeWindow = &timeWindow
+	}
+
+	return rule, nil
+}
+
+// validateRule checks that the trigger device has the metric, that the action
+// device accepts the command and that both are devices of the home.
+func (rSI RuleServiceImpl) validateRule(ctx context.Context, homeId string, rule request.RuleRequest) error {
+
+	var validationErrors []string
+
+	triggerDevice, err := getDeviceOfHome(ctx, rSI.deviceService, homeId, rule.Trigger.DeviceID)
+	if err != nil {
+		return err
+	}
+
+	if triggerDevice == nil {
+		validationErrors = append(validationErrors, fmt.Sprintf("the trigger device %v is not a device of the home", rule.Trigger.DeviceID))
+	} else if schema, found := capability.Get(triggerDevice.Type); found && rule.Trigger.Value != nil {
+		validationErrors = append(validationErrors, schema.ValidateReading(rule.Trigger.Metric, *rule.Trigger.Value, "")...)
+	}
+
+	actionDevice, err := getDeviceOfHome(ctx, rSI.deviceService, homeId, rule.Action.DeviceID)
+	if err != nil {
+		return err
+	}
+
+	if actionDevice == nil {
+		validationErrors = append(validationErrors, fmt.Sprintf("the action device %v is not a device of the home", rule.Action.DeviceID))
+	} else if err := validateDeviceCommand(actionDevice.Type, request.SendDeviceCommandRequest{Name: rule.Action.Command, Params: rule.Action.Params}); err != nil {
+		validationErrors = append(validationErrors, hdError.From(err).ErrorMessage)
+	}
+
+	if rule.TimeWindow != nil && rule.TimeWindow.From == rule.TimeWindow.To {
+		validationErrors = append(validationErrors, "the time window must end at a different time than it starts")
+	}
+
+	if len(validationErrors) == 0 {
+		return nil
+	}
+
+	log.Printf("Invalid rule for the home %v: %v", homeId, validationErrors)
+	return hdError.ErrInvalidRule.WithMessage(strings.Join(validationErrors, "; "))
+}
+
+// getDeviceOfHome returns the device when it is a device of the home, or nil.
+func getDeviceOfHome(ctx context.Context, deviceService HomeDeviceService, homeId string, deviceId string) (*response.HomdeDeviceResponse, error) {
+
+	device, err := deviceService.GetHomeDevice(ctx, deviceId)
+	if err != nil {
+		if errors.Is(err, hdError.ErrDeviceNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if device.HomeID != homeId {
+		return nil, nil
+	}
+
+	return device, nil
+}
+
+// getHomeTimezone returns the timezone of the home, or the default one when
+// the home has none or the service has no homes configured.
+func getHomeTimezone(ctx context.Context, homeDao dao.HomeDao, homeId string) (string, error) {
+
+	if homeDao == nil {
+		return defaultTimezone, nil
+	}
+
+	home, err := homeDao.GetHome(ctx, homeId)
+	if err != nil {
+		return "", err
+	}
+
+	return resolveValue(home.Timezone, defaultTimezone), nil
+}
+
+// deleteAllRules deletes the rules of a home that is being deleted.
+func deleteAllRules(ctx context.Context, ruleDao dao.RuleDao, homeId string) error {
+
+	if ruleDao == nil {
+		return nil
+	}
+
+	rules, err := ruleDao.ListRules(ctx, homeId)
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules.Rules {
+		if err := ruleDao.DeleteRule(ctx, homeId, rule.ID, 0); err != nil && !errors.Is(err, hdError.ErrRuleNotFound) {
+			log.Printf("Error deleting the rule %v of the home %v: %v", rule.ID, homeId, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (rSI RuleServiceImpl) checkRuleDao(errorDefinition hdError.Definition) error {
+
+	if rSI.ruleDao == nil {
+		log.Printf("The rules are not configured")
+		return errorDefinition.New()
+	}
+
+	return nil
+}
+
+// NewRuleServiceImplFromConfig uses the DynamoDB daos.
+func NewRuleServiceImplFromConfig(cfg aws.Config, commandQueue queue.CommandQueue) RuleService {
+	client := dynamodb.NewFromConfig(cfg)
+	deviceService := newHomeDeviceServiceFromConfig(cfg, client)
+	commandService := NewDeviceCommandServiceImpl(dao.DeviceCommandDaoImpl{DynamoDbApi: client}, deviceService, commandQueue)
+	return NewRuleServiceImpl(dao.RuleDaoImpl{DynamoDbApi: client}, dao.RuleExecutionDaoImpl{DynamoDbApi: client}, dao.HomeDaoImpl{DynamoDbApi: client}, deviceService, commandService)
+}
+
+// NewRuleServiceImpl checks the devices of the rules with the deviceService and
+// sends their actions with the commandService.
+func NewRuleServiceImpl(ruleDao dao.RuleDao, ruleExecutionDao dao.RuleExecutionDao, homeDao dao.HomeDao, deviceService HomeDeviceService, commandService DeviceCommandService) RuleService {
+	return RuleServiceImpl{ruleDao: ruleDao, ruleExecutionDao: ruleExecutionDao, homeDao: homeDao, deviceService: deviceService, commandService: commandService}
+}
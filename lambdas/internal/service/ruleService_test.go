@@ -0,0 +1,410 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/odhoman/home-devices/internal/constants"
+	"github.com/odhoman/home-devices/internal/dao"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	hdMock "github.com/odhoman/home-devices/internal/mock"
+	"github.com/odhoman/home-devices/internal/queue"
+	"github.com/odhoman/home-devices/internal/request"
+	hdREsponse "github.com/odhoman/home-devices/internal/response"
+	"github.com/stretchr/testify/assert"
+	mock "github.com/stretchr/testify/mock"
+)
+
+func TestCreateRule_Defaults(t *testing.T) {
+	env := newRuleServiceForTesting(t)
+
+	rule, err := env.service.CreateRule(context.Background(), env.home.ID, env.motionRule())
+
+	assert.Nil(t, err)
+	assert.True(t, rule.Enabled)
+	assert.Equal(t, int64(constants.DefaultRuleCooldownSeconds), rule.CooldownSeconds)
+	assert.Equal(t, "Europe/Madrid", rule.TimeWindow.Timezone)
+	assert.Equal(t, env.home.ID, rule.HomeID)
+}
+
+func TestCreateRule_UnknownHome(t *testing.T) {
+	env := newRuleServiceForTesting(t)
+
+	_, err := env.service.CreateRule(context.Background(), "home99999", env.motionRule())
+
+	assert.ErrorIs(t, err, hdError.ErrHomeNotFound)
+}
+
+func TestCreateRule_InvalidForTheDevices(t *testing.T) {
+	env := newRuleServiceForTesting(t)
+
+	rule := env.motionRule()
+	rule.Trigger.Metric = "pressure"
+	rule.Action.Command = "lock"
+	rule.TimeWindow = &request.RuleTimeWindow{From: "22:00", To: "22:00"}
+
+	_, err := env.service.CreateRule(context.Background(), env.home.ID, rule)
+
+	assert.ErrorIs(t, err, hdError.ErrInvalidRule)
+	assert.EqualError(t, err, "pressure is not a metric of the sensor devices; lock is not a command of the light devices; "+
+		"the time window must end at a different time than it starts")
+}
+
+func TestCreateRule_DeviceOfAnotherHome(t *testing.T) {
+	env := newRuleServiceForTesting(t)
+	ctx := context.Background()
+
+	other, _ := env.service.CreateHome(ctx, request.CreateHomeRequest{Name: "Mountain House", Timezone: "UTC", Owner: "user-1"})
+	device, _ := env.service.CreateHomeDevice(ctx, request.CreateDeviceRequest{MAC: "00:11:22:33:44:99", Name: "Porch Light", Type: "light", HomeID: other.ID})
+
+	rule := env.motionRule()
+	rule.Action.DeviceID = device.ID
+
+	_, err := env.service.CreateRule(ctx, env.home.ID, rule)
+
+	assert.ErrorIs(t, err, hdError.ErrInvalidRule)
+	assert.EqualError(t, err, "the action device "+device.ID+" is not a device of the home")
+}
+
+func TestCreateRule_NotConfigured(t *testing.T) {
+	service := RuleServiceImpl{}
+
+	_, err := service.CreateRule(context.Background(), "home1", request.RuleRequest{})
+
+	assert.ErrorIs(t, err, hdError.ErrRuleNotCreated)
+}
+
+func TestUpdateRule_Validates(t *testing.T) {
+	env := newRuleServiceForTesting(t)
+	ctx := context.Background()
+	saved := env.saveRule(t, env.motionRule())
+
+	rule := env.motionRule()
+	rule.Trigger.Metric = "on"
+
+	err := env.service.UpdateRule(ctx, rule, env.home.ID, saved.ID, saved.Version)
+	assert.ErrorIs(t, err, hdError.ErrInvalidRule)
+
+	rule = env.motionRule()
+	rule.Name = "Hallway light"
+	rule.TimeWindow = nil
+
+	assert.Nil(t, env.service.UpdateRule(ctx, rule, env.home.ID, saved.ID, saved.Version))
+
+	updated, _ := env.service.GetRule(ctx, env.home.ID, saved.ID)
+	assert.Equal(t, "Hallway light", updated.Name)
+	assert.Nil(t, updated.TimeWindow)
+}
+
+func TestListRuleExecutions_RuleNotFound(t *testing.T) {
+	env := newRuleServiceForTesting(t)
+
+	_, err := env.service.ListRuleExecutions(context.Background(), env.home.ID, "00000000-0000-0000-0000-000000000000")
+
+	assert.ErrorIs(t, err, hdError.ErrRuleNotFound)
+}
+
+func TestDeleteHome_DeletesTheRules(t *testing.T) {
+	env := newRuleServiceForTesting(t)
+	ctx := context.Background()
+	saved := env.saveRule(t, env.motionRule())
+
+	err := env.service.DeleteHome(ctx, env.home.ID, request.DeleteHomeRequest{Strategy: constants.DeleteHomeStrategyCascade}, 0)
+	assert.Nil(t, err)
+
+	_, err = env.service.GetRule(ctx, env.home.ID, saved.ID)
+	assert.ErrorIs(t, err, hdError.ErrRuleNotFound)
+}
+
+func TestEvaluateRules_Fires(t *testing.T) {
+	env := newRuleServiceForTesting(t)
+	ctx := context.Background()
+	rule := env.motionRule()
+	rule.TimeWindow = nil
+	saved := env.saveRule(t, rule)
+
+	executions, err := env.service.EvaluateRules(ctx, env.reading("motion", 1))
+
+	assert.Nil(t, err)
+	if assert.Len(t, executions, 1) {
+		assert.Equal(t, constants.RuleExecutionStatusFired, executions[0].Status)
+		assert.NotEmpty(t, executions[0].CommandID)
+	}
+
+	messages := env.commandQueue.Messages()
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, env.light.ID, messages[0].DeviceID)
+		assert.Equal(t, "turnOn", messages[0].Name)
+	}
+
+	logged, err := env.service.ListRuleExecutions(ctx, env.home.ID, saved.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, executions, logged.Executions)
+}
+
+func TestEvaluateRules_Cooldown(t *testing.T) {
+	env := newRuleServiceForTesting(t)
+	ctx := context.Background()
+	rule := env.motionRule()
+	rule.TimeWindow = nil
+	env.saveRule(t, rule)
+
+	env.service.EvaluateRules(ctx, env.reading("motion", 1))
+	executions, err := env.service.EvaluateRules(ctx, env.reading("motion", 1))
+
+	assert.Nil(t, err)
+	assert.Empty(t, executions)
+	assert.Len(t, env.commandQueue.Messages(), 1)
+}
+
+func TestEvaluateRules_ConditionNotMet(t *testing.T) {
+	env := newRuleServiceForTesting(t)
+	ctx := context.Background()
+	rule := env.motionRule()
+	rule.TimeWindow = nil
+	env.saveRule(t, rule)
+
+	disabled := false
+	rule.Enabled = &disabled
+	env.saveRule(t, rule)
+
+	for _, reading := range []request.TelemetryReadingRequest{
+		env.reading("motion", 0),
+		env.reading("temperature", 1),
+	} {
+		executions, err := env.service.EvaluateRules(ctx, reading)
+		assert.Nil(t, err)
+		assert.Empty(t, executions)
+	}
+
+	assert.Empty(t, env.commandQueue.Messages())
+}
+
+func TestEvaluateRules_OldReading(t *testing.T) {
+	env := newRuleServiceForTesting(t)
+	rule := env.motionRule()
+	rule.TimeWindow = nil
+	env.saveRule(t, rule)
+
+	reading := env.reading("motion", 1)
+	reading.Timestamp = time.Now().Add(-time.Hour).UnixMilli()
+
+	executions, err := env.service.EvaluateRules(context.Background(), reading)
+
+	assert.Nil(t, err)
+	assert.Empty(t, executions)
+}
+
+func TestEvaluateRules_TimeWindow(t *testing.T) {
+	env := newRuleServiceForTesting(t)
+	now := time.Now().UTC()
+
+	outside := env.motionRule()
+	outside.TimeWindow = &request.RuleTimeWindow{From: now.Add(time.Hour).Format("15:04"), To: now.Add(2 * time.Hour).Format("15:04"), Timezone: "UTC"}
+	env.saveRule(t, outside)
+
+	inside := env.motionRule()
+	inside.TimeWindow = &request.RuleTimeWindow{From: now.Add(-time.Hour).Format("15:04"), To: now.Add(time.Hour).Format("15:04"), Timezone: "UTC"}
+	saved := env.saveRule(t, inside)
+
+	executions, err := env.service.EvaluateRules(context.Background(), env.reading("motion", 1))
+
+	assert.Nil(t, err)
+	if assert.Len(t, executions, 1) {
+		assert.Equal(t, saved.ID, executions[0].RuleID)
+	}
+}
+
+func TestEvaluateRules_ErrorSendingTheCommandIsRecorded(t *testing.T) {
+	mockQueue := new(hdMock.MockCommandQueue)
+	env := newRuleServiceWithQueueForTesting(t, mockQueue)
+	ctx := context.Background()
+	rule := env.motionRule()
+	rule.TimeWindow = nil
+	env.saveRule(t, rule)
+
+	mockQueue.On("Publish", ctx, mock.Anything).Return(errors.New("throttled"))
+
+	executions, err := env.service.EvaluateRules(ctx, env.reading("motion", 1))
+
+	assert.Nil(t, err)
+	if assert.Len(t, executions, 1) {
+		assert.Equal(t, constants.RuleExecutionStatusFailed, executions[0].Status)
+		assert.Equal(t, hdError.ErrSendingDeviceCommand.Message, executions[0].Error)
+	}
+}
+
+func TestEvaluateRules_ErrorClaimingTheFiring(t *testing.T) {
+	mockRuleDao := new(hdMock.MockRuleDao)
+	commandQueue := queue.NewInMemoryCommandQueue()
+	deviceService := HomeDeviceServiceImpl{homeDeviceDao: new(hdMock.MockHomeDeviceDao)}
+	service := RuleServiceImpl{ruleDao: mockRuleDao, deviceService: deviceService, commandService: NewDeviceCommandServiceImpl(dao.NewInMemoryDeviceCommandDao(), deviceService, commandQueue)}
+	ctx := context.Background()
+
+	mockRuleDao.On("ListTriggeredRules", ctx, "sensor-1").Return([]hdREsponse.RuleResponse{{
+		ID:              "rule-1",
+		HomeID:          "home1",
+		Enabled:         true,
+		Trigger:         hdREsponse.RuleTriggerResponse{DeviceID: "sensor-1", Metric: "temperature", Operator: "gt", Value: 25},
+		CooldownSeconds: 60,
+	}}, nil)
+	mockRuleDao.On("ClaimRuleFiring", ctx, "home1", "rule-1", mock.Anything, mock.Anything, int64(60)).Return(hdError.ErrUpdatingRule.New())
+
+	value := 30.0
+	_, err := service.EvaluateRules(ctx, request.TelemetryReadingRequest{DeviceID: "sensor-1", Metric: "temperature", Value: &value, Timestamp: time.Now().UnixMilli()})
+
+	assert.ErrorIs(t, err, hdError.ErrUpdatingRule)
+	assert.Empty(t, commandQueue.Messages())
+}
+
+func TestEvaluateRules_ReadingAlreadyFired(t *testing.T) {
+	mockRuleDao := new(hdMock.MockRuleDao)
+	commandQueue := queue.NewInMemoryCommandQueue()
+	deviceService := HomeDeviceServiceImpl{homeDeviceDao: new(hdMock.MockHomeDeviceDao)}
+	service := RuleServiceImpl{ruleDao: mockRuleDao, deviceService: deviceService, commandService: NewDeviceCommandServiceImpl(dao.NewInMemoryDeviceCommandDao(), deviceService, commandQueue)}
+	ctx := context.Background()
+	readingAt := time.Now().UnixMilli()
+
+	mockRuleDao.On("ListTriggeredRules", ctx, "sensor-1").Return([]hdREsponse.RuleResponse{{
+		ID:              "rule-1",
+		HomeID:          "home1",
+		Enabled:         true,
+		Trigger:         hdREsponse.RuleTriggerResponse{DeviceID: "sensor-1", Metric: "temperature", Operator: "gt", Value: 25},
+		CooldownSeconds: 60,
+	}}, nil)
+	mockRuleDao.On("ClaimRuleFiring", ctx, "home1", "rule-1", readingAt, mock.Anything, int64(60)).Return(hdError.ErrRuleAlreadyFired.New())
+
+	value := 30.0
+	executions, err := service.EvaluateRules(ctx, request.TelemetryReadingRequest{DeviceID: "sensor-1", Metric: "temperature", Value: &value, Timestamp: readingAt})
+
+	assert.Nil(t, err)
+	assert.Empty(t, executions)
+	assert.Empty(t, commandQueue.Messages())
+}
+
+func TestEvaluateRules_NotConfigured(t *testing.T) {
+	value := 1.0
+
+	executions, err := RuleServiceImpl{}.EvaluateRules(context.Background(), request.TelemetryReadingRequest{DeviceID: "sensor-1", Metric: "motion", Value: &value})
+
+	assert.Nil(t, err)
+	assert.Empty(t, executions)
+}
+
+func TestCompareRuleValue(t *testing.T) {
+	assert.True(t, compareRuleValue(constants.RuleOperatorGreaterThan, 2, 1))
+	assert.False(t, compareRuleValue(constants.RuleOperatorGreaterThan, 1, 1))
+	assert.True(t, compareRuleValue(constants.RuleOperatorGreaterThanOrEqual, 1, 1))
+	assert.True(t, compareRuleValue(constants.RuleOperatorLessThan, 0, 1))
+	assert.True(t, compareRuleValue(constants.RuleOperatorLessThanOrEqual, 1, 1))
+	assert.True(t, compareRuleValue(constants.RuleOperatorEqual, 1, 1))
+	assert.True(t, compareRuleValue(constants.RuleOperatorNotEqual, 0, 1))
+	assert.False(t, compareRuleValue("between", 1, 1))
+}
+
+func TestIsInTimeWindow(t *testing.T) {
+	at := func(hour int, minute int) time.Time {
+		return time.Date(2024, 1, 15, hour, minute, 0, 0, time.UTC)
+	}
+	night := &hdREsponse.RuleTimeWindowResponse{From: "22:00", To: "06:00", Timezone: "UTC"}
+	evening := &hdREsponse.RuleTimeWindowResponse{From: "18:00", To: "22:00", Timezone: "Europe/Madrid"}
+
+	assert.True(t, isInTimeWindow(nil, at(12, 0)))
+	assert.True(t, isInTimeWindow(night, at(22, 0)))
+	assert.True(t, isInTimeWindow(night, at(3, 30)))
+	assert.False(t, isInTimeWindow(night, at(6, 0)))
+	assert.False(t, isInTimeWindow(night, at(12, 0)))
+	// 17:30 UTC is 18:30 in Madrid in January
+	assert.True(t, isInTimeWindow(evening, at(17, 30)))
+	assert.False(t, isInTimeWindow(evening, at(21, 30)))
+}
+
+// ruleTestService changes the homes, their devices and their rules.
+type ruleTestService struct {
+	HomeService
+	HomeDeviceService
+	RuleService
+}
+
+// ruleTestEnv is a home with a motion sensor and a light, and a service with
+// in-memory daos and queue.
+type ruleTestEnv struct {
+	service      ruleTestService
+	commandQueue *queue.InMemoryCommandQueue
+	home         *hdREsponse.HomeResponse
+	sensor       *hdREsponse.HomdeDeviceResponse
+	light        *hdREsponse.HomdeDeviceResponse
+}
+
+func newRuleServiceForTesting(t *testing.T) ruleTestEnv {
+	t.Helper()
+
+	commandQueue := queue.NewInMemoryCommandQueue()
+	env := newRuleServiceWithQueueForTesting(t, commandQueue)
+	env.commandQueue = commandQueue
+
+	return env
+}
+
+// newRuleServiceWithQueueForTesting sends the commands of the rules through
+// the given queue.
+func newRuleServiceWithQueueForTesting(t *testing.T, commandQueue queue.CommandQueue) ruleTestEnv {
+	t.Helper()
+
+	homeDao := dao.NewInMemoryHomeDao()
+	ruleDao := dao.NewInMemoryRuleDao()
+	deviceService := NewHomeDeviceServiceImpl2(dao.NewInMemoryHomeDeviceDao(), WithHomeDao(homeDao))
+	commandService := NewDeviceCommandServiceImpl(dao.NewInMemoryDeviceCommandDao(), deviceService, commandQueue)
+
+	env := ruleTestEnv{service: ruleTestService{
+		HomeService:       NewHomeServiceImpl(homeDao, deviceService, nil, ruleDao, nil, nil),
+		HomeDeviceService: deviceService,
+		RuleService:       NewRuleServiceImpl(ruleDao, dao.NewInMemoryRuleExecutionDao(), homeDao, deviceService, commandService),
+	}}
+	ctx := context.Background()
+
+	var err error
+	env.home, err = env.service.CreateHome(ctx, request.CreateHomeRequest{Name: "Beach House", Timezone: "Europe/Madrid", Owner: "user-1"})
+	if err != nil {
+		t.Fatalf("Unexpected error creating the home for testing. Error: %v", err)
+	}
+
+	env.sensor, err = env.service.CreateHomeDevice(ctx, request.CreateDeviceRequest{MAC: "00:11:22:33:44:01", Name: "Hallway Sensor", Type: "sensor", HomeID: env.home.ID})
+	if err != nil {
+		t.Fatalf("Unexpected error creating the sensor for testing. Error: %v", err)
+	}
+
+	env.light, err = env.service.CreateHomeDevice(ctx, request.CreateDeviceRequest{MAC: "00:11:22:33:44:02", Name: "Hallway Light", Type: "light", HomeID: env.home.ID})
+	if err != nil {
+		t.Fatalf("Unexpected error creating the light for testing. Error: %v", err)
+	}
+
+	return env
+}
+
+func (env ruleTestEnv) motionRule() request.RuleRequest {
+	value := 1.0
+	return request.RuleRequest{
+		Name:       "Hallway light at night",
+		Trigger:    request.RuleTrigger{DeviceID: env.sensor.ID, Metric: "motion", Operator: constants.RuleOperatorEqual, Value: &value},
+		TimeWindow: &request.RuleTimeWindow{From: "22:00", To: "06:00"},
+		Action:     request.RuleAction{DeviceID: env.light.ID, Command: "turnOn"},
+	}
+}
+
+func (env ruleTestEnv) saveRule(t *testing.T, rule request.RuleRequest) *hdREsponse.RuleResponse {
+	t.Helper()
+
+	saved, err := env.service.CreateRule(context.Background(), env.home.ID, rule)
+	if err != nil {
+		t.Fatalf("Unexpected error creating the rule for testing. Error: %v", err)
+	}
+
+	return saved
+}
+
+func (env ruleTestEnv) reading(metric string, value float64) request.TelemetryReadingRequest {
+	return request.TelemetryReadingRequest{DeviceID: env.sensor.ID, Metric: metric, Value: &value, Timestamp: time.Now().UnixMilli()}
+}
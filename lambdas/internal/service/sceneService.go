@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	constants "github.com/odhoman/home-devices/internal/constants"
+	dao "github.com/odhoman/home-devices/internal/dao"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+type SceneService interface {
+	CreateScene(ctx context.Context, homeId string, scene request.SceneRequest) (*response.SceneResponse, error)
+	GetScene(ctx context.Context, homeId string, id string) (*response.SceneResponse, error)
+	ListScenes(ctx context.Context, homeId string) (*response.SceneListResponse, error)
+	UpdateScene(ctx context.Context, scene request.SceneRequest, homeId string, id string, expectedVersion int64) error
+	DeleteScene(ctx context.Context, homeId string, id string, expectedVersion int64) error
+	ActivateScene(ctx context.Context, homeId string, id string) (*response.SceneActivationResponse, error)
+}
+
+type SceneServiceImpl struct {
+	sceneDao           dao.SceneDao
+	homeDao            dao.HomeDao
+	deviceService      HomeDeviceService
+	deviceStateService DeviceStateService
+}
+
+// CreateScene checks that the devices of the scene are devices of the home
+// and that their states are valid for their types, and saves the scene.
+func (sSI SceneServiceImpl) CreateScene(ctx context.Context, homeId string, scene request.SceneRequest) (*response.SceneResponse, error) {
+
+	if err := sSI.checkSceneDao(hdError.ErrSceneNotCreated); err != nil {
+		return nil, err
+	}
+
+	if err := checkHomeExists(ctx, sSI.homeDao, homeId, hdError.ErrHomeNotFound); err != nil {
+		return nil, err
+	}
+
+	if err := sSI.validateScene(ctx, homeId, scene); err != nil {
+		return nil, err
+	}
+
+	return sSI.sceneDao.SaveScene(ctx, homeId, scene)
+}
+
+func (sSI SceneServiceImpl) GetScene(ctx context.Context, homeId string, id string) (*response.SceneResponse, error) {
+
+	if err := sSI.checkSceneDao(hdError.ErrGettingScene); err != nil {
+		return nil, err
+	}
+
+	return sSI.sceneDao.GetScene(ctx, homeId, id)
+}
+
+func (sSI SceneServiceImpl) ListScenes(ctx context.Context, homeId string) (*response.SceneListResponse, error) {
+
+	if err := sSI.checkSceneDao(hdError.ErrListingScenes); err != nil {
+		return nil, err
+	}
+
+	return sSI.sceneDao.ListScenes(ctx, homeId)
+}
+
+// UpdateScene replaces the scene, with the same checks as CreateScene.
+func (sSI SceneServiceImpl) UpdateScene(ctx context.Context, scene request.SceneRequest, homeId string, id string, expectedVersion int64) error {
+
+	if err := sSI.checkSceneDao(hdError.ErrUpdatingScene); err != nil {
+		return err
+	}
+
+	if err := checkHomeExists(ctx, sSI.homeDao, homeId, hdError.ErrHomeNotFound); err != nil {
+		return err
+	}
+
+	if err := sSI.validateScene(ctx, homeId, scene); err != nil {
+		return err
+	}
+
+	return sSI.sceneDao.UpdateScene(ctx, scene, homeId, id, expectedVersion)
+}
+
+func (sSI SceneServiceImpl) DeleteScene(ctx context.Context, homeId string, id string, expectedVersion int64) error {
+
+	if err := sSI.checkSceneDao(hdError.ErrDeletingScene); err != nil {
+		return err
+	}
+
+	return sSI.sceneDao.DeleteScene(ctx, homeId, id, expectedVersion)
+}
+
+// ActivateScene sets the desired state of every device of the scene, up to
+// MaxSceneActivationConcurrency devices at the same time.
+func (sSI SceneServiceImpl) ActivateScene(ctx context.Context, homeId string, id string) (*response.SceneActivationResponse, error) {
+
+	if err := sSI.checkSceneDao(hdError.ErrActivatingScene); err != nil {
+		return nil, err
+	}
+
+	if sSI.deviceStateService == nil {
+		log.Printf("The device state is not configured")
+		return nil, hdError.ErrActivatingScene.New()
+	}
+
+	scene, err := sSI.sceneDao.GetScene(ctx, homeId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]response.SceneDeviceResultResponse, len(scene.Devices))
+	semaphore := make(chan struct{}, constants.MaxSceneActivationConcurrency)
+	var waitGroup sync.WaitGroup
+
+	for index, device := range scene.Devices {
+		waitGroup.Add(1)
+		go func(index int, device response.SceneDeviceResponse) {
+			defer waitGroup.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			results[index] = sSI.applySceneDevice(ctx, homeId, device)
+		}(index, device)
+	}
+
+	waitGroup.Wait()
+
+	return &response.SceneActivationResponse{
+		SceneID:     scene.ID,
+		HomeID:      homeId,
+		ActivatedAt: time.Now().Unix(),
+		Results:     results,
+	}, nil
+}
+
+// applySceneDevice sets the desired state of a device of the scene, checking
+// again that it is a device of the home.
+func (sSI SceneServiceImpl) applySceneDevice(ctx context.Context, homeId string, device response.SceneDeviceResponse) response.SceneDeviceResultResponse {
+
+	result := response.SceneDeviceResultResponse{
+		DeviceID: device.DeviceID,
+		Status:   constants.SceneDeviceStatusFailed,
+	}
+
+	homeDevice, err := getDeviceOfHome(ctx, sSI.deviceService, homeId, device.DeviceID)
+	if err != nil {
+		result.Error = hdError.From(err).ErrorMessage
+		return result
+	}
+
+	if homeDevice == nil {
+		result.Error = "the device is not a device of the home"
+		return result
+	}
+
+	state, err := sSI.deviceStateService.UpdateDeviceState(ctx, device.DeviceID, request.UpdateDeviceStateRequest{Desired: device.State})
+	if err != nil {
+		log.Printf("Error setting the state of the device %v of a scene of the home %v: %v", device.DeviceID, homeId, err)
+		result.Error = hdError.From(err).ErrorMessage
+		return result
+	}
+
+	result.Status = constants.SceneDeviceStatusApplied
+	result.DesiredVersion = state.Desired.Version
+
+	return result
+}
+
+// validateScene checks that every device is a device of the home, only once,
+// and that its state is valid for its type.
+func (sSI SceneServiceImpl) validateScene(ctx context.Context, homeId string, scene request.SceneRequest) error {
+
+	var validationErrors []string
+	seen := map[string]bool{}
+
+	for _, sceneDevice := range scene.Devices {
+
+		if seen[sceneDevice.DeviceID] {
+			validationErrors = append(validationErrors, fmt.Sprintf("the device %v is more than once in the scene", sceneDevice.DeviceID))
+			continue
+		}
+		seen[sceneDevice.DeviceID] = true
+
+		device, err := getDeviceOfHome(ctx, sSI.deviceService, homeId, sceneDevice.DeviceID)
+		if err != nil {
+			return err
+		}
+
+		if device == nil {
+			validationErrors = append(validationErrors, fmt.Sprintf("the device %v is not a device of the home", sceneDevice.DeviceID))
+			continue
+		}
+
+		if err := validateDeviceState(device.Type, request.UpdateDeviceStateRequest{Desired: sceneDevice.State}); err != nil {
+			validationErrors = append(validationErrors, fmt.Sprintf("the device %v: %v", sceneDevice.DeviceID, hdError.From(err).ErrorMessage))
+		}
+	}
+
+	if len(validationErrors) == 0 {
+		return nil
+	}
+
+	log.Printf("Invalid scene for the home %v: %v", homeId, validationErrors)
+	return hdError.ErrInvalidScene.WithMessage(strings.Join(validationErrors, "; "))
+}
+
+// deleteAllScenes deletes the scenes of a home that is being deleted.
+func deleteAllScenes(ctx context.Context, sceneDao dao.SceneDao, homeId string) error {
+
+	if sceneDao == nil {
+		return nil
+	}
+
+	scenes, err := sceneDao.ListScenes(ctx, homeId)
+	if err != nil {
+		return err
+	}
+
+	for _, scene := range scenes.Scenes {
+		if err := sceneDao.DeleteScene(ctx, homeId, scene.ID, 0); err != nil && !errors.Is(err, hdError.ErrSceneNotFound) {
+			log.Printf("Error deleting the scene %v of the home %v: %v", scene.ID, homeId, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (sSI SceneServiceImpl) checkSceneDao(errorDefinition hdError.Definition) error {
+
+	if sSI.sceneDao == nil {
+		log.Printf("The scenes are not configured")
+		return errorDefinition.New()
+	}
+
+	return nil
+}
+
+// NewSceneServiceImplFromConfig uses the DynamoDB daos.
+func NewSceneServiceImplFromConfig(cfg aws.Config) SceneService {
+	client := dynamodb.NewFromConfig(cfg)
+	deviceService := newHomeDeviceServiceFromConfig(cfg, client)
+	deviceStateService := NewDeviceStateServiceImpl(dao.DeviceShadowDaoImpl{DynamoDbApi: client}, deviceService)
+	return NewSceneServiceImpl(dao.SceneDaoImpl{DynamoDbApi: client}, dao.HomeDaoImpl{DynamoDbApi: client}, deviceService, deviceStateService)
+}
+
+// NewSceneServiceImpl checks the devices of the scenes with the deviceService
+// and sets their desired state with the deviceStateService.
+func NewSceneServiceImpl(sceneDao dao.SceneDao, homeDao dao.HomeDao, deviceService HomeDeviceService, deviceStateService DeviceStateService) SceneService {
+	return SceneServiceImpl{sceneDao: sceneDao, homeDao: homeDao, deviceService: deviceService, deviceStateService: deviceStateService}
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/odhoman/home-devices/internal/constants"
+	"github.com/odhoman/home-devices/internal/dao"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	hdMock "github.com/odhoman/home-devices/internal/mock"
+	"github.com/odhoman/home-devices/internal/request"
+	hdREsponse "github.com/odhoman/home-devices/internal/response"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateScene(t *testing.T) {
+	env := newSceneServiceForTesting(t)
+
+	scene, err := env.service.CreateScene(context.Background(), env.home.ID, env.movieNight())
+
+	assert.Nil(t, err)
+	assert.Equal(t, env.home.ID, scene.HomeID)
+	assert.Equal(t, "Movie night", scene.Name)
+	assert.Len(t, scene.Devices, 2)
+}
+
+func TestCreateScene_UnknownHome(t *testing.T) {
+	env := newSceneServiceForTesting(t)
+
+	_, err := env.service.CreateScene(context.Background(), "home99999", env.movieNight())
+
+	assert.ErrorIs(t, err, hdError.ErrHomeNotFound)
+}
+
+func TestCreateScene_InvalidForTheDevices(t *testing.T) {
+	env := newSceneServiceForTesting(t)
+	ctx := context.Background()
+
+	other, _ := env.service.CreateHome(ctx, request.CreateHomeRequest{Name: "Mountain House", Timezone: "UTC", Owner: "user-1"})
+	device, _ := env.service.CreateHomeDevice(ctx, request.CreateDeviceRequest{MAC: "00:11:22:33:44:99", Name: "Porch Light", Type: "light", HomeID: other.ID})
+
+	scene := env.movieNight()
+	scene.Devices[0].State = map[string]interface{}{"brightness": float64(120)}
+	scene.Devices = append(scene.Devices,
+		request.SceneDevice{DeviceID: env.plug.ID, State: map[string]interface{}{"on": true}},
+		request.SceneDevice{DeviceID: device.ID, State: map[string]interface{}{"on": true}},
+	)
+
+	_, err := env.service.CreateScene(ctx, env.home.ID, scene)
+
+	assert.ErrorIs(t, err, hdError.ErrInvalidScene)
+	assert.EqualError(t, err, "the device "+env.light.ID+": brightness must be between 0 and 100; "+
+		"the device "+env.plug.ID+" is more than once in the scene; "+
+		"the device "+device.ID+" is not a device of the home")
+}
+
+func TestCreateScene_NotConfigured(t *testing.T) {
+	service := SceneServiceImpl{}
+
+	_, err := service.CreateScene(context.Background(), "home1", request.SceneRequest{})
+
+	assert.ErrorIs(t, err, hdError.ErrSceneNotCreated)
+}
+
+func TestUpdateScene_Validates(t *testing.T) {
+	env := newSceneServiceForTesting(t)
+	ctx := context.Background()
+	saved := env.saveScene(t, env.movieNight())
+
+	scene := env.movieNight()
+	scene.Devices[1].State = map[string]interface{}{"power": float64(10)}
+
+	err := env.service.UpdateScene(ctx, scene, env.home.ID, saved.ID, saved.Version)
+	assert.ErrorIs(t, err, hdError.ErrInvalidScene)
+
+	scene = env.movieNight()
+	scene.Name = "Good night"
+	scene.Devices = scene.Devices[:1]
+
+	assert.Nil(t, env.service.UpdateScene(ctx, scene, env.home.ID, saved.ID, saved.Version))
+
+	updated, _ := env.service.GetScene(ctx, env.home.ID, saved.ID)
+	assert.Equal(t, "Good night", updated.Name)
+	assert.Len(t, updated.Devices, 1)
+}
+
+func TestActivateScene(t *testing.T) {
+	env := newSceneServiceForTesting(t)
+	ctx := context.Background()
+	saved := env.saveScene(t, env.movieNight())
+
+	activation, err := env.service.ActivateScene(ctx, env.home.ID, saved.ID)
+
+	assert.Nil(t, err)
+	assert.Equal(t, saved.ID, activation.SceneID)
+	assert.Equal(t, []hdREsponse.SceneDeviceResultResponse{
+		{DeviceID: env.light.ID, Status: constants.SceneDeviceStatusApplied, DesiredVersion: 1},
+		{DeviceID: env.plug.ID, Status: constants.SceneDeviceStatusApplied, DesiredVersion: 1},
+	}, activation.Results)
+
+	state, _ := env.service.GetDeviceState(ctx, env.light.ID)
+	assert.Equal(t, map[string]interface{}{"on": true, "brightness": float64(20)}, state.Desired.State)
+}
+
+func TestActivateScene_DeviceDeletedAfterSavingTheScene(t *testing.T) {
+	env := newSceneServiceForTesting(t)
+	ctx := context.Background()
+	saved := env.saveScene(t, env.movieNight())
+
+	if _, err := env.service.DeleteHomeDevice(ctx, env.plug.ID, 0); err != nil {
+		t.Fatalf("Unexpected error deleting the plug. Error: %v", err)
+	}
+
+	activation, err := env.service.ActivateScene(ctx, env.home.ID, saved.ID)
+
+	assert.Nil(t, err)
+	assert.Equal(t, constants.SceneDeviceStatusApplied, activation.Results[0].Status)
+	assert.Equal(t, hdREsponse.SceneDeviceResultResponse{
+		DeviceID: env.plug.ID,
+		Status:   constants.SceneDeviceStatusFailed,
+		Error:    "the device is not a device of the home",
+	}, activation.Results[1])
+}
+
+func TestActivateScene_MoreDevicesThanTheConcurrency(t *testing.T) {
+	env := newSceneServiceForTesting(t)
+	ctx := context.Background()
+
+	scene := request.SceneRequest{Name: "Everything on"}
+	for i := 0; i < constants.MaxSceneActivationConcurrency*2+1; i++ {
+		device, err := env.service.CreateHomeDevice(ctx, request.CreateDeviceRequest{MAC: fmt.Sprintf("00:11:22:33:55:%02d", i), Name: "Plug", Type: "plug", HomeID: env.home.ID})
+		if err != nil {
+			t.Fatalf("Unexpected error creating the plug for testing. Error: %v", err)
+		}
+		scene.Devices = append(scene.Devices, request.SceneDevice{DeviceID: device.ID, State: map[string]interface{}{"on": true}})
+	}
+	saved := env.saveScene(t, scene)
+
+	activation, err := env.service.ActivateScene(ctx, env.home.ID, saved.ID)
+
+	assert.Nil(t, err)
+	assert.Len(t, activation.Results, len(scene.Devices))
+	for i, result := range activation.Results {
+		assert.Equal(t, scene.Devices[i].DeviceID, result.DeviceID)
+		assert.Equal(t, constants.SceneDeviceStatusApplied, result.Status)
+	}
+}
+
+func TestActivateScene_NotFound(t *testing.T) {
+	env := newSceneServiceForTesting(t)
+
+	_, err := env.service.ActivateScene(context.Background(), env.home.ID, "00000000-0000-0000-0000-000000000000")
+
+	assert.ErrorIs(t, err, hdError.ErrSceneNotFound)
+}
+
+func TestActivateScene_NotConfigured(t *testing.T) {
+	service := SceneServiceImpl{sceneDao: dao.NewInMemorySceneDao(), deviceService: HomeDeviceServiceImpl{homeDeviceDao: new(hdMock.MockHomeDeviceDao)}}
+
+	_, err := service.ActivateScene(context.Background(), "home1", "scene1")
+
+	assert.ErrorIs(t, err, hdError.ErrActivatingScene)
+}
+
+func TestDeleteHome_DeletesTheScenes(t *testing.T) {
+	env := newSceneServiceForTesting(t)
+	ctx := context.Background()
+	saved := env.saveScene(t, env.movieNight())
+
+	err := env.service.DeleteHome(ctx, env.home.ID, request.DeleteHomeRequest{Strategy: constants.DeleteHomeStrategyCascade}, 0)
+	assert.Nil(t, err)
+
+	_, err = env.service.GetScene(ctx, env.home.ID, saved.ID)
+	assert.ErrorIs(t, err, hdError.ErrSceneNotFound)
+}
+
+// sceneTestService changes the homes, their devices and their scenes.
+type sceneTestService struct {
+	HomeService
+	HomeDeviceService
+	DeviceStateService
+	SceneService
+}
+
+type sceneTestEnv struct {
+	service sceneTestService
+	home    *hdREsponse.HomeResponse
+	light   *hdREsponse.HomdeDeviceResponse
+	plug    *hdREsponse.HomdeDeviceResponse
+}
+
+func newSceneServiceForTesting(t *testing.T) sceneTestEnv {
+	t.Helper()
+
+	homeDao := dao.NewInMemoryHomeDao()
+	sceneDao := dao.NewInMemorySceneDao()
+	deviceService := NewHomeDeviceServiceImpl2(dao.NewInMemoryHomeDeviceDao(), WithHomeDao(homeDao))
+	deviceStateService := NewDeviceStateServiceImpl(dao.NewInMemoryDeviceShadowDao(), deviceService)
+
+	env := sceneTestEnv{service: sceneTestService{
+		HomeService:        NewHomeServiceImpl(homeDao, deviceService, nil, nil, nil, sceneDao),
+		HomeDeviceService:  deviceService,
+		DeviceStateService: deviceStateService,
+		SceneService:       NewSceneServiceImpl(sceneDao, homeDao, deviceService, deviceStateService),
+	}}
+	ctx := context.Background()
+
+	var err error
+	env.home, err = env.service.CreateHome(ctx, request.CreateHomeRequest{Name: "Beach House", Timezone: "Europe/Madrid", Owner: "user-1"})
+	if err != nil {
+		t.Fatalf("Unexpected error creating the home for testing. Error: %v", err)
+	}
+
+	env.light, err = env.service.CreateHomeDevice(ctx, request.CreateDeviceRequest{MAC: "00:11:22:33:44:01", Name: "Living Room Light", Type: "light", HomeID: env.home.ID})
+	if err != nil {
+		t.Fatalf("Unexpected error creating the light for testing. Error: %v", err)
+	}
+
+	env.plug, err = env.service.CreateHomeDevice(ctx, request.CreateDeviceRequest{MAC: "00:11:22:33:44:02", Name: "Popcorn Machine", Type: "plug", HomeID: env.home.ID})
+	if err != nil {
+		t.Fatalf("Unexpected error creating the plug for testing. Error: %v", err)
+	}
+
+	return env
+}
+
+func (env sceneTestEnv) movieNight() request.SceneRequest {
+	return request.SceneRequest{
+		Name: "Movie night",
+		Devices: []request.SceneDevice{
+			{DeviceID: env.light.ID, State: map[string]interface{}{"on": true, "brightness": float64(20)}},
+			{DeviceID: env.plug.ID, State: map[string]interface{}{"on": true}},
+		},
+	}
+}
+
+func (env sceneTestEnv) saveScene(t *testing.T, scene request.SceneRequest) *hdREsponse.SceneResponse {
+	t.Helper()
+
+	saved, err := env.service.CreateScene(context.Background(), env.home.ID, scene)
+	if err != nil {
+		t.Fatalf("Unexpected error creating the scene for testing. Error: %v", err)
+	}
+
+	return saved
+}
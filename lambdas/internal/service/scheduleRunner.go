@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	constants "github.com/odhoman/home-devices/internal/constants"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+)
+
+// RunDueSchedules sends the command of every schedule whose next run is not
+// after now to its target, through SendDeviceCommand, and moves the schedule to
+// its next run after now.
+func (sSI ScheduleServiceImpl) RunDueSchedules(ctx context.Context, now time.Time) ([]response.ScheduleRunResponse, error) {
+
+	runs := []response.ScheduleRunResponse{}
+
+	if err := sSI.checkScheduleDao(hdError.ErrListingSchedules); err != nil {
+		return runs, err
+	}
+
+	schedules, err := sSI.scheduleDao.ListDueSchedules(ctx, now.Unix())
+	if err != nil {
+		return runs, err
+	}
+
+	for _, schedule := range schedules {
+
+		var nextRunAt int64
+		spec, location, parseErr := parseScheduleSpec(schedule)
+		if parseErr != nil {
+			// it was valid when saved, stop it instead of failing every minute
+			log.Printf("The schedule %v of the home %v does not run anymore: %v", schedule.ID, schedule.HomeID, parseErr)
+		} else {
+			nextRunAt = nextScheduleRun(spec, location, now)
+		}
+
+		if err := sSI.scheduleDao.ClaimScheduleRun(ctx, schedule.HomeID, schedule.ID, schedule.NextRunAt, nextRunAt); err != nil {
+			if errors.Is(err, hdError.ErrScheduleAlreadyRun) || errors.Is(err, hdError.ErrScheduleNotFound) {
+				continue
+			}
+			return runs, err
+		}
+
+		run := response.ScheduleRunResponse{
+			ScheduleID: schedule.ID,
+			HomeID:     schedule.HomeID,
+			RunAt:      schedule.NextRunAt,
+			Status:     constants.ScheduleRunStatusDispatched,
+			CommandIDs: []string{},
+		}
+
+		if now.Unix()-schedule.NextRunAt > constants.MaxScheduleDelaySeconds {
+			log.Printf("The run at %v of the schedule %v of the home %v is too late, it is missed", schedule.NextRunAt, schedule.ID, schedule.HomeID)
+			run.Status = constants.ScheduleRunStatusMissed
+			runs = append(runs, run)
+			continue
+		}
+
+		deviceIds, err := sSI.resolveScheduleTarget(ctx, schedule)
+		if err != nil {
+			log.Printf("Error resolving the target of the schedule %v of the home %v: %v", schedule.ID, schedule.HomeID, err)
+			run.Errors = append(run.Errors, hdError.From(err).ErrorMessage)
+		}
+
+		for _, deviceId := range deviceIds {
+			command, err := sSI.commandService.SendDeviceCommand(ctx, deviceId, request.SendDeviceCommandRequest{
+				Name:   schedule.Command,
+				Params: schedule.Params,
+			})
+			if err != nil {
+				log.Printf("Error sending the command of the schedule %v of the home %v to the device %v: %v", schedule.ID, schedule.HomeID, deviceId, err)
+				run.Errors = append(run.Errors, fmt.Sprintf("%v: %v", deviceId, hdError.From(err).ErrorMessage))
+				continue
+			}
+			run.CommandIDs = append(run.CommandIDs, command.ID)
+		}
+
+		runs = append(runs, run)
+	}
+
+	return runs, nil
+}
+
+// resolveScheduleTarget returns the ids of the devices the command is sent to:
+// the target device, or the devices of the home, or of the room, with the
+// target type when they are read.
+func (sSI ScheduleServiceImpl) resolveScheduleTarget(ctx context.Context, schedule response.ScheduleResponse) ([]string, error) {
+
+	if schedule.Target.DeviceID != "" {
+		return []string{schedule.Target.DeviceID}, nil
+	}
+
+	devices, err := listAllHomeDevices(ctx, sSI.deviceService, schedule.HomeID, schedule.Target.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceIds := []string{}
+	for _, device := range devices {
+		if device.Type == schedule.Target.DeviceType {
+			deviceIds = append(deviceIds, device.ID)
+		}
+	}
+
+	return deviceIds, nil
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	dao "github.com/odhoman/home-devices/internal/dao"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	queue "github.com/odhoman/home-devices/internal/queue"
+	request "github.com/odhoman/home-devices/internal/request"
+	response "github.com/odhoman/home-devices/internal/response"
+	hdSchedule "github.com/odhoman/home-devices/internal/schedule"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/google/uuid"
+)
+
+type ScheduleService interface {
+	CreateSchedule(ctx context.Context, homeId string, schedule request.ScheduleRequest) (*response.ScheduleResponse, error)
+	GetSchedule(ctx context.Context, homeId string, id string) (*response.ScheduleResponse, error)
+	ListSchedules(ctx context.Context, homeId string) (*response.ScheduleListResponse, error)
+	UpdateSchedule(ctx context.Context, schedule request.ScheduleRequest, homeId string, id string, expectedVersion int64) error
+	DeleteSchedule(ctx context.Context, homeId string, id string, expectedVersion int64) error
+	RunDueSchedules(ctx context.Context, now time.Time) ([]response.ScheduleRunResponse, error)
+}
+
+type ScheduleServiceImpl struct {
+	scheduleDao    dao.ScheduleDao
+	homeDao        dao.HomeDao
+	roomDao        dao.RoomDao
+	deviceService  HomeDeviceService
+	commandService DeviceCommandService
+}
+
+// CreateSchedule checks that the cron expression or the RRULE runs, that the
+// target is a device or a room of the home and that the command is valid for
+// the target, and saves the schedule with its next run.
+func (sSI ScheduleServiceImpl) CreateSchedule(ctx context.Context, homeId string, scheduleRequest request.ScheduleRequest) (*response.ScheduleResponse, error) {
+
+	if err := sSI.checkScheduleDao(hdError.ErrScheduleNotCreated); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	schedule, err := sSI.prepareSchedule(ctx, homeId, scheduleRequest, now.Unix(), now)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule.ID = uuid.New().String()
+	schedule.Version = 1
+
+	return sSI.scheduleDao.SaveSchedule(ctx, schedule)
+}
+
+func (sSI ScheduleServiceImpl) GetSchedule(ctx context.Context, homeId string, id string) (*response.ScheduleResponse, error) {
+
+	if err := sSI.checkScheduleDao(hdError.ErrGettingSchedule); err != nil {
+		return nil, err
+	}
+
+	return sSI.scheduleDao.GetSchedule(ctx, homeId, id)
+}
+
+func (sSI ScheduleServiceImpl) ListSchedules(ctx context.Context, homeId string) (*response.ScheduleListResponse, error) {
+
+	if err := sSI.checkScheduleDao(hdError.ErrListingSchedules); err != nil {
+		return nil, err
+	}
+
+	return sSI.scheduleDao.ListSchedules(ctx, homeId)
+}
+
+// UpdateSchedule replaces the schedule, with the same checks as CreateSchedule,
+// and computes its next run again.
+func (sSI ScheduleServiceImpl) UpdateSchedule(ctx context.Context, scheduleRequest request.ScheduleRequest, homeId string, id string, expectedVersion int64) error {
+
+	if err := sSI.checkScheduleDao(hdError.ErrUpdatingSchedule); err != nil {
+		return err
+	}
+
+	current, err := sSI.scheduleDao.GetSchedule(ctx, homeId, id)
+	if err != nil {
+		return err
+	}
+
+	schedule, err := sSI.prepareSchedule(ctx, homeId, scheduleRequest, current.CreatedAt, time.Now())
+	if err != nil {
+		return err
+	}
+
+	schedule.ID = id
+
+	return sSI.scheduleDao.UpdateSchedule(ctx, schedule, expectedVersion)
+}
+
+func (sSI ScheduleServiceImpl) DeleteSchedule(ctx context.Context, homeId string, id string, expectedVersion int64) error {
+
+	if err := sSI.checkScheduleDao(hdError.ErrDeletingSchedule); err != nil {
+		return err
+	}
+
+	return sSI.scheduleDao.DeleteSchedule(ctx, homeId, id, expectedVersion)
+}
+
+// prepareSchedule validates the schedule for the home and builds it with its
+// timezone and its next run.
+func (sSI ScheduleServiceImpl) prepareSchedule(ctx context.Context, homeId string, scheduleRequest request.ScheduleRequest, createdAt int64, now time.Time) (response.ScheduleResponse, error) {
+
+	timezone, err := getHomeTimezone(ctx, sSI.homeDao, homeId)
+	if err != nil {
+		return response.ScheduleResponse{}, err
+	}
+
+	schedule := response.ScheduleResponse{
+		HomeID:   homeId,
+		Name:     scheduleRequest.Name,
+		Enabled:  scheduleRequest.Enabled == nil || *scheduleRequest.Enabled,
+		Cron:     scheduleRequest.Cron,
+		RRule:    scheduleRequest.RRule,
+		Timezone: resolveValue(scheduleRequest.Timezone, timezone),
+		Target: response.ScheduleTargetResponse{
+			DeviceID:   scheduleRequest.Target.DeviceID,
+			DeviceType: scheduleRequest.Target.DeviceType,
+			RoomID:     scheduleRequest.Target.RoomID,
+		},
+		Command:    scheduleRequest.Command,
+		Params:     scheduleRequest.Params,
+		CreatedAt:  createdAt,
+		ModifiedAt: now.Unix(),
+	}
+
+	if schedule.Params == nil {
+		schedule.Params = map[string]interface{}{}
+	}
+
+	nextRunAt, err := sSI.validateSchedule(ctx, schedule, now)
+	if err != nil {
+		return schedule, err
+	}
+
+	// a disabled schedule is not due, it runs again from the time it is
+	// enabled
+	if schedule.Enabled {
+		schedule.NextRunAt = nextRunAt
+	}
+
+	return schedule, nil
+}
+
+// validateSchedule checks the times, the target and the command of the
+// schedule, and returns its next run after now.
+func (sSI ScheduleServiceImpl) validateSchedule(ctx context.Context, schedule response.ScheduleResponse, now time.Time) (int64, error) {
+
+	var validationErrors []string
+	var nextRunAt int64
+
+	spec, location, parseErr := parseScheduleSpec(schedule)
+	if parseErr != nil {
+		validationErrors = append(validationErrors, parseErr.Error())
+	} else if nextRunAt = nextScheduleRun(spec, location, now); nextRunAt == 0 {
+		validationErrors = append(validationErrors, "the schedule does not run after now")
+	}
+
+	command := request.SendDeviceCommandRequest{Name: schedule.Command, Params: schedule.Params}
+
+	if schedule.Target.DeviceID != "" {
+		device, err := getDeviceOfHome(ctx, sSI.deviceService, schedule.HomeID, schedule.Target.DeviceID)
+		if err != nil {
+			return 0, err
+		}
+
+		if device == nil {
+			validationErrors = append(validationErrors, fmt.Sprintf("the target device %v is not a device of the home", schedule.Target.DeviceID))
+		} else if err := validateDeviceCommand(device.Type, command); err != nil {
+			validationErrors = append(validationErrors, hdError.From(err).ErrorMessage)
+		}
+	} else {
+		if schedule.Target.RoomID != "" {
+			if err := checkRoomExists(ctx, sSI.roomDao, schedule.HomeID, schedule.Target.RoomID); err != nil {
+				if !errors.Is(err, hdError.ErrUnknownRoom) {
+					return 0, err
+				}
+				validationErrors = append(validationErrors, fmt.Sprintf("the target room %v is not a room of the home", schedule.Target.RoomID))
+			}
+		}
+
+		if err := validateDeviceCommand(schedule.Target.DeviceType, command); err != nil {
+			validationErrors = append(validationErrors, hdError.From(err).ErrorMessage)
+		}
+	}
+
+	if len(validationErrors) == 0 {
+		return nextRunAt, nil
+	}
+
+	log.Printf("Invalid schedule for the home %v: %v", schedule.HomeID, validationErrors)
+	return 0, hdError.ErrInvalidSchedule.WithMessage(strings.Join(validationErrors, "; "))
+}
+
+// parseScheduleSpec parses the cron expression or the RRULE of the schedule,
+// whose start is the time it was created, and loads its timezone.
+func parseScheduleSpec(schedule response.ScheduleResponse) (hdSchedule.Spec, *time.Location, error) {
+
+	location, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return hdSchedule.Spec{}, nil, fmt.Errorf("%v is not a valid timezone", schedule.Timezone)
+	}
+
+	if schedule.Cron != "" {
+		spec, err := hdSchedule.ParseCron(schedule.Cron)
+		return spec, location, err
+	}
+
+	spec, err := hdSchedule.ParseRRule(schedule.RRule, time.Unix(schedule.CreatedAt, 0).In(location))
+	return spec, location, err
+}
+
+// nextScheduleRun returns the next run after the given time, in the timezone
+// of the schedule, or 0 when it does not run anymore.
+func nextScheduleRun(spec hdSchedule.Spec, location *time.Location, after time.Time) int64 {
+
+	next, found := spec.Next(after.In(location))
+	if !found {
+		return 0
+	}
+
+	return next.Unix()
+}
+
+// deleteAllSchedules deletes the schedules of a home that is being deleted.
+func deleteAllSchedules(ctx context.Context, scheduleDao dao.ScheduleDao, homeId string) error {
+
+	if scheduleDao == nil {
+		return nil
+	}
+
+	schedules, err := scheduleDao.ListSchedules(ctx, homeId)
+	if err != nil {
+		return err
+	}
+
+	for _, schedule := range schedules.Schedules {
+		if err := scheduleDao.DeleteSchedule(ctx, homeId, schedule.ID, 0); err != nil && !errors.Is(err, hdError.ErrScheduleNotFound) {
+			log.Printf("Error deleting the schedule %v of the home %v: %v", schedule.ID, homeId, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (sSI ScheduleServiceImpl) checkScheduleDao(errorDefinition hdError.Definition) error {
+
+	if sSI.scheduleDao == nil {
+		log.Printf("The schedules are not configured")
+		return errorDefinition.New()
+	}
+
+	return nil
+}
+
+// NewScheduleServiceImplFromConfig uses the DynamoDB daos.
+func NewScheduleServiceImplFromConfig(cfg aws.Config, commandQueue queue.CommandQueue) ScheduleService {
+	client := dynamodb.NewFromConfig(cfg)
+	deviceService := newHomeDeviceServiceFromConfig(cfg, client)
+	commandService := NewDeviceCommandServiceImpl(dao.DeviceCommandDaoImpl{DynamoDbApi: client}, deviceService, commandQueue)
+	return NewScheduleServiceImpl(dao.ScheduleDaoImpl{DynamoDbApi: client}, dao.HomeDaoImpl{DynamoDbApi: client}, dao.RoomDaoImpl{DynamoDbApi: client}, deviceService, commandService)
+}
+
+// NewScheduleServiceImpl checks and resolves the targets of the schedules with
+// the deviceService and sends their commands with the commandService.
+func NewScheduleServiceImpl(scheduleDao dao.ScheduleDao, homeDao dao.HomeDao, roomDao dao.RoomDao, deviceService HomeDeviceService, commandService DeviceCommandService) ScheduleService {
+	return ScheduleServiceImpl{scheduleDao: scheduleDao, homeDao: homeDao, roomDao: roomDao, deviceService: deviceService, commandService: commandService}
+}
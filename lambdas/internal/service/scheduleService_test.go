@@ -0,0 +1,391 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/odhoman/home-devices/internal/constants"
+	"github.com/odhoman/home-devices/internal/dao"
+	hdError "github.com/odhoman/home-devices/internal/error"
+	hdMock "github.com/odhoman/home-devices/internal/mock"
+	"github.com/odhoman/home-devices/internal/queue"
+	"github.com/odhoman/home-devices/internal/request"
+	hdREsponse "github.com/odhoman/home-devices/internal/response"
+	"github.com/stretchr/testify/assert"
+	mock "github.com/stretchr/testify/mock"
+)
+
+func TestCreateSchedule_Defaults(t *testing.T) {
+	env := newScheduleServiceForTesting(t)
+	before := time.Now()
+
+	schedule, err := env.service.CreateSchedule(context.Background(), env.home.ID, env.lightsOffSchedule())
+
+	assert.Nil(t, err)
+	assert.True(t, schedule.Enabled)
+	assert.Equal(t, "Europe/Madrid", schedule.Timezone)
+	assert.Equal(t, env.home.ID, schedule.HomeID)
+	assert.Equal(t, map[string]interface{}{}, schedule.Params)
+	assert.Equal(t, int64(1), schedule.Version)
+
+	madrid, _ := time.LoadLocation("Europe/Madrid")
+	local := before.In(madrid)
+	midnight := time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, madrid)
+	assert.Equal(t, midnight.Unix(), schedule.NextRunAt)
+}
+
+func TestCreateSchedule_Disabled(t *testing.T) {
+	env := newScheduleServiceForTesting(t)
+	disabled := false
+	scheduleRequest := env.lightsOffSchedule()
+	scheduleRequest.Enabled = &disabled
+
+	schedule, err := env.service.CreateSchedule(context.Background(), env.home.ID, scheduleRequest)
+
+	assert.Nil(t, err)
+	assert.False(t, schedule.Enabled)
+	assert.Zero(t, schedule.NextRunAt)
+}
+
+func TestCreateSchedule_UnknownHome(t *testing.T) {
+	env := newScheduleServiceForTesting(t)
+
+	_, err := env.service.CreateSchedule(context.Background(), "home99999", env.lightsOffSchedule())
+
+	assert.ErrorIs(t, err, hdError.ErrHomeNotFound)
+}
+
+func TestCreateSchedule_Invalid(t *testing.T) {
+	env := newScheduleServiceForTesting(t)
+	ctx := context.Background()
+
+	other, _ := env.service.CreateHome(ctx, request.CreateHomeRequest{Name: "Mountain House", Timezone: "UTC", Owner: "user-1"})
+	device, _ := env.service.CreateHomeDevice(ctx, request.CreateDeviceRequest{MAC: "00:11:22:33:44:99", Name: "Porch Light", Type: "light", HomeID: other.ID})
+
+	tests := map[string]struct {
+		update          func(*request.ScheduleRequest)
+		expectedMessage string
+	}{
+		"the cron expression and the command": {
+			update: func(scheduleRequest *request.ScheduleRequest) {
+				scheduleRequest.Cron = "61 * * * *"
+				scheduleRequest.Command = "lock"
+			},
+			expectedMessage: "61 is not a valid minute, it must be between 0 and 59; lock is not a command of the light devices",
+		},
+		"an RRULE that ended": {
+			update: func(scheduleRequest *request.ScheduleRequest) {
+				scheduleRequest.Cron = ""
+				scheduleRequest.RRule = "FREQ=DAILY;UNTIL=20200101"
+			},
+			expectedMessage: "the schedule does not run after now",
+		},
+		"a device of another home": {
+			update: func(scheduleRequest *request.ScheduleRequest) {
+				scheduleRequest.Target.DeviceID = device.ID
+			},
+			expectedMessage: "the target device " + device.ID + " is not a device of the home",
+		},
+		"a room of another home": {
+			update: func(scheduleRequest *request.ScheduleRequest) {
+				scheduleRequest.Target = request.ScheduleTarget{DeviceType: "light", RoomID: "00000000-0000-0000-0000-000000000000"}
+			},
+			expectedMessage: "the target room 00000000-0000-0000-0000-000000000000 is not a room of the home",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			scheduleRequest := env.lightsOffSchedule()
+			test.update(&scheduleRequest)
+
+			_, err := env.service.CreateSchedule(ctx, env.home.ID, scheduleRequest)
+
+			assert.ErrorIs(t, err, hdError.ErrInvalidSchedule)
+			assert.EqualError(t, err, test.expectedMessage)
+		})
+	}
+}
+
+func TestCreateSchedule_NotConfigured(t *testing.T) {
+	service := ScheduleServiceImpl{}
+
+	_, err := service.CreateSchedule(context.Background(), "home1", request.ScheduleRequest{})
+
+	assert.ErrorIs(t, err, hdError.ErrScheduleNotCreated)
+}
+
+func TestUpdateSchedule_ComputesTheNextRun(t *testing.T) {
+	env := newScheduleServiceForTesting(t)
+	ctx := context.Background()
+	saved := env.saveSchedule(t, env.lightsOffSchedule())
+
+	scheduleRequest := env.lightsOffSchedule()
+	scheduleRequest.Cron = "* * * * *"
+	scheduleRequest.Timezone = "UTC"
+
+	assert.Nil(t, env.service.UpdateSchedule(ctx, scheduleRequest, env.home.ID, saved.ID, saved.Version))
+
+	updated, _ := env.service.GetSchedule(ctx, env.home.ID, saved.ID)
+	assert.Equal(t, "UTC", updated.Timezone)
+	assert.Equal(t, time.Now().Truncate(time.Minute).Add(time.Minute).Unix(), updated.NextRunAt)
+	assert.Equal(t, saved.CreatedAt, updated.CreatedAt)
+	assert.Equal(t, saved.Version+1, updated.Version)
+
+	err := env.service.UpdateSchedule(ctx, scheduleRequest, env.home.ID, "00000000-0000-0000-0000-000000000000", 0)
+	assert.ErrorIs(t, err, hdError.ErrScheduleNotFound)
+}
+
+func TestDeleteHome_DeletesTheSchedules(t *testing.T) {
+	env := newScheduleServiceForTesting(t)
+	ctx := context.Background()
+	saved := env.saveSchedule(t, env.lightsOffSchedule())
+
+	err := env.service.DeleteHome(ctx, env.home.ID, request.DeleteHomeRequest{Strategy: constants.DeleteHomeStrategyCascade}, 0)
+	assert.Nil(t, err)
+
+	_, err = env.service.GetSchedule(ctx, env.home.ID, saved.ID)
+	assert.ErrorIs(t, err, hdError.ErrScheduleNotFound)
+}
+
+func TestRunDueSchedules_SendsTheCommandToTheGroup(t *testing.T) {
+	env := newScheduleServiceForTesting(t)
+	ctx := context.Background()
+
+	scheduleRequest := env.lightsOffSchedule()
+	scheduleRequest.Target = request.ScheduleTarget{DeviceType: "light"}
+	saved := env.saveSchedule(t, scheduleRequest)
+	runAt := time.Unix(saved.NextRunAt, 0)
+
+	runs, err := env.service.RunDueSchedules(ctx, runAt)
+
+	assert.Nil(t, err)
+	if assert.Len(t, runs, 1) {
+		assert.Equal(t, saved.ID, runs[0].ScheduleID)
+		assert.Equal(t, saved.NextRunAt, runs[0].RunAt)
+		assert.Equal(t, constants.ScheduleRunStatusDispatched, runs[0].Status)
+		assert.Len(t, runs[0].CommandIDs, 2)
+		assert.Empty(t, runs[0].Errors)
+	}
+
+	deviceIds := []string{}
+	for _, message := range env.commandQueue.Messages() {
+		assert.Equal(t, "turnOff", message.Name)
+		deviceIds = append(deviceIds, message.DeviceID)
+	}
+	assert.ElementsMatch(t, []string{env.light.ID, env.lamp.ID}, deviceIds)
+
+	madrid, _ := time.LoadLocation("Europe/Madrid")
+	updated, _ := env.service.GetSchedule(ctx, env.home.ID, saved.ID)
+	assert.Equal(t, saved.NextRunAt, updated.LastRunAt)
+	assert.Equal(t, runAt.In(madrid).AddDate(0, 0, 1).Unix(), updated.NextRunAt)
+}
+
+func TestRunDueSchedules_RetryDoesNotRunTwice(t *testing.T) {
+	env := newScheduleServiceForTesting(t)
+	ctx := context.Background()
+	saved := env.saveSchedule(t, env.lightsOffSchedule())
+	runAt := time.Unix(saved.NextRunAt, 0)
+
+	env.service.RunDueSchedules(ctx, runAt)
+	runs, err := env.service.RunDueSchedules(ctx, runAt)
+
+	assert.Nil(t, err)
+	assert.Empty(t, runs)
+	assert.Len(t, env.commandQueue.Messages(), 1)
+}
+
+func TestRunDueSchedules_NotDue(t *testing.T) {
+	env := newScheduleServiceForTesting(t)
+	saved := env.saveSchedule(t, env.lightsOffSchedule())
+
+	runs, err := env.service.RunDueSchedules(context.Background(), time.Unix(saved.NextRunAt-60, 0))
+
+	assert.Nil(t, err)
+	assert.Empty(t, runs)
+	assert.Empty(t, env.commandQueue.Messages())
+}
+
+func TestRunDueSchedules_Missed(t *testing.T) {
+	env := newScheduleServiceForTesting(t)
+	ctx := context.Background()
+	saved := env.saveSchedule(t, env.lightsOffSchedule())
+	now := time.Unix(saved.NextRunAt+constants.MaxScheduleDelaySeconds+1, 0)
+
+	runs, err := env.service.RunDueSchedules(ctx, now)
+
+	assert.Nil(t, err)
+	if assert.Len(t, runs, 1) {
+		assert.Equal(t, constants.ScheduleRunStatusMissed, runs[0].Status)
+		assert.Empty(t, runs[0].CommandIDs)
+	}
+	assert.Empty(t, env.commandQueue.Messages())
+
+	madrid, _ := time.LoadLocation("Europe/Madrid")
+	updated, _ := env.service.GetSchedule(ctx, env.home.ID, saved.ID)
+	assert.Equal(t, time.Unix(saved.NextRunAt, 0).In(madrid).AddDate(0, 0, 1).Unix(), updated.NextRunAt)
+}
+
+func TestRunDueSchedules_LastRun(t *testing.T) {
+	env := newScheduleServiceForTesting(t)
+	ctx := context.Background()
+
+	// the rule ends at its first run
+	nextMinute := time.Now().UTC().Truncate(time.Minute).Add(time.Minute)
+	scheduleRequest := env.lightsOffSchedule()
+	scheduleRequest.Cron = ""
+	scheduleRequest.RRule = fmt.Sprintf("FREQ=MINUTELY;UNTIL=%v", nextMinute.Format("20060102T150405Z"))
+	saved := env.saveSchedule(t, scheduleRequest)
+
+	runs, err := env.service.RunDueSchedules(ctx, time.Unix(saved.NextRunAt, 0))
+
+	assert.Nil(t, err)
+	assert.Len(t, runs, 1)
+
+	updated, _ := env.service.GetSchedule(ctx, env.home.ID, saved.ID)
+	assert.Equal(t, nextMinute.Unix(), updated.LastRunAt)
+	assert.Zero(t, updated.NextRunAt)
+}
+
+func TestRunDueSchedules_ErrorSendingTheCommandIsRecorded(t *testing.T) {
+	mockQueue := new(hdMock.MockCommandQueue)
+	env := newScheduleServiceWithQueueForTesting(t, mockQueue)
+	ctx := context.Background()
+	saved := env.saveSchedule(t, env.lightsOffSchedule())
+
+	mockQueue.On("Publish", ctx, mock.Anything).Return(errors.New("throttled"))
+
+	runs, err := env.service.RunDueSchedules(ctx, time.Unix(saved.NextRunAt, 0))
+
+	assert.Nil(t, err)
+	if assert.Len(t, runs, 1) {
+		assert.Equal(t, constants.ScheduleRunStatusDispatched, runs[0].Status)
+		assert.Empty(t, runs[0].CommandIDs)
+		assert.Equal(t, []string{env.light.ID + ": " + hdError.ErrSendingDeviceCommand.Message}, runs[0].Errors)
+	}
+}
+
+func TestRunDueSchedules_ErrorClaimingTheRun(t *testing.T) {
+	mockScheduleDao := new(hdMock.MockScheduleDao)
+	commandQueue := queue.NewInMemoryCommandQueue()
+	deviceService := HomeDeviceServiceImpl{homeDeviceDao: new(hdMock.MockHomeDeviceDao)}
+	service := ScheduleServiceImpl{scheduleDao: mockScheduleDao, deviceService: deviceService, commandService: NewDeviceCommandServiceImpl(dao.NewInMemoryDeviceCommandDao(), deviceService, commandQueue)}
+	ctx := context.Background()
+	now := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	mockScheduleDao.On("ListDueSchedules", ctx, now.Unix()).Return([]hdREsponse.ScheduleResponse{{
+		ID:        "schedule-1",
+		HomeID:    "home1",
+		Enabled:   true,
+		Cron:      "0 0 * * *",
+		Timezone:  "UTC",
+		Target:    hdREsponse.ScheduleTargetResponse{DeviceID: "light-1"},
+		Command:   "turnOff",
+		NextRunAt: now.Unix(),
+	}}, nil)
+	mockScheduleDao.On("ClaimScheduleRun", ctx, "home1", "schedule-1", now.Unix(), now.AddDate(0, 0, 1).Unix()).
+		Return(hdError.ErrUpdatingSchedule.New())
+
+	_, err := service.RunDueSchedules(ctx, now)
+
+	assert.ErrorIs(t, err, hdError.ErrUpdatingSchedule)
+	assert.Empty(t, commandQueue.Messages())
+}
+
+func TestRunDueSchedules_NotConfigured(t *testing.T) {
+	runs, err := ScheduleServiceImpl{}.RunDueSchedules(context.Background(), time.Now())
+
+	assert.ErrorIs(t, err, hdError.ErrListingSchedules)
+	assert.Empty(t, runs)
+}
+
+// scheduleTestService changes the homes, their devices and their schedules.
+type scheduleTestService struct {
+	HomeService
+	HomeDeviceService
+	ScheduleService
+}
+
+// scheduleTestEnv is a home with two lights and a sensor, and a service with
+// in-memory daos and queue.
+type scheduleTestEnv struct {
+	service      scheduleTestService
+	commandQueue *queue.InMemoryCommandQueue
+	home         *hdREsponse.HomeResponse
+	light        *hdREsponse.HomdeDeviceResponse
+	lamp         *hdREsponse.HomdeDeviceResponse
+}
+
+func newScheduleServiceForTesting(t *testing.T) scheduleTestEnv {
+	t.Helper()
+
+	commandQueue := queue.NewInMemoryCommandQueue()
+	env := newScheduleServiceWithQueueForTesting(t, commandQueue)
+	env.commandQueue = commandQueue
+
+	return env
+}
+
+// newScheduleServiceWithQueueForTesting sends the commands of the schedules
+// through the given queue.
+func newScheduleServiceWithQueueForTesting(t *testing.T, commandQueue queue.CommandQueue) scheduleTestEnv {
+	t.Helper()
+
+	homeDao := dao.NewInMemoryHomeDao()
+	roomDao := dao.NewInMemoryRoomDao()
+	scheduleDao := dao.NewInMemoryScheduleDao()
+	deviceService := NewHomeDeviceServiceImpl2(dao.NewInMemoryHomeDeviceDao(), WithHomeDao(homeDao), WithRoomDao(roomDao))
+	commandService := NewDeviceCommandServiceImpl(dao.NewInMemoryDeviceCommandDao(), deviceService, commandQueue)
+
+	env := scheduleTestEnv{service: scheduleTestService{
+		HomeService:       NewHomeServiceImpl(homeDao, deviceService, roomDao, nil, scheduleDao, nil),
+		HomeDeviceService: deviceService,
+		ScheduleService:   NewScheduleServiceImpl(scheduleDao, homeDao, roomDao, deviceService, commandService),
+	}}
+	ctx := context.Background()
+
+	var err error
+	env.home, err = env.service.CreateHome(ctx, request.CreateHomeRequest{Name: "Beach House", Timezone: "Europe/Madrid", Owner: "user-1"})
+	if err != nil {
+		t.Fatalf("Unexpected error creating the home for testing. Error: %v", err)
+	}
+
+	env.light, err = env.service.CreateHomeDevice(ctx, request.CreateDeviceRequest{MAC: "00:11:22:33:44:01", Name: "Hallway Light", Type: "light", HomeID: env.home.ID})
+	if err != nil {
+		t.Fatalf("Unexpected error creating the light for testing. Error: %v", err)
+	}
+
+	env.lamp, err = env.service.CreateHomeDevice(ctx, request.CreateDeviceRequest{MAC: "00:11:22:33:44:02", Name: "Desk Lamp", Type: "light", HomeID: env.home.ID})
+	if err != nil {
+		t.Fatalf("Unexpected error creating the lamp for testing. Error: %v", err)
+	}
+
+	if _, err = env.service.CreateHomeDevice(ctx, request.CreateDeviceRequest{MAC: "00:11:22:33:44:03", Name: "Hallway Sensor", Type: "sensor", HomeID: env.home.ID}); err != nil {
+		t.Fatalf("Unexpected error creating the sensor for testing. Error: %v", err)
+	}
+
+	return env
+}
+
+func (env scheduleTestEnv) lightsOffSchedule() request.ScheduleRequest {
+	return request.ScheduleRequest{
+		Name:    "Lights off at midnight",
+		Cron:    "0 0 * * *",
+		Target:  request.ScheduleTarget{DeviceID: env.light.ID},
+		Command: "turnOff",
+	}
+}
+
+func (env scheduleTestEnv) saveSchedule(t *testing.T, scheduleRequest request.ScheduleRequest) *hdREsponse.ScheduleResponse {
+	t.Helper()
+
+	saved, err := env.service.CreateSchedule(context.Background(), env.home.ID, scheduleRequest)
+	if err != nil {
+		t.Fatalf("Unexpected error creating the schedule for testing. Error: %v", err)
+	}
+
+	return saved
+}
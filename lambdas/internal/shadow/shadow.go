@@ -0,0 +1,71 @@
+package shadow
+
+import (
+	"reflect"
+)
+
+// Sections of a device shadow.
+const (
+	SectionDesired  = "desired"
+	SectionReported = "reported"
+)
+
+// Merge applies a JSON merge patch (RFC 7386) to a state document and returns
+// the new document: a null value removes the key, an object is merged into the
+// object it replaces and any other value replaces the previous one.
+func Merge(document map[string]interface{}, patch map[string]interface{}) map[string]interface{} {
+
+	merged := make(map[string]interface{}, len(document)+len(patch))
+	for key, value := range document {
+		merged[key] = value
+	}
+
+	for key, value := range patch {
+
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+
+		if patchObject, ok := value.(map[string]interface{}); ok {
+			current, _ := merged[key].(map[string]interface{})
+			merged[key] = Merge(current, patchObject)
+			continue
+		}
+
+		merged[key] = value
+	}
+
+	return merged
+}
+
+// Delta returns the values of desired that the device has not reported yet: the
+// keys missing in reported or with another value.
+func Delta(desired map[string]interface{}, reported map[string]interface{}) map[string]interface{} {
+
+	delta := map[string]interface{}{}
+
+	for key, desiredValue := range desired {
+
+		reportedValue, found := reported[key]
+		if !found {
+			delta[key] = desiredValue
+			continue
+		}
+
+		desiredObject, desiredIsObject := desiredValue.(map[string]interface{})
+		reportedObject, reportedIsObject := reportedValue.(map[string]interface{})
+		if desiredIsObject && reportedIsObject {
+			if objectDelta := Delta(desiredObject, reportedObject); len(objectDelta) > 0 {
+				delta[key] = objectDelta
+			}
+			continue
+		}
+
+		if !reflect.DeepEqual(desiredValue, reportedValue) {
+			delta[key] = desiredValue
+		}
+	}
+
+	return delta
+}
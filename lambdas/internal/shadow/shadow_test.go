@@ -0,0 +1,68 @@
+package shadow
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+
+	document := decode(t, `{"power":"off","brightness":40,"color":{"r":255,"g":0,"b":0},"mode":"eco"}`)
+	patch := decode(t, `{"power":"on","color":{"g":128},"mode":null,"schedule":{"on":"07:00"}}`)
+
+	merged := Merge(document, patch)
+
+	assert.Equal(t, decode(t, `{"power":"on","brightness":40,"color":{"r":255,"g":128,"b":0},"schedule":{"on":"07:00"}}`), merged)
+	// the document passed in is not modified
+	assert.Equal(t, decode(t, `{"power":"off","brightness":40,"color":{"r":255,"g":0,"b":0},"mode":"eco"}`), document)
+}
+
+func TestMerge_ReplacesValueWithObject(t *testing.T) {
+
+	merged := Merge(decode(t, `{"color":"red"}`), decode(t, `{"color":{"r":255}}`))
+
+	assert.Equal(t, decode(t, `{"color":{"r":255}}`), merged)
+}
+
+func TestMerge_EmptyDocument(t *testing.T) {
+
+	merged := Merge(nil, decode(t, `{"power":"on","mode":null}`))
+
+	assert.Equal(t, decode(t, `{"power":"on"}`), merged)
+}
+
+func TestDelta(t *testing.T) {
+
+	desired := decode(t, `{"power":"on","brightness":80,"color":{"r":255,"g":128},"mode":"eco"}`)
+	reported := decode(t, `{"power":"on","brightness":40,"color":{"r":255,"g":0},"temperature":21.5}`)
+
+	assert.Equal(t, decode(t, `{"brightness":80,"color":{"g":128},"mode":"eco"}`), Delta(desired, reported))
+}
+
+func TestDelta_InSync(t *testing.T) {
+
+	desired := decode(t, `{"power":"on","color":{"r":255}}`)
+	reported := decode(t, `{"power":"on","color":{"r":255},"temperature":21.5}`)
+
+	assert.Empty(t, Delta(desired, reported))
+}
+
+func TestDelta_NothingReported(t *testing.T) {
+
+	desired := decode(t, `{"power":"on"}`)
+
+	assert.Equal(t, desired, Delta(desired, nil))
+	assert.Empty(t, Delta(nil, desired))
+}
+
+func decode(t *testing.T, document string) map[string]interface{} {
+	t.Helper()
+
+	var value map[string]interface{}
+	if err := json.Unmarshal([]byte(document), &value); err != nil {
+		t.Fatalf("invalid document %v: %v", document, err)
+	}
+	return value
+}
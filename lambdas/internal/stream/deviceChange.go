@@ -0,0 +1,122 @@
+package stream
+
+import (
+	hdAudit "github.com/odhoman/home-devices/internal/audit"
+	hdDao "github.com/odhoman/home-devices/internal/dao"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// The principal of the removals made by the TTL of the table.
+const (
+	ttlIdentityType        = "Service"
+	ttlIdentityPrincipalId = "dynamodb.amazonaws.com"
+)
+
+// DeviceChange is a write to the devices table read from its stream, made by
+// the service or by anything else, like an ops script or the console.
+type DeviceChange struct {
+	// ID is the id of the stream record
+	ID        string                        `json:"id"`
+	Operation string                        `json:"operation"`
+	DeviceID  string                        `json:"deviceId"`
+	Before    *response.HomdeDeviceResponse `json:"before"`
+	After     *response.HomdeDeviceResponse `json:"after"`
+	// the device was soft deleted before or after the change
+	BeforeDeleted bool                         `json:"beforeDeleted,omitempty"`
+	AfterDeleted  bool                         `json:"afterDeleted,omitempty"`
+	Changes       []response.DeviceFieldChange `json:"changes"`
+	// Expired tells that the TTL of the table removed a soft deleted device
+	Expired   bool  `json:"expired,omitempty"`
+	ChangedAt int64 `json:"changedAt"`
+}
+
+// NewDeviceChange decodes the images of the record and finds the operation and
+// the fields that changed.
+func NewDeviceChange(record events.DynamoDBEventRecord) (*DeviceChange, error) {
+
+	before, beforeDeleted, err := hdDao.DecodeDeviceStreamImage(record.Change.OldImage)
+	if err != nil {
+		return nil, err
+	}
+
+	after, afterDeleted, err := hdDao.DecodeDeviceStreamImage(record.Change.NewImage)
+	if err != nil {
+		return nil, err
+	}
+
+	if before == nil && after == nil {
+		return nil, nil
+	}
+
+	change := DeviceChange{
+		ID:            record.EventID,
+		Before:        before,
+		After:         after,
+		BeforeDeleted: beforeDeleted,
+		AfterDeleted:  afterDeleted,
+		Changes:       Diff(before, after),
+		ChangedAt:     record.Change.ApproximateCreationDateTime.Unix(),
+	}
+
+	switch {
+	case before == nil:
+		change.DeviceID = after.ID
+		change.Operation = hdAudit.OperationCreate
+	case after == nil:
+		change.DeviceID = before.ID
+		change.Operation = hdAudit.OperationHardDelete
+		change.Expired = record.UserIdentity != nil &&
+			record.UserIdentity.Type == ttlIdentityType &&
+			record.UserIdentity.PrincipalID == ttlIdentityPrincipalId
+	case !beforeDeleted && afterDeleted:
+		change.DeviceID = after.ID
+		change.Operation = hdAudit.OperationDelete
+	case beforeDeleted && !afterDeleted:
+		change.DeviceID = after.ID
+		change.Operation = hdAudit.OperationRestore
+	case len(change.Changes) == 0:
+		// only lastSeenAt or the version changed
+		return nil, nil
+	case len(change.Changes) == 1 && change.Changes[0].Field == "status":
+		change.DeviceID = after.ID
+		change.Operation = hdAudit.OperationStatusChange
+	default:
+		change.DeviceID = after.ID
+		change.Operation = hdAudit.OperationUpdate
+	}
+
+	return &change, nil
+}
+
+// Diff returns the fields that changed between before and after, the ones of
+// the device history and the status.
+func Diff(before *response.HomdeDeviceResponse, after *response.HomdeDeviceResponse) []response.DeviceFieldChange {
+
+	changes := hdAudit.Diff(before, after)
+
+	beforeStatus, afterStatus := "", ""
+	if before != nil {
+		beforeStatus = before.Status
+	}
+	if after != nil {
+		afterStatus = after.Status
+	}
+
+	if beforeStatus != afterStatus {
+		changes = append(changes, response.DeviceFieldChange{
+			Field:  "status",
+			Before: beforeStatus,
+			After:  afterStatus,
+		})
+	}
+
+	return changes
+}
+
+// active tells if the device counts as one of its home before or after the
+// change.
+func active(device *response.HomdeDeviceResponse, deleted bool) bool {
+	return device != nil && !deleted
+}
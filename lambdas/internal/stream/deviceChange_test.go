@@ -0,0 +1,162 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	hdAudit "github.com/odhoman/home-devices/internal/audit"
+	response "github.com/odhoman/home-devices/internal/response"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// deviceImage builds the image of a device of the home, with the attributes
+// given as name and value pairs; the values starting with a digit are numbers.
+func deviceImage(homeId string, attributes ...string) map[string]events.DynamoDBAttributeValue {
+
+	image := map[string]events.DynamoDBAttributeValue{
+		"id":      events.NewStringAttribute("device1"),
+		"mac":     events.NewStringAttribute("00:11:22:33:44:55"),
+		"name":    events.NewStringAttribute("Living Room Light"),
+		"type":    events.NewStringAttribute("light"),
+		"homeId":  events.NewStringAttribute(homeId),
+		"version": events.NewNumberAttribute("1"),
+	}
+
+	for i := 0; i+1 < len(attributes); i += 2 {
+		if value := attributes[i+1]; value[0] >= '0' && value[0] <= '9' {
+			image[attributes[i]] = events.NewNumberAttribute(value)
+		} else {
+			image[attributes[i]] = events.NewStringAttribute(value)
+		}
+	}
+
+	return image
+}
+
+func streamRecord(eventName string, oldImage map[string]events.DynamoDBAttributeValue, newImage map[string]events.DynamoDBAttributeValue) events.DynamoDBEventRecord {
+	return events.DynamoDBEventRecord{
+		EventID:   "event-" + eventName,
+		EventName: eventName,
+		Change: events.DynamoDBStreamRecord{
+			ApproximateCreationDateTime: events.SecondsEpochTime{Time: time.Unix(1729000000, 0)},
+			OldImage:                    oldImage,
+			NewImage:                    newImage,
+			SequenceNumber:              "100",
+		},
+	}
+}
+
+func TestNewDeviceChange_Create(t *testing.T) {
+
+	change, err := NewDeviceChange(streamRecord("INSERT", nil, deviceImage("home1")))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "event-INSERT", change.ID)
+	assert.Equal(t, hdAudit.OperationCreate, change.Operation)
+	assert.Equal(t, "device1", change.DeviceID)
+	assert.Nil(t, change.Before)
+	assert.Equal(t, "home1", change.After.HomeID)
+	assert.Equal(t, int64(1729000000), change.ChangedAt)
+	assert.Equal(t, []response.DeviceFieldChange{
+		{Field: "mac", Before: "", After: "00:11:22:33:44:55"},
+		{Field: "name", Before: "", After: "Living Room Light"},
+		{Field: "type", Before: "", After: "light"},
+		{Field: "homeId", Before: "", After: "home1"},
+		{Field: "status", Before: "", After: "offline"},
+	}, change.Changes)
+}
+
+func TestNewDeviceChange_Update(t *testing.T) {
+
+	change, err := NewDeviceChange(streamRecord("MODIFY", deviceImage("home1"), deviceImage("home2", "roomId", "room1", "version", "2")))
+
+	assert.Nil(t, err)
+	assert.Equal(t, hdAudit.OperationUpdate, change.Operation)
+	assert.Equal(t, []response.DeviceFieldChange{
+		{Field: "homeId", Before: "home1", After: "home2"},
+		{Field: "roomId", Before: "", After: "room1"},
+	}, change.Changes)
+}
+
+func TestNewDeviceChange_DeleteAndRestore(t *testing.T) {
+
+	deleted := deviceImage("home1", "deletedAt", "1729000000", "expiresAt", "1731592000", "version", "2")
+
+	change, err := NewDeviceChange(streamRecord("MODIFY", deviceImage("home1"), deleted))
+
+	assert.Nil(t, err)
+	assert.Equal(t, hdAudit.OperationDelete, change.Operation)
+	assert.False(t, change.BeforeDeleted)
+	assert.True(t, change.AfterDeleted)
+	assert.Empty(t, change.Changes)
+
+	change, err = NewDeviceChange(streamRecord("MODIFY", deleted, deviceImage("home1", "version", "3")))
+
+	assert.Nil(t, err)
+	assert.Equal(t, hdAudit.OperationRestore, change.Operation)
+	assert.True(t, change.BeforeDeleted)
+	assert.False(t, change.AfterDeleted)
+}
+
+func TestNewDeviceChange_Remove(t *testing.T) {
+
+	deleted := deviceImage("home1", "deletedAt", "1729000000")
+
+	change, err := NewDeviceChange(streamRecord("REMOVE", deviceImage("home1"), nil))
+
+	assert.Nil(t, err)
+	assert.Equal(t, hdAudit.OperationHardDelete, change.Operation)
+	assert.Equal(t, "device1", change.DeviceID)
+	assert.False(t, change.Expired)
+
+	// the TTL removes the soft deleted device once the retention is over
+	record := streamRecord("REMOVE", deleted, nil)
+	record.UserIdentity = &events.DynamoDBUserIdentity{Type: "Service", PrincipalID: "dynamodb.amazonaws.com"}
+
+	change, err = NewDeviceChange(record)
+
+	assert.Nil(t, err)
+	assert.Equal(t, hdAudit.OperationHardDelete, change.Operation)
+	assert.True(t, change.Expired)
+	assert.True(t, change.BeforeDeleted)
+}
+
+func TestNewDeviceChange_StatusChange(t *testing.T) {
+
+	change, err := NewDeviceChange(streamRecord("MODIFY", deviceImage("home1", "status", "offline"), deviceImage("home1", "status", "online", "lastSeenAt", "1729000000")))
+
+	assert.Nil(t, err)
+	assert.Equal(t, hdAudit.OperationStatusChange, change.Operation)
+	assert.Equal(t, []response.DeviceFieldChange{{Field: "status", Before: "offline", After: "online"}}, change.Changes)
+}
+
+func TestNewDeviceChange_Skipped(t *testing.T) {
+
+	// a heartbeat of an online device
+	change, err := NewDeviceChange(streamRecord("MODIFY",
+		deviceImage("home1", "status", "online", "lastSeenAt", "1729000000"),
+		deviceImage("home1", "status", "online", "lastSeenAt", "1729000060")))
+
+	assert.Nil(t, err)
+	assert.Nil(t, change)
+
+	// the mac + homeId guard of a new device
+	change, err = NewDeviceChange(streamRecord("INSERT", nil, map[string]events.DynamoDBAttributeValue{
+		"id":       events.NewStringAttribute("MAC#00:11:22:33:44:55#HOME#home1"),
+		"deviceId": events.NewStringAttribute("device1"),
+	}))
+
+	assert.Nil(t, err)
+	assert.Nil(t, change)
+}
+
+func TestNewDeviceChange_Malformed(t *testing.T) {
+
+	_, err := NewDeviceChange(streamRecord("INSERT", nil, map[string]events.DynamoDBAttributeValue{
+		"name": events.NewStringAttribute("Living Room Light"),
+	}))
+
+	assert.NotNil(t, err)
+}
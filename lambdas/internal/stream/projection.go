@@ -0,0 +1,62 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	hdDao "github.com/odhoman/home-devices/internal/dao"
+)
+
+// Projection keeps a view of the devices up to date with their changes, like an
+// audit trail or the counters of the homes.
+type Projection interface {
+	Name() string
+	Project(ctx context.Context, change DeviceChange) error
+}
+
+// AuditProjection logs every change as a JSON line, so there is a trail of the
+// writes made outside the service too, which are not in the device history.
+type AuditProjection struct{}
+
+func (aP AuditProjection) Name() string {
+	return "audit"
+}
+
+func (aP AuditProjection) Project(ctx context.Context, change DeviceChange) error {
+
+	changeJson, err := json.Marshal(change)
+	if err != nil {
+		// it can not fail for a DeviceChange, and retrying would not help
+		log.Printf("Error serializing the change %v of the device %v: %v", change.ID, change.DeviceID, err)
+		return nil
+	}
+
+	log.Printf("Device change: %s", changeJson)
+	return nil
+}
+
+// CounterProjection keeps the number of active devices of each home, in total
+// and by type.
+type CounterProjection struct {
+	DeviceCounterDao hdDao.DeviceCounterDao
+}
+
+func (cP CounterProjection) Name() string {
+	return "counters"
+}
+
+func (cP CounterProjection) Project(ctx context.Context, change DeviceChange) error {
+
+	deltas := []hdDao.DeviceCountDelta{}
+
+	if active(change.Before, change.BeforeDeleted) {
+		deltas = append(deltas, hdDao.DeviceCountDelta{HomeID: change.Before.HomeID, Type: change.Before.Type, Delta: -1})
+	}
+
+	if active(change.After, change.AfterDeleted) {
+		deltas = append(deltas, hdDao.DeviceCountDelta{HomeID: change.After.HomeID, Type: change.After.Type, Delta: 1})
+	}
+
+	return cP.DeviceCounterDao.AddDeviceCounts(ctx, change.ID, deltas)
+}
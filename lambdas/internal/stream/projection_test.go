@@ -0,0 +1,60 @@
+package stream
+
+import (
+	"context"
+	"testing"
+
+	hdDao "github.com/odhoman/home-devices/internal/dao"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounterProjection(t *testing.T) {
+
+	counterDao := hdDao.NewInMemoryDeviceCounterDao()
+	projection := CounterProjection{DeviceCounterDao: counterDao}
+	ctx := context.Background()
+
+	light := deviceImage("home1")
+	moved := deviceImage("home2", "version", "2")
+	deleted := deviceImage("home2", "deletedAt", "1729000000", "version", "3")
+	restored := deviceImage("home2", "version", "4")
+
+	project := func(eventId string, record events.DynamoDBEventRecord) {
+		record.EventID = eventId
+		change, err := NewDeviceChange(record)
+		assert.Nil(t, err)
+		assert.Nil(t, projection.Project(ctx, *change))
+	}
+
+	project("1", streamRecord("INSERT", nil, light))
+	assert.Equal(t, map[string]int64{"TOTAL": 1, "light": 1}, counterDao.Counts("home1"))
+
+	project("2", streamRecord("MODIFY", light, moved))
+	assert.Equal(t, map[string]int64{"TOTAL": 0, "light": 0}, counterDao.Counts("home1"))
+	assert.Equal(t, map[string]int64{"TOTAL": 1, "light": 1}, counterDao.Counts("home2"))
+
+	// a change seen again when its batch is retried is not counted twice
+	project("2", streamRecord("MODIFY", light, moved))
+	assert.Equal(t, map[string]int64{"TOTAL": 1, "light": 1}, counterDao.Counts("home2"))
+
+	project("3", streamRecord("MODIFY", moved, deleted))
+	assert.Equal(t, map[string]int64{"TOTAL": 0, "light": 0}, counterDao.Counts("home2"))
+
+	project("4", streamRecord("MODIFY", deleted, restored))
+	assert.Equal(t, map[string]int64{"TOTAL": 1, "light": 1}, counterDao.Counts("home2"))
+
+	project("5", streamRecord("MODIFY", restored, deleted))
+	// the TTL removal of a soft deleted device does not count
+	project("6", streamRecord("REMOVE", deleted, nil))
+	assert.Equal(t, map[string]int64{"TOTAL": 0, "light": 0}, counterDao.Counts("home2"))
+}
+
+func TestAuditProjection(t *testing.T) {
+
+	change, _ := NewDeviceChange(streamRecord("INSERT", nil, deviceImage("home1")))
+
+	assert.Equal(t, "audit", AuditProjection{}.Name())
+	assert.Nil(t, AuditProjection{}.Project(context.Background(), *change))
+}
@@ -3,6 +3,8 @@ package utils
 import (
 	"errors"
 	"os"
+	"strconv"
+	"strings"
 )
 
 func ResolveDefaultDate(date, defaultValue int64) int64 {
@@ -27,3 +29,39 @@ func GetValueProperty(propertyKey string) (string, error) {
 
 	return value, nil
 }
+
+// GetHeader returns the value of a header ignoring the case of its name, as
+// API Gateway keeps the case sent by the client.
+func GetHeader(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+func BuildVersionETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// ParseVersionETag returns the version of an If-Match header value.
+func ParseVersionETag(eTag string) (int64, error) {
+
+	value := strings.TrimSpace(eTag)
+
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+
+	if strings.HasPrefix(value, "W/") {
+		return 0, errors.New("Weak ETag " + eTag + " can not be used in If-Match")
+	}
+
+	version, err := strconv.ParseInt(strings.Trim(value, "\""), 10, 64)
+	if err != nil || version <= 0 {
+		return 0, errors.New("Invalid version ETag " + eTag)
+	}
+
+	return version, nil
+}
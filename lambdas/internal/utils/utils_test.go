@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetHeader_IgnoresCase(t *testing.T) {
+	headers := map[string]string{"if-match": "\"3\""}
+
+	assert.Equal(t, "\"3\"", GetHeader(headers, "If-Match"))
+	assert.Equal(t, "", GetHeader(headers, "ETag"))
+	assert.Equal(t, "", GetHeader(nil, "If-Match"))
+}
+
+func TestBuildVersionETag(t *testing.T) {
+	assert.Equal(t, "\"3\"", BuildVersionETag(3))
+}
+
+func TestParseVersionETag_Success(t *testing.T) {
+	for eTag, expected := range map[string]int64{
+		"":      0,
+		"*":     0,
+		"\"3\"": 3,
+		"3":     3,
+	} {
+		version, err := ParseVersionETag(eTag)
+
+		assert.Nil(t, err)
+		assert.Equal(t, expected, version)
+	}
+}
+
+func TestParseVersionETag_Invalid(t *testing.T) {
+	for _, eTag := range []string{"\"abc\"", "\"0\"", "\"-1\"", "W/\"12\""} {
+		_, err := ParseVersionETag(eTag)
+
+		assert.NotNil(t, err)
+	}
+}
@@ -0,0 +1,76 @@
+package validation
+
+var englishMessages = map[string]string{
+	// the messages of the fields of the requests
+	"mac.length":               "MAC address must be between {min} and {max} characters",
+	"mac.pattern":              "Please enter a valid MAC address",
+	"name.length":              "Name must be between {min} and {max} characters",
+	"type.deviceType":          "Type must be one of {values}",
+	"type.oneof":               "Type must be {alternatives}",
+	"homeId.length":            "Home ID must be between {min} and {max} characters",
+	"id.length":                "ID must be between {min} and {max} characters",
+	"timezone.timezone":        "Please enter a valid IANA timezone, e.g. Europe/Madrid",
+	"address.max":              "Address must be at most {param} characters",
+	"owner.length":             "Owner must be between {min} and {max} characters",
+	"strategy.oneof":           "Strategy must be {alternatives}",
+	"targetHomeId.required_if": "Target home ID is required to reassign the devices",
+	"targetHomeId.length":      "Target home ID must be between {min} and {max} characters",
+	"roomId.uuid":              "Room ID must be the id of a room",
+	"roomId.excluded_with":     "Room ID can only be set with a deviceType",
+	"timeoutSeconds.range":     "Timeout must be between {min} and {max} seconds",
+	"commandId.uuid":           "Command ID must be the id of a command",
+	"status.oneof":             "Status must be {alternatives}",
+	"error.max":                "Error must be at most {param} characters",
+	"limit.range":              "Limit must be between {min} and {max}",
+	"metric.required_unless":   "Metric is required unless the record is a heartbeat",
+	"metric.max":               "Metric must be at most {param} characters",
+	"value.required_unless":    "Value is required unless the record is a heartbeat",
+	"operator.oneof":           "Operator must be one of {values}",
+	"from.datetime":            "From must be a time of the day, e.g. 22:00",
+	"to.datetime":              "To must be a time of the day, e.g. 22:00",
+	"command.length":           "Command must be between {min} and {max} characters",
+	"cron.required_without":    "Either a cron expression or an RRULE is required",
+	"cron.excluded_with":       "Only one of cron and rrule can be set",
+	"cron.max":                 "Cron must be at most {param} characters",
+	"rrule.max":                "RRULE must be at most {param} characters",
+	"deviceId.required":        "Device ID is required",
+	"target.required_without":  "Target must have a deviceId or a deviceType",
+	"target.excluded_with":     "Target must have either a deviceId or a deviceType, not both",
+	"deviceType.deviceType":    "Device type must be one of {values}",
+	"scene.devices":            "A scene must have between {min} and {max} devices",
+	"scene.state":              "Each device of the scene must have a state",
+	"cooldownSeconds.range":    "Cooldown must be between {min} and {max} seconds",
+
+	// the messages of the validator tags
+	"required":                 "{field} is required",
+	"required_if":              "{field} is required when {param}",
+	"required_unless":          "{field} is required unless {param}",
+	"required_with":            "{field} is required with {param}",
+	"required_without":         "{field} is required without {param}",
+	"excluded_with":            "{field} can not be set with {param}",
+	"excluded_without":         "{field} can only be set with {param}",
+	"min.string":               "{field} must be at least {param} characters",
+	"min.number":               "{field} must be at least {param}",
+	"min.items":                "{field} must have at least {param} items",
+	"max.string":               "{field} must be at most {param} characters",
+	"max.number":               "{field} must be at most {param}",
+	"max.items":                "{field} must have at most {param} items",
+	"len.string":               "{field} must be {param} characters long",
+	"len.number":               "{field} must be {param}",
+	"len.items":                "{field} must have {param} items",
+	"eq":                       "{field} must be {param}",
+	"ne":                       "{field} must not be {param}",
+	"gt":                       "{field} must be greater than {param}",
+	"gte":                      "{field} must be at least {param}",
+	"lt":                       "{field} must be less than {param}",
+	"lte":                      "{field} must be at most {param}",
+	"oneof":                    "{field} must be {alternatives}",
+	"uuid":                     "{field} must be a UUID",
+	"datetime":                 "{field} must match the format {param}",
+	"timezone":                 "{field} must be an IANA timezone, e.g. Europe/Madrid",
+	"email":                    "{field} must be an email address",
+	"url":                      "{field} must be a URL",
+	"MacACAddressPatternMatch": "{field} must be a MAC address",
+	"deviceType":               "{field} must be one of {values}",
+	invalidMessageKey:          "{field} is not valid ({rule})",
+}
@@ -0,0 +1,130 @@
+package validation
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The languages of the validation messages, English is the default.
+const (
+	LanguageEnglish = "en"
+	LanguageSpanish = "es"
+)
+
+// invalidMessageKey is used for the tags without a message of their own.
+const invalidMessageKey = "invalid"
+
+// The messages of each language by key.
+var messages = map[string]map[string]string{
+	LanguageEnglish: englishMessages,
+	LanguageSpanish: spanishMessages,
+}
+
+// The words used to join the alternatives of each language.
+var alternativeConjunctions = map[string]string{
+	LanguageEnglish: "or",
+	LanguageSpanish: "o",
+}
+
+type languageKey struct{}
+
+// WithLanguage returns a context whose validation messages are in the
+// language.
+func WithLanguage(ctx context.Context, language string) context.Context {
+	return context.WithValue(ctx, languageKey{}, language)
+}
+
+// LanguageFromContext returns the language of the validation messages, English
+// when the context has none.
+func LanguageFromContext(ctx context.Context) string {
+
+	if language, ok := ctx.Value(languageKey{}).(string); ok && messages[language] != nil {
+		return language
+	}
+
+	return LanguageEnglish
+}
+
+// NegotiateLanguage returns the language of the Accept-Language header with
+// the highest quality among the languages of the messages, e.g. es for
+// "es-ES,es;q=0.9,en;q=0.8", or English when there is none.
+func NegotiateLanguage(acceptLanguage string) string {
+
+	type weightedLanguage struct {
+		language string
+		quality  float64
+	}
+
+	var accepted []weightedLanguage
+	for _, languageRange := range strings.Split(acceptLanguage, ",") {
+
+		parameters := strings.Split(languageRange, ";")
+		tag := strings.ToLower(strings.TrimSpace(parameters[0]))
+		language, _, _ := strings.Cut(tag, "-")
+
+		quality := 1.0
+		for _, parameter := range parameters[1:] {
+			if value, found := strings.CutPrefix(strings.TrimSpace(parameter), "q="); found {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = parsed
+				}
+			}
+		}
+
+		if messages[language] != nil && quality > 0 {
+			accepted = append(accepted, weightedLanguage{language, quality})
+		}
+	}
+
+	if len(accepted) == 0 {
+		return LanguageEnglish
+	}
+
+	sort.SliceStable(accepted, func(i, j int) bool {
+		return accepted[i].quality > accepted[j].quality
+	})
+
+	return accepted[0].language
+}
+
+// Message returns the message of the error in the language, English when
+// there are no messages in the language, with the field, the rule and the
+// params in the placeholders of its template.
+func (fE FieldError) Message(language string) string {
+
+	if messages[language] == nil {
+		language = LanguageEnglish
+	}
+
+	template, ok := messages[language][fE.MessageKey]
+	if !ok {
+		template = messages[language][invalidMessageKey]
+	}
+
+	replacements := []string{"{field}", fE.Field, "{rule}", fE.Rule}
+	for name, value := range fE.Params {
+		if name != "values" {
+			replacements = append(replacements, "{"+name+"}", value)
+		}
+	}
+
+	if values, ok := fE.Params["values"]; ok {
+		replacements = append(replacements,
+			"{values}", strings.ReplaceAll(values, ",", ", "),
+			"{alternatives}", joinAlternatives(strings.Split(values, ","), alternativeConjunctions[language]))
+	}
+
+	return strings.NewReplacer(replacements...).Replace(template)
+}
+
+// joinAlternatives joins the values like a sentence, e.g. "online or offline".
+func joinAlternatives(values []string, conjunction string) string {
+
+	if len(values) < 2 {
+		return strings.Join(values, "")
+	}
+
+	return strings.Join(values[:len(values)-1], ", ") + " " + conjunction + " " + values[len(values)-1]
+}
@@ -0,0 +1,76 @@
+package validation
+
+var spanishMessages = map[string]string{
+	// the messages of the fields of the requests
+	"mac.length":               "La dirección MAC debe tener entre {min} y {max} caracteres",
+	"mac.pattern":              "Introduce una dirección MAC válida",
+	"name.length":              "El nombre debe tener entre {min} y {max} caracteres",
+	"type.deviceType":          "El tipo debe ser uno de {values}",
+	"type.oneof":               "El tipo debe ser {alternatives}",
+	"homeId.length":            "El ID del hogar debe tener entre {min} y {max} caracteres",
+	"id.length":                "El ID debe tener entre {min} y {max} caracteres",
+	"timezone.timezone":        "Introduce una zona horaria IANA válida, p. ej. Europe/Madrid",
+	"address.max":              "La dirección debe tener como máximo {param} caracteres",
+	"owner.length":             "El propietario debe tener entre {min} y {max} caracteres",
+	"strategy.oneof":           "La estrategia debe ser {alternatives}",
+	"targetHomeId.required_if": "El ID del hogar de destino es obligatorio para reasignar los dispositivos",
+	"targetHomeId.length":      "El ID del hogar de destino debe tener entre {min} y {max} caracteres",
+	"roomId.uuid":              "El ID de la habitación debe ser el id de una habitación",
+	"roomId.excluded_with":     "El ID de la habitación solo se puede indicar con un deviceType",
+	"timeoutSeconds.range":     "El tiempo de espera debe estar entre {min} y {max} segundos",
+	"commandId.uuid":           "El ID del comando debe ser el id de un comando",
+	"status.oneof":             "El estado debe ser {alternatives}",
+	"error.max":                "El error debe tener como máximo {param} caracteres",
+	"limit.range":              "El límite debe estar entre {min} y {max}",
+	"metric.required_unless":   "La métrica es obligatoria salvo que el registro sea un heartbeat",
+	"metric.max":               "La métrica debe tener como máximo {param} caracteres",
+	"value.required_unless":    "El valor es obligatorio salvo que el registro sea un heartbeat",
+	"operator.oneof":           "El operador debe ser uno de {values}",
+	"from.datetime":            "From debe ser una hora del día, p. ej. 22:00",
+	"to.datetime":              "To debe ser una hora del día, p. ej. 22:00",
+	"command.length":           "El comando debe tener entre {min} y {max} caracteres",
+	"cron.required_without":    "Se necesita una expresión cron o una RRULE",
+	"cron.excluded_with":       "Solo se puede indicar cron o rrule, no ambos",
+	"cron.max":                 "El cron debe tener como máximo {param} caracteres",
+	"rrule.max":                "La RRULE debe tener como máximo {param} caracteres",
+	"deviceId.required":        "El ID del dispositivo es obligatorio",
+	"target.required_without":  "El destino debe tener un deviceId o un deviceType",
+	"target.excluded_with":     "El destino debe tener un deviceId o un deviceType, no ambos",
+	"deviceType.deviceType":    "El tipo de dispositivo debe ser uno de {values}",
+	"scene.devices":            "Una escena debe tener entre {min} y {max} dispositivos",
+	"scene.state":              "Cada dispositivo de la escena debe tener un estado",
+	"cooldownSeconds.range":    "El tiempo de enfriamiento debe estar entre {min} y {max} segundos",
+
+	// the messages of the validator tags
+	"required":                 "{field} es obligatorio",
+	"required_if":              "{field} es obligatorio cuando {param}",
+	"required_unless":          "{field} es obligatorio salvo que {param}",
+	"required_with":            "{field} es obligatorio con {param}",
+	"required_without":         "{field} es obligatorio sin {param}",
+	"excluded_with":            "{field} no se puede indicar con {param}",
+	"excluded_without":         "{field} solo se puede indicar con {param}",
+	"min.string":               "{field} debe tener al menos {param} caracteres",
+	"min.number":               "{field} debe ser como mínimo {param}",
+	"min.items":                "{field} debe tener al menos {param} elementos",
+	"max.string":               "{field} debe tener como máximo {param} caracteres",
+	"max.number":               "{field} debe ser como máximo {param}",
+	"max.items":                "{field} debe tener como máximo {param} elementos",
+	"len.string":               "{field} debe tener {param} caracteres",
+	"len.number":               "{field} debe ser {param}",
+	"len.items":                "{field} debe tener {param} elementos",
+	"eq":                       "{field} debe ser {param}",
+	"ne":                       "{field} no debe ser {param}",
+	"gt":                       "{field} debe ser mayor que {param}",
+	"gte":                      "{field} debe ser como mínimo {param}",
+	"lt":                       "{field} debe ser menor que {param}",
+	"lte":                      "{field} debe ser como máximo {param}",
+	"oneof":                    "{field} debe ser {alternatives}",
+	"uuid":                     "{field} debe ser un UUID",
+	"datetime":                 "{field} debe tener el formato {param}",
+	"timezone":                 "{field} debe ser una zona horaria IANA, p. ej. Europe/Madrid",
+	"email":                    "{field} debe ser una dirección de correo electrónico",
+	"url":                      "{field} debe ser una URL",
+	"MacACAddressPatternMatch": "{field} debe ser una dirección MAC",
+	"deviceType":               "{field} debe ser uno de {values}",
+	invalidMessageKey:          "{field} no es válido ({rule})",
+}
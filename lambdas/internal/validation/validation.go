@@ -1,57 +1,271 @@
 package validation
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"reflect"
 	"regexp"
+	"strings"
 
+	// the lambda runtime does not have the zoneinfo database
+	_ "time/tzdata"
+
+	capability "github.com/odhoman/home-devices/internal/capability"
 	response "github.com/odhoman/home-devices/internal/response"
 
 	"github.com/go-playground/validator/v10"
 )
 
+// FieldError is a field of a request that is not valid: the JSON path of the
+// field, e.g. target.deviceId, the validator tag it fails, its params and the
+// key of its message.
+type FieldError struct {
+	Field      string
+	Rule       string
+	Params     map[string]string
+	MessageKey string
+}
+
+// The message keys of the fields with a message of their own, by the name of
+// the field in the request struct and the validator tag.
+var fieldMessageKeys = map[string]string{
+	"MAC.MacACAddressPatternMatch": "mac.pattern",
+	"Type.deviceType":              "type.deviceType",
+	"Type.oneof":                   "type.oneof",
+	"Timezone.timezone":            "timezone.timezone",
+	"Address.max":                  "address.max",
+	"Strategy.oneof":               "strategy.oneof",
+	"TargetHomeID.required_if":     "targetHomeId.required_if",
+	"RoomID.uuid":                  "roomId.uuid",
+	"RoomID.excluded_with":         "roomId.excluded_with",
+	"CommandID.uuid":               "commandId.uuid",
+	"Status.oneof":                 "status.oneof",
+	"Error.max":                    "error.max",
+	"Metric.required_unless":       "metric.required_unless",
+	"Metric.max":                   "metric.max",
+	"Value.required_unless":        "value.required_unless",
+	"Operator.oneof":               "operator.oneof",
+	"From.datetime":                "from.datetime",
+	"To.datetime":                  "to.datetime",
+	"Cron.required_without":        "cron.required_without",
+	"Cron.excluded_with":           "cron.excluded_with",
+	"Cron.max":                     "cron.max",
+	"RRule.max":                    "rrule.max",
+	"DeviceID.required":            "deviceId.required",
+	"DeviceID.required_without":    "target.required_without",
+	"DeviceID.excluded_with":       "target.excluded_with",
+	"DeviceType.deviceType":        "deviceType.deviceType",
+	"State.required":               "scene.state",
+	"State.min":                    "scene.state",
+}
+
+// The message keys of the fields with a length or a range, whose messages show
+// both bounds, e.g. "Name must be between {min} and {max} characters".
+var boundedFieldMessageKeys = map[string]string{
+	"MAC.min":             "mac.length",
+	"MAC.max":             "mac.length",
+	"Name.min":            "name.length",
+	"Name.max":            "name.length",
+	"HomeID.min":          "homeId.length",
+	"HomeID.max":          "homeId.length",
+	"ID.min":              "id.length",
+	"ID.max":              "id.length",
+	"Owner.min":           "owner.length",
+	"Owner.max":           "owner.length",
+	"TargetHomeID.min":    "targetHomeId.length",
+	"TargetHomeID.max":    "targetHomeId.length",
+	"TimeoutSeconds.min":  "timeoutSeconds.range",
+	"TimeoutSeconds.max":  "timeoutSeconds.range",
+	"Limit.min":           "limit.range",
+	"Limit.max":           "limit.range",
+	"Command.min":         "command.length",
+	"Command.max":         "command.length",
+	"Devices.required":    "scene.devices",
+	"Devices.min":         "scene.devices",
+	"Devices.max":         "scene.devices",
+	"CooldownSeconds.min": "cooldownSeconds.range",
+	"CooldownSeconds.max": "cooldownSeconds.range",
+}
+
+// ValidateDeviceRequestStruct validates the request and returns the English
+// messages of the fields that are not valid.
 func ValidateDeviceRequestStruct(s interface{}) []string {
+	var validationErrors []string
+	for _, fieldError := range ValidateRequest(s) {
+		validationErrors = append(validationErrors, fieldError.Message(LanguageEnglish))
+	}
+	return validationErrors
+}
+
+// ValidateRequestParams validates the request and returns the fields that are
+// not valid, with their messages in the language of the context.
+func ValidateRequestParams(ctx context.Context, s interface{}) []response.InvalidParam {
+	language := LanguageFromContext(ctx)
+	var invalidParams []response.InvalidParam
+	for _, fieldError := range ValidateRequest(s) {
+		invalidParams = append(invalidParams, response.InvalidParam{
+			Name:       fieldError.Field,
+			Reason:     fieldError.Message(language),
+			Rule:       fieldError.Rule,
+			Params:     fieldError.Params,
+			MessageKey: fieldError.MessageKey,
+		})
+	}
+	return invalidParams
+}
+
+// ValidateRequest validates the request and returns the fields that are not
+// valid, named by their path in the JSON of the request, e.g. target.deviceId
+// or devices[0].state.
+func ValidateRequest(s interface{}) []FieldError {
 	validate := validator.New()
+	validate.RegisterTagNameFunc(getJSONFieldName)
 	validate.RegisterValidation("MacACAddressPatternMatch", validateMACAddress)
-	var validationErrors []string
+	validate.RegisterValidation("deviceType", validateDeviceType)
+	var fieldErrors []FieldError
 	if err := validate.Struct(s); err != nil {
 
 		for _, err := range err.(validator.ValidationErrors) {
-			validationErrors = append(validationErrors, getMessageForFieldError(err.Tag(), err.Field()))
+			messageKey, params := getMessageKey(reflect.TypeOf(s), err)
+			fieldErrors = append(fieldErrors, FieldError{
+				Field:      getParamName(err.Namespace()),
+				Rule:       err.Tag(),
+				Params:     params,
+				MessageKey: messageKey,
+			})
 		}
 
 	}
-	return validationErrors
+	return fieldErrors
 }
 
-func getMessageForFieldError(tag, field string) string {
+// getMessageKey returns the key of the message of the field when it has one, or
+// the one of the tag, e.g. min.string for the minimum length of a string, and
+// the params of the message.
+func getMessageKey(structType reflect.Type, err validator.FieldError) (string, map[string]string) {
+
+	name := err.StructField() + "." + err.Tag()
+	params := getParams(err)
 
-	switch field {
-	case "MAC":
-		if tag == "min" || tag == "max" {
-			return "MAC address must be between 12 and 17 characters"
-		} else if tag == "MacACAddressPatternMatch" {
-			return "Please enter a valid MAC address"
+	if key, ok := boundedFieldMessageKeys[name]; ok {
+		if min, max, found := getBounds(structType, err.StructNamespace()); found {
+			if params == nil {
+				params = map[string]string{}
+			}
+			params["min"] = min
+			params["max"] = max
+			return key, params
 		}
-	case "Name":
-		if tag == "min" || tag == "max" {
-			return "Name must be between 3 and 50 characters"
+	}
+
+	if key, ok := fieldMessageKeys[name]; ok {
+		return key, params
+	}
+
+	switch err.Tag() {
+	case "min", "max", "len":
+		switch err.Kind() {
+		case reflect.String:
+			return err.Tag() + ".string", params
+		case reflect.Slice, reflect.Map, reflect.Array:
+			return err.Tag() + ".items", params
+		default:
+			return err.Tag() + ".number", params
 		}
-	case "Type":
-		if tag == "min" || tag == "max" {
-			return "Type must be between 3 and 20 characters"
+	}
+
+	return err.Tag(), params
+}
+
+// getParams returns the param of the tag and, for the tags accepting a set of
+// values, the values separated by commas.
+func getParams(err validator.FieldError) map[string]string {
+
+	params := map[string]string{}
+
+	if err.Param() != "" {
+		params["param"] = err.Param()
+	}
+
+	switch err.Tag() {
+	case "oneof":
+		params["values"] = strings.Join(strings.Fields(err.Param()), ",")
+	case "deviceType":
+		params["values"] = strings.Join(capability.Names(), ",")
+	}
+
+	if len(params) == 0 {
+		return nil
+	}
+
+	return params
+}
+
+// getBounds returns the min and the max of the validator tag of the field in
+// the namespace, e.g. 3 and 50 for "required,min=3,max=50".
+func getBounds(structType reflect.Type, structNamespace string) (string, string, bool) {
+
+	names := strings.Split(structNamespace, ".")[1:]
+	if len(names) == 0 {
+		return "", "", false
+	}
+
+	var field reflect.StructField
+	for _, name := range names {
+
+		for structType.Kind() == reflect.Pointer || structType.Kind() == reflect.Slice || structType.Kind() == reflect.Array || structType.Kind() == reflect.Map {
+			structType = structType.Elem()
 		}
-	case "HomeID":
-		if tag == "min" || tag == "max" {
-			return "Home ID must be between 5 and 30 characters"
+
+		fieldName, _, _ := strings.Cut(name, "[")
+		found := false
+		if structType.Kind() == reflect.Struct {
+			field, found = structType.FieldByName(fieldName)
+		}
+
+		if !found {
+			return "", "", false
+		}
+
+		structType = field.Type
+	}
+
+	fieldTags, itemTags, _ := strings.Cut(field.Tag.Get("validate"), "dive")
+	if strings.HasSuffix(names[len(names)-1], "]") {
+		fieldTags = itemTags
+	}
+
+	var min, max string
+	for _, tag := range strings.Split(fieldTags, ",") {
+		if value, found := strings.CutPrefix(tag, "min="); found {
+			min = value
+		} else if value, found := strings.CutPrefix(tag, "max="); found {
+			max = value
 		}
 	}
 
-	return getDefaultValidationErrorMessage(tag, field)
+	return min, max, min != "" && max != ""
 }
 
-func getDefaultValidationErrorMessage(tag, field string) string {
-	return fmt.Sprintf("Validation failed for field '%s': %s", field, tag)
+// getJSONFieldName names the fields by their JSON name, so the namespaces of
+// the validation errors are the paths of the fields in the request.
+func getJSONFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+// getParamName removes the name of the request struct from the namespace of a
+// validation error, e.g. CreateDeviceRequest.mac is mac.
+func getParamName(namespace string) string {
+	_, name, found := strings.Cut(namespace, ".")
+	if !found {
+		return namespace
+	}
+	return name
 }
 
 func ValidateAndResponseBadRequestErrors(s interface{}) map[string]interface{} {
@@ -83,3 +297,9 @@ func validateMACAddress(fl validator.FieldLevel) bool {
 	macRegex := regexp.MustCompile(`^([0-9A-Fa-f]{2}[:-]){5}([0-9A-Fa-f]{2})$`)
 	return macRegex.MatchString(mac)
 }
+
+// validateDeviceType accepts the types of the capability registry.
+func validateDeviceType(fl validator.FieldLevel) bool {
+	_, found := capability.Get(fl.Field().String())
+	return found
+}
@@ -0,0 +1,125 @@
+package validation
+
+import (
+	"context"
+	"testing"
+
+	hDRequest "github.com/odhoman/home-devices/internal/request"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRequest_FieldErrors(t *testing.T) {
+
+	fieldErrors := ValidateRequest(hDRequest.ScheduleRequest{
+		Name:    "Lights off",
+		Cron:    "0 0 * * *",
+		Target:  hDRequest.ScheduleTarget{DeviceType: "toaster", RoomID: "kitchen"},
+		Command: "on",
+	})
+
+	assert.Equal(t, []FieldError{
+		{Field: "target.deviceType", Rule: "deviceType", Params: map[string]string{"values": "light,lock,plug,sensor,thermostat"}, MessageKey: "deviceType.deviceType"},
+		{Field: "target.roomId", Rule: "uuid", MessageKey: "roomId.uuid"},
+		{Field: "command", Rule: "min", Params: map[string]string{"param": "3", "min": "3", "max": "50"}, MessageKey: "command.length"},
+	}, fieldErrors)
+}
+
+func TestValidateRequest_NestedSlices(t *testing.T) {
+
+	fieldErrors := ValidateRequest(hDRequest.SceneRequest{
+		Name:    "Movie night",
+		Devices: []hDRequest.SceneDevice{{DeviceID: "light-1", State: map[string]interface{}{"on": false}}, {DeviceID: "light-2"}},
+	})
+
+	assert.Equal(t, []FieldError{{Field: "devices[1].state", Rule: "required", MessageKey: "scene.state"}}, fieldErrors)
+}
+
+func TestValidateRequest_BoundsOfTheRequest(t *testing.T) {
+
+	type roomRequest struct {
+		Name  string `json:"name" validate:"min=3,max=50"`
+		Limit int32  `json:"limit" validate:"min=1,max=100"`
+	}
+
+	type labelRequest struct {
+		Name  string `json:"name" validate:"min=1,max=20"`
+		Limit int32  `json:"limit" validate:"min=10"`
+	}
+
+	roomErrors := ValidateRequest(roomRequest{Name: "ab", Limit: 0})
+	labelErrors := ValidateRequest(labelRequest{Name: "a very long name for a label", Limit: 5})
+
+	assert.Equal(t, []string{"Name must be between 3 and 50 characters", "Limit must be between 1 and 100"},
+		[]string{roomErrors[0].Message(LanguageEnglish), roomErrors[1].Message(LanguageEnglish)})
+	assert.Equal(t, []string{"Name must be between 1 and 20 characters", "limit must be at least 10"},
+		[]string{labelErrors[0].Message(LanguageEnglish), labelErrors[1].Message(LanguageEnglish)})
+	assert.Equal(t, "El nombre debe tener entre 1 y 20 caracteres", labelErrors[0].Message(LanguageSpanish))
+
+	assert.Equal(t, FieldError{Field: "limit", Rule: "min", Params: map[string]string{"param": "10"}, MessageKey: "min.number"}, labelErrors[1])
+}
+
+func TestFieldError_Message(t *testing.T) {
+
+	tests := []struct {
+		fieldError FieldError
+		english    string
+		spanish    string
+	}{
+		{FieldError{Field: "name", Rule: "required", MessageKey: "required"}, "name is required", "name es obligatorio"},
+		{FieldError{Field: "name", Rule: "min", Params: map[string]string{"param": "3"}, MessageKey: "min.string"}, "name must be at least 3 characters", "name debe tener al menos 3 caracteres"},
+		{FieldError{Field: "status", Rule: "oneof", Params: map[string]string{"param": "online offline", "values": "online,offline"}, MessageKey: "status.oneof"}, "Status must be online or offline", "El estado debe ser online o offline"},
+		{FieldError{Field: "mac", Rule: "MacACAddressPatternMatch", MessageKey: "mac.pattern"}, "Please enter a valid MAC address", "Introduce una dirección MAC válida"},
+		{FieldError{Field: "code", Rule: "hexcolor", MessageKey: "hexcolor"}, "code is not valid (hexcolor)", "code no es válido (hexcolor)"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.english, test.fieldError.Message(LanguageEnglish))
+		assert.Equal(t, test.spanish, test.fieldError.Message(LanguageSpanish))
+		assert.Equal(t, test.english, test.fieldError.Message("fr"))
+	}
+}
+
+func TestMessages_EveryKeyIsTranslated(t *testing.T) {
+
+	for key := range englishMessages {
+		assert.Contains(t, spanishMessages, key)
+	}
+
+	for key := range spanishMessages {
+		assert.Contains(t, englishMessages, key)
+	}
+
+	for key := range fieldMessageKeys {
+		assert.Contains(t, englishMessages, fieldMessageKeys[key], key)
+	}
+
+	for key := range boundedFieldMessageKeys {
+		assert.Contains(t, englishMessages, boundedFieldMessageKeys[key], key)
+	}
+}
+
+func TestNegotiateLanguage(t *testing.T) {
+
+	assert.Equal(t, LanguageEnglish, NegotiateLanguage(""))
+	assert.Equal(t, LanguageSpanish, NegotiateLanguage("es"))
+	assert.Equal(t, LanguageSpanish, NegotiateLanguage("es-ES,es;q=0.9,en;q=0.8"))
+	assert.Equal(t, LanguageEnglish, NegotiateLanguage("es;q=0.5, en-GB"))
+	assert.Equal(t, LanguageSpanish, NegotiateLanguage("fr-FR, es;q=0.7"))
+	assert.Equal(t, LanguageEnglish, NegotiateLanguage("fr-FR, es;q=0"))
+}
+
+func TestValidateRequestParams_LanguageOfTheContext(t *testing.T) {
+
+	request := hDRequest.CreateHomeRequest{Timezone: "UTC", Owner: "user-1"}
+
+	invalidParams := ValidateRequestParams(WithLanguage(context.Background(), LanguageSpanish), request)
+
+	assert.Len(t, invalidParams, 1)
+	assert.Equal(t, "name", invalidParams[0].Name)
+	assert.Equal(t, "name es obligatorio", invalidParams[0].Reason)
+	assert.Equal(t, "required", invalidParams[0].Rule)
+	assert.Equal(t, "required", invalidParams[0].MessageKey)
+
+	assert.Equal(t, []string{"name is required"}, ValidateDeviceRequestStruct(request))
+}
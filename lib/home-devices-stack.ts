@@ -7,6 +7,9 @@ import * as sqs from 'aws-cdk-lib/aws-sqs';
 import * as eventSources from 'aws-cdk-lib/aws-lambda-event-sources';
 import * as iam from 'aws-cdk-lib/aws-iam';
 import * as kinesis from 'aws-cdk-lib/aws-kinesis';
+import * as sns from 'aws-cdk-lib/aws-sns';
+import * as events from 'aws-cdk-lib/aws-events';
+import * as targets from 'aws-cdk-lib/aws-events-targets';
 import { LambdaHelper } from './helper/lambda-helper';
 import { ApiGatewayHelper } from './helper/api-gateway-helper';
 
@@ -15,16 +18,71 @@ export class HomeDevicesStack extends cdk.Stack {
     super(scope, id, props);
 
     const macHomeIdIndexName = "MacHomeIdIndex"
+    const homeIdIndexName = "HomeIdIndex"
+    const deletedDeviceRetentionDays = "30"
+    const triggerDeviceIdIndexName = "TriggerDeviceIdIndex"
+    const nextRunAtIndexName = "NextRunAtIndex"
+    const statusIndexName = "StatusIndex"
 
     // Table
     var homeDevicesTable = this.createHomeDeviceTable(this, "HomeDevices", "id"); 
     this.addGlobalSecondaryIndex(homeDevicesTable, macHomeIdIndexName, "mac", "homeId")
+    this.addGlobalSecondaryIndex(homeDevicesTable, homeIdIndexName, "homeId", "createdAt", dynamodb.AttributeType.NUMBER)
+    this.addGlobalSecondaryIndex(homeDevicesTable, statusIndexName, "status", "lastSeenAt", dynamodb.AttributeType.NUMBER)
+    const deviceHistoryTable = this.createDeviceHistoryTable(this, "HomeDeviceHistory");
+    const telemetryTable = this.createTelemetryTable(this, "HomeDeviceTelemetry");
+    const deviceShadowTable = this.createDeviceShadowTable(this, "HomeDeviceShadow");
+    const homesTable = this.createHomeTable(this, "Homes");
+    const roomsTable = this.createRoomTable(this, "HomeRooms");
+    const deviceCommandsTable = this.createDeviceCommandTable(this, "HomeDeviceCommands");
+    const rulesTable = this.createRuleTable(this, "HomeRules");
+    this.addGlobalSecondaryIndex(rulesTable, triggerDeviceIdIndexName, "triggerDeviceId", "id")
+    const ruleExecutionsTable = this.createRuleExecutionTable(this, "HomeRuleExecutions");
+    const schedulesTable = this.createScheduleTable(this, "HomeSchedules");
+    this.addGlobalSecondaryIndex(schedulesTable, nextRunAtIndexName, "runState", "nextRunAt", dynamodb.AttributeType.NUMBER)
+    const scenesTable = this.createSceneTable(this, "HomeScenes");
+    const deviceCountersTable = this.createDeviceCounterTable(this, "HomeDeviceCounters");
 
     // Queue
+    const homeDevicesDeadLetterQueue = new sqs.Queue(this, 'HomeDevicesDLQ', {
+      retentionPeriod: cdk.Duration.days(14),
+    });
+
     const homeDevicesQueue = new sqs.Queue(this, 'HomeDevicesSQS', {
       retentionPeriod: cdk.Duration.days(4),
+      // messages that keep failing with transient errors end up in the DLQ too
+      deadLetterQueue: {
+        queue: homeDevicesDeadLetterQueue,
+        maxReceiveCount: 5,
+      },
+    });
+
+    // commands published for the devices, and the acks they send back
+    const deviceCommandsQueue = new sqs.Queue(this, 'HomeDeviceCommandsQueue', {
+      // no command lives longer than the maximum timeout of one hour
+      retentionPeriod: cdk.Duration.hours(1),
+    });
+
+    const deviceCommandAcksDeadLetterQueue = new sqs.Queue(this, 'HomeDeviceCommandAcksDLQ', {
+      retentionPeriod: cdk.Duration.days(14),
+    });
+
+    const deviceCommandAcksQueue = new sqs.Queue(this, 'HomeDeviceCommandAcksQueue', {
+      retentionPeriod: cdk.Duration.days(1),
+      deadLetterQueue: {
+        queue: deviceCommandAcksDeadLetterQueue,
+        maxReceiveCount: 5,
+      },
+    });
+
+    // the records of the devices stream that could not be projected
+    const deviceStreamDeadLetterQueue = new sqs.Queue(this, 'HomeDeviceStreamDLQ', {
+      retentionPeriod: cdk.Duration.days(14),
     });
 
+    // events published for every change of a device, for the other teams to subscribe to
+    const deviceEventsTopic = new sns.Topic(this, 'HomeDeviceEventsTopic');
+
     // Kinesis Stream
     const kinesisStream = new kinesis.Stream(this, 'HomeDevicesKinesisStream', {
       streamMode: kinesis.StreamMode.PROVISIONED,
@@ -32,12 +90,50 @@ export class HomeDevicesStack extends cdk.Stack {
     });
 
     // Lambdas
-    const createDeviceLambda = this.createCreateDeviceLambda(homeDevicesTable, macHomeIdIndexName);
+    const createDeviceLambda = this.createCreateDeviceLambda(homeDevicesTable, deviceHistoryTable, homesTable, roomsTable, macHomeIdIndexName, deviceEventsTopic);
     const getDeviceLambda = this.createGetDeviceLambda(homeDevicesTable);
-    const updateDeviceLambda = this.createUpdateDeviceLambda(homeDevicesTable);
-    const deleteDeviceLambda = this.createDeleteDeviceLambda(homeDevicesTable);
-    const kinesisLambda = this.createKinesisLambda(kinesisStream);
-    this.createHomeDeviceListenerLambda(this, homeDevicesQueue, homeDevicesTable, macHomeIdIndexName);
+    const updateDeviceLambda = this.createUpdateDeviceLambda(homeDevicesTable, deviceHistoryTable, homesTable, roomsTable, deviceEventsTopic);
+    const deleteDeviceLambda = this.createDeleteDeviceLambda(homeDevicesTable, deviceHistoryTable, roomsTable, deletedDeviceRetentionDays, deviceEventsTopic);
+    const restoreDeviceLambda = this.createRestoreDeviceLambda(homeDevicesTable, deviceHistoryTable, homesTable, roomsTable, deletedDeviceRetentionDays, deviceEventsTopic);
+    const listDevicesLambda = this.createListDevicesLambda(homeDevicesTable, homeIdIndexName);
+    const getDeviceHistoryLambda = this.createGetDeviceHistoryLambda(deviceHistoryTable);
+    const getDeviceStateLambda = this.createGetDeviceStateLambda(homeDevicesTable, deviceShadowTable);
+    const updateDeviceStateLambda = this.createUpdateDeviceStateLambda(homeDevicesTable, deviceShadowTable);
+    const kinesisLambda = this.createKinesisLambda(kinesisStream, homeDevicesTable, deviceHistoryTable, telemetryTable, deviceShadowTable, rulesTable, ruleExecutionsTable, deviceCommandsTable, deviceCommandsQueue, triggerDeviceIdIndexName, deviceEventsTopic);
+    const createHomeLambda = this.createCreateHomeLambda(homesTable);
+    const getHomeLambda = this.createGetHomeLambda(homesTable);
+    const updateHomeLambda = this.createUpdateHomeLambda(homesTable);
+    const deleteHomeLambda = this.createDeleteHomeLambda(homesTable, roomsTable, rulesTable, schedulesTable, scenesTable, homeDevicesTable, deviceHistoryTable, macHomeIdIndexName, homeIdIndexName, deletedDeviceRetentionDays, deviceEventsTopic);
+    const createRoomLambda = this.createCreateRoomLambda(roomsTable, homesTable);
+    const listRoomsLambda = this.createListRoomsLambda(roomsTable);
+    const getRoomLambda = this.createGetRoomLambda(roomsTable);
+    const updateRoomLambda = this.createUpdateRoomLambda(roomsTable);
+    const deleteRoomLambda = this.createDeleteRoomLambda(roomsTable, homeDevicesTable, homeIdIndexName);
+    const listDeviceTypesLambda = this.createListDeviceTypesLambda();
+    const createRuleLambda = this.createCreateRuleLambda(rulesTable, homesTable, homeDevicesTable);
+    const listRulesLambda = this.createListRulesLambda(rulesTable);
+    const getRuleLambda = this.createGetRuleLambda(rulesTable);
+    const updateRuleLambda = this.createUpdateRuleLambda(rulesTable, homesTable, homeDevicesTable);
+    const deleteRuleLambda = this.createDeleteRuleLambda(rulesTable);
+    const listRuleExecutionsLambda = this.createListRuleExecutionsLambda(rulesTable, ruleExecutionsTable);
+    const sendDeviceCommandLambda = this.createSendDeviceCommandLambda(homeDevicesTable, deviceCommandsTable, deviceCommandsQueue);
+    const getDeviceCommandLambda = this.createGetDeviceCommandLambda(deviceCommandsTable);
+    const createScheduleLambda = this.createCreateScheduleLambda(schedulesTable, homesTable, roomsTable, homeDevicesTable);
+    const listSchedulesLambda = this.createListSchedulesLambda(schedulesTable);
+    const getScheduleLambda = this.createGetScheduleLambda(schedulesTable);
+    const updateScheduleLambda = this.createUpdateScheduleLambda(schedulesTable, homesTable, roomsTable, homeDevicesTable);
+    const deleteScheduleLambda = this.createDeleteScheduleLambda(schedulesTable);
+    this.createSchedulerLambda(schedulesTable, homeDevicesTable, deviceCommandsTable, deviceCommandsQueue, nextRunAtIndexName, homeIdIndexName);
+    this.createPresenceSweeperLambda(homeDevicesTable, deviceHistoryTable, statusIndexName, deviceEventsTopic);
+    this.createDeviceStreamProcessorLambda(homeDevicesTable, deviceCountersTable, deviceStreamDeadLetterQueue);
+    const createSceneLambda = this.createCreateSceneLambda(scenesTable, homesTable, homeDevicesTable);
+    const listScenesLambda = this.createListScenesLambda(scenesTable);
+    const getSceneLambda = this.createGetSceneLambda(scenesTable);
+    const updateSceneLambda = this.createUpdateSceneLambda(scenesTable, homesTable, homeDevicesTable);
+    const deleteSceneLambda = this.createDeleteSceneLambda(scenesTable);
+    const activateSceneLambda = this.createActivateSceneLambda(scenesTable, homeDevicesTable, deviceShadowTable);
+    this.createDeviceCommandAckListenerLambda(deviceCommandAcksQueue, deviceCommandAcksDeadLetterQueue, deviceCommandsTable);
+    this.createHomeDeviceListenerLambda(this, homeDevicesQueue, homeDevicesDeadLetterQueue, homeDevicesTable, deviceHistoryTable, homesTable, roomsTable, macHomeIdIndexName, deletedDeviceRetentionDays, deviceEventsTopic);
 
     // ApiGateway
     const api = ApiGatewayHelper.createApiGateway(this, 'HomeDevicesApi');
@@ -45,45 +141,220 @@ export class HomeDevicesStack extends cdk.Stack {
     ApiGatewayHelper.addLambdaIntegration(api, 'v1/device/{id}', 'GET', new apigateway.LambdaIntegration(getDeviceLambda));
     ApiGatewayHelper.addLambdaIntegration(api, 'v1/device/{id}', 'PUT', new apigateway.LambdaIntegration(updateDeviceLambda));
     ApiGatewayHelper.addLambdaIntegration(api, 'v1/device/{id}', 'DELETE', new apigateway.LambdaIntegration(deleteDeviceLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/device/{id}/restore', 'POST', new apigateway.LambdaIntegration(restoreDeviceLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/device/{id}/history', 'GET', new apigateway.LambdaIntegration(getDeviceHistoryLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/device/{id}/state', 'GET', new apigateway.LambdaIntegration(getDeviceStateLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/device/{id}/state', 'PATCH', new apigateway.LambdaIntegration(updateDeviceStateLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/device/{id}/commands', 'POST', new apigateway.LambdaIntegration(sendDeviceCommandLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/device/{id}/commands/{cmdId}', 'GET', new apigateway.LambdaIntegration(getDeviceCommandLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}/devices', 'GET', new apigateway.LambdaIntegration(listDevicesLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home', 'POST', new apigateway.LambdaIntegration(createHomeLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}', 'GET', new apigateway.LambdaIntegration(getHomeLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}', 'PUT', new apigateway.LambdaIntegration(updateHomeLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}', 'DELETE', new apigateway.LambdaIntegration(deleteHomeLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}/rooms', 'POST', new apigateway.LambdaIntegration(createRoomLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}/rooms', 'GET', new apigateway.LambdaIntegration(listRoomsLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}/rooms/{roomId}', 'GET', new apigateway.LambdaIntegration(getRoomLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}/rooms/{roomId}', 'PUT', new apigateway.LambdaIntegration(updateRoomLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}/rooms/{roomId}', 'DELETE', new apigateway.LambdaIntegration(deleteRoomLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}/rules', 'POST', new apigateway.LambdaIntegration(createRuleLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}/rules', 'GET', new apigateway.LambdaIntegration(listRulesLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}/rules/{ruleId}', 'GET', new apigateway.LambdaIntegration(getRuleLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}/rules/{ruleId}', 'PUT', new apigateway.LambdaIntegration(updateRuleLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}/rules/{ruleId}', 'DELETE', new apigateway.LambdaIntegration(deleteRuleLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}/rules/{ruleId}/executions', 'GET', new apigateway.LambdaIntegration(listRuleExecutionsLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}/schedules', 'POST', new apigateway.LambdaIntegration(createScheduleLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}/schedules', 'GET', new apigateway.LambdaIntegration(listSchedulesLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}/schedules/{scheduleId}', 'GET', new apigateway.LambdaIntegration(getScheduleLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}/schedules/{scheduleId}', 'PUT', new apigateway.LambdaIntegration(updateScheduleLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}/schedules/{scheduleId}', 'DELETE', new apigateway.LambdaIntegration(deleteScheduleLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}/scenes', 'POST', new apigateway.LambdaIntegration(createSceneLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}/scenes', 'GET', new apigateway.LambdaIntegration(listScenesLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}/scenes/{sceneId}', 'GET', new apigateway.LambdaIntegration(getSceneLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}/scenes/{sceneId}', 'PUT', new apigateway.LambdaIntegration(updateSceneLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}/scenes/{sceneId}', 'DELETE', new apigateway.LambdaIntegration(deleteSceneLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/home/{homeId}/scenes/{sceneId}/activate', 'POST', new apigateway.LambdaIntegration(activateSceneLambda));
+    ApiGatewayHelper.addLambdaIntegration(api, 'v1/device-types', 'GET', new apigateway.LambdaIntegration(listDeviceTypesLambda));
   }
 
   private createHomeDeviceTable(scope: Construct, name: string, partitionKeyName: string): dynamodb.Table {
     var homeDevicesTable = new dynamodb.Table(scope, name, {
       partitionKey: { name: partitionKeyName, type: dynamodb.AttributeType.STRING },
       removalPolicy: cdk.RemovalPolicy.RETAIN,
+      // soft deleted devices are removed once the retention window is over
+      timeToLiveAttribute: 'expiresAt',
+      // every write, from the lambdas or not, is projected by the device stream processor
+      stream: dynamodb.StreamViewType.NEW_AND_OLD_IMAGES,
     });
 
     return homeDevicesTable;
   }
 
-  private addGlobalSecondaryIndex(table: dynamodb.Table, macHomeIdIndexName: string, partitionKey: string, sortKey: string): void {
+  private createDeviceHistoryTable(scope: Construct, name: string): dynamodb.Table {
+    // one item per change, sorted by time within each device
+    var deviceHistoryTable = new dynamodb.Table(scope, name, {
+      partitionKey: { name: 'deviceId', type: dynamodb.AttributeType.STRING },
+      sortKey: { name: 'changeId', type: dynamodb.AttributeType.STRING },
+      removalPolicy: cdk.RemovalPolicy.RETAIN,
+    });
+
+    return deviceHistoryTable;
+  }
+
+  private createTelemetryTable(scope: Construct, name: string): dynamodb.Table {
+    // one item per reading, readingId is the timestamp and the metric so the readings are sorted by time within each device
+    var telemetryTable = new dynamodb.Table(scope, name, {
+      partitionKey: { name: 'deviceId', type: dynamodb.AttributeType.STRING },
+      sortKey: { name: 'readingId', type: dynamodb.AttributeType.STRING },
+      removalPolicy: cdk.RemovalPolicy.RETAIN,
+    });
+
+    return telemetryTable;
+  }
+
+  private createDeviceShadowTable(scope: Construct, name: string): dynamodb.Table {
+    // one item per device with its desired and reported state documents
+    var deviceShadowTable = new dynamodb.Table(scope, name, {
+      partitionKey: { name: 'deviceId', type: dynamodb.AttributeType.STRING },
+      removalPolicy: cdk.RemovalPolicy.RETAIN,
+    });
+
+    return deviceShadowTable;
+  }
+
+  private createHomeTable(scope: Construct, name: string): dynamodb.Table {
+    // one item per home, the devices reference it by homeId
+    var homesTable = new dynamodb.Table(scope, name, {
+      partitionKey: { name: 'id', type: dynamodb.AttributeType.STRING },
+      removalPolicy: cdk.RemovalPolicy.RETAIN,
+    });
+
+    return homesTable;
+  }
+
+  private createRoomTable(scope: Construct, name: string): dynamodb.Table {
+    // one item per room, grouped by the home they belong to
+    var roomsTable = new dynamodb.Table(scope, name, {
+      partitionKey: { name: 'homeId', type: dynamodb.AttributeType.STRING },
+      sortKey: { name: 'id', type: dynamodb.AttributeType.STRING },
+      removalPolicy: cdk.RemovalPolicy.RETAIN,
+    });
+
+    return roomsTable;
+  }
+
+  private createDeviceCommandTable(scope: Construct, name: string): dynamodb.Table {
+    // one item per command, grouped by the device it was sent to
+    var deviceCommandsTable = new dynamodb.Table(scope, name, {
+      partitionKey: { name: 'deviceId', type: dynamodb.AttributeType.STRING },
+      sortKey: { name: 'id', type: dynamodb.AttributeType.STRING },
+      removalPolicy: cdk.RemovalPolicy.RETAIN,
+    });
+
+    return deviceCommandsTable;
+  }
+
+  private createRuleTable(scope: Construct, name: string): dynamodb.Table {
+    // one item per rule, grouped by the home they belong to; the trigger device index finds the rules of a reading
+    var rulesTable = new dynamodb.Table(scope, name, {
+      partitionKey: { name: 'homeId', type: dynamodb.AttributeType.STRING },
+      sortKey: { name: 'id', type: dynamodb.AttributeType.STRING },
+      removalPolicy: cdk.RemovalPolicy.RETAIN,
+    });
+
+    return rulesTable;
+  }
+
+  private createRuleExecutionTable(scope: Construct, name: string): dynamodb.Table {
+    // one item per execution, sorted by time within each rule
+    var ruleExecutionsTable = new dynamodb.Table(scope, name, {
+      partitionKey: { name: 'ruleId', type: dynamodb.AttributeType.STRING },
+      sortKey: { name: 'id', type: dynamodb.AttributeType.STRING },
+      removalPolicy: cdk.RemovalPolicy.RETAIN,
+    });
+
+    return ruleExecutionsTable;
+  }
+
+  private createScheduleTable(scope: Construct, name: string): dynamodb.Table {
+    // one item per schedule, grouped by the home they belong to; only the enabled schedules are in the next run index
+    var schedulesTable = new dynamodb.Table(scope, name, {
+      partitionKey: { name: 'homeId', type: dynamodb.AttributeType.STRING },
+      sortKey: { name: 'id', type: dynamodb.AttributeType.STRING },
+      removalPolicy: cdk.RemovalPolicy.RETAIN,
+    });
+
+    return schedulesTable;
+  }
+
+  private createSceneTable(scope: Construct, name: string): dynamodb.Table {
+    // one item per scene, grouped by the home they belong to
+    var scenesTable = new dynamodb.Table(scope, name, {
+      partitionKey: { name: 'homeId', type: dynamodb.AttributeType.STRING },
+      sortKey: { name: 'id', type: dynamodb.AttributeType.STRING },
+      removalPolicy: cdk.RemovalPolicy.RETAIN,
+    });
+
+    return scenesTable;
+  }
+
+  private createDeviceCounterTable(scope: Construct, name: string): dynamodb.Table {
+    // the number of active devices of each home, one item for the total and one per type
+    var deviceCountersTable = new dynamodb.Table(scope, name, {
+      partitionKey: { name: 'homeId', type: dynamodb.AttributeType.STRING },
+      sortKey: { name: 'counter', type: dynamodb.AttributeType.STRING },
+      removalPolicy: cdk.RemovalPolicy.RETAIN,
+    });
+
+    return deviceCountersTable;
+  }
+
+  private addGlobalSecondaryIndex(table: dynamodb.Table, indexName: string, partitionKey: string, sortKey: string, sortKeyType: dynamodb.AttributeType = dynamodb.AttributeType.STRING): void {
     table.addGlobalSecondaryIndex({
-      indexName: macHomeIdIndexName,
+      indexName: indexName,
       partitionKey: { name: partitionKey, type: dynamodb.AttributeType.STRING },
-      sortKey: { name: sortKey, type: dynamodb.AttributeType.STRING },
+      sortKey: { name: sortKey, type: sortKeyType },
       projectionType: dynamodb.ProjectionType.ALL,
     });
   }
 
-  private createHomeDeviceListenerLambda(scope: Construct, homeDevicesQueue: cdk.aws_sqs.Queue, homeDevicesTable: cdk.aws_dynamodb.Table, macHomeIdIndexName: string): lambda.Function {
+  private createHomeDeviceListenerLambda(scope: Construct, homeDevicesQueue: cdk.aws_sqs.Queue, homeDevicesDeadLetterQueue: cdk.aws_sqs.Queue, homeDevicesTable: cdk.aws_dynamodb.Table, deviceHistoryTable: cdk.aws_dynamodb.Table, homesTable: cdk.aws_dynamodb.Table, roomsTable: cdk.aws_dynamodb.Table, macHomeIdIndexName: string, deletedDeviceRetentionDays: string, deviceEventsTopic: sns.Topic): lambda.Function {
     var homeDeviceListenerLambda = LambdaHelper.createLambda(scope, 'HomeDeviceListener', 'bootstrap', 'lambdas/cmd/homeDeviceListener', {
       SQS_QUEUE_URL: homeDevicesQueue.queueUrl,
-      HOME_DEVICE_TABLE_NAME: homeDevicesTable.tableName      
+      DEAD_LETTER_QUEUE_URL: homeDevicesDeadLetterQueue.queueUrl,
+      HOME_DEVICE_TABLE_NAME: homeDevicesTable.tableName,
+      MAC_HOMEID_INDEX_NAME: macHomeIdIndexName,
+      DELETED_DEVICE_RETENTION_DAYS: deletedDeviceRetentionDays,
+      DEVICE_HISTORY_TABLE_NAME: deviceHistoryTable.tableName,
+      HOME_TABLE_NAME: homesTable.tableName,
+      ROOM_TABLE_NAME: roomsTable.tableName,
+      EVENT_TOPIC_ARN: deviceEventsTopic.topicArn
     });
 
     homeDevicesTable.grantReadWriteData(homeDeviceListenerLambda);
+    deviceHistoryTable.grantWriteData(homeDeviceListenerLambda);
+    deviceEventsTopic.grantPublish(homeDeviceListenerLambda);
+    homesTable.grantReadData(homeDeviceListenerLambda);
+    roomsTable.grantReadWriteData(homeDeviceListenerLambda);
+    homeDevicesDeadLetterQueue.grantSendMessages(homeDeviceListenerLambda);
 
     homeDeviceListenerLambda.addEventSource(new eventSources.SqsEventSource(homeDevicesQueue, {
       batchSize: 10,
+      // only the messages returned in batchItemFailures are retried
+      reportBatchItemFailures: true,
     }));
 
     return homeDeviceListenerLambda;
   }
 
-  private createCreateDeviceLambda(homeDevicesTable: cdk.aws_dynamodb.Table, macHomeIdIndexName: string): cdk.aws_lambda.Function {
+  private createCreateDeviceLambda(homeDevicesTable: cdk.aws_dynamodb.Table, deviceHistoryTable: cdk.aws_dynamodb.Table, homesTable: cdk.aws_dynamodb.Table, roomsTable: cdk.aws_dynamodb.Table, macHomeIdIndexName: string, deviceEventsTopic: sns.Topic): cdk.aws_lambda.Function {
     var createDeviceLambda = LambdaHelper.createLambda(this, 'CreateDevice', 'bootstrap', 'lambdas/cmd/createDevice', {
       HOME_DEVICE_TABLE_NAME: homeDevicesTable.tableName,
-      MAC_HOMEID_INDEX_NAME: macHomeIdIndexName
+      MAC_HOMEID_INDEX_NAME: macHomeIdIndexName,
+      DEVICE_HISTORY_TABLE_NAME: deviceHistoryTable.tableName,
+      HOME_TABLE_NAME: homesTable.tableName,
+      ROOM_TABLE_NAME: roomsTable.tableName,
+      EVENT_TOPIC_ARN: deviceEventsTopic.topicArn
     });
 
     createDeviceLambda.addToRolePolicy(new iam.PolicyStatement({
@@ -95,6 +366,10 @@ export class HomeDevicesStack extends cdk.Stack {
     }));
 
     homeDevicesTable.grantWriteData(createDeviceLambda);
+    deviceHistoryTable.grantWriteData(createDeviceLambda);
+    deviceEventsTopic.grantPublish(createDeviceLambda);
+    homesTable.grantReadData(createDeviceLambda);
+    roomsTable.grantReadWriteData(createDeviceLambda);
 
     return createDeviceLambda;
   }
@@ -109,37 +384,577 @@ export class HomeDevicesStack extends cdk.Stack {
     return getDeviceLambda;
   }
 
-  private createUpdateDeviceLambda(homeDevicesTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+  private createUpdateDeviceLambda(homeDevicesTable: cdk.aws_dynamodb.Table, deviceHistoryTable: cdk.aws_dynamodb.Table, homesTable: cdk.aws_dynamodb.Table, roomsTable: cdk.aws_dynamodb.Table, deviceEventsTopic: sns.Topic): cdk.aws_lambda.Function {
     var updateDeviceLambda = LambdaHelper.createLambda(this, 'UpdateDevice', 'bootstrap', 'lambdas/cmd/updateDevice', {
       HOME_DEVICE_TABLE_NAME: homeDevicesTable.tableName,
+      DEVICE_HISTORY_TABLE_NAME: deviceHistoryTable.tableName,
+      HOME_TABLE_NAME: homesTable.tableName,
+      ROOM_TABLE_NAME: roomsTable.tableName,
+      EVENT_TOPIC_ARN: deviceEventsTopic.topicArn
     });
 
-    homeDevicesTable.grantWriteData(updateDeviceLambda);
+    homeDevicesTable.grantReadWriteData(updateDeviceLambda);
+    deviceHistoryTable.grantWriteData(updateDeviceLambda);
+    deviceEventsTopic.grantPublish(updateDeviceLambda);
+    homesTable.grantReadData(updateDeviceLambda);
+    roomsTable.grantReadWriteData(updateDeviceLambda);
 
     return updateDeviceLambda;
   }
 
-  private createDeleteDeviceLambda(homeDevicesTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+  private createDeleteDeviceLambda(homeDevicesTable: cdk.aws_dynamodb.Table, deviceHistoryTable: cdk.aws_dynamodb.Table, roomsTable: cdk.aws_dynamodb.Table, deletedDeviceRetentionDays: string, deviceEventsTopic: sns.Topic): cdk.aws_lambda.Function {
     var deleteDeviceLambda = LambdaHelper.createLambda(this, 'DeleteDevice', 'bootstrap', 'lambdas/cmd/deleteDevice', {
       HOME_DEVICE_TABLE_NAME: homeDevicesTable.tableName,
+      DELETED_DEVICE_RETENTION_DAYS: deletedDeviceRetentionDays,
+      DEVICE_HISTORY_TABLE_NAME: deviceHistoryTable.tableName,
+      ROOM_TABLE_NAME: roomsTable.tableName,
+      EVENT_TOPIC_ARN: deviceEventsTopic.topicArn
     });
 
     homeDevicesTable.grantReadWriteData(deleteDeviceLambda);
+    deviceHistoryTable.grantWriteData(deleteDeviceLambda);
+    deviceEventsTopic.grantPublish(deleteDeviceLambda);
+    roomsTable.grantWriteData(deleteDeviceLambda);
 
     return deleteDeviceLambda;
   }
 
-  private createKinesisLambda(kinesisStream: kinesis.Stream): cdk.aws_lambda.Function {
+  private createRestoreDeviceLambda(homeDevicesTable: cdk.aws_dynamodb.Table, deviceHistoryTable: cdk.aws_dynamodb.Table, homesTable: cdk.aws_dynamodb.Table, roomsTable: cdk.aws_dynamodb.Table, deletedDeviceRetentionDays: string, deviceEventsTopic: sns.Topic): cdk.aws_lambda.Function {
+    var restoreDeviceLambda = LambdaHelper.createLambda(this, 'RestoreDevice', 'bootstrap', 'lambdas/cmd/restoreDevice', {
+      HOME_DEVICE_TABLE_NAME: homeDevicesTable.tableName,
+      DELETED_DEVICE_RETENTION_DAYS: deletedDeviceRetentionDays,
+      DEVICE_HISTORY_TABLE_NAME: deviceHistoryTable.tableName,
+      HOME_TABLE_NAME: homesTable.tableName,
+      ROOM_TABLE_NAME: roomsTable.tableName,
+      EVENT_TOPIC_ARN: deviceEventsTopic.topicArn
+    });
+
+    homeDevicesTable.grantReadWriteData(restoreDeviceLambda);
+    deviceHistoryTable.grantWriteData(restoreDeviceLambda);
+    deviceEventsTopic.grantPublish(restoreDeviceLambda);
+    homesTable.grantReadData(restoreDeviceLambda);
+    roomsTable.grantReadWriteData(restoreDeviceLambda);
+
+    return restoreDeviceLambda;
+  }
+
+  private createListDevicesLambda(homeDevicesTable: cdk.aws_dynamodb.Table, homeIdIndexName: string): cdk.aws_lambda.Function {
+    var listDevicesLambda = LambdaHelper.createLambda(this, 'ListDevices', 'bootstrap', 'lambdas/cmd/listDevices', {
+      HOME_DEVICE_TABLE_NAME: homeDevicesTable.tableName,
+      HOME_ID_INDEX_NAME: homeIdIndexName
+    });
+
+    homeDevicesTable.grantReadData(listDevicesLambda);
+
+    return listDevicesLambda;
+  }
+
+  private createGetDeviceHistoryLambda(deviceHistoryTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var getDeviceHistoryLambda = LambdaHelper.createLambda(this, 'GetDeviceHistory', 'bootstrap', 'lambdas/cmd/getDeviceHistory', {
+      DEVICE_HISTORY_TABLE_NAME: deviceHistoryTable.tableName,
+    });
+
+    deviceHistoryTable.grantReadData(getDeviceHistoryLambda);
+
+    return getDeviceHistoryLambda;
+  }
+
+  private createGetDeviceStateLambda(homeDevicesTable: cdk.aws_dynamodb.Table, deviceShadowTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var getDeviceStateLambda = LambdaHelper.createLambda(this, 'GetDeviceState', 'bootstrap', 'lambdas/cmd/getDeviceState', {
+      HOME_DEVICE_TABLE_NAME: homeDevicesTable.tableName,
+      DEVICE_SHADOW_TABLE_NAME: deviceShadowTable.tableName
+    });
+
+    homeDevicesTable.grantReadData(getDeviceStateLambda);
+    deviceShadowTable.grantReadData(getDeviceStateLambda);
+
+    return getDeviceStateLambda;
+  }
+
+  private createUpdateDeviceStateLambda(homeDevicesTable: cdk.aws_dynamodb.Table, deviceShadowTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var updateDeviceStateLambda = LambdaHelper.createLambda(this, 'UpdateDeviceState', 'bootstrap', 'lambdas/cmd/updateDeviceState', {
+      HOME_DEVICE_TABLE_NAME: homeDevicesTable.tableName,
+      DEVICE_SHADOW_TABLE_NAME: deviceShadowTable.tableName
+    });
+
+    homeDevicesTable.grantReadData(updateDeviceStateLambda);
+    deviceShadowTable.grantReadWriteData(updateDeviceStateLambda);
+
+    return updateDeviceStateLambda;
+  }
+
+  private createSendDeviceCommandLambda(homeDevicesTable: cdk.aws_dynamodb.Table, deviceCommandsTable: cdk.aws_dynamodb.Table, deviceCommandsQueue: cdk.aws_sqs.Queue): cdk.aws_lambda.Function {
+    var sendDeviceCommandLambda = LambdaHelper.createLambda(this, 'SendDeviceCommand', 'bootstrap', 'lambdas/cmd/sendDeviceCommand', {
+      HOME_DEVICE_TABLE_NAME: homeDevicesTable.tableName,
+      DEVICE_COMMAND_TABLE_NAME: deviceCommandsTable.tableName,
+      COMMAND_QUEUE_URL: deviceCommandsQueue.queueUrl
+    });
+
+    homeDevicesTable.grantReadData(sendDeviceCommandLambda);
+    deviceCommandsTable.grantReadWriteData(sendDeviceCommandLambda);
+    deviceCommandsQueue.grantSendMessages(sendDeviceCommandLambda);
+
+    return sendDeviceCommandLambda;
+  }
+
+  private createGetDeviceCommandLambda(deviceCommandsTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var getDeviceCommandLambda = LambdaHelper.createLambda(this, 'GetDeviceCommand', 'bootstrap', 'lambdas/cmd/getDeviceCommand', {
+      DEVICE_COMMAND_TABLE_NAME: deviceCommandsTable.tableName
+    });
+
+    deviceCommandsTable.grantReadData(getDeviceCommandLambda);
+
+    return getDeviceCommandLambda;
+  }
+
+  private createDeviceCommandAckListenerLambda(deviceCommandAcksQueue: cdk.aws_sqs.Queue, deviceCommandAcksDeadLetterQueue: cdk.aws_sqs.Queue, deviceCommandsTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var deviceCommandAckListenerLambda = LambdaHelper.createLambda(this, 'DeviceCommandAckListener', 'bootstrap', 'lambdas/cmd/deviceCommandAckListener', {
+      DEAD_LETTER_QUEUE_URL: deviceCommandAcksDeadLetterQueue.queueUrl,
+      DEVICE_COMMAND_TABLE_NAME: deviceCommandsTable.tableName
+    });
+
+    deviceCommandsTable.grantReadWriteData(deviceCommandAckListenerLambda);
+    deviceCommandAcksDeadLetterQueue.grantSendMessages(deviceCommandAckListenerLambda);
+
+    deviceCommandAckListenerLambda.addEventSource(new eventSources.SqsEventSource(deviceCommandAcksQueue, {
+      batchSize: 10,
+      // only the acks returned in batchItemFailures are retried
+      reportBatchItemFailures: true,
+    }));
+
+    return deviceCommandAckListenerLambda;
+  }
+
+  private createCreateHomeLambda(homesTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var createHomeLambda = LambdaHelper.createLambda(this, 'CreateHome', 'bootstrap', 'lambdas/cmd/createHome', {
+      HOME_TABLE_NAME: homesTable.tableName
+    });
+
+    homesTable.grantWriteData(createHomeLambda);
+
+    return createHomeLambda;
+  }
+
+  private createGetHomeLambda(homesTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var getHomeLambda = LambdaHelper.createLambda(this, 'GetHome', 'bootstrap', 'lambdas/cmd/getHome', {
+      HOME_TABLE_NAME: homesTable.tableName
+    });
+
+    homesTable.grantReadData(getHomeLambda);
+
+    return getHomeLambda;
+  }
+
+  private createUpdateHomeLambda(homesTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var updateHomeLambda = LambdaHelper.createLambda(this, 'UpdateHome', 'bootstrap', 'lambdas/cmd/updateHome', {
+      HOME_TABLE_NAME: homesTable.tableName
+    });
+
+    homesTable.grantReadWriteData(updateHomeLambda);
+
+    return updateHomeLambda;
+  }
+
+  private createDeleteHomeLambda(homesTable: cdk.aws_dynamodb.Table, roomsTable: cdk.aws_dynamodb.Table, rulesTable: cdk.aws_dynamodb.Table, schedulesTable: cdk.aws_dynamodb.Table, scenesTable: cdk.aws_dynamodb.Table, homeDevicesTable: cdk.aws_dynamodb.Table, deviceHistoryTable: cdk.aws_dynamodb.Table, macHomeIdIndexName: string, homeIdIndexName: string, deletedDeviceRetentionDays: string, deviceEventsTopic: sns.Topic): cdk.aws_lambda.Function {
+    // the devices of the home are deleted or reassigned, and its rooms, rules, schedules and scenes deleted, before the home
+    var deleteHomeLambda = LambdaHelper.createLambda(this, 'DeleteHome', 'bootstrap', 'lambdas/cmd/deleteHome', {
+      HOME_TABLE_NAME: homesTable.tableName,
+      ROOM_TABLE_NAME: roomsTable.tableName,
+      RULE_TABLE_NAME: rulesTable.tableName,
+      SCHEDULE_TABLE_NAME: schedulesTable.tableName,
+      SCENE_TABLE_NAME: scenesTable.tableName,
+      HOME_DEVICE_TABLE_NAME: homeDevicesTable.tableName,
+      MAC_HOMEID_INDEX_NAME: macHomeIdIndexName,
+      HOME_ID_INDEX_NAME: homeIdIndexName,
+      DELETED_DEVICE_RETENTION_DAYS: deletedDeviceRetentionDays,
+      DEVICE_HISTORY_TABLE_NAME: deviceHistoryTable.tableName,
+      EVENT_TOPIC_ARN: deviceEventsTopic.topicArn
+    });
+
+    homesTable.grantReadWriteData(deleteHomeLambda);
+    roomsTable.grantReadWriteData(deleteHomeLambda);
+    rulesTable.grantReadWriteData(deleteHomeLambda);
+    schedulesTable.grantReadWriteData(deleteHomeLambda);
+    scenesTable.grantReadWriteData(deleteHomeLambda);
+    homeDevicesTable.grantReadWriteData(deleteHomeLambda);
+    deviceHistoryTable.grantWriteData(deleteHomeLambda);
+    deviceEventsTopic.grantPublish(deleteHomeLambda);
+
+    return deleteHomeLambda;
+  }
+
+  private createCreateRoomLambda(roomsTable: cdk.aws_dynamodb.Table, homesTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var createRoomLambda = LambdaHelper.createLambda(this, 'CreateRoom', 'bootstrap', 'lambdas/cmd/createRoom', {
+      ROOM_TABLE_NAME: roomsTable.tableName,
+      HOME_TABLE_NAME: homesTable.tableName
+    });
+
+    roomsTable.grantWriteData(createRoomLambda);
+    homesTable.grantReadData(createRoomLambda);
+
+    return createRoomLambda;
+  }
+
+  private createListRoomsLambda(roomsTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var listRoomsLambda = LambdaHelper.createLambda(this, 'ListRooms', 'bootstrap', 'lambdas/cmd/listRooms', {
+      ROOM_TABLE_NAME: roomsTable.tableName
+    });
+
+    roomsTable.grantReadData(listRoomsLambda);
+
+    return listRoomsLambda;
+  }
+
+  private createGetRoomLambda(roomsTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var getRoomLambda = LambdaHelper.createLambda(this, 'GetRoom', 'bootstrap', 'lambdas/cmd/getRoom', {
+      ROOM_TABLE_NAME: roomsTable.tableName
+    });
+
+    roomsTable.grantReadData(getRoomLambda);
+
+    return getRoomLambda;
+  }
+
+  private createUpdateRoomLambda(roomsTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var updateRoomLambda = LambdaHelper.createLambda(this, 'UpdateRoom', 'bootstrap', 'lambdas/cmd/updateRoom', {
+      ROOM_TABLE_NAME: roomsTable.tableName
+    });
+
+    roomsTable.grantReadWriteData(updateRoomLambda);
+
+    return updateRoomLambda;
+  }
+
+  private createDeleteRoomLambda(roomsTable: cdk.aws_dynamodb.Table, homeDevicesTable: cdk.aws_dynamodb.Table, homeIdIndexName: string): cdk.aws_lambda.Function {
+    // a room is only deleted when none of the devices of the home is in it
+    var deleteRoomLambda = LambdaHelper.createLambda(this, 'DeleteRoom', 'bootstrap', 'lambdas/cmd/deleteRoom', {
+      ROOM_TABLE_NAME: roomsTable.tableName,
+      HOME_DEVICE_TABLE_NAME: homeDevicesTable.tableName,
+      HOME_ID_INDEX_NAME: homeIdIndexName
+    });
+
+    roomsTable.grantReadWriteData(deleteRoomLambda);
+    homeDevicesTable.grantReadData(deleteRoomLambda);
+
+    return deleteRoomLambda;
+  }
+
+  private createCreateRuleLambda(rulesTable: cdk.aws_dynamodb.Table, homesTable: cdk.aws_dynamodb.Table, homeDevicesTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    // the trigger and the action are checked against the devices of the home
+    var createRuleLambda = LambdaHelper.createLambda(this, 'CreateRule', 'bootstrap', 'lambdas/cmd/createRule', {
+      RULE_TABLE_NAME: rulesTable.tableName,
+      HOME_TABLE_NAME: homesTable.tableName,
+      HOME_DEVICE_TABLE_NAME: homeDevicesTable.tableName
+    });
+
+    rulesTable.grantWriteData(createRuleLambda);
+    homesTable.grantReadData(createRuleLambda);
+    homeDevicesTable.grantReadData(createRuleLambda);
+
+    return createRuleLambda;
+  }
+
+  private createListRulesLambda(rulesTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var listRulesLambda = LambdaHelper.createLambda(this, 'ListRules', 'bootstrap', 'lambdas/cmd/listRules', {
+      RULE_TABLE_NAME: rulesTable.tableName
+    });
+
+    rulesTable.grantReadData(listRulesLambda);
+
+    return listRulesLambda;
+  }
+
+  private createGetRuleLambda(rulesTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var getRuleLambda = LambdaHelper.createLambda(this, 'GetRule', 'bootstrap', 'lambdas/cmd/getRule', {
+      RULE_TABLE_NAME: rulesTable.tableName
+    });
+
+    rulesTable.grantReadData(getRuleLambda);
+
+    return getRuleLambda;
+  }
+
+  private createUpdateRuleLambda(rulesTable: cdk.aws_dynamodb.Table, homesTable: cdk.aws_dynamodb.Table, homeDevicesTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var updateRuleLambda = LambdaHelper.createLambda(this, 'UpdateRule', 'bootstrap', 'lambdas/cmd/updateRule', {
+      RULE_TABLE_NAME: rulesTable.tableName,
+      HOME_TABLE_NAME: homesTable.tableName,
+      HOME_DEVICE_TABLE_NAME: homeDevicesTable.tableName
+    });
+
+    rulesTable.grantReadWriteData(updateRuleLambda);
+    homesTable.grantReadData(updateRuleLambda);
+    homeDevicesTable.grantReadData(updateRuleLambda);
+
+    return updateRuleLambda;
+  }
+
+  private createDeleteRuleLambda(rulesTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var deleteRuleLambda = LambdaHelper.createLambda(this, 'DeleteRule', 'bootstrap', 'lambdas/cmd/deleteRule', {
+      RULE_TABLE_NAME: rulesTable.tableName
+    });
+
+    rulesTable.grantReadWriteData(deleteRuleLambda);
+
+    return deleteRuleLambda;
+  }
+
+  private createListRuleExecutionsLambda(rulesTable: cdk.aws_dynamodb.Table, ruleExecutionsTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var listRuleExecutionsLambda = LambdaHelper.createLambda(this, 'ListRuleExecutions', 'bootstrap', 'lambdas/cmd/listRuleExecutions', {
+      RULE_TABLE_NAME: rulesTable.tableName,
+      RULE_EXECUTION_TABLE_NAME: ruleExecutionsTable.tableName
+    });
+
+    rulesTable.grantReadData(listRuleExecutionsLambda);
+    ruleExecutionsTable.grantReadData(listRuleExecutionsLambda);
+
+    return listRuleExecutionsLambda;
+  }
+
+  private createCreateScheduleLambda(schedulesTable: cdk.aws_dynamodb.Table, homesTable: cdk.aws_dynamodb.Table, roomsTable: cdk.aws_dynamodb.Table, homeDevicesTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    // the schedule takes the timezone of the home, and its target is checked against the devices and rooms of the home
+    var createScheduleLambda = LambdaHelper.createLambda(this, 'CreateSchedule', 'bootstrap', 'lambdas/cmd/createSchedule', {
+      SCHEDULE_TABLE_NAME: schedulesTable.tableName,
+      HOME_TABLE_NAME: homesTable.tableName,
+      ROOM_TABLE_NAME: roomsTable.tableName,
+      HOME_DEVICE_TABLE_NAME: homeDevicesTable.tableName
+    });
+
+    schedulesTable.grantWriteData(createScheduleLambda);
+    homesTable.grantReadData(createScheduleLambda);
+    roomsTable.grantReadData(createScheduleLambda);
+    homeDevicesTable.grantReadData(createScheduleLambda);
+
+    return createScheduleLambda;
+  }
+
+  private createListSchedulesLambda(schedulesTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var listSchedulesLambda = LambdaHelper.createLambda(this, 'ListSchedules', 'bootstrap', 'lambdas/cmd/listSchedules', {
+      SCHEDULE_TABLE_NAME: schedulesTable.tableName
+    });
+
+    schedulesTable.grantReadData(listSchedulesLambda);
+
+    return listSchedulesLambda;
+  }
+
+  private createGetScheduleLambda(schedulesTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var getScheduleLambda = LambdaHelper.createLambda(this, 'GetSchedule', 'bootstrap', 'lambdas/cmd/getSchedule', {
+      SCHEDULE_TABLE_NAME: schedulesTable.tableName
+    });
+
+    schedulesTable.grantReadData(getScheduleLambda);
+
+    return getScheduleLambda;
+  }
+
+  private createUpdateScheduleLambda(schedulesTable: cdk.aws_dynamodb.Table, homesTable: cdk.aws_dynamodb.Table, roomsTable: cdk.aws_dynamodb.Table, homeDevicesTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var updateScheduleLambda = LambdaHelper.createLambda(this, 'UpdateSchedule', 'bootstrap', 'lambdas/cmd/updateSchedule', {
+      SCHEDULE_TABLE_NAME: schedulesTable.tableName,
+      HOME_TABLE_NAME: homesTable.tableName,
+      ROOM_TABLE_NAME: roomsTable.tableName,
+      HOME_DEVICE_TABLE_NAME: homeDevicesTable.tableName
+    });
+
+    schedulesTable.grantReadWriteData(updateScheduleLambda);
+    homesTable.grantReadData(updateScheduleLambda);
+    roomsTable.grantReadData(updateScheduleLambda);
+    homeDevicesTable.grantReadData(updateScheduleLambda);
+
+    return updateScheduleLambda;
+  }
+
+  private createDeleteScheduleLambda(schedulesTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var deleteScheduleLambda = LambdaHelper.createLambda(this, 'DeleteSchedule', 'bootstrap', 'lambdas/cmd/deleteSchedule', {
+      SCHEDULE_TABLE_NAME: schedulesTable.tableName
+    });
+
+    schedulesTable.grantReadWriteData(deleteScheduleLambda);
+
+    return deleteScheduleLambda;
+  }
+
+  private createSchedulerLambda(schedulesTable: cdk.aws_dynamodb.Table, homeDevicesTable: cdk.aws_dynamodb.Table, deviceCommandsTable: cdk.aws_dynamodb.Table, deviceCommandsQueue: cdk.aws_sqs.Queue, nextRunAtIndexName: string, homeIdIndexName: string): cdk.aws_lambda.Function {
+    // the due schedules send their command to the target device, or to the devices of the target type of the home
+    var schedulerLambda = LambdaHelper.createLambda(this, 'Scheduler', 'bootstrap', 'lambdas/cmd/scheduler', {
+      SCHEDULE_TABLE_NAME: schedulesTable.tableName,
+      NEXT_RUN_AT_INDEX_NAME: nextRunAtIndexName,
+      HOME_DEVICE_TABLE_NAME: homeDevicesTable.tableName,
+      HOME_ID_INDEX_NAME: homeIdIndexName,
+      DEVICE_COMMAND_TABLE_NAME: deviceCommandsTable.tableName,
+      COMMAND_QUEUE_URL: deviceCommandsQueue.queueUrl
+    });
+
+    schedulesTable.grantReadWriteData(schedulerLambda);
+    homeDevicesTable.grantReadData(schedulerLambda);
+    deviceCommandsTable.grantReadWriteData(schedulerLambda);
+    deviceCommandsQueue.grantSendMessages(schedulerLambda);
+
+    // the runs are claimed before sending, so a retried invocation of the same minute does not send them twice
+    new events.Rule(this, 'SchedulerRule', {
+      schedule: events.Schedule.rate(cdk.Duration.minutes(1)),
+      targets: [new targets.LambdaFunction(schedulerLambda)],
+    });
+
+    return schedulerLambda;
+  }
+
+  private createPresenceSweeperLambda(homeDevicesTable: cdk.aws_dynamodb.Table, deviceHistoryTable: cdk.aws_dynamodb.Table, statusIndexName: string, deviceEventsTopic: sns.Topic): cdk.aws_lambda.Function {
+    // the online devices not seen for longer than the timeout of their type are marked offline, recorded in their history and published
+    var presenceSweeperLambda = LambdaHelper.createLambda(this, 'PresenceSweeper', 'bootstrap', 'lambdas/cmd/presenceSweeper', {
+      HOME_DEVICE_TABLE_NAME: homeDevicesTable.tableName,
+      STATUS_INDEX_NAME: statusIndexName,
+      DEVICE_HISTORY_TABLE_NAME: deviceHistoryTable.tableName,
+      EVENT_TOPIC_ARN: deviceEventsTopic.topicArn
+    });
+
+    homeDevicesTable.grantReadWriteData(presenceSweeperLambda);
+    deviceHistoryTable.grantWriteData(presenceSweeperLambda);
+    deviceEventsTopic.grantPublish(presenceSweeperLambda);
+
+    new events.Rule(this, 'PresenceSweeperRule', {
+      schedule: events.Schedule.rate(cdk.Duration.minutes(1)),
+      targets: [new targets.LambdaFunction(presenceSweeperLambda)],
+    });
+
+    return presenceSweeperLambda;
+  }
+
+  private createDeviceStreamProcessorLambda(homeDevicesTable: cdk.aws_dynamodb.Table, deviceCountersTable: cdk.aws_dynamodb.Table, deviceStreamDeadLetterQueue: cdk.aws_sqs.Queue): cdk.aws_lambda.Function {
+    // the writes to the devices table, including the ones made outside the lambdas, are logged as an audit trail and counted by home
+    var deviceStreamProcessorLambda = LambdaHelper.createLambda(this, 'DeviceStreamProcessor', 'bootstrap', 'lambdas/cmd/deviceStreamProcessor', {
+      DEVICE_COUNTER_TABLE_NAME: deviceCountersTable.tableName
+    });
+
+    deviceCountersTable.grantReadWriteData(deviceStreamProcessorLambda);
+
+    deviceStreamProcessorLambda.addEventSource(new eventSources.DynamoEventSource(homeDevicesTable, {
+      startingPosition: lambda.StartingPosition.TRIM_HORIZON,
+      batchSize: 100,
+      maxBatchingWindow: cdk.Duration.seconds(5),
+      // the batch is retried from the record returned in batchItemFailures
+      reportBatchItemFailures: true,
+      // a record that keeps failing is sent to the DLQ, so it does not block the shard for longer than a day
+      retryAttempts: 100,
+      maxRecordAge: cdk.Duration.days(1),
+      onFailure: new eventSources.SqsDlq(deviceStreamDeadLetterQueue),
+    }));
+
+    return deviceStreamProcessorLambda;
+  }
+
+  private createListDeviceTypesLambda(): cdk.aws_lambda.Function {
+    // the device types are part of the code, there is no table to read
+    return LambdaHelper.createLambda(this, 'ListDeviceTypes', 'bootstrap', 'lambdas/cmd/listDeviceTypes', {});
+  }
+
+  private createCreateSceneLambda(scenesTable: cdk.aws_dynamodb.Table, homesTable: cdk.aws_dynamodb.Table, homeDevicesTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    // the devices of the scene must be devices of the home, and their states are checked against their types
+    var createSceneLambda = LambdaHelper.createLambda(this, 'CreateScene', 'bootstrap', 'lambdas/cmd/createScene', {
+      SCENE_TABLE_NAME: scenesTable.tableName,
+      HOME_TABLE_NAME: homesTable.tableName,
+      HOME_DEVICE_TABLE_NAME: homeDevicesTable.tableName
+    });
+
+    scenesTable.grantWriteData(createSceneLambda);
+    homesTable.grantReadData(createSceneLambda);
+    homeDevicesTable.grantReadData(createSceneLambda);
+
+    return createSceneLambda;
+  }
+
+  private createListScenesLambda(scenesTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var listScenesLambda = LambdaHelper.createLambda(this, 'ListScenes', 'bootstrap', 'lambdas/cmd/listScenes', {
+      SCENE_TABLE_NAME: scenesTable.tableName
+    });
+
+    scenesTable.grantReadData(listScenesLambda);
+
+    return listScenesLambda;
+  }
+
+  private createGetSceneLambda(scenesTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var getSceneLambda = LambdaHelper.createLambda(this, 'GetScene', 'bootstrap', 'lambdas/cmd/getScene', {
+      SCENE_TABLE_NAME: scenesTable.tableName
+    });
+
+    scenesTable.grantReadData(getSceneLambda);
+
+    return getSceneLambda;
+  }
+
+  private createUpdateSceneLambda(scenesTable: cdk.aws_dynamodb.Table, homesTable: cdk.aws_dynamodb.Table, homeDevicesTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var updateSceneLambda = LambdaHelper.createLambda(this, 'UpdateScene', 'bootstrap', 'lambdas/cmd/updateScene', {
+      SCENE_TABLE_NAME: scenesTable.tableName,
+      HOME_TABLE_NAME: homesTable.tableName,
+      HOME_DEVICE_TABLE_NAME: homeDevicesTable.tableName
+    });
+
+    scenesTable.grantReadWriteData(updateSceneLambda);
+    homesTable.grantReadData(updateSceneLambda);
+    homeDevicesTable.grantReadData(updateSceneLambda);
+
+    return updateSceneLambda;
+  }
+
+  private createDeleteSceneLambda(scenesTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    var deleteSceneLambda = LambdaHelper.createLambda(this, 'DeleteScene', 'bootstrap', 'lambdas/cmd/deleteScene', {
+      SCENE_TABLE_NAME: scenesTable.tableName
+    });
+
+    scenesTable.grantReadWriteData(deleteSceneLambda);
+
+    return deleteSceneLambda;
+  }
+
+  private createActivateSceneLambda(scenesTable: cdk.aws_dynamodb.Table, homeDevicesTable: cdk.aws_dynamodb.Table, deviceShadowTable: cdk.aws_dynamodb.Table): cdk.aws_lambda.Function {
+    // the desired state of each device of the scene is merged into its shadow
+    var activateSceneLambda = LambdaHelper.createLambda(this, 'ActivateScene', 'bootstrap', 'lambdas/cmd/activateScene', {
+      SCENE_TABLE_NAME: scenesTable.tableName,
+      HOME_DEVICE_TABLE_NAME: homeDevicesTable.tableName,
+      DEVICE_SHADOW_TABLE_NAME: deviceShadowTable.tableName
+    });
+
+    scenesTable.grantReadData(activateSceneLambda);
+    homeDevicesTable.grantReadData(activateSceneLambda);
+    deviceShadowTable.grantReadWriteData(activateSceneLambda);
+
+    return activateSceneLambda;
+  }
+
+  private createKinesisLambda(kinesisStream: kinesis.Stream, homeDevicesTable: cdk.aws_dynamodb.Table, deviceHistoryTable: cdk.aws_dynamodb.Table, telemetryTable: cdk.aws_dynamodb.Table, deviceShadowTable: cdk.aws_dynamodb.Table, rulesTable: cdk.aws_dynamodb.Table, ruleExecutionsTable: cdk.aws_dynamodb.Table, deviceCommandsTable: cdk.aws_dynamodb.Table, deviceCommandsQueue: cdk.aws_sqs.Queue, triggerDeviceIdIndexName: string, deviceEventsTopic: sns.Topic): cdk.aws_lambda.Function {
+    // the readings fire the rules of their device, which send their actions as device commands
+    // the readings and the heartbeats mark their device seen, and the ones coming online are recorded in its history and published
     var kinesisListener = LambdaHelper.createLambda(this, 'KinesisListener', 'bootstrap', 'lambdas/cmd/kinesisListener', {
-      // Puedes agregar tus variables de entorno si las necesitas
+      HOME_DEVICE_TABLE_NAME: homeDevicesTable.tableName,
+      DEVICE_HISTORY_TABLE_NAME: deviceHistoryTable.tableName,
+      TELEMETRY_TABLE_NAME: telemetryTable.tableName,
+      DEVICE_SHADOW_TABLE_NAME: deviceShadowTable.tableName,
+      RULE_TABLE_NAME: rulesTable.tableName,
+      TRIGGER_DEVICE_ID_INDEX_NAME: triggerDeviceIdIndexName,
+      RULE_EXECUTION_TABLE_NAME: ruleExecutionsTable.tableName,
+      DEVICE_COMMAND_TABLE_NAME: deviceCommandsTable.tableName,
+      COMMAND_QUEUE_URL: deviceCommandsQueue.queueUrl,
+      EVENT_TOPIC_ARN: deviceEventsTopic.topicArn
     });
 
+    homeDevicesTable.grantReadWriteData(kinesisListener);
+    deviceHistoryTable.grantWriteData(kinesisListener);
+    deviceEventsTopic.grantPublish(kinesisListener);
+    telemetryTable.grantWriteData(kinesisListener);
+    deviceShadowTable.grantReadWriteData(kinesisListener);
+    rulesTable.grantReadWriteData(kinesisListener);
+    ruleExecutionsTable.grantWriteData(kinesisListener);
+    deviceCommandsTable.grantReadWriteData(kinesisListener);
+    deviceCommandsQueue.grantSendMessages(kinesisListener);
+
     // Agregar Kinesis como Event Source y configurar ParallelizationFactor
     kinesisListener.addEventSource(new eventSources.KinesisEventSource(kinesisStream, {
       startingPosition: lambda.StartingPosition.LATEST,
       batchSize: 10,  // Establecer el batch size a 10
       maxBatchingWindow: cdk.Duration.seconds(10),  // Establecer el batch window a 10 segundos
       parallelizationFactor: 3,
+      // the batch is retried from the record returned in batchItemFailures
+      reportBatchItemFailures: true,
     }));
 
     return kinesisListener;
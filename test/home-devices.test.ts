@@ -35,6 +35,395 @@ test('DynamoDB Table Created', () => {
     });
 });
 
+test('Device Status Index Created', () => {
+    const app = new cdk.App();
+    const stack = new HomeDevicesStack(app, 'MyTestStack');
+    const template = Template.fromStack(stack);
+
+    // the presence sweeper finds the online devices not seen for a while
+    template.hasResourceProperties('AWS::DynamoDB::Table', {
+        GlobalSecondaryIndexes: Match.arrayWith([
+            Match.objectLike({
+                IndexName: 'StatusIndex',
+                KeySchema: [
+                    {
+                        AttributeName: 'status',
+                        KeyType: 'HASH',
+                    },
+                    {
+                        AttributeName: 'lastSeenAt',
+                        KeyType: 'RANGE',
+                    },
+                ],
+                Projection: {
+                    ProjectionType: 'ALL',
+                },
+            }),
+        ]),
+    });
+});
+
+test('DynamoDB Table TTL Enabled', () => {
+    const app = new cdk.App();
+    const stack = new HomeDevicesStack(app, 'MyTestStack');
+    const template = Template.fromStack(stack);
+
+    template.hasResourceProperties('AWS::DynamoDB::Table', {
+        TimeToLiveSpecification: {
+            AttributeName: 'expiresAt',
+            Enabled: true
+        }
+    });
+});
+
+test('Device History Table Created', () => {
+    const app = new cdk.App();
+    const stack = new HomeDevicesStack(app, 'MyTestStack');
+    const template = Template.fromStack(stack);
+
+    template.hasResourceProperties('AWS::DynamoDB::Table', {
+        KeySchema: [
+            {
+                AttributeName: 'deviceId',
+                KeyType: 'HASH'
+            },
+            {
+                AttributeName: 'changeId',
+                KeyType: 'RANGE'
+            }
+        ]
+    });
+});
+
+test('Telemetry Table Created', () => {
+    const app = new cdk.App();
+    const stack = new HomeDevicesStack(app, 'MyTestStack');
+    const template = Template.fromStack(stack);
+
+    template.hasResourceProperties('AWS::DynamoDB::Table', {
+        KeySchema: [
+            {
+                AttributeName: 'deviceId',
+                KeyType: 'HASH'
+            },
+            {
+                AttributeName: 'readingId',
+                KeyType: 'RANGE'
+            }
+        ]
+    });
+});
+
+test('Device Shadow Table Created', () => {
+    const app = new cdk.App();
+    const stack = new HomeDevicesStack(app, 'MyTestStack');
+    const template = Template.fromStack(stack);
+
+    template.hasResourceProperties('AWS::DynamoDB::Table', {
+        KeySchema: [
+            {
+                AttributeName: 'deviceId',
+                KeyType: 'HASH'
+            }
+        ]
+    });
+});
+
+test('Homes Table Created', () => {
+    const app = new cdk.App();
+    const stack = new HomeDevicesStack(app, 'MyTestStack');
+    const template = Template.fromStack(stack);
+
+    template.hasResourceProperties('AWS::DynamoDB::Table', {
+        KeySchema: [
+            {
+                AttributeName: 'id',
+                KeyType: 'HASH'
+            }
+        ],
+        GlobalSecondaryIndexes: Match.absent(),
+        TimeToLiveSpecification: Match.absent()
+    });
+});
+
+test('Rooms Table Created', () => {
+    const app = new cdk.App();
+    const stack = new HomeDevicesStack(app, 'MyTestStack');
+    const template = Template.fromStack(stack);
+
+    template.hasResourceProperties('AWS::DynamoDB::Table', {
+        KeySchema: [
+            {
+                AttributeName: 'homeId',
+                KeyType: 'HASH'
+            },
+            {
+                AttributeName: 'id',
+                KeyType: 'RANGE'
+            }
+        ]
+    });
+});
+
+test('Device Commands Table Created', () => {
+    const app = new cdk.App();
+    const stack = new HomeDevicesStack(app, 'MyTestStack');
+    const template = Template.fromStack(stack);
+
+    template.hasResourceProperties('AWS::DynamoDB::Table', {
+        KeySchema: [
+            {
+                AttributeName: 'deviceId',
+                KeyType: 'HASH'
+            },
+            {
+                AttributeName: 'id',
+                KeyType: 'RANGE'
+            }
+        ]
+    });
+});
+
+test('Rules Table Created', () => {
+    const app = new cdk.App();
+    const stack = new HomeDevicesStack(app, 'MyTestStack');
+    const template = Template.fromStack(stack);
+
+    template.hasResourceProperties('AWS::DynamoDB::Table', {
+        KeySchema: [
+            {
+                AttributeName: 'homeId',
+                KeyType: 'HASH'
+            },
+            {
+                AttributeName: 'id',
+                KeyType: 'RANGE'
+            }
+        ],
+        GlobalSecondaryIndexes: Match.arrayWith([
+            Match.objectLike({
+                IndexName: 'TriggerDeviceIdIndex',
+                KeySchema: [
+                    {
+                        AttributeName: 'triggerDeviceId',
+                        KeyType: 'HASH',
+                    },
+                    {
+                        AttributeName: 'id',
+                        KeyType: 'RANGE',
+                    },
+                ],
+                Projection: {
+                    ProjectionType: 'ALL',
+                },
+            }),
+        ]),
+    });
+});
+
+test('Rule Executions Table Created', () => {
+    const app = new cdk.App();
+    const stack = new HomeDevicesStack(app, 'MyTestStack');
+    const template = Template.fromStack(stack);
+
+    template.hasResourceProperties('AWS::DynamoDB::Table', {
+        KeySchema: [
+            {
+                AttributeName: 'ruleId',
+                KeyType: 'HASH'
+            },
+            {
+                AttributeName: 'id',
+                KeyType: 'RANGE'
+            }
+        ]
+    });
+});
+
+test('Schedules Table Created', () => {
+    const app = new cdk.App();
+    const stack = new HomeDevicesStack(app, 'MyTestStack');
+    const template = Template.fromStack(stack);
+
+    template.hasResourceProperties('AWS::DynamoDB::Table', {
+        KeySchema: [
+            {
+                AttributeName: 'homeId',
+                KeyType: 'HASH'
+            },
+            {
+                AttributeName: 'id',
+                KeyType: 'RANGE'
+            }
+        ],
+        GlobalSecondaryIndexes: Match.arrayWith([
+            Match.objectLike({
+                IndexName: 'NextRunAtIndex',
+                KeySchema: [
+                    {
+                        AttributeName: 'runState',
+                        KeyType: 'HASH',
+                    },
+                    {
+                        AttributeName: 'nextRunAt',
+                        KeyType: 'RANGE',
+                    },
+                ],
+                Projection: {
+                    ProjectionType: 'ALL',
+                },
+            }),
+        ]),
+    });
+});
+
+test('Scheduler Runs Every Minute', () => {
+    const app = new cdk.App();
+    const stack = new HomeDevicesStack(app, 'MyTestStack');
+    const template = Template.fromStack(stack);
+
+    template.hasResourceProperties('AWS::Events::Rule', {
+        ScheduleExpression: 'rate(1 minute)',
+        State: 'ENABLED',
+        Targets: Match.arrayWith([
+            Match.objectLike({
+                Arn: Match.objectLike({
+                    "Fn::GetAtt": [
+                        Match.stringLikeRegexp('Scheduler'),
+                        "Arn"
+                    ]
+                }),
+            }),
+        ]),
+    });
+});
+
+test('Presence Sweeper Runs Every Minute', () => {
+    const app = new cdk.App();
+    const stack = new HomeDevicesStack(app, 'MyTestStack');
+    const template = Template.fromStack(stack);
+
+    template.hasResourceProperties('AWS::Events::Rule', {
+        ScheduleExpression: 'rate(1 minute)',
+        State: 'ENABLED',
+        Targets: Match.arrayWith([
+            Match.objectLike({
+                Arn: Match.objectLike({
+                    "Fn::GetAtt": [
+                        Match.stringLikeRegexp('PresenceSweeper'),
+                        "Arn"
+                    ]
+                }),
+            }),
+        ]),
+    });
+});
+
+test('Device Stream Processor Created', () => {
+    const app = new cdk.App();
+    const stack = new HomeDevicesStack(app, 'MyTestStack');
+    const template = Template.fromStack(stack);
+
+    template.hasResourceProperties('AWS::DynamoDB::Table', {
+        KeySchema: Match.arrayWith([{
+            AttributeName: 'id',
+            KeyType: 'HASH'
+        }]),
+        StreamSpecification: {
+            StreamViewType: 'NEW_AND_OLD_IMAGES'
+        }
+    });
+
+    template.hasResourceProperties('AWS::DynamoDB::Table', {
+        KeySchema: [
+            {
+                AttributeName: 'homeId',
+                KeyType: 'HASH'
+            },
+            {
+                AttributeName: 'counter',
+                KeyType: 'RANGE'
+            }
+        ]
+    });
+
+    template.hasResourceProperties('AWS::Lambda::Function', {
+        Handler: 'bootstrap',
+        Runtime: 'provided.al2023',
+        Role: Match.objectLike({
+            "Fn::GetAtt": [
+                Match.stringLikeRegexp('DeviceStreamProcessorServiceRole'),
+                "Arn"
+            ]
+        }),
+        Environment: {
+            Variables: {
+                DEVICE_COUNTER_TABLE_NAME: Match.anyValue(),
+            }
+        }
+    });
+
+    // the stream is retried from the failed record, and the records that keep failing go to the DLQ
+    template.hasResourceProperties('AWS::Lambda::EventSourceMapping', {
+        BatchSize: 100,
+        StartingPosition: 'TRIM_HORIZON',
+        FunctionResponseTypes: ['ReportBatchItemFailures'],
+        MaximumRetryAttempts: 100,
+        MaximumRecordAgeInSeconds: 86400,
+        DestinationConfig: {
+            OnFailure: {
+                Destination: Match.anyValue()
+            }
+        }
+    });
+});
+
+test('Device Command Queues Created', () => {
+    const app = new cdk.App();
+    const stack = new HomeDevicesStack(app, 'MyTestStack');
+    const template = Template.fromStack(stack);
+
+    // the commands are not worth delivering once they expire
+    template.hasResourceProperties('AWS::SQS::Queue', {
+        MessageRetentionPeriod: 3600
+    });
+
+    template.hasResourceProperties('AWS::SQS::Queue', {
+        MessageRetentionPeriod: 86400,
+        RedrivePolicy: {
+            deadLetterTargetArn: Match.anyValue(),
+            maxReceiveCount: 5
+        }
+    });
+});
+
+test('Device Events Topic Created', () => {
+    const app = new cdk.App();
+    const stack = new HomeDevicesStack(app, 'MyTestStack');
+    const template = Template.fromStack(stack);
+
+    template.resourceCountIs('AWS::SNS::Topic', 1);
+
+    // the lambdas that change the devices can publish their events
+    template.hasResourceProperties('AWS::IAM::Policy', {
+        PolicyDocument: {
+            Statement: Match.arrayWith([
+                Match.objectLike({
+                    Action: 'sns:Publish',
+                    Resource: {
+                        Ref: Match.stringLikeRegexp('HomeDeviceEventsTopic'),
+                    },
+                }),
+            ]),
+        },
+        Roles: [
+            {
+                Ref: Match.stringLikeRegexp('UpdateDeviceServiceRole'),
+            },
+        ],
+    });
+});
+
 test('SQS Queue Created', () => {
     const app = new cdk.App();
     const stack = new HomeDevicesStack(app, 'MyTestStack');
@@ -45,6 +434,35 @@ test('SQS Queue Created', () => {
     });
 });
 
+test('SQS Dead Letter Queue Created', () => {
+    const app = new cdk.App();
+    const stack = new HomeDevicesStack(app, 'MyTestStack');
+    const template = Template.fromStack(stack);
+
+    template.hasResourceProperties('AWS::SQS::Queue', {
+        MessageRetentionPeriod: 345600,
+        RedrivePolicy: {
+            deadLetterTargetArn: Match.anyValue(),
+            maxReceiveCount: 5
+        }
+    });
+
+    template.hasResourceProperties('AWS::SQS::Queue', {
+        MessageRetentionPeriod: 1209600 // 14 days in seconds
+    });
+});
+
+test('SQS Event Source Reports Batch Item Failures', () => {
+    const app = new cdk.App();
+    const stack = new HomeDevicesStack(app, 'MyTestStack');
+    const template = Template.fromStack(stack);
+
+    template.hasResourceProperties('AWS::Lambda::EventSourceMapping', {
+        BatchSize: 10,
+        FunctionResponseTypes: ['ReportBatchItemFailures']
+    });
+});
+
 test('Lambda Functions Created', () => {
     const app = new cdk.App();
     const stack = new HomeDevicesStack(app, 'MyTestStack');
@@ -63,7 +481,9 @@ test('Lambda Functions Created', () => {
         Environment: {
             Variables: {
                 HOME_DEVICE_TABLE_NAME: Match.anyValue(),
-                MAC_HOMEID_INDEX_NAME: Match.anyValue()
+                MAC_HOMEID_INDEX_NAME: Match.anyValue(),
+                HOME_TABLE_NAME: Match.anyValue(),
+                EVENT_TOPIC_ARN: Match.anyValue()
             }
         }
     });
@@ -99,6 +519,43 @@ test('Lambda Functions Created', () => {
         Environment: {
             Variables: {
                 HOME_DEVICE_TABLE_NAME: Match.anyValue(),
+                EVENT_TOPIC_ARN: Match.anyValue(),
+            }
+        }
+    });
+
+    // Check restoreDevice Lambda
+    template.hasResourceProperties('AWS::Lambda::Function', {
+        Handler: 'bootstrap',
+        Runtime: 'provided.al2023',
+        Role: Match.objectLike({
+            "Fn::GetAtt": [
+                Match.stringLikeRegexp('RestoreDeviceServiceRole'),
+                "Arn"
+            ]
+        }),
+        Environment: {
+            Variables: {
+                HOME_DEVICE_TABLE_NAME: Match.anyValue(),
+                DELETED_DEVICE_RETENTION_DAYS: '30',
+                EVENT_TOPIC_ARN: Match.anyValue(),
+            }
+        }
+    });
+
+    // Check getDeviceHistory Lambda
+    template.hasResourceProperties('AWS::Lambda::Function', {
+        Handler: 'bootstrap',
+        Runtime: 'provided.al2023',
+        Role: Match.objectLike({
+            "Fn::GetAtt": [
+                Match.stringLikeRegexp('GetDeviceHistoryServiceRole'),
+                "Arn"
+            ]
+        }),
+        Environment: {
+            Variables: {
+                DEVICE_HISTORY_TABLE_NAME: Match.anyValue(),
             }
         }
     });
@@ -116,6 +573,8 @@ test('Lambda Functions Created', () => {
         Environment: {
             Variables: {
                 HOME_DEVICE_TABLE_NAME: Match.anyValue(),
+                HOME_TABLE_NAME: Match.anyValue(),
+                EVENT_TOPIC_ARN: Match.anyValue(),
             }
         }
     });
@@ -134,9 +593,430 @@ test('Lambda Functions Created', () => {
             Variables: {
                 HOME_DEVICE_TABLE_NAME: Match.anyValue(),
                 SQS_QUEUE_URL: Match.anyValue(),
+                DEAD_LETTER_QUEUE_URL: Match.anyValue(),
+                MAC_HOMEID_INDEX_NAME: Match.anyValue(),
+                DELETED_DEVICE_RETENTION_DAYS: Match.anyValue(),
+                DEVICE_HISTORY_TABLE_NAME: Match.anyValue(),
+                HOME_TABLE_NAME: Match.anyValue(),
+                EVENT_TOPIC_ARN: Match.anyValue(),
+            }
+        }
+    });
+
+    // Check kinesisListener Lambda
+    template.hasResourceProperties('AWS::Lambda::Function', {
+        Handler: 'bootstrap',
+        Runtime: 'provided.al2023',
+        Role: Match.objectLike({
+            "Fn::GetAtt": [
+                Match.stringLikeRegexp('KinesisListener'),
+                "Arn"
+            ]
+        }),
+        Environment: {
+            Variables: {
+                HOME_DEVICE_TABLE_NAME: Match.anyValue(),
+                DEVICE_HISTORY_TABLE_NAME: Match.anyValue(),
+                TELEMETRY_TABLE_NAME: Match.anyValue(),
+                DEVICE_SHADOW_TABLE_NAME: Match.anyValue(),
+                RULE_TABLE_NAME: Match.anyValue(),
+                TRIGGER_DEVICE_ID_INDEX_NAME: 'TriggerDeviceIdIndex',
+                RULE_EXECUTION_TABLE_NAME: Match.anyValue(),
+                DEVICE_COMMAND_TABLE_NAME: Match.anyValue(),
+                COMMAND_QUEUE_URL: Match.anyValue(),
+                EVENT_TOPIC_ARN: Match.anyValue(),
+            }
+        }
+    });
+
+    // Check getDeviceState Lambda
+    template.hasResourceProperties('AWS::Lambda::Function', {
+        Handler: 'bootstrap',
+        Runtime: 'provided.al2023',
+        Role: Match.objectLike({
+            "Fn::GetAtt": [
+                Match.stringLikeRegexp('GetDeviceStateServiceRole'),
+                "Arn"
+            ]
+        }),
+        Environment: {
+            Variables: {
+                HOME_DEVICE_TABLE_NAME: Match.anyValue(),
+                DEVICE_SHADOW_TABLE_NAME: Match.anyValue(),
+            }
+        }
+    });
+
+    // Check updateDeviceState Lambda
+    template.hasResourceProperties('AWS::Lambda::Function', {
+        Handler: 'bootstrap',
+        Runtime: 'provided.al2023',
+        Role: Match.objectLike({
+            "Fn::GetAtt": [
+                Match.stringLikeRegexp('UpdateDeviceStateServiceRole'),
+                "Arn"
+            ]
+        }),
+        Environment: {
+            Variables: {
+                HOME_DEVICE_TABLE_NAME: Match.anyValue(),
+                DEVICE_SHADOW_TABLE_NAME: Match.anyValue(),
+            }
+        }
+    });
+
+    // Check createHome, getHome and updateHome Lambdas
+    ['CreateHomeServiceRole', 'GetHomeServiceRole', 'UpdateHomeServiceRole'].forEach(role => {
+        template.hasResourceProperties('AWS::Lambda::Function', {
+            Handler: 'bootstrap',
+            Runtime: 'provided.al2023',
+            Role: Match.objectLike({
+                "Fn::GetAtt": [
+                    Match.stringLikeRegexp(role),
+                    "Arn"
+                ]
+            }),
+            Environment: {
+                Variables: {
+                    HOME_TABLE_NAME: Match.anyValue(),
+                }
+            }
+        });
+    });
+
+    // Check deleteHome Lambda
+    template.hasResourceProperties('AWS::Lambda::Function', {
+        Handler: 'bootstrap',
+        Runtime: 'provided.al2023',
+        Role: Match.objectLike({
+            "Fn::GetAtt": [
+                Match.stringLikeRegexp('DeleteHomeServiceRole'),
+                "Arn"
+            ]
+        }),
+        Environment: {
+            Variables: {
+                HOME_TABLE_NAME: Match.anyValue(),
+                RULE_TABLE_NAME: Match.anyValue(),
+                SCHEDULE_TABLE_NAME: Match.anyValue(),
+                SCENE_TABLE_NAME: Match.anyValue(),
+                HOME_DEVICE_TABLE_NAME: Match.anyValue(),
+                HOME_ID_INDEX_NAME: Match.anyValue(),
+                DEVICE_HISTORY_TABLE_NAME: Match.anyValue(),
+                DELETED_DEVICE_RETENTION_DAYS: '30',
+                EVENT_TOPIC_ARN: Match.anyValue(),
+            }
+        }
+    });
+
+    // Check createRoom, listRooms, getRoom and updateRoom Lambdas
+    ['CreateRoomServiceRole', 'ListRoomsServiceRole', 'GetRoomServiceRole', 'UpdateRoomServiceRole'].forEach(role => {
+        template.hasResourceProperties('AWS::Lambda::Function', {
+            Handler: 'bootstrap',
+            Runtime: 'provided.al2023',
+            Role: Match.objectLike({
+                "Fn::GetAtt": [
+                    Match.stringLikeRegexp(role),
+                    "Arn"
+                ]
+            }),
+            Environment: {
+                Variables: {
+                    ROOM_TABLE_NAME: Match.anyValue(),
+                }
+            }
+        });
+    });
+
+    // Check deleteRoom Lambda
+    template.hasResourceProperties('AWS::Lambda::Function', {
+        Handler: 'bootstrap',
+        Runtime: 'provided.al2023',
+        Role: Match.objectLike({
+            "Fn::GetAtt": [
+                Match.stringLikeRegexp('DeleteRoomServiceRole'),
+                "Arn"
+            ]
+        }),
+        Environment: {
+            Variables: {
+                ROOM_TABLE_NAME: Match.anyValue(),
+                HOME_DEVICE_TABLE_NAME: Match.anyValue(),
+                HOME_ID_INDEX_NAME: Match.anyValue(),
+            }
+        }
+    });
+
+    // Check listDeviceTypes Lambda
+    template.hasResourceProperties('AWS::Lambda::Function', {
+        Handler: 'bootstrap',
+        Runtime: 'provided.al2023',
+        Role: Match.objectLike({
+            "Fn::GetAtt": [
+                Match.stringLikeRegexp('ListDeviceTypesServiceRole'),
+                "Arn"
+            ]
+        }),
+    });
+
+    // Check sendDeviceCommand Lambda
+    template.hasResourceProperties('AWS::Lambda::Function', {
+        Handler: 'bootstrap',
+        Runtime: 'provided.al2023',
+        Role: Match.objectLike({
+            "Fn::GetAtt": [
+                Match.stringLikeRegexp('SendDeviceCommandServiceRole'),
+                "Arn"
+            ]
+        }),
+        Environment: {
+            Variables: {
+                HOME_DEVICE_TABLE_NAME: Match.anyValue(),
+                DEVICE_COMMAND_TABLE_NAME: Match.anyValue(),
+                COMMAND_QUEUE_URL: Match.anyValue(),
+            }
+        }
+    });
+
+    // Check getDeviceCommand Lambda
+    template.hasResourceProperties('AWS::Lambda::Function', {
+        Handler: 'bootstrap',
+        Runtime: 'provided.al2023',
+        Role: Match.objectLike({
+            "Fn::GetAtt": [
+                Match.stringLikeRegexp('GetDeviceCommandServiceRole'),
+                "Arn"
+            ]
+        }),
+        Environment: {
+            Variables: {
+                DEVICE_COMMAND_TABLE_NAME: Match.anyValue(),
+            }
+        }
+    });
+
+    // Check deviceCommandAckListener Lambda
+    template.hasResourceProperties('AWS::Lambda::Function', {
+        Handler: 'bootstrap',
+        Runtime: 'provided.al2023',
+        Role: Match.objectLike({
+            "Fn::GetAtt": [
+                Match.stringLikeRegexp('DeviceCommandAckListenerServiceRole'),
+                "Arn"
+            ]
+        }),
+        Environment: {
+            Variables: {
+                DEAD_LETTER_QUEUE_URL: Match.anyValue(),
+                DEVICE_COMMAND_TABLE_NAME: Match.anyValue(),
+            }
+        }
+    });
+
+    // Check createRule and updateRule Lambdas
+    ['CreateRuleServiceRole', 'UpdateRuleServiceRole'].forEach(role => {
+        template.hasResourceProperties('AWS::Lambda::Function', {
+            Handler: 'bootstrap',
+            Runtime: 'provided.al2023',
+            Role: Match.objectLike({
+                "Fn::GetAtt": [
+                    Match.stringLikeRegexp(role),
+                    "Arn"
+                ]
+            }),
+            Environment: {
+                Variables: {
+                    RULE_TABLE_NAME: Match.anyValue(),
+                    HOME_TABLE_NAME: Match.anyValue(),
+                    HOME_DEVICE_TABLE_NAME: Match.anyValue(),
+                }
+            }
+        });
+    });
+
+    // Check listRules, getRule and deleteRule Lambdas
+    ['ListRulesServiceRole', 'GetRuleServiceRole', 'DeleteRuleServiceRole'].forEach(role => {
+        template.hasResourceProperties('AWS::Lambda::Function', {
+            Handler: 'bootstrap',
+            Runtime: 'provided.al2023',
+            Role: Match.objectLike({
+                "Fn::GetAtt": [
+                    Match.stringLikeRegexp(role),
+                    "Arn"
+                ]
+            }),
+            Environment: {
+                Variables: {
+                    RULE_TABLE_NAME: Match.anyValue(),
+                }
+            }
+        });
+    });
+
+    // Check listRuleExecutions Lambda
+    template.hasResourceProperties('AWS::Lambda::Function', {
+        Handler: 'bootstrap',
+        Runtime: 'provided.al2023',
+        Role: Match.objectLike({
+            "Fn::GetAtt": [
+                Match.stringLikeRegexp('ListRuleExecutionsServiceRole'),
+                "Arn"
+            ]
+        }),
+        Environment: {
+            Variables: {
+                RULE_TABLE_NAME: Match.anyValue(),
+                RULE_EXECUTION_TABLE_NAME: Match.anyValue(),
+            }
+        }
+    });
+
+    // Check createSchedule and updateSchedule Lambdas
+    ['CreateScheduleServiceRole', 'UpdateScheduleServiceRole'].forEach(role => {
+        template.hasResourceProperties('AWS::Lambda::Function', {
+            Handler: 'bootstrap',
+            Runtime: 'provided.al2023',
+            Role: Match.objectLike({
+                "Fn::GetAtt": [
+                    Match.stringLikeRegexp(role),
+                    "Arn"
+                ]
+            }),
+            Environment: {
+                Variables: {
+                    SCHEDULE_TABLE_NAME: Match.anyValue(),
+                    HOME_TABLE_NAME: Match.anyValue(),
+                    ROOM_TABLE_NAME: Match.anyValue(),
+                    HOME_DEVICE_TABLE_NAME: Match.anyValue(),
+                }
+            }
+        });
+    });
+
+    // Check listSchedules, getSchedule and deleteSchedule Lambdas
+    ['ListSchedulesServiceRole', 'GetScheduleServiceRole', 'DeleteScheduleServiceRole'].forEach(role => {
+        template.hasResourceProperties('AWS::Lambda::Function', {
+            Handler: 'bootstrap',
+            Runtime: 'provided.al2023',
+            Role: Match.objectLike({
+                "Fn::GetAtt": [
+                    Match.stringLikeRegexp(role),
+                    "Arn"
+                ]
+            }),
+            Environment: {
+                Variables: {
+                    SCHEDULE_TABLE_NAME: Match.anyValue(),
+                }
+            }
+        });
+    });
+
+    // Check scheduler Lambda
+    template.hasResourceProperties('AWS::Lambda::Function', {
+        Handler: 'bootstrap',
+        Runtime: 'provided.al2023',
+        Role: Match.objectLike({
+            "Fn::GetAtt": [
+                Match.stringLikeRegexp('SchedulerServiceRole'),
+                "Arn"
+            ]
+        }),
+        Environment: {
+            Variables: {
+                SCHEDULE_TABLE_NAME: Match.anyValue(),
+                NEXT_RUN_AT_INDEX_NAME: 'NextRunAtIndex',
+                HOME_DEVICE_TABLE_NAME: Match.anyValue(),
+                HOME_ID_INDEX_NAME: Match.anyValue(),
+                DEVICE_COMMAND_TABLE_NAME: Match.anyValue(),
+                COMMAND_QUEUE_URL: Match.anyValue(),
             }
         }
     });
+
+    // Check presenceSweeper Lambda
+    template.hasResourceProperties('AWS::Lambda::Function', {
+        Handler: 'bootstrap',
+        Runtime: 'provided.al2023',
+        Role: Match.objectLike({
+            "Fn::GetAtt": [
+                Match.stringLikeRegexp('PresenceSweeperServiceRole'),
+                "Arn"
+            ]
+        }),
+        Environment: {
+            Variables: {
+                HOME_DEVICE_TABLE_NAME: Match.anyValue(),
+                STATUS_INDEX_NAME: 'StatusIndex',
+                DEVICE_HISTORY_TABLE_NAME: Match.anyValue(),
+                EVENT_TOPIC_ARN: Match.anyValue(),
+            }
+        }
+    });
+
+    // Check createScene and updateScene Lambdas
+    ['CreateSceneServiceRole', 'UpdateSceneServiceRole'].forEach(role => {
+        template.hasResourceProperties('AWS::Lambda::Function', {
+            Handler: 'bootstrap',
+            Runtime: 'provided.al2023',
+            Role: Match.objectLike({
+                "Fn::GetAtt": [
+                    Match.stringLikeRegexp(role),
+                    "Arn"
+                ]
+            }),
+            Environment: {
+                Variables: {
+                    SCENE_TABLE_NAME: Match.anyValue(),
+                    HOME_TABLE_NAME: Match.anyValue(),
+                    HOME_DEVICE_TABLE_NAME: Match.anyValue(),
+                }
+            }
+        });
+    });
+
+    // Check listScenes, getScene and deleteScene Lambdas
+    ['ListScenesServiceRole', 'GetSceneServiceRole', 'DeleteSceneServiceRole'].forEach(role => {
+        template.hasResourceProperties('AWS::Lambda::Function', {
+            Handler: 'bootstrap',
+            Runtime: 'provided.al2023',
+            Role: Match.objectLike({
+                "Fn::GetAtt": [
+                    Match.stringLikeRegexp(role),
+                    "Arn"
+                ]
+            }),
+            Environment: {
+                Variables: {
+                    SCENE_TABLE_NAME: Match.anyValue(),
+                }
+            }
+        });
+    });
+
+    // Check activateScene Lambda
+    template.hasResourceProperties('AWS::Lambda::Function', {
+        Handler: 'bootstrap',
+        Runtime: 'provided.al2023',
+        Role: Match.objectLike({
+            "Fn::GetAtt": [
+                Match.stringLikeRegexp('ActivateSceneServiceRole'),
+                "Arn"
+            ]
+        }),
+        Environment: {
+            Variables: {
+                SCENE_TABLE_NAME: Match.anyValue(),
+                HOME_DEVICE_TABLE_NAME: Match.anyValue(),
+                DEVICE_SHADOW_TABLE_NAME: Match.anyValue(),
+            }
+        }
+    });
+
+    template.hasResourceProperties('AWS::Lambda::EventSourceMapping', {
+        FunctionResponseTypes: ['ReportBatchItemFailures'],
+        StartingPosition: 'LATEST',
+    });
 });
 
 
@@ -188,4 +1068,56 @@ test('API Gateway Methods Created', () => {
         Type: 'AWS_PROXY',
       },
     });
+  
+    // Verificar que el método PATCH para el recurso 'v1/device/{id}/state' esté creado
+    template.hasResourceProperties('AWS::ApiGateway::Method', {
+      HttpMethod: 'PATCH',
+      ResourceId: Match.anyValue(),
+      RestApiId: Match.anyValue(),
+      Integration: {
+        IntegrationHttpMethod: 'POST',
+        Type: 'AWS_PROXY',
+      },
+    });
+
+    // the device types are discovered at 'v1/device-types'
+    template.hasResourceProperties('AWS::ApiGateway::Resource', {
+      PathPart: 'device-types',
+    });
+
+    // the commands are sent at 'v1/device/{id}/commands' and read at 'v1/device/{id}/commands/{cmdId}'
+    template.hasResourceProperties('AWS::ApiGateway::Resource', {
+      PathPart: 'commands',
+    });
+
+    template.hasResourceProperties('AWS::ApiGateway::Resource', {
+      PathPart: '{cmdId}',
+    });
+
+    // the rules are managed at 'v1/home/{homeId}/rules' and their log read at 'v1/home/{homeId}/rules/{ruleId}/executions'
+    template.hasResourceProperties('AWS::ApiGateway::Resource', {
+      PathPart: 'rules',
+    });
+
+    template.hasResourceProperties('AWS::ApiGateway::Resource', {
+      PathPart: 'executions',
+    });
+
+    // the schedules are managed at 'v1/home/{homeId}/schedules'
+    template.hasResourceProperties('AWS::ApiGateway::Resource', {
+      PathPart: 'schedules',
+    });
+
+    template.hasResourceProperties('AWS::ApiGateway::Resource', {
+      PathPart: '{scheduleId}',
+    });
+
+    // the scenes are managed at 'v1/home/{homeId}/scenes' and activated at 'v1/home/{homeId}/scenes/{sceneId}/activate'
+    template.hasResourceProperties('AWS::ApiGateway::Resource', {
+      PathPart: 'scenes',
+    });
+
+    template.hasResourceProperties('AWS::ApiGateway::Resource', {
+      PathPart: 'activate',
+    });
   });
\ No newline at end of file